@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"fmt"
+
 	"github.com/spf13/cobra"
 
 	annotation "github.com/xiusin/go-annotation/pkg"
@@ -10,7 +12,17 @@ import (
 var annotationsCmd = &cobra.Command{
 	Use:   "src",
 	Short: "注解路由生成",
-	Run: func(cmd *cobra.Command, args []string) {
+	// 生成失败时只输出错误, 不打印用法
+	SilenceUsage: true,
+	Long:         "根据控制器的 Rest 注解生成各包 routes.gen.go、src/router/routes.gen.go 与菜单权限种子 resources/menu.gen.json(不纳入版本库), 路由重复时以非零状态退出",
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		// 注解处理器以 panic 报告路由冲突等错误
+		defer func() {
+			if e := recover(); e != nil {
+				err = fmt.Errorf("生成注解路由失败: %v", e)
+			}
+		}()
 		annotation.Process()
+		return nil
 	},
 }
@@ -3,13 +3,14 @@ package crud
 import (
 	"bytes"
 	"encoding/json"
-	"errors"
 	"fmt"
+	"go/format"
+	"io/fs"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strconv"
 	"strings"
+	"text/template"
 
 	"github.com/xiusin/pine"
 	"github.com/xiusin/pinecms/cmd/util"
@@ -17,9 +18,8 @@ import (
 	. "github.com/xiusin/pinecms/src/config"
 	"xorm.io/xorm/schemas"
 
-	"github.com/xwb1989/sqlparser"
-
 	"github.com/alecthomas/chroma/quick"
+	"github.com/pmezard/go-difflib/difflib"
 	"github.com/spf13/cobra"
 )
 
@@ -58,6 +58,11 @@ var Cmd = &cobra.Command{
 状态 -  仅解析名称, 组件根据类型或字段后缀推导
 状态:-1=禁用,0=待审核,1=正常 - 解析名称并设置下拉
 状态:-1=禁用,0=待审核,1=正常:el-checkbox
+
+模板: 在 --tpl 目录(默认 resources/crud)放置同名 .tpl 文件覆盖内置模板, 分隔符为 [[ ]]
+前端: --target 默认为 cool, 其他目标读取模板目录下同名子目录内的 .tpl 文件
+关联: 外键引用其他表时渲染为下拉选择, 被其他表引用时列表与详情加载子表数据
+预览: --info 打印生成内容, --dry-run 打印与已有文件的差异
 `,
 	Run: func(cmd *cobra.Command, args []string) {
 		InitDB()
@@ -68,7 +73,10 @@ var Cmd = &cobra.Command{
 		table, _ := cmd.Flags().GetString("table")
 		force, _ := cmd.Flags().GetBool("force") // 强制创建
 		onlyInfo, _ := cmd.Flags().GetBool("info")
-		frontendPath, _ := cmd.Flags().GetString("path")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		frontendPath, _ := cmd.Flags().GetString("fepath")
+		tpl, _ := cmd.Flags().GetString("tpl")
+		target, _ := cmd.Flags().GetString("target")
 		if len(table) == 0 {
 			_ = cmd.Help()
 			return
@@ -89,34 +97,41 @@ var Cmd = &cobra.Command{
 			pine.Logger().Error(fmt.Sprintf("无法获取数据表[%s]元信息", getTableName(table)))
 			return
 		}
+		table = strings.TrimPrefix(table, DB().Db.DbPrefix)
 		for _, v := range tableMata.Columns() {
 			cols[v.Name] = v
 		}
-		controllerName, controllerPath := getControllerName(table)
-		tablePath := tableDir + table + goExt
-		if !force { // 非强制创建则检测文件是否存在
-			for _, s := range []string{frontendPath + "/" + feModuleDir + table, controllerPath, tablePath} {
-				if _, err := os.Stat(s); !os.IsNotExist(err) {
-					pine.Logger().Info("已有存在: " + s)
-					return
+		files, err := planFiles(tableMata, metas, table, frontendPath, tpl, target)
+		if err != nil {
+			pine.Logger().Error(err.Error())
+			return
+		}
+		switch {
+		case onlyInfo:
+			for _, f := range files {
+				pine.Logger().Info("生成文件： " + f.Path)
+				_ = quick.Highlight(os.Stdout, f.Content+"\n", f.Lang, "terminal256", theme)
+			}
+		case dryRun:
+			for _, f := range files {
+				printDiff(f)
+			}
+		default:
+			if !force { // 非强制创建则检测文件是否存在
+				for _, f := range files {
+					if _, err := os.Stat(f.Path); err == nil && f.Path != routerFile {
+						pine.Logger().Info("已有存在: " + f.Path)
+						return
+					}
 				}
 			}
+			for _, f := range files {
+				_ = os.MkdirAll(filepath.Dir(f.Path), os.ModePerm)
+				helper.PanicErr(os.WriteFile(f.Path, []byte(f.Content), 0o644))
+				pine.Logger().Info("创建文件： " + f.Path)
+			}
+			pine.Logger().Info("创建模块文件成功, 已注册路由信息至: " + routerFile)
 		}
-
-		helper.PanicErr(genTableFileAndFrontendFile(onlyInfo, table, tableDir+table+goExt, frontendPath))
-		helper.PanicErr(genControllerFile(onlyInfo, controllerName, table, controllerPath))
-
-		byts, err := os.ReadFile(routerFile)
-		helper.PanicErr(err)
-		controllerNamespace := `"github.com/xiusin/pinecms/src/application/controllers/backend"`
-		pineNamespace := `"github.com/xiusin/pine"`
-		holder := "// holder"
-		if !bytes.Contains(byts, []byte(controllerNamespace)) {
-			byts = bytes.Replace(byts, []byte(pineNamespace), []byte(pineNamespace+"\r\n\t"+controllerNamespace), 1)
-		}
-		byts = bytes.Replace(byts, []byte(holder), []byte(holder+"\r\n\t"+`backendRouter.Handle(new(backend.`+controllerName+`), "/`+util.SnakeString(table)+`")`), 1)
-		os.WriteFile(routerFile, byts, os.ModePerm)
-		pine.Logger().Info("创建模块文件成功, 已注册路由信息至: " + routerFile)
 	},
 }
 
@@ -124,7 +139,10 @@ func init() {
 	Cmd.Flags().String("table", "", "数据库表名")
 	Cmd.Flags().Bool("force", false, "是否强制覆盖（可能导致已有代码丢失）")
 	Cmd.Flags().Bool("info", false, "是否只打印生成文件以及操作步骤")
+	Cmd.Flags().Bool("dry-run", false, "只打印与已有文件的差异, 不写入")
 	Cmd.Flags().String("fepath", "admin", "前端开发根目录")
+	Cmd.Flags().String("tpl", tplDir, "自定义模板目录, 同名文件覆盖内置模板")
+	Cmd.Flags().String("target", defaultTarget, "前端目标, 其他目标读取模板目录下的同名子目录")
 }
 
 func getControllerName(tableName string) (controller string, filename string) {
@@ -141,144 +159,167 @@ func getTableName(table string) string {
 	return prefix + table
 }
 
-func genControllerFile(print bool, controllerName, tableName, controllerPath string) error {
-	var err error
-	content := strings.ReplaceAll(
-		strings.ReplaceAll(
-			strings.ReplaceAll(controllerTpl, "[ctrl]", controllerName),
-			"[table]",
-			util.SnakeString(tableName),
-		),
-		"[searchFieldDsl]",
-		searchFieldDsl,
-	)
-	if !print {
-		err = os.WriteFile(controllerPath, []byte(content), os.ModePerm)
-	}
-	if err == nil {
-		pine.Logger().Info("创建文件： " + controllerPath)
+// genFile 待生成的文件
+type genFile struct {
+	Path    string
+	Lang    string // 高亮语言
+	Content string
+}
+
+// crudData 模板数据
+type crudData struct {
+	*tableResult
+	Table      string // 表名, 不含前缀
+	Struct     string
+	Controller string
+	PKColumn   string
+	BelongsTo  []Relation
+	HasMany    []Relation
+}
+
+// planFiles 渲染所有待生成的文件, 包括路由注册
+func planFiles(meta *schemas.Table, metas []*schemas.Table, table, frontendPath, tpl, target string) ([]genFile, error) {
+	data := &crudData{Table: util.SnakeString(table), Struct: util.CamelString(table)}
+	controllerName, controllerPath := getControllerName(table)
+	data.Controller = controllerName
+	if len(meta.PrimaryKeys) > 0 {
+		data.PKColumn = meta.PrimaryKeys[0]
 	}
-	if print {
-		_ = quick.Highlight(os.Stdout, content, "go", "terminal256", theme)
+	data.BelongsTo, data.HasMany = relations(meta, metas, util.CamelString(data.PKColumn))
+	if len(data.PKColumn) == 0 {
+		data.HasMany = nil // 无主键无法关联子表
 	}
-	return err
-}
 
-func genTableFileAndFrontendFile(print bool, tableName, tablePath, frontendPath string) error {
-	realTableName := DB().Db.DbPrefix + strings.ToLower(tableName)
-	res, err := Orm().QueryString(`SHOW CREATE TABLE ` + realTableName)
+	sqlTbl := sqlTable(meta)
+	result, err := sqlTbl.toXorm(data.BelongsTo)
 	if err != nil {
-		return err
+		return nil, err
 	}
-
-	createSQL := res[0]["Create Table"]
-
-	// 替换字段
-	reg := regexp.MustCompile(`"(.+?)"\s`)
-
-	createSQL = reg.ReplaceAllStringFunc(createSQL, func(s string) string {
-		s = strings.Trim(s, `" `)
-		return "`" + s + "` "
-	})
-	stmt, err := sqlparser.Parse(createSQL)
-	var tableStruct string
-	helper.PanicErr(err)
-	switch stmt := stmt.(type) {
-	case *sqlparser.DDL:
-		if stmt.TableSpec == nil {
-			pine.Logger().Error("Can't get table spec")
-			break
+	data.tableResult = result
+	if len(data.HasMany) > 0 {
+		var columns []map[string]any
+		_ = json.Unmarshal([]byte(result.TableDSL), &columns)
+		for _, rel := range data.HasMany {
+			columns = append(columns, map[string]any{"prop": rel.JSON, "label": rel.RefTable})
 		}
-		var table SQLTable
+		result.TableDSL = dsl(columns)
+	}
 
-		var uniqueKeys []string
-		var primaryKey string
-		for _, ind := range stmt.TableSpec.Indexes {
-			switch ind.Info.Type {
-			case "primary key":
-				primaryKey = ind.Columns[0].Column.String()
-			case "unique key":
-				uniqueKeys = append(uniqueKeys, ind.Columns[0].Column.String())
-			default:
-				pine.Logger().Warn("未知类型 ", ind.Info.Type)
+	type planItem struct{ path, tpl, lang string }
+	plan := []planItem{
+		{controllerPath, "controller.go.tpl", "go"},
+		{tableDir + table + goExt, "table.go.tpl", "go"},
+		{tableDir + table + "_test" + goExt, "table_test.go.tpl", "go"},
+	}
+	moduleBaseDir := filepath.Join(frontendPath, feModuleDir+table)
+	if target == defaultTarget {
+		plan = append(plan,
+			planItem{filepath.Join(moduleBaseDir, "service", "index.ts"), "service.ts.tpl", "typescript"},
+			planItem{filepath.Join(moduleBaseDir, "service", "router.ts"), "router.ts.tpl", "typescript"},
+			planItem{filepath.Join(moduleBaseDir, "views", table+".vue"), "index.vue.tpl", "vue"},
+			planItem{filepath.Join(moduleBaseDir, "index.ts"), "service_index.ts.tpl", "typescript"},
+		)
+	} else {
+		// 目标目录下的模板按相对路径生成, 文件名中的 __table__ 替换为表名
+		root := filepath.Join(tpl, target)
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || !strings.HasSuffix(path, ".tpl") {
+				return err
 			}
+			rel, _ := filepath.Rel(tpl, path)
+			name := strings.ReplaceAll(strings.TrimSuffix(path[len(root)+1:], ".tpl"), "__table__", table)
+			plan = append(plan, planItem{filepath.Join(moduleBaseDir, name), rel, strings.TrimPrefix(filepath.Ext(name), ".")})
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("读取前端目标%s模板失败: %w", target, err)
 		}
-
-		table.Name = stmt.NewName.Name.String()
-		for _, col := range stmt.TableSpec.Columns {
-			var scol SQLColumn
-
-			scol.Name = col.Name.String()
-			scol.Type = col.Type.Type
-			scol.EnumValues = col.Type.EnumValues
-			if col.Type.Length != nil {
-				scol.Length = string(col.Type.Length.Val)
-			}
-			scol.AutoIncrement = bool(col.Type.Autoincrement)
-			scol.NotNull = bool(col.Type.NotNull)
-			if col.Type.Default != nil {
-				scol.Default = string(col.Type.Default.Val)
-			}
-			scol.IsPrimaryKey = col.Name.String() == primaryKey
-			for _, k := range uniqueKeys {
-				if scol.Name == k {
-					scol.IsUnique = true
-					break
-				}
+	}
+	var files []genFile
+	for _, item := range plan {
+		content, err := render(tpl, item.tpl, data)
+		if err != nil {
+			return nil, err
+		}
+		if item.lang == "go" {
+			if formatted, err := format.Source([]byte(content)); err == nil {
+				content = string(formatted)
 			}
-
-			table.Cols = append(table.Cols, scol)
 		}
-
-		tableStruct = table.toXorm(print, tableName, frontendPath)
+		files = append(files, genFile{Path: item.path, Lang: item.lang, Content: content})
 	}
 
-	if len(tableStruct) == 0 {
-		return errors.New("没有生成模型内容, 请检查数据表是否正确")
+	router, err := registerRouter(controllerName, data.Table)
+	if err != nil {
+		return nil, err
+	}
+	if len(router) > 0 {
+		files = append(files, genFile{Path: routerFile, Lang: "go", Content: router})
 	}
+	return files, nil
+}
 
-	content := strings.ReplaceAll(tableTpl, "[struct]", tableStruct)
-	if !print {
-		err = os.WriteFile(tablePath, []byte(content), os.ModePerm)
+// render 优先读取模板目录下的同名文件
+func render(dir, name string, data any) (string, error) {
+	text, exist := templates[name]
+	if byts, err := os.ReadFile(filepath.Join(dir, name)); err == nil {
+		text, exist = string(byts), true
+	}
+	if !exist {
+		return "", fmt.Errorf("模板%s不存在", name)
 	}
-	if err == nil {
-		pine.Logger().Warn("创建文件： " + tablePath)
+	t, err := template.New(name).Delims("[[", "]]").Parse(text)
+	if err != nil {
+		return "", err
 	}
-	if print {
-		_ = quick.Highlight(os.Stdout, content, "go", "terminal256", theme)
+	var buf bytes.Buffer
+	if err = t.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("渲染模板%s失败: %w", name, err)
 	}
-	return err
+	return buf.String(), nil
 }
 
-// genFrontendFile 生成前端模块
-func genFrontendFile(table, frontendPath string, tableDsl, formDsl, filterDsl []map[string]any) {
-	//fmt.Println(tableDsl)
-	//data, _ := json.Marshal(tableDsl)
-	//fmt.Println(string(data))
-	//fmt.Println(filterDsl)
-
-	moduleBaseDir := filepath.Join(frontendPath, feModuleDir+table)
-	files := map[string]string{
-		filepath.Join(moduleBaseDir, "service", "index.ts"):  serviceTsTpl,
-		filepath.Join(moduleBaseDir, "service", "router.ts"): serviceRouterTpl,
-		filepath.Join(moduleBaseDir, "views", table+".vue"):  indexVueTpl,
-		filepath.Join(moduleBaseDir, "index.ts"):             serviceIndexTsTpl,
+// registerRouter 在占位符后注册路由, 已注册时返回空
+func registerRouter(controllerName, route string) (string, error) {
+	byts, err := os.ReadFile(routerFile)
+	if err != nil {
+		return "", err
+	}
+	if bytes.Contains(byts, []byte("new(backend."+controllerName+")")) {
+		return "", nil
 	}
-	for filename, content := range files {
-		_ = os.MkdirAll(filepath.Dir(filename), os.ModePerm)
-		if strings.Contains(filename, ".vue") {
-			data, _ := json.MarshalIndent(formDsl, "\t\t\t", "\t")
-			content = string(bytes.ReplaceAll([]byte(content), []byte("[formDSL]"), data))
+	controllerNamespace := `"github.com/xiusin/pinecms/src/application/controllers/backend"`
+	pineNamespace := `"github.com/xiusin/pine"`
+	holder := "// holder"
+	if !bytes.Contains(byts, []byte(controllerNamespace)) {
+		byts = bytes.Replace(byts, []byte(pineNamespace), []byte(pineNamespace+"\n\t"+controllerNamespace), 1)
+	}
+	byts = bytes.Replace(byts, []byte(holder), []byte(holder+"\n\t"+`backendRouter.Handle(new(backend.`+controllerName+`), "/`+route+`")`), 1)
+	if formatted, err := format.Source(byts); err == nil {
+		byts = formatted
+	}
+	return string(byts), nil
+}
 
-			data, _ = json.MarshalIndent(tableDsl, "\t\t\t", "\t")
-			content = string(bytes.ReplaceAll([]byte(content), []byte("[tableDSL]"), data))
-		}
-		if err := os.WriteFile(filename, bytes.ReplaceAll([]byte(content), []byte("[table]"), []byte(util.SnakeString(table))), os.ModePerm); err == nil {
-			pine.Logger().Info("创建文件： " + filename)
-		} else {
-			pine.Logger().Warn("创建文件" + filename + "失败")
-		}
+// printDiff 打印与已有文件的差异, 新文件只打印行数
+func printDiff(f genFile) {
+	old, err := os.ReadFile(f.Path)
+	if err != nil {
+		pine.Logger().Info(fmt.Sprintf("新建： %s (%d行)", f.Path, len(difflib.SplitLines(f.Content))))
+		return
+	}
+	diff, _ := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(old)),
+		B:        difflib.SplitLines(f.Content),
+		FromFile: f.Path,
+		ToFile:   f.Path + " (生成)",
+		Context:  3,
+	})
+	if len(diff) == 0 {
+		pine.Logger().Info("无变化： " + f.Path)
+		return
 	}
+	_ = quick.Highlight(os.Stdout, diff, "diff", "terminal256", theme)
 }
 
 // getLabelAndFieldTypeAndProps 解析生成基础结构
@@ -5,30 +5,128 @@ const (
 	tableDir      = "src/application/models/tables/"
 	feModuleDir   = "src/cool/modules/"
 	routerFile    = "src/router/module.go"
+	tplDir        = "resources/crud" // 同名文件覆盖内置模板
+	defaultTarget = "cool"           // 内置的 cool-admin 前端模板
 	theme         = "vim"
 	goExt         = ".go"
+)
+
+// 内置模板, 使用 [[ ]] 作为分隔符, 避免与 vue 模板冲突
+const (
 	controllerTpl = `package backend
+
 import (
 	"github.com/xiusin/pinecms/src/application/models/tables"
+[[- if .HasCallback ]]
+	"xorm.io/xorm"
+[[- end ]]
 )
 
-type [ctrl] struct {
+type [[ .Controller ]] struct {
 	BaseController
 }
 
-func (c *[ctrl]) Construct() {
-    c.SearchFields = map[string]searchFieldDsl{
-[searchFieldDsl]
+func (c *[[ .Controller ]]) Construct() {
+	c.Group = "[[ .Table ]]管理"
+	c.ApiEntityName = "[[ .Table ]]"
+	c.SearchFields = []SearchFieldDsl{
+[[- range .SearchFields ]]
+		[[ .Code ]],
+[[- end ]]
+	}
+	c.Table = &tables.[[ .Struct ]]{}
+	c.Entries = &[]tables.[[ .Struct ]]{}
+	c.BaseController.Construct()
+[[- if ne .PKColumn "id" ]]
+	c.TableKey = "[[ .PKColumn ]]"
+	c.TableStructKey = "[[ .PK ]]"
+[[- end ]]
+[[- if .HasMany ]]
+	c.OpAfter = c.after
+[[- end ]]
+}
+[[- if .HasMany ]]
+
+// after 列表与详情加载子表数据
+func (c *[[ .Controller ]]) after(op int, _ any) error {
+	switch op {
+	case OpList:
+		return c.loadRelations(*c.Entries.(*[]tables.[[ .Struct ]]))
+	case OpInfo:
+		items := []tables.[[ .Struct ]]{*c.Table.(*tables.[[ .Struct ]])}
+		err := c.loadRelations(items)
+		*c.Table.(*tables.[[ .Struct ]]) = items[0]
+		return err
+	}
+	return nil
+}
+
+func (c *[[ .Controller ]]) loadRelations(items []tables.[[ .Struct ]]) error {
+	if len(items) == 0 {
+		return nil
+	}
+	ids := make([]any, len(items))
+	for i := range items {
+		ids[i] = items[i].[[ .PK ]]
 	}
-	c.Group = "[table]管理"
-  	c.ApiEntityName = "[table]"
-	
-	c.Table = &tables.[table]{}
-	c.Entries = &[]tables.[table]{}
-}`
+[[- range .HasMany ]]
+	var [[ .Var ]] []*tables.[[ .RefStruct ]]
+	if err := c.Orm.In("[[ .Column ]]", ids...).Find(&[[ .Var ]]); err != nil {
+		return err
+	}
+	for i := range items {
+		for _, child := range [[ .Var ]] {
+			if [[ .Match ]] {
+				items[i].[[ .Name ]] = append(items[i].[[ .Name ]], child)
+			}
+		}
+	}
+[[- end ]]
+	return nil
+}
+[[- end ]]
+`
+
 	tableTpl = `package tables
 
-[struct]
+type [[ .Struct ]] struct {
+[[ .Fields ]]
+[[- range .HasMany ]]
+	[[ .Name ]] []*[[ .RefStruct ]] ` + "`" + `xorm:"-" json:"[[ .JSON ]]"` + "`" + `
+[[- end ]]
+}
+`
+
+	tableTestTpl = `package tables
+
+import (
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+)
+
+func Test[[ .Struct ]]Validate(t *testing.T) {
+	err := validator.New().Struct(&[[ .Struct ]]{})
+[[- if .Required ]]
+	errs, ok := err.(validator.ValidationErrors)
+	if !ok {
+		t.Fatalf("空数据应校验失败: %v", err)
+	}
+	failed := map[string]bool{}
+	for _, e := range errs {
+		failed[e.Field()] = true
+	}
+	for _, field := range []string{[[ range $i, $f := .Required ]][[ if $i ]], [[ end ]]"[[ $f ]]"[[ end ]]} {
+		if !failed[field] {
+			t.Errorf("字段%s应为必填", field)
+		}
+	}
+[[- else ]]
+	if err != nil {
+		t.Fatal(err)
+	}
+[[- end ]]
+}
 `
 
 	indexVueTpl = `<template>
@@ -42,7 +140,15 @@ func (c *[ctrl]) Construct() {
 		</el-row>
 
 		<el-row>
-			<cl-table v-bind="table" />
+			<cl-table v-bind="table">
+[[- range .HasMany ]]
+				<template #column-[[ .JSON ]]="{ scope }">
+					<el-table :data="scope.row.[[ .JSON ]] || []" size="mini" border>
+						<el-table-column v-for="col in [[ .Var ]]Columns" :key="col.prop" v-bind="col" />
+					</el-table>
+				</template>
+[[- end ]]
+			</cl-table>
 		</el-row>
 
 		<el-row type="flex">
@@ -59,7 +165,7 @@ import { CrudLoad, Table, Upsert } from "cl-admin-crud-vue3/types";
 import { defineComponent, inject, reactive } from "vue";
 
 export default defineComponent({
-	name: "sys-[table]",
+	name: "sys-[[ .Table ]]",
 
 	setup() {
 		const service = inject<any>("service");
@@ -67,15 +173,32 @@ export default defineComponent({
 		const form = reactive<any>({});
 
 		const upsert = reactive<Upsert>({
-			items: [formDSL]
+			items: [[ .FormDSL ]]
 		});
 
 		const table = reactive<Table>({
-			columns: [tableDSL]
+			columns: [[ .TableDSL ]]
 		});
+[[- range .HasMany ]]
+
+		const [[ .Var ]]Columns = [[ .ColumnsDSL ]];
+[[- end ]]
+
+		// 加载关联表下拉选项
+		function loadOptions() {
+[[- range .BelongsTo ]]
+			service.[[ .RefTable ]].request({ url: "/select" }).then((list: any[]) => {
+				const item: any = upsert.items.find((e: any) => e.prop === "[[ .Column ]]");
+				const column: any = table.columns.find((e: any) => e.prop === "[[ .Column ]]");
+				if (item) item.component.options = list;
+				if (column) column.dict = list;
+			});
+[[- end ]]
+		}
 
 		function onLoad({ ctx, app }: CrudLoad) {
-			ctx.service(service.[table]).done();
+			ctx.service(service.[[ .Table ]]).done();
+			loadOptions();
 			app.refresh();
 		}
 
@@ -83,18 +206,36 @@ export default defineComponent({
 			form,
 			upsert,
 			table,
+[[- range .HasMany ]]
+			[[ .Var ]]Columns,
+[[- end ]]
 			onLoad
 		};
 	}
 });
-</script>`
+</script>
+`
 
-	serviceTsTpl = `import Router from "./router"; export default {[table]: new Router()};`
+	serviceTsTpl = `import Router from "./router"; export default {[[ .Table ]]: new Router()};
+`
 
 	serviceRouterTpl = `import { BaseService, Service, Permission } from "/@/core";
-@Service("[table]")
-class Sys[table] extends BaseService {}
-export default Sys[table];`
+@Service("[[ .Table ]]")
+class Sys[[ .Table ]] extends BaseService {}
+export default Sys[[ .Table ]];
+`
 
-	serviceIndexTsTpl = `import service from "./service"; export default { service };`
+	serviceIndexTsTpl = `import service from "./service"; export default { service };
+`
 )
+
+// templates 模板文件名 => 内置模板
+var templates = map[string]string{
+	"controller.go.tpl":    controllerTpl,
+	"table.go.tpl":         tableTpl,
+	"table_test.go.tpl":    tableTestTpl,
+	"index.vue.tpl":        indexVueTpl,
+	"service.ts.tpl":       serviceTsTpl,
+	"router.ts.tpl":        serviceRouterTpl,
+	"service_index.ts.tpl": serviceIndexTsTpl,
+}
@@ -0,0 +1,134 @@
+package crud
+
+import (
+	"encoding/json"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/xiusin/pine"
+	"github.com/xiusin/pinecms/cmd/util"
+	"github.com/xiusin/pinecms/src/common/dialect"
+	. "github.com/xiusin/pinecms/src/config"
+	"xorm.io/xorm/schemas"
+)
+
+// Relation 由外键推断的关联
+// belongs-to: 本表 Column 引用 RefTable, 表单渲染为下拉选择
+// has-many: RefTable.Column 引用本表, 列表与详情加载子表数据
+type Relation struct {
+	Column     string // 外键列, belongs-to 为本表字段, has-many 为子表字段
+	RefTable   string // 关联表, 不含前缀
+	RefStruct  string // 关联表结构体
+	Name       string // has-many 在本表结构体中的字段名
+	JSON       string // has-many 字段json名
+	Var        string // 模板内变量名
+	Match      string // has-many 子表与本表的关联条件
+	ColumnsDSL string // has-many 子表列表列
+}
+
+// relations 读取外键, 子表结构体不存在时跳过 has-many
+func relations(table *schemas.Table, metas []*schemas.Table, pk string) (belongsTo, hasMany []Relation) {
+	keys, err := dialect.ForeignKeys(Orm())
+	if err != nil {
+		pine.Logger().Warn("读取外键失败, 不生成关联", err)
+		return
+	}
+	prefix := DB().Db.DbPrefix
+	for _, key := range keys {
+		if key.Table == table.Name && key.RefTable != table.Name {
+			ref := strings.TrimPrefix(key.RefTable, prefix)
+			belongsTo = append(belongsTo, Relation{Column: key.Column, RefTable: util.SnakeString(ref), RefStruct: util.CamelString(ref)})
+		}
+		if key.RefTable != table.Name {
+			continue
+		}
+		child := strings.TrimPrefix(key.Table, prefix)
+		rel := Relation{
+			Column:    key.Column,
+			RefTable:  util.SnakeString(child),
+			RefStruct: util.CamelString(child),
+			Name:      util.CamelString(child) + "List",
+			JSON:      util.SnakeString(child) + "_list",
+			Var:       lowerFirst(util.CamelString(child)),
+		}
+		fieldType, exist := structFieldType(rel.RefStruct, util.CamelString(key.Column))
+		if !exist {
+			pine.Logger().Info("子表" + key.Table + "尚未生成结构体, 跳过关联")
+			continue
+		}
+		left, right := "child."+util.CamelString(key.Column), "items[i]."+pk
+		if fieldType != goType(table.GetColumn(keyOf(table, key.RefColumn))) {
+			left, right = "int64("+left+")", "int64("+right+")"
+		}
+		rel.Match = left + " == " + right
+		for _, meta := range metas {
+			if meta.Name == key.Table {
+				rel.ColumnsDSL = childColumns(meta)
+			}
+		}
+		hasMany = append(hasMany, rel)
+	}
+	return
+}
+
+// keyOf SQLite 外键未指定引用列时为主键
+func keyOf(table *schemas.Table, column string) string {
+	if len(column) == 0 && len(table.PrimaryKeys) > 0 {
+		return table.PrimaryKeys[0]
+	}
+	return column
+}
+
+// structFieldType 从已生成的 tables 文件中读取结构体字段类型
+func structFieldType(structName, field string) (string, bool) {
+	files, _ := os.ReadDir(tableDir)
+	expr := regexp.MustCompile(`(?s)type ` + structName + ` struct \{(.*?)\n\}`)
+	fieldExpr := regexp.MustCompile(`(?m)^\s*` + field + `\s+(\S+)`)
+	for _, f := range files {
+		data, err := os.ReadFile(tableDir + f.Name())
+		if err != nil {
+			continue
+		}
+		if m := expr.FindSubmatch(data); m != nil {
+			if fm := fieldExpr.FindSubmatch(m[1]); fm != nil {
+				return string(fm[1]), true
+			}
+			return "", false
+		}
+	}
+	return "", false
+}
+
+// childColumns 子表列表列, 不含大字段
+func childColumns(meta *schemas.Table) string {
+	var columns []map[string]any
+	for _, col := range meta.Columns() {
+		if strings.HasSuffix(strings.ToLower(col.SQLType.Name), "text") || strings.Contains(strings.ToLower(col.SQLType.Name), "blob") {
+			continue
+		}
+		label, _, _ := parseCommentInfo(col.Name, col.Comment, false)
+		columns = append(columns, map[string]any{"prop": col.Name, "label": label})
+	}
+	data, _ := json.MarshalIndent(columns, "\t\t", "\t")
+	return string(data)
+}
+
+// goType 与生成的结构体字段类型一致
+func goType(col *schemas.Column) string {
+	if col == nil {
+		return ""
+	}
+	typ := strings.TrimSpace(strings.TrimPrefix(strings.ToLower(col.SQLType.Name), "unsigned "))
+	if alias, ok := sqlTypeAlias[typ]; ok {
+		typ = alias
+	}
+	return goTypes[typ]
+}
+
+func lowerFirst(s string) string {
+	if len(s) == 0 {
+		return s
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
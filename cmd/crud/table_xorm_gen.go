@@ -1,15 +1,17 @@
 package crud
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/xiusin/pinecms/cmd/util"
+	"xorm.io/xorm/schemas"
 )
 
-var searchFieldDsl string
-
 // SQLTable 表名结构体
 type SQLTable struct {
 	Name string
@@ -28,97 +30,226 @@ type SQLColumn struct {
 	Default       string
 }
 
-func (t *SQLTable) toXorm(print bool, tableName string, frontendPath string) string {
-	var str strings.Builder
-	str.WriteString(fmt.Sprintf("type %s struct {\n", util.CamelString(tableName)))
+// sqlTypeAlias 各数据库类型名统一为MySQL写法
+var sqlTypeAlias = map[string]string{
+	"integer":          "int",
+	"serial":           "int",
+	"bigserial":        "bigint",
+	"bool":             "tinyint",
+	"boolean":          "tinyint",
+	"real":             "double",
+	"double precision": "double",
+	"numeric":          "decimal",
+	"timestamp":        "datetime",
+	"timestamptz":      "datetime",
+	"bytea":            "blob",
+	"longblob":         "blob",
+	"mediumblob":       "blob",
+}
 
-	var tableDsl []map[string]any
-	var formDsl []map[string]any
-	var filterDsl []map[string]any
+// goTypes 字段类型对应的结构体类型
+var goTypes = map[string]string{
+	"varchar": "string", "text": "string", "char": "string", "longtext": "string", "mediumtext": "string",
+	"smalltext": "string", "tinytext": "string", "set": "string", "json": "string",
+	"enum": "int", "tinyint": "int", "smallint": "int", "mediumint": "int",
+	"int": "int64", "bigint": "int64",
+	"double": "float64", "float": "float64", "decimal": "float64",
+	"year": "LocalTime", "date": "LocalTime", "datetime": "LocalTime", "time": "LocalTime", "timestamp": "LocalTime",
+	"blob": "[]byte",
+}
 
-	for _, col := range t.Cols {
-		tableField := util.SnakeString(col.Name)
-		coreCol := cols[tableField]
+// searchField 列表筛选字段
+type searchField struct {
+	Field string
+	Code  string // SearchFieldDsl 字面量
+}
 
-		// ↓↓↓↓↓↓↓↓ 解析生成table结构 开始
-		{
-			str.WriteRune('\t')
-			str.WriteString(util.CamelString(col.Name))
+// tableResult 由表结构生成的结构体字段与前端配置
+type tableResult struct {
+	Fields       string // 结构体字段
+	PK           string // 主键结构体字段
+	Required     []string
+	SearchFields []searchField
+	HasCallback  bool // 筛选字段使用了回调
+	TableDSL     string
+	FormDSL      string
+	FilterDSL    string
+}
 
-			var goType string
-			switch col.Type {
-			case "varchar", "text", "char", "longtext", "mediumtext", "smalltext", "tinytext", "set":
-				goType = "string"
-			case "enum", "tinyint":
-				goType = "int"
-			case "int", "bigint":
-				goType = "int64"
-			case "double", "float", "decimal":
-				goType = "float64"
-			case "year", "date", "datetime", "time", "timestamp":
-				goType = "LocalTime"
-			case "blob":
-				goType = "[]byte"
-			default:
-				panic(col.Name + " 是一个未知类型")
+// sqlTable 由xorm读取的表元信息构建, 不依赖 SHOW CREATE TABLE, 可用于所有支持的数据库
+func sqlTable(meta *schemas.Table) SQLTable {
+	table := SQLTable{Name: meta.Name}
+	uniqueKeys := map[string]bool{}
+	for _, index := range meta.Indexes {
+		if index.Type == schemas.UniqueType && len(index.Cols) == 1 {
+			uniqueKeys[index.Cols[0]] = true
+		}
+	}
+	for _, col := range meta.Columns() {
+		typ := strings.TrimSpace(strings.TrimPrefix(strings.ToLower(col.SQLType.Name), "unsigned "))
+		if alias, ok := sqlTypeAlias[typ]; ok {
+			typ = alias
+		}
+		scol := SQLColumn{
+			Name:          col.Name,
+			Type:          typ,
+			IsPrimaryKey:  col.IsPrimaryKey,
+			IsUnique:      uniqueKeys[col.Name],
+			AutoIncrement: col.IsAutoIncrement,
+			NotNull:       !col.Nullable,
+		}
+		if col.Length > 0 && col.Length2 > 0 {
+			scol.Length = fmt.Sprintf("%d,%d", col.Length, col.Length2)
+		} else if col.Length > 0 {
+			scol.Length = strconv.FormatInt(col.Length, 10)
+		}
+		for value := range col.EnumOptions {
+			scol.EnumValues = append(scol.EnumValues, value)
+		}
+		sort.Slice(scol.EnumValues, func(i, j int) bool {
+			return col.EnumOptions[scol.EnumValues[i]] < col.EnumOptions[scol.EnumValues[j]]
+		})
+		for i, value := range scol.EnumValues {
+			scol.EnumValues[i] = "'" + value + "'"
+		}
+		if !col.DefaultIsEmpty {
+			// PostgreSQL 默认值带有类型转换, 如 'a'::character varying
+			def := col.Default
+			if i := strings.Index(def, "::"); i > 0 {
+				def = def[:i]
 			}
-			str.WriteString(" " + goType)
-			str.WriteString(" `xorm:\"")
-
-			str.WriteString(col.Type)
-
-			if len(col.EnumValues) > 0 {
-				str.WriteRune('(')
-				for i, en := range col.EnumValues {
-					str.WriteString(en)
-					if i != len(col.EnumValues)-1 {
-						str.WriteRune(',')
-					}
-				}
-				str.WriteRune(')')
-			} else if len(col.Length) > 0 {
-				str.WriteString("(" + col.Length + ")")
+			if !strings.HasPrefix(def, "nextval(") {
+				scol.Default = strings.Trim(def, "'")
 			}
+		}
+		table.Cols = append(table.Cols, scol)
+	}
+	return table
+}
 
-			if col.AutoIncrement {
-				str.WriteString(" autoincr")
+// validateTag 由 NOT NULL 与长度生成校验规则, 非空且无默认值为必填
+func validateTag(col SQLColumn, goType string) (tag string, required bool) {
+	if col.IsPrimaryKey || col.AutoIncrement {
+		return "", false
+	}
+	var rules []string
+	required = col.NotNull && len(col.Default) == 0 && goType != "LocalTime"
+	if required {
+		rules = append(rules, "required")
+	} else {
+		rules = append(rules, "omitempty")
+	}
+	if goType == "string" && len(col.Length) > 0 && !strings.Contains(col.Length, ",") && col.Type != "set" {
+		rules = append(rules, "max="+col.Length)
+	}
+	if col.Type == "enum" && len(col.EnumValues) > 0 {
+		values := make([]string, 0, len(col.EnumValues))
+		for _, v := range col.EnumValues {
+			v = strings.Trim(v, "'")
+			if strings.ContainsAny(v, " ,") {
+				values = nil
+				break
 			}
+			values = append(values, v)
+		}
+		if len(values) > 0 {
+			rules = append(rules, "oneof="+strings.Join(values, " "))
+		}
+	}
+	if len(rules) == 1 && !required {
+		return "", false
+	}
+	return strings.Join(rules, ","), required
+}
 
-			if col.NotNull {
-				str.WriteString(" not null")
+// searchCode 筛选字段: 日期按区间, set按包含, 其他按相等
+func searchCode(col SQLColumn) (code string, callback bool) {
+	switch col.Type {
+	case "date", "datetime", "timestamp":
+		return `{Field: "` + col.Name + `", CallBack: func(sess *xorm.Session, v ...any) {
+			if len(v) == 2 {
+				sess.Where("` + col.Name + ` BETWEEN ? AND ?", v[0], v[1])
 			}
-
-			if len(col.Default) > 0 {
-				if strings.Contains(goType, "int") || strings.Contains(goType, "float") || col.Default == "null" {
-					str.WriteString(" default " + col.Default)
-				} else {
-					str.WriteString(" default '" + col.Default + "'")
-				}
+		}}`, true
+	case "set":
+		return `{Field: "` + col.Name + `", CallBack: func(sess *xorm.Session, v ...any) {
+			for _, item := range v {
+				sess.Where("FIND_IN_SET(?, ` + col.Name + `)", item)
 			}
-			if col.IsPrimaryKey {
-				str.WriteString(" pk")
-			}
-			if col.IsUnique {
-				str.WriteString(" unique")
-			}
-			str.WriteString(" '" + col.Name + "'")
+		}}`, true
+	}
+	return `{Field: "` + col.Name + `", Op: "="}`, false
+}
 
-			if len(cols[col.Name].Comment) > 0 {
-				clearComment := strings.ReplaceAll(strings.ReplaceAll(cols[col.Name].Comment, "\r\n", " "), "'", "")
-				clearComment = strings.ReplaceAll(clearComment, "\"", "")
-				str.WriteString(" comment('" + clearComment + "')")
-			}
+func (t *SQLTable) toXorm(belongsTo []Relation) (*tableResult, error) {
+	var str strings.Builder
+	result := &tableResult{}
 
-			str.WriteString("\"")
+	var tableDsl []map[string]any
+	var formDsl []map[string]any
+	var filterDsl []map[string]any
 
-			// 设置JsonTag
-			str.WriteString(" json:\"" + tableField + "\"")
+	refs := map[string]Relation{}
+	for _, rel := range belongsTo {
+		refs[rel.Column] = rel
+	}
+
+	for i, col := range t.Cols {
+		tableField := util.SnakeString(col.Name)
+		coreCol := cols[col.Name]
+		fieldName := util.CamelString(col.Name)
+
+		// ↓↓↓↓↓↓↓↓ 解析生成table结构 开始
+		goType, ok := goTypes[col.Type]
+		if !ok {
+			return nil, fmt.Errorf("%s 是一个未知类型%s", col.Name, col.Type)
+		}
+		if col.IsPrimaryKey && len(result.PK) == 0 {
+			result.PK = fieldName
+		}
+		if i > 0 {
+			str.WriteRune('\n')
+		}
+		str.WriteString("\t" + fieldName + " " + goType + " `xorm:\"" + col.Type)
 
-			// 添加验证规则选项
-			if !col.IsPrimaryKey {
-				str.WriteString(" validate:\"required\"")
+		if len(col.EnumValues) > 0 {
+			str.WriteString("(" + strings.Join(col.EnumValues, ",") + ")")
+		} else if len(col.Length) > 0 {
+			str.WriteString("(" + col.Length + ")")
+		}
+		if col.AutoIncrement {
+			str.WriteString(" autoincr")
+		}
+		if col.NotNull {
+			str.WriteString(" not null")
+		}
+		if len(col.Default) > 0 {
+			if strings.Contains(goType, "int") || strings.Contains(goType, "float") || col.Default == "null" {
+				str.WriteString(" default " + col.Default)
+			} else {
+				str.WriteString(" default '" + col.Default + "'")
+			}
+		}
+		if col.IsPrimaryKey {
+			str.WriteString(" pk")
+		}
+		if col.IsUnique {
+			str.WriteString(" unique")
+		}
+		str.WriteString(" '" + col.Name + "'")
+		if len(coreCol.Comment) > 0 {
+			clearComment := strings.ReplaceAll(strings.ReplaceAll(coreCol.Comment, "\r\n", " "), "'", "")
+			clearComment = strings.ReplaceAll(clearComment, "\"", "")
+			str.WriteString(" comment('" + clearComment + "')")
+		}
+		str.WriteString("\" json:\"" + tableField + "\"")
+		if tag, required := validateTag(col, goType); len(tag) > 0 {
+			str.WriteString(" validate:\"" + tag + "\"")
+			if required {
+				result.Required = append(result.Required, fieldName)
 			}
 		}
+		str.WriteString("`")
 		// ↑↑↑↑↑↑↑↑ 解析生成table结构 结束
 
 		labelName, elFieldType, elProps := getLabelAndFieldTypeAndProps(col, coreCol)
@@ -128,6 +259,13 @@ func (t *SQLTable) toXorm(print bool, tableName string, frontendPath string) str
 		comp := map[string]any{"name": elFieldType, "props": elProps}                     // 渲染组件
 		item := map[string]any{"prop": tableField, "label": labelName, "component": comp} // upsert 组件
 
+		// 外键渲染为下拉选择, 选项由关联表的 select 接口加载
+		if _, exist := refs[col.Name]; exist {
+			elFieldType = "el-select"
+			elProps = map[string]any{"size": "mini", "filterable": true, "clearable": true, "is_number": elProps["is_number"], "is_float": false}
+			comp["name"], comp["props"], comp["options"] = elFieldType, elProps, []any{}
+		}
+
 		// 设置字段默认值
 		if !coreCol.DefaultIsEmpty {
 			if elProps["is_number"].(bool) || elProps["is_float"].(bool) {
@@ -164,7 +302,7 @@ func (t *SQLTable) toXorm(print bool, tableName string, frontendPath string) str
 			case "date":
 				props["type"] = "date"
 			default:
-				if elFieldType == "tinyint" && elProps["options"] != nil && comp["name"] == "el-input-number" {
+				if col.Type == "tinyint" && elProps["options"] != nil && comp["name"] == "el-input-number" {
 					filterItem["type"] = "el-select"
 					filterItem["options"] = elProps["options"]
 					tableItem["dict"] = filterItem["options"]
@@ -175,7 +313,7 @@ func (t *SQLTable) toXorm(print bool, tableName string, frontendPath string) str
 					comp["props"] = elProps
 				}
 
-				if (elFieldType == "varchar" || elFieldType == "char") && elProps["options"] != nil {
+				if (col.Type == "varchar" || col.Type == "char") && elProps["options"] != nil {
 					filterItem["type"] = "el-select"
 					filterItem["options"] = elProps["options"]
 					tableItem["dict"] = filterItem["options"]
@@ -186,29 +324,33 @@ func (t *SQLTable) toXorm(print bool, tableName string, frontendPath string) str
 					comp["props"] = elProps
 				}
 			}
-			if strings.HasSuffix(filterItem["type"].(string), "-range") {
-				searchFieldDsl += `		"` + filterItem["name"].(string) + `":{Op: "range"},`
-			} else if coreCol.SQLType.Name == "set" {
-				searchFieldDsl += `		"` + filterItem["name"].(string) + `":{Op: "set"},` // findInSet
-			} else {
-				searchFieldDsl += `		"` + filterItem["name"].(string) + `":{Op: "="},`
-			}
-			searchFieldDsl += "\r\n"
+			code, callback := searchCode(col)
+			result.SearchFields = append(result.SearchFields, searchField{Field: col.Name, Code: code})
+			result.HasCallback = result.HasCallback || callback
 			filterDsl = append(filterDsl, filterItem)
-			//tableItem["type"] = tableFieldType
 			tableDsl = append(tableDsl, tableItem)
 		}
 
-		str.WriteString("`\n")
 		delete(elProps, "is_number")
 		delete(elProps, "is_float")
 		if !col.IsPrimaryKey {
 			formDsl = append(formDsl, item)
 		}
 	}
-	str.WriteString("}")
-
-	genFrontendFile(tableName, frontendPath, tableDsl, formDsl, filterDsl)
+	if str.Len() == 0 {
+		return nil, errors.New("没有生成模型内容, 请检查数据表是否正确")
+	}
+	result.Fields = str.String()
+	result.TableDSL = dsl(tableDsl)
+	result.FormDSL = dsl(formDsl)
+	result.FilterDSL = dsl(filterDsl)
+	return result, nil
+}
 
-	return str.String()
+func dsl(v []map[string]any) string {
+	if v == nil {
+		v = []map[string]any{}
+	}
+	data, _ := json.MarshalIndent(v, "\t\t\t", "\t")
+	return string(data)
 }
@@ -1,374 +1,670 @@
 package dede
 
 import (
-	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"github.com/xiusin/pine"
+	"io"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/xiusin/pinecms/src/common/helper"
+	"github.com/xiusin/pinecms/src/common/theme"
 )
 
-var dedeTplCmd = &cobra.Command{
-	Use:   "tpl",
+// TemplateCmd 转换织梦模板目录为pinecms主题, 挂载于 import 命令下
+var TemplateCmd = &cobra.Command{
+	Use:   "dede-template",
 	Short: "dede模板转换为pinecms模板",
-	Long: `1. 快速转换织梦模板为pinecms模板
-2. 只支持标签级转换, 其他的转换完成根据错误提醒自行修复
+	Long: `1. 转换织梦模板目录为pinecms主题, .htm/.html 转换为 .jet, 其他文件原样复制
+2. 只支持标签级转换, 无法转换的标签保留原样并输出所在行号, 根据报告自行修复
 `,
+	Example: "pinecms import dede-template --src=/www/dede/templets/default --dst=resources/themes/dede",
 	Run: func(cmd *cobra.Command, args []string) {
-		dedepath, _ := cmd.Flags().GetString("dedepath")
-		pinepath, _ := cmd.Flags().GetString("pinepath")
-		dirname, _ := cmd.Flags().GetString("dirname")
+		src, _ := cmd.Flags().GetString("src")
+		dst, _ := cmd.Flags().GetString("dst")
 		force, _ := cmd.Flags().GetBool("force")
-		if dedepath == "" || pinepath == "" {
+		if src == "" {
 			_ = cmd.Usage()
 			return
 		}
-		dedepath = strings.TrimRight(dedepath, "\\/")
-		var theme string
-		if dirname != "" {
-			theme = dirname
-		} else {
-			theme = filepath.Base(dedepath)
+		src = strings.TrimRight(src, "\\/")
+		if dst == "" {
+			dst = filepath.Join(helper.GetRootPath(), "resources/themes", filepath.Base(src))
 		}
-
-		fs, err := os.Stat(pinepath)
+		issues, err := ConvertTemplates(src, dst, force)
 		if err != nil {
-			pine.Logger().Error(err.Error())
+			fmt.Println("转换失败:", err)
 			return
 		}
-
-		if !fs.IsDir() {
-			pine.Logger().Error("您输入的pinepath参数非目录地址")
+		fmt.Printf("\n模板已转换到 %s\n", dst)
+		if len(issues) == 0 {
+			fmt.Println("所有标签均已转换")
 			return
 		}
-		themePath := filepath.Join(pinepath, theme)
-		if force { // 是否强制删除目录
-			err := os.RemoveAll(themePath)
-			if err != nil {
-				pine.Logger().Error(err.Error())
-				return
-			}
+		fmt.Printf("\n以下 %d 处需要手动处理:\n\n", len(issues))
+		for _, issue := range issues {
+			fmt.Println(issue.String())
+		}
+	},
+}
+
+func init() {
+	TemplateCmd.Flags().String("src", "", "织梦模板路径(填入具体主题地址, 如 templets/default)")
+	TemplateCmd.Flags().String("dst", "", "生成的主题目录, 默认为 resources/themes/{src目录名}")
+	TemplateCmd.Flags().Bool("force", false, "是否强制删除已存在的主题目录")
+}
+
+// ConvertTemplates 转换整个模板目录, 返回需要手动处理的问题列表
+func ConvertTemplates(src, dst string, force bool) ([]Issue, error) {
+	info, err := os.Stat(src)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, errors.New("src参数非目录地址")
+	}
+	if _, err := os.Stat(dst); err == nil {
+		if !force {
+			return nil, errors.New("主题目录已存在, 使用 --force 覆盖")
+		}
+		if err := os.RemoveAll(dst); err != nil {
+			return nil, err
+		}
+	}
+	var issues []Issue
+	var tags []string
+	err = filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, _ := filepath.Rel(src, path)
+		ext := strings.ToLower(filepath.Ext(path))
+		if ext != ".html" && ext != ".htm" {
+			return copyFile(path, filepath.Join(dst, rel))
 		}
-		err = os.Mkdir(themePath, os.ModePerm)
+		byts, err := os.ReadFile(path)
 		if err != nil {
-			pine.Logger().Error(err.Error())
-			return
+			return err
 		}
-
-		err = filepath.Walk(dedepath, func(path string, info os.FileInfo, err error) error {
-			if info.IsDir() {
-				return nil
-			}
-			ext := strings.ToLower(filepath.Ext(path))
-			if ext != ".html" && ext != ".htm" {
-				return nil
+		parser := NewParser(filepath.ToSlash(rel), byts)
+		data := parser.Parse()
+		issues = append(issues, parser.Issues()...)
+		for _, tag := range parser.Tags() {
+			if !contains(tags, tag) {
+				tags = append(tags, tag)
 			}
-			// 读取文件内容
-			byts, err := os.ReadFile(path)
-			if err != nil {
-				return err
-			}
-			relativeFilePath, _ := filepath.Rel(dedepath, path)
+		}
+		target := filepath.Join(dst, strings.TrimSuffix(rel, filepath.Ext(rel))+".jet")
+		if err := os.MkdirAll(filepath.Dir(target), os.ModePerm); err != nil {
+			return err
+		}
+		return os.WriteFile(target, data, os.ModePerm)
+	})
+	if err != nil {
+		return issues, err
+	}
+	sort.Strings(tags)
+	manifest, _ := json.MarshalIndent(theme.Manifest{
+		Name:        filepath.Base(dst),
+		Title:       filepath.Base(src),
+		Version:     "1.0.0",
+		Description: "由织梦模板 " + filepath.Base(src) + " 转换",
+		Tags:        tags,
+	}, "", "  ")
+	return issues, os.WriteFile(filepath.Join(dst, theme.ManifestFile), manifest, os.ModePerm)
+}
 
-			parser := &Parser{
-				data: byts,
-				dst:  filepath.Join(themePath, strings.Replace(relativeFilePath, ext, ".jet", 1)),
-				src:  relativeFilePath,
-			}
-			parser.Start()
-			return nil
-		})
-		if err != nil {
-			pine.Logger().Error(err.Error())
-			return
+func copyFile(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), os.ModePerm); err != nil {
+		return err
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	_, err = io.Copy(out, in)
+	return err
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
 		}
-		fmt.Printf("%s\n", `
+	}
+	return false
+}
 
-SUCCESS!
+// Issue 无法自动转换的标签
+type Issue struct {
+	File    string
+	Line    int
+	Tag     string
+	Message string
+}
 
-1. 导入织梦模板成功, 此操作只能解决80%的繁琐替换过程
-2. 测试各个模板数据根据错误修改相应的标签
-3. Enjoy! 😃
+func (i Issue) String() string {
+	return fmt.Sprintf("%s:%d\t%s\t%s", i.File, i.Line, i.Message, i.Tag)
+}
 
-注意: 导入不保证完全正确,建议进行模型设置(固化字段被设置为text类型)`)
-	},
+// 标签所在上下文, 决定[field:xxx/]的取值方式
+const (
+	ctxNone     = iota
+	ctxDocument // 文档列表, field为map
+	ctxCategory // 栏目, field为tables.Category
+	ctxLink     // 友情链接, field为tables.Link
+)
+
+// 模板类型, 决定{dede:field.xxx/}的取值方式
+const (
+	pageOther = iota
+	pageArticle
+	pageList
+)
+
+var (
+	tokenRe = regexp.MustCompile(`\{dede:([\w.]+)([^}]*?)(/?)\}|\{/dede:([\w.]+)\}|\[field:([\w.]+)([^\]]*?)(/?)\]`)
+	attrRe  = regexp.MustCompile(`([\w-]+)\s*=\s*(?:'([^']*)'|"([^"]*)"|([^\s'"]+))`)
+	funcRe  = regexp.MustCompile(`^(\w+)\((.*)\)$`)
+	numRe   = regexp.MustCompile(`^-?\d+$`)
+)
+
+// tagSpec 织梦标签与tags.jet中block的对应关系
+type tagSpec struct {
+	name  string            // pinecms标签名
+	ctx   int               // 标签内部[field:xxx/]的上下文
+	attrs map[string]string // 织梦属性 => pinecms参数
 }
 
-func init() {
-	Cmd.AddCommand(dedeTplCmd)
-	dedeTplCmd.Flags().String("dedepath", "", "织梦模板路径(填入具体主题地址)")
-	dedeTplCmd.Flags().String("pinepath", helper.GetRootPath()+"/resources/themes/", "pine的主题路径")
-	dedeTplCmd.Flags().Bool("force", false, "是否强制删除pinecms同名主题目录")
-	dedeTplCmd.Flags().String("dirname", "", "默认基于pinepath生成目录")
+var tagSpecs = map[string]tagSpec{
+	"arclist": {name: "artlist", ctx: ctxDocument, attrs: map[string]string{
+		"typeid": "typeid", "row": "row", "titlelen": "titlelen", "orderby": "orderby", "orderway": "orderway",
+		"flag": "flag", "att": "flag", "noflag": "noflag", "channelid": "channelid", "keyword": "keyword", "subday": "subday", "limit": "limit",
+	}},
+	"list": {name: "list", ctx: ctxDocument, attrs: map[string]string{
+		"pagesize": "pagesize", "titlelen": "titlelen", "orderby": "orderby", "orderway": "orderway",
+	}},
+	"likeart": {name: "likearticle", ctx: ctxDocument, attrs: map[string]string{"row": "row", "titlelen": "titlelen"}},
+	"channel": {name: "channel", ctx: ctxCategory, attrs: map[string]string{
+		"typeid": "typeid", "reid": "reid", "type": "type", "row": "row", "currentstyle": "currentstyle",
+	}},
+	"channelartlist": {name: "channelartlist", ctx: ctxCategory, attrs: map[string]string{"typeid": "typeid", "row": "row"}},
+	"type":           {name: "type", ctx: ctxCategory, attrs: map[string]string{"typeid": "typeid"}},
+	"flink":          {name: "flink", ctx: ctxLink, attrs: map[string]string{"row": "row"}},
+	"myad":           {name: "myad", attrs: map[string]string{"name": "name", "id": "id", "aid": "id"}},
+	"pagelist":       {name: "pagelist", attrs: map[string]string{}},
+	"prenext":        {name: "prenext", attrs: map[string]string{"get": "get"}},
+}
+
+// 只影响织梦展示效果的属性, 转换时直接忽略
+var ignoredAttrs = map[string]bool{
+	"listsize": true, "listitem": true, "imgwidth": true, "imgheight": true, "infolen": true, "col": true,
+}
+
+// 全局变量映射为站点配置
+var globalFields = map[string]string{
+	"cfg_powerby":     "site_copyright",
+	"cfg_keywords":    "site_keywords",
+	"cfg_description": "site_description",
+	"cfg_beian":       "site_icp",
+	"cfg_webname":     "site_name",
+	"cfg_cmsurl":      "site_host",
+	"cfg_basehost":    "site_url",
+	"cfg_indexurl":    "site_url",
+}
+
+// 模板函数映射, 其他函数不转换
+var funcMaps = map[string]string{
+	"MyDate":        "MyDate",
+	"GetDateTimeMk": "GetDateTimeMK",
+	"GetDateTimeMK": "GetDateTimeMK",
+	"cn_substr":     "cn_substr",
+	"cn_substrR":    "cn_substr",
+}
+
+type block struct {
+	name      string
+	converted bool
+	ctx       int
 }
 
+// Parser 转换单个织梦模板
 type Parser struct {
-	data []byte
-	dst  string
-	src  string
-}
-
-func (p *Parser) appendTag() {
-}
-func (p *Parser) parseInclude() {
-	p.data = regexp.MustCompile("{dede:include\\s+filename=[\"|'](.+)?.html?[\"|']\\s*/}").
-		ReplaceAll(p.data, []byte(`{{include "$1.jet"}}`))
-}
-
-//func (p *Parser) parseTagBlock()  {
-//	ts := `{dede:channel type='son' typeid='127''>[field:typename/]</a>{/dede:channel}`
-//	regexp.MustCompile("{dede:(.+)?\\s+(.+)?}(.+)?{/dede:$1}").ReplaceAllFunc([]byte(ts), func(i []byte) []byte {
-//		fmt.Println(string(i))
-//		return []byte{}
-//	})
-//}
-
-func (p *Parser) parseGlobalSomeField() {
-	var fields = map[string]string{ //
-		"cfg_powerby":     "site_copyright",
-		"cfg_keywords":    "site_keywords",
-		"cfg_description": "site_description",
-		"cfg_beian":       "site_icp",
-		"cfg_webname":     "site_name",
-		"cfg_cmsurl":      "site_host",
-	}
-	p.data = regexp.MustCompile("{dede:global\\.[^\\/]+/}").ReplaceAllFunc(p.data, func(bts []byte) []byte {
-		bts = bytes.TrimPrefix(bts, []byte("{dede:global."))
-		bts = bytes.TrimSuffix(bts, []byte("/}"))
-		fieldInfo := strings.SplitN(string(bts), " ", 2)
-		field := fieldInfo[0]
-		if val, ok := fields[field]; ok {
-			return []byte(`{{global["` + val + `"]}}`)
+	src    string
+	data   []byte
+	page   int
+	stack  []block
+	issues []Issue
+	tags   []string
+	line   int
+	offset int
+}
+
+func NewParser(src string, data []byte) *Parser {
+	p := &Parser{src: src, data: data, line: 1}
+	base := strings.ToLower(filepath.Base(src))
+	switch {
+	case strings.HasPrefix(base, "article_"):
+		p.page = pageArticle
+	case strings.HasPrefix(base, "list_"), strings.HasPrefix(base, "index_"):
+		p.page = pageList
+	}
+	return p
+}
+
+// Issues 无法转换的标签
+func (p *Parser) Issues() []Issue {
+	return p.issues
+}
+
+// Tags 模板用到的pinecms标签
+func (p *Parser) Tags() []string {
+	return p.tags
+}
+
+// Parse 转换模板, 无法转换的标签保留原样
+func (p *Parser) Parse() []byte {
+	var out []byte
+	last := 0
+	for _, m := range tokenRe.FindAllSubmatchIndex(p.data, -1) {
+		out = append(out, p.data[last:m[0]]...)
+		raw := string(p.data[m[0]:m[1]])
+		p.seek(m[0])
+		var converted string
+		switch {
+		case m[2] >= 0:
+			converted = p.openTag(raw, p.group(m, 1), p.group(m, 2), m[6] < m[7])
+		case m[8] >= 0:
+			converted = p.closeTag(raw, p.group(m, 4))
+		default:
+			converted = p.field(raw, p.group(m, 5), p.group(m, 6), m[14] < m[15])
 		}
-		return []byte(`{{global["` + field + `"]}}`)
-	})
+		out = append(out, converted...)
+		last = m[1]
+	}
+	out = append(out, p.data[last:]...)
+	for i := len(p.stack) - 1; i >= 0; i-- {
+		if p.stack[i].converted {
+			p.report("{dede:"+p.stack[i].name+"}", "标签未闭合")
+		}
+	}
+	return append([]byte("{{ import \"tags.jet\" }}\n"), out...)
 }
 
-func replaceFieldName(field string) string {
-	switch field {
-	case "picname", "litpic":
-		field = "thumb"
-	case "pubdate":
-		field = "pubtime"
+func (p *Parser) group(m []int, i int) string {
+	if m[2*i] < 0 {
+		return ""
 	}
-	return field
+	return string(p.data[m[2*i]:m[2*i+1]])
 }
 
-// 解析页面级别的织梦标签
-func (p *Parser) parsePageTagField() {
-	p.data = regexp.MustCompile("{dede:field[^}]+?/}").ReplaceAllFunc(p.data, func(bts []byte) []byte {
-		matched := string(bts)
-		matched = strings.TrimPrefix(matched, "{dede:field")
-		matched = strings.TrimSuffix(matched, "/}")
-		var field string
-		if strings.HasPrefix(matched, ".") {
-			field = strings.TrimPrefix(matched, ".")
-		} else {
-			field = strings.Trim(matched, ` `)
+// seek 计算当前标签所在行号
+func (p *Parser) seek(offset int) {
+	p.line += strings.Count(string(p.data[p.offset:offset]), "\n")
+	p.offset = offset
+}
+
+func (p *Parser) report(tag, msg string) {
+	p.issues = append(p.issues, Issue{File: p.src, Line: p.line, Tag: tag, Message: msg})
+}
+
+func (p *Parser) useTag(name string) {
+	if !contains(p.tags, name) {
+		p.tags = append(p.tags, name)
+	}
+}
+
+func (p *Parser) context() int {
+	for i := len(p.stack) - 1; i >= 0; i-- {
+		if p.stack[i].converted && p.stack[i].ctx != ctxNone {
+			return p.stack[i].ctx
+		}
+	}
+	return ctxNone
+}
+
+func parseAttrs(s string) ([]string, map[string]string) {
+	var keys []string
+	attrs := map[string]string{}
+	for _, m := range attrRe.FindAllStringSubmatch(s, -1) {
+		k := strings.ToLower(m[1])
+		keys = append(keys, k)
+		attrs[k] = m[2] + m[3] + m[4]
+	}
+	return keys, attrs
+}
+
+func (p *Parser) openTag(raw, name, attrStr string, selfClose bool) string {
+	name = strings.ToLower(name)
+	keys, attrs := parseAttrs(attrStr)
+	if _, ok := attrs["runphp"]; ok {
+		return p.unsupported(raw, name, selfClose, "不支持runphp")
+	}
+	if _, ok := attrs["sql"]; ok {
+		return p.unsupported(raw, name, selfClose, "不支持sql属性, 请使用datasource标签")
+	}
+	switch {
+	case strings.HasPrefix(name, "global."):
+		return "{{" + p.global(strings.TrimPrefix(name, "global.")) + "}}"
+	case name == "global":
+		return "{{" + p.global(attrs["name"]) + "}}"
+	case strings.HasPrefix(name, "field."):
+		return p.pageField(raw, strings.TrimPrefix(name, "field."), attrs, selfClose)
+	case name == "field":
+		return p.pageField(raw, attrs["name"], attrs, selfClose)
+	case name == "include":
+		file := attrs["filename"]
+		if file == "" {
+			file = attrs["file"]
 		}
-		fs := strings.SplitN(field, " ", 2)
-		field = strings.TrimPrefix(fs[0], `name=`)
-		field = replaceFieldName(strings.Trim(field, `'" `))
-		var unsafe string
-		if field == "content" || field == "body" {
-			unsafe = " | unsafe"
+		if file == "" {
+			return p.unsupported(raw, name, true, "include缺少filename")
 		}
-		switch field {
-		case "typename": //todo 前端使用标签实现.
-			field = `(isset(field) && field!=nil) ? (field["` + field + `"] ? field["` + field + `"] : field["Catname"]) : (isset(.Field["` + field + `"]) ? .Field["` + field + `"] : .Field["Catname"])`
-		case "typeurl", "typelink":
-			field = `(isset(field) && field!=nil) ? (field["` + field + `"] ? field["` + field + `"] : field["Url"]) : (isset(.Field["` + field + `"]) ? .Field["` + field + `"] : .Field["Url"])`
-		case "body":
-
-			field = `(isset(field) && field!=nil) ? (field["` + field + `"] ? field["` + field + `"] :( field["Content"] ?  field["Content"] : field["content"])) : (isset(.Field["` + field + `"]) ? .Field["` + field + `"] : (.Field["Content"] ? .Field["Content"] : .Field["content"]))`
-		default:
-			strArry := []rune(field)
-			if strArry[0] >= 97 && strArry[0] <= 122 {
-				strArry[0] -= 32
+		return `{{include "` + strings.TrimSuffix(strings.TrimSuffix(file, ".html"), ".htm") + `.jet"}}`
+	}
+	spec, ok := tagSpecs[name]
+	if !ok {
+		return p.unsupported(raw, name, selfClose, "不支持的标签")
+	}
+	p.useTag(spec.name)
+	var params []string
+	values := map[string]string{}
+	for _, k := range keys {
+		param, ok := spec.attrs[k]
+		if !ok {
+			if !ignoredAttrs[k] {
+				p.report(raw, "忽略属性"+k)
 			}
-			field = `(!isset(field)||field==nil) ? (isset(.Field["` + field + `"]) ? .Field["` + field + `"] : .Field["` + string(strArry) + `"]) : field["` + field + `"]`
+			continue
 		}
-		if len(fs) > 1 {
-			function := strings.Trim(fs[1], " ")
-			if strings.HasPrefix(function, "function=") {
-				function = strings.TrimPrefix(function, "function=")
-				function = strings.Trim(function, `'"`) // 按字符trim,直到不包括cutset
-				if !strings.HasPrefix(function, "html2text") {
-					if strings.HasPrefix(function, "GetDateTimeMk") {
-						function = strings.ReplaceAll(function, "GetDateTimeMk", "format_time")
-					}
-					return []byte(`{{` + strings.ReplaceAll(function, "@me", field) + unsafe + `}}`)
-				}
+		for _, kv := range p.param(spec.name, param, attrs[k]) {
+			kv := strings.SplitN(kv, "=", 2)
+			if _, ok := values[kv[0]]; !ok {
+				params = append(params, kv[0])
 			}
+			values[kv[0]] = kv[1]
 		}
-		return []byte(`{{` + field + unsafe + `}}`)
-	})
+	}
+	if spec.name == "type" && attrs["typeid"] == "" {
+		p.report(raw, "type标签需要指定typeid")
+		params, values["typeid"] = append(params, "typeid"), "0"
+	}
+	for i, k := range params {
+		params[i] = k + "=" + values[k]
+	}
+	call := spec.name + "(" + strings.Join(params, ", ") + ")"
+	if selfClose || spec.ctx == ctxNone {
+		return "{{yield " + call + "}}"
+	}
+	p.stack = append(p.stack, block{name: name, converted: true, ctx: spec.ctx})
+	return "{{yield " + call + " content}}"
 }
 
-// 解析标签
-func (p *Parser) parseDedeBlockTags() {
-	// 开始标签视情况替换内容(唯一恶心的地方就是标签类型非强类型)
-	p.data = regexp.MustCompile("{dede:(.+?)\\s+(?s:.+?)}").ReplaceAllFunc(p.data, func(i []byte) []byte {
-		matched := string(i)
-		if strings.Contains(matched, "runphp") ||
-			strings.Contains(matched, "include") ||
-			strings.Contains(matched, "global") ||
-			strings.Contains(matched, "sql=") {
-			return i
+// param 转换标签参数
+func (p *Parser) param(tag, key, val string) []string {
+	switch key {
+	case "limit":
+		vv := strings.SplitN(val, ",", 2)
+		if len(vv) == 2 {
+			return []string{"offset=" + p.value(vv[0]), "row=" + p.value(vv[1])}
 		}
-		matched = strings.TrimPrefix(matched, "{dede:")
-		matched = strings.TrimRight(matched, "}/")
-		// arclist
-		fs := strings.SplitN(matched, " ", 2)
-
-		if len(fs) == 1 {
-			fs = append(fs, " ")
+		return []string{"row=" + p.value(val)}
+	case "orderby":
+		switch val {
+		case "hot", "click":
+			val = "visit_count"
+		case "pubdate", "senddate":
+			val = "pubtime"
+		case "sortrank":
+			val = "listorder"
 		}
-		fs[1] = regexp.MustCompile(`\s+`).ReplaceAllString(fs[1], " ")
-		var pineTagAttrs []string
-		var tag string
-		var block bool
-		switch fs[0] {
-		case "type":
-			block = true
-			tag = fs[0]
-		case "likeart": // likearticle
-			block = true
-			tag = fs[0]
-		case "arclist":
-			block = true
-			tag = "artlist"
-		case "list":
-			block = true
-			tag = "list"
-		case "flink":
-			block = true
-			tag = "flink"
-		case "channel":
-			block = true
-			tag = "channel"
-			fs[1] = regexp.MustCompile(`currentstyle="(?s:.+?)"`).ReplaceAllStringFunc(fs[1], func(s string) string {
-				pineTagAttrs = append(pineTagAttrs, strings.ReplaceAll(s, "\n", ""))
-				return ""
-			})
-		case "channelartlist":
-			block = true
-			tag = "channelartlist"
-		case "prenext":
-			block = true
-			tag = fs[0]
-		default:
-			return i
+	case "get":
+		if val == "preimg" {
+			val = "pre"
 		}
-
-		attrs := strings.Split(fs[1], " ")
-		prevAttr := ""
-		for _, attr := range attrs {
-			if attr == "" {
-				continue
-			}
-			if !strings.Contains(attr, "=") { //
-				prevAttr = attr
-				continue
-			}
-			if strings.HasPrefix(attr, "=") {
-				attr = prevAttr + attr
-				prevAttr = ""
-			}
-			attrkv := strings.Split(attr, "=")
-			k := attrkv[0]
-			v := strings.Trim(attrkv[1], `'"`)
-			if strings.HasPrefix(k, "att") {
-				pineTagAttrs = append(pineTagAttrs, `flag="`+v+`"`)
-			} else if strings.Contains(k, "id") {
-				if strings.Contains(v, ",") || !regexp.MustCompile("^\\d+$").MatchString(v) {
-					pineTagAttrs = append(pineTagAttrs, k+`="`+v+`"`)
-				} else {
-					pineTagAttrs = append(pineTagAttrs, k+`=`+v)
-				}
-			} else if strings.Contains(k, "len") || k == "row" {
-				pineTagAttrs = append(pineTagAttrs, k+`=`+v)
-			} else if k == "limit" {
-				if strings.Contains(v, ",") {
-					vv := strings.Split(v, ",")
-					pineTagAttrs = append(pineTagAttrs, `row=`+vv[1]+``)
-					pineTagAttrs = append(pineTagAttrs, `offset=`+vv[0]+``)
-				} else {
-					pineTagAttrs = append(pineTagAttrs, `offset=`+v)
-				}
-			} else if k == "orderby" {
-				switch v {
-				case "hot", "click":
-					v = "visit_count"
-				case "pubdate":
-					v = "pubtime"
-				}
-				pineTagAttrs = append(pineTagAttrs, k+`="`+v+`"`)
-			} else {
-				pineTagAttrs = append(pineTagAttrs, k+`="`+v+`"`)
-			}
+	case "type":
+		if val == "self" || val == "top" {
+			val = "son"
+			p.report(tag, "channel标签只支持type=son")
 		}
+	}
+	return []string{key + "=" + p.value(val)}
+}
 
-		if block && tag != "" {
-			pine.Logger().Info("%s 替换标签内容 \n%s \n↓\n%s\n\n", p.src, string(i), `{{yield `+tag+`(`+strings.Join(pineTagAttrs, ", ")+`) content}}`)
-			return []byte(`{{yield ` + tag + `(` + strings.Join(pineTagAttrs, ", ") + `) content}}`)
+func (p *Parser) value(val string) string {
+	val = strings.TrimSpace(val)
+	if numRe.MatchString(val) {
+		return val
+	}
+	return strconv.Quote(val)
+}
+
+func (p *Parser) unsupported(raw, name string, selfClose bool, msg string) string {
+	p.report(raw, msg)
+	if !selfClose {
+		p.stack = append(p.stack, block{name: name})
+	}
+	return raw
+}
+
+func (p *Parser) closeTag(raw, name string) string {
+	name = strings.ToLower(name)
+	for i := len(p.stack) - 1; i >= 0; i-- {
+		if p.stack[i].name != name {
+			continue
 		}
-		return nil
-	})
+		b := p.stack[i]
+		p.stack = p.stack[:i]
+		if b.converted {
+			return "{{end}}"
+		}
+		return raw
+	}
+	p.report(raw, "多余的结束标签")
+	return raw
+}
 
-	// 结尾标签直接替换
-	p.data = regexp.MustCompile("{/dede:(type|likeart|arclist|channel|channelartlist|prenext|flink|list)}").ReplaceAll(p.data, []byte("{{end}}"))
-}
-
-// 解析标签内部field 如: [field:xxx /]
-func (p *Parser) parseFieldsInTagBlock() {
-	p.data = regexp.MustCompile("\\[field:\\s*([^\\]]+)?\\s*/\\]").ReplaceAllFunc(p.data, func(bts []byte) []byte {
-		bts = bytes.TrimPrefix(bts, []byte("[field:"))
-		bts = bytes.TrimSuffix(bts, []byte("/]"))
-		fieldInfo := strings.SplitN(string(bts), " ", 2)
-		field := replaceFieldName(fieldInfo[0])
-		switch field {
-		case "typename":
-			field = `field["` + field + `"] ? field["` + field + `"] : field["Catname"]`
-		case "typeurl", "typelink":
-			field = `field["` + field + `"] ? field["` + field + `"] : field["Url"]`
-		default:
-			field = `field["` + field + `"]`
+func (p *Parser) global(name string) string {
+	if val, ok := globalFields[name]; ok {
+		return `global["` + val + `"]`
+	}
+	p.report("{dede:global."+name+"/}", "全局变量没有对应的站点配置")
+	return `global["` + name + `"]`
+}
+
+// pageField 页面级字段 {dede:field.xxx/}
+func (p *Parser) pageField(raw, name string, attrs map[string]string, selfClose bool) string {
+	if !selfClose {
+		return p.unsupported(raw, "field", false, "不支持块级field标签")
+	}
+	name = strings.ToLower(name)
+	if name == "position" {
+		p.useTag("position")
+		return "{{yield position()}}"
+	}
+	if name == "pagelist" {
+		p.useTag("pagelist")
+		return "{{yield pagelist()}}"
+	}
+	if name == "" {
+		p.report(raw, "field标签缺少name")
+		return raw
+	}
+	var expr string
+	switch {
+	case p.context() == ctxCategory: // channelartlist内的{dede:field name='typename'/}
+		expr = categoryField("field", name)
+	case p.page == pageArticle:
+		expr = documentField(".Field", name)
+	case p.page == pageList:
+		expr = categoryField(".Field", name)
+	default:
+		expr = pageOtherField(name)
+	}
+	if expr == "" {
+		p.report(raw, "不支持的字段")
+		return raw
+	}
+	unsafe := ""
+	if name == "body" || name == "content" {
+		unsafe = " | unsafe"
+	}
+	return "{{" + p.function(raw, expr, attrs["function"]) + unsafe + "}}"
+}
+
+// field 标签内部字段 [field:xxx/]
+func (p *Parser) field(raw, name, attrStr string, selfClose bool) string {
+	_, attrs := parseAttrs(attrStr)
+	if !selfClose {
+		if _, ok := attrs["runphp"]; ok {
+			p.report(raw, "不支持runphp")
+		} else {
+			p.report(raw, "字段未闭合")
 		}
-		if len(fieldInfo) > 1 {
-			function := strings.Trim(fieldInfo[1], " ")
-			if strings.HasPrefix(function, "function=") {
-				function = strings.TrimPrefix(function, "function=")
-				function = strings.Trim(function, `'"`) // 按字符trim,直到不包括cutset
-				if !strings.HasPrefix(function, "html2text") {
-					// todo 貌似这里不生效
-					if strings.Contains(function, "GetDateTimeMk") {
-						function = strings.ReplaceAll(function, "GetDateTimeMk", "format_time")
-					}
-					return []byte(`{{` + strings.ReplaceAll(function, "@me", field) + `}}`)
-				}
-			}
+		return raw
+	}
+	name = strings.ToLower(name)
+	for _, b := range p.stack {
+		if !b.converted { // 不支持的标签内部字段原样保留
+			return raw
 		}
-		return []byte(`{{` + field + `}}`)
-	})
+	}
+	ctx := p.context()
+	if name == "global.autoindex" {
+		if ctx != ctxCategory {
+			p.report(raw, "autoindex只能用于channel标签内")
+			return raw
+		}
+		return "{{autoindex}}"
+	}
+	if strings.HasPrefix(name, "global.") {
+		return "{{" + p.global(strings.TrimPrefix(name, "global.")) + "}}"
+	}
+	var expr string
+	switch ctx {
+	case ctxDocument:
+		switch name {
+		case "textlink":
+			return `<a href="{{field["arcurl"]}}">{{field["title"]}}</a>`
+		case "imglink":
+			return `<a href="{{field["arcurl"]}}"><img src="{{field["thumb"]}}" alt="{{field["title"]}}"/></a>`
+		case "image":
+			return `<img src="{{field["thumb"]}}" alt="{{field["title"]}}"/>`
+		case "typelink":
+			return `<a href="{{field["typeurl"]}}">{{field["typename"]}}</a>`
+		}
+		expr = documentField("field", name)
+	case ctxCategory:
+		expr = categoryField("field", name)
+	case ctxLink:
+		if name == "link" {
+			return `<a href="{{field.Url}}" target="_blank">{{field.Name}}</a>`
+		}
+		expr = linkField(name)
+	default:
+		p.report(raw, "字段不在可转换的标签内")
+		return raw
+	}
+	if expr == "" {
+		p.report(raw, "不支持的字段")
+		return raw
+	}
+	return "{{" + p.function(raw, expr, attrs["function"]) + "}}"
+}
+
+// function 转换字段函数, @me 替换为字段值
+func (p *Parser) function(raw, expr, function string) string {
+	function = strings.TrimSpace(function)
+	if function == "" {
+		return expr
+	}
+	m := funcRe.FindStringSubmatch(function)
+	if m == nil {
+		p.report(raw, "无法解析的函数")
+		return expr
+	}
+	if m[1] == "html2text" || m[1] == "htmlspecialchars" {
+		return expr
+	}
+	fn, ok := funcMaps[m[1]]
+	if !ok || strings.Contains(m[2], "(") {
+		p.report(raw, "不支持的函数"+m[1])
+		return expr
+	}
+	args := strings.ReplaceAll(m[2], "'", `"`)
+	return fn + "(" + strings.ReplaceAll(args, "@me", expr) + ")"
 }
 
-func (p *Parser) Start() {
-	p.parseInclude()
-	p.parseFieldsInTagBlock()
-	//{dede:global.cfg_webname/}
-	p.parseGlobalSomeField()
-	p.parsePageTagField()
-	//p.parseTagBlock()
-	p.parseDedeBlockTags()
-	p.data = append([]byte(`{{import "tags.jet"}}
-`), p.data...)
-	// 生成文件
-	_ = os.MkdirAll(filepath.Dir(p.dst), os.ModePerm)
-	helper.PanicErr(os.WriteFile(p.dst, p.data, os.ModePerm))
+func documentField(v, name string) string {
+	switch name {
+	case "litpic", "picname":
+		name = "thumb"
+	case "pubdate":
+		name = "pubtime"
+	case "senddate":
+		name = "created_time"
+	case "body":
+		name = "content"
+	case "info":
+		name = "description"
+	case "writer":
+		name = "author"
+	case "source":
+		name = "from_url"
+	case "typeid":
+		name = "catid"
+	case "typeurl", "typelink":
+		name = "typeurl"
+	}
+	return v + `["` + name + `"]`
+}
+
+func categoryField(v, name string) string {
+	fields := map[string]string{
+		"id": "Catid", "typeid": "Catid", "reid": "Parentid", "topid": "Topid",
+		"typename": "Catname", "title": "Catname", "typelink": "Url", "typeurl": "Url",
+		"keywords": "Keywords", "description": "Description", "typeimg": "Thumb", "thumb": "Thumb", "typedir": "Dir",
+	}
+	if field, ok := fields[name]; ok {
+		return v + "." + field
+	}
+	return ""
+}
+
+func linkField(name string) string {
+	fields := map[string]string{"id": "Id", "url": "Url", "webname": "Name", "logo": "Logo", "msg": "Introduce"}
+	if field, ok := fields[name]; ok {
+		return "field." + field
+	}
+	return ""
+}
+
+// pageOtherField 公共模板无法确定页面类型, 兼容文档与栏目
+func pageOtherField(name string) string {
+	switch name {
+	case "typename":
+		return `isset(.Field["typename"]) ? .Field["typename"] : .Field["Catname"]`
+	case "typeurl", "typelink":
+		return `isset(.Field["typeurl"]) ? .Field["typeurl"] : .Field["Url"]`
+	case "body", "content":
+		return `isset(.Field["content"]) ? .Field["content"] : .Field["Content"]`
+	case "title":
+		return `isset(.Field["title"]) ? .Field["title"] : (isset(.Field["Title"]) ? .Field["Title"] : .Field["Catname"])`
+	}
+	name = strings.TrimPrefix(documentField("", name), `["`)
+	name = strings.TrimSuffix(name, `"]`)
+	upper := strings.ToUpper(name[:1]) + name[1:]
+	return `isset(.Field["` + name + `"]) ? .Field["` + name + `"] : .Field["` + upper + `"]`
 }
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/bytedance/sonic"
+	"github.com/spf13/cobra"
+	"github.com/xiusin/pinecms/cmd/dede"
+	"github.com/xiusin/pinecms/src/application/controllers/backend"
+	"github.com/xiusin/pinecms/src/application/models/tables"
+	"github.com/xiusin/pinecms/src/common/helper"
+	"github.com/xiusin/pinecms/src/common/importer"
+	"github.com/xiusin/pinecms/src/config"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "从其他系统导入模板与数据",
+}
+
+var importResumeCmd = &cobra.Command{
+	Use:   "resume",
+	Short: "继续执行中断的导入任务",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config.InitDB()
+		importer.InitInstall()
+		id, _ := cmd.Flags().GetInt64("job")
+		job := &tables.ImportJob{}
+		if ok, _ := helper.GetORM().ID(id).Get(job); !ok {
+			return errors.New("导入任务不存在")
+		}
+		return runImport(job)
+	},
+}
+
+func init() {
+	for _, source := range importer.Sources() {
+		importCmd.AddCommand(newImportSourceCmd(source))
+	}
+	importResumeCmd.Flags().Int64("job", 0, "导入任务ID")
+	importCmd.AddCommand(importResumeCmd)
+	importCmd.AddCommand(dede.TemplateCmd)
+}
+
+func newImportSourceCmd(source string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   source,
+		Short: "导入" + strings.ToUpper(source) + "文件",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			file, _ := cmd.Flags().GetString("file")
+			if len(file) == 0 {
+				return cmd.Usage()
+			}
+			config.InitDB()
+			importer.InitInstall()
+			job := &tables.ImportJob{Source: source, OriginName: filepath.Base(file)}
+			job.File, _ = filepath.Abs(file)
+			job.ModelId, _ = cmd.Flags().GetInt64("model")
+			job.Catid, _ = cmd.Flags().GetInt64("catid")
+			job.SiteId, _ = cmd.Flags().GetInt64("site")
+			job.SourceKey, _ = cmd.Flags().GetString("source-key")
+			job.IdField, _ = cmd.Flags().GetString("id-field")
+			if mapping, _ := cmd.Flags().GetStringToString("map"); len(mapping) > 0 {
+				job.Mapping, _ = sonic.MarshalString(mapping)
+			}
+			if _, err := helper.GetORM().InsertOne(job); err != nil {
+				return err
+			}
+			return runImport(job)
+		},
+	}
+	cmd.Flags().String("file", "", "导入文件路径")
+	cmd.Flags().Int64("model", 1, "导入的模型ID")
+	cmd.Flags().Int64("catid", 0, "默认栏目ID, 数据中没有栏目时使用")
+	cmd.Flags().Int64("site", 0, "所属站点ID")
+	cmd.Flags().String("source-key", "", "来源标识, 同一标识下的来源ID只导入一次")
+	if source != importer.SourceWXR {
+		cmd.Flags().StringToString("map", nil, "字段映射, 如 --map 标题=title,正文=content")
+		cmd.Flags().String("id-field", "", "来源ID所在列, 为空时按整行内容去重")
+	}
+	return cmd
+}
+
+func runImport(job *tables.ImportJob) error {
+	site, _ := config.SiteConfig()
+	fmt.Printf("开始导入任务 #%d %s\n", job.Id, job.OriginName)
+	err := importer.Run(job, backend.GetStorageEngine(site))
+	fmt.Printf("共 %d 条, 成功 %d, 失败 %d, 已导入跳过 %d\n", job.Total, job.Success, job.Failed, job.Skipped)
+	var items []tables.ImportItem
+	helper.GetORM().Where("job_id = ? AND status = ?", job.Id, false).Asc("line").Limit(50).Find(&items)
+	for _, item := range items {
+		fmt.Printf("  %s #%d %s: %s\n", item.Kind, item.Line, item.SourceId, item.Error)
+	}
+	if err != nil {
+		return fmt.Errorf("导入中断, 可使用 pinecms import resume --job=%d 继续: %w", job.Id, err)
+	}
+	return nil
+}
@@ -9,11 +9,13 @@ import (
 	"github.com/rivo/tview"
 	"github.com/spf13/cobra"
 
+	"github.com/xiusin/pinecms/src/common/dialect"
 	"github.com/xiusin/pinecms/src/common/helper"
+	"github.com/xiusin/pinecms/src/common/installer"
 	"github.com/xiusin/pinecms/src/config"
 )
 
-var initFilePath = "resources/pinecms.sql"
+var initFilePath = installer.SeedFile
 
 var initCmd = &cobra.Command{
 	Use:   "init",
@@ -23,8 +25,8 @@ var initCmd = &cobra.Command{
 		var submitted bool
 		var db config.DbConf
 
-		if db.Initialized() {
-			pine.Logger().Warn("项目已初始化")
+		if installer.Locked() {
+			pine.Logger().Warn("项目已初始化, 如需重新安装请删除 " + installer.LockFile)
 			return
 		}
 
@@ -40,22 +42,41 @@ var initCmd = &cobra.Command{
 		db.Db.Conf.Username = "root"
 		db.Db.Conf.Name = "pinecms"
 
+		drivers := dialect.Names()
+		driverIndex := 0
+		for i, name := range drivers {
+			if name == db.Db.DbDriver {
+				driverIndex = i
+			}
+		}
+		portField := tview.NewInputField().SetLabel("端口").SetText(db.Db.Conf.Port).SetFieldWidth(20).
+			SetChangedFunc(func(text string) {
+				db.Db.Conf.Port = text
+			})
+
 		pages := tview.NewPages()
 		pages.SetBackgroundColor(tcell.ColorDefault)
 		connForm := tview.NewForm().
+			AddDropDown("数据库类型", drivers, driverIndex, func(option string, _ int) {
+				if option == db.Db.DbDriver {
+					return
+				}
+				db.Db.DbDriver = option
+				if d, err := dialect.Get(option); err == nil {
+					portField.SetText(d.DefaultPort())
+				}
+			}).
 			AddInputField("服务器地址", db.Db.Conf.ServeIp, 20, nil, func(text string) {
 				db.Db.Conf.ServeIp = text
 			}).
-			AddInputField("端口", db.Db.Conf.Port, 20, nil, func(text string) {
-				db.Db.Conf.Port = text
-			}).
+			AddFormItem(portField).
 			AddInputField("账号", db.Db.Conf.Username, 20, nil, func(text string) {
 				db.Db.Conf.Username = text
 			}).
 			AddPasswordField("密码", db.Db.Conf.Password, 20, '*', func(text string) {
 				db.Db.Conf.Password = text
 			}).
-			AddInputField("数据库", db.Db.Conf.Name, 20, nil, func(text string) {
+			AddInputField("数据库(SQLite填写文件路径)", db.Db.Conf.Name, 20, nil, func(text string) {
 				db.Db.Conf.Name = text
 			}).
 			AddInputField("表前缀", db.Db.DbPrefix, 20, nil, func(text string) {
@@ -68,7 +89,7 @@ var initCmd = &cobra.Command{
 						alert(pages, "连接数据库异常: "+err.(error).Error())
 					}
 				}()
-				if !db.Db.Conf.Check() {
+				if !db.Db.Valid() {
 					alert(pages, "数据库配置信息必须填写")
 				} else if !submitted {
 					orm := config.InitDB(&db)
@@ -76,12 +97,19 @@ var initCmd = &cobra.Command{
 						alert(pages, "连接数据库错误: "+err.Error())
 						return
 					}
-					if _, err := orm.ImportFile(initFilePath); err != nil {
+					if err := dialect.ImportDump(orm, initFilePath, db.Db.DbPrefix); err != nil {
 						alert(pages, "导入数据库失败: "+err.Error())
 						return
 					}
+					if err := installer.GenerateKeys(); err != nil {
+						alert(pages, "生成密钥失败: "+err.Error())
+						return
+					}
 					submitted = true
 					err := db.BuildYaml()
+					if err == nil {
+						err = installer.Lock()
+					}
 					if err != nil {
 						submitted = false
 						alert(pages, "保存失败, "+err.Error())
@@ -100,7 +128,7 @@ var initCmd = &cobra.Command{
 			SetButtonsAlign(tview.AlignCenter)
 
 		connForm.SetBorder(true).SetTitle("PineCms Initializer").SetTitleAlign(tview.AlignCenter)
-		flex := tview.NewFlex().AddItem(Center(40, 18, connForm), 0, 3, true)
+		flex := tview.NewFlex().AddItem(Center(50, 20, connForm), 0, 3, true)
 		pages.AddPage("base", flex, true, true)
 
 		helper.PanicErr(app.SetRoot(pages, true).EnableMouse(true).Run())
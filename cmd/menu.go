@@ -1,8 +1,11 @@
 package cmd
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
+
 	"github.com/xiusin/pine"
 
 	. "github.com/xiusin/pinecms/src/config"
@@ -24,6 +27,12 @@ var menuCmd = &cobra.Command{
 		table, _ := cmd.Flags().GetString("table")
 		force, _ := cmd.Flags().GetBool("force")
 		menu, _ := cmd.Flags().GetString("menu")
+		if seed, _ := cmd.Flags().GetString("seed"); seed != "" {
+			if err := seedMenus(seed, force); err != nil {
+				pine.Logger().Error(err.Error())
+			}
+			return
+		}
 		if table == "" {
 			cmd.Help()
 			return
@@ -107,7 +116,68 @@ var menuCmd = &cobra.Command{
 	},
 }
 
+// seedMenus 导入 pinecms src 生成的菜单权限, 按权限标识新增, 已存在的菜单需 --force 覆盖
+func seedMenus(path string, force bool) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("%s 不存在, 请先执行 pinecms src 生成", path)
+	} else if err != nil {
+		return err
+	}
+	var menus []tables.Menu
+	if err = json.Unmarshal(data, &menus); err != nil {
+		return err
+	}
+	var created, updated int
+	_, err = Orm().Transaction(func(session *xorm.Session) (any, error) {
+		var save func(menu tables.Menu, parentId int64) error
+		save = func(menu tables.Menu, parentId int64) error {
+			children := menu.Children
+			menu.Parentid = parentId
+			exist := &tables.Menu{}
+			has, err := session.Where("identification = ?", menu.Identification).Get(exist)
+			if err != nil {
+				return err
+			}
+			switch {
+			case !has:
+				menu.Id = 0
+				if _, err = session.Insert(&menu); err != nil {
+					return err
+				}
+				created++
+			case force:
+				menu.Id = exist.Id
+				if _, err = session.ID(exist.Id).Cols("name", "parentid", "type", "router", "perms").Update(&menu); err != nil {
+					return err
+				}
+				updated++
+			default:
+				menu.Id = exist.Id
+			}
+			for _, child := range children {
+				if err = save(child, menu.Id); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		for _, menu := range menus {
+			if err := save(menu, 0); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+	if err != nil {
+		return err
+	}
+	pine.Logger().Info(fmt.Sprintf("导入菜单完成, 新增%d个, 覆盖%d个", created, updated))
+	return nil
+}
+
 func init() {
+	menuCmd.Flags().String("seed", "", "导入注解生成的菜单权限, 如 resources/menu.gen.json")
 	menuCmd.Flags().String("table", "", "数据库表名")
 	menuCmd.Flags().String("menu", "", "顶级菜单名称")
 	menuCmd.Flags().Bool("force", false, "是否强制生成")
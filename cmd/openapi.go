@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/xiusin/pinecms/src/application/controllers/middleware/apidoc"
+	"github.com/xiusin/pinecms/src/application/controllers/middleware/apidoc/export"
+	"github.com/xiusin/pinecms/src/config"
+	"github.com/xiusin/pinecms/src/router"
+)
+
+var openapiCmd = &cobra.Command{
+	Use:   "openapi",
+	Short: "导出 OpenAPI 3.1 接口文档",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config.InitDB()
+		out, _ := cmd.Flags().GetString("out")
+		server, _ := cmd.Flags().GetString("server")
+		router.RegisterApiResources(router.AdminRouteGroups())
+		var servers []export.Server
+		if len(server) > 0 {
+			servers = append(servers, export.Server{URL: server})
+		}
+		doc, err := apidoc.OpenAPI(servers...)
+		if err != nil {
+			return err
+		}
+		data, err := json.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err = os.WriteFile(out, data, 0o644); err != nil {
+			return err
+		}
+		fmt.Printf("共导出 %d 个路径, %d 个结构体: %s\n", len(doc.Paths), len(doc.Components.Schemas), out)
+		return nil
+	},
+}
+
+func init() {
+	openapiCmd.Flags().String("out", "openapi.json", "文档保存路径")
+	openapiCmd.Flags().String("server", "http://localhost:2019", "文档中的服务地址, 为空时不设置")
+}
@@ -84,13 +84,16 @@ func (p *[$s]) Init(app *pine.Application, backend *pine.Router) {
 var [$s]Plugin = [$s]{}`
 
 type Config struct {
-	Name        string `json:"name"`
-	Author      string `json:"author"`
-	Contact     string `json:"contact"`
-	Description string `json:"description"`
-	Version     string `json:"version"`
-	Page        string `json:"page"` // 页面说明
-	Error       string `json:"-"`    // 记录加载信息
+	Name         string   `json:"name"`
+	Author       string   `json:"author"`
+	Contact      string   `json:"contact"`
+	Description  string   `json:"description"`
+	Version      string   `json:"version"`
+	Page         string   `json:"page"`         // 页面说明
+	Runtime      string   `json:"runtime"`      // 运行方式: so(默认) 或 rpc(独立进程)
+	Exec         string   `json:"exec"`         // rpc插件的可执行文件名, 默认与目录同名
+	Capabilities []string `json:"capabilities"` // 声明使用的系统能力: orm cache storage mailer search config
+	Error        string   `json:"-"`            // 记录加载信息
 }
 
 var makePluginCmd = &cobra.Command{
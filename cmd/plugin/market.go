@@ -0,0 +1,145 @@
+package plugin
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/bytedance/sonic"
+	"github.com/spf13/cobra"
+	"github.com/xiusin/pinecms/src/application/plugins/market"
+	"github.com/xiusin/pinecms/src/common/helper"
+)
+
+var keygenCmd = &cobra.Command{
+	Use:   "keygen",
+	Short: "生成插件签名密钥对",
+	Run: func(cmd *cobra.Command, args []string) {
+		pub, priv, err := market.GenerateKey()
+		helper.PanicErr(err)
+		fmt.Println("公钥(配置到 application.yml plugin_market.public_keys):")
+		fmt.Println(pub)
+		fmt.Println("私钥(用于 pinecms plugin publish, 请妥善保管):")
+		fmt.Println(priv)
+	},
+}
+
+var publishCmd = &cobra.Command{
+	Use:   "publish",
+	Short: "打包插件目录, 签名后发布到本地仓库目录并更新索引",
+	Run: func(cmd *cobra.Command, args []string) {
+		name, _ := cmd.Flags().GetString("name")
+		version, _ := cmd.Flags().GetString("version")
+		repo, _ := cmd.Flags().GetString("repo")
+		if len(name) == 0 || len(version) == 0 || len(repo) == 0 {
+			_ = cmd.Usage()
+			return
+		}
+		if !market.ValidName(name) {
+			helper.PanicErr(errors.New("插件名只能包含小写字母、数字、下划线与中划线"))
+		}
+		if len(market.Canonical(version)) == 0 {
+			helper.PanicErr(errors.New("无效的版本号" + version))
+		}
+		key, _ := cmd.Flags().GetString("key")
+		if len(key) == 0 {
+			key = os.Getenv("PINECMS_PLUGIN_KEY")
+		}
+		dir, _ := cmd.Flags().GetString("dir")
+		if len(dir) == 0 {
+			dir = filepath.Join(outputPluginDir, name)
+		}
+
+		archive := fmt.Sprintf("%s/%s-%s.tar.gz", name, name, strings.TrimPrefix(version, "v"))
+		helper.PanicErr(os.MkdirAll(filepath.Join(repo, name), os.ModePerm))
+		sum, size, err := market.Pack(dir, filepath.Join(repo, filepath.FromSlash(archive)))
+		helper.PanicErr(err)
+		signature, err := market.Sign(key, name, version, sum)
+		helper.PanicErr(err)
+
+		release := &market.Release{
+			Version:   version,
+			Archive:   archive,
+			Size:      size,
+			Sha256:    hex.EncodeToString(sum),
+			Signature: signature,
+		}
+		release.PineCMS, _ = cmd.Flags().GetString("pinecms")
+		release.Go, _ = cmd.Flags().GetString("go")
+		release.Runtime, _ = cmd.Flags().GetString("runtime")
+		release.Platforms, _ = cmd.Flags().GetStringSlice("platform")
+		deps, _ := cmd.Flags().GetStringToString("require")
+		if len(deps) > 0 {
+			release.Dependencies = deps
+		}
+		if conf, err := os.ReadFile(filepath.Join(dir, configName)); err == nil && len(release.Runtime) == 0 {
+			var c Config
+			if sonic.Unmarshal(conf, &c) == nil {
+				release.Runtime = c.Runtime
+			}
+		}
+		helper.PanicErr(updateIndex(repo, name, release))
+		fmt.Println("发布插件", name, version, "成功:", archive)
+	},
+}
+
+var repoCmd = &cobra.Command{
+	Use:   "repo",
+	Short: "以HTTP方式提供本地插件仓库目录, 用于测试插件市场",
+	Run: func(cmd *cobra.Command, args []string) {
+		repo, _ := cmd.Flags().GetString("repo")
+		addr, _ := cmd.Flags().GetString("addr")
+		if _, err := os.Stat(filepath.Join(repo, market.IndexFile)); err != nil {
+			helper.PanicErr(errors.New("仓库目录缺少" + market.IndexFile))
+		}
+		fmt.Printf("插件仓库: http://%s/\n", addr)
+		helper.PanicErr(http.ListenAndServe(addr, http.FileServer(http.Dir(repo))))
+	},
+}
+
+// updateIndex 写入或替换索引中的插件版本
+func updateIndex(repo, name string, release *market.Release) error {
+	indexPath := filepath.Join(repo, market.IndexFile)
+	idx := &market.Index{Name: filepath.Base(repo)}
+	if content, err := os.ReadFile(indexPath); err == nil {
+		if err := sonic.Unmarshal(content, idx); err != nil {
+			return err
+		}
+	}
+	plugin := idx.Find(name)
+	if plugin == nil {
+		plugin = &market.Plugin{Name: name, Title: name}
+		idx.Plugins = append(idx.Plugins, plugin)
+	}
+	plugin.Releases = slices.DeleteFunc(plugin.Releases, func(r *market.Release) bool {
+		return market.Compare(r.Version, release.Version) == 0
+	})
+	plugin.Releases = append(plugin.Releases, release)
+	idx.Updated = helper.NowDate(helper.TimeFormat)
+	content, err := sonic.ConfigStd.MarshalIndent(idx, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(indexPath, content, 0644)
+}
+
+func init() {
+	publishCmd.Flags().String("name", "", "插件名称, 即插件目录名")
+	publishCmd.Flags().String("version", "", "版本号")
+	publishCmd.Flags().String("repo", "", "本地仓库目录")
+	publishCmd.Flags().String("dir", "", "插件目录, 默认 plugins/{name}")
+	publishCmd.Flags().String("key", "", "签名私钥, 默认读取环境变量 PINECMS_PLUGIN_KEY")
+	publishCmd.Flags().String("pinecms", "", "兼容的系统版本约束, 如 \">=0.2.0 <1.0.0\"")
+	publishCmd.Flags().String("go", "", "兼容的Go版本约束, 如 \"=1.23.4\"")
+	publishCmd.Flags().String("runtime", "", "运行方式 so 或 rpc, 默认读取插件 config.json")
+	publishCmd.Flags().StringSlice("platform", nil, "支持的平台, 如 linux/amd64")
+	publishCmd.Flags().StringToString("require", nil, "依赖插件, 如 task=^1.0.0")
+
+	repoCmd.Flags().String("repo", "", "本地仓库目录")
+	repoCmd.Flags().String("addr", "127.0.0.1:2020", "监听地址")
+}
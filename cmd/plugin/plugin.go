@@ -10,5 +10,5 @@ var Cmd = &cobra.Command{
 }
 
 func init() {
-	Cmd.AddCommand(makePluginCmd, buildPluginCmd)
+	Cmd.AddCommand(makePluginCmd, buildPluginCmd, keygenCmd, publishCmd, repoCmd)
 }
@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"os"
+
 	"github.com/spf13/cobra"
 
 	"github.com/xiusin/pinecms/cmd/crud"
@@ -24,13 +26,18 @@ var rootCmd = &cobra.Command{
 |_|     		      version: ` + version.Version,
 
 	Run: func(cmd *cobra.Command, args []string) {
+		if !config.DB().Initialized() && !server.Install() {
+			return
+		}
 		config.InitDB()
 		server.Server()
 	},
 }
 
 func Execute() {
-	_ = rootCmd.Execute()
+	if err := rootCmd.Execute(); err != nil {
+		os.Exit(1)
+	}
 }
 
 func init() {
@@ -41,7 +48,11 @@ func init() {
 	rootCmd.AddCommand(crud.Cmd)
 	rootCmd.AddCommand(menuCmd)
 	rootCmd.AddCommand(dede.Cmd)
+	rootCmd.AddCommand(importCmd)
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(importSiteCmd)
 	rootCmd.AddCommand(annotationsCmd)
+	rootCmd.AddCommand(openapiCmd)
 
 	server.InitApp()
 }
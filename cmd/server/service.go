@@ -37,6 +37,10 @@ func (service *Service) Manage(args []string, usage string) (string, error) {
 	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
 
 	go func() {
+		if !config.DB().Initialized() && !server.Install() {
+			interrupt <- os.Interrupt
+			return
+		}
 		config.InitDB()
 		fmt.Println("start server...")
 		server.Server()
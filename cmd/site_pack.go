@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/xiusin/pinecms/src/common/sitepack"
+	"github.com/xiusin/pinecms/src/config"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "导出站点迁移包(模型, 栏目, 配置, 内容与上传文件)",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config.InitDB()
+		out, _ := cmd.Flags().GetString("out")
+		if len(out) == 0 {
+			out = filepath.Join(config.RuntimePath("export"), "pinecms-"+time.Now().Format("20060102150405")+".zip")
+		}
+		site, _ := config.SiteConfig()
+		manifest, err := sitepack.Export(out, site)
+		if err != nil {
+			return err
+		}
+		for _, table := range manifest.Tables {
+			fmt.Printf("  %-24s %d 行\n", table.Name, table.Rows)
+		}
+		fmt.Printf("共导出 %d 张表, %d 个文件(缺失 %d 个): %s\n", len(manifest.Tables), manifest.Files, manifest.Missing, out)
+		return nil
+	},
+}
+
+var importSiteCmd = &cobra.Command{
+	Use:   "import-site",
+	Short: "从迁移包导入站点数据",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file, _ := cmd.Flags().GetString("file")
+		if len(file) == 0 {
+			return cmd.Usage()
+		}
+		conflict, _ := cmd.Flags().GetString("conflict")
+		config.InitDB()
+		site, _ := config.SiteConfig()
+		result, err := sitepack.Import(file, conflict, site)
+		if result != nil {
+			for _, table := range result.Tables {
+				created := ""
+				if table.Created {
+					created = "(新建)"
+				}
+				fmt.Printf("  %-24s 新增 %d, 覆盖 %d, 跳过 %d, 失败 %d %s\n",
+					table.Name, table.Inserted, table.Updated, table.Skipped, table.Failed, created)
+				for _, msg := range table.Errors {
+					fmt.Println("    " + msg)
+				}
+			}
+			fmt.Printf("还原上传文件 %d 个, 如服务正在运行请重启以刷新缓存\n", result.Files)
+		}
+		return err
+	},
+}
+
+func init() {
+	exportCmd.Flags().String("out", "", "迁移包保存路径, 默认 runtime/export/pinecms-时间.zip")
+	importSiteCmd.Flags().String("file", "", "迁移包路径")
+	importSiteCmd.Flags().String("conflict", sitepack.ConflictSkip, "主键冲突处理: skip 跳过, overwrite 覆盖, truncate 导入前清空目标表")
+}
@@ -2,112 +2,512 @@ package rest
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"net/http"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"text/template"
+
+	"github.com/xiusin/pinecms/src/application/models/tables"
+	"github.com/xiusin/pinecms/src/common/route"
 )
 
 const (
-	handlerPackage = `package %s
-`
-	handlerImports = `
+	genFile     = "routes.gen.go"
+	centralFile = "src/router/" + genFile
+	menuFile    = "resources/menu.gen.json"
+)
+
+const (
+	handlerTemplate = `package {{ .Package }}
+
 import (
 	"github.com/xiusin/pine"
+	"github.com/xiusin/pinecms/src/common/route"
+{{- range $alias, $path := .Imports }}
+	{{ $alias }} "{{ $path }}"
+{{- end }}
 )
-`
-	handlerTemplate = `
-func (c *{{ .Receiver }}) RegisterRoute(b pine.IRouterWrapper) {
-	{{range .Handlers}}b.{{ .Method }}("{{ .Route }}", "{{ .Handler }}"){{end}}
+
+// AnnotatedRoutes 注解路由表
+var AnnotatedRoutes = []route.Route{
+{{- range .Routes }}
+	{Mount: {{ printf "%q" .Mount }}, Method: {{ printf "%q" .Method }}, Path: {{ printf "%q" .Path }}, Controller: {{ printf "%q" .Controller }}, Handler: {{ printf "%q" .Handler }}
+{{- if .Middleware }}, Middleware: []string{ {{- range $i, $m := .Middleware }}{{ if $i }}, {{ end }}{{ printf "%q" $m }}{{ end -}} }{{ end }}
+{{- if .Permission }}, Permission: {{ printf "%q" .Permission }}{{ end }}
+{{- if .Title }}, Title: {{ printf "%q" .Title }}{{ end }}
+{{- if .Group }}, Group: {{ printf "%q" .Group }}{{ end }}
+{{- if .Request }}, Request: {{ .Request }}{{ end }}
+{{- if .Response }}, Response: {{ .Response }}{{ end }}},
+{{- end }}
+}
+{{ range .Controllers }}
+func (c *{{ . }}) RegisterRoute(b pine.IRouterWrapper) {
+	route.Bind(b, AnnotatedRoutes, "{{ . }}")
+}
+{{ end }}`
+
+	centralTemplate = `package router
+
+import (
+	"github.com/xiusin/pinecms/src/common/route"
+{{- range $alias, $path := .Imports }}
+	{{ $alias }} "{{ $path }}"
+{{- end }}
+)
+
+// routeGroups 注解控制器, 前缀用于登记增删改查接口文档
+var routeGroups = []RouteGroup{
+{{- range .Groups }}
+	{Mount: {{ printf "%q" .Mount }}, Prefix: {{ printf "%q" .Prefix }}, Handler: new({{ .Alias }}.{{ .Name }})},
+{{- end }}
+}
+
+// annotatedRoutes 注解路由表
+func annotatedRoutes() []route.Route {
+	var routes []route.Route
+{{- range .Packages }}
+	routes = append(routes, {{ . }}.AnnotatedRoutes...)
+{{- end }}
+	return routes
 }
 `
 )
 
-type outputKey struct {
-	pkg string
-	dir string
+// genRoute 模板中的路由, 请求与响应为类型字面量
+type genRoute struct {
+	route.Route
+	Request  string
+	Response string
 }
 
-type handlerTemplateMethods struct {
-	Method  string
-	Route   string
-	Handler string
+type genGroup struct {
+	Mount, Prefix, Alias, Name string
 }
-type handlerTemplateParams struct {
-	Receiver string
-	Path     string
-	Handlers []handlerTemplateMethods
+
+// pkgInfo 解析包内结构体的方法与嵌入字段, 用于推断未注解的方法路由
+type pkgInfo struct {
+	name    string
+	methods map[string][]string // 接收者 => 导出方法
+	embeds  map[string][]string // 结构体 => 同包内嵌入的结构体
+	manual  map[string]bool     // 手写了 RegisterRoute 的结构体
 }
 
 type output struct {
-	files map[outputKey]*bytes.Buffer
-	tmp   *template.Template
+	root   string
+	module string
+	pkgs   map[string]*pkgInfo
+	files  map[string][]byte
 }
 
-func newOutput() (*output, error) {
-	tmp, err := template.New("handler").Parse(handlerTemplate)
+// newOutput 由扫描目录向上查找 go.mod, 生成的文件以模块根目录为准
+func newOutput(root string) (*output, error) {
+	root, err := filepath.Abs(root)
 	if err != nil {
 		return nil, err
 	}
+	var data []byte
+	for {
+		if data, err = os.ReadFile(filepath.Join(root, "go.mod")); err == nil {
+			break
+		}
+		parent := filepath.Dir(root)
+		if parent == root {
+			return nil, errors.New("unable to find go.mod")
+		}
+		root = parent
+	}
+	m := regexp.MustCompile(`(?m)^module\s+(\S+)`).FindSubmatch(data)
+	if m == nil {
+		return nil, errors.New("unable to find module path in go.mod")
+	}
 	return &output{
-		files: make(map[outputKey]*bytes.Buffer),
-		tmp:   tmp,
+		root:   root,
+		module: string(m[1]),
+		pkgs:   map[string]*pkgInfo{},
+		files:  map[string][]byte{},
 	}, nil
+}
 
+func (o *output) get() map[string][]byte {
+	return o.files
 }
 
-func (o *output) append(meta handlerMetadata, handlers handlerMapping) error {
-	outKey := outputKey{
-		pkg: meta.pkg,
-		dir: meta.dir,
+// build 生成各包的路由表、中心路由表与菜单种子数据, 路由重复时返回错误
+func (o *output) build(controllers map[string]*controller) error {
+	keys := make([]string, 0, len(controllers))
+	for k := range controllers {
+		keys = append(keys, k)
 	}
-	b := o.upsertBuffer(outKey)
+	sort.Strings(keys)
 
-	htm := []handlerTemplateMethods{}
-	for m, h := range handlers.mapping {
-		ss := strings.Split(h, sp)
-		htm = append(htm, handlerTemplateMethods{Method: httpMethodConstant(m), Handler: ss[0], Route: ss[1]})
+	var (
+		all     []route.Route
+		groups  []genGroup
+		menus   []tables.Menu
+		dirs    []string
+		byDir   = map[string][]genRoute{}
+		names   = map[string][]string{}
+		imports = map[string]map[string]string{}
+		aliases = map[string]string{} // 包路径 => 中心路由表中的别名
+		used    = map[string]bool{}
+	)
+	for _, key := range keys {
+		c := controllers[key]
+		if !c.annotated {
+			continue
+		}
+		info, err := o.parse(c.dir)
+		if err != nil {
+			return err
+		}
+		if info.manual[c.name] {
+			return fmt.Errorf("%s 已手写 RegisterRoute, 改用注解后需删除", c.name)
+		}
+		routes, err := o.routes(controllers, info, c, used)
+		if err != nil {
+			return err
+		}
+		if _, exist := byDir[c.dir]; !exist {
+			dirs = append(dirs, c.dir)
+			imports[c.dir] = map[string]string{}
+		}
+		for _, r := range routes {
+			all = append(all, r.Route)
+		}
+		byDir[c.dir] = append(byDir[c.dir], routes...)
+		names[c.dir] = append(names[c.dir], c.name)
+		for _, h := range c.handlers {
+			for alias, path := range h.imports {
+				imports[c.dir][alias] = path
+			}
+		}
+
+		pkgPath, err := o.importPath(c.dir)
+		if err != nil {
+			return err
+		}
+		alias, exist := aliases[pkgPath]
+		if !exist {
+			alias = info.name
+			for i := 2; containsValue(aliases, alias); i++ {
+				alias = info.name + strconv.Itoa(i)
+			}
+			aliases[pkgPath] = alias
+		}
+		for _, prefix := range prefixes(c.rest.Path) {
+			groups = append(groups, genGroup{Mount: c.rest.Mount, Prefix: prefix, Alias: alias, Name: c.name})
+		}
+		if len(c.rest.Menu) > 0 {
+			menus = append(menus, menu(c, routes))
+		}
+	}
+	for key, c := range controllers {
+		if !c.annotated && !used[key] && len(c.handlers) > 0 {
+			return fmt.Errorf("%s 的方法带有 @Rest 注解, 但结构体未注解也未被注解的控制器嵌入", c.name)
+		}
 	}
-	toTemplate := handlerTemplateParams{
-		Receiver: meta.structName,
-		Path:     handlers.path,
-		Handlers: htm,
+	if conflicts := route.Conflicts(all); len(conflicts) > 0 {
+		return errors.New("路由冲突:\n" + strings.Join(conflicts, "\n"))
+	}
+
+	for _, dir := range dirs {
+		data, err := render(handlerTemplate, map[string]any{
+			"Package":     o.pkgs[dir].name,
+			"Imports":     imports[dir],
+			"Routes":      byDir[dir],
+			"Controllers": names[dir],
+		})
+		if err != nil {
+			return err
+		}
+		o.files[filepath.Join(dir, genFile)] = data
 	}
 
-	err := o.tmp.Execute(b, toTemplate)
+	central := map[string]string{}
+	var packages []string
+	for path, alias := range aliases {
+		central[alias] = path
+		packages = append(packages, alias)
+	}
+	sort.Strings(packages)
+	data, err := render(centralTemplate, map[string]any{"Imports": central, "Groups": groups, "Packages": packages})
 	if err != nil {
 		return err
 	}
+	o.files[filepath.Join(o.root, centralFile)] = data
+
+	if menus == nil {
+		menus = []tables.Menu{}
+	}
+	if data, err = json.Marshal(menus); err != nil {
+		return err
+	}
+	o.files[filepath.Join(o.root, menuFile)] = data
 	return nil
 }
 
-func (o *output) get() map[string][]byte {
-	out := map[string][]byte{}
-	for k, buf := range o.files {
-		out[k.dir+"/"+".handlers.gen.go"] = buf.Bytes()
+// routes 控制器的路由: 注解的方法按注解生成, 其余按 pine 的方法名约定(含嵌入结构体的方法)生成
+func (o *output) routes(controllers map[string]*controller, info *pkgInfo, c *controller, used map[string]bool) ([]genRoute, error) {
+	owners := map[string]string{} // 方法 => 声明方法的结构体
+	var methods []string
+	var visit func(name string)
+	visit = func(name string) {
+		used[filepath.Join(c.dir, name)] = true
+		for _, m := range info.methods[name] {
+			if _, exist := owners[m]; !exist {
+				owners[m] = name
+				methods = append(methods, m)
+			}
+		}
+		for _, embed := range info.embeds[name] {
+			visit(embed)
+		}
+	}
+	visit(c.name)
+	sort.Strings(methods)
+
+	for name := range c.handlers {
+		if _, exist := owners[name]; !exist {
+			return nil, fmt.Errorf("%s.%s 不是导出的方法, 无法注册路由", c.name, name)
+		}
+	}
+
+	var routes []genRoute
+	for _, name := range methods {
+		owner := controllers[filepath.Join(c.dir, owners[name])]
+		var h handler
+		explicit := false
+		if owner != nil {
+			h, explicit = owner.handlers[name]
+		}
+		method, path, ok := inferMethod(name)
+		switch {
+		case explicit:
+			if len(h.rest.Method) > 0 {
+				method = h.rest.Method
+			} else if !ok {
+				method = "GET"
+			}
+			if len(h.rest.Route) > 0 {
+				path = h.rest.Route
+			} else if !ok {
+				path = "/" + upperCharToUnderLine(name)
+			}
+		case !c.rest.Magic:
+			continue
+		case ok:
+		case name == "Index":
+			method, path = route.MethodAny, ""
+		default:
+			continue
+		}
+		group := h.rest.Group
+		if len(group) == 0 {
+			group = c.rest.Group
+		}
+		for _, prefix := range prefixes(c.rest.Path) {
+			full := prefix + path
+			if len(full) == 0 {
+				full = "/"
+			}
+			routes = append(routes, genRoute{
+				Route: route.Route{
+					Mount:      c.rest.Mount,
+					Method:     method,
+					Path:       full,
+					Controller: c.name,
+					Handler:    name,
+					Middleware: append(split(c.rest.Middleware), split(h.rest.Middleware)...),
+					Permission: h.rest.Perm,
+					Title:      h.rest.Title,
+					Group:      group,
+				},
+				Request:  literal(h.rest.Req),
+				Response: literal(h.rest.Resp),
+			})
+		}
+	}
+	sort.SliceStable(routes, func(i, j int) bool {
+		return routes[i].Path < routes[j].Path
+	})
+	return routes, nil
+}
+
+// menu 控制器菜单, 子菜单为各路由的权限标识
+func menu(c *controller, routes []genRoute) tables.Menu {
+	prefix := prefixes(c.rest.Path)[0]
+	parent := tables.Menu{
+		Name:           c.rest.Menu,
+		Display:        true,
+		Type:           1,
+		Router:         prefix,
+		Identification: "menu:" + strings.ReplaceAll(strings.Trim(prefix, "/"), "/", ":"),
+	}
+	actions := map[string]string{"list": "列表", "add": "新增", "edit": "编辑", "update": "编辑", "delete": "删除", "info": "查看", "select": "下拉选项"}
+	seen := map[string]bool{}
+	for _, r := range routes {
+		if !strings.HasPrefix(r.Path, prefix) {
+			continue
+		}
+		segments := strings.Split(strings.Trim(r.Path, "/"), "/")
+		if len(segments) < 2 {
+			continue
+		}
+		perm := r.Permission
+		if len(perm) == 0 {
+			perm = segments[0] + ":" + segments[1] // 与 casbin 按路径前两段校验一致
+		}
+		if seen[perm] {
+			continue
+		}
+		seen[perm] = true
+		obj, act, _ := strings.Cut(perm, ":")
+		name := r.Title
+		if len(name) == 0 {
+			if name = actions[act]; len(name) == 0 {
+				name = r.Handler
+			}
+		}
+		parent.Children = append(parent.Children, tables.Menu{
+			Name:           name,
+			Type:           2,
+			Router:         "/" + obj + "/" + act,
+			Perms:          perm,
+			Identification: perm,
+		})
+	}
+	return parent
+}
+
+// parse 解析目录内的结构体方法与嵌入字段, 跳过生成的文件与测试
+func (o *output) parse(dir string) (*pkgInfo, error) {
+	if info, exist := o.pkgs[dir]; exist {
+		return info, nil
+	}
+	pkgs, err := parser.ParseDir(token.NewFileSet(), dir, func(fi os.FileInfo) bool {
+		return fi.Name() != genFile && !strings.HasSuffix(fi.Name(), "_test.go")
+	}, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+	info := &pkgInfo{methods: map[string][]string{}, embeds: map[string][]string{}, manual: map[string]bool{}}
+	for name, pkg := range pkgs {
+		info.name = name
+		for _, f := range pkg.Files {
+			for _, decl := range f.Decls {
+				switch d := decl.(type) {
+				case *ast.FuncDecl:
+					recv := MethodReceiver(d)
+					if len(recv) == 0 || !d.Name.IsExported() {
+						continue
+					}
+					if d.Name.Name == "RegisterRoute" {
+						info.manual[recv] = true
+						continue
+					}
+					info.methods[recv] = append(info.methods[recv], d.Name.Name)
+				case *ast.GenDecl:
+					for _, spec := range d.Specs {
+						ts, ok := spec.(*ast.TypeSpec)
+						if !ok {
+							continue
+						}
+						st, ok := ts.Type.(*ast.StructType)
+						if !ok {
+							continue
+						}
+						for _, field := range st.Fields.List {
+							typ := field.Type
+							if star, ok := typ.(*ast.StarExpr); ok {
+								typ = star.X
+							}
+							if ident, ok := typ.(*ast.Ident); ok && len(field.Names) == 0 {
+								info.embeds[ts.Name.Name] = append(info.embeds[ts.Name.Name], ident.Name)
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	o.pkgs[dir] = info
+	return info, nil
+}
+
+func (o *output) importPath(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(o.root, dir)
+	if err != nil {
+		return "", err
+	}
+	return o.module + "/" + filepath.ToSlash(rel), nil
+}
+
+func render(text string, data any) ([]byte, error) {
+	tmp, err := template.New("routes").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	if err = tmp.Execute(&b, data); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// prefixes 控制器路由前缀, "/" 视为无前缀
+func prefixes(path string) []string {
+	var out []string
+	for _, p := range strings.Split(path, ",") {
+		p = "/" + strings.Trim(strings.TrimSpace(p), "/")
+		if p == "/" {
+			p = ""
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+func split(s string) []string {
+	var out []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); len(item) > 0 {
+			out = append(out, item)
+		}
 	}
 	return out
 }
 
-func (o *output) upsertBuffer(k outputKey) *bytes.Buffer {
-	if b, ok := o.files[k]; ok {
-		return b
+// literal 类型名转为字面量, 如 tables.Admin => &tables.Admin{}, []tables.Admin => []tables.Admin{}
+func literal(typ string) string {
+	typ = strings.TrimSpace(typ)
+	switch {
+	case len(typ) == 0:
+		return ""
+	case strings.HasPrefix(typ, "[]"):
+		return typ + "{}"
+	default:
+		return "&" + strings.TrimPrefix(typ, "*") + "{}"
 	}
-	b := bytes.NewBufferString(fmt.Sprintf(handlerPackage, k.pkg) + handlerImports + "\n")
-	o.files[k] = b
-	return b
 }
 
-func httpMethodConstant(m string) string {
-	return map[string]string{
-		http.MethodGet:     "GET",
-		http.MethodHead:    "HEAD",
-		http.MethodPost:    "POST",
-		http.MethodPut:     "PUT",
-		http.MethodPatch:   "PATCH",
-		http.MethodDelete:  "DELETE",
-		http.MethodConnect: "CONNECT",
-		http.MethodOptions: "OPTIONS",
-		http.MethodTrace:   "TRACE",
-	}[m]
+func containsValue(m map[string]string, v string) bool {
+	for _, item := range m {
+		if item == v {
+			return true
+		}
+	}
+	return false
 }
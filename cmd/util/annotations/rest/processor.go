@@ -3,29 +3,56 @@ package rest
 import (
 	"fmt"
 	"go/ast"
-	"net/http"
+	"path/filepath"
+	"strings"
 
 	annotation "github.com/xiusin/go-annotation/pkg"
+	"github.com/xiusin/pinecms/src/common/route"
 )
 
+// Rest 路由注解
+//
+// 控制器: Rest(path = "/user", menu = "用户管理", group = "权限管理", middleware = "log")
+// 方法:   Rest(method = "POST", route = "/login", perm = "user:login", title = "登录", req = "loginParam", resp = "tables.Admin")
 type Rest struct {
-	Method string `annotation:"name=method,default=GET"`
-	Path   string `annotation:"name=path,default=/"`
-	Route  string `annotation:"name=route"`
+	Method     string `annotation:"name=method"`              // 请求方法, 为空时按方法名前缀推断
+	Path       string `annotation:"name=path,default=/"`      // 控制器路由前缀, 多个以逗号分隔
+	Route      string `annotation:"name=route"`               // 方法路由, 相对控制器前缀
+	Mount      string `annotation:"name=mount,default=admin"` // 挂载分组 admin 或 app
+	Middleware string `annotation:"name=middleware"`          // 中间件名称, 逗号分隔
+	Perm       string `annotation:"name=perm"`                // casbin 权限标识
+	Title      string `annotation:"name=title"`               // 接口标题
+	Group      string `annotation:"name=group"`               // 接口分组
+	Req        string `annotation:"name=req"`                 // 请求参数类型
+	Resp       string `annotation:"name=resp"`                // 响应数据类型
+	Menu       string `annotation:"name=menu"`                // 菜单名称, 设置后生成菜单权限种子数据
+	Magic      bool   `annotation:"name=magic,default=true"`  // 未注解的方法按 pine 的方法名约定注册
 }
 
-const sp = `|||-|||`
-
 func init() {
-	annotation.Register[Rest](&Processor{
-		mapping: make(map[handlerMetadata]handlerMapping),
-	})
+	annotation.Register[Rest](&Processor{controllers: map[string]*controller{}})
 }
 
 var _ annotation.AnnotationProcessor = (*Processor)(nil)
 
 type Processor struct {
-	mapping map[handlerMetadata]handlerMapping
+	root        string
+	controllers map[string]*controller // 目录+结构体名称
+}
+
+// controller 注解的控制器或被嵌入的结构体
+type controller struct {
+	pkg       string
+	dir       string
+	name      string
+	annotated bool // 结构体带有注解
+	rest      Rest
+	handlers  map[string]handler
+}
+
+type handler struct {
+	rest    Rest
+	imports map[string]string // 请求与响应类型引用的包
 }
 
 func (p *Processor) Process(node annotation.Node) error {
@@ -37,60 +64,75 @@ func (p *Processor) Process(node annotation.Node) error {
 	if len(annotations) > 1 {
 		return fmt.Errorf("expected 1 rest annotation, but got: %d", len(annotations))
 	}
+	p.root = node.Meta().Root()
+	rest := annotations[0]
+	rest.Menu, rest.Title, rest.Group = utf8String(rest.Menu), utf8String(rest.Title), utf8String(rest.Group)
 
 	n := node.ASTNode()
 	switch nt := n.(type) {
 	case *ast.TypeSpec:
-		return p.processStructure(annotations[0], node, nt)
+		return p.processStructure(rest, node, nt)
 	case *ast.FuncDecl:
-		return p.processMethod(annotations[0], node, nt)
+		return p.processMethod(rest, node, nt)
 	default:
 		return fmt.Errorf("unexpected node type %T - %t", n, n)
 	}
 }
 
+// utf8String 注解词法分析按字节读取字符串, 每个字节被转为一个字符, 还原为原始的 UTF-8 字符串
+func utf8String(s string) string {
+	b := make([]byte, 0, len(s))
+	for _, r := range s {
+		if r > 0xff {
+			return s
+		}
+		b = append(b, byte(r))
+	}
+	return string(b)
+}
+
 func (p *Processor) Version() string {
-	return "0.0.1"
+	return "0.1.0"
 }
 
 func (p *Processor) Name() string {
 	return "Rest"
 }
 
-type handlerMetadata struct {
-	pkg        string
-	structName string
-	dir        string
-	fileName   string
-}
-
-type handlerMapping struct {
-	path    string
-	mapping map[string]string
+func (p *Processor) upsert(node annotation.Node, name string) *controller {
+	key := filepath.Join(node.Meta().Dir(), name)
+	c, ok := p.controllers[key]
+	if !ok {
+		c = &controller{
+			pkg:      node.Meta().PackageName(),
+			dir:      node.Meta().Dir(),
+			name:     name,
+			handlers: map[string]handler{},
+		}
+		p.controllers[key] = c
+	}
+	return c
 }
 
 func (p *Processor) processStructure(rest Rest, node annotation.Node, s *ast.TypeSpec) error {
-	key := handlerMetadata{
-		pkg:        node.Meta().PackageName(),
-		structName: s.Name.Name,
-		dir:        node.Meta().Dir(),
-		fileName:   node.Meta().FileName(),
+	if _, ok := s.Type.(*ast.StructType); !ok {
+		return fmt.Errorf("@Rest can only be declared on struct, got %s", s.Name.Name)
 	}
-
-	_, ok := p.mapping[key]
-	if !ok {
-		p.mapping[key] = handlerMapping{
-			path:    rest.Path,
-			mapping: make(map[string]string),
-		}
+	if rest.Mount != route.MountAdmin && rest.Mount != route.MountApp {
+		return fmt.Errorf("invalid mount %s for %s", rest.Mount, s.Name.Name)
 	}
-
+	c := p.upsert(node, s.Name.Name)
+	c.annotated = true
+	c.rest = rest
 	return nil
 }
 
 func (p *Processor) processMethod(rest Rest, node annotation.Node, f *ast.FuncDecl) error {
-	if !p.validateHTTPMethod(rest.Method) {
-		return fmt.Errorf("invalid HTTP method: %s", rest.Method)
+	if len(rest.Method) > 0 {
+		rest.Method = strings.ToUpper(rest.Method)
+		if !route.ValidMethod(rest.Method) {
+			return fmt.Errorf("invalid HTTP method: %s", rest.Method)
+		}
 	}
 
 	recvName := MethodReceiver(f)
@@ -98,54 +140,73 @@ func (p *Processor) processMethod(rest Rest, node annotation.Node, f *ast.FuncDe
 		return fmt.Errorf("expected method receiver, but got empty for %s", f.Name.Name)
 	}
 
-	key := handlerMetadata{
-		pkg:        node.Meta().PackageName(),
-		structName: recvName,
-		dir:        node.Meta().Dir(),
-		fileName:   node.Meta().FileName(),
+	imports := map[string]string{}
+	for _, typ := range []string{rest.Req, rest.Resp} {
+		alias, _, ok := strings.Cut(strings.TrimLeft(typ, "[]*"), ".")
+		if !ok {
+			continue
+		}
+		path, found := importPath(node.Imports(), alias)
+		if !found {
+			return fmt.Errorf("import not found for %s in %s", typ, f.Name.Name)
+		}
+		imports[alias] = path
 	}
 
-	v, ok := p.mapping[key]
-	if !ok {
-		return fmt.Errorf("no mapping for %s", key)
-	}
+	c := p.upsert(node, recvName)
+	c.handlers[f.Name.Name] = handler{rest: rest, imports: imports}
+	return nil
+}
 
-	if len(rest.Route) == 0 {
-		rest.Route = "/" + f.Name.Name
+func importPath(specs []*ast.ImportSpec, alias string) (string, bool) {
+	for _, spec := range specs {
+		path := strings.Trim(spec.Path.Value, `"`)
+		name := path[strings.LastIndex(path, "/")+1:]
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		if name == alias {
+			return path, true
+		}
 	}
+	return "", false
+}
 
-	v.mapping[rest.Method] = f.Name.Name + sp + rest.Route
-
-	return nil
+// inferMethod 按 pine 的方法名约定推断请求方法与路由
+func inferMethod(name string) (method, path string, ok bool) {
+	for _, prefix := range []string{"Get", "Post", "Put", "Delete", "Head"} {
+		if strings.HasPrefix(name, prefix) && len(name) > len(prefix) {
+			return strings.ToUpper(prefix), "/" + upperCharToUnderLine(strings.TrimPrefix(name, prefix)), true
+		}
+	}
+	return "", "", false
 }
 
-func (p *Processor) validateHTTPMethod(m string) bool {
-	_, ok := map[string]struct{}{
-		http.MethodGet:     {},
-		http.MethodHead:    {},
-		http.MethodPost:    {},
-		http.MethodPut:     {},
-		http.MethodPatch:   {},
-		http.MethodDelete:  {},
-		http.MethodConnect: {},
-		http.MethodOptions: {},
-		http.MethodTrace:   {},
-	}[m]
-	return ok
+func upperCharToUnderLine(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		if r >= 'A' && r <= 'Z' {
+			if i > 0 {
+				b.WriteRune('_')
+			}
+			r += 'a' - 'A'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
 }
 
 func (p *Processor) Output() map[string][]byte {
-	o, err := newOutput()
+	if len(p.controllers) == 0 {
+		return nil
+	}
+	o, err := newOutput(p.root)
 	if err != nil {
 		panic(err)
 	}
-	for k, v := range p.mapping {
-		err = o.append(k, v)
-		if err != nil {
-			panic(err)
-		}
+	if err = o.build(p.controllers); err != nil {
+		panic(err)
 	}
-
 	return o.get()
 }
 
@@ -160,6 +221,8 @@ func MethodReceiver(decl *ast.FuncDecl) string {
 			return rv.X.(*ast.Ident).Name
 		case *ast.UnaryExpr:
 			return rv.X.(*ast.Ident).Name
+		case *ast.Ident:
+			return rv.Name
 		}
 	}
 	return ""
@@ -1,11 +1,18 @@
 package backend
 
 import (
+	"errors"
+	"slices"
+
 	"github.com/xiusin/pine/pointer"
 	"github.com/xiusin/pinecms/src/application/models/tables"
 	"github.com/xiusin/pinecms/src/common/helper"
+	"github.com/xiusin/pinecms/src/config"
+	"xorm.io/builder"
+	"xorm.io/xorm"
 )
 
+// @Rest(path = "/ad", menu = "广告管理")
 type AdController struct {
 	BaseController
 }
@@ -25,6 +32,9 @@ func (c *AdController) Construct() {
 	c.BaseController.Construct()
 
 	c.OpBefore = func(i int, a any) error {
+		if err := c.siteScope(i, a); err != nil {
+			return err
+		}
 		if c.IsOperate(i) {
 			t := a.(*tables.Advert)
 			if len(t.DateRange) == 2 {
@@ -35,3 +45,28 @@ func (c *AdController) Construct() {
 		return nil
 	}
 }
+
+// siteScope 广告通过广告位归属站点
+func (c *AdController) siteScope(act int, params any) error {
+	spaces := builder.Select("id").From(c.Orm.TableName(&tables.AdvertSpace{}, true)).Where(builder.Eq{"site_id": config.CtxSiteId(c.Ctx())})
+	switch act {
+	case OpList, OpInfo:
+		params.(*xorm.Session).Where(builder.In("space_id", spaces))
+	case OpAdd, OpEdit:
+		t := params.(*tables.Advert)
+		if exist, _ := c.Orm.Where("id = ?", t.SpaceID).Where(builder.In("id", spaces)).Exist(&tables.AdvertSpace{}); !exist {
+			return errors.New("广告位不属于当前站点")
+		}
+		if act == OpEdit {
+			if exist, _ := c.Orm.Where("id = ?", t.Id).Where(builder.In("space_id", spaces)).Exist(&tables.Advert{}); !exist {
+				return errors.New("广告不属于当前站点")
+			}
+		}
+	case OpDel:
+		ids := slices.Compact(slices.Sorted(slices.Values(params.(*idParams).Ids)))
+		if count, _ := c.Orm.In("id", ids).Where(builder.In("space_id", spaces)).Count(&tables.Advert{}); count != int64(len(ids)) {
+			return errors.New("广告不属于当前站点")
+		}
+	}
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/xiusin/pinecms/src/application/models/tables"
 )
 
+// @Rest(path = "/ad/space", menu = "广告位管理")
 type AdSpaceController struct {
 	BaseController
 }
@@ -29,14 +30,17 @@ func (c *AdSpaceController) before(act int, params any) error {
 			return errors.New("广告位下还有广告,无法直接删除")
 		}
 	}
+	if err := c.SiteScope(act, params); err != nil {
+		return err
+	}
 	if act == OpEdit || act == OpAdd {
 		t, p := &tables.AdvertSpace{}, params.(*tables.AdvertSpace)
 		if act == OpAdd {
-			if exists, _ := c.Orm.Where("name = ? or `key` = ?", p.Name, p.Key).Exist(t); exists {
+			if exists, _ := c.Orm.Where("site_id = ?", p.SiteId).Where("name = ? or "+c.Orm.Quote("key")+" = ?", p.Name, p.Key).Exist(t); exists {
 				return errors.New("广告位名称或标识已经存在")
 			}
 		} else {
-			if exists, _ := c.Orm.Where("id <> ? and (name = ? or `key` = ?)", p.Id, p.Name, p.Key).Exist(t); exists {
+			if exists, _ := c.Orm.Where("site_id = ?", p.SiteId).Where("id <> ? and (name = ? or "+c.Orm.Quote("key")+" = ?)", p.Id, p.Name, p.Key).Exist(t); exists {
 				return errors.New("广告位名称或标识已经存在")
 			}
 		}
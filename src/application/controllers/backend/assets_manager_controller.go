@@ -1,42 +1,51 @@
 package backend
 
 import (
-	"github.com/silenceper/wechat/v2/cache"
+	"errors"
 	"io/fs"
 	"io/ioutil"
+	"net/url"
 	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
-	"time"
 
-	"github.com/bytedance/sonic"
-	"github.com/xiusin/pinecms/src/application/controllers"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/xiusin/pine"
+	"github.com/xiusin/pinecms/src/application/models"
 	"github.com/xiusin/pinecms/src/application/models/tables"
 
-	"github.com/xiusin/pine/di"
 	"github.com/xiusin/pinecms/src/common/helper"
+	"github.com/xiusin/pinecms/src/common/theme"
 	"github.com/xiusin/pinecms/src/config"
 	"xorm.io/xorm"
 )
 
 // AssetsManagerController
 // Deprecated:  废弃
+// @Rest(path = "/assets")
 type AssetsManagerController struct {
 	BaseController
 	conf *config.Config
 }
 
+// InitTemplateHistoryInstall 同步模板历史表
+func InitTemplateHistoryInstall() {
+	if err := helper.GetORM().Sync2(&tables.TemplateHistory{}); err != nil {
+		pine.Logger().Warn("同步模板历史表失败", err)
+	}
+}
+
 func (c *AssetsManagerController) Construct() {
 	c.KeywordsSearch = []SearchFieldDsl{
 		{Field: "name", Op: "LIKE", DataExp: "%$?%"},
 	}
 	c.Orm = helper.GetORM()
-	c.conf = di.MustGet("pinecms.config").(*config.Config)
+	c.conf = config.App()
 }
 
 func (c *AssetsManagerController) GetSelect() {
-	themeDir := filepath.Join(c.conf.View.FeDirname, c.conf.View.Theme)
+	themeDir := filepath.Join(c.conf.View.FeDirname, theme.Active())
 	files := c.getTempList(themeDir)
 	var kv []tables.KV
 	for _, file := range files {
@@ -71,16 +80,58 @@ func (c *AssetsManagerController) getTempList(dir string) []map[string]any {
 }
 
 func (c *AssetsManagerController) PostList() {
-	themeDir := filepath.Join(c.conf.View.FeDirname, c.conf.View.Theme)
+	themeDir := filepath.Join(c.conf.View.FeDirname, theme.Active())
 	helper.Ajax(helper.DirTree(themeDir), 0, c.Ctx())
 }
 
+type templateParams struct {
+	Id      string `json:"id"`
+	Content string `json:"content"`
+	Remark  string `json:"remark"`
+	Tid     int64  `json:"tid"`
+	Aid     int64  `json:"aid"`
+}
+
+// templateName 模板相对于主题目录的路径, 兼容目录树中的完整路径
+func (c *AssetsManagerController) templateName(id string) string {
+	prefix := filepath.Join(c.conf.View.FeDirname, theme.Active())
+	if rel, err := filepath.Rel(prefix, id); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return id
+}
+
+func (c *AssetsManagerController) adminId() int64 {
+	id, _ := c.Ctx().Value("adminid").(int64)
+	return id
+}
+
+// writeTemplate 校验并写入模板, 写入前保存历史版本
+func (c *AssetsManagerController) writeTemplate(name, content, remark string) error {
+	active := theme.Active()
+	_, file, err := theme.TemplatePath(active, name)
+	if err != nil {
+		return err
+	}
+	if err := theme.Validate(active, name, content); err != nil {
+		return err
+	}
+	old, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	if string(old) == content {
+		return nil
+	}
+	if err := models.NewTemplateHistoryModel().Save(active, filepath.ToSlash(name), string(old), c.adminId(), remark); err != nil {
+		return err
+	}
+	return os.WriteFile(file, []byte(content), os.ModePerm)
+}
+
+// PostEdit 保存模板, 语法错误时返回错误行号
 func (c *AssetsManagerController) PostEdit() {
-	p := struct {
-		Content string `json:"content"`
-		Name    string `json:"name"`
-		Id      string `json:"id"`
-	}{}
+	var p templateParams
 	if err := c.Ctx().BindJSON(&p); err != nil {
 		helper.Ajax(err, 1, c.Ctx())
 		return
@@ -89,69 +140,153 @@ func (c *AssetsManagerController) PostEdit() {
 		helper.Ajax("参数错误", 1, c.Ctx())
 		return
 	}
-	fullPath := filepath.Join(c.conf.View.FeDirname, c.conf.View.Theme, p.Id)
-	f, err := os.OpenFile(fullPath, os.O_WRONLY, os.ModePerm)
-	if err != nil {
+	if err := c.writeTemplate(c.templateName(p.Id), p.Content, p.Remark); err != nil {
 		helper.Ajax(err, 1, c.Ctx())
 		return
 	}
-	defer f.Close()
-	if _, err := f.WriteString(p.Content); err != nil {
+	helper.Ajax("修改成功", 0, c.Ctx())
+}
+
+// PostValidate 校验模板语法
+func (c *AssetsManagerController) PostValidate() {
+	var p templateParams
+	if err := c.Ctx().BindJSON(&p); err != nil {
 		helper.Ajax(err, 1, c.Ctx())
 		return
 	}
-
-	helper.Ajax("修改成功", 0, c.Ctx())
+	err := theme.Validate(theme.Active(), c.templateName(p.Id), p.Content)
+	var tplErr *theme.TemplateError
+	switch {
+	case err == nil:
+		helper.Ajax(pine.H{"valid": true}, 0, c.Ctx())
+	case errors.As(err, &tplErr):
+		helper.Ajax(pine.H{"valid": false, "error": tplErr}, 0, c.Ctx())
+	default:
+		helper.Ajax(err, 1, c.Ctx())
+	}
 }
 
-func (c *AssetsManagerController) GetThemes() {
-	files, err := os.ReadDir(c.conf.View.FeDirname)
+// PostHistory 模板历史版本列表
+func (c *AssetsManagerController) PostHistory() {
+	var p templateParams
+	if err := c.Ctx().BindJSON(&p); err != nil {
+		helper.Ajax(err, 1, c.Ctx())
+		return
+	}
+	list, err := models.NewTemplateHistoryModel().List(theme.Active(), filepath.ToSlash(c.templateName(p.Id)))
 	if err != nil {
-		helper.Ajax("读取主题目录失败: "+err.Error(), 1, c.Ctx())
+		helper.Ajax(err, 1, c.Ctx())
 		return
 	}
-	var dirs []*ThemeConfig
-	for _, f := range files {
-		if f.IsDir() {
-			contentByts, err := os.ReadFile(filepath.Join(c.conf.View.FeDirname, f.Name(), "config.json"))
-			if err != nil {
-				continue
-			}
-			var tConf ThemeConfig
-			err = sonic.Unmarshal(contentByts, &tConf)
-			if err != nil {
-				continue
-			}
-			tConf.Dir = f.Name()
-			if tConf.Dir == c.conf.View.Theme {
-				tConf.IsDefault = true
-			}
-			dirs = append(dirs, &tConf)
+	helper.Ajax(list, 0, c.Ctx())
+}
+
+// PostDiff 对比历史版本, target为0时与当前文件对比
+func (c *AssetsManagerController) PostDiff() {
+	var p struct {
+		Id     int64 `json:"id"`
+		Target int64 `json:"target"`
+	}
+	if err := c.Ctx().BindJSON(&p); err != nil {
+		helper.Ajax(err, 1, c.Ctx())
+		return
+	}
+	m := models.NewTemplateHistoryModel()
+	history := m.Get(p.Id)
+	if history == nil {
+		helper.Ajax("历史版本不存在", 1, c.Ctx())
+		return
+	}
+	toName, toContent := "当前版本", ""
+	if p.Target > 0 {
+		target := m.Get(p.Target)
+		if target == nil || target.Theme != history.Theme || target.Name != history.Name {
+			helper.Ajax("对比版本不存在", 1, c.Ctx())
+			return
 		}
+		toName, toContent = target.CreatedAt.String(), target.Content
+	} else {
+		content, err := theme.ReadTemplate(history.Theme, history.Name)
+		if err != nil {
+			helper.Ajax(err, 1, c.Ctx())
+			return
+		}
+		toContent = content
 	}
-	helper.Ajax(dirs, 0, c.Ctx())
+	diff, _ := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(history.Content),
+		B:        difflib.SplitLines(toContent),
+		FromFile: history.CreatedAt.String(),
+		ToFile:   toName,
+		Context:  3,
+	})
+	helper.Ajax(pine.H{"diff": diff, "from": history.Content, "to": toContent}, 0, c.Ctx())
 }
 
-func (c *AssetsManagerController) PostTheme(cache cache.Cache) {
-	var p = map[string]any{}
-	_ = c.Ctx().BindJSON(&p)
-	name := p["theme"].(string)
+// PostRestore 恢复历史版本, 当前内容会保存为新的历史版本
+func (c *AssetsManagerController) PostRestore() {
+	var p struct {
+		Id int64 `json:"id"`
+	}
+	if err := c.Ctx().BindJSON(&p); err != nil {
+		helper.Ajax(err, 1, c.Ctx())
+		return
+	}
+	history := models.NewTemplateHistoryModel().Get(p.Id)
+	if history == nil || history.Theme != theme.Active() {
+		helper.Ajax("历史版本不存在", 1, c.Ctx())
+		return
+	}
+	if err := c.writeTemplate(history.Name, history.Content, "恢复到"+history.CreatedAt.String()+"前"); err != nil {
+		helper.Ajax(err, 1, c.Ctx())
+		return
+	}
+	helper.Ajax("恢复成功", 0, c.Ctx())
+}
 
-	if name == "" {
-		helper.Ajax("模板主题参数错误", 1, c.Ctx())
+// PostPreview 生成模板预览地址, content为空时预览已保存的模板, tid/aid指定渲染的栏目与文档
+func (c *AssetsManagerController) PostPreview() {
+	var p templateParams
+	if err := c.Ctx().BindJSON(&p); err != nil {
+		helper.Ajax(err, 1, c.Ctx())
 		return
 	}
-	fileSys, err := os.Stat(filepath.Join(c.conf.View.FeDirname, name))
-	if err != nil || !fileSys.IsDir() {
-		helper.Ajax("模板主题不存在", 1, c.Ctx())
+	draft := &theme.TemplateDraft{Theme: theme.Active(), Template: filepath.ToSlash(c.templateName(p.Id)), Content: p.Content, Tid: p.Tid, Aid: p.Aid}
+	if len(p.Content) > 0 {
+		if err := theme.Validate(draft.Theme, draft.Template, p.Content); err != nil {
+			helper.Ajax(err, 1, c.Ctx())
+			return
+		}
+	}
+	token, err := theme.SignDraft(draft)
+	if err != nil {
+		helper.Ajax(err, 1, c.Ctx())
 		return
 	}
-	if cache.Set(controllers.CacheTheme, []byte(name), time.Hour) == nil {
-		c.conf.View.Theme = name
-		helper.Ajax("设置主题成功", 0, c.Ctx())
-	} else {
-		helper.Ajax("设置主题失败", 1, c.Ctx())
+	helper.Ajax(pine.H{"url": "/template-preview.go?" + theme.DraftQuery + "=" + url.QueryEscape(token)}, 0, c.Ctx())
+}
+
+// GetThemes 主题列表
+// Deprecated: 使用 ThemeController.List
+func (c *AssetsManagerController) GetThemes() {
+	list, err := theme.List()
+	if err != nil {
+		helper.Ajax("读取主题目录失败: "+err.Error(), 1, c.Ctx())
+		return
+	}
+	helper.Ajax(list, 0, c.Ctx())
+}
+
+// PostTheme 设置主题, 保存到配置表
+// Deprecated: 使用 ThemeController.Active
+func (c *AssetsManagerController) PostTheme() {
+	var p = map[string]string{}
+	_ = c.Ctx().BindJSON(&p)
+	if err := theme.SetActive(p["theme"]); err != nil {
+		helper.Ajax(err, 1, c.Ctx())
+		return
 	}
+	helper.Ajax("设置主题成功", 0, c.Ctx())
 }
 
 func (c *AssetsManagerController) GetInfo() {
@@ -184,7 +319,7 @@ func (c *AssetsManagerController) PostAdd(orm *xorm.Engine) {
 			return
 		}
 		content, _ := c.Ctx().Input().GetString("content")
-		f := filepath.Join(c.conf.View.FeDirname, c.conf.View.Theme, name)
+		f := filepath.Join(c.conf.View.FeDirname, theme.Active(), name)
 		_, err := os.Stat(f)
 		if err == nil {
 			helper.Ajax("模板已经存在", 1, c.Ctx())
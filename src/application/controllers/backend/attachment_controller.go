@@ -6,9 +6,10 @@ import (
 	"github.com/xiusin/pinecms/src/application/controllers/middleware/apidoc"
 	"github.com/xiusin/pinecms/src/application/models/tables"
 	"github.com/xiusin/pinecms/src/common/helper"
+	"github.com/xiusin/pinecms/src/config"
 )
 
-// @Rest(path = "/api/v1/11/{container_id}")
+// @Rest(path = "/attachment", menu = "附件管理")
 type AttachmentController struct {
 	BaseController
 }
@@ -39,10 +40,9 @@ func (c *AttachmentController) before(act int, params any) error {
 		}
 		params.(*xorm.Session).Desc("id")
 	}
-	return nil
+	return c.SiteScope(act, params)
 }
 
-// @Rest(method = "GET", route = "/gaa")
 func (c *AttachmentController) PostAdd() {
 	if err := c.BindParse(); err != nil {
 		helper.Ajax(err.Error(), 1, c.Ctx())
@@ -55,11 +55,19 @@ func (c *AttachmentController) PostAdd() {
 		return
 	}
 	data := &tables.Attachments{}
+	siteId := config.CtxSiteId(c.Ctx())
 
-	if exist, _ := c.Orm.Where("md5 = ?", md5).Get(data); exist {
+	// md5在站点内唯一, 回收站中的同一附件直接恢复
+	if exist, _ := c.Orm.Unscoped().Where("site_id = ?", siteId).Where("md5 = ?", md5).Get(data); exist {
+		if data.DeletedTime != nil {
+			c.Orm.Table(data).ID(data.Id).Unscoped().Update(map[string]any{"deleted_time": nil})
+			data.DeletedTime = nil
+		}
 		helper.Ajax(data, 0, c.Ctx())
 		return
-	} else if err := c.add(); err == nil {
+	}
+	c.Table.(*tables.Attachments).SiteId = siteId
+	if err := c.add(); err == nil {
 		helper.Ajax(c.Table, 0, c.Ctx())
 	} else {
 		helper.Ajax(err, 1, c.Ctx())
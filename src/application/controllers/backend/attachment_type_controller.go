@@ -5,6 +5,7 @@ import (
 	"github.com/xiusin/pinecms/src/common/helper"
 )
 
+// @Rest(path = "/attachment/type", menu = "附件分类")
 type AttachmentTypeController struct {
 	BaseController
 }
@@ -14,7 +14,9 @@ import (
 	"github.com/xiusin/pinecms/src/application/controllers"
 	"github.com/xiusin/pinecms/src/application/controllers/middleware/apidoc"
 	"github.com/xiusin/pinecms/src/application/models/tables"
+	"github.com/xiusin/pinecms/src/common/dialect"
 	"github.com/xiusin/pinecms/src/common/helper"
+	"github.com/xiusin/pinecms/src/config"
 )
 
 var validate = validator.New()
@@ -102,6 +104,41 @@ func (c *BaseController) IsOperate(op int) bool {
 	return slices.Contains([]int{OpAdd, OpEdit}, op)
 }
 
+// SiteScope 数据按站点隔离, 在OpBefore中调用, 表需包含site_id字段.
+// 列表与详情只查询当前站点, 新增写入当前站点, 修改与删除的记录必须属于当前站点
+func (c *BaseController) SiteScope(act int, params any) error {
+	if err := c.FieldScope(act, params, "SiteId", config.CtxSiteId(c.Ctx())); err != nil {
+		return errors.New("数据不属于当前站点")
+	}
+	return nil
+}
+
+// FieldScope 数据按字段值隔离, 在OpBefore中调用, field 为结构体字段名.
+// 列表与详情只查询该值的记录, 新增与修改写入该值, 修改与删除的记录必须匹配该值
+func (c *BaseController) FieldScope(act int, params any, field string, value any) error {
+	col := c.Orm.GetColumnMapper().Obj2Table(field)
+	switch act {
+	case OpList, OpInfo:
+		params.(*xorm.Session).Where(col+" = ?", value)
+	case OpAdd, OpEdit:
+		val := reflect.ValueOf(params).Elem()
+		if act == OpEdit {
+			key := val.FieldByName(c.TableStructKey).Interface()
+			if exist, _ := c.Orm.Table(c.Table).Where(c.TableKey+" = ?", key).Where(col+" = ?", value).Exist(); !exist {
+				return errors.New("无权操作该数据")
+			}
+		}
+		f := val.FieldByName(field)
+		f.Set(reflect.ValueOf(value).Convert(f.Type()))
+	case OpDel:
+		ids := slices.Compact(slices.Sorted(slices.Values(params.(*idParams).Ids)))
+		if count, _ := c.Orm.Table(c.Table).In(c.TableKey, ids).Where(col+" = ?", value).Count(); count != int64(len(ids)) {
+			return errors.New("无权操作该数据")
+		}
+	}
+	return nil
+}
+
 func (c *BaseController) BindParse(receivers ...any) (err error) {
 	receiver := c.Table
 	if len(receivers) > 0 {
@@ -306,11 +343,10 @@ func (c *BaseController) buildQueryCols(sess *xorm.Session) {
 		tableName := c.Orm.TableName(c.Table)
 		var fields = []string{}
 		if err := helper.Cache().Remember(fmt.Sprintf(controllers.CacheTableNameFields, tableName), &fields, func() (any, error) {
-			concat, err := c.Orm.QueryString(`select group_concat(COLUMN_NAME SEPARATOR ',') as fields from information_schema.COLUMNS where table_name = '` + tableName + `'`)
+			fields, err := dialect.Columns(c.Orm, tableName)
 			if err != nil {
 				c.Ctx().Logger().Warn("读取数据表"+tableName+"表字段失败", err)
 			}
-			fields := strings.Split(concat[0]["fields"], ",")
 			return &fields, nil
 		}, 3600); err != nil {
 			c.Ctx().Logger().Warn("缓存表"+tableName+"字段失败", err)
@@ -441,6 +477,12 @@ func (c *BaseController) GetInfo() {
 	query := c.Orm.Where(c.TableKey+"=?", id)
 
 	c.buildQueryCols(query)
+	if c.OpBefore != nil {
+		if err := c.OpBefore(OpInfo, query); err != nil {
+			helper.Ajax(err.Error(), 1, c.Ctx())
+			return
+		}
+	}
 
 	exist, err := query.Get(c.Table)
 	if err != nil {
@@ -460,6 +502,9 @@ func (c *BaseController) GetInfo() {
 }
 
 func (c *BaseController) setApiEntity() {
+	if c.Ctx() == nil { // 非请求内构建, 见 ApiResource
+		return
+	}
 	ps := strings.Split(c.Ctx().Path(), "/")
 	key := ps[len(ps)-1]
 
@@ -499,6 +544,63 @@ func (c *BaseController) setApiEntity() {
 	apidoc.SetApiEntity(c.Ctx(), &apiEntity)
 }
 
+func (c *BaseController) base() *BaseController {
+	return c
+}
+
+// ApiResource 读取控制器的增删改查配置用于生成 OpenAPI 文档, 非 BaseController 控制器返回 false.
+// 配置在 Construct 中设置, 因此 Construct 只能设置字段, 不能访问请求、数据库等, 同步数据表等初始化放到 InitXxxInstall 中
+func ApiResource(prefix string, controller pine.IController) (res apidoc.Resource, ok bool) {
+	typ := reflect.TypeOf(controller)
+	if typ.Kind() != reflect.Ptr {
+		return res, false
+	}
+	instance := reflect.New(typ.Elem()).Interface()
+	ctrl, isBase := instance.(interface{ base() *BaseController })
+	if !isBase {
+		return res, false
+	}
+	if construct, exist := instance.(interface{ Construct() }); exist {
+		construct.Construct()
+	}
+	c := ctrl.base()
+	res = apidoc.Resource{
+		Path:        prefix,
+		Group:       c.Group,
+		SubGroup:    c.SubGroup,
+		Name:        c.ApiEntityName,
+		ListParam:   &listParam{},
+		DeleteParam: &idParams{},
+	}
+	// 内容等动态表的 Table 为表名, 按任意对象处理
+	var fields reflect.Type
+	if t := reflect.TypeOf(c.Table); t != nil && t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct {
+		res.Table, fields = c.Table, t.Elem()
+	}
+	for _, v := range c.SearchFields {
+		if len(v.Field) == 0 {
+			continue
+		}
+		filter := apidoc.Filter{Field: v.Field, Op: v.Op}
+		if fields != nil {
+			if info, err := c.Orm.TableInfo(c.Table); err == nil {
+				if col := info.GetColumn(strings.Trim(v.Field, "`")); col != nil {
+					if field, exist := fields.FieldByName(col.FieldName); exist {
+						filter.Type = field.Type
+					}
+				}
+			}
+		}
+		res.Filters = append(res.Filters, filter)
+	}
+	for _, v := range c.KeywordsSearch {
+		if len(v.Field) > 0 {
+			res.Keywords = append(res.Keywords, v.Field)
+		}
+	}
+	return res, true
+}
+
 func (c *BaseController) GetSelect() {
 	var kv = []tables.KV{}
 
@@ -2,19 +2,20 @@ package backend
 
 import (
 	"errors"
-	"fmt"
 	"regexp"
 
 	"github.com/xiusin/pinecms/src/application/controllers"
 	"github.com/xiusin/pinecms/src/application/models"
 	"github.com/xiusin/pinecms/src/application/models/tables"
 	"github.com/xiusin/pinecms/src/common/helper"
+	"github.com/xiusin/pinecms/src/common/hooks"
+	"github.com/xiusin/pinecms/src/config"
 	"xorm.io/xorm"
 )
 
+// @Rest(path = "/category", menu = "分类管理")
 type CategoryController struct {
 	BaseController
-	sql string
 }
 
 func (c *CategoryController) Construct() {
@@ -24,46 +25,48 @@ func (c *CategoryController) Construct() {
 	c.SubGroup = "分类管理"
 	c.ApiEntityName = "分类"
 	c.OpBefore = c.before
-	c.sql = "SELECT COUNT(*) total FROM `%s` WHERE id=? and deleted_time IS NULL"
+	c.OpAfter = c.after
 	c.BaseController.Construct()
 	c.TableStructKey = "Catid"
 }
 
 func (c *CategoryController) before(act int, params any) error {
-	if act == OpList {
+	if act == OpInfo {
+		params.(*xorm.Session).Where("site_id = ?", config.CtxSiteId(c.Ctx()))
+	} else if act == OpList {
+		params.(*xorm.Session).Where("site_id = ?", config.CtxSiteId(c.Ctx()))
 		typ, _ := c.Input().GetString("type")
 		if typ == "content" {
-			params.(*xorm.Session).Where("`type` <> ?", 2)
+			params.(*xorm.Session).Where(c.Orm.Quote("type")+" <> ?", 2)
 		}
 	} else if act == OpDel {
 		ids := params.(*idParams)
 		if len(ids.Ids) > 1 {
 			return errors.New("分类不支持批量删除")
 		}
-		ok, _ := c.Orm.In("parent_id", ids.Ids).Exist(c.Table)
+		if exist, _ := c.Orm.In("id", ids.Ids).Where("site_id = ?", config.CtxSiteId(c.Ctx())).Exist(&tables.Category{}); !exist {
+			return errors.New("分类不属于当前站点")
+		}
+		ok, _ := c.Orm.In("parentid", ids.Ids).Exist(&tables.Category{})
 		if ok {
 			return errors.New("有下级分类，不可删除")
 		}
+		if err := hooks.CategoryBeforeDelete.Emit(c.event(nil, ids.Ids)); err != nil {
+			return err
+		}
 		cat := models.NewCategoryModel().GetCategory(ids.Ids[0])
 		document := models.NewDocumentModel().GetByID(cat.ModelId)
+		// 分类移入回收站, 单页内容在彻底删除时删除
 		if document == nil || document.Id <= 0 {
-			models.NewCategoryModel().DeleteById(cat.Catid)
-			if cat.Type == 1 {
-				models.NewPageModel().DelPage(cat.Catid)
-			}
 			return nil
 		}
-		sql := []any{fmt.Sprintf(c.sql, controllers.GetTableName(document.Table)), cat.Catid}
-		totals, _ := c.Orm.QueryString(sql...)
-		var total = "0"
-		if len(totals) > 0 {
-			total = totals[0]["total"]
-		}
-		if total != "0" {
+		total, _ := c.Orm.Table(controllers.GetTableName(document.Table)).Where("catid = ?", cat.Catid).Where("deleted_time IS NULL").Count()
+		if total > 0 {
 			return errors.New("分类下有文章，无法删除")
 		}
 	} else if act == OpAdd {
 		cat := params.(*tables.Category)
+		cat.SiteId = config.CtxSiteId(c.Ctx())
 		switch cat.Type {
 		case 0:
 			cat.Url = ""
@@ -81,17 +84,38 @@ func (c *CategoryController) before(act int, params any) error {
 				cat.Topid = cat.Parentid
 			}
 		}
+		return hooks.CategoryBeforeSave.Emit(c.event(cat, nil))
 	} else if act == OpEdit {
 		cat := params.(*tables.Category)
 		if cat.Dir != "" && !regexp.MustCompile("^[A-Za-z0-9_-]+$").MatchString(cat.Dir) {
 			return errors.New("静态目录参数错误")
 		}
+		cat.SiteId = config.CtxSiteId(c.Ctx())
+		if exist, _ := c.Orm.Where("id = ?", cat.Catid).Where("site_id = ?", cat.SiteId).Exist(&tables.Category{}); !exist {
+			return errors.New("分类不属于当前站点")
+		}
+		return hooks.CategoryBeforeSave.Emit(c.event(cat, nil))
 	}
 	return nil
 }
 
+func (c *CategoryController) after(act int, params any) error {
+	switch act {
+	case OpAdd, OpEdit:
+		hooks.CategoryAfterSave.Fire(c.event(params.(*tables.Category), nil))
+	case OpDel:
+		hooks.CategoryAfterDelete.Fire(c.event(nil, params.(*idParams).Ids))
+	}
+	return nil
+}
+
+func (c *CategoryController) event(cat *tables.Category, ids []int64) *hooks.CategoryEvent {
+	adminId, _ := c.Ctx().Value("adminid").(int64)
+	return &hooks.CategoryEvent{Category: cat, Ids: ids, AdminId: adminId}
+}
+
 func (c *CategoryController) GetSelect() {
-	_ = c.Orm.OrderBy("listorder").Find(c.Entries)
+	_ = c.Orm.Where("site_id = ?", config.CtxSiteId(c.Ctx())).OrderBy("listorder").Find(c.Entries)
 	m := c.Entries.(*[]*tables.Category)
 	var kv []tables.KV
 	for _, model := range *m {
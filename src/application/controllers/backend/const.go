@@ -11,15 +11,6 @@ type MenuV2 struct {
 	Children        []MenuV2 `json:"children"`
 }
 
-type ThemeConfig struct {
-	Name        string         `json:"name"`
-	Author      string         `json:"author"`
-	Description string         `json:"description"`
-	Extra       map[string]any `json:"extra"`
-	IsDefault   bool           `json:"is_default"`
-	Dir         string         `json:"dir"`
-}
-
 type FieldShowInPageList struct {
 	Forms []KV `json:"forms"`
 	List  []KV `json:"list"`
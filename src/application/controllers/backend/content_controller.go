@@ -1,9 +1,6 @@
 package backend
 
 import (
-	"fmt"
-	"strconv"
-	"strings"
 	"time"
 
 	"github.com/fatih/structs"
@@ -14,14 +11,43 @@ import (
 	"github.com/xiusin/pine"
 	"github.com/xiusin/pinecms/src/application/controllers"
 	"github.com/xiusin/pinecms/src/application/models/tables"
+	"github.com/xiusin/pinecms/src/common/dialect"
 	"github.com/xiusin/pinecms/src/common/helper"
+	"github.com/xiusin/pinecms/src/common/hooks"
+	"github.com/xiusin/pinecms/src/common/recycle"
 	"github.com/xiusin/pinecms/src/common/search"
+	"github.com/xiusin/pinecms/src/config"
+	"xorm.io/xorm"
 )
 
+// @Rest(path = "/content", menu = "内容管理")
 type ContentController struct {
 	BaseController
 }
 
+// published 提交的数据是否为发布状态
+func published(data map[string]any) bool {
+	return cast.ToInt(data["status"]) == 1
+}
+
+// emitBeforeSave 触发保存前事件, 发布时同时触发发布前事件
+func emitBeforeSave(ev *hooks.ContentEvent, publish bool) error {
+	if err := hooks.ContentBeforeSave.Emit(ev); err != nil {
+		return err
+	}
+	if publish {
+		return hooks.ContentBeforePublish.Emit(ev)
+	}
+	return nil
+}
+
+func fireAfterSave(ev *hooks.ContentEvent, publish bool) {
+	hooks.ContentAfterSave.Fire(ev)
+	if publish {
+		hooks.ContentAfterPublish.Fire(ev)
+	}
+}
+
 func (c *ContentController) Construct() {
 	c.Group = "内容管理"
 	c.KeywordsSearch = []SearchFieldDsl{
@@ -37,10 +63,21 @@ func (c *ContentController) Construct() {
 	c.BaseController.Construct()
 }
 
+// ownCategory 分类属于当前站点, 内容通过分类归属站点
+func (c *ContentController) ownCategory(catid int64) bool {
+	exist, _ := c.Orm.Where("id = ?", catid).Where("site_id = ?", config.CtxSiteId(c.Ctx())).Exist(&tables.Category{})
+	return exist
+}
+
+// siteScope 限定内容属于当前站点的分类
+func (c *ContentController) siteScope(query *xorm.Session) *xorm.Session {
+	return query.Where("catid IN (SELECT id FROM "+c.Orm.TableName(&tables.Category{}, true)+" WHERE site_id = ?)", config.CtxSiteId(c.Ctx()))
+}
+
 func (c *ContentController) PostList() {
 	catid, _ := c.Input().GetInt64("cid")
 	var category tables.Category
-	_, _ = c.Orm.Where("id = ?", catid).Get(&category)
+	_, _ = c.Orm.Where("id = ?", catid).Where("site_id = ?", config.CtxSiteId(c.Ctx())).Get(&category)
 	if category.Catid == 0 {
 		helper.Ajax("栏目不存在或已删除", 1, c.Ctx())
 		return
@@ -62,7 +99,7 @@ func (c *ContentController) PostList() {
 	} else {
 		var fields tables.ModelDslFields
 		c.Orm.Where("mid = ?", category.ModelId).Find(&fields)
-		query.Where("catid = ?", catid).OrderBy("listorder DESC").OrderBy("id DESC")
+		query.Where("catid = ?", catid).Where("deleted_time IS NULL").OrderBy("listorder DESC").OrderBy("id DESC")
 		query.Cols(fields.GetListFields()...)
 		var count int64
 		var contents []map[string]any
@@ -105,6 +142,10 @@ func (c *ContentController) PostAdd() {
 		helper.Ajax("缺少关键参数", 1, c.Ctx())
 		return
 	}
+	if !c.ownCategory(int64(catid)) {
+		helper.Ajax("栏目不属于当前站点", 1, c.Ctx())
+		return
+	}
 
 	var document tables.DocumentModel
 	c.Orm.Where("id = ?", mid).Get(&document)
@@ -113,29 +154,30 @@ func (c *ContentController) PostAdd() {
 		return
 	}
 	c.Table = controllers.GetTableName(document.Table) // 设置表名
-	query := c.Orm.Table(c.Table)
 
 	var data = map[string]any{}
 	_ = c.Ctx().BindJSON(&data)
 	data["created_time"] = helper.NowDate(helper.TimeFormat)
 	data["updated_time"] = helper.NowDate(helper.TimeFormat)
 
-	fields := make([]string, 0, len(data))
-	args := make([]any, 0, len(data))
-	for k, v := range data {
-		fields = append(fields, k)
-		args = append(args, v)
-	}
-	sql := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", c.Table, strings.Join(fields, ","), strings.TrimRight(strings.Repeat("?,", len(data)), ","))
-	result, err := query.Exec(append([]any{sql}, args...)...)
+	adminId, _ := c.Ctx().Value("adminid").(int64)
+	ev := &hooks.ContentEvent{ModelId: int64(mid), CatId: int64(catid), Table: c.Table.(string), Data: data, AdminId: adminId}
+	publish := published(data)
+	if err := emitBeforeSave(ev, publish); err != nil {
+		helper.Ajax(err.Error(), 1, c.Ctx())
+		return
+	}
+
+	id, err := dialect.Insert(c.Orm, c.Table.(string), data)
 	if err == nil {
-		id, _ := result.LastInsertId()
 		data["id"] = id
+		ev.Id = id
 		eid, err := di.MustGet(controllers.ServiceSearchName).(search.ISearch).Index("document", data)
 		if err != nil {
 			pine.Logger().Error("数据入search失败", err, "id: ", id)
 		}
 		c.Orm.Table(controllers.GetTableName("search_m_a_e")).Insert(map[string]any{"mid": mid, "aid": id, "eid": eid})
+		fireAfterSave(ev, publish)
 		helper.Ajax("更新内容成功", 0, c.Ctx())
 	} else {
 		helper.Ajax("更新内容失败: "+err.Error(), 1, c.Ctx())
@@ -151,6 +193,10 @@ func (c *ContentController) PostEdit() {
 		helper.Ajax("缺少关键参数", 1, c.Ctx())
 		return
 	}
+	if !c.ownCategory(int64(catid)) {
+		helper.Ajax("栏目不属于当前站点", 1, c.Ctx())
+		return
+	}
 
 	var document tables.DocumentModel
 	c.Orm.Where("id = ?", mid).Get(&document)
@@ -170,6 +216,19 @@ func (c *ContentController) PostEdit() {
 		delete(data, "pubtime")
 	}
 	data["updated_time"] = helper.NowDate(helper.TimeFormat)
+
+	var status int
+	if exist, _ := c.Orm.Table(c.Table).Where("id = ?", id).Where("catid = ?", catid).Cols("status").Get(&status); !exist {
+		helper.Ajax("内容不存在", 1, c.Ctx())
+		return
+	}
+	adminId, _ := c.Ctx().Value("adminid").(int64)
+	ev := &hooks.ContentEvent{ModelId: int64(mid), CatId: int64(catid), Id: int64(id), Table: c.Table.(string), Data: data, AdminId: adminId}
+	publish := published(data) && status != 1
+	if err := emitBeforeSave(ev, publish); err != nil {
+		helper.Ajax(err.Error(), 1, c.Ctx())
+		return
+	}
 	_, err := query.Where("id = ?", id).Where("mid = ?", mid).Where("catid = ?", catid).AllCols().Update(&data)
 	if err == nil {
 		engine := di.MustGet(controllers.ServiceSearchName).(search.ISearch)
@@ -186,6 +245,7 @@ func (c *ContentController) PostEdit() {
 		if err != nil {
 			pine.Logger().Error("保存数据到search失败", err)
 		}
+		fireAfterSave(ev, publish)
 		helper.Ajax("更新内容成功", 0, c.Ctx())
 	} else {
 		helper.Ajax("更新内容失败: "+err.Error(), 1, c.Ctx())
@@ -202,12 +262,15 @@ func (c *ContentController) GetInfo() {
 		return
 	}
 	c.Table = controllers.GetTableName(document.Table) // 设置表名
-	query := c.Orm.Table(c.Table)
-	contents, err := query.Where("id = ?", id).QueryInterface()
+	contents, err := c.siteScope(c.Orm.Table(c.Table)).Where("id = ?", id).QueryInterface()
 	if err != nil {
 		helper.Ajax("错误"+err.Error(), 1, c.Ctx())
 		return
 	}
+	if len(contents) == 0 {
+		helper.Ajax("内容不存在", 1, c.Ctx())
+		return
+	}
 	for field, value := range contents[0] {
 		switch value := value.(type) {
 		case []byte:
@@ -235,32 +298,33 @@ func (c *ContentController) PostDelete() {
 		return
 	}
 	c.Table = controllers.GetTableName(document.Table)
-	idArr := []string{"0"}
-	for _, id := range ids.Ids {
-		idArr = append(idArr, strconv.Itoa(int(id)))
+	if count, _ := c.siteScope(c.Orm.Table(c.Table)).In("id", ids.Ids).Count(); count != int64(len(ids.Ids)) {
+		helper.Ajax("内容不属于当前站点", 1, c.Ctx())
+		return
 	}
-	ret, err := c.Orm.Exec("DELETE FROM `" + c.Table.(string) + "` WHERE `" + c.TableKey + "` IN (" + strings.Join(idArr, ",") + ")")
+	adminId, _ := c.Ctx().Value("adminid").(int64)
+	ev := &hooks.ContentEvent{ModelId: int64(mid), Ids: ids.Ids, Table: c.Table.(string), AdminId: adminId}
+	if err := hooks.ContentBeforeDelete.Emit(ev); err != nil {
+		helper.Ajax(err.Error(), 1, c.Ctx())
+		return
+	}
+	// 移入回收站, 彻底删除时再删除搜索索引
+	rowNum, err := recycle.SoftDelete(c.Orm, c.Table.(string), ids.Ids)
 	if err != nil {
 		helper.Ajax(err.Error(), 1, c.Ctx())
 		return
 	}
-	if rowNum, _ := ret.RowsAffected(); rowNum == 0 {
+	if rowNum == 0 {
 		helper.Ajax("删除失败", 1, c.Ctx())
 		return
 	}
-	engine := di.MustGet(controllers.ServiceSearchName).(search.ISearch)
-	var s []tables.SearchMAE
-	c.Orm.Table(controllers.GetTableName("search_m_a_e")).Where("mid = ?", mid).In("aid", idArr).Find(&s)
-	for _, v := range s {
-		engine.Delete("document", v.Eid)
-		c.Orm.Table(controllers.GetTableName("search_m_a_e")).Where("mid = ?", mid).In("aid", idArr).Delete()
-	}
+	hooks.ContentAfterDelete.Fire(ev)
 	helper.Ajax("删除成功", 0, c.Ctx())
 }
 
 func (c *ContentController) GetPage() {
 	catid, _ := c.Ctx().Input().GetInt64("id")
-	if catid == 0 {
+	if catid == 0 || !c.ownCategory(catid) {
 		helper.Ajax("页面错误", 1, c.Ctx())
 		return
 	}
@@ -277,7 +341,7 @@ func (c *ContentController) GetPage() {
 func (c *ContentController) PostPage() {
 	var page tables.Page
 	c.Ctx().BindJSON(&page)
-	if page.Id == 0 {
+	if page.Id == 0 || !c.ownCategory(page.Id) {
 		helper.Ajax("分类ID不存在", 1, c.Ctx())
 		return
 	}
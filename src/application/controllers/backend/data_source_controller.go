@@ -0,0 +1,110 @@
+package backend
+
+import (
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/xiusin/pine"
+	"github.com/xiusin/pinecms/src/application/models"
+	"github.com/xiusin/pinecms/src/application/models/tables"
+	"github.com/xiusin/pinecms/src/common/helper"
+	"xorm.io/xorm"
+)
+
+var dataSourceOnce sync.Once
+
+// InitDataSourceInstall 同步数据源表并写入内置数据源
+func InitDataSourceInstall() {
+	dataSourceOnce.Do(func() {
+		defer func() {
+			if err := recover(); err != nil {
+				pine.Logger().Warn("初始化数据源失败", err)
+			}
+		}()
+		orm := helper.GetORM()
+		if err := orm.Sync2(&tables.DataSource{}); err != nil {
+			pine.Logger().Warn(err.Error())
+			return
+		}
+		if exist, _ := orm.Where("name = ?", "category_count").Exist(&tables.DataSource{}); !exist {
+			orm.InsertOne(&tables.DataSource{
+				Name:  "category_count",
+				Title: "栏目文章数量",
+				Table: "articles",
+				Conditions: []tables.DataSourceCondition{
+					{Column: "status", Op: "=", Value: "1"},
+					{Column: "deleted_time", Op: "NULL"},
+					{Column: "catid", Op: "=", Param: "catid", Value: "0"},
+				},
+				Count:    true,
+				Limit:    1,
+				CacheTtl: 600,
+				Status:   true,
+			})
+		}
+	})
+}
+
+// @Rest(path = "/datasource", menu = "数据源管理")
+type DataSourceController struct {
+	BaseController
+}
+
+func (c *DataSourceController) Construct() {
+	c.KeywordsSearch = []SearchFieldDsl{
+		{Field: "name", Op: "LIKE", DataExp: "%$?%"},
+		{Field: "title", Op: "LIKE", DataExp: "%$?%"},
+	}
+	c.Table = &tables.DataSource{}
+	c.Entries = &[]tables.DataSource{}
+	c.ApiEntityName = "数据源"
+	c.Group = "数据源管理"
+	c.BaseController.Construct()
+	c.OpBefore = c.before
+}
+
+func (c *DataSourceController) before(act int, params any) error {
+	switch act {
+	case OpList:
+		params.(*xorm.Session).Desc("id")
+	case OpAdd, OpEdit:
+		return models.NewDataSourceModel().Validate(params.(*tables.DataSource))
+	}
+	return nil
+}
+
+// PostTables 可查询的数据表及字段
+func (c *DataSourceController) PostTables() {
+	m := models.NewDataSourceModel()
+	var list []map[string]any
+	for _, table := range slices.Sorted(maps.Keys(m.AllowedTables())) {
+		list = append(list, map[string]any{"table": table, "columns": m.QueryColumns(table)})
+	}
+	helper.Ajax(list, 0, c.Ctx())
+}
+
+// PostPreview 预览数据源查询结果
+func (c *DataSourceController) PostPreview() {
+	var req struct {
+		tables.DataSource
+		Params map[string]any `json:"params"`
+	}
+	if err := c.Ctx().BindJSON(&req); err != nil {
+		helper.Ajax(err, 1, c.Ctx())
+		return
+	}
+	req.Id, req.CacheTtl = 0, 0
+	m := models.NewDataSourceModel()
+	query, err := m.Compile(&req.DataSource)
+	if err != nil {
+		helper.Ajax(err, 1, c.Ctx())
+		return
+	}
+	rows, err := m.Execute(query, req.Params)
+	if err != nil {
+		helper.Ajax(err, 1, c.Ctx())
+		return
+	}
+	helper.Ajax(rows, 0, c.Ctx())
+}
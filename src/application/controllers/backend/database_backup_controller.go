@@ -3,26 +3,21 @@ package backend
 import (
 	"path/filepath"
 
-	"github.com/xiusin/pine"
-	"github.com/xiusin/pinecms/src/application/controllers"
 	"github.com/xiusin/pinecms/src/common/helper"
+	"github.com/xiusin/pinecms/src/config"
 )
 
+// @Rest(path = "/", magic = "false")
 type DatabaseBackupController struct {
 	BaseController
 }
 
 // 定时备份任务功能
 
-func (c *DatabaseBackupController) RegisterRoute(b pine.IRouterWrapper) {
-	b.ANY("/backup/list", "BackupList")
-	b.POST("/backup/delete", "BackupDelete")
-	b.POST("/backup/download", "BackupDownload")
-}
-
+// @Rest(method = "ANY", route = "/backup/list", title = "备份列表", group = "数据库管理")
 func (c *DatabaseBackupController) BackupList() {
-	settingData := c.Ctx().Value(controllers.CacheSetting).(map[string]string)
-	uploader := getStorageEngine(settingData)
+	settingData := config.CtxSiteConfig(c.Ctx())
+	uploader := GetStorageEngine(settingData)
 	list, err := uploader.List(baseBackupDir)
 	if err != nil {
 		c.Logger().Error(err.Error())
@@ -30,15 +25,16 @@ func (c *DatabaseBackupController) BackupList() {
 	helper.Ajax(list, 0, c.Ctx())
 }
 
+// @Rest(method = "POST", route = "/backup/download", title = "下载备份", group = "数据库管理")
 func (c *DatabaseBackupController) BackupDownload() {
-	settingData := c.Ctx().Value(controllers.CacheSetting).(map[string]string)
+	settingData := config.CtxSiteConfig(c.Ctx())
 	name, _ := c.Input().GetString("name")
 	if len(name) == 0 {
 		helper.Ajax("参数错误", 1, c.Ctx())
 		return
 	}
 	relName := filepath.Join(baseBackupDir, name)
-	uploader := getStorageEngine(settingData)
+	uploader := GetStorageEngine(settingData)
 	exists, _ := uploader.Exists(relName)
 	if !exists {
 		helper.Ajax("文件不存在或已经被删除", 1, c.Ctx())
@@ -47,15 +43,16 @@ func (c *DatabaseBackupController) BackupDownload() {
 	helper.Ajax(uploader.GetFullUrl(relName), 0, c.Ctx())
 }
 
+// @Rest(method = "POST", route = "/backup/delete", title = "删除备份", group = "数据库管理", middleware = "request_log")
 func (c *DatabaseBackupController) BackupDelete() {
-	settingData := c.Ctx().Value(controllers.CacheSetting).(map[string]string)
+	settingData := config.CtxSiteConfig(c.Ctx())
 	names := c.Input().Get("ids")
 	if len(names.([]any)) == 0 {
 		helper.Ajax("参数错误", 1, c.Ctx())
 		return
 	}
 	relName := names.([]any)[0].(string)
-	uploader := getStorageEngine(settingData)
+	uploader := GetStorageEngine(settingData)
 	if err := uploader.Remove(relName); err != nil {
 		helper.Ajax(err, 1, c.Ctx())
 		return
@@ -15,23 +15,20 @@ import (
 	"github.com/alexmullins/zip"
 	"github.com/xiusin/pine"
 	"github.com/xiusin/pinecms/src/application/controllers"
+	"github.com/xiusin/pinecms/src/common/dialect"
 	"github.com/xiusin/pinecms/src/common/helper"
+	"github.com/xiusin/pinecms/src/config"
 	"xorm.io/xorm"
 )
 
+// @Rest(path = "/", magic = "false")
 type DatabaseController struct {
 	pine.Controller
 }
 
 var baseBackupDir = fmt.Sprintf("%s/%s", "database", "backup")
 
-func (c *DatabaseController) RegisterRoute(b pine.IRouterWrapper) {
-	b.ANY("/database/list", "Manager")
-	b.POST("/database/repair", "Repair")
-	b.POST("/database/optimize", "Optimize")
-	b.POST("/database/backup", "Backup")
-}
-
+// @Rest(method = "ANY", route = "/database/list", title = "数据表列表", group = "数据库管理")
 func (c *DatabaseController) Manager(orm *xorm.Engine, cache contracts.Cache) {
 	var metaDataset []*schemas.Table
 	var data []map[string]any
@@ -63,6 +60,7 @@ func (c *DatabaseController) Manager(orm *xorm.Engine, cache contracts.Cache) {
 	helper.Ajax(data, 0, c.Ctx())
 }
 
+// @Rest(method = "POST", route = "/database/repair", title = "修复数据表", group = "数据库管理", middleware = "request_log")
 func (c *DatabaseController) Repair(orm *xorm.Engine) {
 	tables := c.Input().GetFormStrings("tables")
 	if len(tables) == 0 {
@@ -70,7 +68,12 @@ func (c *DatabaseController) Repair(orm *xorm.Engine) {
 		return
 	}
 	for _, table := range tables {
-		_, err := orm.Exec("REPAIR TABLE `" + table + "`")
+		var err error
+		for _, sql := range dialect.Of(orm).Maintain("repair", table) {
+			if _, err = orm.Exec(sql); err != nil {
+				break
+			}
+		}
 		if err != nil {
 			helper.Ajax("修复错误："+table+": "+err.Error(), 1, c.Ctx())
 			return
@@ -79,6 +82,7 @@ func (c *DatabaseController) Repair(orm *xorm.Engine) {
 	helper.Ajax("修复完成", 0, c.Ctx())
 }
 
+// @Rest(method = "POST", route = "/database/optimize", title = "优化数据表", group = "数据库管理", middleware = "request_log")
 func (c *DatabaseController) Optimize(orm *xorm.Engine) {
 	tables := c.Input().GetFormStrings("tables")
 	if len(tables) == 0 {
@@ -87,7 +91,12 @@ func (c *DatabaseController) Optimize(orm *xorm.Engine) {
 	}
 
 	for _, table := range tables {
-		_, err := orm.Exec("OPTIMIZE TABLE `" + table + "`")
+		var err error
+		for _, sql := range dialect.Of(orm).Maintain("optimize", table) {
+			if _, err = orm.Exec(sql); err != nil {
+				break
+			}
+		}
 		if err != nil {
 			helper.Ajax("优化错误："+table+": "+err.Error(), 1, c.Ctx())
 			return
@@ -96,8 +105,9 @@ func (c *DatabaseController) Optimize(orm *xorm.Engine) {
 	helper.Ajax("优化完成", 0, c.Ctx())
 }
 
+// @Rest(method = "POST", route = "/database/backup", title = "备份数据库", group = "数据库管理", middleware = "request_log")
 func (c *DatabaseController) Backup() {
-	settingData := c.Ctx().Value(controllers.CacheSetting).(map[string]string)
+	settingData := config.CtxSiteConfig(c.Ctx())
 	msg, code := c.backup(settingData)
 	helper.Ajax(msg, int64(code), c.Ctx())
 }
@@ -110,7 +120,7 @@ func (c *DatabaseController) backup(settingData map[string]string) (msg string,
 	}
 	fNameBaseName := strings.Replace(strings.Replace(time.Now().In(helper.GetLocation()).Format(helper.TimeFormat), " ", "-", 1), ":", "", 3)
 
-	uploader := getStorageEngine(settingData)
+	uploader := GetStorageEngine(settingData)
 
 	uploadFile := fmt.Sprintf("%s/%s", baseBackupDir, fNameBaseName+".zip")
 	buf := bytes.NewBuffer([]byte{})
@@ -7,6 +7,7 @@ import (
 	"github.com/xiusin/pinecms/src/common/helper"
 )
 
+// @Rest(path = "/department", menu = "部门管理")
 type DepartmentController struct {
 	BaseController
 }
@@ -7,6 +7,7 @@ import (
 	"github.com/xiusin/pinecms/src/common/helper"
 )
 
+// @Rest(path = "/dict/category", menu = "字典分类")
 type DictCategoryController struct {
 	BaseController
 }
@@ -36,7 +37,7 @@ func (c *DictCategoryController) before(act int, param any) error {
 		if len(key) == 0 {
 			return errors.New("字典分类标识不存在")
 		}
-		sess := c.Orm.Table(c.Table).Where("`key` = ?", key)
+		sess := c.Orm.Table(c.Table).Where(c.Orm.Quote("key")+" = ?", key)
 		if OpEdit == act {
 			sess = sess.Where("id <> ?", id)
 		}
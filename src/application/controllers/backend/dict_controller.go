@@ -7,6 +7,7 @@ import (
 	"github.com/xiusin/pinecms/src/common/helper"
 )
 
+// @Rest(path = "/dict", menu = "字典管理")
 type DictController struct {
 	BaseController
 }
@@ -4,10 +4,12 @@ import (
 	"github.com/xiusin/pine"
 	"github.com/xiusin/pinecms/src/application/controllers"
 	"github.com/xiusin/pinecms/src/application/models/tables"
+	"github.com/xiusin/pinecms/src/common/dialect"
 	"github.com/xiusin/pinecms/src/common/helper"
 	"net/http"
 )
 
+// @Rest(path = "/district", menu = "地区管理")
 type DistrictController struct {
 	BaseController
 }
@@ -79,17 +81,17 @@ func (c *DistrictController) PostImport() {
 	}
 	defer resp.Body.Close()
 	c.Orm.DropTables(&tables.District{}) //先删除table
-	if _, err = c.Orm.Import(resp.Body); err != nil {
+	if err = dialect.ImportSQL(c.Orm, resp.Body); err != nil {
 		helper.Ajax(err.Error(), 1, c.Ctx())
 		return
 	}
-	c.Orm.Exec("rename table district to " + controllers.GetTableName("district") + ";")
+	c.Orm.Exec("ALTER TABLE " + c.Orm.Quote("district") + " RENAME TO " + c.Orm.Quote(controllers.GetTableName("district")))
 	helper.Ajax("导入数据库成功", 0, c.Ctx())
 }
 
 func (c *DistrictController) GetSelect()  {
 	parentId, _ := c.Input().GetString("parent_id")
-	c.Orm.Where("parent_id = ?", parentId).OrderBy("`order`").Find(c.Entries)
+	c.Orm.Where("parent_id = ?", parentId).OrderBy(c.Orm.Quote("order")).Find(c.Entries)
 
 	helper.Ajax(c.Entries, 0, c.Ctx())
 }
@@ -4,24 +4,21 @@ import (
 	"errors"
 	"fmt"
 	"github.com/xiusin/pine/contracts"
-	"regexp"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/bytedance/sonic"
-	"github.com/xiusin/pine/di"
+	"github.com/xiusin/pine"
 
 	"github.com/xiusin/pinecms/src/application/controllers"
 	"github.com/xiusin/pinecms/src/application/models"
 	"github.com/xiusin/pinecms/src/application/models/tables"
 	"github.com/xiusin/pinecms/src/common/helper"
-	"xorm.io/xorm"
+	"github.com/xiusin/pinecms/src/common/migration"
 )
 
+// @Rest(path = "/model", menu = "模型管理")
 type DocumentController struct {
-	sqlFieldTypeMap map[string]string
-	extraFields     []map[string]string
 	BaseController
 }
 
@@ -64,53 +61,6 @@ func (c *DocumentController) Construct() {
 	c.Table = &tables.DocumentModel{}
 	c.Entries = &[]*tables.DocumentModel{}
 	c.ApiEntityName = "模型"
-	c.sqlFieldTypeMap = map[string]string{
-		"varchar": "varchar(100)",
-		"int":     "int(10)",
-	}
-	c.extraFields = []map[string]string{
-		{
-			"COLUMN_NAME":    "catid",
-			"EXTRA":          "",
-			"COLUMN_TYPE":    "int",
-			"IS_NULLABLE":    "NO",
-			"COLUMN_COMMENT": "所属栏目ID",
-			"COLUMN_DEFAULT": "0",
-		},
-		{
-			"COLUMN_NAME":    "mid",
-			"EXTRA":          "",
-			"COLUMN_TYPE":    "int",
-			"IS_NULLABLE":    "NO",
-			"COLUMN_COMMENT": "模型ID",
-			"COLUMN_DEFAULT": "0",
-		},
-		{
-			"COLUMN_NAME":    "created_time",
-			"EXTRA":          "",
-			"COLUMN_TYPE":    "datetime",
-			"IS_NULLABLE":    "YES",
-			"COLUMN_COMMENT": "",
-			"COLUMN_DEFAULT": "",
-		},
-		{
-			"COLUMN_NAME":    "updated_time",
-			"EXTRA":          "",
-			"COLUMN_TYPE":    "datetime",
-			"IS_NULLABLE":    "YES",
-			"COLUMN_COMMENT": "",
-			"COLUMN_DEFAULT": "",
-		},
-		{
-			"COLUMN_NAME":    "deleted_time",
-			"EXTRA":          "",
-			"COLUMN_TYPE":    "datetime",
-			"IS_NULLABLE":    "YES",
-			"COLUMN_COMMENT": "",
-			"COLUMN_DEFAULT": "",
-		},
-	}
-
 	c.OpBefore = c.before
 	c.OpAfter = c.after
 	c.BaseController.Construct()
@@ -145,8 +95,11 @@ func (c *DocumentController) after(act int, params any) error {
 			}
 		}
 	}
-	if act == OpDel { // TODO 删除指定字段列表
-
+	if act == OpDel { // 模型删除后移除字段定义, 数据表保留以免误删内容
+		for _, id := range params.(*idParams).Ids {
+			models.NewDocumentFieldDslModel().DeleteByMID(id)
+			helper.Cache().Delete(fmt.Sprintf(controllers.CacheModelTablePrefix, id))
+		}
 	}
 	return nil
 }
@@ -164,101 +117,56 @@ func (c *DocumentController) GetSelect() {
 	helper.Ajax(kv, 0, c.Ctx())
 }
 
-func (c *DocumentController) GetSql(orm *xorm.Engine) {
-	modelID, _ := c.Ctx().Input().GetInt64("mid")
-	model := models.NewDocumentModel()
-	dm := model.GetByID(modelID)
-	if dm == nil {
-		helper.Ajax("模型不存在", 1, c.Ctx())
+// GetSql 对比字段与数据表生成迁移计划, exec=true时执行, 存在数据丢失风险时需confirm=true
+func (c *DocumentController) GetSql() {
+	modelID, _ := c.Input().GetInt64("mid")
+	plan, err := migration.NewPlan(modelID)
+	if err != nil {
+		helper.Ajax(err, 1, c.Ctx())
 		return
 	}
-	dm.Execed = false
-	// 如果已经执行过SQL 直接返回一个错误
-	if dm.Execed {
-		helper.Ajax("没有任何改动可以执行", 1, c.Ctx())
+	if exec, _ := c.Input().GetBool("exec"); !exec {
+		helper.Ajax(pine.H{"sql": plan.SQL(), "steps": plan.Steps, "warnings": plan.Warnings, "checksum": plan.Checksum()}, 0, c.Ctx())
 		return
 	}
-	//由于执行与SQL显示在同一个控制器内, 所以通过exec区分一下
-	exec, _ := c.Ctx().Input().GetBool("exec")
-	// 模型字段
-	fields := models.NewDocumentFieldDslModel().GetList(modelID)
-	// 关联数据
-	fieldTypes := models.NewDocumentModelFieldModel().GetMap()
-	preg, _ := regexp.Compile("/(.+?)\\?")
-	tableSchema := strings.TrimLeft(preg.FindString(orm.DataSourceName()), "/")
-	tableSchema = strings.TrimRight(tableSchema, "?")
-
-	var existsFields []map[string]string
-	var fieldStrs []string
-	querySQL := ""
-	tableName := controllers.GetTableName(dm.Table)
-	if ok, _ := orm.IsTableExist(tableName); ok {
-		querySQL = "ALTER TABLE `" + tableName + "` "
-		existsFields, _ = orm.QueryString("select * from information_schema.columns where TABLE_NAME='" + tableName + "' and  table_schema = '" + tableSchema + "'")
-		for _, field := range fields {
-			var exists bool
-			for _, existsField := range existsFields {
-				if field.TableField == existsField["COLUMN_NAME"] {
-					exists = true
-					break
-				}
-			}
-			if !exists {
-				colType, ok := c.sqlFieldTypeMap[fieldTypes[field.FieldType].Type]
-				if !ok {
-					colType = fieldTypes[field.FieldType].Type
-				}
-				fieldStrs = append(fieldStrs, fmt.Sprintf("\tADD `%s` %s %s %s %s %s", field.TableField, colType, "", "", "", `COMMENT "`+field.FormName+`"`))
-			}
-		}
-		if len(fieldStrs) > 0 {
-			querySQL += "\n" + regexp.MustCompile(" +").ReplaceAllString(strings.Join(fieldStrs, ",\n"), " ")
-		} else {
-			querySQL = ""
-		}
-	} else {
-		existsFields = append(existsFields, c.extraFields...)
-		querySQL += "CREATE TABLE `" + tableName + "` ( \n"
-		querySQL += fmt.Sprintf("\t`%s` %s %s %s %s %s,\n", "id", "int", "NOT NULL", "", "auto_increment", `COMMENT "ID自增字段"`)
+	confirm, _ := c.Input().GetBool("confirm")
+	checksum, _ := c.Input().GetString("checksum")
+	record, err := migration.Apply(plan, checksum, c.Ctx().Value("adminid").(int64), confirm)
+	if err != nil {
+		helper.Ajax(err.Error(), 1, c.Ctx())
+		return
+	}
+	helper.Ajax(record, 0, c.Ctx())
+}
 
-		for _, field := range fields {
-			colType, ok := c.sqlFieldTypeMap[fieldTypes[field.FieldType].Type]
-			if !ok {
-				colType = fieldTypes[field.FieldType].Type
-			}
-			querySQL += fmt.Sprintf("\t`%s` %s %s %s %s %s,\n", field.TableField, strings.ToUpper(colType), "", "", "", `COMMENT "`+field.FormName+`"`)
-		}
-		for _, f := range existsFields {
-			var notNull = ""
-			if f["IS_NULLABLE"] == "NO" {
-				notNull = "NOT NULL"
-			}
-			var defaultVal = ""
-			if f["COLUMN_DEFAULT"] != "" {
-				defaultVal = "DEFAULT '" + f["COLUMN_DEFAULT"] + "'"
-			}
-			querySQL += fmt.Sprintf("\t`%s` %s %s %s %s %s,\n", f["COLUMN_NAME"], strings.ToUpper(f["COLUMN_TYPE"]), notNull, defaultVal, f["EXTRA"], `COMMENT "`+f["COLUMN_COMMENT"]+`"`)
-		}
-		querySQL += "\tPRIMARY KEY (`id`) USING BTREE) \nENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT \"" +
-			strings.ReplaceAll(strings.ReplaceAll(strings.ReplaceAll(dm.Remark, "\"", ""), "`", ""), "\n", "\t") + "\";"
+// GetMigrations 模型的迁移记录
+func (c *DocumentController) GetMigrations() {
+	modelID, _ := c.Input().GetInt64("mid")
+	list, err := migration.History(modelID)
+	if err != nil {
+		helper.Ajax(err, 1, c.Ctx())
+		return
+	}
+	helper.Ajax(list, 0, c.Ctx())
+}
 
+// PostRollback 回滚模型最近一次迁移
+func (c *DocumentController) PostRollback() {
+	var p struct {
+		Mid     int64 `json:"mid"`
+		Force   bool  `json:"force"`
+		Confirm bool  `json:"confirm"`
 	}
-	querySQL = regexp.MustCompile(" +").ReplaceAllString(querySQL, " ")
-	if exec && querySQL != "" {
-		_, err := di.MustGet(&xorm.Engine{}).(*xorm.Engine).Exec(querySQL)
-		if err != nil {
-			helper.Ajax(err.Error(), 1, c.Ctx())
-			return
-		}
-		ret, _ := orm.ID(modelID).Table(&tables.DocumentModel{}).Update(map[string]any{"execed": 1})
-		if ret > 0 {
-			helper.Ajax("执行SQL成功", 0, c.Ctx())
-			return
-		}
-		helper.Ajax("执行SQL失败", 1, c.Ctx())
-	} else {
-		helper.Ajax(querySQL, 0, c.Ctx())
+	_ = c.Ctx().BindJSON(&p)
+	record, warnings, err := migration.Rollback(p.Mid, p.Force, p.Confirm)
+	if errors.Is(err, migration.ErrConfirm) {
+		helper.Ajax(pine.H{"message": err.Error(), "warnings": warnings}, 1, c.Ctx())
+		return
+	} else if err != nil {
+		helper.Ajax(err.Error(), 1, c.Ctx())
+		return
 	}
+	helper.Ajax(record, 0, c.Ctx())
 }
 
 func (c *DocumentController) GetTable(cacher contracts.Cache) {
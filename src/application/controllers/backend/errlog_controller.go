@@ -6,6 +6,7 @@ import (
 	"github.com/xiusin/pinecms/src/common/helper"
 )
 
+// @Rest(path = "/errlog", menu = "错误日志")
 type ErrorLogController struct {
 	BaseController
 }
@@ -2,27 +2,16 @@ package backend
 
 import (
 	"errors"
-	"fmt"
 	"reflect"
 	"strings"
 
 	"github.com/xiusin/pine"
-	"github.com/xiusin/pine/di"
-	"github.com/xiusin/pinecms/src/application/controllers"
 	"github.com/xiusin/pinecms/src/common/storage"
 )
 
-func getStorageEngine(settingData map[string]string) storage.Uploader {
-	engine := settingData["UPLOAD_ENGINE"]
-	var uploadEngine storage.Uploader
-	uploader, err := di.Get(fmt.Sprintf(controllers.ServiceUploaderEngine, engine))
-	if err != nil {
-		pine.Logger().Warn("缺少存储驱动, 自动转换为本地存储", err)
-		uploadEngine = storage.NewFileUploader(settingData)
-	} else {
-		uploadEngine = uploader.(storage.Uploader)
-	}
-	return uploadEngine
+// GetStorageEngine 根据设置获取当前启用的存储驱动
+func GetStorageEngine(settingData map[string]string) storage.Uploader {
+	return storage.Engine(settingData)
 }
 
 func strFirstToUpper(str string) string {
@@ -0,0 +1,222 @@
+package im
+
+import (
+	"errors"
+
+	"github.com/bytedance/sonic"
+	"github.com/fasthttp/websocket"
+	"github.com/xiusin/pine"
+	"github.com/xiusin/pinecms/src/application/controllers/backend"
+	"github.com/xiusin/pinecms/src/application/models/tables"
+	"github.com/xiusin/pinecms/src/common/helper"
+	"xorm.io/xorm"
+)
+
+// ImSessionController 在线客服会话管理
+type ImSessionController struct {
+	backend.BaseController
+}
+
+func (c *ImSessionController) Construct() {
+	c.Table = &tables.ImSession{}
+	c.Entries = &[]tables.ImSession{}
+	c.SearchFields = []backend.SearchFieldDsl{
+		{Field: "status"},
+		{Field: "channel"},
+		{Field: "admin_id"},
+		{Field: "scope", CallBack: func(session *xorm.Session, i ...any) {
+			switch i[0] {
+			case "mine": // 我接待的
+				session.Where("admin_id = ?", c.adminId()).Where("status = ?", tables.ImSessionServing)
+			case "waiting": // 等待接入
+				session.Where("status = ?", tables.ImSessionWaiting)
+			}
+		}},
+	}
+	c.KeywordsSearch = []backend.SearchFieldDsl{
+		{Field: "nickname", Op: "LIKE", DataExp: "%$?%"},
+		{Field: "visitor_id"},
+	}
+	c.BaseController.Construct()
+	c.OpBefore = func(act int, params any) error {
+		if act == backend.OpList {
+			params.(*xorm.Session).Desc("last_time")
+		}
+		return nil
+	}
+}
+
+func (c *ImSessionController) RegisterRoute(b pine.IRouterWrapper) {
+	b.POST("/im/session/page", "SessionPage")
+	b.POST("/im/session/unreadCount", "SessionUnreadCount")
+	b.POST("/im/session/assign", "SessionAssign")
+	b.POST("/im/session/transfer", "SessionTransfer")
+	b.POST("/im/session/close", "SessionClose")
+	b.POST("/im/session/read", "SessionRead")
+	b.POST("/im/message/page", "MessagePage")
+	b.POST("/im/message/send", "MessageSend")
+	b.POST("/im/message/upload", "MessageUpload")
+	b.GET("/im/ws", "Ws")
+}
+
+func (c *ImSessionController) adminId() int64 {
+	id, _ := c.Ctx().Value("adminid").(int64)
+	return id
+}
+
+// SessionPage 会话列表, params.scope: mine=我接待的 waiting=等待接入
+func (c *ImSessionController) SessionPage() {
+	c.PostList()
+}
+
+// SessionUnreadCount 当前客服的未读消息数与等待接入的会话数
+func (c *ImSessionController) SessionUnreadCount() {
+	unread, _ := c.Orm.Where("admin_id = ?", c.adminId()).Where("status = ?", tables.ImSessionServing).
+		SumInt(&tables.ImSession{}, "admin_unread")
+	waiting, _ := c.Orm.Where("status = ?", tables.ImSessionWaiting).Count(&tables.ImSession{})
+	helper.Ajax(pine.H{"unread": unread, "waiting": waiting}, 0, c.Ctx())
+}
+
+// SessionAssign 接入等待中的会话
+func (c *ImSessionController) SessionAssign() {
+	id, _ := c.Input().GetInt64("id")
+	sess, err := getSession(id)
+	if err != nil {
+		helper.Ajax(err, 1, c.Ctx())
+		return
+	}
+	if sess.AdminId > 0 && sess.AdminId != c.adminId() {
+		helper.Ajax("会话已被其他客服接入", 1, c.Ctx())
+		return
+	}
+	if sess, err = Assign(id, c.adminId()); err != nil {
+		helper.Ajax(err, 1, c.Ctx())
+		return
+	}
+	helper.Ajax(sess, 0, c.Ctx())
+}
+
+// SessionTransfer 转接会话给其他客服
+func (c *ImSessionController) SessionTransfer() {
+	id, _ := c.Input().GetInt64("id")
+	adminId, _ := c.Input().GetInt64("admin_id")
+	if exist, _ := c.Orm.ID(adminId).Exist(&tables.Admin{}); !exist {
+		helper.Ajax("客服不存在", 1, c.Ctx())
+		return
+	}
+	sess, err := Assign(id, adminId)
+	if err != nil {
+		helper.Ajax(err, 1, c.Ctx())
+		return
+	}
+	helper.Ajax(sess, 0, c.Ctx())
+}
+
+// SessionClose 结束会话
+func (c *ImSessionController) SessionClose() {
+	id, _ := c.Input().GetInt64("id")
+	if err := Close(id); err != nil {
+		helper.Ajax(err, 1, c.Ctx())
+		return
+	}
+	helper.Ajax("会话已结束", 0, c.Ctx())
+}
+
+// SessionRead 标记会话内访客消息为已读
+func (c *ImSessionController) SessionRead() {
+	id, _ := c.Input().GetInt64("id")
+	sess, err := getSession(id)
+	if err == nil {
+		err = MarkRead(sess, tables.ImSenderAdmin)
+	}
+	if err != nil {
+		helper.Ajax(err, 1, c.Ctx())
+		return
+	}
+	helper.Ajax("标记已读成功", 0, c.Ctx())
+}
+
+// MessagePage 会话消息记录, 按时间倒序分页
+func (c *ImSessionController) MessagePage() {
+	c.Table = &tables.ImMessage{}
+	c.Entries = &[]tables.ImMessage{}
+	c.KeywordsSearch = []backend.SearchFieldDsl{{Field: "content", Op: "LIKE", DataExp: "%$?%"}}
+	c.SearchFields = []backend.SearchFieldDsl{{Field: "session_id"}, {Field: "msg_type"}}
+	c.OpBefore = func(act int, params any) error {
+		if act == backend.OpList {
+			params.(*xorm.Session).Desc("id")
+		}
+		return nil
+	}
+	c.PostList()
+}
+
+// MessageSend 客服发送消息
+func (c *ImSessionController) MessageSend() {
+	var in incoming
+	if err := c.Ctx().BindJSON(&in); err != nil {
+		helper.Ajax(err, 1, c.Ctx())
+		return
+	}
+	msg, err := sendAs(c.adminId(), &in)
+	if err != nil {
+		helper.Ajax(err, 1, c.Ctx())
+		return
+	}
+	helper.Ajax(msg, 0, c.Ctx())
+}
+
+// MessageUpload 客服上传图片或文件
+func (c *ImSessionController) MessageUpload() {
+	upload(c.Ctx(), adminOwner(c.adminId()))
+}
+
+func sendAs(adminId int64, in *incoming) (*tables.ImMessage, error) {
+	sess, err := getSession(in.SessionId)
+	if err != nil {
+		return nil, err
+	}
+	if sess.AdminId > 0 && sess.AdminId != adminId {
+		return nil, errors.New("会话已被其他客服接入")
+	}
+	msg, err := in.message(tables.ImSenderAdmin, adminId, adminOwner(adminId))
+	if err != nil {
+		return nil, err
+	}
+	return msg, SendMessage(sess, msg)
+}
+
+// Ws 客服websocket连接, 用于接收访客消息及会话变更通知
+func (c *ImSessionController) Ws() {
+	adminId := c.adminId()
+	if err := upGrader.Upgrade(c.Ctx().RequestCtx, func(conn *websocket.Conn) {
+		cli := &client{conn: conn}
+		defaultHub.join(defaultHub.admins, adminId, cli)
+		defer defaultHub.leave(defaultHub.admins, adminId, cli)
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var in incoming
+			if err = sonic.Unmarshal(data, &in); err != nil {
+				_ = cli.send(Event{Type: EventError, Data: "消息格式错误"})
+				continue
+			}
+			switch in.Type {
+			case EventRead:
+				var sess *tables.ImSession
+				if sess, err = getSession(in.SessionId); err == nil {
+					err = MarkRead(sess, tables.ImSenderAdmin)
+				}
+			case EventMessage: // 发送成功后会推送给当前客服的全部连接
+				_, err = sendAs(adminId, &in)
+			}
+			if err != nil {
+				_ = cli.send(Event{Type: EventError, Data: err.Error()})
+			}
+		}
+	}); err != nil {
+		pine.Logger().Warn("升级websocket失败", err)
+	}
+}
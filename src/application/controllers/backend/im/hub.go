@@ -0,0 +1,114 @@
+package im
+
+import (
+	"sync"
+
+	"github.com/fasthttp/websocket"
+	"github.com/valyala/fasthttp"
+)
+
+var upGrader = websocket.FastHTTPUpgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024 * 64,
+	CheckOrigin: func(ctx *fasthttp.RequestCtx) bool {
+		return true
+	},
+}
+
+const (
+	EventMessage = "message" // 新消息
+	EventSession = "session" // 会话变更(接入/转接/结束)
+	EventRead    = "read"    // 消息已读
+	EventError   = "error"   // 错误提示
+)
+
+// Event 推送给websocket客户端的数据
+type Event struct {
+	Type string `json:"type"`
+	Data any    `json:"data"`
+}
+
+type client struct {
+	sync.Mutex
+	conn *websocket.Conn
+}
+
+func (c *client) send(e Event) error {
+	c.Lock()
+	defer c.Unlock()
+	return c.conn.WriteJSON(e)
+}
+
+type clients map[*client]struct{}
+
+// hub 维护在线连接, 访客按会话分组, 客服按管理员分组
+type hub struct {
+	sync.RWMutex
+	visitors map[int64]clients
+	admins   map[int64]clients
+}
+
+var defaultHub = &hub{visitors: map[int64]clients{}, admins: map[int64]clients{}}
+
+func (h *hub) join(group map[int64]clients, id int64, c *client) {
+	h.Lock()
+	defer h.Unlock()
+	if group[id] == nil {
+		group[id] = clients{}
+	}
+	group[id][c] = struct{}{}
+}
+
+func (h *hub) leave(group map[int64]clients, id int64, c *client) {
+	h.Lock()
+	defer h.Unlock()
+	delete(group[id], c)
+	if len(group[id]) == 0 {
+		delete(group, id)
+	}
+}
+
+func (h *hub) push(group map[int64]clients, id int64, e Event) bool {
+	h.RLock()
+	list := make([]*client, 0, len(group[id]))
+	for c := range group[id] {
+		list = append(list, c)
+	}
+	h.RUnlock()
+	for _, c := range list {
+		_ = c.send(e)
+	}
+	return len(list) > 0
+}
+
+// toVisitor 推送给会话内的访客, 返回访客是否在线
+func (h *hub) toVisitor(sessionId int64, e Event) bool {
+	return h.push(h.visitors, sessionId, e)
+}
+
+// toAdmin 推送给指定客服, adminId为0时推送给全部在线客服
+func (h *hub) toAdmin(adminId int64, e Event) {
+	if adminId > 0 {
+		h.push(h.admins, adminId, e)
+		return
+	}
+	for _, id := range h.onlineAdmins() {
+		h.push(h.admins, id, e)
+	}
+}
+
+func (h *hub) onlineAdmins() []int64 {
+	h.RLock()
+	defer h.RUnlock()
+	ids := make([]int64, 0, len(h.admins))
+	for id := range h.admins {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func (h *hub) isAdminOnline(adminId int64) bool {
+	h.RLock()
+	defer h.RUnlock()
+	return len(h.admins[adminId]) > 0
+}
@@ -0,0 +1,32 @@
+package im
+
+import (
+	"sync"
+
+	"github.com/xiusin/pine"
+	"github.com/xiusin/pinecms/src/application/models/tables"
+	"github.com/xiusin/pinecms/src/common/helper"
+)
+
+var once sync.Once
+
+func InitInstall() {
+	once.Do(func() {
+		defer func() {
+			if err := recover(); err != nil {
+				pine.Logger().Warn("初始化安装失败", err)
+			}
+		}()
+		if err := helper.GetORM().Sync2(&tables.ImSession{}, &tables.ImMessage{}); err != nil {
+			pine.Logger().Warn(err.Error())
+		}
+	})
+}
+
+func InitRouter(app *pine.Application, router *pine.Router) {
+	InitInstall()
+	app.POST("/api/im/visitor", visitorHandler)
+	app.GET("/api/im/ws", visitorWsHandler)
+	app.POST("/api/im/upload", visitorUploadHandler)
+	router.Handle(new(ImSessionController))
+}
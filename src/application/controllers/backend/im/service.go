@@ -0,0 +1,256 @@
+package im
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/xiusin/pine"
+	"github.com/xiusin/pinecms/src/application/models/tables"
+	"github.com/xiusin/pinecms/src/common/helper"
+)
+
+const (
+	ChannelWeb = "web"
+
+	MsgTypeText  = "text"
+	MsgTypeImage = "image"
+	MsgTypeFile  = "file"
+)
+
+// Channel 外部渠道的消息发送器, 客服回复外部渠道(如微信公众号)访客时调用
+type Channel func(sess *tables.ImSession, msg *tables.ImMessage) error
+
+var channels sync.Map
+
+// RegisterChannel 注册外部渠道, 渠道收到的访客消息通过 Receive 转入客服收件箱
+func RegisterChannel(name string, ch Channel) {
+	channels.Store(name, ch)
+}
+
+// Receive 接收外部渠道访客消息
+func Receive(channel, appid, visitorId, nickname, avatar string, msg *tables.ImMessage) error {
+	sess, err := openSession(channel, appid, visitorId, nickname, avatar)
+	if err != nil {
+		return err
+	}
+	msg.Sender = tables.ImSenderVisitor
+	return SendMessage(sess, msg)
+}
+
+func getSession(id int64) (*tables.ImSession, error) {
+	sess := &tables.ImSession{}
+	if exist, _ := helper.GetORM().ID(id).Get(sess); !exist {
+		return nil, errors.New("会话不存在")
+	}
+	return sess, nil
+}
+
+// openSession 获取访客未结束的会话, 不存在时创建新会话并分配客服
+func openSession(channel, appid, visitorId, nickname, avatar string) (*tables.ImSession, error) {
+	orm := helper.GetORM()
+	sess := &tables.ImSession{}
+	exist, err := orm.Where("channel = ?", channel).Where("appid = ?", appid).Where("visitor_id = ?", visitorId).
+		Where("status < ?", tables.ImSessionClosed).Desc("id").Get(sess)
+	if err != nil {
+		return nil, err
+	}
+	if exist {
+		if (len(nickname) > 0 && nickname != sess.Nickname) || (len(avatar) > 0 && avatar != sess.Avatar) {
+			sess.Nickname, sess.Avatar = helper.Or(nickname, sess.Nickname), helper.Or(avatar, sess.Avatar)
+			orm.ID(sess.Id).Cols("nickname", "avatar").Update(sess)
+		}
+		return sess, nil
+	}
+	sess = &tables.ImSession{
+		Channel:   channel,
+		Appid:     appid,
+		VisitorId: visitorId,
+		Nickname:  helper.Or(nickname, "访客"+visitorId[max(0, len(visitorId)-6):]),
+		Avatar:    avatar,
+		LastTime:  tables.LocalTime(time.Now()),
+	}
+	if sess.AdminId = pickAdmin(); sess.AdminId > 0 {
+		sess.Status = tables.ImSessionServing
+	}
+	if _, err = orm.InsertOne(sess); err != nil {
+		return nil, err
+	}
+	defaultHub.toAdmin(sess.AdminId, Event{Type: EventSession, Data: sess})
+	return sess, nil
+}
+
+// pickAdmin 在线客服中选择接待中会话最少的一位, 无在线客服时进入等待队列
+func pickAdmin() int64 {
+	var picked, least int64 = 0, -1
+	for _, id := range defaultHub.onlineAdmins() {
+		count, _ := helper.GetORM().Where("admin_id = ?", id).Where("status = ?", tables.ImSessionServing).Count(&tables.ImSession{})
+		if least == -1 || count < least {
+			picked, least = id, count
+		}
+	}
+	return picked
+}
+
+func summary(msg *tables.ImMessage) string {
+	switch msg.MsgType {
+	case MsgTypeImage:
+		return "[图片]"
+	case MsgTypeFile:
+		return "[文件]" + msg.FileName
+	}
+	if content := []rune(msg.Content); len(content) > 80 {
+		return string(content[:80]) + "..."
+	}
+	return msg.Content
+}
+
+// SendMessage 保存消息, 更新未读数并推送给在线的接收方. 接收方离线时消息保持未读, 上线后补发
+func SendMessage(sess *tables.ImSession, msg *tables.ImMessage) error {
+	if sess.Status == tables.ImSessionClosed {
+		return errors.New("会话已结束")
+	}
+	switch msg.MsgType {
+	case "":
+		msg.MsgType = MsgTypeText
+	case MsgTypeText:
+	case MsgTypeImage, MsgTypeFile:
+		if len(msg.Url) == 0 {
+			return errors.New("缺少文件地址")
+		}
+	default:
+		return fmt.Errorf("不支持的消息类型%s", msg.MsgType)
+	}
+	if msg.MsgType == MsgTypeText && len(msg.Content) == 0 {
+		return errors.New("消息内容不能为空")
+	}
+
+	orm := helper.GetORM()
+	msg.Id, msg.SessionId, msg.IsRead = 0, sess.Id, false
+	if _, err := orm.InsertOne(msg); err != nil {
+		return err
+	}
+
+	sess.LastMessage, sess.LastTime = summary(msg), tables.LocalTime(time.Now())
+	update := orm.ID(sess.Id).Cols("last_message", "last_time")
+	switch msg.Sender {
+	case tables.ImSenderVisitor:
+		sess.AdminUnread++
+		update.Incr("admin_unread")
+	default:
+		if sess.AdminId == 0 && msg.AdminId > 0 { // 客服直接回复等待中的会话时自动接入
+			sess.AdminId, sess.Status = msg.AdminId, tables.ImSessionServing
+			update.Cols("admin_id", "status")
+		}
+		sess.VisitorUnread++
+		update.Incr("visitor_unread")
+	}
+	if _, err := update.Update(sess); err != nil {
+		return err
+	}
+
+	data := pine.H{"session": sess, "message": msg}
+	defaultHub.toAdmin(sess.AdminId, Event{Type: EventMessage, Data: data})
+	if msg.Sender == tables.ImSenderVisitor {
+		return nil
+	}
+	if sess.Channel == ChannelWeb {
+		defaultHub.toVisitor(sess.Id, Event{Type: EventMessage, Data: msg})
+		return nil
+	}
+	ch, ok := channels.Load(sess.Channel)
+	if !ok {
+		return fmt.Errorf("渠道%s未注册", sess.Channel)
+	}
+	if err := ch.(Channel)(sess, msg); err != nil {
+		return fmt.Errorf("发送到%s失败: %w", sess.Channel, err)
+	}
+	return nil
+}
+
+func adminName(id int64) string {
+	admin := &tables.Admin{}
+	if exist, _ := helper.GetORM().ID(id).Get(admin); !exist {
+		return fmt.Sprintf("客服%d", id)
+	}
+	return helper.Or(admin.Realname, admin.Username)
+}
+
+// Assign 将会话分配给客服, 已有接待客服时视为转接
+func Assign(sessionId, adminId int64) (*tables.ImSession, error) {
+	sess, err := getSession(sessionId)
+	if err != nil {
+		return nil, err
+	}
+	if sess.Status == tables.ImSessionClosed {
+		return nil, errors.New("会话已结束")
+	}
+	if sess.AdminId == adminId {
+		return sess, nil
+	}
+	prev := sess.AdminId
+	sess.AdminId, sess.Status = adminId, tables.ImSessionServing
+	if _, err = helper.GetORM().ID(sess.Id).Cols("admin_id", "status").Update(sess); err != nil {
+		return nil, err
+	}
+	content := fmt.Sprintf("客服%s为您服务", adminName(adminId))
+	if prev > 0 {
+		content = fmt.Sprintf("会话已由%s转接给%s", adminName(prev), adminName(adminId))
+		defaultHub.toAdmin(prev, Event{Type: EventSession, Data: sess})
+	}
+	return sess, SendMessage(sess, &tables.ImMessage{Sender: tables.ImSenderSystem, Content: content})
+}
+
+// Close 结束会话, 访客再次发送消息时创建新会话
+func Close(sessionId int64) error {
+	sess, err := getSession(sessionId)
+	if err != nil || sess.Status == tables.ImSessionClosed {
+		return err
+	}
+	if sess.Channel == ChannelWeb {
+		defaultHub.toVisitor(sess.Id, Event{Type: EventSession, Data: pine.H{"id": sess.Id, "status": tables.ImSessionClosed}})
+	}
+	sess.Status = tables.ImSessionClosed
+	if _, err = helper.GetORM().ID(sess.Id).Cols("status").Update(sess); err != nil {
+		return err
+	}
+	defaultHub.toAdmin(sess.AdminId, Event{Type: EventSession, Data: sess})
+	return nil
+}
+
+// MarkRead 标记对方发送的消息为已读并清零未读数
+func MarkRead(sess *tables.ImSession, reader uint) error {
+	orm := helper.GetORM()
+	query := orm.Where("session_id = ?", sess.Id).Where("is_read = ?", false)
+	unreadCol := "visitor_unread"
+	if reader == tables.ImSenderAdmin {
+		query.Where("sender = ?", tables.ImSenderVisitor)
+		unreadCol = "admin_unread"
+	} else {
+		query.Where("sender <> ?", tables.ImSenderVisitor)
+	}
+	if _, err := query.Cols("is_read").Update(&tables.ImMessage{IsRead: true}); err != nil {
+		return err
+	}
+	if _, err := orm.Table(sess).ID(sess.Id).Update(map[string]any{unreadCol: 0}); err != nil {
+		return err
+	}
+	event := Event{Type: EventRead, Data: pine.H{"session_id": sess.Id, "reader": reader}}
+	if reader == tables.ImSenderAdmin {
+		sess.AdminUnread = 0
+		defaultHub.toVisitor(sess.Id, event)
+	} else {
+		sess.VisitorUnread = 0
+		defaultHub.toAdmin(sess.AdminId, event)
+	}
+	return nil
+}
+
+// pendingMessages 访客离线期间收到的未读消息
+func pendingMessages(sessionId int64) []tables.ImMessage {
+	var list []tables.ImMessage
+	helper.GetORM().Where("session_id = ?", sessionId).Where("sender <> ?", tables.ImSenderVisitor).
+		Where("is_read = ?", false).Asc("id").Find(&list)
+	return list
+}
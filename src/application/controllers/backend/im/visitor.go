@@ -0,0 +1,313 @@
+package im
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/fasthttp/websocket"
+	"github.com/gbrlsnchs/jwt/v3"
+	"github.com/xiusin/pine"
+	"github.com/xiusin/pinecms/src/application/controllers"
+	"github.com/xiusin/pinecms/src/application/controllers/backend"
+	"github.com/xiusin/pinecms/src/application/models/tables"
+	"github.com/xiusin/pinecms/src/common/helper"
+	"github.com/xiusin/pinecms/src/config"
+	"golang.org/x/time/rate"
+)
+
+const maxUploadSize = 20 << 20
+
+var imageExts = []string{".jpg", ".jpeg", ".png", ".gif", ".webp", ".bmp"}
+
+// uploadTypes 允许上传的扩展名与按内容识别的类型, 禁止 html svg 等可在站点域下执行脚本的文件
+var uploadTypes = map[string][]string{
+	".jpg":  {"image/jpeg"},
+	".jpeg": {"image/jpeg"},
+	".png":  {"image/png"},
+	".gif":  {"image/gif"},
+	".webp": {"image/webp"},
+	".bmp":  {"image/bmp"},
+	".pdf":  {"application/pdf"},
+	".txt":  {"text/plain"},
+	".docx": {"application/zip"},
+	".xlsx": {"application/zip"},
+	".zip":  {"application/zip"},
+}
+
+// limiterGroup 按访客限流
+type limiterGroup struct {
+	sync.Mutex
+	every    time.Duration
+	burst    int
+	limiters map[string]*rate.Limiter
+}
+
+func newLimiterGroup(every time.Duration, burst int) *limiterGroup {
+	return &limiterGroup{every: every, burst: burst, limiters: map[string]*rate.Limiter{}}
+}
+
+func (g *limiterGroup) allow(key string) bool {
+	g.Lock()
+	defer g.Unlock()
+	limiter, exist := g.limiters[key]
+	if !exist {
+		if len(g.limiters) > 10000 {
+			g.limiters = map[string]*rate.Limiter{}
+		}
+		limiter = rate.NewLimiter(rate.Every(g.every), g.burst)
+		g.limiters[key] = limiter
+	}
+	return limiter.Allow()
+}
+
+var (
+	uploadLimiter  = newLimiterGroup(12*time.Second, 5) // 访客上传每分钟最多5次
+	messageLimiter = newLimiterGroup(time.Second, 10)   // 访客发言每秒1条, 最多连发10条
+)
+
+// fileTTL 上传文件凭证的有效期
+const fileTTL = time.Hour
+
+// filePayload 上传文件凭证, 发送图片或文件消息时以凭证中的地址、文件名与大小为准
+type filePayload struct {
+	jwt.Payload
+	Owner    string `json:"owner"`
+	MsgType  string `json:"msg_type"`
+	Url      string `json:"url"`
+	FileName string `json:"file_name"`
+	FileSize int64  `json:"file_size"`
+}
+
+// 上传者标识, 文件凭证只能由上传者使用
+func visitorOwner(visitorId string) string { return "visitor:" + visitorId }
+
+func adminOwner(adminId int64) string { return "admin:" + strconv.FormatInt(adminId, 10) }
+
+// incoming websocket客户端发送的数据
+type incoming struct {
+	Type      string `json:"type"` // message=发送消息 read=标记已读
+	SessionId int64  `json:"session_id"`
+	MsgType   string `json:"msg_type"`
+	Content   string `json:"content"`
+	FileToken string `json:"file_token"` // 图片与文件消息为上传接口返回的凭证
+}
+
+// message 生成消息, 图片与文件消息校验上传凭证属于 owner
+func (in *incoming) message(sender uint, adminId int64, owner string) (*tables.ImMessage, error) {
+	msg := &tables.ImMessage{Sender: sender, AdminId: adminId, MsgType: in.MsgType, Content: in.Content}
+	if in.MsgType != MsgTypeImage && in.MsgType != MsgTypeFile {
+		return msg, nil
+	}
+	var pl filePayload
+	if err := config.JwtVerify(config.JwtAudienceImFile, []byte(in.FileToken), &pl.Payload, &pl); err != nil || pl.Owner != owner {
+		return nil, errors.New("文件凭证无效, 请重新上传")
+	}
+	msg.MsgType, msg.Url, msg.FileName, msg.FileSize = pl.MsgType, pl.Url, pl.FileName, pl.FileSize
+	return msg, nil
+}
+
+func parseVisitor(ctx *pine.Context) (*controllers.ImVisitorPayload, error) {
+	token := ctx.Header("Authorization")
+	if token == "" {
+		token, _ = ctx.Input().GetString("token")
+	}
+	if token == "" {
+		return nil, errors.New("缺少访客凭证")
+	}
+	var pl controllers.ImVisitorPayload
+	if err := config.JwtVerify(config.JwtAudienceVisitor, []byte(token), &pl.Payload, &pl); err != nil || len(pl.VisitorId) == 0 {
+		return nil, errors.New("访客凭证无效")
+	}
+	return &pl, nil
+}
+
+// visitorHandler 签发访客凭证, 已有有效凭证时更新昵称头像后重新签发
+func visitorHandler(ctx *pine.Context) {
+	p := struct {
+		Nickname string `json:"nickname"`
+		Avatar   string `json:"avatar"`
+	}{}
+	_ = ctx.BindJSON(&p)
+	pl, err := parseVisitor(ctx)
+	if err != nil {
+		pl = &controllers.ImVisitorPayload{VisitorId: string(helper.Krand(24, 3))}
+	}
+	pl.Payload = jwt.Payload{Subject: "PineCMS", ExpirationTime: jwt.NumericDate(time.Now().Add(30 * 24 * time.Hour))}
+	pl.Nickname, pl.Avatar = helper.Or(p.Nickname, pl.Nickname), helper.Or(p.Avatar, pl.Avatar)
+	token, err := config.JwtSign(config.JwtAudienceVisitor, &pl.Payload, pl)
+	if err != nil {
+		helper.Ajax("签发访客凭证失败", 1, ctx)
+		return
+	}
+	helper.Ajax(pine.H{"visitor_id": pl.VisitorId, "token": string(token)}, 0, ctx)
+}
+
+// visitorWsHandler 访客websocket连接, 连接后推送当前会话与离线消息
+func visitorWsHandler(ctx *pine.Context) {
+	pl, err := parseVisitor(ctx)
+	if err != nil {
+		helper.Ajax(err.Error(), 1, ctx)
+		return
+	}
+	if err = upGrader.Upgrade(ctx.RequestCtx, func(conn *websocket.Conn) {
+		c := &client{conn: conn}
+		sess, err := openSession(ChannelWeb, "", pl.VisitorId, pl.Nickname, pl.Avatar)
+		if err != nil {
+			_ = c.send(Event{Type: EventError, Data: err.Error()})
+			return
+		}
+		defaultHub.join(defaultHub.visitors, sess.Id, c)
+		defer func() { defaultHub.leave(defaultHub.visitors, sess.Id, c) }()
+
+		_ = c.send(Event{Type: EventSession, Data: sess})
+		if pending := pendingMessages(sess.Id); len(pending) > 0 {
+			for i := range pending {
+				_ = c.send(Event{Type: EventMessage, Data: &pending[i]})
+			}
+			_ = MarkRead(sess, tables.ImSenderVisitor)
+		}
+
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var in incoming
+			if err = sonic.Unmarshal(data, &in); err != nil {
+				_ = c.send(Event{Type: EventError, Data: "消息格式错误"})
+				continue
+			}
+			// 会话被客服结束后, 访客再次发言时开启新会话
+			current, err := openSession(ChannelWeb, "", pl.VisitorId, pl.Nickname, pl.Avatar)
+			if err != nil {
+				_ = c.send(Event{Type: EventError, Data: err.Error()})
+				continue
+			}
+			if current.Id != sess.Id {
+				defaultHub.leave(defaultHub.visitors, sess.Id, c)
+				sess = current
+				defaultHub.join(defaultHub.visitors, sess.Id, c)
+				_ = c.send(Event{Type: EventSession, Data: sess})
+			}
+			switch in.Type {
+			case EventRead:
+				err = MarkRead(sess, tables.ImSenderVisitor)
+			case EventMessage:
+				if !messageLimiter.allow(pl.VisitorId) {
+					err = errors.New("发送过于频繁, 请稍后再试")
+					break
+				}
+				var msg *tables.ImMessage
+				if msg, err = in.message(tables.ImSenderVisitor, 0, visitorOwner(pl.VisitorId)); err != nil {
+					break
+				}
+				if err = SendMessage(sess, msg); err == nil {
+					_ = c.send(Event{Type: EventMessage, Data: msg})
+				}
+			}
+			if err != nil {
+				_ = c.send(Event{Type: EventError, Data: err.Error()})
+			}
+		}
+	}); err != nil {
+		pine.Logger().Warn("升级websocket失败", err)
+	}
+}
+
+// visitorUploadHandler 访客上传图片或文件, 需有未结束的会话
+func visitorUploadHandler(ctx *pine.Context) {
+	pl, err := parseVisitor(ctx)
+	if err != nil {
+		helper.Ajax(err.Error(), 1, ctx)
+		return
+	}
+	if exist, _ := helper.GetORM().Where("channel = ?", ChannelWeb).Where("visitor_id = ?", pl.VisitorId).
+		Where("status < ?", tables.ImSessionClosed).Exist(&tables.ImSession{}); !exist {
+		helper.Ajax("会话已结束, 请发送消息后再上传", 1, ctx)
+		return
+	}
+	if !uploadLimiter.allow(pl.VisitorId) {
+		helper.Ajax("上传过于频繁, 请稍后再试", 1, ctx)
+		return
+	}
+	upload(ctx, visitorOwner(pl.VisitorId))
+}
+
+// upload 上传聊天文件到当前存储驱动, 返回发送消息所需的文件凭证, 凭证只能由 owner 使用
+func upload(ctx *pine.Context, owner string) {
+	fh, err := ctx.FormFile("file")
+	if err != nil {
+		helper.Ajax("读取上传文件失败", 1, ctx)
+		return
+	}
+	if fh.Size > maxUploadSize {
+		helper.Ajax("文件不能超过20M", 1, ctx)
+		return
+	}
+	ext := strings.ToLower(filepath.Ext(fh.Filename))
+	types, allowed := uploadTypes[ext]
+	if !allowed {
+		helper.Ajax("不支持上传该类型的文件", 1, ctx)
+		return
+	}
+	f, err := fh.Open()
+	if err != nil {
+		helper.Ajax("读取上传文件失败", 1, ctx)
+		return
+	}
+	defer f.Close()
+
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(f, head)
+	if !matchType(http.DetectContentType(head[:n]), types) {
+		helper.Ajax("文件内容与扩展名不符", 1, ctx)
+		return
+	}
+	if _, err = f.Seek(0, io.SeekStart); err != nil {
+		helper.Ajax("读取上传文件失败", 1, ctx)
+		return
+	}
+	url, err := backend.GetStorageEngine(config.CtxSiteConfig(ctx)).Upload("im/"+helper.NowDate("20060102")+"/"+string(helper.Krand(16, 3))+ext, f)
+	if err != nil {
+		helper.Ajax(err, 1, ctx)
+		return
+	}
+	msgType := MsgTypeFile
+	for _, v := range imageExts {
+		if v == ext {
+			msgType = MsgTypeImage
+			break
+		}
+	}
+	pl := &filePayload{
+		Payload:  jwt.Payload{Subject: "PineCMS", ExpirationTime: jwt.NumericDate(time.Now().Add(fileTTL))},
+		Owner:    owner,
+		MsgType:  msgType,
+		Url:      url,
+		FileName: filepath.Base(fh.Filename),
+		FileSize: fh.Size,
+	}
+	token, err := config.JwtSign(config.JwtAudienceImFile, &pl.Payload, pl)
+	if err != nil {
+		helper.Ajax("签发文件凭证失败", 1, ctx)
+		return
+	}
+	helper.Ajax(pine.H{"msg_type": msgType, "url": url, "file_name": pl.FileName, "file_size": pl.FileSize, "file_token": string(token)}, 0, ctx)
+}
+
+func matchType(contentType string, types []string) bool {
+	contentType, _, _ = strings.Cut(contentType, ";")
+	for _, t := range types {
+		if contentType == t {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+package im
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gbrlsnchs/jwt/v3"
+	"github.com/xiusin/pinecms/src/application/models/tables"
+	"github.com/xiusin/pinecms/src/config"
+)
+
+// fileToken 签发上传文件凭证
+func fileToken(t *testing.T, audience, owner string, ttl time.Duration) string {
+	t.Helper()
+	pl := &filePayload{
+		Payload:  jwt.Payload{ExpirationTime: jwt.NumericDate(time.Now().Add(ttl))},
+		Owner:    owner,
+		MsgType:  MsgTypeFile,
+		Url:      "/uploads/im/a.pdf",
+		FileName: "a.pdf",
+		FileSize: 100,
+	}
+	token, err := config.JwtSign(audience, &pl.Payload, pl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(token)
+}
+
+func TestIncomingMessage(t *testing.T) {
+	owner := visitorOwner("v1")
+	in := &incoming{MsgType: MsgTypeImage, FileToken: fileToken(t, config.JwtAudienceImFile, owner, time.Hour)}
+	msg, err := in.message(tables.ImSenderVisitor, 0, owner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 地址、文件名、大小与类型以凭证为准
+	if msg.Url != "/uploads/im/a.pdf" || msg.FileName != "a.pdf" || msg.FileSize != 100 || msg.MsgType != MsgTypeFile {
+		t.Errorf("消息应使用凭证中的文件信息: %+v", msg)
+	}
+
+	cases := map[string]*incoming{
+		"缺少凭证":  {MsgType: MsgTypeImage},
+		"其他访客":  {MsgType: MsgTypeFile, FileToken: fileToken(t, config.JwtAudienceImFile, visitorOwner("v2"), time.Hour)},
+		"凭证已过期": {MsgType: MsgTypeFile, FileToken: fileToken(t, config.JwtAudienceImFile, owner, -time.Minute)},
+		"其他受众":  {MsgType: MsgTypeFile, FileToken: fileToken(t, config.JwtAudienceVisitor, owner, time.Hour)},
+	}
+	for name, in := range cases {
+		if _, err = in.message(tables.ImSenderVisitor, 0, owner); err == nil {
+			t.Errorf("%s: 应拒绝发送文件消息", name)
+		}
+	}
+
+	msg, err = (&incoming{MsgType: MsgTypeText, Content: "你好"}).message(tables.ImSenderVisitor, 0, owner)
+	if err != nil || msg.Url != "" || msg.Content != "你好" {
+		t.Errorf("文本消息不需要凭证: %+v %v", msg, err)
+	}
+}
+
+func TestMessageLimiter(t *testing.T) {
+	g := newLimiterGroup(time.Hour, 2)
+	if !g.allow("v1") || !g.allow("v1") {
+		t.Fatal("突发次数内应允许")
+	}
+	if g.allow("v1") {
+		t.Error("超过突发次数应限流")
+	}
+	if !g.allow("v2") {
+		t.Error("不同访客不应互相影响")
+	}
+}
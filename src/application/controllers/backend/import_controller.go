@@ -0,0 +1,249 @@
+package backend
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bytedance/sonic"
+	"github.com/valyala/fasthttp"
+	"github.com/xiusin/pine"
+	"github.com/xiusin/pinecms/src/application/models"
+	"github.com/xiusin/pinecms/src/application/models/tables"
+	"github.com/xiusin/pinecms/src/common/helper"
+	"github.com/xiusin/pinecms/src/common/importer"
+	"github.com/xiusin/pinecms/src/config"
+	"xorm.io/xorm"
+)
+
+// ImportController 导入WordPress WXR与CSV/JSON内容
+// @Rest(path = "/import", menu = "内容导入")
+type ImportController struct {
+	BaseController
+}
+
+type importMappingParams struct {
+	Id        int64             `json:"id"`
+	ModelId   int64             `json:"model_id"`
+	Catid     int64             `json:"catid"`
+	SourceKey string            `json:"source_key"`
+	IdField   string            `json:"id_field"`
+	Mapping   map[string]string `json:"mapping"`
+}
+
+func (c *ImportController) Construct() {
+	c.KeywordsSearch = []SearchFieldDsl{
+		{Field: "origin_name", Op: "LIKE", DataExp: "%$?%"},
+	}
+	c.SearchFields = []SearchFieldDsl{
+		{Op: "=", Field: "source"},
+		{Op: "=", Field: "status"},
+	}
+	c.Table = &tables.ImportJob{}
+	c.Entries = &[]tables.ImportJob{}
+	c.ApiEntityName = "导入任务"
+	c.Group = "内容导入"
+	c.BaseController.Construct()
+	c.OpBefore = c.before
+}
+
+func (c *ImportController) before(act int, params any) error {
+	switch act {
+	case OpList:
+		params.(*xorm.Session).Where("site_id = ?", config.CtxSiteId(c.Ctx())).Desc("id")
+	case OpAdd, OpEdit:
+		return errors.New("请通过上传文件创建导入任务")
+	case OpDel:
+		ids := params.(*idParams)
+		var jobs []tables.ImportJob
+		c.Orm.In("id", ids.Ids).Find(&jobs)
+		for _, job := range jobs {
+			if importer.IsRunning(job.Id) {
+				return errors.New("任务正在执行, 无法删除")
+			}
+			_ = os.Remove(job.File)
+		}
+	}
+	return nil
+}
+
+func (c *ImportController) job(id int64) (*tables.ImportJob, error) {
+	job := &tables.ImportJob{}
+	if ok, _ := c.Orm.ID(id).Where("site_id = ?", config.CtxSiteId(c.Ctx())).Get(job); !ok {
+		return nil, errors.New("导入任务不存在")
+	}
+	return job, nil
+}
+
+// PostUpload 上传导入文件创建任务, CSV/JSON需要再配置字段映射
+func (c *ImportController) PostUpload() {
+	fh, err := c.Ctx().FormFile("file")
+	if err != nil {
+		helper.Ajax("请上传导入文件", 1, c.Ctx())
+		return
+	}
+	source, _ := c.Input().GetString("source")
+	if len(source) == 0 {
+		source = strings.TrimPrefix(strings.ToLower(filepath.Ext(fh.Filename)), ".")
+		if source == "xml" {
+			source = importer.SourceWXR
+		}
+	}
+	if !helper.InArray(source, importer.Sources()) {
+		helper.Ajax("不支持的数据源类型", 1, c.Ctx())
+		return
+	}
+	job := &tables.ImportJob{
+		SiteId:     config.CtxSiteId(c.Ctx()),
+		Source:     source,
+		OriginName: fh.Filename,
+		AdminId:    c.Ctx().Value("adminid").(int64),
+	}
+	job.ModelId, _ = c.Input().GetInt64("model_id")
+	job.Catid, _ = c.Input().GetInt64("catid")
+	job.SourceKey, _ = c.Input().GetString("source_key")
+
+	dir := config.RuntimePath("import")
+	_ = os.MkdirAll(dir, os.ModePerm)
+	job.File = filepath.Join(dir, string(helper.Krand(16, 3))+"."+source)
+	if err := fasthttp.SaveMultipartFile(fh, job.File); err != nil {
+		helper.Ajax("保存导入文件失败: "+err.Error(), 1, c.Ctx())
+		return
+	}
+	if _, err := c.Orm.InsertOne(job); err != nil {
+		helper.Ajax(err, 1, c.Ctx())
+		return
+	}
+	c.columns(job)
+}
+
+// PostColumns 读取CSV/JSON的列与示例数据, 以及模型可映射的字段
+func (c *ImportController) PostColumns() {
+	var p importMappingParams
+	_ = c.Ctx().BindJSON(&p)
+	job, err := c.job(p.Id)
+	if err != nil {
+		helper.Ajax(err, 1, c.Ctx())
+		return
+	}
+	c.columns(job)
+}
+
+func (c *ImportController) columns(job *tables.ImportJob) {
+	data := pine.H{"job": job}
+	if job.Source != importer.SourceWXR {
+		columns, rows, err := importer.Columns(job, 5)
+		if err != nil {
+			helper.Ajax("读取文件失败: "+err.Error(), 1, c.Ctx())
+			return
+		}
+		data["columns"], data["rows"] = columns, rows
+	}
+	if job.ModelId > 0 {
+		var fields []tables.DocumentModelDsl
+		c.Orm.Where("mid = ?", job.ModelId).Cols("table_field", "form_name", "required").Asc("listorder").Find(&fields)
+		data["fields"] = fields
+	}
+	helper.Ajax(data, 0, c.Ctx())
+}
+
+// PostMapping 设置导入模型, 默认栏目与字段映射
+func (c *ImportController) PostMapping() {
+	var p importMappingParams
+	if err := c.Ctx().BindJSON(&p); err != nil {
+		helper.Ajax(err, 1, c.Ctx())
+		return
+	}
+	job, err := c.job(p.Id)
+	if err != nil {
+		helper.Ajax(err, 1, c.Ctx())
+		return
+	}
+	if job.Status == importer.StatusRunning && importer.IsRunning(job.Id) {
+		helper.Ajax("任务正在执行", 1, c.Ctx())
+		return
+	}
+	model := models.NewDocumentModel().GetByID(p.ModelId)
+	if model == nil || model.Id == 0 {
+		helper.Ajax("模型不存在", 1, c.Ctx())
+		return
+	}
+	columns := models.NewDataSourceModel().TableColumns(model.Table)
+	for column, field := range p.Mapping {
+		if len(field) > 0 && !helper.InArray(field, columns) {
+			helper.Ajax("列"+column+"映射的字段"+field+"不存在", 1, c.Ctx())
+			return
+		}
+	}
+	job.ModelId, job.Catid, job.IdField = p.ModelId, p.Catid, p.IdField
+	if len(p.SourceKey) > 0 {
+		job.SourceKey = p.SourceKey
+	}
+	job.Mapping, _ = sonic.MarshalString(p.Mapping)
+	if _, err := c.Orm.ID(job.Id).Cols("model_id", "catid", "id_field", "source_key", "mapping").Update(job); err != nil {
+		helper.Ajax(err, 1, c.Ctx())
+		return
+	}
+	helper.Ajax("保存成功", 0, c.Ctx())
+}
+
+// PostStart 开始或继续导入, restart=true时从头处理(已导入的数据仍会跳过)
+func (c *ImportController) PostStart() {
+	var p struct {
+		Id      int64 `json:"id"`
+		Restart bool  `json:"restart"`
+	}
+	_ = c.Ctx().BindJSON(&p)
+	job, err := c.job(p.Id)
+	if err != nil {
+		helper.Ajax(err, 1, c.Ctx())
+		return
+	}
+	if importer.IsRunning(job.Id) {
+		helper.Ajax("任务正在执行", 1, c.Ctx())
+		return
+	}
+	if job.ModelId == 0 {
+		helper.Ajax("请先选择导入的模型", 1, c.Ctx())
+		return
+	}
+	if p.Restart || job.Status == importer.StatusDone {
+		job.Cursor, job.Success, job.Failed, job.Skipped = 0, 0, 0, 0
+	}
+	uploader := GetStorageEngine(config.CtxSiteConfig(c.Ctx()))
+	go func() {
+		if err := importer.Run(job, uploader); err != nil {
+			pine.Logger().Warn("导入任务执行失败", job.Id, err)
+		}
+	}()
+	helper.Ajax("任务已开始", 0, c.Ctx())
+}
+
+// PostErrors 导入失败的记录
+func (c *ImportController) PostErrors() {
+	var p struct {
+		Id   int64 `json:"id"`
+		Page int   `json:"page"`
+		Size int   `json:"size"`
+	}
+	_ = c.Ctx().BindJSON(&p)
+	if _, err := c.job(p.Id); err != nil {
+		helper.Ajax(err, 1, c.Ctx())
+		return
+	}
+	if p.Page < 1 {
+		p.Page = 1
+	}
+	if p.Size < 1 || p.Size > 100 {
+		p.Size = 20
+	}
+	var items []tables.ImportItem
+	total, err := c.Orm.Where("job_id = ? AND status = ?", p.Id, false).Asc("line").
+		Limit(p.Size, (p.Page-1)*p.Size).FindAndCount(&items)
+	if err != nil {
+		helper.Ajax(err, 1, c.Ctx())
+		return
+	}
+	helper.Ajax(pine.H{"list": items, "pagination": pine.H{"page": p.Page, "size": p.Size, "total": total}}, 0, c.Ctx())
+}
@@ -16,14 +16,12 @@ import (
 	"github.com/xiusin/pinecms/src/common/helper"
 )
 
+// @Rest(path = "/", magic = "false")
 type IndexController struct {
 	pine.Controller
 }
 
-func (c *IndexController) RegisterRoute(b pine.IRouterWrapper) {
-	b.ANY("/index/main", "Main")
-}
-
+// @Rest(method = "ANY", route = "/index/main", title = "后台首页统计", group = "系统")
 func (c *IndexController) Main(orm *xorm.Engine, iCache contracts.Cache) {
 	//var us, _ = disk.Usage(helper.GetRootPath())
 	//要转换的值，fmt方式，切割长度如果为-1则显示最大长度，64是float64
@@ -5,6 +5,7 @@ import (
 	"xorm.io/xorm"
 )
 
+// @Rest(path = "/level", menu = "职级管理")
 type LevelController struct {
 	BaseController
 }
@@ -2,6 +2,7 @@ package backend
 
 import "github.com/xiusin/pinecms/src/application/models/tables"
 
+// @Rest(path = "/link", menu = "友链管理")
 type LinkController struct {
 	BaseController
 }
@@ -15,4 +16,5 @@ func (c *LinkController) Construct() {
 	c.ApiEntityName = "友链"
 	c.Group = "友情链接管理"
 	c.BaseController.Construct()
+	c.OpBefore = c.SiteScope
 }
@@ -6,6 +6,7 @@ import (
 	"github.com/xiusin/pinecms/src/common/helper"
 )
 
+// @Rest(path = "/log", menu = "操作日志")
 type LogController struct {
 	BaseController
 }
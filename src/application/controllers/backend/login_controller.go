@@ -7,30 +7,20 @@ import (
 
 	"github.com/xiusin/pine"
 	"github.com/xiusin/pinecms/src/application/controllers"
-	"github.com/xiusin/pinecms/src/application/controllers/middleware/apidoc"
 	"github.com/xiusin/pinecms/src/application/models"
 	"github.com/xiusin/pinecms/src/common/helper"
 	"github.com/xiusin/pinecms/src/config"
 )
 
+// @Rest(path = "/", magic = "false")
 type LoginController struct {
 	pine.Controller
 }
 
-func (c *LoginController) RegisterRoute(b pine.IRouterWrapper) {
-	b.ANY("/login", "Login")
-}
-
+// Login 账号密码登录系统, 返回JWT凭证
+// @Rest(method = "ANY", route = "/login", title = "登录系统", group = "登录模块", req = "loginUserParam", middleware = "limiter")
 func (c *LoginController) Login() {
 	var p loginUserParam
-	apidoc.SetApiEntity(c.Ctx(), &apidoc.Entity{
-		ApiParam: &p,
-		AppId:    "admin",
-		Group:    "登录模块",
-		SubGroup: "系统登录",
-		Title:    "登录系统",
-		Desc:     "账号密码登录系统， 并且返回JWT凭证",
-	})
 
 	helper.PanicErr(parseParam(c.Ctx(), &p))
 
@@ -50,7 +40,6 @@ func (c *LoginController) Login() {
 		helper.Ajax(err, 1, c.Ctx())
 		return
 	}
-	var hs = jwt.NewHS256([]byte(config.App().JwtKey))
 	now := time.Now()
 	pl := controllers.LoginAdminPayload{
 		Payload: jwt.Payload{
@@ -62,7 +51,7 @@ func (c *LoginController) Login() {
 		RoleID:    admin.RoleIdList,
 		AdminName: admin.Username,
 	}
-	if token, err := jwt.Sign(pl, hs); err != nil {
+	if token, err := config.JwtSign(config.JwtAudienceAdmin, &pl.Payload, &pl); err != nil {
 		helper.Ajax("登录失败", 1, c.Ctx())
 	} else {
 		helper.Ajax(pine.H{
@@ -4,9 +4,11 @@ import (
 	"errors"
 
 	"github.com/xiusin/pinecms/src/application/models/tables"
+	"github.com/xiusin/pinecms/src/common/hooks"
 	"xorm.io/builder"
 )
 
+// @Rest(path = "/member", menu = "会员管理")
 type MemberController struct {
 	BaseController
 }
@@ -22,6 +24,7 @@ func (c *MemberController) Construct() {
 
 	c.BaseController.Construct()
 	c.OpBefore = c.before
+	c.OpAfter = c.after
 }
 
 func (c *MemberController) before(act int, params any) error {
@@ -31,6 +34,7 @@ func (c *MemberController) before(act int, params any) error {
 		if exist, _ := c.Orm.Table(c.Table).Where("account = ?", data.Account).Or("email = ?", data.Email).Exist(); exist {
 			return errors.New("账号或邮箱已存在")
 		}
+		return hooks.MemberBeforeRegister.Emit(&hooks.MemberEvent{Member: data, Source: hooks.SourceBackend})
 
 	case OpEdit:
 		data := c.Table.(*tables.Member)
@@ -41,3 +45,10 @@ func (c *MemberController) before(act int, params any) error {
 	}
 	return nil
 }
+
+func (c *MemberController) after(act int, params any) error {
+	if act == OpAdd {
+		hooks.MemberAfterRegister.Fire(&hooks.MemberEvent{Member: params.(*tables.Member), Source: hooks.SourceBackend})
+	}
+	return nil
+}
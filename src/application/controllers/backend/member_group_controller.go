@@ -8,6 +8,7 @@ import (
 	"xorm.io/xorm"
 )
 
+// @Rest(path = "/member/group", menu = "会员分组")
 type MemberGroupController struct {
 	BaseController
 }
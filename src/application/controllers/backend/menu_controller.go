@@ -6,6 +6,7 @@ import (
 	"github.com/xiusin/pinecms/src/common/helper"
 )
 
+// @Rest(path = "/menu", menu = "菜单管理")
 type MenuController struct {
 	BaseController
 }
@@ -6,12 +6,16 @@ import (
 	"time"
 
 	"github.com/bytedance/sonic"
+	"github.com/xiusin/pine"
 	"github.com/xiusin/pinecms/src/application/models/tables"
 	"github.com/xiusin/pinecms/src/application/plugins"
+	"github.com/xiusin/pinecms/src/application/plugins/market"
+	"github.com/xiusin/pinecms/src/application/plugins/sdk"
 	"github.com/xiusin/pinecms/src/common/helper"
 	"xorm.io/xorm"
 )
 
+// @Rest(path = "/plugin", menu = "插件管理")
 type PluginController struct {
 	BaseController
 }
@@ -120,6 +124,75 @@ func (c *PluginController) PostInstall() {
 	}
 }
 
+// PostUninstall 卸载插件, 结束插件进程并删除插件记录及其菜单
+func (c *PluginController) PostUninstall() {
+	path, _ := c.Input().GetString("path")
+	if len(path) == 0 {
+		helper.Ajax("请传入要卸载的插件地址", 1, c.Ctx())
+		return
+	}
+	plugin := &tables.Plugin{}
+	if exist, _ := c.Orm.Where("path = ?", path).Get(plugin); !exist {
+		helper.Ajax("插件未安装", 1, c.Ctx())
+		return
+	}
+	plugins.PluginMgr().Uninstall(path)
+	if _, err := c.Orm.Transaction(func(session *xorm.Session) (any, error) {
+		if _, err := session.Where("plugin_id = ?", plugin.Id).Delete(&tables.Menu{}); err != nil {
+			return nil, err
+		}
+		return session.ID(plugin.Id).Delete(&tables.Plugin{})
+	}); err != nil {
+		helper.Ajax(err.Error(), 1, c.Ctx())
+		return
+	}
+	helper.Ajax("success", 0, c.Ctx())
+}
+
+// GetMarket 插件市场列表, 标记已安装版本与可安装的最新版本
+func (c *PluginController) GetMarket() {
+	mgr := plugins.PluginMgr()
+	idx, err := mgr.MarketIndex()
+	if err != nil {
+		helper.Ajax("读取插件仓库失败: "+err.Error(), 1, c.Ctx())
+		return
+	}
+	env, installed := market.CurrentEnv(), mgr.Installed()
+	list := make([]pine.H, 0, len(idx.Plugins))
+	for _, plugin := range idx.Plugins {
+		item := pine.H{"plugin": plugin, "installed": installed[plugin.Name]}
+		if latest, err := plugin.Latest(env, ""); err != nil {
+			item["error"] = err.Error()
+		} else {
+			item["latest"] = latest.Version
+			item["upgradable"] = len(installed[plugin.Name]) > 0 && market.Compare(latest.Version, installed[plugin.Name]) > 0
+		}
+		list = append(list, item)
+	}
+	helper.Ajax(list, 0, c.Ctx())
+}
+
+// PostMarketInstall 从插件市场安装或升级插件, version 为版本约束, 为空时安装最新兼容版本
+func (c *PluginController) PostMarketInstall() {
+	name, _ := c.Input().GetString("name")
+	if len(name) == 0 {
+		helper.Ajax("请传入插件名称", 1, c.Ctx())
+		return
+	}
+	version, _ := c.Input().GetString("version")
+	steps, err := plugins.PluginMgr().MarketInstall(name, version)
+	if err != nil {
+		helper.Ajax(err.Error(), 1, c.Ctx())
+		return
+	}
+	helper.Ajax(steps, 0, c.Ctx())
+}
+
+// GetMarketProgress 插件下载安装进度
+func (c *PluginController) GetMarketProgress() {
+	helper.Ajax(market.Progresses(), 0, c.Ctx())
+}
+
 func (c *PluginController) PostEnable() {
 	path, _ := c.Input().GetBytes("path")
 	if path == nil || len(path) == 0 {
@@ -142,17 +215,39 @@ func (c *PluginController) GetConfig() {
 	plugin := &tables.Plugin{}
 	path, _ := c.Ctx().Input().GetString("path")
 	c.Orm.Where("path = ?", path).Get(plugin)
-	helper.Ajax(plugin.Config, 0, c.Ctx())
+	settings, err := sdk.PluginSettings(plugin)
+	if err != nil {
+		settings = plugin.Config
+	}
+	helper.Ajax(settings, 0, c.Ctx())
 }
 
 func (c *PluginController) PostConfig() {
+	form := &tables.Plugin{}
+	if err := c.Ctx().BindJSON(form); err != nil {
+		helper.Ajax(err.Error(), 1, c.Ctx())
+		return
+	}
 	plugin := &tables.Plugin{}
-	if err := c.Ctx().BindJSON(plugin); err != nil {
+	if exist, _ := c.Orm.Where("path = ?", form.Path).Get(plugin); !exist {
+		helper.Ajax("插件不存在", 1, c.Ctx())
+		return
+	}
+	view, _ := sonic.Marshal(plugin.View)
+	schema, err := sdk.ParseSchema(view)
+	if err != nil {
 		helper.Ajax(err.Error(), 1, c.Ctx())
 		return
 	}
-	plugin.UpdatedAt = tables.LocalTime(time.Now())
-	res, _ := c.Orm.Where("path = ?", plugin.Path).Update(plugin)
+	settings, err := schema.Validate(form.Config)
+	if err != nil {
+		helper.Ajax(err.Error(), 1, c.Ctx())
+		return
+	}
+	res, _ := c.Orm.ID(plugin.Id).Cols("config", "updated_at").Update(&tables.Plugin{
+		Config:    settings,
+		UpdatedAt: tables.LocalTime(time.Now()),
+	})
 	if res > 0 {
 		helper.Ajax("success", 0, c.Ctx())
 	} else {
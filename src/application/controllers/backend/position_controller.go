@@ -4,6 +4,7 @@ import (
 	"github.com/xiusin/pinecms/src/application/models/tables"
 )
 
+// @Rest(path = "/position", menu = "岗位管理")
 type PositionController struct {
 	BaseController
 }
@@ -11,9 +11,11 @@ import (
 	"github.com/xiusin/pinecms/src/application/models"
 	"github.com/xiusin/pinecms/src/application/models/tables"
 	"github.com/xiusin/pinecms/src/common/helper"
+	"github.com/xiusin/pinecms/src/common/hooks"
 	"github.com/xiusin/pinecms/src/config"
 )
 
+// @Rest(path = "/public,/api")
 type PublicController struct {
 	BaseController
 }
@@ -42,8 +44,7 @@ func (c *PublicController) GetMenu() {
 }
 
 func (c *PublicController) PostUpload() {
-	cfg, _ := config.SiteConfig()
-	uploader, uploadDir := getStorageEngine(cfg), helper.NowDate("20060102")
+	uploader, uploadDir := GetStorageEngine(config.CtxSiteConfig(c.Ctx())), helper.NowDate("20060102")
 	mf, err := c.Ctx().MultipartForm()
 	if err != nil {
 		c.Logger().Error("上传文件失败", err)
@@ -69,9 +70,10 @@ func (c *PublicController) PostUpload() {
 
 		md5sum := fmt.Sprintf("%x", md5hash.Sum(nil))
 		attach := &tables.Attachments{}
-		c.Orm.Where("md5 = ?", md5sum).Get(attach)
+		c.Orm.Unscoped().Where("site_id = ?", config.CtxSiteId(c.Ctx())).Where("md5 = ?", md5sum).Get(attach) // 回收站中的附件文件未删除, 可直接复用
 		resJson := map[string]any{"originalName": fs.Filename, "size": fs.Size, "md5": md5sum}
-		if len(attach.Url) == 0 {
+		exist := len(attach.Url) > 0
+		if !exist {
 			filename := string(helper.Krand(16, 3)) + strings.ToLower(filepath.Ext(fs.Filename))
 			storageName := uploadDir + "/" + filename
 			path, err := uploader.Upload(storageName, f)
@@ -84,6 +86,16 @@ func (c *PublicController) PostUpload() {
 		}
 		resJson["name"] = attach.Name
 		resJson["url"] = attach.Url
+		adminId, _ := c.Ctx().Value("adminid").(int64)
+		hooks.UploadCompleted.Fire(&hooks.UploadEvent{
+			Name:         attach.Name,
+			OriginalName: fs.Filename,
+			Url:          attach.Url,
+			Md5:          md5sum,
+			Size:         fs.Size,
+			Exist:        exist,
+			AdminId:      adminId,
+		})
 		helper.Ajax(resJson, 0, c.Ctx())
 	}
 }
@@ -0,0 +1,60 @@
+package backend
+
+import (
+	"github.com/xiusin/pine"
+	"github.com/xiusin/pinecms/src/common/helper"
+	"github.com/xiusin/pinecms/src/common/recycle"
+	"github.com/xiusin/pinecms/src/config"
+)
+
+// RecycleController 回收站管理
+// @Rest(path = "/recycle", menu = "回收站")
+type RecycleController struct {
+	pine.Controller
+}
+
+type recycleParams struct {
+	Kind string  `json:"kind"`
+	Mid  int64   `json:"mid"`
+	Ids  []int64 `json:"ids"`
+}
+
+// GetList 回收站列表, 按类型、模型与标题筛选
+func (c *RecycleController) GetList() {
+	q := recycle.Query{SiteId: config.CtxSiteId(c.Ctx())}
+	q.Kind, _ = c.Input().GetString("kind")
+	q.ModelId, _ = c.Input().GetInt64("mid")
+	q.Keyword, _ = c.Input().GetString("keyword")
+	q.Page, _ = c.Input().GetInt("page", 1)
+	q.Size, _ = c.Input().GetInt("size", 20)
+	list, total, err := recycle.List(q)
+	if err != nil {
+		helper.Ajax(err.Error(), 1, c.Ctx())
+		return
+	}
+	helper.Ajax(pine.H{"list": list, "pagination": pine.H{"page": q.Page, "size": q.Size, "total": total}}, 0, c.Ctx())
+}
+
+// PostRestore 还原, 分类的上级分类一并还原
+func (c *RecycleController) PostRestore() {
+	c.action(recycle.Restore, "还原成功")
+}
+
+// PostPurge 彻底删除, 同时删除存储文件与搜索索引
+func (c *RecycleController) PostPurge() {
+	c.action(recycle.Purge, "删除成功")
+}
+
+func (c *RecycleController) action(fn func(recycle.Scope, string, int64, []int64) error, msg string) {
+	p := &recycleParams{}
+	if err := c.Ctx().BindJSON(p); err != nil || len(p.Ids) == 0 {
+		helper.Ajax("参数错误", 1, c.Ctx())
+		return
+	}
+	scope := recycle.Scope{SiteId: config.CtxSiteId(c.Ctx())}
+	if err := fn(scope, p.Kind, p.Mid, p.Ids); err != nil {
+		helper.Ajax(err.Error(), 1, c.Ctx())
+		return
+	}
+	helper.Ajax(msg, 0, c.Ctx())
+}
@@ -0,0 +1,113 @@
+package backend
+
+import (
+	"context"
+
+	"github.com/xiusin/pine"
+	"github.com/xiusin/pinecms/src/common/helper"
+	"github.com/xiusin/pinecms/src/common/river"
+)
+
+// RiverController river任务队列管理
+// @Rest(path = "/river", menu = "任务队列")
+type RiverController struct {
+	pine.Controller
+}
+
+type riverParams struct {
+	Id    int64  `json:"id"`
+	Queue string `json:"queue"`
+}
+
+// GetQueues 队列列表、已注册的worker与可筛选的任务状态
+func (c *RiverController) GetQueues() {
+	queues, err := river.Queues(context.Background())
+	if err != nil {
+		helper.Ajax(err.Error(), 1, c.Ctx())
+		return
+	}
+	helper.Ajax(pine.H{
+		"queues":  queues,
+		"workers": river.Workers(),
+		"states":  river.States,
+	}, 0, c.Ctx())
+}
+
+// GetJobs 按队列、类型与状态分页查询任务, 下一页传入返回的 cursor
+func (c *RiverController) GetJobs() {
+	query := river.JobQuery{}
+	query.Queue, _ = c.Input().GetString("queue")
+	query.Kind, _ = c.Input().GetString("kind")
+	query.State, _ = c.Input().GetString("state")
+	query.Cursor, _ = c.Input().GetString("cursor")
+	query.Size, _ = c.Input().GetInt("size", 20)
+	jobs, cursor, err := river.Jobs(context.Background(), query)
+	if err != nil {
+		helper.Ajax(err.Error(), 1, c.Ctx())
+		return
+	}
+	helper.Ajax(pine.H{"list": jobs, "cursor": cursor}, 0, c.Ctx())
+}
+
+// GetJob 任务详情, 包含参数与每次执行的错误
+func (c *RiverController) GetJob() {
+	id, _ := c.Input().GetInt64("id")
+	c.job(id, river.GetJob)
+}
+
+// PostRetry 重新执行任务
+func (c *RiverController) PostRetry() {
+	c.job(c.params().Id, river.RetryJob)
+}
+
+// PostCancel 取消任务
+func (c *RiverController) PostCancel() {
+	c.job(c.params().Id, river.CancelJob)
+}
+
+// PostDelete 删除任务
+func (c *RiverController) PostDelete() {
+	c.job(c.params().Id, river.DeleteJob)
+}
+
+// PostPause 暂停队列
+func (c *RiverController) PostPause() {
+	c.queue(river.PauseQueue)
+}
+
+// PostResume 恢复队列
+func (c *RiverController) PostResume() {
+	c.queue(river.ResumeQueue)
+}
+
+func (c *RiverController) params() *riverParams {
+	p := &riverParams{}
+	_ = c.Ctx().BindJSON(p)
+	return p
+}
+
+func (c *RiverController) job(id int64, fn func(context.Context, int64) (*river.Job, error)) {
+	if id < 1 {
+		helper.Ajax("参数错误", 1, c.Ctx())
+		return
+	}
+	job, err := fn(context.Background(), id)
+	if err != nil {
+		helper.Ajax(err.Error(), 1, c.Ctx())
+		return
+	}
+	helper.Ajax(job, 0, c.Ctx())
+}
+
+func (c *RiverController) queue(fn func(context.Context, string) error) {
+	p := c.params()
+	if len(p.Queue) == 0 {
+		helper.Ajax("参数错误", 1, c.Ctx())
+		return
+	}
+	if err := fn(context.Background(), p.Queue); err != nil {
+		helper.Ajax(err.Error(), 1, c.Ctx())
+		return
+	}
+	helper.Ajax("操作成功", 0, c.Ctx())
+}
@@ -10,6 +10,7 @@ import (
 	"xorm.io/xorm"
 )
 
+// @Rest(path = "/role", menu = "角色管理")
 type AdminRoleController struct {
 	BaseController
 }
@@ -0,0 +1,464 @@
+// Code generated by Rest annotation processor. DO NOT EDIT.
+// versions:
+//
+//	go: go1.27.1
+//	go-annotation: 0.1.0
+//	Rest: 0.1.0
+package backend
+
+import (
+	"github.com/xiusin/pine"
+	"github.com/xiusin/pinecms/src/common/route"
+	theme "github.com/xiusin/pinecms/src/common/theme"
+)
+
+// AnnotatedRoutes 注解路由表
+var AnnotatedRoutes = []route.Route{
+	{Mount: "admin", Method: "POST", Path: "/ad/add", Controller: "AdController", Handler: "PostAdd"},
+	{Mount: "admin", Method: "POST", Path: "/ad/delete", Controller: "AdController", Handler: "PostDelete"},
+	{Mount: "admin", Method: "POST", Path: "/ad/edit", Controller: "AdController", Handler: "PostEdit"},
+	{Mount: "admin", Method: "GET", Path: "/ad/info", Controller: "AdController", Handler: "GetInfo"},
+	{Mount: "admin", Method: "POST", Path: "/ad/list", Controller: "AdController", Handler: "PostList"},
+	{Mount: "admin", Method: "GET", Path: "/ad/select", Controller: "AdController", Handler: "GetSelect"},
+	{Mount: "admin", Method: "POST", Path: "/ad/update", Controller: "AdController", Handler: "PostUpdate"},
+	{Mount: "admin", Method: "POST", Path: "/ad/space/add", Controller: "AdSpaceController", Handler: "PostAdd"},
+	{Mount: "admin", Method: "POST", Path: "/ad/space/delete", Controller: "AdSpaceController", Handler: "PostDelete"},
+	{Mount: "admin", Method: "POST", Path: "/ad/space/edit", Controller: "AdSpaceController", Handler: "PostEdit"},
+	{Mount: "admin", Method: "GET", Path: "/ad/space/info", Controller: "AdSpaceController", Handler: "GetInfo"},
+	{Mount: "admin", Method: "POST", Path: "/ad/space/list", Controller: "AdSpaceController", Handler: "PostList"},
+	{Mount: "admin", Method: "GET", Path: "/ad/space/select", Controller: "AdSpaceController", Handler: "GetSelect"},
+	{Mount: "admin", Method: "POST", Path: "/ad/space/update", Controller: "AdSpaceController", Handler: "PostUpdate"},
+	{Mount: "admin", Method: "POST", Path: "/role/add", Controller: "AdminRoleController", Handler: "PostAdd"},
+	{Mount: "admin", Method: "POST", Path: "/role/delete", Controller: "AdminRoleController", Handler: "PostDelete"},
+	{Mount: "admin", Method: "POST", Path: "/role/edit", Controller: "AdminRoleController", Handler: "PostEdit"},
+	{Mount: "admin", Method: "GET", Path: "/role/info", Controller: "AdminRoleController", Handler: "GetInfo"},
+	{Mount: "admin", Method: "POST", Path: "/role/list", Controller: "AdminRoleController", Handler: "PostList"},
+	{Mount: "admin", Method: "GET", Path: "/role/select", Controller: "AdminRoleController", Handler: "GetSelect"},
+	{Mount: "admin", Method: "POST", Path: "/role/update", Controller: "AdminRoleController", Handler: "PostUpdate"},
+	{Mount: "admin", Method: "POST", Path: "/assets/add", Controller: "AssetsManagerController", Handler: "PostAdd"},
+	{Mount: "admin", Method: "POST", Path: "/assets/delete", Controller: "AssetsManagerController", Handler: "PostDelete"},
+	{Mount: "admin", Method: "POST", Path: "/assets/diff", Controller: "AssetsManagerController", Handler: "PostDiff"},
+	{Mount: "admin", Method: "POST", Path: "/assets/edit", Controller: "AssetsManagerController", Handler: "PostEdit"},
+	{Mount: "admin", Method: "POST", Path: "/assets/history", Controller: "AssetsManagerController", Handler: "PostHistory"},
+	{Mount: "admin", Method: "GET", Path: "/assets/info", Controller: "AssetsManagerController", Handler: "GetInfo"},
+	{Mount: "admin", Method: "POST", Path: "/assets/list", Controller: "AssetsManagerController", Handler: "PostList"},
+	{Mount: "admin", Method: "POST", Path: "/assets/preview", Controller: "AssetsManagerController", Handler: "PostPreview"},
+	{Mount: "admin", Method: "POST", Path: "/assets/restore", Controller: "AssetsManagerController", Handler: "PostRestore"},
+	{Mount: "admin", Method: "GET", Path: "/assets/select", Controller: "AssetsManagerController", Handler: "GetSelect"},
+	{Mount: "admin", Method: "POST", Path: "/assets/theme", Controller: "AssetsManagerController", Handler: "PostTheme"},
+	{Mount: "admin", Method: "GET", Path: "/assets/themes", Controller: "AssetsManagerController", Handler: "GetThemes"},
+	{Mount: "admin", Method: "GET", Path: "/assets/thumb", Controller: "AssetsManagerController", Handler: "GetThumb"},
+	{Mount: "admin", Method: "POST", Path: "/assets/update", Controller: "AssetsManagerController", Handler: "PostUpdate"},
+	{Mount: "admin", Method: "POST", Path: "/assets/validate", Controller: "AssetsManagerController", Handler: "PostValidate"},
+	{Mount: "admin", Method: "POST", Path: "/attachment/add", Controller: "AttachmentController", Handler: "PostAdd"},
+	{Mount: "admin", Method: "POST", Path: "/attachment/delete", Controller: "AttachmentController", Handler: "PostDelete"},
+	{Mount: "admin", Method: "POST", Path: "/attachment/edit", Controller: "AttachmentController", Handler: "PostEdit"},
+	{Mount: "admin", Method: "GET", Path: "/attachment/info", Controller: "AttachmentController", Handler: "GetInfo"},
+	{Mount: "admin", Method: "POST", Path: "/attachment/list", Controller: "AttachmentController", Handler: "PostList"},
+	{Mount: "admin", Method: "GET", Path: "/attachment/select", Controller: "AttachmentController", Handler: "GetSelect"},
+	{Mount: "admin", Method: "POST", Path: "/attachment/update", Controller: "AttachmentController", Handler: "PostUpdate"},
+	{Mount: "admin", Method: "POST", Path: "/attachment/type/add", Controller: "AttachmentTypeController", Handler: "PostAdd"},
+	{Mount: "admin", Method: "POST", Path: "/attachment/type/delete", Controller: "AttachmentTypeController", Handler: "PostDelete"},
+	{Mount: "admin", Method: "POST", Path: "/attachment/type/edit", Controller: "AttachmentTypeController", Handler: "PostEdit"},
+	{Mount: "admin", Method: "GET", Path: "/attachment/type/info", Controller: "AttachmentTypeController", Handler: "GetInfo"},
+	{Mount: "admin", Method: "POST", Path: "/attachment/type/list", Controller: "AttachmentTypeController", Handler: "PostList"},
+	{Mount: "admin", Method: "GET", Path: "/attachment/type/select", Controller: "AttachmentTypeController", Handler: "GetSelect"},
+	{Mount: "admin", Method: "POST", Path: "/attachment/type/update", Controller: "AttachmentTypeController", Handler: "PostUpdate"},
+	{Mount: "admin", Method: "POST", Path: "/category/add", Controller: "CategoryController", Handler: "PostAdd"},
+	{Mount: "admin", Method: "POST", Path: "/category/delete", Controller: "CategoryController", Handler: "PostDelete"},
+	{Mount: "admin", Method: "POST", Path: "/category/edit", Controller: "CategoryController", Handler: "PostEdit"},
+	{Mount: "admin", Method: "GET", Path: "/category/info", Controller: "CategoryController", Handler: "GetInfo"},
+	{Mount: "admin", Method: "POST", Path: "/category/list", Controller: "CategoryController", Handler: "PostList"},
+	{Mount: "admin", Method: "GET", Path: "/category/select", Controller: "CategoryController", Handler: "GetSelect"},
+	{Mount: "admin", Method: "POST", Path: "/category/update", Controller: "CategoryController", Handler: "PostUpdate"},
+	{Mount: "admin", Method: "POST", Path: "/content/add", Controller: "ContentController", Handler: "PostAdd"},
+	{Mount: "admin", Method: "POST", Path: "/content/delete", Controller: "ContentController", Handler: "PostDelete"},
+	{Mount: "admin", Method: "POST", Path: "/content/edit", Controller: "ContentController", Handler: "PostEdit"},
+	{Mount: "admin", Method: "GET", Path: "/content/info", Controller: "ContentController", Handler: "GetInfo"},
+	{Mount: "admin", Method: "POST", Path: "/content/list", Controller: "ContentController", Handler: "PostList"},
+	{Mount: "admin", Method: "GET", Path: "/content/page", Controller: "ContentController", Handler: "GetPage"},
+	{Mount: "admin", Method: "POST", Path: "/content/page", Controller: "ContentController", Handler: "PostPage"},
+	{Mount: "admin", Method: "GET", Path: "/content/select", Controller: "ContentController", Handler: "GetSelect"},
+	{Mount: "admin", Method: "POST", Path: "/content/update", Controller: "ContentController", Handler: "PostUpdate"},
+	{Mount: "admin", Method: "POST", Path: "/datasource/add", Controller: "DataSourceController", Handler: "PostAdd"},
+	{Mount: "admin", Method: "POST", Path: "/datasource/delete", Controller: "DataSourceController", Handler: "PostDelete"},
+	{Mount: "admin", Method: "POST", Path: "/datasource/edit", Controller: "DataSourceController", Handler: "PostEdit"},
+	{Mount: "admin", Method: "GET", Path: "/datasource/info", Controller: "DataSourceController", Handler: "GetInfo"},
+	{Mount: "admin", Method: "POST", Path: "/datasource/list", Controller: "DataSourceController", Handler: "PostList"},
+	{Mount: "admin", Method: "POST", Path: "/datasource/preview", Controller: "DataSourceController", Handler: "PostPreview"},
+	{Mount: "admin", Method: "GET", Path: "/datasource/select", Controller: "DataSourceController", Handler: "GetSelect"},
+	{Mount: "admin", Method: "POST", Path: "/datasource/tables", Controller: "DataSourceController", Handler: "PostTables"},
+	{Mount: "admin", Method: "POST", Path: "/datasource/update", Controller: "DataSourceController", Handler: "PostUpdate"},
+	{Mount: "admin", Method: "POST", Path: "/backup/delete", Controller: "DatabaseBackupController", Handler: "BackupDelete", Middleware: []string{"request_log"}, Title: "删除备份", Group: "数据库管理"},
+	{Mount: "admin", Method: "POST", Path: "/backup/download", Controller: "DatabaseBackupController", Handler: "BackupDownload", Title: "下载备份", Group: "数据库管理"},
+	{Mount: "admin", Method: "ANY", Path: "/backup/list", Controller: "DatabaseBackupController", Handler: "BackupList", Title: "备份列表", Group: "数据库管理"},
+	{Mount: "admin", Method: "POST", Path: "/database/backup", Controller: "DatabaseController", Handler: "Backup", Middleware: []string{"request_log"}, Title: "备份数据库", Group: "数据库管理"},
+	{Mount: "admin", Method: "ANY", Path: "/database/list", Controller: "DatabaseController", Handler: "Manager", Title: "数据表列表", Group: "数据库管理"},
+	{Mount: "admin", Method: "POST", Path: "/database/optimize", Controller: "DatabaseController", Handler: "Optimize", Middleware: []string{"request_log"}, Title: "优化数据表", Group: "数据库管理"},
+	{Mount: "admin", Method: "POST", Path: "/database/repair", Controller: "DatabaseController", Handler: "Repair", Middleware: []string{"request_log"}, Title: "修复数据表", Group: "数据库管理"},
+	{Mount: "admin", Method: "POST", Path: "/department/add", Controller: "DepartmentController", Handler: "PostAdd"},
+	{Mount: "admin", Method: "POST", Path: "/department/delete", Controller: "DepartmentController", Handler: "PostDelete"},
+	{Mount: "admin", Method: "POST", Path: "/department/edit", Controller: "DepartmentController", Handler: "PostEdit"},
+	{Mount: "admin", Method: "GET", Path: "/department/info", Controller: "DepartmentController", Handler: "GetInfo"},
+	{Mount: "admin", Method: "POST", Path: "/department/list", Controller: "DepartmentController", Handler: "PostList"},
+	{Mount: "admin", Method: "GET", Path: "/department/select", Controller: "DepartmentController", Handler: "GetSelect"},
+	{Mount: "admin", Method: "POST", Path: "/department/update", Controller: "DepartmentController", Handler: "PostUpdate"},
+	{Mount: "admin", Method: "POST", Path: "/dict/category/add", Controller: "DictCategoryController", Handler: "PostAdd"},
+	{Mount: "admin", Method: "POST", Path: "/dict/category/delete", Controller: "DictCategoryController", Handler: "PostDelete"},
+	{Mount: "admin", Method: "POST", Path: "/dict/category/edit", Controller: "DictCategoryController", Handler: "PostEdit"},
+	{Mount: "admin", Method: "GET", Path: "/dict/category/info", Controller: "DictCategoryController", Handler: "GetInfo"},
+	{Mount: "admin", Method: "POST", Path: "/dict/category/list", Controller: "DictCategoryController", Handler: "PostList"},
+	{Mount: "admin", Method: "GET", Path: "/dict/category/select", Controller: "DictCategoryController", Handler: "GetSelect"},
+	{Mount: "admin", Method: "POST", Path: "/dict/category/update", Controller: "DictCategoryController", Handler: "PostUpdate"},
+	{Mount: "admin", Method: "POST", Path: "/dict/add", Controller: "DictController", Handler: "PostAdd"},
+	{Mount: "admin", Method: "POST", Path: "/dict/delete", Controller: "DictController", Handler: "PostDelete"},
+	{Mount: "admin", Method: "POST", Path: "/dict/edit", Controller: "DictController", Handler: "PostEdit"},
+	{Mount: "admin", Method: "GET", Path: "/dict/info", Controller: "DictController", Handler: "GetInfo"},
+	{Mount: "admin", Method: "POST", Path: "/dict/list", Controller: "DictController", Handler: "PostList"},
+	{Mount: "admin", Method: "GET", Path: "/dict/select", Controller: "DictController", Handler: "GetSelect"},
+	{Mount: "admin", Method: "POST", Path: "/dict/update", Controller: "DictController", Handler: "PostUpdate"},
+	{Mount: "admin", Method: "POST", Path: "/district/add", Controller: "DistrictController", Handler: "PostAdd"},
+	{Mount: "admin", Method: "POST", Path: "/district/delete", Controller: "DistrictController", Handler: "PostDelete"},
+	{Mount: "admin", Method: "POST", Path: "/district/edit", Controller: "DistrictController", Handler: "PostEdit"},
+	{Mount: "admin", Method: "POST", Path: "/district/import", Controller: "DistrictController", Handler: "PostImport"},
+	{Mount: "admin", Method: "GET", Path: "/district/info", Controller: "DistrictController", Handler: "GetInfo"},
+	{Mount: "admin", Method: "POST", Path: "/district/list", Controller: "DistrictController", Handler: "PostList"},
+	{Mount: "admin", Method: "GET", Path: "/district/select", Controller: "DistrictController", Handler: "GetSelect"},
+	{Mount: "admin", Method: "POST", Path: "/district/update", Controller: "DistrictController", Handler: "PostUpdate"},
+	{Mount: "admin", Method: "POST", Path: "/model/add", Controller: "DocumentController", Handler: "PostAdd"},
+	{Mount: "admin", Method: "POST", Path: "/model/delete", Controller: "DocumentController", Handler: "PostDelete"},
+	{Mount: "admin", Method: "POST", Path: "/model/edit", Controller: "DocumentController", Handler: "PostEdit"},
+	{Mount: "admin", Method: "GET", Path: "/model/info", Controller: "DocumentController", Handler: "GetInfo"},
+	{Mount: "admin", Method: "POST", Path: "/model/list", Controller: "DocumentController", Handler: "PostList"},
+	{Mount: "admin", Method: "GET", Path: "/model/migrations", Controller: "DocumentController", Handler: "GetMigrations"},
+	{Mount: "admin", Method: "POST", Path: "/model/rollback", Controller: "DocumentController", Handler: "PostRollback"},
+	{Mount: "admin", Method: "GET", Path: "/model/select", Controller: "DocumentController", Handler: "GetSelect"},
+	{Mount: "admin", Method: "GET", Path: "/model/sql", Controller: "DocumentController", Handler: "GetSql"},
+	{Mount: "admin", Method: "GET", Path: "/model/table", Controller: "DocumentController", Handler: "GetTable"},
+	{Mount: "admin", Method: "POST", Path: "/model/update", Controller: "DocumentController", Handler: "PostUpdate"},
+	{Mount: "admin", Method: "POST", Path: "/errlog/add", Controller: "ErrorLogController", Handler: "PostAdd"},
+	{Mount: "admin", Method: "POST", Path: "/errlog/clear", Controller: "ErrorLogController", Handler: "PostClear"},
+	{Mount: "admin", Method: "POST", Path: "/errlog/delete", Controller: "ErrorLogController", Handler: "PostDelete"},
+	{Mount: "admin", Method: "POST", Path: "/errlog/edit", Controller: "ErrorLogController", Handler: "PostEdit"},
+	{Mount: "admin", Method: "GET", Path: "/errlog/info", Controller: "ErrorLogController", Handler: "GetInfo"},
+	{Mount: "admin", Method: "POST", Path: "/errlog/list", Controller: "ErrorLogController", Handler: "PostList"},
+	{Mount: "admin", Method: "GET", Path: "/errlog/select", Controller: "ErrorLogController", Handler: "GetSelect"},
+	{Mount: "admin", Method: "POST", Path: "/errlog/update", Controller: "ErrorLogController", Handler: "PostUpdate"},
+	{Mount: "admin", Method: "POST", Path: "/import/add", Controller: "ImportController", Handler: "PostAdd"},
+	{Mount: "admin", Method: "POST", Path: "/import/columns", Controller: "ImportController", Handler: "PostColumns"},
+	{Mount: "admin", Method: "POST", Path: "/import/delete", Controller: "ImportController", Handler: "PostDelete"},
+	{Mount: "admin", Method: "POST", Path: "/import/edit", Controller: "ImportController", Handler: "PostEdit"},
+	{Mount: "admin", Method: "POST", Path: "/import/errors", Controller: "ImportController", Handler: "PostErrors"},
+	{Mount: "admin", Method: "GET", Path: "/import/info", Controller: "ImportController", Handler: "GetInfo"},
+	{Mount: "admin", Method: "POST", Path: "/import/list", Controller: "ImportController", Handler: "PostList"},
+	{Mount: "admin", Method: "POST", Path: "/import/mapping", Controller: "ImportController", Handler: "PostMapping"},
+	{Mount: "admin", Method: "GET", Path: "/import/select", Controller: "ImportController", Handler: "GetSelect"},
+	{Mount: "admin", Method: "POST", Path: "/import/start", Controller: "ImportController", Handler: "PostStart"},
+	{Mount: "admin", Method: "POST", Path: "/import/update", Controller: "ImportController", Handler: "PostUpdate"},
+	{Mount: "admin", Method: "POST", Path: "/import/upload", Controller: "ImportController", Handler: "PostUpload"},
+	{Mount: "admin", Method: "ANY", Path: "/index/main", Controller: "IndexController", Handler: "Main", Title: "后台首页统计", Group: "系统"},
+	{Mount: "admin", Method: "POST", Path: "/level/add", Controller: "LevelController", Handler: "PostAdd"},
+	{Mount: "admin", Method: "POST", Path: "/level/delete", Controller: "LevelController", Handler: "PostDelete"},
+	{Mount: "admin", Method: "POST", Path: "/level/edit", Controller: "LevelController", Handler: "PostEdit"},
+	{Mount: "admin", Method: "GET", Path: "/level/info", Controller: "LevelController", Handler: "GetInfo"},
+	{Mount: "admin", Method: "POST", Path: "/level/list", Controller: "LevelController", Handler: "PostList"},
+	{Mount: "admin", Method: "GET", Path: "/level/select", Controller: "LevelController", Handler: "GetSelect"},
+	{Mount: "admin", Method: "POST", Path: "/level/update", Controller: "LevelController", Handler: "PostUpdate"},
+	{Mount: "admin", Method: "POST", Path: "/link/add", Controller: "LinkController", Handler: "PostAdd"},
+	{Mount: "admin", Method: "POST", Path: "/link/delete", Controller: "LinkController", Handler: "PostDelete"},
+	{Mount: "admin", Method: "POST", Path: "/link/edit", Controller: "LinkController", Handler: "PostEdit"},
+	{Mount: "admin", Method: "GET", Path: "/link/info", Controller: "LinkController", Handler: "GetInfo"},
+	{Mount: "admin", Method: "POST", Path: "/link/list", Controller: "LinkController", Handler: "PostList"},
+	{Mount: "admin", Method: "GET", Path: "/link/select", Controller: "LinkController", Handler: "GetSelect"},
+	{Mount: "admin", Method: "POST", Path: "/link/update", Controller: "LinkController", Handler: "PostUpdate"},
+	{Mount: "admin", Method: "POST", Path: "/log/add", Controller: "LogController", Handler: "PostAdd"},
+	{Mount: "admin", Method: "POST", Path: "/log/clear", Controller: "LogController", Handler: "PostClear"},
+	{Mount: "admin", Method: "POST", Path: "/log/delete", Controller: "LogController", Handler: "PostDelete"},
+	{Mount: "admin", Method: "POST", Path: "/log/edit", Controller: "LogController", Handler: "PostEdit"},
+	{Mount: "admin", Method: "GET", Path: "/log/info", Controller: "LogController", Handler: "GetInfo"},
+	{Mount: "admin", Method: "POST", Path: "/log/list", Controller: "LogController", Handler: "PostList"},
+	{Mount: "admin", Method: "GET", Path: "/log/select", Controller: "LogController", Handler: "GetSelect"},
+	{Mount: "admin", Method: "POST", Path: "/log/update", Controller: "LogController", Handler: "PostUpdate"},
+	{Mount: "admin", Method: "ANY", Path: "/login", Controller: "LoginController", Handler: "Login", Middleware: []string{"limiter"}, Title: "登录系统", Group: "登录模块", Request: &loginUserParam{}},
+	{Mount: "admin", Method: "POST", Path: "/member/add", Controller: "MemberController", Handler: "PostAdd"},
+	{Mount: "admin", Method: "POST", Path: "/member/delete", Controller: "MemberController", Handler: "PostDelete"},
+	{Mount: "admin", Method: "POST", Path: "/member/edit", Controller: "MemberController", Handler: "PostEdit"},
+	{Mount: "admin", Method: "GET", Path: "/member/info", Controller: "MemberController", Handler: "GetInfo"},
+	{Mount: "admin", Method: "POST", Path: "/member/list", Controller: "MemberController", Handler: "PostList"},
+	{Mount: "admin", Method: "GET", Path: "/member/select", Controller: "MemberController", Handler: "GetSelect"},
+	{Mount: "admin", Method: "POST", Path: "/member/update", Controller: "MemberController", Handler: "PostUpdate"},
+	{Mount: "admin", Method: "POST", Path: "/member/group/add", Controller: "MemberGroupController", Handler: "PostAdd"},
+	{Mount: "admin", Method: "POST", Path: "/member/group/delete", Controller: "MemberGroupController", Handler: "PostDelete"},
+	{Mount: "admin", Method: "POST", Path: "/member/group/edit", Controller: "MemberGroupController", Handler: "PostEdit"},
+	{Mount: "admin", Method: "GET", Path: "/member/group/info", Controller: "MemberGroupController", Handler: "GetInfo"},
+	{Mount: "admin", Method: "POST", Path: "/member/group/list", Controller: "MemberGroupController", Handler: "PostList"},
+	{Mount: "admin", Method: "GET", Path: "/member/group/select", Controller: "MemberGroupController", Handler: "GetSelect"},
+	{Mount: "admin", Method: "POST", Path: "/member/group/update", Controller: "MemberGroupController", Handler: "PostUpdate"},
+	{Mount: "admin", Method: "POST", Path: "/menu/add", Controller: "MenuController", Handler: "PostAdd"},
+	{Mount: "admin", Method: "POST", Path: "/menu/delete", Controller: "MenuController", Handler: "PostDelete"},
+	{Mount: "admin", Method: "POST", Path: "/menu/edit", Controller: "MenuController", Handler: "PostEdit"},
+	{Mount: "admin", Method: "GET", Path: "/menu/info", Controller: "MenuController", Handler: "GetInfo"},
+	{Mount: "admin", Method: "POST", Path: "/menu/list", Controller: "MenuController", Handler: "PostList"},
+	{Mount: "admin", Method: "GET", Path: "/menu/select", Controller: "MenuController", Handler: "GetSelect"},
+	{Mount: "admin", Method: "POST", Path: "/menu/update", Controller: "MenuController", Handler: "PostUpdate"},
+	{Mount: "admin", Method: "POST", Path: "/plugin/add", Controller: "PluginController", Handler: "PostAdd"},
+	{Mount: "admin", Method: "GET", Path: "/plugin/config", Controller: "PluginController", Handler: "GetConfig"},
+	{Mount: "admin", Method: "POST", Path: "/plugin/config", Controller: "PluginController", Handler: "PostConfig"},
+	{Mount: "admin", Method: "POST", Path: "/plugin/delete", Controller: "PluginController", Handler: "PostDelete"},
+	{Mount: "admin", Method: "POST", Path: "/plugin/edit", Controller: "PluginController", Handler: "PostEdit"},
+	{Mount: "admin", Method: "POST", Path: "/plugin/enable", Controller: "PluginController", Handler: "PostEnable"},
+	{Mount: "admin", Method: "GET", Path: "/plugin/info", Controller: "PluginController", Handler: "GetInfo"},
+	{Mount: "admin", Method: "POST", Path: "/plugin/install", Controller: "PluginController", Handler: "PostInstall"},
+	{Mount: "admin", Method: "POST", Path: "/plugin/list", Controller: "PluginController", Handler: "PostList"},
+	{Mount: "admin", Method: "GET", Path: "/plugin/market", Controller: "PluginController", Handler: "GetMarket"},
+	{Mount: "admin", Method: "POST", Path: "/plugin/market_install", Controller: "PluginController", Handler: "PostMarketInstall"},
+	{Mount: "admin", Method: "GET", Path: "/plugin/market_progress", Controller: "PluginController", Handler: "GetMarketProgress"},
+	{Mount: "admin", Method: "GET", Path: "/plugin/select", Controller: "PluginController", Handler: "GetSelect"},
+	{Mount: "admin", Method: "POST", Path: "/plugin/uninstall", Controller: "PluginController", Handler: "PostUninstall"},
+	{Mount: "admin", Method: "POST", Path: "/plugin/update", Controller: "PluginController", Handler: "PostUpdate"},
+	{Mount: "admin", Method: "POST", Path: "/position/add", Controller: "PositionController", Handler: "PostAdd"},
+	{Mount: "admin", Method: "POST", Path: "/position/delete", Controller: "PositionController", Handler: "PostDelete"},
+	{Mount: "admin", Method: "POST", Path: "/position/edit", Controller: "PositionController", Handler: "PostEdit"},
+	{Mount: "admin", Method: "GET", Path: "/position/info", Controller: "PositionController", Handler: "GetInfo"},
+	{Mount: "admin", Method: "POST", Path: "/position/list", Controller: "PositionController", Handler: "PostList"},
+	{Mount: "admin", Method: "GET", Path: "/position/select", Controller: "PositionController", Handler: "GetSelect"},
+	{Mount: "admin", Method: "POST", Path: "/position/update", Controller: "PositionController", Handler: "PostUpdate"},
+	{Mount: "admin", Method: "POST", Path: "/api/add", Controller: "PublicController", Handler: "PostAdd"},
+	{Mount: "admin", Method: "GET", Path: "/api/apidoc", Controller: "PublicController", Handler: "GetApidoc"},
+	{Mount: "admin", Method: "POST", Path: "/api/delete", Controller: "PublicController", Handler: "PostDelete"},
+	{Mount: "admin", Method: "POST", Path: "/api/edit", Controller: "PublicController", Handler: "PostEdit"},
+	{Mount: "admin", Method: "GET", Path: "/api/info", Controller: "PublicController", Handler: "GetInfo"},
+	{Mount: "admin", Method: "POST", Path: "/api/list", Controller: "PublicController", Handler: "PostList"},
+	{Mount: "admin", Method: "GET", Path: "/api/menu", Controller: "PublicController", Handler: "GetMenu"},
+	{Mount: "admin", Method: "GET", Path: "/api/pprof", Controller: "PublicController", Handler: "GetPprof"},
+	{Mount: "admin", Method: "GET", Path: "/api/select", Controller: "PublicController", Handler: "GetSelect"},
+	{Mount: "admin", Method: "GET", Path: "/api/statsviz", Controller: "PublicController", Handler: "GetStatsviz"},
+	{Mount: "admin", Method: "POST", Path: "/api/update", Controller: "PublicController", Handler: "PostUpdate"},
+	{Mount: "admin", Method: "POST", Path: "/api/upload", Controller: "PublicController", Handler: "PostUpload"},
+	{Mount: "admin", Method: "POST", Path: "/public/add", Controller: "PublicController", Handler: "PostAdd"},
+	{Mount: "admin", Method: "GET", Path: "/public/apidoc", Controller: "PublicController", Handler: "GetApidoc"},
+	{Mount: "admin", Method: "POST", Path: "/public/delete", Controller: "PublicController", Handler: "PostDelete"},
+	{Mount: "admin", Method: "POST", Path: "/public/edit", Controller: "PublicController", Handler: "PostEdit"},
+	{Mount: "admin", Method: "GET", Path: "/public/info", Controller: "PublicController", Handler: "GetInfo"},
+	{Mount: "admin", Method: "POST", Path: "/public/list", Controller: "PublicController", Handler: "PostList"},
+	{Mount: "admin", Method: "GET", Path: "/public/menu", Controller: "PublicController", Handler: "GetMenu"},
+	{Mount: "admin", Method: "GET", Path: "/public/pprof", Controller: "PublicController", Handler: "GetPprof"},
+	{Mount: "admin", Method: "GET", Path: "/public/select", Controller: "PublicController", Handler: "GetSelect"},
+	{Mount: "admin", Method: "GET", Path: "/public/statsviz", Controller: "PublicController", Handler: "GetStatsviz"},
+	{Mount: "admin", Method: "POST", Path: "/public/update", Controller: "PublicController", Handler: "PostUpdate"},
+	{Mount: "admin", Method: "POST", Path: "/public/upload", Controller: "PublicController", Handler: "PostUpload"},
+	{Mount: "admin", Method: "GET", Path: "/recycle/list", Controller: "RecycleController", Handler: "GetList"},
+	{Mount: "admin", Method: "POST", Path: "/recycle/purge", Controller: "RecycleController", Handler: "PostPurge"},
+	{Mount: "admin", Method: "POST", Path: "/recycle/restore", Controller: "RecycleController", Handler: "PostRestore"},
+	{Mount: "admin", Method: "POST", Path: "/river/cancel", Controller: "RiverController", Handler: "PostCancel"},
+	{Mount: "admin", Method: "POST", Path: "/river/delete", Controller: "RiverController", Handler: "PostDelete"},
+	{Mount: "admin", Method: "GET", Path: "/river/job", Controller: "RiverController", Handler: "GetJob"},
+	{Mount: "admin", Method: "GET", Path: "/river/jobs", Controller: "RiverController", Handler: "GetJobs"},
+	{Mount: "admin", Method: "POST", Path: "/river/pause", Controller: "RiverController", Handler: "PostPause"},
+	{Mount: "admin", Method: "GET", Path: "/river/queues", Controller: "RiverController", Handler: "GetQueues"},
+	{Mount: "admin", Method: "POST", Path: "/river/resume", Controller: "RiverController", Handler: "PostResume"},
+	{Mount: "admin", Method: "POST", Path: "/river/retry", Controller: "RiverController", Handler: "PostRetry"},
+	{Mount: "admin", Method: "POST", Path: "/setting/add", Controller: "SettingController", Handler: "PostAdd"},
+	{Mount: "admin", Method: "POST", Path: "/setting/delete", Controller: "SettingController", Handler: "PostDelete"},
+	{Mount: "admin", Method: "POST", Path: "/setting/edit", Controller: "SettingController", Handler: "PostEdit"},
+	{Mount: "admin", Method: "POST", Path: "/setting/groups", Controller: "SettingController", Handler: "PostGroups"},
+	{Mount: "admin", Method: "GET", Path: "/setting/info", Controller: "SettingController", Handler: "GetInfo"},
+	{Mount: "admin", Method: "POST", Path: "/setting/list", Controller: "SettingController", Handler: "PostList"},
+	{Mount: "admin", Method: "GET", Path: "/setting/select", Controller: "SettingController", Handler: "GetSelect"},
+	{Mount: "admin", Method: "POST", Path: "/setting/test", Controller: "SettingController", Handler: "PostTest"},
+	{Mount: "admin", Method: "POST", Path: "/setting/update", Controller: "SettingController", Handler: "PostUpdate"},
+	{Mount: "admin", Method: "POST", Path: "/site/add", Controller: "SiteController", Handler: "PostAdd"},
+	{Mount: "admin", Method: "POST", Path: "/site/delete", Controller: "SiteController", Handler: "PostDelete"},
+	{Mount: "admin", Method: "POST", Path: "/site/edit", Controller: "SiteController", Handler: "PostEdit"},
+	{Mount: "admin", Method: "GET", Path: "/site/info", Controller: "SiteController", Handler: "GetInfo"},
+	{Mount: "admin", Method: "POST", Path: "/site/list", Controller: "SiteController", Handler: "PostList"},
+	{Mount: "admin", Method: "GET", Path: "/site/select", Controller: "SiteController", Handler: "GetSelect"},
+	{Mount: "admin", Method: "POST", Path: "/site/update", Controller: "SiteController", Handler: "PostUpdate"},
+	{Mount: "admin", Method: "GET", Path: "/stat/data", Controller: "StatController", Handler: "GetData"},
+	{Mount: "admin", Method: "GET", Path: "/stat/local_i_p", Controller: "StatController", Handler: "GetLocalIP"},
+	{Mount: "admin", Method: "GET", Path: "/stat/out_ip", Controller: "StatController", Handler: "GetOutIp"},
+	{Mount: "admin", Method: "POST", Path: "/table/add", Controller: "TableController", Handler: "PostAdd"},
+	{Mount: "admin", Method: "POST", Path: "/table/delete", Controller: "TableController", Handler: "PostDelete"},
+	{Mount: "admin", Method: "POST", Path: "/table/edit", Controller: "TableController", Handler: "PostEdit"},
+	{Mount: "admin", Method: "GET", Path: "/table/fields", Controller: "TableController", Handler: "GetFields"},
+	{Mount: "admin", Method: "GET", Path: "/table/info", Controller: "TableController", Handler: "GetInfo"},
+	{Mount: "admin", Method: "POST", Path: "/table/list", Controller: "TableController", Handler: "PostList"},
+	{Mount: "admin", Method: "GET", Path: "/table/select", Controller: "TableController", Handler: "GetSelect"},
+	{Mount: "admin", Method: "POST", Path: "/table/update", Controller: "TableController", Handler: "PostUpdate"},
+	{Mount: "admin", Method: "POST", Path: "/tags/add", Controller: "TagsController", Handler: "PostAdd"},
+	{Mount: "admin", Method: "POST", Path: "/tags/delete", Controller: "TagsController", Handler: "PostDelete"},
+	{Mount: "admin", Method: "POST", Path: "/tags/edit", Controller: "TagsController", Handler: "PostEdit"},
+	{Mount: "admin", Method: "GET", Path: "/tags/info", Controller: "TagsController", Handler: "GetInfo"},
+	{Mount: "admin", Method: "POST", Path: "/tags/list", Controller: "TagsController", Handler: "PostList"},
+	{Mount: "admin", Method: "GET", Path: "/tags/select", Controller: "TagsController", Handler: "GetSelect"},
+	{Mount: "admin", Method: "POST", Path: "/tags/update", Controller: "TagsController", Handler: "PostUpdate"},
+	{Mount: "admin", Method: "POST", Path: "/theme/active", Controller: "ThemeController", Handler: "Active", Title: "启用主题", Group: "主题管理", Request: &themeParam{}},
+	{Mount: "admin", Method: "POST", Path: "/theme/install", Controller: "ThemeController", Handler: "Install", Middleware: []string{"request_log"}, Title: "安装主题", Group: "主题管理", Response: &theme.Manifest{}},
+	{Mount: "admin", Method: "GET", Path: "/theme/list", Controller: "ThemeController", Handler: "List", Title: "主题列表", Group: "主题管理", Response: []*theme.Manifest{}},
+	{Mount: "admin", Method: "POST", Path: "/theme/preview", Controller: "ThemeController", Handler: "Preview", Title: "预览主题", Group: "主题管理", Request: &themeParam{}},
+	{Mount: "admin", Method: "POST", Path: "/theme/settings", Controller: "ThemeController", Handler: "SaveSettings", Permission: "theme:save_settings", Title: "保存主题配置", Group: "主题管理", Request: &themeSettingsParam{}},
+	{Mount: "admin", Method: "GET", Path: "/theme/settings", Controller: "ThemeController", Handler: "Settings", Title: "主题配置", Group: "主题管理", Response: &theme.Manifest{}},
+	{Mount: "admin", Method: "POST", Path: "/theme/uninstall", Controller: "ThemeController", Handler: "Uninstall", Middleware: []string{"request_log"}, Title: "卸载主题", Group: "主题管理", Request: &themeParam{}},
+	{Mount: "admin", Method: "POST", Path: "/user/add", Controller: "UserController", Handler: "PostAdd"},
+	{Mount: "admin", Method: "GET", Path: "/user/admin_info", Controller: "UserController", Handler: "GetAdminInfo"},
+	{Mount: "admin", Method: "POST", Path: "/user/delete", Controller: "UserController", Handler: "PostDelete"},
+	{Mount: "admin", Method: "POST", Path: "/user/edit", Controller: "UserController", Handler: "PostEdit"},
+	{Mount: "admin", Method: "GET", Path: "/user/info", Controller: "UserController", Handler: "GetInfo"},
+	{Mount: "admin", Method: "POST", Path: "/user/list", Controller: "UserController", Handler: "PostList"},
+	{Mount: "admin", Method: "POST", Path: "/user/logout", Controller: "UserController", Handler: "PostLogout"},
+	{Mount: "admin", Method: "POST", Path: "/user/person_update", Controller: "UserController", Handler: "PostPersonUpdate"},
+	{Mount: "admin", Method: "GET", Path: "/user/select", Controller: "UserController", Handler: "GetSelect"},
+	{Mount: "admin", Method: "POST", Path: "/user/update", Controller: "UserController", Handler: "PostUpdate"},
+}
+
+func (c *AdController) RegisterRoute(b pine.IRouterWrapper) {
+	route.Bind(b, AnnotatedRoutes, "AdController")
+}
+
+func (c *AdSpaceController) RegisterRoute(b pine.IRouterWrapper) {
+	route.Bind(b, AnnotatedRoutes, "AdSpaceController")
+}
+
+func (c *AdminRoleController) RegisterRoute(b pine.IRouterWrapper) {
+	route.Bind(b, AnnotatedRoutes, "AdminRoleController")
+}
+
+func (c *AssetsManagerController) RegisterRoute(b pine.IRouterWrapper) {
+	route.Bind(b, AnnotatedRoutes, "AssetsManagerController")
+}
+
+func (c *AttachmentController) RegisterRoute(b pine.IRouterWrapper) {
+	route.Bind(b, AnnotatedRoutes, "AttachmentController")
+}
+
+func (c *AttachmentTypeController) RegisterRoute(b pine.IRouterWrapper) {
+	route.Bind(b, AnnotatedRoutes, "AttachmentTypeController")
+}
+
+func (c *CategoryController) RegisterRoute(b pine.IRouterWrapper) {
+	route.Bind(b, AnnotatedRoutes, "CategoryController")
+}
+
+func (c *ContentController) RegisterRoute(b pine.IRouterWrapper) {
+	route.Bind(b, AnnotatedRoutes, "ContentController")
+}
+
+func (c *DataSourceController) RegisterRoute(b pine.IRouterWrapper) {
+	route.Bind(b, AnnotatedRoutes, "DataSourceController")
+}
+
+func (c *DatabaseBackupController) RegisterRoute(b pine.IRouterWrapper) {
+	route.Bind(b, AnnotatedRoutes, "DatabaseBackupController")
+}
+
+func (c *DatabaseController) RegisterRoute(b pine.IRouterWrapper) {
+	route.Bind(b, AnnotatedRoutes, "DatabaseController")
+}
+
+func (c *DepartmentController) RegisterRoute(b pine.IRouterWrapper) {
+	route.Bind(b, AnnotatedRoutes, "DepartmentController")
+}
+
+func (c *DictCategoryController) RegisterRoute(b pine.IRouterWrapper) {
+	route.Bind(b, AnnotatedRoutes, "DictCategoryController")
+}
+
+func (c *DictController) RegisterRoute(b pine.IRouterWrapper) {
+	route.Bind(b, AnnotatedRoutes, "DictController")
+}
+
+func (c *DistrictController) RegisterRoute(b pine.IRouterWrapper) {
+	route.Bind(b, AnnotatedRoutes, "DistrictController")
+}
+
+func (c *DocumentController) RegisterRoute(b pine.IRouterWrapper) {
+	route.Bind(b, AnnotatedRoutes, "DocumentController")
+}
+
+func (c *ErrorLogController) RegisterRoute(b pine.IRouterWrapper) {
+	route.Bind(b, AnnotatedRoutes, "ErrorLogController")
+}
+
+func (c *ImportController) RegisterRoute(b pine.IRouterWrapper) {
+	route.Bind(b, AnnotatedRoutes, "ImportController")
+}
+
+func (c *IndexController) RegisterRoute(b pine.IRouterWrapper) {
+	route.Bind(b, AnnotatedRoutes, "IndexController")
+}
+
+func (c *LevelController) RegisterRoute(b pine.IRouterWrapper) {
+	route.Bind(b, AnnotatedRoutes, "LevelController")
+}
+
+func (c *LinkController) RegisterRoute(b pine.IRouterWrapper) {
+	route.Bind(b, AnnotatedRoutes, "LinkController")
+}
+
+func (c *LogController) RegisterRoute(b pine.IRouterWrapper) {
+	route.Bind(b, AnnotatedRoutes, "LogController")
+}
+
+func (c *LoginController) RegisterRoute(b pine.IRouterWrapper) {
+	route.Bind(b, AnnotatedRoutes, "LoginController")
+}
+
+func (c *MemberController) RegisterRoute(b pine.IRouterWrapper) {
+	route.Bind(b, AnnotatedRoutes, "MemberController")
+}
+
+func (c *MemberGroupController) RegisterRoute(b pine.IRouterWrapper) {
+	route.Bind(b, AnnotatedRoutes, "MemberGroupController")
+}
+
+func (c *MenuController) RegisterRoute(b pine.IRouterWrapper) {
+	route.Bind(b, AnnotatedRoutes, "MenuController")
+}
+
+func (c *PluginController) RegisterRoute(b pine.IRouterWrapper) {
+	route.Bind(b, AnnotatedRoutes, "PluginController")
+}
+
+func (c *PositionController) RegisterRoute(b pine.IRouterWrapper) {
+	route.Bind(b, AnnotatedRoutes, "PositionController")
+}
+
+func (c *PublicController) RegisterRoute(b pine.IRouterWrapper) {
+	route.Bind(b, AnnotatedRoutes, "PublicController")
+}
+
+func (c *RecycleController) RegisterRoute(b pine.IRouterWrapper) {
+	route.Bind(b, AnnotatedRoutes, "RecycleController")
+}
+
+func (c *RiverController) RegisterRoute(b pine.IRouterWrapper) {
+	route.Bind(b, AnnotatedRoutes, "RiverController")
+}
+
+func (c *SettingController) RegisterRoute(b pine.IRouterWrapper) {
+	route.Bind(b, AnnotatedRoutes, "SettingController")
+}
+
+func (c *SiteController) RegisterRoute(b pine.IRouterWrapper) {
+	route.Bind(b, AnnotatedRoutes, "SiteController")
+}
+
+func (c *StatController) RegisterRoute(b pine.IRouterWrapper) {
+	route.Bind(b, AnnotatedRoutes, "StatController")
+}
+
+func (c *TableController) RegisterRoute(b pine.IRouterWrapper) {
+	route.Bind(b, AnnotatedRoutes, "TableController")
+}
+
+func (c *TagsController) RegisterRoute(b pine.IRouterWrapper) {
+	route.Bind(b, AnnotatedRoutes, "TagsController")
+}
+
+func (c *ThemeController) RegisterRoute(b pine.IRouterWrapper) {
+	route.Bind(b, AnnotatedRoutes, "ThemeController")
+}
+
+func (c *UserController) RegisterRoute(b pine.IRouterWrapper) {
+	route.Bind(b, AnnotatedRoutes, "UserController")
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/xiusin/pinecms/src/config"
 )
 
+// @Rest(path = "/setting", menu = "系统配置")
 type SettingController struct {
 	BaseController
 }
@@ -45,7 +46,7 @@ func (c *SettingController) before(act int, params any) error {
 		if pars == nil {
 			panic(errors.New("必须选择分组"))
 		}
-		params.(*xorm.Session).Where("`group` = ?", pars.(map[string]any)["group"]).OrderBy("listorder DESC").OrderBy("id")
+		params.(*xorm.Session).Where(c.Orm.Quote("group")+" = ?", pars.(map[string]any)["group"]).OrderBy("listorder DESC").OrderBy("id")
 	}
 	return nil
 }
@@ -61,7 +62,7 @@ func (c *SettingController) after(act int, _ any) error {
 // PostGroups 获取新分组
 func (c *SettingController) PostGroups() {
 	var groups []tables.Setting
-	c.Orm.Table(&tables.Setting{}).GroupBy("`group`").Find(&groups)
+	c.Orm.Table(&tables.Setting{}).GroupBy(c.Orm.Quote("group")).Find(&groups)
 	var kvs = []KV{}
 
 	for _, group := range groups {
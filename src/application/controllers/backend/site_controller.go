@@ -0,0 +1,100 @@
+package backend
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/xiusin/pine"
+	"github.com/xiusin/pine/di"
+	"github.com/xiusin/pinecms/src/application/controllers"
+	"github.com/xiusin/pinecms/src/application/models/tables"
+	"github.com/xiusin/pinecms/src/common/helper"
+	"xorm.io/xorm"
+)
+
+var siteOnce sync.Once
+
+// InitSiteInstall 同步多站点所需的表结构, 需在casbin初始化前调用
+func InitSiteInstall() {
+	siteOnce.Do(func() {
+		defer func() {
+			if err := recover(); err != nil {
+				pine.Logger().Warn("初始化多站点失败", err)
+			}
+		}()
+		if err := helper.GetORM().Sync2(&tables.Site{}, &tables.Category{}, &tables.Admin{}, &tables.Tags{}, &tables.Link{}, &tables.AdvertSpace{}); err != nil {
+			pine.Logger().Warn(err.Error())
+		}
+	})
+}
+
+// refreshPolicy 重新生成casbin权限与站点域
+func refreshPolicy() {
+	if handler, err := di.Get(controllers.ServiceCasbinAddPolicy); err == nil {
+		handler.(func())()
+	}
+}
+
+// @Rest(path = "/site", menu = "站点管理")
+type SiteController struct {
+	BaseController
+}
+
+func (c *SiteController) Construct() {
+	c.KeywordsSearch = []SearchFieldDsl{
+		{Field: "name", Op: "LIKE", DataExp: "%$?%"},
+		{Field: "host", Op: "LIKE", DataExp: "%$?%"},
+	}
+	c.Table = &tables.Site{}
+	c.Entries = &[]tables.Site{}
+	c.ApiEntityName = "站点"
+	c.Group = "站点管理"
+	c.BaseController.Construct()
+	c.OpBefore = c.before
+	c.OpAfter = c.after
+}
+
+func (c *SiteController) before(act int, params any) error {
+	switch act {
+	case OpList:
+		params.(*xorm.Session).Asc("listorder").Asc("id")
+	case OpAdd, OpEdit:
+		site := params.(*tables.Site)
+		site.Host = strings.ToLower(strings.TrimSpace(site.Host))
+		for i, alias := range site.Aliases {
+			site.Aliases[i] = strings.ToLower(strings.TrimSpace(alias))
+		}
+		hosts := append([]string{site.Host}, site.Aliases...)
+		var sites []tables.Site
+		c.Orm.Where("id <> ?", site.Id).Find(&sites)
+		for _, other := range sites {
+			for _, host := range hosts {
+				if other.Host == host || slices.Contains(other.Aliases, host) {
+					return errors.New("域名" + host + "已被站点" + other.Name + "使用")
+				}
+			}
+		}
+		if site.Settings == nil {
+			site.Settings = map[string]string{}
+		}
+	case OpDel:
+		if exist, _ := c.Orm.In("site_id", params.(*idParams).Ids).Exist(&tables.Category{}); exist {
+			return errors.New("站点下存在栏目, 无法删除")
+		}
+	}
+	return nil
+}
+
+func (c *SiteController) after(act int, params any) error {
+	if act == OpList || act == OpInfo {
+		return nil
+	}
+	if site, ok := params.(*tables.Site); ok && site.IsDefault { // 只保留一个默认站点
+		c.Orm.Where("id <> ?", site.Id).Cols("is_default").Update(&tables.Site{})
+	}
+	helper.Cache().Delete(controllers.CacheSites)
+	refreshPolicy()
+	return nil
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/shirou/gopsutil/net"
 	"github.com/xiusin/pine"
 	"github.com/xiusin/pinecms/cmd/version"
+	"github.com/xiusin/pinecms/src/common/dialect"
 	"github.com/xiusin/pinecms/src/common/helper"
 	"xorm.io/xorm"
 )
@@ -94,6 +95,7 @@ type IPLocate struct {
 
 var outIp *IPLocate
 
+// @Rest(path = "/stat")
 type StatController struct {
 	pine.Controller
 }
@@ -234,9 +236,6 @@ func (stat *StatController) GetData(orm *xorm.Engine, cacher contracts.Cache) {
 	s.XormVersion = ""
 	s.LocalIp, _ = stat.GetLocalIP()
 	s.OutIp, _ = stat.GetOutIp()
-	versions, err := orm.QueryString("SELECT VERSION() AS version")
-	if err == nil {
-		s.MysqlVersion = versions[0]["version"]
-	}
+	s.MysqlVersion = dialect.Of(orm).Name() + " " + dialect.Version(orm)
 	helper.Ajax(s, 0, stat.Ctx())
 }
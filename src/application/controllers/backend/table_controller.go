@@ -13,6 +13,7 @@ import (
 	"xorm.io/xorm"
 )
 
+// @Rest(path = "/table", menu = "字段管理")
 type TableController struct {
 	BaseController
 }
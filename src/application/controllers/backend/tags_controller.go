@@ -2,6 +2,7 @@ package backend
 
 import "github.com/xiusin/pinecms/src/application/models/tables"
 
+// @Rest(path = "/tags", menu = "标签管理")
 type TagsController struct {
 	BaseController
 }
@@ -15,4 +16,5 @@ func (c *TagsController) Construct() {
 	c.ApiEntityName = "标签"
 	c.Group = "标签管理"
 	c.BaseController.Construct()
+	c.OpBefore = c.SiteScope
 }
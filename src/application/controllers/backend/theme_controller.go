@@ -0,0 +1,126 @@
+package backend
+
+import (
+	"net/url"
+
+	"github.com/xiusin/pine"
+	"github.com/xiusin/pinecms/src/common/helper"
+	"github.com/xiusin/pinecms/src/common/theme"
+)
+
+// ThemeController 主题包管理
+// @Rest(path = "/", magic = "false")
+type ThemeController struct {
+	pine.Controller
+}
+
+// themeParam 按名称操作主题的参数
+type themeParam struct {
+	Name string `json:"name"`
+}
+
+// themeSettingsParam 保存主题配置的参数
+type themeSettingsParam struct {
+	Name   string            `json:"name"`
+	Values map[string]string `json:"values"`
+}
+
+func (c *ThemeController) themeName() string {
+	var p themeParam
+	if err := c.Ctx().BindJSON(&p); err != nil || len(p.Name) == 0 {
+		p.Name, _ = c.Ctx().Input().GetString("name")
+	}
+	return p.Name
+}
+
+// @Rest(method = "GET", route = "/theme/list", title = "主题列表", group = "主题管理", resp = "[]*theme.Manifest")
+func (c *ThemeController) List() {
+	list, err := theme.List()
+	if err != nil {
+		helper.Ajax("读取主题目录失败: "+err.Error(), 1, c.Ctx())
+		return
+	}
+	helper.Ajax(list, 0, c.Ctx())
+}
+
+// Install 上传安装或升级主题包, 字段file, force=1时允许覆盖同版本或降级
+// @Rest(method = "POST", route = "/theme/install", title = "安装主题", group = "主题管理", resp = "theme.Manifest", middleware = "request_log")
+func (c *ThemeController) Install() {
+	fh, err := c.Ctx().FormFile("file")
+	if err != nil {
+		helper.Ajax("请上传主题包", 1, c.Ctx())
+		return
+	}
+	f, err := fh.Open()
+	if err != nil {
+		helper.Ajax(err, 1, c.Ctx())
+		return
+	}
+	defer f.Close()
+	force, _ := c.Ctx().Input().GetBool("force")
+	manifest, err := theme.Install(f, fh.Size, force)
+	if err != nil {
+		helper.Ajax(err, 1, c.Ctx())
+		return
+	}
+	helper.Ajax(manifest, 0, c.Ctx())
+}
+
+// @Rest(method = "POST", route = "/theme/uninstall", title = "卸载主题", group = "主题管理", req = "themeParam", middleware = "request_log")
+func (c *ThemeController) Uninstall() {
+	if err := theme.Uninstall(c.themeName()); err != nil {
+		helper.Ajax(err, 1, c.Ctx())
+		return
+	}
+	helper.Ajax("卸载主题成功", 0, c.Ctx())
+}
+
+// @Rest(method = "POST", route = "/theme/active", title = "启用主题", group = "主题管理", req = "themeParam")
+func (c *ThemeController) Active() {
+	if err := theme.SetActive(c.themeName()); err != nil {
+		helper.Ajax(err, 1, c.Ctx())
+		return
+	}
+	helper.Ajax("设置主题成功", 0, c.Ctx())
+}
+
+// Preview 生成预览地址, 访问后当前浏览器使用该主题浏览前台, 不影响其他访客
+// @Rest(method = "POST", route = "/theme/preview", title = "预览主题", group = "主题管理", req = "themeParam")
+func (c *ThemeController) Preview() {
+	name := c.themeName()
+	token, err := theme.SignPreview(name)
+	if err != nil {
+		helper.Ajax(err, 1, c.Ctx())
+		return
+	}
+	helper.Ajax(pine.H{
+		"url":      "/?" + theme.PreviewQuery + "=" + url.QueryEscape(token),
+		"exit_url": "/?" + theme.PreviewExitQuery + "=1",
+	}, 0, c.Ctx())
+}
+
+// @Rest(method = "GET", route = "/theme/settings", title = "主题配置", group = "主题管理", resp = "theme.Manifest")
+func (c *ThemeController) Settings() {
+	name, _ := c.Ctx().Input().GetString("name")
+	manifest, err := theme.Load(name)
+	if err != nil {
+		helper.Ajax(err, 1, c.Ctx())
+		return
+	}
+	helper.Ajax(pine.H{"fields": manifest.Settings, "values": theme.Settings(name)}, 0, c.Ctx())
+}
+
+// SaveSettings 保存主题配置, 与读取配置使用不同的权限标识
+// @Rest(method = "POST", route = "/theme/settings", perm = "theme:save_settings", title = "保存主题配置", group = "主题管理", req = "themeSettingsParam")
+func (c *ThemeController) SaveSettings() {
+	var p themeSettingsParam
+	if err := c.Ctx().BindJSON(&p); err != nil {
+		helper.Ajax(err, 1, c.Ctx())
+		return
+	}
+	if err := theme.SaveSettings(p.Name, p.Values); err != nil {
+		helper.Ajax(err, 1, c.Ctx())
+		return
+	}
+	helper.Ajax("保存主题配置成功", 0, c.Ctx())
+}
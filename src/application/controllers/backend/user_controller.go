@@ -12,6 +12,7 @@ import (
 	"github.com/xiusin/pinecms/src/common/helper"
 )
 
+// @Rest(path = "/user", menu = "管理员管理")
 type UserController struct {
 	BaseController
 }
@@ -101,6 +102,9 @@ func (c *UserController) before(opType int, param any) error {
 }
 
 func (c *UserController) after(opType int, param any) error {
+	if opType == OpAdd || opType == OpEdit || opType == OpDel { // 角色或站点变化后刷新权限
+		refreshPolicy()
+	}
 	if opType == OpList {
 		admins := c.Entries.(*[]*tables.Admin)
 		roles := models.NewAdminRoleModel().All()
@@ -14,6 +14,9 @@ type WechatAccountController struct {
 func (c *WechatAccountController) Construct() {
 	c.Table = &tables.WechatAccount{}
 	c.Entries = &[]tables.WechatAccount{}
+	c.SearchFields = []backend.SearchFieldDsl{
+		{Field: "platform"},
+	}
 	c.BaseController.Construct()
 }
 
@@ -29,7 +32,12 @@ func (c *WechatAccountController) PostClear() {
 }
 
 func (c *WechatAccountController) PostSelect() {
-	_ = c.Orm.Find(c.Entries)
+	sess := c.Orm.NewSession()
+	defer sess.Close()
+	if platform, err := c.Input().GetInt("platform"); err == nil {
+		sess.Where("platform = ?", platform)
+	}
+	_ = sess.Find(c.Entries)
 	m := c.Entries.(*[]tables.WechatAccount)
 	var kv []tables.KV
 	for _, model := range *m {
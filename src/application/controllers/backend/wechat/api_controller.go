@@ -11,6 +11,8 @@ import (
 	"github.com/xiusin/pine"
 	"github.com/xiusin/pinecms/src/application/controllers"
 	"github.com/xiusin/pinecms/src/application/models/tables"
+	"github.com/xiusin/pinecms/src/common/dialect"
+	"github.com/xiusin/pinecms/src/config"
 	"xorm.io/xorm"
 )
 
@@ -59,8 +61,15 @@ func msgHandler(ctx *pine.Context) {
 		var replyMsg any
 		var err error
 
+		go forwardToIm(config.CtxSiteConfig(ctx), appid, msg)
+
+		if tpl := matchPosterKeyword(appid, msg.Content); tpl != nil {
+			go sendPoster(config.CtxSiteConfig(ctx), tpl, string(msg.FromUserName))
+			return &message.Reply{MsgType: message.MsgTypeText, MsgData: message.NewText("海报生成中, 请稍候...")}
+		}
+
 		var baseSql = "SELECT * FROM %s WHERE ((match_value = ? AND exact_match = 1) OR " +
-			"(INSTR(?, match_value) > 0 AND  exact_match = 0)) AND appid = '" + appid +
+			"(" + dialect.Current().Instr("?", "match_value") + " > 0 AND  exact_match = 0)) AND appid = '" + appid +
 			"' AND status = 1 ORDER BY exact_match DESC, id DESC LIMIT 1"
 
 		orm.SQL(fmt.Sprintf(baseSql, controllers.GetTableName("wechat_msg_reply_rule")), msg.Content, msg.Content).Find(&rules)
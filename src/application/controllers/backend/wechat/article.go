@@ -0,0 +1,304 @@
+package wechat
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/silenceper/wechat/v2/officialaccount"
+	"github.com/silenceper/wechat/v2/officialaccount/draft"
+	"github.com/silenceper/wechat/v2/officialaccount/material"
+	"github.com/xiusin/pine"
+	"github.com/xiusin/pinecms/src/application/controllers"
+	"github.com/xiusin/pinecms/src/application/controllers/backend"
+	"github.com/xiusin/pinecms/src/application/models/tables"
+	"github.com/xiusin/pinecms/src/common/dialect"
+	"github.com/xiusin/pinecms/src/common/helper"
+	"github.com/xiusin/pinecms/src/config"
+)
+
+// imgSrcPattern 匹配内容中的图片地址, 微信文章图片地址保存在data-src内
+var imgSrcPattern = regexp.MustCompile(`(?i)(<img[^>]+?(?:data-src|src)=["'])([^"']+)(["'])`)
+
+// maxImageSize 微信永久素材图片上限为10M
+const maxImageSize = 10 << 20
+
+// imageClient 下载图片, 只允许连接公网地址, 防止内容中的图片地址访问内网服务
+var imageClient = &http.Client{
+	Timeout:   30 * time.Second,
+	Transport: &http.Transport{DialContext: (&net.Dialer{Timeout: 10 * time.Second, Control: helper.PublicAddr}).DialContext},
+}
+
+// imageExts 导入图文时允许的图片扩展名
+var imageExts = []string{"jpg", "jpeg", "png", "gif", "webp"}
+
+// fetchImage 读取图片内容, 本地静态资源直接读取文件, 否则通过http下载
+func fetchImage(src string) ([]byte, error) {
+	if !strings.HasPrefix(src, "http://") && !strings.HasPrefix(src, "https://") && !strings.HasPrefix(src, "//") {
+		for _, static := range config.App().Statics {
+			if strings.HasPrefix(src, static.Route) {
+				name := strings.TrimLeft(strings.Split(strings.TrimPrefix(src, static.Route), "?")[0], "/")
+				if !filepath.IsLocal(name) {
+					return nil, fmt.Errorf("图片路径%s不合法", src)
+				}
+				if byts, err := os.ReadFile(filepath.Join(static.Path, name)); err == nil {
+					return byts, nil
+				}
+			}
+		}
+		src = strings.TrimRight(config.GetSiteConfigByKey("SITE_URL"), "/") + "/" + strings.TrimLeft(src, "/")
+	} else if strings.HasPrefix(src, "//") {
+		src = "https:" + src
+	}
+	resp, err := imageClient.Get(src)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("下载图片%s失败: %s", src, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxImageSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxImageSize {
+		return nil, fmt.Errorf("图片%s超过10M", src)
+	}
+	return data, nil
+}
+
+// imageExt 图片地址的扩展名, 微信图片地址的格式在 wx_fmt 参数中, 不在允许范围内时使用 .jpg
+func imageExt(src string) string {
+	ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(strings.Split(src, "?")[0])), ".")
+	if idx := strings.Index(src, "wx_fmt="); idx > -1 {
+		ext = strings.ToLower(strings.Split(src[idx+7:], "&")[0])
+	}
+	if !slices.Contains(imageExts, ext) {
+		return ".jpg"
+	}
+	return "." + ext
+}
+
+// withTempImage 微信素材接口只接受文件路径, 将图片写入临时文件后回调
+func withTempImage(src string, fn func(filename string) error) error {
+	data, err := fetchImage(src)
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp("", "pinecms_wechat_*"+imageExt(src))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		f.Close()
+		os.Remove(f.Name())
+	}()
+	if _, err = io.Copy(f, bytes.NewReader(data)); err != nil {
+		return err
+	}
+	return fn(f.Name())
+}
+
+// replaceContentImages 替换内容中的全部图片地址, 相同地址只处理一次
+func replaceContentImages(content string, replacer func(src string) (string, error)) string {
+	replaced := map[string]string{}
+	return imgSrcPattern.ReplaceAllStringFunc(content, func(tag string) string {
+		matches := imgSrcPattern.FindStringSubmatch(tag)
+		src := matches[2]
+		if _, ok := replaced[src]; !ok {
+			newSrc, err := replacer(src)
+			if err != nil {
+				pine.Logger().Warn("替换图片地址失败", src, err)
+				newSrc = src
+			}
+			replaced[src] = newSrc
+		}
+		return strings.Replace(tag, matches[1]+src+matches[3], matches[1]+replaced[src]+matches[3], 1)
+	})
+}
+
+// getDocument 读取模型内的文档数据
+func getDocument(mid, aid int64) (*tables.DocumentModel, map[string]string, error) {
+	orm := helper.GetORM()
+	var model tables.DocumentModel
+	if exist, _ := orm.Where("id = ?", mid).Get(&model); !exist {
+		return nil, nil, errors.New("模型不存在")
+	}
+	docs, err := orm.Table(controllers.GetTableName(model.Table)).Where("id = ?", aid).QueryString()
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(docs) == 0 {
+		return nil, nil, fmt.Errorf("文档%d不存在", aid)
+	}
+	return &model, docs[0], nil
+}
+
+// buildDraftArticle 将文档转换为微信草稿, 内容图片与封面上传至微信
+func buildDraftArticle(account *officialaccount.OfficialAccount, doc map[string]string, thumbMediaId string) (*draft.Article, error) {
+	if len(thumbMediaId) == 0 {
+		if len(doc["thumb"]) == 0 {
+			return nil, errors.New("文档缺少缩略图, 无法作为图文封面")
+		}
+		if err := withTempImage(doc["thumb"], func(filename string) (err error) {
+			thumbMediaId, _, err = account.GetMaterial().AddMaterial(material.MediaTypeImage, filename)
+			return err
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	content := replaceContentImages(doc["content"], func(src string) (wxUrl string, err error) {
+		if strings.Contains(src, "mmbiz.qpic.cn") {
+			return src, nil
+		}
+		err = withTempImage(src, func(filename string) (err error) {
+			wxUrl, err = account.GetMaterial().ImageUpload(filename)
+			return err
+		})
+		return wxUrl, err
+	})
+
+	return &draft.Article{
+		Title:        doc["title"],
+		Author:       doc["author"],
+		Digest:       doc["description"],
+		Content:      content,
+		ThumbMediaID: thumbMediaId,
+		ShowCoverPic: 1,
+	}, nil
+}
+
+// PushArticle 推送文档到公众号草稿箱, 已存在映射关系时更新草稿
+func PushArticle(appid string, mid, aid int64) (*tables.WechatArticle, error) {
+	account, _ := GetOfficialAccount(appid)
+	_, doc, err := getDocument(mid, aid)
+	if err != nil {
+		return nil, err
+	}
+	orm := helper.GetORM()
+	mapping := &tables.WechatArticle{}
+	orm.Where("appid = ?", appid).Where("mid = ?", mid).Where("aid = ?", aid).Get(mapping)
+
+	article, err := buildDraftArticle(account, doc, mapping.ThumbMediaId)
+	if err != nil {
+		return nil, err
+	}
+	if len(mapping.MediaId) > 0 {
+		if err = account.GetDraft().UpdateDraft(article, mapping.MediaId, 0); err != nil {
+			return nil, err
+		}
+	} else if mapping.MediaId, err = account.GetDraft().AddDraft([]*draft.Article{article}); err != nil {
+		return nil, err
+	}
+
+	mapping.Appid, mapping.Mid, mapping.Aid = appid, mid, aid
+	mapping.Title, mapping.ThumbMediaId = article.Title, article.ThumbMediaID
+	if mapping.Id > 0 {
+		_, err = orm.ID(mapping.Id).AllCols().Update(mapping)
+	} else {
+		_, err = orm.InsertOne(mapping)
+	}
+	return mapping, err
+}
+
+// importItem 待导入的微信图文, 草稿与已发布图文统一转换为此结构
+type importItem struct {
+	Title        string
+	Author       string
+	Digest       string
+	Content      string
+	URL          string
+	ThumbMediaID string
+}
+
+// ImportArticle 导入微信图文到指定栏目, 图片转存到当前存储驱动, 内容首图作为缩略图
+func ImportArticle(appid string, catid int64, mediaId string, item importItem) (int64, error) {
+	orm := helper.GetORM()
+	if exist, _ := orm.Where("appid = ?", appid).Where("media_id = ?", mediaId).Where("title = ?", item.Title).Exist(&tables.WechatArticle{}); exist {
+		return 0, fmt.Errorf("图文《%s》已导入", item.Title)
+	}
+	var category tables.Category
+	if exist, _ := orm.Where("id = ?", catid).Get(&category); !exist || category.ModelId < 1 {
+		return 0, errors.New("栏目不存在或未绑定模型")
+	}
+	var model tables.DocumentModel
+	if exist, _ := orm.Where("id = ?", category.ModelId).Get(&model); !exist {
+		return 0, errors.New("栏目模型不存在")
+	}
+
+	cfg, err := config.SiteConfigFor(config.ScopeSite(category.SiteId))
+	if err != nil {
+		return 0, err
+	}
+	uploader, uploadDir := backend.GetStorageEngine(cfg), "wechat/"+helper.NowDate("20060102")
+	var thumb string
+	content := replaceContentImages(item.Content, func(src string) (string, error) {
+		data, err := fetchImage(src)
+		if err != nil {
+			return "", err
+		}
+		url, err := uploader.Upload(uploadDir+"/"+string(helper.Krand(16, 3))+imageExt(src), bytes.NewReader(data))
+		if err == nil && len(thumb) == 0 {
+			thumb = url
+		}
+		return url, err
+	})
+	content = strings.ReplaceAll(content, "data-src=", "src=")
+
+	now := helper.NowDate(helper.TimeFormat)
+	data := map[string]any{
+		"title":        item.Title,
+		"author":       item.Author,
+		"description":  item.Digest,
+		"content":      content,
+		"thumb":        thumb,
+		"from_url":     item.URL,
+		"catid":        catid,
+		"mid":          model.Id,
+		"status":       1,
+		"pubtime":      now,
+		"created_time": now,
+		"updated_time": now,
+	}
+	aid, err := dialect.Insert(orm, controllers.GetTableName(model.Table), data)
+	if err != nil {
+		return 0, fmt.Errorf("导入图文《%s》失败: %w", item.Title, err)
+	}
+
+	_, err = orm.InsertOne(&tables.WechatArticle{
+		Appid:        appid,
+		Mid:          model.Id,
+		Aid:          aid,
+		Title:        item.Title,
+		MediaId:      mediaId,
+		ThumbMediaId: item.ThumbMediaID,
+	})
+	return aid, err
+}
+
+// autoSyncArticle 文档修改后推送开启了自动同步的草稿
+func autoSyncArticle(mid, aid int64) {
+	defer func() {
+		if err := recover(); err != nil {
+			pine.Logger().Warn("同步图文失败", err)
+		}
+	}()
+	var mappings []tables.WechatArticle
+	helper.GetORM().Where("mid = ?", mid).Where("aid = ?", aid).Where("auto_sync = ?", true).Find(&mappings)
+	for _, mapping := range mappings {
+		if _, err := PushArticle(mapping.Appid, mid, aid); err != nil {
+			pine.Logger().Warn(fmt.Sprintf("同步图文%s失败", mapping.MediaId), err)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package wechat
+
+import (
+	"fmt"
+
+	"github.com/xiusin/pine"
+	"github.com/xiusin/pinecms/src/application/controllers/backend"
+	"github.com/xiusin/pinecms/src/application/models/tables"
+	"github.com/xiusin/pinecms/src/common/helper"
+	"xorm.io/xorm"
+)
+
+// WechatArticleController 文档与微信图文同步
+type WechatArticleController struct {
+	backend.BaseController
+}
+
+func (c *WechatArticleController) Construct() {
+	c.Table = &tables.WechatArticle{}
+	c.Entries = &[]tables.WechatArticle{}
+	c.SearchFields = []backend.SearchFieldDsl{
+		{Field: "appid"},
+		{Field: "mid"},
+		{Field: "aid"},
+		{Field: "title", Op: "LIKE", DataExp: "%$?%"},
+	}
+	c.BaseController.Construct()
+	c.OpBefore = c.Before
+}
+
+func (c *WechatArticleController) Before(act int, params any) error {
+	if act == backend.OpList {
+		(params.(*xorm.Session)).Desc("id")
+	}
+	return nil
+}
+
+// PostPush 发布文档为草稿, 已关联的文档更新草稿内容
+func (c *WechatArticleController) PostPush() {
+	p := struct {
+		Appid    string  `json:"appid"`
+		Mid      int64   `json:"mid"`
+		Ids      []int64 `json:"ids"`
+		AutoSync bool    `json:"auto_sync"`
+		Publish  bool    `json:"publish"`
+	}{}
+	if err := c.Ctx().BindJSON(&p); err != nil {
+		helper.Ajax(err, 1, c.Ctx())
+		return
+	}
+	if len(p.Appid) == 0 || p.Mid < 1 || len(p.Ids) == 0 {
+		helper.Ajax("参数错误", 1, c.Ctx())
+		return
+	}
+	account, _ := GetOfficialAccount(p.Appid)
+
+	var errs []string
+	for _, id := range p.Ids {
+		mapping, err := PushArticle(p.Appid, p.Mid, id)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("文档%d: %s", id, err))
+			continue
+		}
+		if p.AutoSync != mapping.AutoSync {
+			mapping.AutoSync = p.AutoSync
+			c.Orm.ID(mapping.Id).Cols("auto_sync").Update(mapping)
+		}
+		if p.Publish {
+			if mapping.PublishId, err = account.GetFreePublish().Publish(mapping.MediaId); err != nil {
+				errs = append(errs, fmt.Sprintf("文档%d发布失败: %s", id, err))
+				continue
+			}
+			c.Orm.ID(mapping.Id).Cols("publish_id").Update(mapping)
+		}
+	}
+	if len(errs) > 0 {
+		helper.Ajax(errs, 1, c.Ctx())
+		return
+	}
+	helper.Ajax("推送图文成功", 0, c.Ctx())
+}
+
+// PostStatus 查询发布状态, 发布成功后记录article_id
+func (c *WechatArticleController) PostStatus() {
+	id, _ := c.Input().GetInt64("id")
+	mapping := &tables.WechatArticle{}
+	if exist, _ := c.Orm.ID(id).Get(mapping); !exist || mapping.PublishId == 0 {
+		helper.Ajax("图文未发布", 1, c.Ctx())
+		return
+	}
+	account, _ := GetOfficialAccount(mapping.Appid)
+	status, err := account.GetFreePublish().SelectStatus(mapping.PublishId)
+	if err != nil {
+		helper.Ajax(err, 1, c.Ctx())
+		return
+	}
+	if len(status.ArticleID) > 0 && status.ArticleID != mapping.ArticleId {
+		mapping.ArticleId = status.ArticleID
+		c.Orm.ID(mapping.Id).Cols("article_id").Update(mapping)
+	}
+	helper.Ajax(status, 0, c.Ctx())
+}
+
+// PostImport 导入公众号已发布图文或草稿到指定栏目
+func (c *WechatArticleController) PostImport() {
+	p := struct {
+		Appid  string `json:"appid"`
+		Catid  int64  `json:"catid"`
+		Source string `json:"source"` // draft=草稿箱 其他=已发布图文
+	}{}
+	if err := c.Ctx().BindJSON(&p); err != nil {
+		helper.Ajax(err, 1, c.Ctx())
+		return
+	}
+	if len(p.Appid) == 0 || p.Catid < 1 {
+		helper.Ajax("参数错误", 1, c.Ctx())
+		return
+	}
+	account, _ := GetOfficialAccount(p.Appid)
+
+	var imported int
+	var errs []string
+	collect := func(mediaId string, item importItem) {
+		if _, err := ImportArticle(p.Appid, p.Catid, mediaId, item); err != nil {
+			errs = append(errs, err.Error())
+		} else {
+			imported++
+		}
+	}
+
+	var offset, size int64 = 0, 20
+	for {
+		var count int64
+		if p.Source == "draft" {
+			list, err := account.GetDraft().PaginateDraft(offset, size, false)
+			if err != nil {
+				helper.Ajax(err, 1, c.Ctx())
+				return
+			}
+			for _, item := range list.Item {
+				for _, news := range item.Content.NewsItem {
+					collect(item.MediaID, importItem{news.Title, news.Author, news.Digest, news.Content, news.ContentSourceURL, news.ThumbMediaID})
+				}
+			}
+			count = list.ItemCount
+		} else {
+			list, err := account.GetFreePublish().Paginate(offset, size, false)
+			if err != nil {
+				helper.Ajax(err, 1, c.Ctx())
+				return
+			}
+			for _, item := range list.Item {
+				for _, news := range item.Content.NewsItem {
+					if !news.IsDeleted {
+						collect(item.ArticleID, importItem{news.Title, news.Author, news.Digest, news.Content, news.URL, news.ThumbMediaID})
+					}
+				}
+			}
+			count = list.ItemCount
+		}
+		if count < size {
+			break
+		}
+		offset += size
+	}
+	helper.Ajax(pine.H{"imported": imported, "errors": errs}, 0, c.Ctx())
+}
@@ -0,0 +1,37 @@
+package wechat
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestImageExt(t *testing.T) {
+	cases := map[string]string{
+		"https://mmbiz.qpic.cn/a/0?wx_fmt=png":              ".png",
+		"https://mmbiz.qpic.cn/a/0?wx_fmt=JPEG&from=appmsg": ".jpeg",
+		"https://mmbiz.qpic.cn/a/0?wx_fmt=../../x":          ".jpg",
+		"https://mmbiz.qpic.cn/a/0?wx_fmt=php":              ".jpg",
+		"/uploads/a.gif?v=1":                                ".gif",
+		"/uploads/a":                                        ".jpg",
+		"/uploads/a.svg":                                    ".jpg",
+	}
+	for src, ext := range cases {
+		if got := imageExt(src); got != ext {
+			t.Errorf("%s 扩展名应为%s, 实际%s", src, ext, got)
+		}
+	}
+}
+
+func TestFetchImagePrivateAddr(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("secret"))
+	}))
+	defer srv.Close()
+	for _, src := range []string{srv.URL + "/a.png", "http://169.254.169.254/latest/meta-data", "http://10.0.0.1/a.png"} {
+		if _, err := fetchImage(src); err == nil || !strings.Contains(err.Error(), "禁止访问") {
+			t.Errorf("%s 不应访问内网地址: %v", src, err)
+		}
+	}
+}
@@ -5,33 +5,77 @@ import (
 	"github.com/xiusin/pine/contracts"
 
 	"github.com/silenceper/wechat/v2"
+	"github.com/silenceper/wechat/v2/miniprogram"
+	miniConfig "github.com/silenceper/wechat/v2/miniprogram/config"
 	"github.com/silenceper/wechat/v2/officialaccount"
 	offConfig "github.com/silenceper/wechat/v2/officialaccount/config"
+	"github.com/silenceper/wechat/v2/work"
+	workConfig "github.com/silenceper/wechat/v2/work/config"
 	"github.com/xiusin/pine/di"
 	"github.com/xiusin/pinecms/src/application/controllers"
 	"github.com/xiusin/pinecms/src/application/models/tables"
 	"github.com/xiusin/pinecms/src/common/helper"
 )
 
-func GetOfficialAccount(appid string) (*officialaccount.OfficialAccount, *tables.WechatAccount) {
+var platformNames = map[uint]string{
+	tables.WechatPlatformOfficial: "公众号",
+	tables.WechatPlatformMini:     "小程序",
+	tables.WechatPlatformWork:     "企业微信",
+}
+
+// getAccount 读取指定平台的账号配置, 账号不存在或平台不匹配时panic
+func getAccount(appid string, platform uint) *tables.WechatAccount {
 	accountData := &tables.WechatAccount{}
 	orm := helper.GetORM()
 	orm.Where("app_id = ?", appid).Get(accountData)
-	if accountData.Id == 0 {
-		panic(errors.New("公众号" + appid + "不存在"))
+	if accountData.Id == 0 || accountData.Platform != platform {
+		panic(errors.New(platformNames[platform] + appid + "不存在"))
 	}
-	wc, memory := wechat.NewWechat(), &WechatTokenCacher{Cache: di.MustGet(controllers.ServiceICache).(contracts.Cache)}
+	return accountData
+}
+
+// newWechat 所有平台共用同一个token缓存
+func newWechat() *wechat.Wechat {
+	wc := wechat.NewWechat()
+	wc.SetCache(&WechatTokenCacher{Cache: di.MustGet(controllers.ServiceICache).(contracts.Cache)})
+	return wc
+}
+
+func GetOfficialAccount(appid string) (*officialaccount.OfficialAccount, *tables.WechatAccount) {
+	accountData := getAccount(appid, tables.WechatPlatformOfficial)
 	cfg := &offConfig.Config{
 		AppID:          accountData.AppId,
 		AppSecret:      accountData.Secret,
 		Token:          accountData.Token,
 		EncodingAESKey: accountData.AesKey,
-		Cache:          memory,
 	}
-	account := wc.GetOfficialAccount(cfg)
+	account := newWechat().GetOfficialAccount(cfg)
 	return account, accountData
 }
 
+// GetMiniProgram 获取小程序实例
+func GetMiniProgram(appid string) (*miniprogram.MiniProgram, *tables.WechatAccount) {
+	accountData := getAccount(appid, tables.WechatPlatformMini)
+	cfg := &miniConfig.Config{
+		AppID:     accountData.AppId,
+		AppSecret: accountData.Secret,
+	}
+	return newWechat().GetMiniProgram(cfg), accountData
+}
+
+// GetWork 获取企业微信应用实例, appid对应企业corpid
+func GetWork(corpid string) (*work.Work, *tables.WechatAccount) {
+	accountData := getAccount(corpid, tables.WechatPlatformWork)
+	cfg := &workConfig.Config{
+		CorpID:         accountData.AppId,
+		CorpSecret:     accountData.Secret,
+		AgentID:        accountData.AgentId,
+		Token:          accountData.Token,
+		EncodingAESKey: accountData.AesKey,
+	}
+	return newWechat().GetWork(cfg), accountData
+}
+
 func SaveCacheMaterialListKey(key string, cacher contracts.Cache) {
 	var keys []string
 	cacher.GetWithUnmarshal(CacheKeyWechatMaterialListKeys, &keys)
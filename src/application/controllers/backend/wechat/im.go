@@ -0,0 +1,71 @@
+package wechat
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/silenceper/wechat/v2/officialaccount/material"
+	"github.com/silenceper/wechat/v2/officialaccount/message"
+	"github.com/xiusin/pine"
+	"github.com/xiusin/pinecms/src/application/controllers/backend"
+	"github.com/xiusin/pinecms/src/application/controllers/backend/im"
+	"github.com/xiusin/pinecms/src/application/models/tables"
+	"github.com/xiusin/pinecms/src/common/helper"
+	"github.com/xiusin/pinecms/src/config"
+)
+
+const imChannel = "wechat"
+
+// forwardToIm 将粉丝发送的文本与图片消息转入在线客服收件箱, 图片转存到当前存储驱动
+func forwardToIm(cfg config.Site, appid string, msg *message.MixMessage) {
+	defer func() {
+		if err := recover(); err != nil {
+			pine.Logger().Warn("转发客服消息失败", err)
+		}
+	}()
+	imMsg := &tables.ImMessage{MsgType: im.MsgTypeText, Content: msg.Content}
+	switch msg.MsgType {
+	case message.MsgTypeText:
+	case message.MsgTypeImage:
+		data, err := fetchImage(msg.PicURL)
+		if err != nil {
+			pine.Logger().Warn("下载粉丝图片失败", err)
+			return
+		}
+		imMsg.MsgType = im.MsgTypeImage
+		if imMsg.Url, err = backend.GetStorageEngine(cfg).Upload(fmt.Sprintf("im/%s/%s.jpg", helper.NowDate("20060102"), helper.Krand(16, 3)), bytes.NewReader(data)); err != nil {
+			pine.Logger().Warn("保存粉丝图片失败", err)
+			return
+		}
+	default:
+		return
+	}
+	openid := string(msg.FromUserName)
+	fans := &tables.WechatMember{}
+	helper.GetORM().Where("appid = ?", appid).Where("openid = ?", openid).Get(fans)
+	if err := im.Receive(imChannel, appid, openid, fans.Nickname, fans.Headimgurl, imMsg); err != nil {
+		pine.Logger().Warn("转发客服消息失败", err)
+	}
+}
+
+// replyFromIm 客服回复通过客服消息接口发送给粉丝, 文件以链接形式发送
+func replyFromIm(sess *tables.ImSession, msg *tables.ImMessage) error {
+	account, _ := GetOfficialAccount(sess.Appid)
+	manager := account.GetCustomerMessageManager()
+	switch msg.MsgType {
+	case im.MsgTypeImage:
+		var mediaId string
+		if err := withTempImage(msg.Url, func(filename string) error {
+			media, err := account.GetMaterial().MediaUpload(material.MediaTypeImage, filename)
+			mediaId = media.MediaID
+			return err
+		}); err != nil {
+			return err
+		}
+		return manager.Send(message.NewCustomerImgMessage(sess.VisitorId, mediaId))
+	case im.MsgTypeFile:
+		return manager.Send(message.NewCustomerTextMessage(sess.VisitorId, fmt.Sprintf(`<a href="%s">%s</a>`, msg.Url, msg.FileName)))
+	default:
+		return manager.Send(message.NewCustomerTextMessage(sess.VisitorId, msg.Content))
+	}
+}
@@ -42,7 +42,7 @@ func (c *WechatMaterialController) PostList(cacher contracts.Cache) {
 
 	p.Size = 20
 
-	count, err := c.Orm.Where("`type` = ?", p.Type).Where("appid = ?", p.Appid).Limit(p.Size, (p.Page-1)*p.Size).FindAndCount(c.Entries)
+	count, err := c.Orm.Where(c.Orm.Quote("type")+" = ?", p.Type).Where("appid = ?", p.Appid).Limit(p.Size, (p.Page-1)*p.Size).FindAndCount(c.Entries)
 
 	if err != nil {
 		helper.Ajax(err, 1, c.Ctx())
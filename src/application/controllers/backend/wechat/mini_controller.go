@@ -0,0 +1,206 @@
+package wechat
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gbrlsnchs/jwt/v3"
+	"github.com/silenceper/wechat/v2/miniprogram/subscribe"
+	"github.com/xiusin/pine"
+	"github.com/xiusin/pinecms/src/application/controllers"
+	"github.com/xiusin/pinecms/src/application/controllers/backend"
+	"github.com/xiusin/pinecms/src/application/models/tables"
+	"github.com/xiusin/pinecms/src/common/helper"
+	"github.com/xiusin/pinecms/src/common/hooks"
+	"github.com/xiusin/pinecms/src/config"
+	"xorm.io/xorm"
+)
+
+// WechatMiniController 小程序订阅消息管理
+type WechatMiniController struct {
+	backend.BaseController
+}
+
+func (c *WechatMiniController) Construct() {
+	c.Table = &tables.WechatMsgTemplate{}
+	c.Entries = &[]tables.WechatMsgTemplate{}
+	c.SearchFields = []backend.SearchFieldDsl{
+		{Field: "appid"},
+		{Field: "title", Op: "LIKE", DataExp: "%$?%"},
+	}
+	c.BaseController.Construct()
+}
+
+// PostSync 同步小程序订阅消息模板
+func (c *WechatMiniController) PostSync() {
+	appid, _ := c.Input().GetString("appid")
+	if len(appid) == 0 {
+		helper.Ajax("请选择小程序", 1, c.Ctx())
+		return
+	}
+	mini, _ := GetMiniProgram(appid)
+
+	templateList, err := mini.GetSubscribe().ListTemplates()
+	if err != nil {
+		helper.Ajax(err, 1, c.Ctx())
+		return
+	}
+	for _, item := range templateList.Data {
+		if exist, _ := c.Orm.Where("appid = ?", appid).Where("template_id = ?", item.PriTmplID).Exist(c.Table); !exist {
+			c.Orm.InsertOne(&tables.WechatMsgTemplate{
+				Appid:      appid,
+				TemplateId: item.PriTmplID,
+				Title:      item.Title,
+				Content:    item.Content,
+				Example:    item.Example,
+				Status:     true,
+				UpdatedAt:  tables.LocalTime(time.Now()),
+			})
+		}
+	}
+	helper.Ajax("同步成功", 0, c.Ctx())
+}
+
+// PostSend 向小程序用户发送订阅消息
+func (c *WechatMiniController) PostSend() {
+	p := struct {
+		AppId      string  `json:"appid"`
+		TemplateId string  `json:"template_id"`
+		Page       string  `json:"page"`
+		State      string  `json:"miniprogram_state"`
+		MemberIds  []int64 `json:"member_ids"`
+		Data       []struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		} `json:"data"`
+	}{}
+
+	if err := c.Ctx().BindJSON(&p); err != nil {
+		helper.Ajax(err, 1, c.Ctx())
+		return
+	}
+
+	if p.TemplateId == "" || p.AppId == "" || len(p.Data) == 0 {
+		helper.Ajax("参数错误", 1, c.Ctx())
+		return
+	}
+	mini, _ := GetMiniProgram(p.AppId)
+
+	var users []tables.WechatMember
+	sess := c.Orm.Where("appid = ?", p.AppId)
+	if len(p.MemberIds) > 0 {
+		sess.In("member_id", p.MemberIds)
+	}
+	sess.Cols("openid").Find(&users)
+
+	if len(users) == 0 {
+		helper.Ajax("无法查找到相关推送用户", 1, c.Ctx())
+		return
+	}
+
+	failed, msgInfo := 0, map[string]*subscribe.DataItem{}
+	for _, datum := range p.Data {
+		msgInfo[datum.Name] = &subscribe.DataItem{Value: datum.Value}
+	}
+
+	for _, user := range users {
+		err := mini.GetSubscribe().Send(&subscribe.Message{
+			ToUser:           user.Openid,
+			TemplateID:       p.TemplateId,
+			Page:             p.Page,
+			MiniprogramState: p.State,
+			Data:             msgInfo,
+		})
+		if err != nil {
+			failed++
+			pine.Logger().Error(fmt.Sprintf("发送订阅消息给%s失败", user.Openid), err)
+		}
+	}
+	helper.Ajax(fmt.Sprintf("发送信息%d成功, %d失败", len(users)-failed, failed), 0, c.Ctx())
+}
+
+// miniLoginHandler 小程序登录, 通过code2session换取openid并绑定前台会员
+func miniLoginHandler(ctx *pine.Context) {
+	appid := ctx.Params().Get("appid")
+	code, _ := ctx.Input().GetString("code")
+	if len(appid) == 0 || len(code) == 0 {
+		helper.Ajax("参数错误", 1, ctx)
+		return
+	}
+
+	mini, _ := GetMiniProgram(appid)
+	session, err := mini.GetAuth().Code2Session(code)
+	if err != nil {
+		helper.Ajax(err, 1, ctx)
+		return
+	}
+
+	orm := helper.GetORM()
+	fans := &tables.WechatMember{}
+	if _, err = orm.Where("appid = ?", appid).Where("openid = ?", session.OpenID).Get(fans); err != nil {
+		helper.Ajax(err, 1, ctx)
+		return
+	}
+
+	var registered *hooks.MemberEvent
+	_, err = orm.Transaction(func(sess *xorm.Session) (any, error) {
+		if fans.MemberId == 0 {
+			member := &tables.Member{
+				Account:   "wx_" + session.OpenID,
+				Nickname:  "微信用户",
+				Status:    2,
+				LoginTime: tables.LocalTime(time.Now()),
+				LoginIp:   ctx.ClientIP(),
+			}
+			registered = &hooks.MemberEvent{Member: member, Source: hooks.SourceWechatMini}
+			if err := hooks.MemberBeforeRegister.Emit(registered); err != nil {
+				return nil, err
+			}
+			if _, err := sess.InsertOne(member); err != nil {
+				return nil, err
+			}
+			fans.MemberId = member.Id
+		} else if _, err := sess.ID(fans.MemberId).Cols("login_time", "login_ip").Update(&tables.Member{
+			LoginTime: tables.LocalTime(time.Now()),
+			LoginIp:   ctx.ClientIP(),
+		}); err != nil {
+			return nil, err
+		}
+
+		fans.SessionKey = session.SessionKey
+		if fans.Id == 0 {
+			fans.Appid, fans.Openid, fans.Unionid = appid, session.OpenID, session.UnionID
+			_, err := sess.InsertOne(fans)
+			return nil, err
+		}
+		_, err := sess.ID(fans.Id).Cols("member_id", "session_key", "unionid").Update(&tables.WechatMember{
+			MemberId:   fans.MemberId,
+			SessionKey: session.SessionKey,
+			Unionid:    session.UnionID,
+		})
+		return nil, err
+	})
+	if err != nil {
+		helper.Ajax(err, 1, ctx)
+		return
+	}
+	if registered != nil {
+		hooks.MemberAfterRegister.Fire(registered)
+	}
+
+	pl := controllers.LoginMemberPayload{
+		Payload: jwt.Payload{
+			Subject:        "PineCMS",
+			ExpirationTime: jwt.NumericDate(time.Now().Add(7 * 24 * time.Hour)),
+		},
+		MemberId: fans.MemberId,
+		Appid:    appid,
+		Openid:   session.OpenID,
+	}
+	token, err := config.JwtSign(config.JwtAudienceMember, &pl.Payload, &pl)
+	if err != nil {
+		helper.Ajax("登录失败", 1, ctx)
+		return
+	}
+	helper.Ajax(pine.H{"member_id": fans.MemberId, "openid": session.OpenID, "token": string(token)}, 0, ctx)
+}
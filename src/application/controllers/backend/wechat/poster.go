@@ -0,0 +1,128 @@
+package wechat
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/silenceper/wechat/v2/officialaccount/basic"
+	"github.com/silenceper/wechat/v2/officialaccount/material"
+	"github.com/silenceper/wechat/v2/officialaccount/message"
+	"github.com/xiusin/pine"
+	"github.com/xiusin/pinecms/src/application/controllers/backend"
+	"github.com/xiusin/pinecms/src/application/models/tables"
+	"github.com/xiusin/pinecms/src/common/helper"
+	"github.com/xiusin/pinecms/src/common/poster"
+	"github.com/xiusin/pinecms/src/config"
+)
+
+// GeneratePoster 生成粉丝专属海报并缓存到站点的存储驱动, 返回海报地址
+func GeneratePoster(cfg config.Site, tpl *tables.WechatPoster, openid string, force bool) (string, error) {
+	if tpl.Design == nil {
+		return "", errors.New("海报未设计")
+	}
+	uploader := backend.GetStorageEngine(cfg)
+	// 模板修改后文件名随之变化, 旧海报自动失效
+	storageName := fmt.Sprintf("poster/%s/%d_%s_%s.png", tpl.Appid, tpl.Id,
+		helper.GetMd5(tpl.UpdatedAt.String())[:8], openid)
+	if exist, _ := uploader.Exists(storageName); exist && !force {
+		return uploader.GetFullUrl(storageName), nil
+	}
+
+	account, _ := GetOfficialAccount(tpl.Appid)
+	fans := &tables.WechatMember{}
+	helper.GetORM().Where("appid = ?", tpl.Appid).Where("openid = ?", openid).Get(fans)
+	if fans.Id == 0 {
+		if info, err := account.GetUser().GetUserInfo(openid); err == nil {
+			fans.Nickname, fans.Headimgurl = info.Nickname, info.Headimgurl
+		}
+	}
+
+	data := &poster.Data{Vars: map[string]string{
+		"nickname": fans.Nickname,
+		"openid":   openid,
+		"date":     helper.NowDate("2006-01-02"),
+	}}
+	if len(fans.Headimgurl) > 0 {
+		data.Avatar, _ = fetchImage(fans.Headimgurl)
+	}
+
+	sceneStr := strings.ReplaceAll(helper.Or(tpl.SceneStr, "poster_{openid}"), "{openid}", openid)
+	var req *basic.Request
+	if tpl.ExpireSeconds > 0 {
+		req = basic.NewTmpQrRequest(time.Duration(tpl.ExpireSeconds)*time.Second, sceneStr)
+	} else {
+		req = basic.NewLimitQrRequest(sceneStr)
+	}
+	ticket, err := account.GetBasic().GetQRTicket(req)
+	if err != nil {
+		return "", err
+	}
+	if data.Qrcode, err = fetchImage(basic.ShowQRCode(ticket)); err != nil {
+		return "", err
+	}
+
+	byts, err := poster.Render(tpl.Design, data, fetchImage)
+	if err != nil {
+		return "", err
+	}
+	url, err := uploader.Upload(storageName, bytes.NewReader(byts))
+	if err != nil {
+		return "", err
+	}
+	if fans.Id > 0 {
+		helper.GetORM().ID(fans.Id).Cols("poster").Update(&tables.WechatMember{Poster: url})
+	}
+	return url, nil
+}
+
+// sendPoster 异步生成海报并通过客服消息发送给粉丝, 避免被动回复超时
+func sendPoster(cfg config.Site, tpl *tables.WechatPoster, openid string) {
+	defer func() {
+		if err := recover(); err != nil {
+			pine.Logger().Error("发送海报失败", err)
+		}
+	}()
+	url, err := GeneratePoster(cfg, tpl, openid, false)
+	if err != nil {
+		pine.Logger().Error("生成海报失败", err)
+		return
+	}
+	account, _ := GetOfficialAccount(tpl.Appid)
+	byts, err := fetchImage(url)
+	if err != nil {
+		pine.Logger().Error("读取海报失败", err)
+		return
+	}
+	tmp := filepath.Join(os.TempDir(), fmt.Sprintf("poster_%d_%s.png", tpl.Id, openid))
+	if err = os.WriteFile(tmp, byts, os.ModePerm); err != nil {
+		pine.Logger().Error("写入海报临时文件失败", err)
+		return
+	}
+	defer os.Remove(tmp)
+
+	media, err := account.GetMaterial().MediaUpload(material.MediaTypeImage, tmp)
+	if err != nil {
+		pine.Logger().Error("上传海报失败", err)
+		return
+	}
+	if err = account.GetCustomerMessageManager().Send(message.NewCustomerImgMessage(openid, media.MediaID)); err != nil {
+		pine.Logger().Error("发送海报失败", err)
+	}
+}
+
+// matchPosterKeyword 匹配海报关键字
+func matchPosterKeyword(appid, keyword string) *tables.WechatPoster {
+	if len(keyword) == 0 {
+		return nil
+	}
+	tpl := &tables.WechatPoster{}
+	if exist, _ := helper.GetORM().Where("appid = ?", appid).Where("keyword = ?", keyword).Where("status = ?", true).Get(tpl); !exist {
+		return nil
+	}
+	return tpl
+}
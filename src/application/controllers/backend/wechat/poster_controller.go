@@ -0,0 +1,69 @@
+package wechat
+
+import (
+	"errors"
+
+	"github.com/xiusin/pinecms/src/application/controllers/backend"
+	"github.com/xiusin/pinecms/src/application/models/tables"
+	"github.com/xiusin/pinecms/src/common/helper"
+	"github.com/xiusin/pinecms/src/config"
+)
+
+// WechatPosterController 粉丝海报模板管理
+type WechatPosterController struct {
+	backend.BaseController
+}
+
+func (c *WechatPosterController) Construct() {
+	c.Table = &tables.WechatPoster{}
+	c.Entries = &[]tables.WechatPoster{}
+	c.SearchFields = []backend.SearchFieldDsl{
+		{Field: "appid"},
+		{Field: "name", Op: "LIKE", DataExp: "%$?%"},
+	}
+	c.BaseController.Construct()
+	c.OpBefore = c.before
+}
+
+func (c *WechatPosterController) before(act int, params any) error {
+	if c.IsOperate(act) {
+		data := params.(*tables.WechatPoster)
+		if len(data.Appid) == 0 {
+			return errors.New("请选择公众号")
+		}
+		if data.Design == nil {
+			return errors.New("请设计海报")
+		}
+		if len(data.Keyword) > 0 {
+			sess := c.Orm.Where("appid = ?", data.Appid).Where("keyword = ?", data.Keyword)
+			if data.Id > 0 {
+				sess.Where("id <> ?", data.Id)
+			}
+			if exist, _ := sess.Exist(&tables.WechatPoster{}); exist {
+				return errors.New("关键字已被其他海报使用")
+			}
+		}
+	}
+	return nil
+}
+
+// PostPreview 使用指定粉丝预览海报, 每次预览都会重新生成
+func (c *WechatPosterController) PostPreview() {
+	id, _ := c.Input().GetInt64("id")
+	openid, _ := c.Input().GetString("openid")
+	tpl := &tables.WechatPoster{}
+	if exist, _ := c.Orm.ID(id).Get(tpl); !exist {
+		helper.Ajax("海报不存在", 1, c.Ctx())
+		return
+	}
+	if len(openid) == 0 {
+		helper.Ajax("请选择预览粉丝", 1, c.Ctx())
+		return
+	}
+	url, err := GeneratePoster(config.CtxSiteConfig(c.Ctx()), tpl, openid, true)
+	if err != nil {
+		helper.Ajax(err, 1, c.Ctx())
+		return
+	}
+	helper.Ajax(url, 0, c.Ctx())
+}
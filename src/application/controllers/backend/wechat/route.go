@@ -1,11 +1,39 @@
 package wechat
 
 import (
+	"sync"
+
 	"github.com/xiusin/pine"
+	"github.com/xiusin/pinecms/src/application/controllers/backend/im"
+	"github.com/xiusin/pinecms/src/application/models/tables"
+	"github.com/xiusin/pinecms/src/common/helper"
+	"github.com/xiusin/pinecms/src/common/hooks"
 )
 
+var once sync.Once
+
+func InitInstall() {
+	once.Do(func() {
+		defer func() {
+			if err := recover(); err != nil {
+				pine.Logger().Warn("初始化安装失败", err)
+			}
+		}()
+		if err := helper.GetORM().Sync2(&tables.WechatAccount{}, &tables.WechatMember{}, &tables.WechatArticle{}, &tables.WechatPoster{}); err != nil {
+			pine.Logger().Warn(err.Error())
+		}
+	})
+}
+
 func InitRouter(app *pine.Application, router *pine.Router) {
+	InitInstall()
+	hooks.ContentAfterSave.On(hooks.PriorityNormal, func(ev *hooks.ContentEvent) error {
+		go autoSyncArticle(ev.ModelId, ev.Id)
+		return nil
+	})
+	im.RegisterChannel(imChannel, replyFromIm)
 	app.ANY("/api/wechat/msg/:appid", msgHandler)
+	app.POST("/api/wechat/mini/:appid/login", miniLoginHandler)
 	router.Handle(new(WechatAccountController), "/wechat/account")
 	router.Handle(new(WechatUserController), "/wechat/user")
 	router.Handle(new(WechatMagController), "/wechat/msg")
@@ -15,4 +43,8 @@ func InitRouter(app *pine.Application, router *pine.Router) {
 	router.Handle(new(WechatMsgTemplateController), "/wechat/template")
 	router.Handle(new(WechatUserTagsController), "/wechat/user/tags")
 	router.Handle(new(WechatMenuController), "/wechat/menu")
+	router.Handle(new(WechatMiniController), "/wechat/mini")
+	router.Handle(new(WechatWorkController), "/wechat/work")
+	router.Handle(new(WechatArticleController), "/wechat/article")
+	router.Handle(new(WechatPosterController), "/wechat/poster")
 }
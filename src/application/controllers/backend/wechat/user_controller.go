@@ -8,6 +8,7 @@ import (
 	"github.com/xiusin/pinecms/src/application/controllers/backend"
 	"github.com/xiusin/pinecms/src/application/models/tables"
 	"github.com/xiusin/pinecms/src/common/helper"
+	"github.com/xiusin/pinecms/src/config"
 	"golang.org/x/sync/errgroup"
 	"xorm.io/xorm"
 )
@@ -129,7 +130,26 @@ func (c *WechatUserController) PostSync() {
 	helper.Ajax("同步完成", 0, c.Ctx())
 }
 
-// PostSavePoster 保存海报 vue-canvas-poster
+// PostSavePoster 为粉丝生成海报
 func (c *WechatUserController) PostSavePoster() {
-
+	p := struct {
+		PosterId int64  `json:"poster_id"`
+		Openid   string `json:"openid"`
+		Force    bool   `json:"force"`
+	}{}
+	if err := c.Ctx().BindJSON(&p); err != nil {
+		helper.Ajax(err, 1, c.Ctx())
+		return
+	}
+	tpl := &tables.WechatPoster{}
+	if exist, _ := c.Orm.ID(p.PosterId).Get(tpl); !exist || len(p.Openid) == 0 {
+		helper.Ajax("参数错误", 1, c.Ctx())
+		return
+	}
+	url, err := GeneratePoster(config.CtxSiteConfig(c.Ctx()), tpl, p.Openid, p.Force)
+	if err != nil {
+		helper.Ajax(err, 1, c.Ctx())
+		return
+	}
+	helper.Ajax(url, 0, c.Ctx())
 }
@@ -0,0 +1,101 @@
+package wechat
+
+import (
+	"cmp"
+	"strings"
+
+	"github.com/silenceper/wechat/v2/work/message"
+	"github.com/xiusin/pinecms/src/application/controllers/backend"
+	"github.com/xiusin/pinecms/src/application/models/tables"
+	"github.com/xiusin/pinecms/src/common/helper"
+)
+
+// WechatWorkController 企业微信应用消息
+type WechatWorkController struct {
+	backend.BaseController
+}
+
+func (c *WechatWorkController) Construct() {
+	c.Table = &tables.WechatAccount{}
+	c.Entries = &[]tables.WechatAccount{}
+	c.ExceptCols = []string{"secret", "token", "aes_key"}
+	c.BaseController.Construct()
+	c.OpBefore = c.before
+}
+
+// before 只操作企业微信账号, 列表与详情不返回密钥, 修改时未填写的密钥保持不变
+func (c *WechatWorkController) before(act int, params any) error {
+	if err := c.FieldScope(act, params, "Platform", tables.WechatPlatformWork); err != nil {
+		return err
+	}
+	if act == backend.OpEdit {
+		p, old := params.(*tables.WechatAccount), &tables.WechatAccount{}
+		if _, err := c.Orm.ID(p.Id).Get(old); err != nil {
+			return err
+		}
+		p.Secret = cmp.Or(p.Secret, old.Secret)
+		p.Token = cmp.Or(p.Token, old.Token)
+		p.AesKey = cmp.Or(p.AesKey, old.AesKey)
+	}
+	return nil
+}
+
+// PostSend 发送应用消息, 支持 text, markdown, textcard
+func (c *WechatWorkController) PostSend() {
+	p := struct {
+		CorpId      string   `json:"corpid"`
+		MsgType     string   `json:"msgtype"`
+		ToUser      []string `json:"touser"`
+		ToParty     []string `json:"toparty"`
+		ToTag       []string `json:"totag"`
+		Content     string   `json:"content"`
+		Title       string   `json:"title"`
+		Url         string   `json:"url"`
+		BtnTxt      string   `json:"btntxt"`
+		Description string   `json:"description"`
+	}{}
+
+	if err := c.Ctx().BindJSON(&p); err != nil {
+		helper.Ajax(err, 1, c.Ctx())
+		return
+	}
+	if p.CorpId == "" || len(p.ToUser)+len(p.ToParty)+len(p.ToTag) == 0 {
+		helper.Ajax("参数错误", 1, c.Ctx())
+		return
+	}
+
+	wk, account := GetWork(p.CorpId)
+	common := &message.SendRequestCommon{
+		ToUser:  strings.Join(p.ToUser, "|"),
+		ToParty: strings.Join(p.ToParty, "|"),
+		ToTag:   strings.Join(p.ToTag, "|"),
+		MsgType: p.MsgType,
+		AgentID: account.AgentId,
+	}
+
+	var request any
+	switch p.MsgType {
+	case "text":
+		request = message.SendTextRequest{SendRequestCommon: common, Text: message.TextField{Content: p.Content}}
+	case "markdown":
+		request = struct {
+			*message.SendRequestCommon
+			Markdown message.TextField `json:"markdown"`
+		}{common, message.TextField{Content: p.Content}}
+	case "textcard":
+		request = struct {
+			*message.SendRequestCommon
+			TextCard map[string]string `json:"textcard"`
+		}{common, map[string]string{"title": p.Title, "description": p.Description, "url": p.Url, "btntxt": p.BtnTxt}}
+	default:
+		helper.Ajax("不支持的消息类型", 1, c.Ctx())
+		return
+	}
+
+	res, err := wk.GetMessage().Send("Send"+helper.UcFirst(p.MsgType), request)
+	if err != nil {
+		helper.Ajax(err, 1, c.Ctx())
+		return
+	}
+	helper.Ajax(res, 0, c.Ctx())
+}
@@ -1,6 +1,5 @@
 package controllers
 
-const CacheTheme = "theme"
 const CacheStatistics = "pinecms_persist.statistics"
 const CacheRefer = "statistics_refer"
 const CacheMemCollect = "pinecms.mem.collect"
@@ -21,3 +20,8 @@ const CacheTableNameFields = "pinecms.orm.tbl.%s.fields"
 const CacheAdminRolesList = "pinecms.admin.roles.%d"
 const CacheTableNames = "pinecms.orm.table.name"
 
+const CacheSites = "pinecms.sites"
+const CacheSite = "pinecms.site"
+const CacheDataSource = "pinecms.datasource.%s.%s"
+const CacheApiKey = "pinecms.headless.key.%s"
+const CacheTemplateDraft = "pinecms.template.draft.%s"
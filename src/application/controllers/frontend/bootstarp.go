@@ -25,15 +25,17 @@ func (c *IndexController) Bootstrap() {
 	}()
 	// todo 开启前端资源缓存 304
 	// todo 拦截存在静态文件的问题, 不过最好交给nginx等服务器转发
+	c.useTheme()
 	pageName := c.Ctx().Params().Get("pagename") // 必须包含.html, 在nginx要注意如果以/结尾的path需要追加index.html
-	if config.GetSiteConfigByKey("SITE_DEBUG", "关闭") == "关闭" {
+	setting := config.CtxSiteConfig(c.Ctx())
+	if setting.Get("SITE_DEBUG", "关闭") == "关闭" {
 		if pageName == "" {
 			pageName = "/"
 		}
 		if strings.HasSuffix(pageName, "/") {
 			pageName += IndexTpl
 		}
-		absFilePath := filepath.Join(config.GetSiteConfigByKey("SITE_STATIC_PAGE_DIR"), pageName)
+		absFilePath := GetStaticFile(c.Ctx(), pageName)
 		if byts, err := os.ReadFile(absFilePath); err == nil { // 如果已经存在缓存页面则直接并发执行
 			c.Ctx().Render().ContentType(pine.ContentTypeHTML)
 			pine.Logger().Info("render for file", absFilePath)
@@ -69,7 +71,7 @@ func (c *IndexController) Bootstrap() {
 			pageName = filepath.Join(pageName, fileName)
 			c.Ctx().Params().Set("page", "1")
 		}
-		cat := models.NewCategoryModel().GetWithDirForBE(last)
+		cat := models.NewCategoryModel().GetWithDirForBE(last, config.CtxSiteId(c.Ctx()))
 		if cat == nil {
 			// 拆分出来想要的数据 page_{tid}
 			if strings.HasPrefix(last, "page_") {
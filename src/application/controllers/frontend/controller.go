@@ -11,10 +11,13 @@ import (
 	jet2 "github.com/CloudyKit/jet"
 	"github.com/xiusin/pine"
 	"github.com/xiusin/pine/di"
+	"github.com/xiusin/pine/render/engine/pjet"
 	"github.com/xiusin/pinecms/src/application/controllers"
 	"github.com/xiusin/pinecms/src/application/models"
 	"github.com/xiusin/pinecms/src/application/models/tables"
 	"github.com/xiusin/pinecms/src/common/helper"
+	"github.com/xiusin/pinecms/src/common/hooks"
+	"github.com/xiusin/pinecms/src/common/theme"
 	"github.com/xiusin/pinecms/src/config"
 	"xorm.io/xorm"
 )
@@ -23,13 +26,22 @@ type IndexController struct {
 	pine.Controller
 }
 
+// ctxTemplateDraft 模板预览草稿在请求上下文中的key
+const ctxTemplateDraft = "pinecms.template.draft"
+
 func (c *IndexController) RegisterRoute(b pine.IRouterWrapper) {
 	// 必须放到最后 否则搜索路由时会优先被此路由拦截到
 	b.GET("/search.go", "Search")
+	b.GET("/template-preview.go", "TemplatePreview")
 	b.GET("/*pagename", "Bootstrap")
 }
 
-func viewDataToJetMap(binding map[string]any) jet2.VarMap {
+// viewDataToJetMap 触发页面渲染事件后转换模板变量
+func viewDataToJetMap(tpl string, binding map[string]any) jet2.VarMap {
+	if binding == nil {
+		binding = map[string]any{}
+	}
+	hooks.PageRender.Fire(&hooks.RenderEvent{Template: tpl, Data: binding})
 	vars := jet2.VarMap{}
 	for k, v := range binding {
 		vars[k] = reflect.ValueOf(v)
@@ -74,20 +86,51 @@ func getOrmSess(model *tables.DocumentModel) *xorm.Session {
 	return helper.GetORM().Table(controllers.GetTableName(model.Table)).Where("status = 1").Where("deleted_time IS NULL")
 }
 
-func template(tpl string) string {
+// useTheme 处理主题预览并向模板注入主题配置.
+// 预览时切换当前请求的主题, 且不读取也不覆盖站点的静态页面
+func (c *IndexController) useTheme() {
+	conf := di.MustGet(controllers.ServiceConfig).(*config.Config)
+	setting := config.CtxSiteConfig(c.Ctx())
+	name := setting.Get("SITE_THEME", conf.View.Theme)
+	preview := theme.Preview(c.Ctx())
+	if len(preview) > 0 && setting != nil {
+		name = preview
+		setting["SITE_THEME"] = preview
+		setting["SITE_DEBUG"] = "开启"
+		setting["SITE_STATIC_PAGE_DIR"] = config.RuntimePath(filepath.Join("preview", string(c.Ctx().Host())))
+	}
+	c.ViewData("theme", theme.Settings(name))
+	c.ViewData("theme_preview", len(preview) > 0)
+}
+
+// template 模板路径, 优先使用当前站点的主题
+func template(ctx *pine.Context, tpl string) string {
 	//todo 支持mobile pc
 	conf := di.MustGet(controllers.ServiceConfig).(*config.Config)
-	path := filepath.Join(conf.View.Theme, tpl)
+	path := filepath.Join(config.CtxSiteConfig(ctx).Get("SITE_THEME", conf.View.Theme), tpl)
 	if runtime.GOOS == "windows" {
 		path = strings.ReplaceAll(path, "\\", "/")
 	}
 	return path
 }
 
-func GetStaticFile(filename string) string {
-	setting, _ := config.SiteConfig()
-	if setting["SITE_STATIC_PAGE_DIR"] == "" {
-		setting["SITE_STATIC_PAGE_DIR"] = "resources/html"
+// loadTemplate 加载模板, 模板预览时使用草稿中的模板替换
+func loadTemplate(ctx *pine.Context, tpl string) (*jet2.Template, error) {
+	engine := pine.Make(controllers.ServiceJetEngine).(*pjet.PineJet)
+	if draft, ok := ctx.Value(ctxTemplateDraft).(*theme.TemplateDraft); ok {
+		name, _, err := theme.TemplatePath(draft.Theme, draft.Template)
+		if err != nil {
+			return nil, err
+		}
+		if len(draft.Content) > 0 {
+			return engine.Parse(name, draft.Content)
+		}
+		return engine.GetTemplate(name)
 	}
-	return filepath.Join(setting["SITE_STATIC_PAGE_DIR"], filename)
+	return engine.GetTemplate(template(ctx, tpl))
+}
+
+// GetStaticFile 当前站点的静态页面文件路径
+func GetStaticFile(ctx *pine.Context, filename string) string {
+	return filepath.Join(config.CtxSiteConfig(ctx).Get("SITE_STATIC_PAGE_DIR", "resources/html"), filename)
 }
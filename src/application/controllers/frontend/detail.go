@@ -10,14 +10,13 @@ import (
 	"strings"
 
 	"github.com/xiusin/pine"
-	"github.com/xiusin/pine/render/engine/pjet"
 	"github.com/xiusin/pinecms/src/application/controllers"
 	"github.com/xiusin/pinecms/src/application/models"
 )
 
 func (c *IndexController) Detail(pathname string) {
 	c.setTemplateData()
-	pageFilePath := GetStaticFile(pathname)
+	pageFilePath := GetStaticFile(c.Ctx(), pathname)
 	aid, _ := c.Ctx().Params().GetInt64("aid")
 	tid, _ := c.Ctx().Params().GetInt64("tid")
 	var err error
@@ -72,15 +71,14 @@ func (c *IndexController) Detail(pathname string) {
 		return
 	}
 	defer f.Close()
-	pineJet := pine.Make(controllers.ServiceJetEngine).(*pjet.PineJet)
-	temp, err := pineJet.GetTemplate(template(tpl))
+	temp, err := loadTemplate(c.Ctx(), tpl)
 	if err != nil {
 		pine.Logger().Error(err.Error())
 		c.Ctx().Abort(http.StatusNotFound)
 		return
 	}
 
-	err = temp.Execute(f, viewDataToJetMap(c.Render().GetViewData()), struct {
+	err = temp.Execute(f, viewDataToJetMap(tpl, c.Render().GetViewData()), struct {
 		Field    map[string]string
 		TypeID   int64
 		ArtID    int64
@@ -25,5 +25,5 @@ func (c *FescController) DownList() {
 	sess := getOrmSess(cat.Model).Where("id = ?", aid).Limit(1)
 	data, _ := sess.QueryString()
 	c.ViewData("data", data[0])
-	c.View(template("down_list.jet"))
+	c.View(template(c.Ctx(), "down_list.jet"))
 }
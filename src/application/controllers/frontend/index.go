@@ -4,15 +4,12 @@ import (
 	"os"
 	"path/filepath"
 
-	"github.com/xiusin/pine"
-	"github.com/xiusin/pine/render/engine/pjet"
-	"github.com/xiusin/pinecms/src/application/controllers"
 )
 
 func (c *IndexController) Index() {
 	c.setTemplateData()
 	indexPage := "editor.tpl"
-	pageFilePath := GetStaticFile(indexPage)
+	pageFilePath := GetStaticFile(c.Ctx(), indexPage)
 	_ = os.MkdirAll(filepath.Dir(pageFilePath), os.ModePerm)
 	f, err := os.OpenFile(pageFilePath, os.O_CREATE|os.O_TRUNC|os.O_RDWR, os.ModePerm)
 	if err != nil {
@@ -20,13 +17,12 @@ func (c *IndexController) Index() {
 		return
 	}
 	defer f.Close()
-	jet := pine.Make(controllers.ServiceJetEngine).(*pjet.PineJet)
-	temp, err := jet.GetTemplate(template("index.jet"))
+	temp, err := loadTemplate(c.Ctx(), "index.jet")
 	if err != nil {
 		c.Logger().Error(err.Error())
 		return
 	}
-	err = temp.Execute(f, viewDataToJetMap(c.Render().GetViewData()), nil)
+	err = temp.Execute(f, viewDataToJetMap("index.jet", c.Render().GetViewData()), nil)
 	if err != nil {
 		c.Logger().Error(err.Error())
 		return
@@ -6,15 +6,13 @@ import (
 	"path/filepath"
 
 	"github.com/xiusin/pine"
-	"github.com/xiusin/pine/render/engine/pjet"
-	"github.com/xiusin/pinecms/src/application/controllers"
 	"github.com/xiusin/pinecms/src/application/models"
 	"github.com/xiusin/pinecms/src/application/models/tables"
 )
 
 func (c *IndexController) List(pageFilePath string) {
 	c.setTemplateData()
-	pageFilePath = GetStaticFile(pageFilePath)
+	pageFilePath = GetStaticFile(c.Ctx(), pageFilePath)
 	queryTid, _ := c.Ctx().Input().GetInt64("tid")
 	tid, _ := c.Ctx().Params().GetInt64("tid", queryTid)
 	if tid < 1 {
@@ -50,14 +48,13 @@ func (c *IndexController) List(pageFilePath string) {
 		return
 	}
 	defer f.Close()
-	jet := pine.Make(controllers.ServiceJetEngine).(*pjet.PineJet)
-	temp, err := jet.GetTemplate(template(tpl))
+	temp, err := loadTemplate(c.Ctx(), tpl)
 	if err != nil {
 		c.Ctx().Abort(http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	err = temp.Execute(f, viewDataToJetMap(c.Render().GetViewData()), struct {
+	err = temp.Execute(f, viewDataToJetMap(tpl, c.Render().GetViewData()), struct {
 		Field     *tables.Category
 		TypeID    int64
 		ArtCount  int64
@@ -6,15 +6,13 @@ import (
 	"path/filepath"
 
 	"github.com/xiusin/pine"
-	"github.com/xiusin/pine/render/engine/pjet"
-	"github.com/xiusin/pinecms/src/application/controllers"
 	"github.com/xiusin/pinecms/src/application/models"
 	"github.com/xiusin/pinecms/src/application/models/tables"
 )
 
 func (c *IndexController) Page(pathname string) {
 	c.setTemplateData()
-	pageFilePath := GetStaticFile(pathname)
+	pageFilePath := GetStaticFile(c.Ctx(), pathname)
 	tid, _ := c.Ctx().Params().GetInt64("tid")
 	if tid < 1 {
 		c.Ctx().Abort(404, "tid failed")
@@ -44,17 +42,16 @@ func (c *IndexController) Page(pathname string) {
 		return
 	}
 	defer f.Close()
-	pineJet := pine.Make(controllers.ServiceJetEngine).(*pjet.PineJet)
 	tpl := "page.jet"
 	if len(category.DetailTpl) > 0 {
 		tpl = category.DetailTpl
 	}
-	temp, err := pineJet.GetTemplate(template(tpl))
+	temp, err := loadTemplate(c.Ctx(), tpl)
 	if err != nil {
 		_ = c.Ctx().WriteString(err.Error())
 		return
 	}
-	err = temp.Execute(f, viewDataToJetMap(c.Render().GetViewData()), struct {
+	err = temp.Execute(f, viewDataToJetMap(tpl, c.Render().GetViewData()), struct {
 		Field       *tables.Page // 单页信息
 		Position    string
 		TypeID      int64
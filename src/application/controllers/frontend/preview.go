@@ -0,0 +1,53 @@
+package frontend
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/xiusin/pinecms/src/application/models"
+	"github.com/xiusin/pinecms/src/common/helper"
+	"github.com/xiusin/pinecms/src/common/theme"
+	"github.com/xiusin/pinecms/src/config"
+)
+
+// TemplatePreview 模板编辑器预览, 使用草稿渲染指定栏目或文档.
+// 渲染结果写入运行时临时目录并在响应后删除, 不影响站点静态页面
+func (c *IndexController) TemplatePreview() {
+	draft, err := theme.ParseDraft(string(c.Ctx().QueryArgs().Peek(theme.DraftQuery)))
+	if err != nil {
+		c.Ctx().Abort(http.StatusForbidden, err.Error())
+		return
+	}
+	dir := config.RuntimePath(filepath.Join("preview", "template-"+helper.GetRandomString(8)))
+	defer os.RemoveAll(dir)
+
+	setting := config.CtxSiteConfig(c.Ctx())
+	setting["SITE_THEME"] = draft.Theme
+	setting["SITE_STATIC_PAGE_DIR"] = dir
+	c.Ctx().Set(ctxTemplateDraft, draft)
+	c.ViewData("theme", theme.Settings(draft.Theme))
+	c.ViewData("theme_preview", true)
+
+	if draft.Tid <= 0 {
+		c.Index()
+		return
+	}
+	category := models.NewCategoryModel().GetCategory(draft.Tid)
+	if category == nil {
+		c.Ctx().Abort(http.StatusNotFound, "栏目不存在")
+		return
+	}
+	c.Ctx().Params().Set("tid", strconv.FormatInt(draft.Tid, 10))
+	switch {
+	case draft.Aid > 0:
+		c.Ctx().Params().Set("aid", strconv.FormatInt(draft.Aid, 10))
+		c.Detail("preview.html")
+	case category.Type == 1:
+		c.Page("preview.html")
+	default:
+		c.Ctx().Params().Set("page", "1")
+		c.List("preview.html")
+	}
+}
@@ -18,6 +18,7 @@ import (
 )
 
 func (c *IndexController) Search(orm *xorm.Engine) {
+	c.useTheme()
 	q, _ := c.Ctx().Input().GetString("q")
 	keywords, _ := c.Ctx().Input().GetString("keywords", q)
 	keywords, _ = c.Ctx().Input().GetString("keyword", keywords)
@@ -170,10 +171,10 @@ func (c *IndexController) Search(orm *xorm.Engine) {
 					queries[k] = v
 				}
 				query = strings.Join(queries, " UNION ")
-				query = fmt.Sprintf("SELECT * FROM ("+query+") AS t ORDER BY t.%s LIMIT  %d, %d", orderby, offset, pagesize)
+				query = fmt.Sprintf("SELECT * FROM ("+query+") AS t ORDER BY t.%s LIMIT %d OFFSET %d", orderby, pagesize, offset)
 			} else {
 				query = strings.ReplaceAll(query, "$?", selectField)
-				query += fmt.Sprintf(" ORDER BY $$.%s DESC LIMIT %d, %d", orderby, offset, pagesize)
+				query += fmt.Sprintf(" ORDER BY $$.%s DESC LIMIT %d OFFSET %d", orderby, pagesize, offset)
 				query = strings.ReplaceAll(query, "$$", controllers.GetTableName(tableName))
 			}
 			query = strings.ReplaceAll(query, "$1", controllers.GetTableName("category"))
@@ -193,14 +194,14 @@ func (c *IndexController) Search(orm *xorm.Engine) {
 		return list
 	}
 	pineJet := pine.Make(controllers.ServiceJetEngine).(*pjet.PineJet)
-	tpl, err := pineJet.GetTemplate(template("search.jet"))
+	tpl, err := pineJet.GetTemplate(template(c.Ctx(), "search.jet"))
 	if err != nil {
 		pine.Logger().Error("读取搜索页面失败", err)
 		c.Ctx().Abort(500, err.Error())
 		return
 	}
 	c.Ctx().Render().ContentType(pine.ContentTypeHTML)
-	if err := tpl.Execute(c.Ctx().Response.BodyWriter(), viewDataToJetMap(c.Ctx().Render().GetViewData()), struct {
+	if err := tpl.Execute(c.Ctx().Response.BodyWriter(), viewDataToJetMap("search.jet", c.Ctx().Render().GetViewData()), struct {
 		Field       *tables.Category
 		Position    string
 		ArtCount    int64
@@ -0,0 +1,109 @@
+package headless
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/xiusin/pine"
+	"github.com/xiusin/pinecms/src/application/controllers"
+	"github.com/xiusin/pinecms/src/application/models/tables"
+	"github.com/xiusin/pinecms/src/common/helper"
+	"golang.org/x/time/rate"
+)
+
+const defaultRateLimit = 60
+
+type keyLimiter struct {
+	limit   int
+	limiter *rate.Limiter
+	touched atomic.Int64 // 最后写入调用时间的时间戳
+}
+
+var limiters sync.Map
+
+// getApiKey 读取启用的调用凭证
+func getApiKey(key string) *tables.ApiKey {
+	var apiKey tables.ApiKey
+	err := helper.Cache().Remember(fmt.Sprintf(controllers.CacheApiKey, key), &apiKey, func() (any, error) {
+		if exist, err := helper.GetORM().Where("api_key = ?", key).Where("status = ?", true).Get(&apiKey); !exist || err != nil {
+			return nil, fmt.Errorf("调用凭证%s不存在: %v", key, err)
+		}
+		return &apiKey, nil
+	}, 60)
+	if err != nil {
+		return nil
+	}
+	return &apiKey
+}
+
+// allow 按凭证限流, 修改每分钟请求数后重建限流器
+func allow(apiKey *tables.ApiKey) bool {
+	kl := getLimiter(apiKey)
+	if !kl.limiter.Allow() {
+		return false
+	}
+	if now := time.Now(); now.Unix()-kl.touched.Swap(now.Unix()) >= 60 { // 每分钟最多记录一次调用时间
+		go func() {
+			t := tables.LocalTime(now)
+			helper.GetORM().ID(apiKey.Id).Cols("last_used_at").Update(&tables.ApiKey{LastUsedAt: &t})
+		}()
+	}
+	return true
+}
+
+func getLimiter(apiKey *tables.ApiKey) *keyLimiter {
+	limit := apiKey.RateLimit
+	if limit <= 0 {
+		limit = defaultRateLimit
+	}
+	val, ok := limiters.Load(apiKey.Key)
+	if !ok || val.(*keyLimiter).limit != limit {
+		val = &keyLimiter{limit: limit, limiter: rate.NewLimiter(rate.Every(time.Minute/time.Duration(limit)), limit)}
+		limiters.Store(apiKey.Key, val)
+	}
+	return val.(*keyLimiter)
+}
+
+// cors 内容接口允许任意来源调用, 凭证通过请求头传递不依赖cookie
+func cors() pine.Handler {
+	return func(ctx *pine.Context) {
+		ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")
+		ctx.Response.Header.Set("Access-Control-Allow-Headers", "X-Api-Key, If-None-Match, Content-Type")
+		ctx.Response.Header.Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+		ctx.Response.Header.Set("Access-Control-Expose-Headers", "ETag, X-RateLimit-Limit")
+		if ctx.IsOptions() {
+			ctx.Stop()
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// apiKeyAuth 校验请求头X-Api-Key或参数api_key
+func apiKeyAuth() pine.Handler {
+	return func(ctx *pine.Context) {
+		key := ctx.Header("X-Api-Key")
+		if len(key) == 0 {
+			key = string(ctx.QueryArgs().Peek("api_key"))
+		}
+		if len(key) == 0 {
+			fail(ctx, http.StatusUnauthorized, "缺少调用凭证")
+			return
+		}
+		apiKey := getApiKey(key)
+		if apiKey == nil {
+			fail(ctx, http.StatusUnauthorized, "调用凭证无效")
+			return
+		}
+		ctx.Response.Header.Set("X-RateLimit-Limit", strconv.Itoa(getLimiter(apiKey).limit))
+		if !allow(apiKey) {
+			fail(ctx, http.StatusTooManyRequests, "请求过于频繁")
+			return
+		}
+		ctx.Next()
+	}
+}
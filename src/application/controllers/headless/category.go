@@ -0,0 +1,119 @@
+package headless
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/xiusin/pine"
+	"github.com/xiusin/pinecms/src/application/models"
+	"github.com/xiusin/pinecms/src/application/models/tables"
+	"github.com/xiusin/pinecms/src/config"
+)
+
+// siteCategories 当前站点的导航栏目
+func siteCategories(ctx *pine.Context) []tables.Category {
+	siteId := config.CtxSiteId(ctx)
+	var list []tables.Category
+	for _, category := range models.NewCategoryModel().GetAll(true) {
+		if category.SiteId == siteId && category.Ismenu {
+			list = append(list, category)
+		}
+	}
+	return list
+}
+
+func siteCategory(ctx *pine.Context, id int64) *tables.Category {
+	for _, category := range siteCategories(ctx) {
+		if category.Catid == id {
+			return &category
+		}
+	}
+	return nil
+}
+
+func categoryItem(category tables.Category, modelTables map[int64]string) pine.H {
+	url := category.Url
+	if category.Type != 2 {
+		url = fmt.Sprintf("/%s/", models.NewCategoryModel().GetUrlPrefix(category.Catid))
+	}
+	return pine.H{
+		"id":          category.Catid,
+		"parent_id":   category.Parentid,
+		"name":        category.Catname,
+		"type":        category.Type,
+		"model":       modelTables[category.ModelId],
+		"dir":         category.Dir,
+		"url":         url,
+		"thumb":       category.Thumb,
+		"keywords":    category.Keywords,
+		"description": category.Description,
+		"listorder":   category.Listorder,
+	}
+}
+
+func modelTables() map[int64]string {
+	var m = map[int64]string{}
+	for _, model := range models.NewDocumentModel().GetAllForBE() {
+		m[model.Id] = model.Table
+	}
+	return m
+}
+
+func categoryTree(categories []tables.Category, parentId int64, modelTables map[int64]string) []pine.H {
+	var tree = []pine.H{}
+	for _, category := range categories {
+		if category.Parentid == parentId {
+			item := categoryItem(category, modelTables)
+			item["children"] = categoryTree(categories, category.Catid, modelTables)
+			tree = append(tree, item)
+		}
+	}
+	return tree
+}
+
+// categoriesHandler 栏目树, parent指定根栏目, flat=1时返回平铺列表
+func categoriesHandler(ctx *pine.Context) {
+	categories := siteCategories(ctx)
+	mt := modelTables()
+	if string(ctx.QueryArgs().Peek("flat")) == "1" {
+		var list = []pine.H{}
+		for _, category := range categories {
+			list = append(list, categoryItem(category, mt))
+		}
+		ok(ctx, list)
+		return
+	}
+	ok(ctx, categoryTree(categories, int64(queryInt(ctx, "parent", 0)), mt))
+}
+
+// categoryHandler 栏目详情, 单页栏目附带页面内容
+func categoryHandler(ctx *pine.Context) {
+	id, _ := strconv.ParseInt(ctx.Params().Get("id"), 10, 64)
+	category := siteCategory(ctx, id)
+	if category == nil {
+		fail(ctx, http.StatusNotFound, "栏目不存在")
+		return
+	}
+	item := categoryItem(*category, modelTables())
+	if category.Type == 1 {
+		item["page"] = models.NewPageModel().GetPage(category.Catid)
+	}
+	ok(ctx, item)
+}
+
+// pageHandler 单页内容
+func pageHandler(ctx *pine.Context) {
+	id, _ := strconv.ParseInt(ctx.Params().Get("id"), 10, 64)
+	category := siteCategory(ctx, id)
+	if category == nil || category.Type != 1 {
+		fail(ctx, http.StatusNotFound, "页面不存在")
+		return
+	}
+	page := models.NewPageModel().GetPage(category.Catid)
+	if page == nil {
+		fail(ctx, http.StatusNotFound, "页面不存在")
+		return
+	}
+	ok(ctx, page)
+}
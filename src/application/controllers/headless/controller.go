@@ -0,0 +1,82 @@
+package headless
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/xiusin/pinecms/src/application/controllers"
+	"github.com/xiusin/pinecms/src/application/controllers/backend"
+	"github.com/xiusin/pinecms/src/application/models/tables"
+	"github.com/xiusin/pinecms/src/common/helper"
+	"xorm.io/xorm"
+)
+
+// ApiKeyController 内容接口调用凭证管理
+type ApiKeyController struct {
+	backend.BaseController
+}
+
+func (c *ApiKeyController) Construct() {
+	c.Table = &tables.ApiKey{}
+	c.Entries = &[]tables.ApiKey{}
+	c.KeywordsSearch = []backend.SearchFieldDsl{
+		{Field: "name", Op: "LIKE", DataExp: "%$?%"},
+	}
+	c.ApiEntityName = "调用凭证"
+	c.Group = "内容接口"
+	c.BaseController.Construct()
+	c.OpBefore = c.before
+	c.OpAfter = c.after
+}
+
+func (c *ApiKeyController) before(act int, params any) error {
+	switch act {
+	case backend.OpList:
+		params.(*xorm.Session).Desc("id")
+	case backend.OpAdd, backend.OpEdit:
+		key := params.(*tables.ApiKey)
+		if len(key.Key) == 0 {
+			var err error
+			if key.Key, err = newApiKey(); err != nil {
+				return err
+			}
+		}
+		if exist, _ := c.Orm.Where("api_key = ?", key.Key).Where("id <> ?", key.Id).Exist(&tables.ApiKey{}); exist {
+			return errors.New("调用凭证已存在")
+		}
+		if key.RateLimit <= 0 {
+			key.RateLimit = defaultRateLimit
+		}
+		c.clearCache()
+	case backend.OpDel:
+		c.clearCache()
+	}
+	return nil
+}
+
+func (c *ApiKeyController) after(act int, _ any) error {
+	if act != backend.OpList && act != backend.OpInfo {
+		c.clearCache()
+	}
+	return nil
+}
+
+// clearCache 清除凭证缓存, 禁用或删除后立即生效
+func (c *ApiKeyController) clearCache() {
+	var keys []string
+	c.Orm.Table(&tables.ApiKey{}).Cols("api_key").Find(&keys)
+	for _, key := range keys {
+		helper.Cache().Delete(fmt.Sprintf(controllers.CacheApiKey, key))
+	}
+}
+
+// newApiKey 生成32位随机调用凭证
+func newApiKey() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,210 @@
+package headless
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/xiusin/pine"
+	"github.com/xiusin/pinecms/src/application/controllers"
+	"github.com/xiusin/pinecms/src/application/models"
+	"github.com/xiusin/pinecms/src/application/models/tables"
+	"github.com/xiusin/pinecms/src/common/helper"
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// 模型表固定字段
+var (
+	fixedFields    = []string{"id", "catid", "mid", "status", "listorder", "visit_count", "pubtime", "created_time", "updated_time"}
+	fixedSortables = []string{"id", "listorder", "visit_count", "pubtime", "created_time", "updated_time"}
+)
+
+// docSchema 模型对外开放的字段, 由模型字段定义(DSL)与数据表实际字段求交集
+type docSchema struct {
+	model    *tables.DocumentModel
+	fields   []string
+	sortable []string
+	dsl      map[string]tables.DocumentModelDsl
+}
+
+func getDocSchema(name string) *docSchema {
+	dm := models.NewDocumentModel().GetWithTableNameForBE(name)
+	if dm == nil {
+		if id, err := strconv.ParseInt(name, 10, 64); err == nil {
+			dm = models.NewDocumentModel().GetByIDForBE(id)
+		}
+	}
+	if dm == nil || dm.Enabled != 1 {
+		return nil
+	}
+	columns := models.NewDataSourceModel().TableColumns(dm.Table)
+	schema := &docSchema{model: dm, dsl: map[string]tables.DocumentModelDsl{}}
+	for _, field := range fixedFields {
+		if slices.Contains(columns, field) {
+			schema.fields = append(schema.fields, field)
+		}
+	}
+	for _, field := range fixedSortables {
+		if slices.Contains(columns, field) {
+			schema.sortable = append(schema.sortable, field)
+		}
+	}
+	for _, field := range models.NewDocumentFieldDslModel().GetList(dm.Id) {
+		if !field.Status || !slices.Contains(columns, field.TableField) || slices.Contains(schema.fields, field.TableField) {
+			continue
+		}
+		schema.fields = append(schema.fields, field.TableField)
+		schema.dsl[field.TableField] = field
+		if field.Sortable {
+			schema.sortable = append(schema.sortable, field.TableField)
+		}
+	}
+	return schema
+}
+
+// cond 已发布且属于当前站点栏目的文档
+func (s *docSchema) cond(ctx *pine.Context) builder.Cond {
+	var catids []int64
+	for _, category := range siteCategories(ctx) {
+		if category.ModelId == s.model.Id {
+			catids = append(catids, category.Catid)
+		}
+	}
+	return builder.IsNull{"deleted_time"}.And(builder.Eq{"status": 1}, builder.In("catid", catids))
+}
+
+func (s *docSchema) session(cond builder.Cond) *xorm.Session {
+	return helper.GetORM().Table(controllers.GetTableName(s.model.Table)).Where(cond)
+}
+
+// filter 按字段搜索类型构建条件: 1=精确 2=模糊 3=多值(逗号分隔) 4=范围(逗号分隔)
+func (s *docSchema) filter(cond builder.Cond, filters map[string]string) (builder.Cond, error) {
+	for field, value := range filters {
+		if field == "id" || field == "catid" {
+			cond = cond.And(builder.In(field, helper.ConvertToAnySlice(strings.Split(value, ","))...))
+			continue
+		}
+		dsl, ok := s.dsl[field]
+		if !ok || !dsl.Searchable {
+			return nil, fmt.Errorf("字段%s不支持筛选", field)
+		}
+		switch dsl.SearchType {
+		case 2:
+			cond = cond.And(builder.Like{field, value})
+		case 3:
+			cond = cond.And(builder.In(field, helper.ConvertToAnySlice(strings.Split(value, ","))...))
+		case 4:
+			if ranges := strings.SplitN(value, ",", 2); len(ranges) == 2 {
+				if len(ranges[0]) > 0 {
+					cond = cond.And(builder.Gte{field: ranges[0]})
+				}
+				if len(ranges[1]) > 0 {
+					cond = cond.And(builder.Lte{field: ranges[1]})
+				}
+			}
+		default:
+			cond = cond.And(builder.Eq{field: value})
+		}
+	}
+	return cond, nil
+}
+
+func withUrl(list []map[string]string) {
+	m := models.NewCategoryModel()
+	for _, item := range list {
+		catid, _ := strconv.ParseInt(item["catid"], 10, 64)
+		if len(item["id"]) > 0 && catid > 0 {
+			item["url"] = fmt.Sprintf("/%s/%s.html", m.GetUrlPrefix(catid), item["id"])
+		}
+	}
+}
+
+// modelsHandler 模型及字段定义
+func modelsHandler(ctx *pine.Context) {
+	fieldTypes := models.NewDocumentModelFieldModel().GetMap()
+	var list = []pine.H{}
+	for _, model := range models.NewDocumentModel().GetAllForBE() {
+		schema := getDocSchema(model.Table)
+		if schema == nil {
+			continue
+		}
+		var fields = []pine.H{}
+		for _, field := range schema.fields {
+			item := pine.H{"field": field, "sortable": slices.Contains(schema.sortable, field)}
+			if dsl, ok := schema.dsl[field]; ok {
+				item["name"] = dsl.FormName
+				item["required"] = dsl.Required
+				item["searchable"] = dsl.Searchable
+				item["search_type"] = dsl.SearchType
+				if fieldType, ok := fieldTypes[dsl.FieldType]; ok {
+					item["type"] = fieldType.Name
+				}
+			}
+			fields = append(fields, item)
+		}
+		list = append(list, pine.H{"id": model.Id, "name": model.Name, "table": model.Table, "fields": fields})
+	}
+	ok(ctx, list)
+}
+
+// documentsHandler 模型文档列表
+func documentsHandler(ctx *pine.Context) {
+	schema := getDocSchema(ctx.Params().Get("model"))
+	if schema == nil {
+		fail(ctx, http.StatusNotFound, "模型不存在")
+		return
+	}
+	q, err := parseList(ctx, schema.sortable, schema.fields, "-listorder,-id")
+	if err != nil {
+		fail(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	cond, err := schema.filter(schema.cond(ctx), q.Filters)
+	if err != nil {
+		fail(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(q.Keyword) > 0 {
+		cond = cond.And(builder.Like{"title", q.Keyword})
+	}
+	total, err := schema.session(cond).Count()
+	if err != nil {
+		fail(ctx, http.StatusInternalServerError, err.Error())
+		return
+	}
+	fields := q.Fields
+	if len(fields) == 0 {
+		fields = schema.fields
+	}
+	sess := schema.session(cond)
+	defer sess.Close()
+	q.apply(sess)
+	list, err := sess.Cols(fields...).QueryString()
+	if err != nil {
+		fail(ctx, http.StatusInternalServerError, err.Error())
+		return
+	}
+	withUrl(list)
+	ok(ctx, q.result(list, total))
+}
+
+// documentHandler 文档详情
+func documentHandler(ctx *pine.Context) {
+	schema := getDocSchema(ctx.Params().Get("model"))
+	if schema == nil {
+		fail(ctx, http.StatusNotFound, "模型不存在")
+		return
+	}
+	sess := schema.session(schema.cond(ctx).And(builder.Eq{"id": ctx.Params().Get("id")}))
+	defer sess.Close()
+	list, err := sess.Cols(schema.fields...).Limit(1).QueryString()
+	if err != nil || len(list) == 0 {
+		fail(ctx, http.StatusNotFound, "文档不存在")
+		return
+	}
+	withUrl(list)
+	ok(ctx, list[0])
+}
@@ -0,0 +1,83 @@
+package headless
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/xiusin/pine"
+	"github.com/xiusin/pinecms/src/application/models/tables"
+	"github.com/xiusin/pinecms/src/common/helper"
+	"github.com/xiusin/pinecms/src/config"
+	"xorm.io/builder"
+)
+
+// tagsHandler 标签列表
+func tagsHandler(ctx *pine.Context) {
+	q, err := parseList(ctx, []string{"id", "ref_num", "clicks", "listorder"},
+		[]string{"id", "name", "ref_num", "clicks", "seo_title", "seo_keywords", "seo_description", "listorder"}, "listorder,-id")
+	if err != nil {
+		fail(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	sess := helper.GetORM().Where("status = 1").Where("site_id = ?", config.CtxSiteId(ctx))
+	defer sess.Close()
+	if len(q.Keyword) > 0 {
+		sess.Where(builder.Like{"name", q.Keyword})
+	}
+	q.apply(sess)
+	var list []tables.Tags
+	total, err := sess.FindAndCount(&list)
+	if err != nil {
+		fail(ctx, http.StatusInternalServerError, err.Error())
+		return
+	}
+	ok(ctx, q.result(pick(list, q.Fields), total))
+}
+
+// adsHandler 广告位下正在投放的广告, space为广告位key或ID
+func adsHandler(ctx *pine.Context) {
+	space := ctx.Params().Get("space")
+	adSpace := &tables.AdvertSpace{}
+	orm := helper.GetORM()
+	if exist, _ := orm.Where("site_id = ?", config.CtxSiteId(ctx)).Where(orm.Quote("key")+" = ? OR id = ?", space, space).Get(adSpace); !exist {
+		fail(ctx, http.StatusNotFound, "广告位不存在")
+		return
+	}
+	now := time.Now().In(helper.GetLocation()).Format(helper.TimeFormat)
+	var list []tables.Advert
+	err := orm.Where("space_id = ?", adSpace.Id).Where("status = 1").
+		Where("start_time IS NULL OR start_time <= ?", now).
+		Where("end_time IS NULL OR end_time >= ?", now).
+		Asc("listorder").Find(&list)
+	if err != nil {
+		fail(ctx, http.StatusInternalServerError, err.Error())
+		return
+	}
+	for i := range list {
+		list[i].SpaceName = adSpace.Name
+	}
+	ok(ctx, pine.H{"space": adSpace, "list": list})
+}
+
+// linksHandler 已审核的友情链接, filter[linktype]筛选类型
+func linksHandler(ctx *pine.Context) {
+	q, err := parseList(ctx, []string{"id", "listorder", "addtime"},
+		[]string{"id", "linktype", "name", "url", "logo", "introduce", "listorder", "addtime"}, "listorder,id")
+	if err != nil {
+		fail(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	sess := helper.GetORM().Where("passed = 1").Where("site_id = ?", config.CtxSiteId(ctx))
+	defer sess.Close()
+	if linktype, ok := q.Filters["linktype"]; ok {
+		sess.Where("linktype = ?", linktype)
+	}
+	q.apply(sess)
+	var list []tables.Link
+	total, err := sess.FindAndCount(&list)
+	if err != nil {
+		fail(ctx, http.StatusInternalServerError, err.Error())
+		return
+	}
+	ok(ctx, q.result(pick(list, q.Fields), total))
+}
@@ -0,0 +1,106 @@
+package headless
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/bytedance/sonic"
+	"github.com/xiusin/pine"
+	"xorm.io/xorm"
+)
+
+const maxPageSize = 100
+
+// listQuery 列表请求参数: page, size, sort=-pubtime,id, fields=id,title, filter[字段]=值
+type listQuery struct {
+	Page    int
+	Size    int
+	Sort    []string
+	Fields  []string
+	Filters map[string]string
+	Keyword string
+}
+
+func queryInt(ctx *pine.Context, key string, def int) int {
+	if val, err := strconv.Atoi(string(ctx.QueryArgs().Peek(key))); err == nil && val > 0 {
+		return val
+	}
+	return def
+}
+
+// parseList 解析列表参数, 排序与返回字段必须在允许范围内
+func parseList(ctx *pine.Context, sortable, selectable []string, defSort string) (*listQuery, error) {
+	q := &listQuery{
+		Page:    queryInt(ctx, "page", 1),
+		Size:    min(queryInt(ctx, "size", 20), maxPageSize),
+		Filters: map[string]string{},
+		Keyword: strings.TrimSpace(string(ctx.QueryArgs().Peek("q"))),
+	}
+	sort := string(ctx.QueryArgs().Peek("sort"))
+	if len(sort) == 0 {
+		sort = defSort
+	}
+	for _, field := range strings.Split(sort, ",") {
+		if field = strings.TrimSpace(field); len(field) == 0 {
+			continue
+		}
+		order := "ASC"
+		if strings.HasPrefix(field, "-") {
+			field, order = field[1:], "DESC"
+		}
+		if !slices.Contains(sortable, field) {
+			return nil, fmt.Errorf("不支持按%s排序", field)
+		}
+		q.Sort = append(q.Sort, field+" "+order)
+	}
+	if fields := string(ctx.QueryArgs().Peek("fields")); len(fields) > 0 {
+		for _, field := range strings.Split(fields, ",") {
+			if field = strings.TrimSpace(field); len(field) == 0 {
+				continue
+			}
+			if !slices.Contains(selectable, field) {
+				return nil, fmt.Errorf("字段%s不存在", field)
+			}
+			q.Fields = append(q.Fields, field)
+		}
+	}
+	ctx.QueryArgs().VisitAll(func(key, value []byte) {
+		if k := string(key); strings.HasPrefix(k, "filter[") && strings.HasSuffix(k, "]") {
+			q.Filters[k[7:len(k)-1]] = string(value)
+		}
+	})
+	return q, nil
+}
+
+func (q *listQuery) apply(sess *xorm.Session) {
+	if len(q.Sort) > 0 {
+		sess.OrderBy(strings.Join(q.Sort, ", "))
+	}
+	sess.Limit(q.Size, (q.Page-1)*q.Size)
+}
+
+func (q *listQuery) result(list any, total int64) pine.H {
+	return pine.H{"list": list, "pagination": pine.H{"page": q.Page, "size": q.Size, "total": total}}
+}
+
+// pick 按fields筛选结构体数据的json字段
+func pick[T any](list []T, fields []string) any {
+	if len(fields) == 0 {
+		return list
+	}
+	var res = make([]map[string]any, 0, len(list))
+	for _, item := range list {
+		var m map[string]any
+		byts, _ := sonic.Marshal(item)
+		_ = sonic.Unmarshal(byts, &m)
+		for key := range m {
+			if !slices.Contains(fields, key) {
+				delete(m, key)
+			}
+		}
+		res = append(res, m)
+	}
+	return res
+}
@@ -0,0 +1,38 @@
+package headless
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+
+	"github.com/bytedance/sonic"
+	"github.com/xiusin/pine"
+	"github.com/xiusin/pinecms/src/config"
+)
+
+// ok 输出数据并附带ETag, 客户端携带If-None-Match且内容未变化时返回304
+func ok(ctx *pine.Context, data any) {
+	body, err := sonic.Marshal(pine.H{"code": 1000, "data": data})
+	if err != nil {
+		fail(ctx, http.StatusInternalServerError, err.Error())
+		return
+	}
+	sum := md5.Sum(body)
+	etag := `"` + hex.EncodeToString(sum[:]) + `"`
+	maxAge, _ := strconv.Atoi(config.CtxSiteConfig(ctx).Get("HEADLESS_MAX_AGE", "60"))
+	ctx.Response.Header.Set("ETag", etag)
+	ctx.Response.Header.Set("Cache-Control", "public, max-age="+strconv.Itoa(maxAge))
+	if string(ctx.Request.Header.Peek("If-None-Match")) == etag {
+		ctx.SetStatus(http.StatusNotModified)
+		return
+	}
+	ctx.Response.Header.SetContentType("application/json; charset=utf-8")
+	ctx.SetBody(body)
+}
+
+func fail(ctx *pine.Context, status int, msg string) {
+	ctx.SetStatus(status)
+	ctx.Stop()
+	_ = ctx.Render().JSON(pine.H{"code": status, "message": msg})
+}
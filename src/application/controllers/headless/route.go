@@ -0,0 +1,45 @@
+package headless
+
+import (
+	"sync"
+
+	"github.com/valyala/fasthttp"
+	"github.com/xiusin/pine"
+	"github.com/xiusin/pinecms/src/application/models/tables"
+	"github.com/xiusin/pinecms/src/common/helper"
+)
+
+var once sync.Once
+
+func InitInstall() {
+	once.Do(func() {
+		defer func() {
+			if err := recover(); err != nil {
+				pine.Logger().Warn("初始化安装失败", err)
+			}
+		}()
+		if err := helper.GetORM().Sync2(&tables.ApiKey{}); err != nil {
+			pine.Logger().Warn(err.Error())
+		}
+	})
+}
+
+func InitRouter(app *pine.Application, router *pine.Router) {
+	InitInstall()
+	v1 := app.Group("/api/v1", cors(), apiKeyAuth())
+	for path, handler := range map[string]pine.Handler{
+		"/categories":           categoriesHandler,
+		"/categories/:id":       categoryHandler,
+		"/models":               modelsHandler,
+		"/documents/:model":     documentsHandler,
+		"/documents/:model/:id": documentHandler,
+		"/tags":                 tagsHandler,
+		"/ads/:space":           adsHandler,
+		"/links":                linksHandler,
+		"/pages/:id":            pageHandler,
+	} {
+		v1.GET(path, handler)
+		v1.AddRoute(fasthttp.MethodOptions, path, handler) // 跨域预检由cors中间件直接响应
+	}
+	router.Handle(new(ApiKeyController), "/headless/key")
+}
@@ -14,6 +14,22 @@ type LoginAdminPayload struct {
 	RoleID    []int64 `json:"role_id"`
 }
 
+// LoginMemberPayload 前台会员登录JWT载体
+type LoginMemberPayload struct {
+	jwt.Payload
+	MemberId int64  `json:"member_id"`
+	Appid    string `json:"appid"`
+	Openid   string `json:"openid"`
+}
+
+// ImVisitorPayload 在线客服访客JWT载体
+type ImVisitorPayload struct {
+	jwt.Payload
+	VisitorId string `json:"visitor_id"`
+	Nickname  string `json:"nickname"`
+	Avatar    string `json:"avatar"`
+}
+
 // GetTableName 获取表名
 func GetTableName(name string) string {
 	tablePrefix := di.MustGet(ServiceTablePrefix).(string)
@@ -41,8 +41,10 @@ func New(app *pine.Application, config *Config) pine.Handler {
 			getApiData(ctx)
 		case "edit":
 			saveApiData(ctx)
-		case "export": // 导出丝袜哥
-			exportApiData(ctx)
+		case "openapi": // OpenAPI 3.1 文档, 供前端生成客户端
+			exportApiData(ctx, false)
+		case "export": // 下载 OpenAPI 文档
+			exportApiData(ctx, true)
 		case "reset": //标注接口字段为不可再修改， go端不可直接配置
 
 		}
@@ -0,0 +1,183 @@
+package export
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const openAPIVersion = "3.1.0"
+
+// Document OpenAPI 3.1 文档
+type Document struct {
+	OpenAPI    string                `json:"openapi"`
+	Info       Info                  `json:"info"`
+	Servers    []Server              `json:"servers,omitempty"`
+	Tags       []Tag                 `json:"tags,omitempty"`
+	Paths      map[string]PathItem   `json:"paths"`
+	Components Components            `json:"components"`
+	Security   []map[string][]string `json:"security,omitempty"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+type Server struct {
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem 请求方法(小写) => 接口
+type PathItem map[string]*Operation
+
+type Operation struct {
+	OperationId string               `json:"operationId"`
+	Summary     string               `json:"summary,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"` // query header path
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema  *Schema `json:"schema"`
+	Example any     `json:"example,omitempty"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas,omitempty"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type        string `json:"type"`
+	In          string `json:"in,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// JSONBody json请求体
+func JSONBody(schema *Schema) *RequestBody {
+	return &RequestBody{Required: true, Content: map[string]MediaType{"application/json": {Schema: schema}}}
+}
+
+// JSONResponse json响应
+func JSONResponse(desc string, schema *Schema, example any) map[string]*Response {
+	return map[string]*Response{"200": {
+		Description: desc,
+		Content:     map[string]MediaType{"application/json": {Schema: schema, Example: example}},
+	}}
+}
+
+// Builder 收集接口与结构体生成文档
+type Builder struct {
+	doc   *Document
+	types map[string]string // 结构体完整路径 => 组件名
+}
+
+func NewBuilder(info Info, servers ...Server) *Builder {
+	return &Builder{
+		doc: &Document{
+			OpenAPI:    openAPIVersion,
+			Info:       info,
+			Servers:    servers,
+			Paths:      map[string]PathItem{},
+			Components: Components{Schemas: map[string]*Schema{}},
+		},
+		types: map[string]string{},
+	}
+}
+
+// Security 设置全局认证方式
+func (b *Builder) Security(name string, scheme SecurityScheme) {
+	if b.doc.Components.SecuritySchemes == nil {
+		b.doc.Components.SecuritySchemes = map[string]SecurityScheme{}
+	}
+	b.doc.Components.SecuritySchemes[name] = scheme
+	b.doc.Security = append(b.doc.Security, map[string][]string{name: {}})
+}
+
+// Exist 接口是否已添加
+func (b *Builder) Exist(method, path string) bool {
+	_, exist := b.doc.Paths[path][strings.ToLower(method)]
+	return exist
+}
+
+// Add 添加接口, 已存在的接口不覆盖
+func (b *Builder) Add(method, path string, op *Operation) bool {
+	method = strings.ToLower(method)
+	if b.Exist(method, path) {
+		return false
+	}
+	if b.doc.Paths[path] == nil {
+		b.doc.Paths[path] = PathItem{}
+	}
+	if len(op.OperationId) == 0 {
+		op.OperationId = operationId(method, path)
+	}
+	if op.Responses == nil {
+		op.Responses = map[string]*Response{"200": {Description: "OK"}}
+	}
+	b.doc.Paths[path][method] = op
+	return true
+}
+
+// Document 生成文档, 标签按名称排序
+func (b *Builder) Document() *Document {
+	tags := map[string]struct{}{}
+	for _, item := range b.doc.Paths {
+		for _, op := range item {
+			for _, tag := range op.Tags {
+				tags[tag] = struct{}{}
+			}
+		}
+	}
+	b.doc.Tags = b.doc.Tags[:0]
+	for tag := range tags {
+		b.doc.Tags = append(b.doc.Tags, Tag{Name: tag})
+	}
+	sort.Slice(b.doc.Tags, func(i, j int) bool { return b.doc.Tags[i].Name < b.doc.Tags[j].Name })
+	return b.doc
+}
+
+// operationId 由方法与路径生成, 如 post /v2/user/list => postV2UserList
+func operationId(method, path string) string {
+	var sb strings.Builder
+	sb.WriteString(method)
+	for _, part := range strings.FieldsFunc(path, func(r rune) bool {
+		return r == '/' || r == '_' || r == '-' || r == '.' || r == '{' || r == '}' || r == ':'
+	}) {
+		sb.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	if sb.Len() == len(method) {
+		return fmt.Sprintf("%sRoot", method)
+	}
+	return sb.String()
+}
@@ -0,0 +1,275 @@
+package export
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema json schema, 仅包含生成客户端需要的字段
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Default              any                `json:"default,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Examples             []any              `json:"examples,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	ExclusiveMinimum     *float64           `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     *float64           `json:"exclusiveMaximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+}
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	textType    = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	jsonType    = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	commentExpr = regexp.MustCompile(`comment\('([^']*)'\)`)
+)
+
+// Schema 反射结构体生成schema, 具名结构体注册为组件并返回引用
+func (b *Builder) Schema(v any) *Schema {
+	if v == nil {
+		return &Schema{}
+	}
+	t, ok := v.(reflect.Type)
+	if !ok {
+		t = reflect.TypeOf(v)
+	}
+	return b.schemaOf(t)
+}
+
+// Object 返回结构体schema本身而非引用, 用于在此基础上追加字段
+func (b *Builder) Object(v any) *Schema {
+	s := b.Schema(v)
+	if len(s.Ref) == 0 {
+		return s
+	}
+	ref := b.doc.Components.Schemas[strings.TrimPrefix(s.Ref, "#/components/schemas/")]
+	c := *ref
+	c.Properties = make(map[string]*Schema, len(ref.Properties))
+	for k, p := range ref.Properties {
+		c.Properties[k] = p
+	}
+	c.Required = append([]string(nil), ref.Required...)
+	return &c
+}
+
+func (b *Builder) schemaOf(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t.Kind() == reflect.Struct && t.ConvertibleTo(timeType):
+		// 自定义时间类型(如 tables.LocalTime)按 2006-01-02 15:04:05 序列化
+		return &Schema{Type: "string", Examples: []any{time.DateTime}}
+	case t.Implements(jsonType) || reflect.PointerTo(t).Implements(jsonType):
+		if t.Kind() != reflect.Struct {
+			return b.kindSchema(t)
+		}
+		return &Schema{}
+	case t.Implements(textType) || reflect.PointerTo(t).Implements(textType):
+		return &Schema{Type: "string"}
+	}
+	return b.kindSchema(t)
+}
+
+func (b *Builder) kindSchema(t reflect.Type) *Schema {
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: b.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: b.schemaOf(t.Elem())}
+	case reflect.Struct:
+		return b.structSchema(t)
+	}
+	return &Schema{}
+}
+
+// structSchema 具名结构体注册到 components.schemas, 同名不同包的结构体附加包名
+func (b *Builder) structSchema(t reflect.Type) *Schema {
+	if len(t.Name()) == 0 {
+		s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+		b.fields(t, s)
+		return s
+	}
+	key := t.PkgPath() + "." + t.Name()
+	name, exist := b.types[key]
+	if !exist {
+		name = strings.ToUpper(t.Name()[:1]) + t.Name()[1:]
+		if _, used := b.doc.Components.Schemas[name]; used {
+			pkg := t.PkgPath()[strings.LastIndex(t.PkgPath(), "/")+1:]
+			name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+		}
+		b.types[key] = name
+		s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+		b.doc.Components.Schemas[name] = s // 先注册, 避免自引用时死循环
+		b.fields(t, s)
+	}
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// fields 按 json 标签生成字段, 描述取 api 标签的 remark 或 xorm 的 comment, 约束取 validate 标签
+func (b *Builder) fields(t reflect.Type, s *Schema) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && len(name) == 0 {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct && ft != timeType {
+				b.fields(ft, s)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if len(name) == 0 {
+			name = field.Name
+		}
+		prop := b.schemaOf(field.Type)
+		if len(prop.Ref) == 0 {
+			required := applyTags(prop, field.Tag)
+			if required {
+				s.Required = append(s.Required, name)
+			}
+		} else if desc := description(field.Tag); len(desc) > 0 {
+			// 3.1 允许 $ref 与 description 并列
+			prop = &Schema{Ref: prop.Ref, Description: desc}
+		}
+		s.Properties[name] = prop
+	}
+}
+
+func description(tag reflect.StructTag) string {
+	for _, item := range strings.Split(tag.Get("api"), "|") {
+		if k, v, ok := strings.Cut(item, ":"); ok && k == "remark" {
+			return v
+		}
+	}
+	if m := commentExpr.FindStringSubmatch(tag.Get("xorm")); len(m) > 1 {
+		return m[1]
+	}
+	return ""
+}
+
+// applyTags 应用描述、默认值与 validate 约束, 返回是否必填
+func applyTags(s *Schema, tag reflect.StructTag) (required bool) {
+	s.Description = description(tag)
+	for _, item := range strings.Split(tag.Get("api"), "|") {
+		k, v, _ := strings.Cut(item, ":")
+		switch k {
+		case "default":
+			s.Default = v
+		case "require":
+			required = required || v == "true"
+		}
+	}
+	for _, rule := range strings.Split(tag.Get("validate"), ",") {
+		k, v, _ := strings.Cut(rule, "=")
+		switch k {
+		case "dive":
+			return // dive 之后的规则作用于元素
+		case "required":
+			required = true
+		case "email":
+			s.Format = "email"
+		case "url", "uri":
+			s.Format = "uri"
+		case "ip":
+			s.Format = "ipv4"
+		case "oneof":
+			for _, item := range strings.Fields(v) {
+				if s.Type == "integer" || s.Type == "number" {
+					if n, err := strconv.ParseFloat(item, 64); err == nil {
+						s.Enum = append(s.Enum, n)
+						continue
+					}
+				}
+				s.Enum = append(s.Enum, item)
+			}
+		case "min", "max", "len", "gte", "lte", "gt", "lt":
+			n, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			limit(s, k, n)
+		}
+	}
+	return required
+}
+
+// limit 数字约束取值范围, 字符串约束长度, 数组约束元素个数
+func limit(s *Schema, rule string, n float64) {
+	size := int(n)
+	switch s.Type {
+	case "integer", "number":
+		switch rule {
+		case "min", "gte":
+			s.Minimum = &n
+		case "max", "lte":
+			s.Maximum = &n
+		case "gt":
+			s.ExclusiveMinimum = &n
+		case "lt":
+			s.ExclusiveMaximum = &n
+		case "len":
+			s.Minimum, s.Maximum = &n, &n
+		}
+	case "string":
+		switch rule {
+		case "min", "gte":
+			s.MinLength = &size
+		case "max", "lte":
+			s.MaxLength = &size
+		case "len":
+			s.MinLength, s.MaxLength = &size, &size
+		}
+	case "array":
+		switch rule {
+		case "min", "gte":
+			s.MinItems = &size
+		case "max", "lte":
+			s.MaxItems = &size
+		case "len":
+			s.MinItems, s.MaxItems = &size, &size
+		}
+	}
+}
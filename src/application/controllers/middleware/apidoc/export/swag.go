@@ -1,15 +1,31 @@
 package export
 
-import "github.com/xiusin/pine"
+import (
+	"encoding/json"
 
+	"github.com/xiusin/pine"
+)
+
+// Swag 以 OpenAPI 3.1 json 输出文档
 type Swag struct {
-	template string
+	ctx      *pine.Context
+	Doc      *Document
+	Filename string // 不为空时作为附件下载
 }
 
 func (s *Swag) SetContext(ctx *pine.Context) {
-	s.template = ""
+	s.ctx = ctx
 }
 
 func (s *Swag) Export() {
-
+	data, err := json.MarshalIndent(s.Doc, "", "  ")
+	if err != nil {
+		_ = s.ctx.WriteJSON(pine.H{"code": 1, "msg": err.Error()})
+		return
+	}
+	if len(s.Filename) > 0 {
+		s.ctx.Response.Header.Set("Content-Disposition", "attachment; filename="+s.Filename)
+	}
+	s.ctx.Response.Header.SetContentType("application/json; charset=utf-8")
+	_ = s.ctx.Write(data)
 }
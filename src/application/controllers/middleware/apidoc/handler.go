@@ -1,9 +1,11 @@
 package apidoc
 
 import (
+	"strings"
+
 	"github.com/xiusin/pine"
+	"github.com/xiusin/pinecms/src/application/controllers/middleware/apidoc/export"
 	"github.com/xiusin/pinecms/src/common/helper"
-	"strings"
 )
 
 func getConfig(ctx *pine.Context) {
@@ -121,7 +123,18 @@ func saveRequestData(ctx *pine.Context) {
 	helper.Ajax("更新成功", 0, ctx)
 }
 
-// 导出Api数据
-func exportApiData(ctx *pine.Context) {
-
+// 导出 OpenAPI 3.1 文档, download 为真时作为附件下载
+func exportApiData(ctx *pine.Context, download bool) {
+	server := export.Server{URL: string(ctx.URI().Scheme()) + "://" + string(ctx.Host())}
+	doc, err := OpenAPI(server)
+	if err != nil {
+		helper.Ajax(err.Error(), 1, ctx)
+		return
+	}
+	var exporter export.ExportIntf = &export.Swag{Doc: doc}
+	if download {
+		exporter = &export.Swag{Doc: doc, Filename: "openapi.json"}
+	}
+	exporter.SetContext(ctx)
+	exporter.Export()
 }
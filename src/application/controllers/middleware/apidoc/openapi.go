@@ -0,0 +1,303 @@
+package apidoc
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sonyarouje/simdb"
+	"github.com/xiusin/pinecms/src/application/controllers/middleware/apidoc/export"
+)
+
+// Resource 通过 BaseController 提供的增删改查接口
+type Resource struct {
+	Path        string   // 路由前缀, 如 /v2/user
+	Group       string   // 分组
+	SubGroup    string   // 子分组
+	Name        string   // 实体名称, 如 用户
+	Table       any      // 表结构体, 为空时按任意对象处理
+	ListParam   any      // 列表请求参数结构体
+	DeleteParam any      // 删除请求参数结构体
+	Filters     []Filter // 列表筛选字段, 放在请求参数的 params 内
+	Keywords    []string // 关键字匹配的字段
+}
+
+// Filter 列表筛选字段
+type Filter struct {
+	Field string
+	Op    string
+	Type  reflect.Type // 字段类型, 无法推断时为空
+}
+
+// Endpoint 注解路由登记的接口
+type Endpoint struct {
+	Method   string // 请求方法, ANY 按 POST 处理
+	Path     string // 完整路径, 如 /v2/user/login
+	Title    string
+	Group    string
+	Request  any // 请求参数结构体
+	Response any // 响应数据结构体
+}
+
+var (
+	resources  []Resource
+	endpoints  []Endpoint
+	resourceMu sync.Mutex
+)
+
+// RegisterResource 登记增删改查接口用于生成 OpenAPI 文档
+func RegisterResource(r Resource) {
+	resourceMu.Lock()
+	defer resourceMu.Unlock()
+	resources = append(resources, r)
+}
+
+// RegisterEndpoint 登记注解路由的接口用于生成 OpenAPI 文档, 仅设置了标题的接口会被导出
+func RegisterEndpoint(e Endpoint) {
+	resourceMu.Lock()
+	defer resourceMu.Unlock()
+	endpoints = append(endpoints, e)
+}
+
+// OpenAPI 由登记的增删改查接口、注解接口与记录的接口数据生成 OpenAPI 3.1 文档, 按此顺序优先
+func OpenAPI(servers ...export.Server) (*export.Document, error) {
+	conf := defaultConfig
+	if conf == nil {
+		conf = DefaultConfig()
+	}
+	b := export.NewBuilder(export.Info{Title: conf.Title, Description: conf.Desc, Version: "2.0"}, servers...)
+	b.Security("token", export.SecurityScheme{Type: "apiKey", In: "header", Name: "Authorization", Description: "登录接口返回的jwt"})
+
+	resourceMu.Lock()
+	for _, r := range resources {
+		addResource(b, r)
+	}
+	for _, e := range endpoints {
+		addEndpoint(b, e)
+	}
+	resourceMu.Unlock()
+
+	entities, err := recordedEntities(conf)
+	if err != nil {
+		return nil, err
+	}
+	for i := range entities {
+		addEntity(b, &entities[i])
+	}
+	return b.Document(), nil
+}
+
+func recordedEntities(conf *Config) ([]apiEntity, error) {
+	driver := simdbDriver
+	if driver == nil {
+		var err error
+		if driver, err = simdb.New(conf.DataPath); err != nil {
+			return nil, err
+		}
+	}
+	var entities []apiEntity
+	err := driver.Open(&apiEntity{}).Get().AsEntity(&entities)
+	if err != nil && !errors.Is(err, simdb.ErrRecordNotFound) {
+		return nil, err
+	}
+	return entities, nil
+}
+
+// envelope helper.Ajax 的响应结构, code 为1000时成功
+func envelope(data *export.Schema) *export.Schema {
+	s := &export.Schema{Type: "object", Required: []string{"code"}, Properties: map[string]*export.Schema{
+		"code":    {Type: "integer", Description: "状态码, 1000为成功"},
+		"message": {Type: "string", Description: "操作描述或错误信息"},
+	}}
+	if data != nil {
+		s.Properties["data"] = data
+	}
+	return s
+}
+
+func addResource(b *export.Builder, r Resource) {
+	tag := r.SubGroup
+	if len(tag) == 0 {
+		tag = r.Group
+	}
+	var tags []string
+	if len(tag) > 0 {
+		tags = []string{tag}
+	}
+	entity := &export.Schema{Type: "object"}
+	if r.Table != nil {
+		entity = b.Schema(r.Table)
+	}
+
+	list := b.Object(r.ListParam)
+	if list.Properties != nil {
+		params := &export.Schema{Type: "object", Description: "筛选条件", Properties: map[string]*export.Schema{}}
+		for _, f := range r.Filters {
+			prop := &export.Schema{}
+			if f.Type != nil {
+				prop = b.Schema(f.Type)
+			}
+			if f.Op != "" && f.Op != "=" {
+				prop = &export.Schema{Ref: prop.Ref, Type: prop.Type, Format: prop.Format, Items: prop.Items, Description: "匹配方式: " + f.Op}
+			}
+			params.Properties[strings.Trim(f.Field, "`")] = prop
+		}
+		list.Properties["params"] = params
+		if kw, exist := list.Properties["keyWord"]; exist && len(r.Keywords) > 0 {
+			c := *kw
+			c.Description = "匹配字段: " + strings.Join(r.Keywords, ", ")
+			list.Properties["keyWord"] = &c
+		}
+	}
+	pagination := &export.Schema{Type: "object", Properties: map[string]*export.Schema{
+		"page":  {Type: "integer"},
+		"size":  {Type: "integer"},
+		"total": {Type: "integer", Format: "int64"},
+	}}
+
+	b.Add("post", r.Path+"/list", &export.Operation{
+		Summary: r.Name + "列表", Tags: tags,
+		RequestBody: export.JSONBody(list),
+		Responses: export.JSONResponse("OK", envelope(&export.Schema{Type: "object", Properties: map[string]*export.Schema{
+			"list":       {Type: "array", Items: entity},
+			"pagination": pagination,
+		}}), nil),
+	})
+	b.Add("post", r.Path+"/add", &export.Operation{
+		Summary: "新增" + r.Name, Tags: tags,
+		RequestBody: export.JSONBody(entity),
+		Responses:   export.JSONResponse("OK", envelope(entity), nil),
+	})
+	b.Add("post", r.Path+"/edit", &export.Operation{
+		Summary: "编辑" + r.Name, Tags: tags,
+		RequestBody: export.JSONBody(entity),
+		Responses:   export.JSONResponse("OK", envelope(nil), nil),
+	})
+	b.Add("post", r.Path+"/delete", &export.Operation{
+		Summary: "删除" + r.Name, Tags: tags,
+		RequestBody: export.JSONBody(b.Schema(r.DeleteParam)),
+		Responses:   export.JSONResponse("OK", envelope(nil), nil),
+	})
+	b.Add("get", r.Path+"/info", &export.Operation{
+		Summary: r.Name + "详情", Tags: tags,
+		Parameters: []*export.Parameter{{Name: "id", In: "query", Required: true, Schema: &export.Schema{Type: "integer", Format: "int64"}}},
+		Responses:  export.JSONResponse("OK", envelope(entity), nil),
+	})
+}
+
+// addEndpoint 注解路由的接口, 参数与响应按注解的结构体生成
+func addEndpoint(b *export.Builder, e Endpoint) {
+	method := strings.ToLower(e.Method)
+	if method == "any" {
+		method = "post"
+	}
+	if len(e.Title) == 0 || b.Exist(method, e.Path) {
+		return
+	}
+	op := &export.Operation{Summary: e.Title}
+	if len(e.Group) > 0 {
+		op.Tags = []string{e.Group}
+	}
+	if e.Request != nil {
+		if method == "get" || method == "head" {
+			for name, prop := range b.Object(e.Request).Properties {
+				op.Parameters = append(op.Parameters, &export.Parameter{Name: name, In: "query", Schema: prop})
+			}
+			sort.Slice(op.Parameters, func(i, j int) bool { return op.Parameters[i].Name < op.Parameters[j].Name })
+		} else {
+			op.RequestBody = export.JSONBody(b.Schema(e.Request))
+		}
+	}
+	var data *export.Schema
+	if e.Response != nil {
+		data = b.Schema(e.Response)
+	}
+	op.Responses = export.JSONResponse("OK", envelope(data), nil)
+	b.Add(method, e.Path, op)
+}
+
+// addEntity 记录的接口数据, 未登记为增删改查的接口按记录的参数生成
+func addEntity(b *export.Builder, e *apiEntity) {
+	if len(e.URL) == 0 || len(e.Method) == 0 || b.Exist(e.Method, e.URL) {
+		return
+	}
+	op := &export.Operation{Summary: e.Title, Description: e.Desc}
+	if tag := e.SubGroup; len(tag) > 0 {
+		op.Tags = []string{tag}
+	} else if len(e.Group.Title) > 0 {
+		op.Tags = []string{e.Group.Title}
+	}
+	for _, h := range e.Header {
+		if strings.EqualFold(h.Name, "Authorization") {
+			continue // 见全局认证
+		}
+		op.Parameters = append(op.Parameters, &export.Parameter{Name: h.Name, In: "header", Description: h.Desc, Required: h.Require, Schema: paramSchema(apiParam{Type: h.Type})})
+	}
+	for _, q := range e.Query {
+		op.Parameters = append(op.Parameters, &export.Parameter{Name: q.Name, In: "query", Description: q.Desc, Required: q.Require, Schema: paramSchema(q)})
+	}
+	if len(e.Param) > 0 && !strings.EqualFold(e.Method, "get") {
+		body := paramSchema(apiParam{Type: "object", Params: e.Param})
+		var example any
+		if raw := json.RawMessage(e.RawParam); json.Valid(raw) {
+			example = raw
+		}
+		op.RequestBody = &export.RequestBody{Required: true, Content: map[string]export.MediaType{"application/json": {Schema: body, Example: example}}}
+	}
+	res := envelope(nil)
+	if len(e.Return) > 0 {
+		res = &export.Schema{Type: "object", Properties: map[string]*export.Schema{}}
+		for _, ret := range e.Return {
+			p := paramSchema(apiParam{Name: ret.Name, Type: ret.Type, Desc: ret.Desc, Params: ret.Params})
+			if len(ret.Default) > 0 {
+				p.Default = ret.Default
+			}
+			res.Properties[ret.Name] = p
+		}
+	}
+	var example any
+	if raw := json.RawMessage(e.RawReturn); json.Valid(raw) {
+		example = raw
+	}
+	op.Responses = export.JSONResponse("OK", res, example)
+	b.Add(e.Method, e.URL, op)
+}
+
+// paramSchema 记录的参数类型: number bool string object array float any
+func paramSchema(p apiParam) *export.Schema {
+	s := &export.Schema{Description: p.Desc}
+	if p.Default != nil && p.Default != "" {
+		s.Default = p.Default
+	}
+	switch strings.ToLower(p.Type) {
+	case "number", "int", "integer":
+		s.Type = "integer"
+	case "float", "double":
+		s.Type = "number"
+	case "bool", "boolean":
+		s.Type = "boolean"
+	case "string":
+		s.Type = "string"
+	case "object":
+		s.Type = "object"
+		if len(p.Params) > 0 {
+			s.Properties = map[string]*export.Schema{}
+			for _, child := range p.Params {
+				s.Properties[child.Name] = paramSchema(child)
+				if child.Require {
+					s.Required = append(s.Required, child.Name)
+				}
+			}
+		}
+	case "array":
+		s.Type = "array"
+		s.Items = paramSchema(apiParam{Type: p.ChildrenType, Params: p.Params})
+		if len(p.ChildrenType) == 0 && len(p.Params) > 0 {
+			s.Items = paramSchema(apiParam{Type: "object", Params: p.Params})
+		}
+	}
+	return s
+}
@@ -4,12 +4,14 @@ import (
 	_ "embed"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/casbin/casbin/v2"
 	casbinModel "github.com/casbin/casbin/v2/model"
+	"github.com/casbin/casbin/v2/util"
 	xd "github.com/casbin/xorm-adapter"
 	"xorm.io/xorm"
 
@@ -18,6 +20,8 @@ import (
 	"github.com/xiusin/pinecms/src/application/controllers"
 	"github.com/xiusin/pinecms/src/application/models/tables"
 	"github.com/xiusin/pinecms/src/common/helper"
+	"github.com/xiusin/pinecms/src/common/route"
+	"github.com/xiusin/pinecms/src/config"
 )
 
 var publicApis = []string{
@@ -40,6 +44,8 @@ func Casbin(engine *xorm.Engine) pine.Handler {
 
 	enforcer, err := casbin.NewSyncedCachedEnforcer(model, adapter)
 	helper.PanicErr(err)
+	// 站点域为*的授权对全部站点生效
+	enforcer.AddNamedDomainMatchingFunc("g", "KeyMatch", util.KeyMatch)
 	enforcer.EnableCache(true)
 	enforcer.EnableAutoSave(true)
 	enforcer.StartAutoLoadPolicy(time.Minute * 2)
@@ -66,6 +72,7 @@ func Casbin(engine *xorm.Engine) pine.Handler {
 			var admin = &tables.Admin{}
 			if exist, _ := engine.Where("id = ?", adminId).Get(admin); !exist {
 				ctx.Abort(http.StatusForbidden)
+				return
 			}
 			pathString := strings.Split(strings.Trim(ctx.Path(), "/"), "/")
 
@@ -75,11 +82,15 @@ func Casbin(engine *xorm.Engine) pine.Handler {
 					ctx.Next()
 					return
 				}
-				for _, role := range admin.RoleIdList {
-					if passable, _ := enforcer.Enforce(fmt.Sprintf("%d", role), pathString[1], pathString[2]); passable {
-						ctx.Next()
-						return
-					}
+				siteId := strconv.FormatInt(config.CtxSiteId(ctx), 10)
+				obj, act := pathString[1], pathString[2]
+				// 注解设置了权限标识时按标识校验
+				if perm := route.Permission(route.MountAdmin, string(ctx.Method()), strings.Join(pathString, "/")); len(perm) > 0 {
+					obj, act, _ = strings.Cut(perm, ":")
+				}
+				if passable, _ := enforcer.Enforce(adminSubject(admin.Userid), siteId, obj, act); passable {
+					ctx.Next()
+					return
 				}
 			}
 			ctx.Abort(http.StatusForbidden)
@@ -97,12 +108,18 @@ func clearPolicy(enforcer *casbin.SyncedCachedEnforcer, _locker *sync.Mutex) fun
 	}
 }
 
-// 根据角色注入权限
+func adminSubject(adminId int64) string {
+	return fmt.Sprintf("admin:%d", adminId)
+}
+
+// 根据角色注入权限, 并按管理员可管理的站点注入角色域
 func addPolicy(engine *xorm.Engine, enforcer *casbin.SyncedCachedEnforcer, _locker *sync.Mutex) func() {
 	return func() {
 		_locker.Lock()
 		defer _locker.Unlock()
 		engine.Table(&xd.CasbinRule{}).Where("v0 > 0").Delete()
+		engine.Table(&xd.CasbinRule{}).Where("ptype = ?", "g").Delete()
+		enforcer.RemoveFilteredGroupingPolicy(0)
 		var roles []tables.AdminRole
 		engine.Find(&roles)
 		var menus []tables.Menu
@@ -121,6 +138,24 @@ func addPolicy(engine *xorm.Engine, enforcer *casbin.SyncedCachedEnforcer, _lock
 				}
 			}
 		}
+		var admins []tables.Admin
+		engine.Find(&admins)
+		for _, admin := range admins {
+			domains := []string{"*"}
+			if len(admin.SiteIds) > 0 {
+				domains = domains[:0]
+				for _, id := range admin.SiteIds {
+					if site := config.GetSiteById(id); site != nil {
+						domains = append(domains, strconv.FormatInt(config.SiteScope(site), 10))
+					}
+				}
+			}
+			for _, role := range admin.RoleIdList {
+				for _, domain := range domains {
+					enforcer.AddGroupingPolicy(adminSubject(admin.Userid), strconv.FormatInt(role, 10), domain)
+				}
+			}
+		}
 		enforcer.SavePolicy()
 	}
 }
@@ -1,21 +1,32 @@
 package middleware
 
 import (
+	"strconv"
+	"strings"
+
 	"github.com/xiusin/pine"
 	"github.com/xiusin/pinecms/src/application/controllers"
 	"github.com/xiusin/pinecms/src/config"
-	"strings"
 )
 
+// SetGlobalConfigData 根据域名解析当前站点并合并站点配置.
+// 后台接口可通过请求头 X-Site-Id 切换管理的站点, 是否有权限由casbin按站点域校验
 func SetGlobalConfigData() pine.Handler {
 	return func(ctx *pine.Context) {
-		settingData, err := config.SiteConfig()
+		site := config.ResolveSite(string(ctx.Host()))
+		if siteId, _ := strconv.ParseInt(ctx.Header("X-Site-Id"), 10, 64); siteId > 0 && strings.HasPrefix(ctx.Path(), "/v2/") {
+			if s := config.GetSiteById(siteId); s != nil {
+				site = s
+			}
+		}
+		settingData, err := config.SiteConfigFor(site)
 		if err != nil {
 			pine.Logger().Error("无法读取到配置内容:" + err.Error())
 			return
 		}
 		settingData["site_url"] = string(ctx.Host())
 		ctx.Set(controllers.CacheSetting, settingData)
+		ctx.Set(controllers.CacheSite, site)
 		lower := map[string]string{}
 		for k, v := range settingData {
 			lower[strings.ToLower(k)] = v
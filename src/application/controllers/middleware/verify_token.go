@@ -2,7 +2,6 @@ package middleware
 
 import (
 	"fmt"
-	"github.com/gbrlsnchs/jwt/v3"
 	"github.com/xiusin/pine"
 	"github.com/xiusin/pine/di"
 	"github.com/xiusin/pinecms/src/application/controllers"
@@ -24,11 +23,9 @@ func VerifyJwtToken() pine.Handler {
 			if token == "" {
 				token, _ = ctx.Input().GetString("token")
 			}
-			var hs = jwt.NewHS256([]byte(config.App().JwtKey))
-			// 验证token
+			// 验证token, 只接受未过期的后台登录令牌
 			var pl controllers.LoginAdminPayload
-			_, err := jwt.Verify([]byte(token), hs, &pl)
-			if err != nil {
+			if err := config.JwtVerify(config.JwtAudienceAdmin, []byte(token), &pl.Payload, &pl); err != nil || pl.AdminId <= 0 {
 				_ = ctx.Render().JSON(pine.H{"code": 1, "msg": "授权失败, 请重新登录"})
 				return
 			}
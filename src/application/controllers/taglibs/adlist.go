@@ -8,6 +8,7 @@ import (
 	"github.com/CloudyKit/jet"
 	"github.com/xiusin/pinecms/src/application/models/tables"
 	"github.com/xiusin/pinecms/src/common/helper"
+	"xorm.io/builder"
 )
 
 /**
@@ -30,7 +31,8 @@ func AdList(args jet.Arguments) reflect.Value {
 	if order == "" {
 		order = "listorder desc"
 	}
-	orm := helper.GetORM().OrderBy(order)
+	spaces := builder.Select("id").From(helper.GetORM().TableName(&tables.AdvertSpace{}, true)).Where(builder.Eq{"site_id": siteId(args)})
+	orm := helper.GetORM().OrderBy(order).Where(builder.In("space_id", spaces))
 	// 获取广告位信息
 	var pos = args.Get(1)
 	switch pos.Type().String() {
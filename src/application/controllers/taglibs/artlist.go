@@ -62,8 +62,7 @@ func ArcList(args jet.Arguments) reflect.Value {
 		offset := getNumber(args.Get(1))
 		limit := getNumber(args.Get(2))
 		if limit == 0 { // 没有设置分页条数, 使用系统默认分页数目
-			conf, _ := config.SiteConfig()
-			t, _ := strconv.Atoi(conf["SITE_PAGE_SIZE"])
+			t, _ := strconv.Atoi(siteSetting(args, "SITE_PAGE_SIZE"))
 			limit = int64(t)
 			if limit == 0 {
 				limit = 15
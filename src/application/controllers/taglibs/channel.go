@@ -46,7 +46,7 @@ func Channel(args jet.Arguments) reflect.Value {
 			}
 			_reid = cat.Parentid
 		}
-		orm := getCategoryOrm().Limit(_row).Asc("listorder")
+		orm := getCategoryOrm(args).Limit(_row).Asc("listorder")
 		switch _type {
 		case "top":
 			_reid = 0
@@ -67,7 +67,7 @@ func Channel(args jet.Arguments) reflect.Value {
 
 		if len(arr) == 0 && _type == "son" && _reid != 0 {
 			//如果用子栏目模式，当没有子栏目时显示同级栏目
-			getCategoryOrm().Limit(_row).Asc("listorder").Where("parentid = ?", _reid).Find(&arr)
+			getCategoryOrm(args).Limit(_row).Asc("listorder").Where("parentid = ?", _reid).Find(&arr)
 		}
 		for k, v := range arr {
 			if v.Type != 2 {
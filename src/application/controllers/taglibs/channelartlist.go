@@ -35,11 +35,11 @@ func ChannelArtList(args jet.Arguments) reflect.Value {
 
 	var orm *xorm.Session
 	if _typeid == "0" || _typeid == "top" {
-		orm = getCategoryOrm().Where("parentid = 0")
+		orm = getCategoryOrm(args).Where("parentid = 0")
 	} else if strings.Contains(_typeid, ",") {
-		orm = getCategoryOrm().In("catid", strings.Split(_typeid, ","))
+		orm = getCategoryOrm(args).In("catid", strings.Split(_typeid, ","))
 	} else {
-		orm = getCategoryOrm().Where("parentid = ?", _typeid)
+		orm = getCategoryOrm(args).Where("parentid = ?", _typeid)
 	}
 
 	_topid := getNumber(args.Get(2)) // 当前页面的ID
@@ -60,7 +60,7 @@ func ChannelArtList(args jet.Arguments) reflect.Value {
 		if v.Type != 2 {
 			if withSons {
 				var sons tables.Category
-				count, _ := getCategoryOrm().Where("parentid = ?", v.Catid).Count(&sons)
+				count, _ := getCategoryOrm(args).Where("parentid = ?", v.Catid).Count(&sons)
 				if count > 0 {
 					cats[k].HasSon = true
 				}
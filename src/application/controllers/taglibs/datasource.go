@@ -0,0 +1,50 @@
+package taglibs
+
+import (
+	"reflect"
+
+	"github.com/CloudyKit/jet"
+	"github.com/xiusin/pine"
+	"github.com/xiusin/pinecms/src/application/models"
+)
+
+/**
+ * 标签：{{yield datasource(name="", params=map()) content}} {{end}}
+ * 作用：数据源查询标签, 执行后台定义的命名查询, 参数绑定防注入
+ * 用法示例： {{yield datasource(name="category_count", params=map("catid", field.Catid)) content}} {{field["total"]}} {{end}}
+ * 参数说明：
+ * 	name 数据源调用名称
+ * 	params 查询参数, 对应数据源条件中的参数名, page参数用于分页
+ */
+func DataSource(args jet.Arguments) reflect.Value {
+	args.RequireNumOfArguments("datasource", 1, 2)
+	defer func() {
+		if err := recover(); err != nil {
+			pine.Logger().Error("DataSource Failed", err)
+		}
+	}()
+	name := args.Get(0).String()
+	params := map[string]any{}
+	if args.NumOfArguments() > 1 {
+		if p, ok := args.Get(1).Interface().(map[string]any); ok {
+			params = p
+		}
+	}
+	m := models.NewDataSourceModel()
+	ds := m.GetByName(name)
+	if ds == nil {
+		pine.Logger().Warn("数据源不存在或已禁用", name)
+		return reflect.ValueOf([]map[string]string{})
+	}
+	query, err := m.Compile(ds)
+	if err != nil {
+		pine.Logger().Error("数据源校验失败", name, err)
+		return reflect.ValueOf([]map[string]string{})
+	}
+	rows, err := m.Execute(query, params)
+	if err != nil {
+		pine.Logger().Error("数据源查询失败", name, err)
+		return reflect.ValueOf([]map[string]string{})
+	}
+	return reflect.ValueOf(rows)
+}
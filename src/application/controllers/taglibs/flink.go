@@ -21,7 +21,7 @@ func Flink(args jet.Arguments) reflect.Value {
 		}
 	}()
 	orm := helper.GetORM()
-	sess := orm.Table(&tables.Link{})
+	sess := orm.Table(&tables.Link{}).Where("site_id = ?", siteId(args))
 	defer sess.Close()
 	row := int(args.Get(0).Float())
 	if row == 0 {
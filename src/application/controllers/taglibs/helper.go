@@ -13,6 +13,7 @@ import (
 	"github.com/xiusin/pinecms/src/application/controllers"
 	"github.com/xiusin/pinecms/src/application/models/tables"
 	"github.com/xiusin/pinecms/src/common/helper"
+	"github.com/xiusin/pinecms/src/config"
 	"xorm.io/xorm"
 )
 
@@ -56,8 +57,26 @@ func getOrmSess(table ...string) *xorm.Session {
 	return helper.GetORM().Table(controllers.GetTableName(table[0]))
 }
 
-func getCategoryOrm() *xorm.Session {
-	return helper.GetORM().Table(&tables.Category{}).Where("ismenu = 1")
+// siteSetting 读取当前站点配置, 站点配置由渲染变量global传入
+func siteSetting(args jet.Arguments, key string) string {
+	if global := args.Runtime().Resolve("global"); global.IsValid() {
+		if setting, ok := global.Interface().(map[string]string); ok {
+			if val, ok := setting[strings.ToLower(key)]; ok {
+				return val
+			}
+		}
+	}
+	return config.GetSiteConfigByKey(key)
+}
+
+// siteId 当前站点ID, 用于筛选站点栏目
+func siteId(args jet.Arguments) int64 {
+	id, _ := strconv.ParseInt(siteSetting(args, "SITE_ID"), 10, 64)
+	return id
+}
+
+func getCategoryOrm(args jet.Arguments) *xorm.Session {
+	return helper.GetORM().Table(&tables.Category{}).Where("ismenu = 1").Where("site_id = ?", siteId(args))
 }
 
 func getCategoryTable() string {
@@ -69,6 +88,7 @@ func getTagHash(args jet.Arguments) string {
 	for i := 0; i < args.NumOfArguments(); i++ {
 		arr = append(arr, args.Get(i).Interface())
 	}
+	arr = append(arr, siteId(args)) // 不同站点分别缓存
 	byts, _ := json.Marshal(&arr)
 	md := md5.New()
 	md.Write(byts)
@@ -31,8 +31,7 @@ func List(args jet.Arguments) reflect.Value {
 	page := getNumber(args.Get(1))
 	pagesize := getNumber(args.Get(2))
 	if pagesize == 0 {
-		conf, _ := config.SiteConfig()
-		ps, _ := strconv.Atoi(conf["SITE_PAGE_SIZE"])
+		ps, _ := strconv.Atoi(siteSetting(args, "SITE_PAGE_SIZE"))
 		if ps == 0 {
 			pagesize = 15
 		} else {
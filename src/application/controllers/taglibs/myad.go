@@ -6,6 +6,7 @@ import (
 	"github.com/xiusin/pinecms/src/common/helper"
 	"reflect"
 	"time"
+	"xorm.io/builder"
 )
 
 /**
@@ -20,7 +21,8 @@ func MyAd(args jet.Arguments) reflect.Value {
 	id := int(getNumber(args.Get(0)))
 	name := args.Get(1).String()
 	now := time.Now().In(helper.GetLocation()).Format(helper.TimeFormat)
-	orm := helper.GetORM().Where("status = 1").Where("start_time <= ?", now).Where("end_time >= ?", now).Select("id, name, image, link_url")
+	spaces := builder.Select("id").From(helper.GetORM().TableName(&tables.AdvertSpace{}, true)).Where(builder.Eq{"site_id": siteId(args)})
+	orm := helper.GetORM().Where(builder.In("space_id", spaces)).Where("status = 1").Where("start_time <= ?", now).Where("end_time >= ?", now).Select("id, name, image, link_url")
 	if id > 0 {
 		orm.ID(id)
 	}
@@ -5,7 +5,6 @@ import (
 	"github.com/xiusin/pine"
 	"github.com/xiusin/pinecms/src/application/models"
 	"github.com/xiusin/pinecms/src/common/helper"
-	"github.com/xiusin/pinecms/src/config"
 	"math"
 	"reflect"
 	"strconv"
@@ -25,9 +24,8 @@ func PageList(args jet.Arguments) reflect.Value {
 	total := int(getNumber(args.Get(2)))
 	page := int(getNumber(args.Get(3)))
 	qp, _ := args.Get(4).Interface().(map[string][]string)
-	conf, _ := config.SiteConfig()
 	if limit == 0 {
-		limit, _ = strconv.Atoi(conf["SITE_PAGE_SIZE"])
+		limit, _ = strconv.Atoi(siteSetting(args, "SITE_PAGE_SIZE"))
 		if limit == 0 {
 			limit = 15
 		}
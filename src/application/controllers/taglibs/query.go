@@ -14,6 +14,7 @@ import (
 /**
  * 标签：{{yield query(sql="") content}} {{end}}
  * 作用：特殊标签，SQL查询标签
+ * 已废弃: 请使用datasource标签, 需在系统设置中开启TPL_QUERY_ENABLE才能使用
  * 用法示例： {{yield query(sql="SELECT * FROM #@_tables") content}} .. HTML ..{{end}}
  * 参数说明：
  * 	sql SQL语句，只用于select类型语句
@@ -22,6 +23,10 @@ func Query(args jet.Arguments) reflect.Value {
 	if !checkArgType(&args) {
 		return defaultArrReturnVal
 	}
+	if siteSetting(args, "TPL_QUERY_ENABLE") != "开启" {
+		pine.Logger().Warn("query标签已废弃, 请使用datasource标签或在设置中开启TPL_QUERY_ENABLE")
+		return reflect.ValueOf([]map[string]string{})
+	}
 	defer func() {
 		if err := recover(); err != nil {
 			pine.Logger().Error("Query Failed", err)
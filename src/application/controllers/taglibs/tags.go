@@ -3,7 +3,6 @@ package taglibs
 import (
 	"github.com/CloudyKit/jet"
 	"github.com/xiusin/pine"
-	"github.com/xiusin/pinecms/src/config"
 	"reflect"
 	"runtime/debug"
 	"strings"
@@ -18,7 +17,6 @@ func HotWords(args jet.Arguments) reflect.Value {
 			pine.Logger().Error("HotWords Failed", err, string(debug.Stack()))
 		}
 	}()
-	conf, _ := config.SiteConfig()
-	words := strings.Split(conf["SITE_HOTWORDS"], ",")
+	words := strings.Split(siteSetting(args, "SITE_HOTWORDS"), ",")
 	return reflect.ValueOf(words)
 }
@@ -115,10 +115,11 @@ func (c *CategoryModel) GetTree(categories []tables.Category, parentid int64) []
 	return res
 }
 
-func (c *CategoryModel) GetWithDirForBE(dir string) *tables.Category {
+// GetWithDirForBE 根据静态目录获取站点下的栏目
+func (c *CategoryModel) GetWithDirForBE(dir string, siteId int64) *tables.Category {
 	categories := c.GetAll(true)
 	for _, v := range categories {
-		if v.Dir == dir {
+		if v.Dir == dir && v.SiteId == siteId {
 			return &v
 		}
 	}
@@ -175,7 +176,7 @@ func (c *CategoryModel) GetNextCategoryOnlyCatids(parentid int64, withSelf bool)
 
 func (c *CategoryModel) GetSelectTree(parentid int64) []map[string]any {
 	categories := new([]tables.Category)
-	err := c.orm.Where("parentid = ?", parentid).OrderBy("`listorder` ASC,`id` DESC").Find(categories)
+	err := c.orm.Where("parentid = ?", parentid).OrderBy("listorder ASC, id DESC").Find(categories)
 	if err != nil {
 		log.Println(err.Error())
 	}
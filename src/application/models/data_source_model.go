@@ -0,0 +1,282 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/bytedance/sonic"
+	"github.com/xiusin/pinecms/src/application/controllers"
+	"github.com/xiusin/pinecms/src/application/models/tables"
+	"github.com/xiusin/pinecms/src/common/dialect"
+	"github.com/xiusin/pinecms/src/common/helper"
+	"github.com/xiusin/pinecms/src/config"
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// MaxDataSourceRows 数据源单次查询的最大行数
+const MaxDataSourceRows = 500
+
+// dataSourceColumns 内置表允许查询的字段, 未配置 DATASOURCE_TABLES 时允许查询这些表以及全部模型表
+var dataSourceColumns = map[string][]string{
+	"category":     {"id", "site_id", "parentid", "topid", "model_id", "catname", "type", "keywords", "description", "thumb", "dir", "url", "listorder", "ismenu", "created_at", "updated_at", "deleted_time"},
+	"tags":         {"id", "name", "ref_num", "clicks", "seo_title", "seo_keywords", "seo_description", "listorder", "status", "created_at"},
+	"link":         {"id", "linktype", "name", "url", "logo", "introduce", "listorder", "passed", "addtime"},
+	"advert":       {"id", "name", "space_id", "link_url", "image", "listorder", "start_time", "end_time", "status"},
+	"advert_space": {"id", "name", "key", "remark"},
+	"page":         {"id", "title", "keywords", "description", "content", "created_at", "updated_at"},
+}
+
+// dataSourceModelColumns 模型表固定允许查询的字段, 另外允许模型中启用的字段
+var dataSourceModelColumns = []string{"id", "catid", "title", "thumb", "keywords", "description", "content", "listorder", "status", "recommend", "tags", "visit_count", "created_at", "updated_at", "deleted_time"}
+
+// compiledDataSources 校验通过的数据源, 按定义版本缓存, 渲染时不再重复校验
+var compiledDataSources sync.Map
+
+var dataSourceNamePattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_]*$`)
+
+var dataSourceOps = []string{"=", "<>", ">", ">=", "<", "<=", "LIKE", "IN", "NULL", "NOTNULL"}
+
+type DataSourceModel struct {
+	orm *xorm.Engine
+}
+
+func NewDataSourceModel() *DataSourceModel {
+	return &DataSourceModel{orm: helper.GetORM()}
+}
+
+// DataSourceQuery 校验通过的数据源查询
+type DataSourceQuery struct {
+	*tables.DataSource
+	table string // 带前缀的表名
+	key   string // 定义版本
+}
+
+// AllowedTables 允许数据源查询的表(不含前缀)及字段白名单.
+// DATASOURCE_TABLES 格式为 表名[:字段|字段], 逗号分隔, 未列出字段时使用内置表或模型表的白名单
+func (m *DataSourceModel) AllowedTables() map[string][]string {
+	models := map[string]int64{}
+	for _, model := range NewDocumentModel().GetAllForBE() {
+		models[model.Table] = model.Id
+	}
+	builtin := func(table string) []string {
+		if cols, ok := dataSourceColumns[table]; ok {
+			return cols
+		}
+		if mid, ok := models[table]; ok {
+			return m.modelColumns(mid)
+		}
+		return nil
+	}
+	list := map[string][]string{}
+	if setting := config.GetSiteConfigByKey("DATASOURCE_TABLES"); len(setting) > 0 {
+		for _, item := range strings.Split(setting, ",") {
+			table, cols, _ := strings.Cut(strings.TrimSpace(item), ":")
+			if len(table) == 0 {
+				continue
+			}
+			if len(cols) == 0 {
+				list[table] = builtin(table)
+				continue
+			}
+			for _, col := range strings.Split(cols, "|") {
+				if col = strings.TrimSpace(col); len(col) > 0 {
+					list[table] = append(list[table], col)
+				}
+			}
+		}
+		return list
+	}
+	for table := range dataSourceColumns {
+		list[table] = builtin(table)
+	}
+	for table := range models {
+		list[table] = builtin(table)
+	}
+	return list
+}
+
+// modelColumns 模型表允许查询的字段
+func (m *DataSourceModel) modelColumns(mid int64) []string {
+	cols := slices.Clone(dataSourceModelColumns)
+	for _, field := range NewDocumentFieldDslModel().GetList(mid) {
+		if field.Status && len(field.TableField) > 0 && !slices.Contains(cols, field.TableField) {
+			cols = append(cols, field.TableField)
+		}
+	}
+	return cols
+}
+
+// QueryColumns 数据表允许查询的字段, 白名单与实际字段的交集
+func (m *DataSourceModel) QueryColumns(table string) []string {
+	allowed, ok := m.AllowedTables()[table]
+	if !ok {
+		return nil
+	}
+	var list []string
+	for _, col := range m.TableColumns(table) {
+		if slices.Contains(allowed, col) {
+			list = append(list, col)
+		}
+	}
+	return list
+}
+
+// TableColumns 读取数据表字段
+func (m *DataSourceModel) TableColumns(table string) []string {
+	tableName := controllers.GetTableName(table)
+	var fields []string
+	_ = helper.Cache().Remember(fmt.Sprintf(controllers.CacheTableNameFields, tableName), &fields, func() (any, error) {
+		fields, err := dialect.Columns(m.orm, tableName)
+		if err != nil {
+			return nil, fmt.Errorf("读取数据表%s字段失败: %w", tableName, err)
+		}
+		return &fields, nil
+	}, 3600)
+	return fields
+}
+
+// Validate 校验数据源定义, 表与字段必须在白名单内
+func (m *DataSourceModel) Validate(ds *tables.DataSource) error {
+	if !dataSourceNamePattern.MatchString(ds.Name) {
+		return errors.New("调用名称只能包含字母数字下划线, 且以字母开头")
+	}
+	if _, ok := m.AllowedTables()[ds.Table]; !ok {
+		return fmt.Errorf("数据表%s不允许查询", ds.Table)
+	}
+	columns := m.QueryColumns(ds.Table)
+	if len(columns) == 0 {
+		return fmt.Errorf("数据表%s不存在或未配置可查询字段", ds.Table)
+	}
+	for _, col := range ds.Columns {
+		if !slices.Contains(columns, col) {
+			return fmt.Errorf("字段%s不允许查询", col)
+		}
+	}
+	for _, cond := range ds.Conditions {
+		if !slices.Contains(columns, cond.Column) {
+			return fmt.Errorf("条件字段%s不允许查询", cond.Column)
+		}
+		if !slices.Contains(dataSourceOps, strings.ToUpper(cond.Op)) {
+			return fmt.Errorf("条件操作符%s不支持", cond.Op)
+		}
+	}
+	if len(ds.OrderBy) > 0 {
+		for _, order := range strings.Split(ds.OrderBy, ",") {
+			parts := strings.Fields(order)
+			if len(parts) == 0 || len(parts) > 2 || !slices.Contains(columns, parts[0]) ||
+				(len(parts) == 2 && !slices.Contains([]string{"ASC", "DESC"}, strings.ToUpper(parts[1]))) {
+				return fmt.Errorf("排序%s不正确", order)
+			}
+		}
+	}
+	if ds.Limit <= 0 || ds.Limit > MaxDataSourceRows {
+		return fmt.Errorf("返回行数需在1-%d之间", MaxDataSourceRows)
+	}
+	return nil
+}
+
+// Compile 校验数据源并按名称缓存, 定义更新或白名单设置变化后重新校验
+func (m *DataSourceModel) Compile(ds *tables.DataSource) (*DataSourceQuery, error) {
+	key := fmt.Sprintf("%d.%s.%s", ds.Id, ds.UpdatedAt.String(), config.GetSiteConfigByKey("DATASOURCE_TABLES"))
+	if ds.Id > 0 {
+		if q, ok := compiledDataSources.Load(ds.Name); ok && q.(*DataSourceQuery).key == key {
+			return q.(*DataSourceQuery), nil
+		}
+	}
+	if err := m.Validate(ds); err != nil {
+		return nil, err
+	}
+	q := &DataSourceQuery{DataSource: ds, table: controllers.GetTableName(ds.Table), key: key}
+	if ds.Id > 0 {
+		compiledDataSources.Store(ds.Name, q)
+	}
+	return q, nil
+}
+
+// GetByName 获取启用的数据源
+func (m *DataSourceModel) GetByName(name string) *tables.DataSource {
+	ds := &tables.DataSource{}
+	if exist, _ := m.orm.Where("name = ?", name).Where("status = ?", true).Get(ds); !exist {
+		return nil
+	}
+	return ds
+}
+
+func (m *DataSourceModel) buildCond(ds *tables.DataSource, params map[string]any) builder.Cond {
+	cond := builder.NewCond()
+	for _, c := range ds.Conditions {
+		val := c.Value
+		if v, ok := params[c.Param]; ok && len(c.Param) > 0 && v != nil && fmt.Sprint(v) != "" {
+			val = fmt.Sprint(v)
+		}
+		op := strings.ToUpper(c.Op)
+		if len(val) == 0 && op != "NULL" && op != "NOTNULL" {
+			continue // 参数未传入且无默认值时忽略条件
+		}
+		switch op {
+		case "=":
+			cond = cond.And(builder.Eq{c.Column: val})
+		case "<>":
+			cond = cond.And(builder.Neq{c.Column: val})
+		case ">":
+			cond = cond.And(builder.Gt{c.Column: val})
+		case ">=":
+			cond = cond.And(builder.Gte{c.Column: val})
+		case "<":
+			cond = cond.And(builder.Lt{c.Column: val})
+		case "<=":
+			cond = cond.And(builder.Lte{c.Column: val})
+		case "LIKE":
+			cond = cond.And(builder.Like{c.Column, val})
+		case "IN":
+			cond = cond.And(builder.In(c.Column, helper.ConvertToAnySlice(strings.Split(val, ","))...))
+		case "NULL":
+			cond = cond.And(builder.IsNull{c.Column})
+		case "NOTNULL":
+			cond = cond.And(builder.NotNull{c.Column})
+		}
+	}
+	return cond
+}
+
+// Execute 执行已校验的数据源查询, params为模板传入的参数, page参数用于分页
+func (m *DataSourceModel) Execute(ds *DataSourceQuery, params map[string]any) ([]map[string]string, error) {
+	query := func() ([]map[string]string, error) {
+		orm := m.orm
+		if ds.ReadOnly {
+			orm = config.ReadonlyDB()
+		}
+		sess := orm.Table(ds.table).Where(m.buildCond(ds.DataSource, params))
+		if ds.Count {
+			total, err := sess.Count()
+			return []map[string]string{{"total": fmt.Sprint(total)}}, err
+		}
+		if len(ds.Columns) > 0 {
+			sess.Cols(ds.Columns...)
+		}
+		if len(ds.OrderBy) > 0 {
+			sess.OrderBy(ds.OrderBy)
+		}
+		page := 1
+		if v, ok := params["page"]; ok {
+			fmt.Sscan(fmt.Sprint(v), &page)
+		}
+		return sess.Limit(ds.Limit, (max(page, 1)-1)*ds.Limit).QueryString()
+	}
+	if ds.CacheTtl <= 0 {
+		return query()
+	}
+	byts, _ := sonic.Marshal(params)
+	key := fmt.Sprintf(controllers.CacheDataSource, ds.Name, helper.GetMd5(ds.UpdatedAt.String()+string(byts)))
+	var rows []map[string]string
+	err := helper.Cache().Remember(key, &rows, func() (any, error) {
+		rows, err := query()
+		return &rows, err
+	}, ds.CacheTtl)
+	return rows, err
+}
@@ -39,7 +39,7 @@ func (l *LogModel) DeleteAll() bool {
 }
 
 func (l *LogModel) DeleteBeforeByDate(date string) bool {
-	res, err := l.orm.Where("`time` <= ? ", date).Delete(&tables.Log{})
+	res, err := l.orm.Where(l.orm.Quote("time")+" <= ?", date).Delete(&tables.Log{})
 	if err != nil {
 		pine.Logger().Error(err.Error())
 		return false
@@ -22,4 +22,5 @@ type Admin struct {
 	Sex           uint       `json:"sex"`
 
 	RoleIdList []int64 `json:"roleIdList" xorm:"json roles"`
+	SiteIds    []int64 `json:"site_ids" xorm:"json sites"` // 可管理的站点, 为空不限制
 }
@@ -2,6 +2,7 @@ package tables
 
 type AdvertSpace struct {
 	Id     int64  `json:"id"`
+	SiteId int64  `json:"site_id" xorm:"default 0 index comment('所属站点 0=默认站点')"`
 	Name   string `json:"name"`
 	Key    string `json:"key"`
 	Remark string `json:"remark"`
@@ -0,0 +1,14 @@
+package tables
+
+// ApiKey 内容接口调用凭证
+type ApiKey struct {
+	Id         int64      `json:"id" xorm:"pk autoincr"`
+	Name       string     `json:"name" xorm:"varchar(50) not null comment('名称')" validate:"required"`
+	Key        string     `json:"key" xorm:"'api_key' varchar(64) not null unique comment('调用凭证')"`
+	RateLimit  int        `json:"rate_limit" xorm:"default 60 comment('每分钟请求数')"`
+	Status     bool       `json:"status" xorm:"default 1 comment('状态')"`
+	Remark     string     `json:"remark" xorm:"varchar(255) comment('备注')"`
+	LastUsedAt *LocalTime `json:"last_used_at" xorm:"comment('最后调用时间')"`
+	CreatedAt  LocalTime  `json:"created_at" xorm:"created"`
+	UpdatedAt  LocalTime  `json:"updated_at" xorm:"updated"`
+}
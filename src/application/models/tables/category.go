@@ -3,6 +3,7 @@ package tables
 // Category 分类
 type Category struct {
 	Catid       int64          `xorm:"pk autoincr id" json:"id"`
+	SiteId      int64          `json:"site_id" xorm:"default 0 index comment('所属站点 0=默认站点')"`
 	Parentid    int64          `json:"parentId" xorm:"comment('所属栏目ID')"`
 	Topid       int64          `json:"topid" xorm:"comment('顶级栏目ID')"`
 	ModelId     int64          `json:"model_id" xorm:"comment('绑定模型ID')"`
@@ -21,6 +22,7 @@ type Category struct {
 	UrlPrefix   string         `xorm:"-" json:"url_prefix"`
 	CreatedAt   *LocalTime     `json:"created_at" xorm:"created"`
 	UpdatedAt   *LocalTime     `json:"updated_at" xorm:"updated"`
+	DeletedTime *LocalTime     `json:"deleted_time" xorm:"deleted index"`
 	Active      bool           `xorm:"-"`
 	HasSon      bool           `xorm:"-"`
 	Model       *DocumentModel `xorm:"-" json:"model"`
@@ -0,0 +1,27 @@
+package tables
+
+// DataSourceCondition 数据源查询条件
+type DataSourceCondition struct {
+	Column string `json:"column"`
+	Op     string `json:"op"`    // = <> > >= < <= LIKE IN NULL NOTNULL
+	Param  string `json:"param"` // 模板传入的参数名, 为空时使用Value
+	Value  string `json:"value"` // 固定值, 或参数未传入时的默认值
+}
+
+// DataSource 模板数据源, 模板通过名称调用后台定义的查询
+type DataSource struct {
+	Id         int64                 `json:"id" xorm:"pk autoincr"`
+	Name       string                `json:"name" xorm:"varchar(50) not null unique comment('调用名称')" validate:"required"`
+	Title      string                `json:"title" xorm:"varchar(100) comment('说明')"`
+	Table      string                `json:"table" xorm:"'table_name' varchar(64) not null comment('数据表, 不含前缀')" validate:"required"`
+	Columns    []string              `json:"columns" xorm:"json comment('查询字段')"`
+	Conditions []DataSourceCondition `json:"conditions" xorm:"json comment('查询条件')"`
+	OrderBy    string                `json:"order_by" xorm:"varchar(100) comment('排序, 如 id desc')"`
+	Count      bool                  `json:"count" xorm:"comment('只返回记录数total')"`
+	Limit      int                   `json:"limit" xorm:"'row_limit' default 10 comment('最大返回行数')"`
+	CacheTtl   int                   `json:"cache_ttl" xorm:"default 0 comment('缓存秒数, 0不缓存')"`
+	ReadOnly   bool                  `json:"read_only" xorm:"comment('使用只读数据库连接')"`
+	Status     bool                  `json:"status" xorm:"default 1 comment('状态')"`
+	CreatedAt  LocalTime             `json:"created_at" xorm:"created"`
+	UpdatedAt  LocalTime             `json:"updated_at" xorm:"updated"`
+}
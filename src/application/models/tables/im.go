@@ -0,0 +1,46 @@
+package tables
+
+const (
+	ImSessionWaiting = iota // 等待接入
+	ImSessionServing        // 接待中
+	ImSessionClosed         // 已结束
+)
+
+const (
+	ImSenderVisitor = iota // 访客
+	ImSenderAdmin          // 客服
+	ImSenderSystem         // 系统
+)
+
+// ImSession 客服会话
+type ImSession struct {
+	Id            int64     `json:"id" xorm:"pk autoincr"`
+	Channel       string    `json:"channel" xorm:"varchar(20) default 'web' comment('来源渠道 web wechat')"`
+	Appid         string    `json:"appid" xorm:"varchar(20) comment('渠道账号')"`
+	VisitorId     string    `json:"visitor_id" xorm:"varchar(64) not null index comment('访客标识')"`
+	Nickname      string    `json:"nickname" xorm:"varchar(50) comment('访客昵称')"`
+	Avatar        string    `json:"avatar" xorm:"varchar(255) comment('访客头像')"`
+	AdminId       int64     `json:"admin_id" xorm:"index comment('接待客服')"`
+	Status        uint      `json:"status" xorm:"tinyint(1) default 0 comment('0=等待接入 1=接待中 2=已结束')"`
+	LastMessage   string    `json:"last_message" xorm:"varchar(255) comment('最后一条消息')"`
+	LastTime      LocalTime `json:"last_time" xorm:"datetime comment('最后消息时间')"`
+	AdminUnread   int       `json:"admin_unread" xorm:"default 0 comment('客服未读数')"`
+	VisitorUnread int       `json:"visitor_unread" xorm:"default 0 comment('访客未读数')"`
+	CreatedAt     LocalTime `json:"created_at" xorm:"created"`
+	UpdatedAt     LocalTime `json:"updated_at" xorm:"updated"`
+}
+
+// ImMessage 客服消息
+type ImMessage struct {
+	Id        int64     `json:"id" xorm:"pk autoincr"`
+	SessionId int64     `json:"session_id" xorm:"index comment('会话ID')"`
+	Sender    uint      `json:"sender" xorm:"tinyint(1) comment('0=访客 1=客服 2=系统')"`
+	AdminId   int64     `json:"admin_id" xorm:"comment('发送消息的客服')"`
+	MsgType   string    `json:"msg_type" xorm:"varchar(10) default 'text' comment('text image file')"`
+	Content   string    `json:"content" xorm:"text comment('消息内容')"`
+	Url       string    `json:"url" xorm:"varchar(255) comment('图片或文件地址')"`
+	FileName  string    `json:"file_name" xorm:"varchar(100) comment('文件名')"`
+	FileSize  int64     `json:"file_size" xorm:"comment('文件大小')"`
+	IsRead    bool      `json:"is_read" xorm:"comment('接收方是否已读')"`
+	CreatedAt LocalTime `json:"created_at" xorm:"created"`
+}
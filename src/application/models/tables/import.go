@@ -0,0 +1,42 @@
+package tables
+
+// ImportJob 内容导入任务
+type ImportJob struct {
+	Id         int64     `json:"id" xorm:"pk autoincr"`
+	SiteId     int64     `json:"site_id" xorm:"default 0 comment('所属站点')"`
+	Source     string    `json:"source" xorm:"varchar(20) not null comment('数据源类型 wxr/csv/json')"`
+	SourceKey  string    `json:"source_key" xorm:"varchar(150) comment('来源标识, 同一标识下的来源ID只导入一次')"`
+	File       string    `json:"file" xorm:"varchar(255) comment('导入文件')"`
+	OriginName string    `json:"origin_name" xorm:"varchar(255) comment('原始文件名')"`
+	ModelId    int64     `json:"model_id" xorm:"comment('导入的模型')"`
+	Catid      int64     `json:"catid" xorm:"comment('默认栏目')"`
+	Mapping    string    `json:"mapping" xorm:"text comment('字段映射 {列名:表字段}')"`
+	IdField    string    `json:"id_field" xorm:"varchar(100) comment('来源ID所在列')"`
+	Status     int       `json:"status" xorm:"tinyint(1) default 0 comment('0=待导入 1=导入中 2=完成 3=失败')"`
+	Cursor     int64     `json:"cursor" xorm:"comment('已处理的记录数, 用于断点续导')"`
+	Total      int64     `json:"total" xorm:"comment('总记录数')"`
+	Success    int64     `json:"success" xorm:"comment('成功数')"`
+	Failed     int64     `json:"failed" xorm:"comment('失败数')"`
+	Skipped    int64     `json:"skipped" xorm:"comment('已导入而跳过的数量')"`
+	Error      string    `json:"error" xorm:"text comment('任务错误')"`
+	AdminId    int64     `json:"admin_id" xorm:"comment('操作人')"`
+	CreatedAt  LocalTime `json:"created_at" xorm:"created"`
+	UpdatedAt  LocalTime `json:"updated_at" xorm:"updated"`
+}
+
+// ImportItem 导入记录, 按来源标识+类型+来源ID保证幂等
+type ImportItem struct {
+	Id        int64     `json:"id" xorm:"pk autoincr"`
+	JobId     int64     `json:"job_id" xorm:"index comment('最后处理的任务')"`
+	SourceKey string    `json:"source_key" xorm:"varchar(150) unique(source_item) comment('来源标识')"`
+	Kind      string    `json:"kind" xorm:"varchar(20) unique(source_item) comment('category/page/tag/attachment/document')"`
+	SourceId  string    `json:"source_id" xorm:"varchar(150) unique(source_item) comment('来源ID')"`
+	Line      int64     `json:"line" xorm:"comment('所在行/序号')"`
+	Origin    string    `json:"origin" xorm:"varchar(500) comment('来源地址')"`
+	TargetId  int64     `json:"target_id" xorm:"comment('导入后的ID')"`
+	Target    string    `json:"target" xorm:"varchar(500) comment('导入后的地址')"`
+	Status    bool      `json:"status" xorm:"comment('是否成功')"`
+	Error     string    `json:"error" xorm:"text comment('失败原因')"`
+	CreatedAt LocalTime `json:"created_at" xorm:"created"`
+	UpdatedAt LocalTime `json:"updated_at" xorm:"updated"`
+}
@@ -2,6 +2,7 @@ package tables
 
 type Link struct {
 	Id        int64     `xorm:"int(11) autoincr not null pk 'id'" json:"id" schema:"id"`
+	SiteId    int64     `xorm:"default 0 index comment('所属站点 0=默认站点')" json:"site_id" schema:"-"`
 	Linktype  int       `xorm:"tinyint(3) not null 'linktype'" json:"linktype" schema:"linktype"`
 	Name      string    `xorm:"varchar(50) not null 'name'" json:"name" schema:"name"`
 	Url       string    `xorm:"varchar(255) not null 'url'" json:"url" schema:"url"`
@@ -0,0 +1,17 @@
+package tables
+
+// ModelMigration 模型表结构迁移记录
+type ModelMigration struct {
+	Id             int64     `json:"id" xorm:"pk autoincr"`
+	Mid            int64     `json:"mid" xorm:"index comment('模型ID')"`
+	Table          string    `json:"table" xorm:"varchar(100) comment('模型表名, 不含前缀')"`
+	Steps          string    `json:"steps" xorm:"longtext comment('迁移步骤 JSON, 含回滚SQL')"`
+	Fields         string    `json:"fields" xorm:"text comment('执行后的字段快照 {dsl_id:表字段}, 用于识别重命名')"`
+	Checksum       string    `json:"checksum" xorm:"varchar(64) comment('迁移步骤校验和')"`
+	SchemaChecksum string    `json:"schema_checksum" xorm:"varchar(64) comment('执行后的表结构校验和')"`
+	Warnings       string    `json:"warnings" xorm:"text comment('数据丢失提示')"`
+	RolledBack     bool      `json:"rolled_back" xorm:"tinyint(1) default 0 comment('是否已回滚')"`
+	AdminId        int64     `json:"admin_id" xorm:"comment('操作人')"`
+	CreatedAt      LocalTime `json:"created_at" xorm:"created"`
+	UpdatedAt      LocalTime `json:"updated_at" xorm:"updated"`
+}
@@ -20,3 +20,11 @@ type Plugin struct {
 	CreatedAt   LocalTime        `json:"created_at"`
 	UpdatedAt   LocalTime        `json:"updated_at"`
 }
+
+// PluginMigration 插件已执行的迁移
+type PluginMigration struct {
+	Id        int64     `json:"id"`
+	Sign      string    `json:"sign" xorm:"varchar(100) index comment('插件标志')"`
+	Version   string    `json:"version" xorm:"varchar(100) comment('迁移版本')"`
+	CreatedAt LocalTime `json:"created_at" xorm:"created"`
+}
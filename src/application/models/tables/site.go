@@ -0,0 +1,18 @@
+package tables
+
+// Site 站点, 按访问域名区分, 未匹配到站点时使用默认站点
+type Site struct {
+	Id           int64             `json:"id" xorm:"pk autoincr"`
+	Name         string            `json:"name" xorm:"varchar(50) not null comment('站点名称')" validate:"required"`
+	Host         string            `json:"host" xorm:"varchar(100) not null unique comment('主域名')" validate:"required"`
+	Aliases      []string          `json:"aliases" xorm:"json comment('别名域名')"`
+	Theme        string            `json:"theme" xorm:"varchar(50) comment('主题目录, 为空使用全局主题')"`
+	StaticDir    string            `json:"static_dir" xorm:"varchar(255) comment('静态页面目录')"`
+	UploadEngine string            `json:"upload_engine" xorm:"varchar(30) comment('存储驱动, 为空使用全局配置')"`
+	Settings     map[string]string `json:"settings" xorm:"json comment('覆盖全局的站点配置')"`
+	IsDefault    bool              `json:"is_default" xorm:"comment('默认站点')"`
+	Status       bool              `json:"status" xorm:"default 1 comment('状态')"`
+	Listorder    int64             `json:"listorder" xorm:"default 0 comment('排序')"`
+	CreatedAt    LocalTime         `json:"created_at" xorm:"created"`
+	UpdatedAt    LocalTime         `json:"updated_at" xorm:"updated"`
+}
@@ -2,6 +2,7 @@ package tables
 
 type Tags struct {
 	Id             int64     `xorm:"pk autoincr id" json:"id"`
+	SiteId         int64     `json:"site_id" xorm:"default 0 index comment('所属站点 0=默认站点')"`
 	Name           string    `json:"name"`
 	RefNum         uint      `json:"ref_num"`
 	Clicks         uint      `json:"clicks"`
@@ -0,0 +1,13 @@
+package tables
+
+// TemplateHistory 模板修改历史, 保存每次修改前的内容
+type TemplateHistory struct {
+	Id        int64     `json:"id" xorm:"pk autoincr"`
+	Theme     string    `json:"theme" xorm:"varchar(50) index(theme_name) comment('主题')"`
+	Name      string    `json:"name" xorm:"varchar(191) index(theme_name) comment('模板路径')"`
+	Content   string    `json:"content,omitempty" xorm:"longtext comment('修改前内容')"`
+	Size      int       `json:"size" xorm:"comment('内容长度')"`
+	AdminId   int64     `json:"admin_id" xorm:"comment('操作人')"`
+	Remark    string    `json:"remark" xorm:"varchar(255) comment('备注')"`
+	CreatedAt LocalTime `json:"created_at" xorm:"created"`
+}
@@ -1,12 +1,20 @@
 package tables
 
+const (
+	WechatPlatformOfficial = iota // 公众号
+	WechatPlatformMini            // 小程序
+	WechatPlatformWork            // 企业微信
+)
+
 type WechatAccount struct {
 	Id       int64  `json:"id" xorm:"pk autoincr"`
-	AppId    string `json:"appid" xorm:"char(20) not null comment('appid')"`
+	AppId    string `json:"appid" xorm:"char(20) not null comment('appid, 企业微信为corpid')"`
 	Name     string `json:"name" xorm:"varchar(50) not null comment('公众号名称')"`
+	Platform uint   `json:"platform" xorm:"tinyint(1) default 0 comment('平台: 0=公众号 1=小程序 2=企业微信')"`
 	Type     uint   `json:"type" xorm:"tinyint(1) comment('账号类型')"`
 	Verified bool   `json:"verified" xorm:"tinyint(1) comment('认证状态')"`
-	Secret   string `json:"secret" xorm:"char(32)"`
+	Secret   string `json:"secret" xorm:"varchar(64)"`
+	AgentId  string `json:"agent_id" xorm:"varchar(20) comment('企业微信应用AgentId')"`
 	Token    string `json:"token" xorm:"varchar(32)"`
 	AesKey   string `json:"aesKey" xorm:"varchar(43)"`
 }
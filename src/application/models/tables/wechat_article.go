@@ -0,0 +1,17 @@
+package tables
+
+// WechatArticle CMS文档与微信图文(草稿)的映射关系
+type WechatArticle struct {
+	Id           int64     `json:"id" xorm:"pk autoincr"`
+	Appid        string    `json:"appid" xorm:"char(20) not null comment('appid')"`
+	Mid          int64     `json:"mid" xorm:"comment('模型ID')"`
+	Aid          int64     `json:"aid" xorm:"comment('文档ID')"`
+	Title        string    `json:"title" xorm:"varchar(100) comment('标题')"`
+	MediaId      string    `json:"media_id" xorm:"varchar(64) comment('草稿media_id')"`
+	ThumbMediaId string    `json:"thumb_media_id" xorm:"varchar(64) comment('封面media_id')"`
+	ArticleId    string    `json:"article_id" xorm:"varchar(64) comment('发布后的article_id')"`
+	PublishId    int64     `json:"publish_id" xorm:"comment('发布任务ID')"`
+	AutoSync     bool      `json:"auto_sync" xorm:"comment('文档修改后自动推送更新')"`
+	CreatedAt    LocalTime `json:"created_at" xorm:"created"`
+	UpdatedAt    LocalTime `json:"updated_at" xorm:"updated"`
+}
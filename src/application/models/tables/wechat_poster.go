@@ -0,0 +1,17 @@
+package tables
+
+import "github.com/xiusin/pinecms/src/common/poster"
+
+// WechatPoster 粉丝海报模板
+type WechatPoster struct {
+	Id            int64            `json:"id" xorm:"pk autoincr"`
+	Appid         string           `json:"appid" xorm:"char(20) not null comment('appid')"`
+	Name          string           `json:"name" xorm:"varchar(50) comment('海报名称')"`
+	Keyword       string           `json:"keyword" xorm:"varchar(50) comment('触发关键字')"`
+	SceneStr      string           `json:"scene_str" xorm:"varchar(64) comment('二维码场景值, 支持{openid}')"`
+	ExpireSeconds int64            `json:"expire_seconds" xorm:"comment('二维码有效期, 0=永久二维码')"`
+	Design        *poster.Template `json:"design" xorm:"json comment('海报设计')"`
+	Status        bool             `json:"status" xorm:"comment('是否启用')"`
+	CreatedAt     LocalTime        `json:"created_at" xorm:"created"`
+	UpdatedAt     LocalTime        `json:"updated_at" xorm:"updated"`
+}
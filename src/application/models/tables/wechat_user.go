@@ -19,4 +19,6 @@ type WechatMember struct {
 	SubscribeScene string  `json:"subscribe_scene"`
 	QrSceneStr     string  `json:"qr_scene_str"`
 	Poster         string  `json:"poster"`
+	MemberId       int64   `json:"member_id" xorm:"comment('绑定的前台会员ID')"`
+	SessionKey     string  `json:"-" xorm:"varchar(64) comment('小程序session_key')"`
 }
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"github.com/xiusin/pinecms/src/application/models/tables"
+	"github.com/xiusin/pinecms/src/common/helper"
+	"xorm.io/xorm"
+)
+
+// MaxTemplateHistory 每个模板保留的历史版本数
+const MaxTemplateHistory = 50
+
+type TemplateHistoryModel struct {
+	orm *xorm.Engine
+}
+
+func NewTemplateHistoryModel() *TemplateHistoryModel {
+	return &TemplateHistoryModel{orm: helper.GetORM()}
+}
+
+// Save 保存修改前的模板内容, 超出保留数量的旧版本会被清理
+func (m *TemplateHistoryModel) Save(theme, name, content string, adminId int64, remark string) error {
+	if _, err := m.orm.InsertOne(&tables.TemplateHistory{
+		Theme:   theme,
+		Name:    name,
+		Content: content,
+		Size:    len(content),
+		AdminId: adminId,
+		Remark:  remark,
+	}); err != nil {
+		return err
+	}
+	var ids []int64
+	m.orm.Table(&tables.TemplateHistory{}).Where("theme = ?", theme).Where("name = ?", name).
+		Desc("id").Limit(1000, MaxTemplateHistory).Cols("id").Find(&ids)
+	if len(ids) > 0 {
+		_, err := m.orm.In("id", ids).Delete(&tables.TemplateHistory{})
+		return err
+	}
+	return nil
+}
+
+// List 模板历史版本, 不包含内容
+func (m *TemplateHistoryModel) List(theme, name string) ([]tables.TemplateHistory, error) {
+	var list []tables.TemplateHistory
+	err := m.orm.Where("theme = ?", theme).Where("name = ?", name).Omit("content").Desc("id").Find(&list)
+	return list, err
+}
+
+func (m *TemplateHistoryModel) Get(id int64) *tables.TemplateHistory {
+	history := &tables.TemplateHistory{}
+	if exist, _ := m.orm.ID(id).Get(history); !exist {
+		return nil
+	}
+	return history
+}
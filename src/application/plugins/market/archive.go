@@ -0,0 +1,138 @@
+package market
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// maxArchiveFile 解压时单个文件的大小上限
+const maxArchiveFile = 512 << 20
+
+// Extract 解压 tar.gz 到目录, 拒绝链接文件以及跳出目录的路径
+func Extract(archive, dest string) error {
+	f, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("插件包格式错误: %w", err)
+	}
+	defer gz.Close()
+	if err := os.MkdirAll(dest, os.ModePerm); err != nil {
+		return err
+	}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("插件包格式错误: %w", err)
+		}
+		name := filepath.FromSlash(hdr.Name)
+		if !filepath.IsLocal(name) {
+			return fmt.Errorf("插件包包含非法路径%s", hdr.Name)
+		}
+		target := filepath.Join(dest, name)
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, os.ModePerm); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if hdr.Size > maxArchiveFile {
+				return fmt.Errorf("插件包文件%s过大", hdr.Name)
+			}
+			if err := writeFile(target, tr, fs.FileMode(hdr.Mode).Perm()|0600); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("插件包不支持文件类型%s", hdr.Name)
+		}
+	}
+}
+
+func writeFile(path string, r io.Reader, mode fs.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, io.LimitReader(r, maxArchiveFile)); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Pack 将插件目录打包为 tar.gz, 返回包的 sha256 摘要与大小
+func Pack(dir, out string) (sum []byte, size int64, err error) {
+	f, err := os.Create(out)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+	hash := sha256.New()
+	gz := gzip.NewWriter(io.MultiWriter(f, hash))
+	tw := tar.NewWriter(gz)
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || path == dir {
+			return err
+		}
+		if d.Type()&fs.ModeSymlink != 0 {
+			return errors.New("插件目录不能包含链接文件: " + path)
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		hdr, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		hdr.Name = filepath.ToSlash(rel)
+		if d.IsDir() {
+			hdr.Name += "/"
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		src, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		_, err = io.Copy(tw, src)
+		return err
+	})
+	if err == nil {
+		err = tw.Close()
+	}
+	if err == nil {
+		err = gz.Close()
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return nil, 0, err
+	}
+	return hash.Sum(nil), info.Size(), nil
+}
@@ -0,0 +1,97 @@
+package market
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeArchive 按给定的条目生成 tar.gz
+func writeArchive(t *testing.T, headers ...*tar.Header) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "plugin.tar.gz")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for _, hdr := range headers {
+		if hdr.Typeflag == tar.TypeReg {
+			hdr.Size = int64(len(hdr.Name))
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if hdr.Typeflag == tar.TypeReg {
+			if _, err := tw.Write([]byte(hdr.Name)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestExtract(t *testing.T) {
+	dest := t.TempDir()
+	archive := writeArchive(t,
+		&tar.Header{Name: "task/", Typeflag: tar.TypeDir, Mode: 0755},
+		&tar.Header{Name: "task/config.json", Typeflag: tar.TypeReg, Mode: 0644},
+	)
+	if err := Extract(archive, dest); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dest, "task", "config.json")); err != nil || string(data) != "task/config.json" {
+		t.Fatalf("解压内容错误: %q %v", data, err)
+	}
+}
+
+func TestExtractRejects(t *testing.T) {
+	cases := map[string]*tar.Header{
+		"上级目录": {Name: "../evil.so", Typeflag: tar.TypeReg, Mode: 0644},
+		"绝对路径": {Name: "/tmp/evil.so", Typeflag: tar.TypeReg, Mode: 0644},
+		"符号链接": {Name: "link", Typeflag: tar.TypeSymlink, Linkname: "/etc/passwd"},
+		"硬链接":  {Name: "hard", Typeflag: tar.TypeLink, Linkname: "/etc/passwd"},
+	}
+	for name, hdr := range cases {
+		root := t.TempDir()
+		dest := filepath.Join(root, "plugins", "task")
+		if err := Extract(writeArchive(t, hdr), dest); err == nil {
+			t.Errorf("%s: 应拒绝解压", name)
+		}
+		if _, err := os.Stat(filepath.Join(root, "plugins", "evil.so")); err == nil {
+			t.Errorf("%s: 文件写到了插件目录之外", name)
+		}
+	}
+}
+
+func TestPackExtract(t *testing.T) {
+	src := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(src, "lib"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "lib", "plugin.so"), []byte("so"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	archive := filepath.Join(t.TempDir(), "out.tar.gz")
+	sum, size, err := Pack(src, archive)
+	if err != nil || len(sum) == 0 || size == 0 {
+		t.Fatalf("打包失败: %v", err)
+	}
+	dest := t.TempDir()
+	if err := Extract(archive, dest); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dest, "lib", "plugin.so")); string(data) != "so" {
+		t.Fatal("解压内容与打包内容不一致")
+	}
+}
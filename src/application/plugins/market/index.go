@@ -0,0 +1,111 @@
+// Package market 插件市场客户端, 读取仓库索引, 按系统版本/Go版本/平台解析插件版本与依赖,
+// 下载后校验 sha256 与 ed25519 签名, 在进程内解压到插件目录.
+package market
+
+import (
+	"fmt"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/xiusin/pinecms/cmd/version"
+)
+
+// IndexFile 仓库根目录下的索引文件
+const IndexFile = "index.json"
+
+// Index 仓库索引
+type Index struct {
+	Name    string    `json:"name"`
+	Updated string    `json:"updated"`
+	Plugins []*Plugin `json:"plugins"`
+}
+
+// Plugin 插件, Name 同时是插件目录名
+type Plugin struct {
+	Name        string     `json:"name"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Author      string     `json:"author"`
+	Releases    []*Release `json:"releases"`
+}
+
+// Release 插件版本
+type Release struct {
+	Version      string            `json:"version"`
+	PineCMS      string            `json:"pinecms"`      // 兼容的系统版本约束, 如 ">=0.2.0 <1.0.0"
+	Go           string            `json:"go"`           // 兼容的Go版本约束, .so 插件需与主程序一致, 如 "=1.23.4"
+	Runtime      string            `json:"runtime"`      // so 或 rpc
+	Platforms    []string          `json:"platforms"`    // 如 linux/amd64, 为空不限
+	Dependencies map[string]string `json:"dependencies"` // 依赖插件名 => 版本约束
+	Archive      string            `json:"archive"`      // tar.gz 包, 相对仓库根目录的路径或完整URL
+	Size         int64             `json:"size"`
+	Sha256       string            `json:"sha256"`    // 十六进制
+	Signature    string            `json:"signature"` // base64, 对插件名、版本与 sha256 摘要的 ed25519 签名
+}
+
+// Env 当前运行环境
+type Env struct {
+	PineCMS  string
+	Go       string
+	Platform string
+}
+
+// CurrentEnv 主程序的运行环境
+func CurrentEnv() Env {
+	return Env{PineCMS: version.Version, Go: runtime.Version(), Platform: runtime.GOOS + "/" + runtime.GOARCH}
+}
+
+// Find 按名称查找插件
+func (idx *Index) Find(name string) *Plugin {
+	for _, p := range idx.Plugins {
+		if p.Name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// Compatible 检查版本是否兼容当前环境
+func (r *Release) Compatible(env Env) error {
+	if len(r.Platforms) > 0 && !slices.Contains(r.Platforms, env.Platform) {
+		return fmt.Errorf("不支持平台%s, 支持: %s", env.Platform, strings.Join(r.Platforms, ", "))
+	}
+	if ok, err := Satisfies(env.PineCMS, r.PineCMS); err != nil || !ok {
+		return fmt.Errorf("需要系统版本%s", r.PineCMS)
+	}
+	// 开发版Go无法比较版本, 不做限制
+	if len(Canonical(env.Go)) > 0 {
+		if ok, err := Satisfies(env.Go, r.Go); err != nil || !ok {
+			return fmt.Errorf("需要Go版本%s", r.Go)
+		}
+	}
+	return nil
+}
+
+// Latest 满足约束且兼容当前环境的最高版本
+func (p *Plugin) Latest(env Env, constraint string) (*Release, error) {
+	var best *Release
+	var lastErr error
+	for _, r := range p.Releases {
+		if ok, err := Satisfies(r.Version, constraint); err != nil {
+			return nil, err
+		} else if !ok {
+			continue
+		}
+		if err := r.Compatible(env); err != nil {
+			lastErr = fmt.Errorf("%s@%s %w", p.Name, r.Version, err)
+			continue
+		}
+		if best == nil || Compare(r.Version, best.Version) > 0 {
+			best = r
+		}
+	}
+	if best != nil {
+		return best, nil
+	}
+	if lastErr != nil {
+		return nil, lastErr
+	}
+	return nil, fmt.Errorf("插件%s没有满足%s的版本", p.Name, constraint)
+}
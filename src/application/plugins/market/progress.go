@@ -0,0 +1,130 @@
+package market
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 安装进度状态
+const (
+	StatusPending     = "pending"
+	StatusDownloading = "downloading"
+	StatusVerifying   = "verifying"
+	StatusExtracting  = "extracting"
+	StatusUpgrading   = "upgrading"
+	StatusDone        = "done"
+	StatusFailed      = "failed"
+	StatusRolledBack  = "rolled_back"
+)
+
+// Progress 插件安装进度, 供后台轮询展示
+type Progress struct {
+	Name      string `json:"name"`
+	Version   string `json:"version"`
+	Status    string `json:"status"`
+	Total     int64  `json:"total"` // 未知时为-1
+	Done      int64  `json:"done"`
+	Message   string `json:"message"`
+	UpdatedAt int64  `json:"updated_at"`
+}
+
+var (
+	progressMu sync.Mutex
+	progresses = map[string]*Progress{}
+)
+
+// Track 开始记录插件的安装进度
+func Track(name, version string) *Progress {
+	progressMu.Lock()
+	defer progressMu.Unlock()
+	p := &Progress{Name: name, Version: version, Status: StatusPending, Total: -1, UpdatedAt: time.Now().Unix()}
+	progresses[name] = p
+	return p
+}
+
+// Progresses 全部安装进度
+func Progresses() []Progress {
+	progressMu.Lock()
+	defer progressMu.Unlock()
+	list := make([]Progress, 0, len(progresses))
+	for _, p := range progresses {
+		list = append(list, *p)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].UpdatedAt > list[j].UpdatedAt })
+	return list
+}
+
+// Running 插件是否正在安装
+func Running(name string) bool {
+	progressMu.Lock()
+	defer progressMu.Unlock()
+	p, ok := progresses[name]
+	return ok && p.Status != StatusDone && p.Status != StatusFailed && p.Status != StatusRolledBack
+}
+
+// Set 更新状态
+func (p *Progress) Set(status, message string) {
+	progressMu.Lock()
+	defer progressMu.Unlock()
+	p.Status, p.Message, p.UpdatedAt = status, message, time.Now().Unix()
+}
+
+// Fail 记录失败原因
+func (p *Progress) Fail(err error) error {
+	p.Set(StatusFailed, err.Error())
+	return err
+}
+
+func (p *Progress) Write(b []byte) (int, error) {
+	progressMu.Lock()
+	defer progressMu.Unlock()
+	p.Done += int64(len(b))
+	p.UpdatedAt = time.Now().Unix()
+	return len(b), nil
+}
+
+// Fetch 下载插件包并校验摘要与签名, 通过后解压到 dest
+func Fetch(repo Repository, name string, rel *Release, keys []ed25519.PublicKey, dest string, p *Progress) error {
+	rc, size, err := repo.Open(rel.Archive)
+	if err != nil {
+		return p.Fail(fmt.Errorf("下载插件包失败: %w", err))
+	}
+	defer rc.Close()
+	if size < 0 {
+		size = rel.Size
+	}
+	progressMu.Lock()
+	p.Total = size
+	progressMu.Unlock()
+	p.Set(StatusDownloading, "")
+
+	tmp, err := os.CreateTemp("", "pinecms-plugin-*.tar.gz")
+	if err != nil {
+		return p.Fail(err)
+	}
+	defer os.Remove(tmp.Name())
+	hash := sha256.New()
+	_, err = io.Copy(io.MultiWriter(tmp, hash, p), rc)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return p.Fail(fmt.Errorf("下载插件包失败: %w", err))
+	}
+
+	p.Set(StatusVerifying, "")
+	if err := Verify(name, rel, hash.Sum(nil), keys); err != nil {
+		return p.Fail(err)
+	}
+	p.Set(StatusExtracting, "")
+	if err := Extract(tmp.Name(), dest); err != nil {
+		return p.Fail(fmt.Errorf("解压插件包失败: %w", err))
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package market
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bytedance/sonic"
+)
+
+// Repository 插件仓库, 本地目录或HTTP地址, 根目录下有 index.json 与插件包
+type Repository interface {
+	Index() (*Index, error)
+	// Open 打开插件包, 返回内容与大小(未知时为-1)
+	Open(archive string) (io.ReadCloser, int64, error)
+}
+
+// Open 按地址创建仓库, http(s):// 开头为远程仓库, 否则为本地目录
+func Open(source string) (Repository, error) {
+	if len(source) == 0 {
+		return nil, errors.New("没有配置插件仓库地址")
+	}
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		return &httpRepository{base: strings.TrimRight(source, "/") + "/", client: &http.Client{Timeout: 10 * time.Minute}}, nil
+	}
+	return dirRepository(source), nil
+}
+
+func decodeIndex(r io.Reader) (*Index, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	idx := &Index{}
+	if err := sonic.Unmarshal(content, idx); err != nil {
+		return nil, fmt.Errorf("解析插件索引失败: %w", err)
+	}
+	return idx, nil
+}
+
+// dirRepository 本地目录仓库, 用于测试或内网部署
+type dirRepository string
+
+func (d dirRepository) Index() (*Index, error) {
+	f, err := os.Open(filepath.Join(string(d), IndexFile))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return decodeIndex(f)
+}
+
+func (d dirRepository) Open(archive string) (io.ReadCloser, int64, error) {
+	path := filepath.Join(string(d), filepath.FromSlash(archive))
+	if rel, err := filepath.Rel(string(d), path); err != nil || strings.HasPrefix(rel, "..") {
+		return nil, 0, fmt.Errorf("插件包%s不在仓库目录内", archive)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, 0, err
+	}
+	return f, info.Size(), nil
+}
+
+type httpRepository struct {
+	base   string
+	client *http.Client
+}
+
+func (h *httpRepository) get(ref string) (*http.Response, error) {
+	base, _ := url.Parse(h.base)
+	u, err := base.Parse(ref)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := h.client.Get(u.String())
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("请求%s失败: %s", u, resp.Status)
+	}
+	return resp, nil
+}
+
+func (h *httpRepository) Index() (*Index, error) {
+	resp, err := h.get(IndexFile)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return decodeIndex(resp.Body)
+}
+
+func (h *httpRepository) Open(archive string) (io.ReadCloser, int64, error) {
+	resp, err := h.get(archive)
+	if err != nil {
+		return nil, 0, err
+	}
+	return resp.Body, resp.ContentLength, nil
+}
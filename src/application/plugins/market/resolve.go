@@ -0,0 +1,80 @@
+package market
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Step 安装计划中的一步, 依赖在前
+type Step struct {
+	Plugin    *Plugin  `json:"plugin"`
+	Release   *Release `json:"release"`
+	Installed string   `json:"installed"` // 已安装的版本, 非空时为升级
+}
+
+// Resolve 解析安装插件需要的步骤. 已安装且满足约束的依赖不再安装;
+// 同一插件被多次依赖时, 已选择的版本需满足全部约束, 否则返回冲突.
+func Resolve(idx *Index, env Env, installed map[string]string, name, constraint string) ([]Step, error) {
+	r := &resolver{idx: idx, env: env, installed: installed, chosen: map[string]*Release{}, visiting: map[string]bool{}}
+	if err := r.resolve(name, constraint, true, nil); err != nil {
+		return nil, err
+	}
+	return r.steps, nil
+}
+
+type resolver struct {
+	idx       *Index
+	env       Env
+	installed map[string]string
+	chosen    map[string]*Release
+	visiting  map[string]bool
+	steps     []Step
+}
+
+func (r *resolver) resolve(name, constraint string, target bool, path []string) error {
+	path = append(path, name)
+	if !ValidName(name) {
+		return fmt.Errorf("无效的插件名%s", name)
+	}
+	if r.visiting[name] {
+		return fmt.Errorf("插件循环依赖: %s", strings.Join(path, " -> "))
+	}
+	if rel, ok := r.chosen[name]; ok {
+		if ok, err := Satisfies(rel.Version, constraint); err != nil || !ok {
+			return fmt.Errorf("插件%s版本冲突: 已选择%s, %s要求%s", name, rel.Version, strings.Join(path[:len(path)-1], " -> "), constraint)
+		}
+		return nil
+	}
+	installed := r.installed[name]
+	if !target && len(installed) > 0 {
+		if ok, _ := Satisfies(installed, constraint); ok {
+			return nil
+		}
+	}
+	p := r.idx.Find(name)
+	if p == nil {
+		return fmt.Errorf("仓库中不存在插件%s", name)
+	}
+	rel, err := p.Latest(r.env, constraint)
+	if err != nil {
+		return err
+	}
+	if target && len(installed) > 0 && Compare(rel.Version, installed) <= 0 {
+		return fmt.Errorf("插件%s已安装%s, 没有可升级的版本", name, installed)
+	}
+	if len(installed) > 0 && Compare(rel.Version, installed) < 0 {
+		return fmt.Errorf("插件%s已安装%s, %s要求的版本%s会降级", name, installed, strings.Join(path[:len(path)-1], " -> "), rel.Version)
+	}
+	r.chosen[name] = rel
+	r.visiting[name] = true
+	for _, dep := range slices.Sorted(maps.Keys(rel.Dependencies)) {
+		if err := r.resolve(dep, rel.Dependencies[dep], false, path); err != nil {
+			return err
+		}
+	}
+	r.visiting[name] = false
+	r.steps = append(r.steps, Step{Plugin: p, Release: rel, Installed: installed})
+	return nil
+}
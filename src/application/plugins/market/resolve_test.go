@@ -0,0 +1,72 @@
+package market
+
+import (
+	"strings"
+	"testing"
+)
+
+var testEnv = Env{PineCMS: "0.2.0", Go: "go1.23.4", Platform: "linux/amd64"}
+
+func testIndex() *Index {
+	return &Index{Plugins: []*Plugin{
+		{Name: "base", Releases: []*Release{{Version: "1.0.0"}, {Version: "1.2.0"}, {Version: "2.0.0"}}},
+		{Name: "blog", Releases: []*Release{
+			{Version: "1.0.0", Dependencies: map[string]string{"base": "^1.0"}},
+			{Version: "1.1.0", Dependencies: map[string]string{"base": "^1.1"}, Platforms: []string{"windows/amd64"}},
+		}},
+		{Name: "shop", Releases: []*Release{{Version: "1.0.0", Dependencies: map[string]string{"base": "^2.0", "blog": "^1.0"}}}},
+		{Name: "loop-a", Releases: []*Release{{Version: "1.0.0", Dependencies: map[string]string{"loop-b": "*"}}}},
+		{Name: "loop-b", Releases: []*Release{{Version: "1.0.0", Dependencies: map[string]string{"loop-a": "*"}}}},
+		{Name: "old", Releases: []*Release{{Version: "1.0.0", Dependencies: map[string]string{"base": "<1.1"}}}},
+		{Name: "../evil", Releases: []*Release{{Version: "1.0.0"}}},
+	}}
+}
+
+func stepNames(steps []Step) string {
+	var names []string
+	for _, s := range steps {
+		names = append(names, s.Plugin.Name+"@"+s.Release.Version)
+	}
+	return strings.Join(names, ",")
+}
+
+func TestResolve(t *testing.T) {
+	steps, err := Resolve(testIndex(), testEnv, nil, "blog", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 1.1.0 不支持当前平台, 依赖在前
+	if got := stepNames(steps); got != "base@1.2.0,blog@1.0.0" {
+		t.Fatalf("安装步骤错误: %s", got)
+	}
+
+	steps, err = Resolve(testIndex(), testEnv, map[string]string{"base": "1.0.0"}, "blog", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := stepNames(steps); got != "blog@1.0.0" {
+		t.Fatalf("已满足约束的依赖不应重复安装: %s", got)
+	}
+}
+
+func TestResolveErrors(t *testing.T) {
+	cases := []struct {
+		name      string
+		installed map[string]string
+		target    string
+		contains  string
+	}{
+		{"版本冲突", nil, "shop", "版本冲突"},
+		{"循环依赖", nil, "loop-a", "循环依赖"},
+		{"插件不存在", nil, "missing", "不存在"},
+		{"非法插件名", nil, "../evil", "无效的插件名"},
+		{"没有可升级版本", map[string]string{"base": "2.0.0"}, "base", "没有可升级"},
+		{"依赖降级", map[string]string{"base": "1.2.0"}, "old", "降级"},
+	}
+	for _, c := range cases {
+		_, err := Resolve(testIndex(), testEnv, c.installed, c.target, "")
+		if err == nil || !strings.Contains(err.Error(), c.contains) {
+			t.Errorf("%s: 期望包含%q的错误, 得到 %v", c.name, c.contains, err)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package market
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+var namePattern = regexp.MustCompile(`^[a-z0-9_-]+$`)
+
+// ValidName 插件名同时是插件目录名, 只能包含小写字母、数字、下划线与中划线
+func ValidName(name string) bool {
+	return namePattern.MatchString(name)
+}
+
+// signedMessage 签名内容同时覆盖插件名、版本与包摘要, 仓库无法将签名的包替换为其他插件或版本
+func signedMessage(name, version string, sum []byte) []byte {
+	return []byte("pinecms-plugin\n" + name + "\n" + version + "\n" + hex.EncodeToString(sum))
+}
+
+// ParseKeys 解析 base64 编码的 ed25519 公钥
+func ParseKeys(keys []string) ([]ed25519.PublicKey, error) {
+	var pubs []ed25519.PublicKey
+	for _, key := range keys {
+		b, err := base64.StdEncoding.DecodeString(key)
+		if err != nil || len(b) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("无效的公钥%s", key)
+		}
+		pubs = append(pubs, b)
+	}
+	return pubs, nil
+}
+
+// Verify 校验插件包摘要与签名, 任一公钥验证通过即可
+func Verify(name string, rel *Release, sum []byte, keys []ed25519.PublicKey) error {
+	if !ValidName(name) {
+		return fmt.Errorf("无效的插件名%s", name)
+	}
+	if len(keys) == 0 {
+		return errors.New("没有配置插件签名公钥")
+	}
+	if hex.EncodeToString(sum) != rel.Sha256 {
+		return errors.New("插件包sha256校验失败")
+	}
+	sig, err := base64.StdEncoding.DecodeString(rel.Signature)
+	if err != nil {
+		return errors.New("插件包签名格式错误")
+	}
+	msg := signedMessage(name, rel.Version, sum)
+	for _, key := range keys {
+		if ed25519.Verify(key, msg, sig) {
+			return nil
+		}
+	}
+	return errors.New("插件包签名校验失败")
+}
+
+// Sign 对插件名、版本与包摘要签名, 私钥为 base64 编码
+func Sign(privateKey, name, version string, sum []byte) (string, error) {
+	b, err := base64.StdEncoding.DecodeString(privateKey)
+	if err != nil || len(b) != ed25519.PrivateKeySize {
+		return "", errors.New("无效的私钥")
+	}
+	return base64.StdEncoding.EncodeToString(ed25519.Sign(b, signedMessage(name, version, sum))), nil
+}
+
+// GenerateKey 生成 base64 编码的签名密钥对
+func GenerateKey() (publicKey, privateKey string, err error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	return base64.StdEncoding.EncodeToString(pub), base64.StdEncoding.EncodeToString(priv), nil
+}
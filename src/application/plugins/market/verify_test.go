@@ -0,0 +1,64 @@
+package market
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+func TestVerify(t *testing.T) {
+	pub, priv, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := ParseKeys([]string{pub})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256([]byte("archive"))
+	sig, err := Sign(priv, "task", "1.0.0", sum[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	rel := &Release{Version: "1.0.0", Sha256: hex.EncodeToString(sum[:]), Signature: sig}
+	if err := Verify("task", rel, sum[:], keys); err != nil {
+		t.Fatal(err)
+	}
+
+	other := sha256.Sum256([]byte("other"))
+	cases := map[string]func() error{
+		"摘要不一致": func() error { return Verify("task", rel, other[:], keys) },
+		"替换插件名": func() error { return Verify("blog", rel, sum[:], keys) },
+		"替换版本": func() error {
+			return Verify("task", &Release{Version: "0.9.0", Sha256: rel.Sha256, Signature: sig}, sum[:], keys)
+		},
+		"非法插件名": func() error { return Verify("../task", rel, sum[:], keys) },
+		"未配置公钥": func() error { return Verify("task", rel, sum[:], nil) },
+		"其他公钥": func() error {
+			pub, _, _ := ed25519.GenerateKey(nil)
+			return Verify("task", rel, sum[:], []ed25519.PublicKey{pub})
+		},
+	}
+	for name, fn := range cases {
+		if fn() == nil {
+			t.Errorf("%s: 应校验失败", name)
+		}
+	}
+}
+
+func TestValidName(t *testing.T) {
+	for name, valid := range map[string]bool{
+		"task":            true,
+		"wechat_pay-v2":   true,
+		"":                false,
+		"Task":            false,
+		"../../resources": false,
+		"a/b":             false,
+		".":               false,
+	} {
+		if ValidName(name) != valid {
+			t.Errorf("ValidName(%q) != %v", name, valid)
+		}
+	}
+}
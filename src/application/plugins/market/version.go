@@ -0,0 +1,92 @@
+package market
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/mod/semver"
+)
+
+// Canonical 规范化版本号: "dev 0.2.0" => v0.2.0, go1.23.4 => v1.23.4, 1.2 => v1.2.0
+func Canonical(version string) string {
+	version = strings.TrimSpace(version)
+	if i := strings.LastIndex(version, " "); i >= 0 {
+		version = version[i+1:]
+	}
+	version = strings.TrimPrefix(strings.TrimPrefix(version, "go"), "v")
+	return semver.Canonical("v" + version)
+}
+
+// Compare 比较版本, 无效版本视为最小
+func Compare(a, b string) int {
+	return semver.Compare(Canonical(a), Canonical(b))
+}
+
+// Satisfies 版本是否满足约束, 约束由空格或逗号分隔的条件组成且需同时满足.
+// 支持 >= > <= < = ^ ~ 以及 * 或空(不限), 如 ">=0.2.0 <1.0.0", "^1.2", "=1.23.4"
+func Satisfies(version, constraint string) (bool, error) {
+	v := Canonical(version)
+	if len(v) == 0 {
+		return false, fmt.Errorf("无效的版本号%s", version)
+	}
+	for _, term := range strings.FieldsFunc(constraint, func(r rune) bool { return r == ' ' || r == ',' }) {
+		ok, err := match(v, term)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func match(v, term string) (bool, error) {
+	if term == "*" {
+		return true, nil
+	}
+	op := strings.TrimRight(term, "0123456789.v")
+	target := Canonical(strings.TrimPrefix(term, op))
+	if len(target) == 0 {
+		return false, fmt.Errorf("无效的版本约束%s", term)
+	}
+	c := semver.Compare(v, target)
+	switch op {
+	case ">=":
+		return c >= 0, nil
+	case ">":
+		return c > 0, nil
+	case "<=":
+		return c <= 0, nil
+	case "<":
+		return c < 0, nil
+	case "=", "":
+		return c == 0, nil
+	case "^":
+		return c >= 0 && semver.Compare(v, caretUpper(target)) < 0, nil
+	case "~":
+		return c >= 0 && semver.Compare(v, bump(target, 1)) < 0, nil
+	}
+	return false, fmt.Errorf("不支持的版本约束%s", term)
+}
+
+// caretUpper ^1.2.3 => v2.0.0, ^0.2.3 => v0.3.0, ^0.0.3 => v0.0.4
+func caretUpper(v string) string {
+	parts := strings.Split(strings.TrimPrefix(v, "v"), ".")
+	for i := 0; i < 2; i++ {
+		if parts[i] != "0" {
+			return bump(v, i)
+		}
+	}
+	return bump(v, 2)
+}
+
+// bump 版本号第i位加1, 之后的位归零
+func bump(v string, i int) string {
+	parts := strings.Split(strings.TrimPrefix(semver.Canonical(v), "v"), ".")
+	parts[2] = strings.SplitN(strings.SplitN(parts[2], "-", 2)[0], "+", 2)[0]
+	n, _ := strconv.Atoi(parts[i])
+	parts[i] = strconv.Itoa(n + 1)
+	for j := i + 1; j < 3; j++ {
+		parts[j] = "0"
+	}
+	return "v" + strings.Join(parts, ".")
+}
@@ -0,0 +1,205 @@
+package plugins
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/bytedance/sonic"
+	"github.com/xiusin/pine"
+	"github.com/xiusin/pine/di"
+	cmdPlugin "github.com/xiusin/pinecms/cmd/plugin"
+	"github.com/xiusin/pinecms/src/application/controllers"
+	"github.com/xiusin/pinecms/src/application/plugins/pluginrpc"
+	"github.com/xiusin/pinecms/src/application/plugins/sdk"
+	"github.com/xiusin/pinecms/src/common/helper"
+)
+
+// routeMethods ANY 对应注册的请求方法
+var routeMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete}
+
+// forwardHeaders 转发到进程外插件的请求头, 登录凭证与 Cookie 不转发, 管理员身份通过 AdminId 传递
+var forwardHeaders = []string{"Content-Type", "Accept", "Accept-Language", "X-Requested-With", "User-Agent", "Referer"}
+
+// scanRemotePlugins 扫描配置为rpc运行方式的插件目录, 以可执行文件路径作为插件标识
+func scanRemotePlugins() {
+	files, _ := filepath.Glob(filepath.Join(pluginMgr.path, "*", jsonName))
+	for _, jsonPath := range files {
+		content, err := os.ReadFile(jsonPath)
+		if err != nil {
+			continue
+		}
+		conf := cmdPlugin.Config{}
+		if err := sonic.Unmarshal(content, &conf); err != nil || conf.Runtime != RuntimeRPC {
+			continue
+		}
+		dir := filepath.Dir(jsonPath)
+		execName := conf.Exec
+		if len(execName) == 0 {
+			execName = filepath.Base(dir)
+		}
+		if runtime.GOOS == "windows" && !strings.HasSuffix(execName, ".exe") {
+			execName += ".exe"
+		}
+		execPath := filepath.Join(dir, execName)
+		if _, err := os.Stat(execPath); err != nil {
+			pine.Logger().Warn("插件可执行文件不存在", execPath)
+			continue
+		}
+		if _, exist := pluginMgr.scannedPlugins[execPath]; !exist {
+			pluginMgr.scannedPlugins[execPath] = &conf
+		}
+	}
+}
+
+// installRemote 启动插件进程并注册路由, 调用方需持有锁
+func (p *pluginManager) installRemote(filename string) (PluginIntf, error) {
+	if plug, exist := p.plugins[filename]; exist {
+		return plug.pi, nil
+	}
+	r, err := startRemote(filename)
+	if err != nil {
+		p.scannedPlugins[filename].Error = err.Error()
+		return nil, err
+	}
+	if len(r.Migrations()) > 0 { // 迁移SQL由主程序执行, 需要声明数据库能力
+		if err := sdk.Require(r.Sign(), p.scannedPlugins[filename].Capabilities, sdk.CapORM); err != nil {
+			r.stop()
+			return nil, err
+		}
+	}
+	if err := migrate(r); err != nil {
+		r.stop()
+		return nil, err
+	}
+	if err := p.registerRemoteRouter(filename, r); err != nil {
+		r.stop()
+		return nil, err
+	}
+	p.plugins[filename] = &Plug{pi: r}
+	p.installPlugins[filename] = struct{}{}
+	return r, nil
+}
+
+// registerRemoteRouter 注册插件声明的路由, 已注册过的路由只更新所属插件
+func (p *pluginManager) registerRemoteRouter(filename string, r *remote) error {
+	group := di.MustGet(controllers.ServiceBackendRouter).(*pine.Router)
+	prefix := "/" + strings.Trim(r.Prefix(), "/")
+	for _, route := range r.manifest.Routes {
+		if strings.ContainsAny(route.Path, ":*") {
+			return fmt.Errorf("插件路由%s不支持参数, 请使用查询参数或请求体传值", route.Path)
+		}
+		path := strings.TrimRight(prefix+"/"+strings.TrimLeft(route.Path, "/"), "/")
+		methods := []string{strings.ToUpper(route.Method)}
+		if methods[0] == "ANY" || len(methods[0]) == 0 {
+			methods = routeMethods
+		}
+		for _, method := range methods {
+			key := method + " " + path
+			if owner, exist := p.routes[key]; exist && owner.plugin != filename {
+				if _, running := p.plugins[owner.plugin]; running {
+					return fmt.Errorf("路由%s已被插件%s占用", key, owner.plugin)
+				}
+			}
+			if _, exist := p.routes[key]; !exist {
+				group.AddRoute(method, path, p.forward(key))
+			}
+			p.routes[key] = remoteRoute{plugin: filename, path: route.Path}
+		}
+	}
+	pine.Logger().Info(fmt.Sprintf("[plugin:%s] 注册路由分组:%s成功", r.Sign(), prefix))
+	return nil
+}
+
+// forward 将请求转发到插件进程
+func (p *pluginManager) forward(key string) pine.Handler {
+	return func(ctx *pine.Context) {
+		p.Lock()
+		route := p.routes[key]
+		plug, exist := p.plugins[route.plugin]
+		p.Unlock()
+		r, ok := (*remote)(nil), false
+		if exist {
+			r, ok = plug.pi.(*remote)
+		}
+		if !ok || !r.IsInstall() {
+			ctx.Abort(http.StatusNotFound)
+			return
+		}
+		if !r.Status() {
+			disableMsg := "插件功能已禁用, 不可访问"
+			if ctx.IsAjax() {
+				helper.Ajax(disableMsg, 1, ctx)
+			} else {
+				ctx.Abort(http.StatusForbidden, disableMsg)
+			}
+			return
+		}
+		req := &pluginrpc.Request{
+			Method: string(ctx.Method()),
+			Path:   route.path,
+			Query:  ctx.QueryArgs().String(),
+			Header: map[string]string{},
+			Body:   ctx.PostBody(),
+		}
+		for _, k := range forwardHeaders {
+			if v := ctx.Request.Header.Peek(k); len(v) > 0 {
+				req.Header[k] = string(v)
+			}
+		}
+		if adminId, ok := ctx.Value("adminid").(int64); ok {
+			req.AdminId = adminId
+		}
+		resp, err := r.handle(req)
+		if err != nil {
+			helper.Ajax("插件处理请求失败: "+err.Error(), 1, ctx)
+			return
+		}
+		for k, v := range resp.Header {
+			ctx.Response.Header.Set(k, v)
+		}
+		ctx.SetStatus(resp.Status)
+		_ = ctx.Write(resp.Body)
+	}
+}
+
+// Emit 通知订阅了事件的进程外插件, 异步执行
+func Emit(event string, payload any) {
+	pluginMgr.Lock()
+	subscribers := map[string]*remote{}
+	for name, plug := range pluginMgr.plugins {
+		if r, ok := plug.pi.(*remote); ok && r.IsInstall() && r.Status() && r.subscribed(event) {
+			subscribers[name] = r
+		}
+	}
+	pluginMgr.Unlock()
+	if len(subscribers) == 0 {
+		return
+	}
+	data, err := sonic.Marshal(payload)
+	if err != nil {
+		pine.Logger().Warn("序列化插件事件失败", event, err)
+		return
+	}
+	for name, r := range subscribers {
+		go func(name string, r *remote) {
+			if err := r.call("Event", pluginrpc.Event{Name: event, Payload: data}, &pluginrpc.Empty{}); err != nil {
+				pine.Logger().Warn("插件"+name+"处理事件"+event+"失败", err)
+			}
+		}(name, r)
+	}
+}
+
+// stopRemotes 主程序退出时结束全部插件进程
+func stopRemotes() {
+	pluginMgr.Lock()
+	defer pluginMgr.Unlock()
+	for _, plug := range pluginMgr.plugins {
+		if r, ok := plug.pi.(*remote); ok {
+			r.stop()
+		}
+	}
+}
@@ -4,13 +4,10 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"plugin"
-	"runtime"
 	"strings"
 	"sync"
 	"time"
@@ -21,7 +18,9 @@ import (
 	cmdPlugin "github.com/xiusin/pinecms/cmd/plugin"
 	"github.com/xiusin/pinecms/src/application/controllers"
 	"github.com/xiusin/pinecms/src/application/models/tables"
+	"github.com/xiusin/pinecms/src/application/plugins/sdk"
 	"github.com/xiusin/pinecms/src/common/helper"
+	"github.com/xiusin/pinecms/src/common/hooks"
 	"github.com/xiusin/pinecms/src/config"
 	"xorm.io/xorm"
 )
@@ -32,6 +31,9 @@ const exportedVarSuffix = "Plugin"
 
 const jsonName = "config.json"
 
+// RuntimeRPC 独立进程运行的插件
+const RuntimeRPC = "rpc"
+
 type PluginIntf interface {
 	Init(di.AbstractBuilder)         // 初始化插件
 	Sign() string                    // 插件的唯一标识, 需要开发者搞一个独一无二如 uuid
@@ -54,9 +56,9 @@ type Plug struct {
 
 var pluginMgr = &pluginManager{
 	plugins:        map[string]*Plug{},
+	routes:         map[string]remoteRoute{},
 	installPlugins: map[string]struct{}{},
 	scannedPlugins: map[string]*cmdPlugin.Config{},
-	remoteDomain:   "https://plugin.xiusin.cn",
 	fileGlob:       "*/**" + ext,
 }
 
@@ -66,9 +68,15 @@ type pluginManager struct {
 	plugins        map[string]*Plug
 	installPlugins map[string]struct{}          // 已经通过安装配置的插件
 	scannedPlugins map[string]*cmdPlugin.Config // 已经扫描到的插件(已安装 + 未安装)
+	routes         map[string]remoteRoute       // 进程外插件注册的路由, 路由无法注销, 请求时按此查找插件
 	path           string
 	fileGlob       string
-	remoteDomain   string
+}
+
+// remoteRoute 路由所属的插件以及插件内的路径
+type remoteRoute struct {
+	plugin string
+	path   string
 }
 
 // IterFn 迭代函数
@@ -135,9 +143,16 @@ func (p *pluginManager) Install(filename string) (PluginIntf, error) {
 	p.Lock()
 	defer p.Unlock()
 	name := helper.UcFirst(strings.TrimSuffix(filepath.Base(filename), ext)) + exportedVarSuffix
-	if _, exist := p.scannedPlugins[filename]; !exist {
+	conf, exist := p.scannedPlugins[filename]
+	if !exist {
 		return nil, fmt.Errorf("插件%s不存在", filename)
 	}
+	if conf.Runtime == RuntimeRPC {
+		return p.installRemote(filename)
+	}
+	if helper.IsWindows() {
+		return nil, errors.New("Windows不支持.so插件, 请使用rpc运行方式")
+	}
 	plug, err := plugin.Open(filename)
 	if err != nil {
 		delete(pluginMgr.scannedPlugins, filename)
@@ -154,13 +169,36 @@ func (p *pluginManager) Install(filename string) (PluginIntf, error) {
 		delete(pluginMgr.scannedPlugins, filename)
 		return nil, errors.New(filename + "插件未实现PluginIntf接口")
 	}
-	pluginEntity.Init(di.GetDefaultDI())
+	if err := migrate(pluginEntity); err != nil {
+		return nil, err
+	}
+	services, err := sdk.Scope(di.GetDefaultDI(), pluginEntity.Sign(), conf.Capabilities)
+	if err != nil {
+		return nil, err
+	}
+	pluginEntity.Init(services)
 	p.registerRouter(pluginEntity)
 	pluginMgr.plugins[filename] = &Plug{plug: plug, pi: pluginEntity}
 	pluginMgr.installPlugins[filename] = struct{}{}
 	return pluginEntity, nil
 }
 
+// migrate 执行插件未执行过的数据迁移
+func migrate(pi PluginIntf) error {
+	if m, ok := pi.(sdk.Migrator); ok {
+		return sdk.Migrate(helper.GetORM(), pi.Sign(), m.Migrations())
+	}
+	return nil
+}
+
+// rollback 卸载时回滚插件的数据迁移
+func rollback(pi PluginIntf) error {
+	if m, ok := pi.(sdk.Migrator); ok {
+		return sdk.Rollback(helper.GetORM(), pi.Sign(), m.Migrations())
+	}
+	return nil
+}
+
 // registerRouter 注册路由, 添加中间件拦截非正常状态
 func (p *pluginManager) registerRouter(plug PluginIntf) {
 	group := di.MustGet(controllers.ServiceBackendRouter).(*pine.Router)
@@ -182,67 +220,34 @@ func (p *pluginManager) registerRouter(plug PluginIntf) {
 	pine.Logger().Info("[plugin:task] 注册路由分组:" + plug.Prefix() + "成功")
 }
 
-// Download 下载插件,系统,go版本,插件版本 TODO 怎么处理进度呢? 下载完成或下载失败
-func (p *pluginManager) Download(name string) {
-	if len(p.path) == 0 {
-		return
-	}
-	pluginPath := filepath.Join(p.path, name+ext)
-	if _, err := os.Stat(pluginPath); err != nil {
-		return
-	}
-
-	f, err := os.OpenFile(pluginPath, os.O_CREATE|os.O_RDWR, os.ModePerm)
-	if err != nil {
-		return
-	}
-
-	go func() {
-		url := fmt.Sprintf("%s/%s/%s/%s/%s%s", p.remoteDomain, runtime.GOOS, runtime.GOARCH, runtime.Version(), name, ".tar.gz")
-		client := &http.Client{}
-		client.Timeout = time.Second * 60 * 10
-		resp, err := client.Get(url)
-		if err != nil {
-			pine.Logger().Error("下载插件"+name+"失败", err)
-			return
-		}
-		defer resp.Body.Close()
-		_, err = io.Copy(f, resp.Body)
-		if err != nil {
-			pine.Logger().Error("保存插件到"+pluginPath+"失败", err)
-		} else {
-			tarExecPath, err := exec.LookPath("tar")
-			if err != nil {
-				pine.Logger().Error("无法查找到tar命令, 请手动解压" + pluginPath)
-				return
-			}
-			cmd := exec.Command(tarExecPath, "-zxvf", pluginPath)
-			cmd.Dir = p.path
-			if err := cmd.Run(); err != nil {
-				pine.Logger().Error("解压" + pluginPath + "失败, 请手动解压")
-			} else {
-				pine.Logger().Info("解压" + pluginPath + "成功, 等待程序扫描加载")
-			}
-		}
-	}()
-}
-
+// Uninstall 卸载插件, 进程外插件会结束进程, 其路由随之不可访问
 func (p *pluginManager) Uninstall(name string) {
 	p.Lock()
-	defer p.Unlock()
 	plugin, exist := p.plugins[name]
 	if !exist {
+		p.Unlock()
 		return
 	}
 	plugin.pi.Uninstall()
+	if err := rollback(plugin.pi); err != nil {
+		pine.Logger().Error("回滚插件"+name+"数据失败", err)
+	}
 	plugin.plug = nil
 	plugin.pi = nil
 
 	delete(p.plugins, name)
+	delete(p.installPlugins, name)
+	for key, route := range p.routes {
+		if route.plugin == name {
+			delete(p.routes, key)
+		}
+	}
+	p.Unlock()
+	p.Reload()
 }
 
 func Init() {
-	if !config.App().PluginEnable || helper.IsWindows() {
+	if !config.App().PluginEnable {
 		return
 	}
 	if pluginPath := config.App().PluginPath; len(pluginPath) > 0 {
@@ -253,10 +258,18 @@ func Init() {
 	}
 	scanPluginDir()
 	go tickScanDir()
+	pine.RegisterOnInterrupt(stopRemotes)
+	hooks.Listen(Emit)
 	pluginMgr.loadPlugin()
 }
 
 func scanPluginDir() {
+	pluginMgr.Lock()
+	defer pluginMgr.Unlock()
+	scanRemotePlugins()
+	if helper.IsWindows() {
+		return
+	}
 	if plugins, _ := filepath.Glob(filepath.Join(pluginMgr.path, pluginMgr.fileGlob)); len(plugins) > 0 {
 		for _, f := range plugins {
 			conf := cmdPlugin.Config{}
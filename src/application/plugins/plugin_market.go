@@ -0,0 +1,202 @@
+package plugins
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/xiusin/pine"
+	"github.com/xiusin/pinecms/src/application/models/tables"
+	"github.com/xiusin/pinecms/src/application/plugins/market"
+	"github.com/xiusin/pinecms/src/application/plugins/pluginrpc"
+	"github.com/xiusin/pinecms/src/common/helper"
+	"github.com/xiusin/pinecms/src/config"
+)
+
+// backupSuffix 升级时原插件目录的备份后缀, 升级失败时还原
+const backupSuffix = ".bak"
+
+// MarketIndex 读取插件仓库索引
+func (p *pluginManager) MarketIndex() (*market.Index, error) {
+	repo, err := market.Open(config.App().PluginMarket.Repository)
+	if err != nil {
+		return nil, err
+	}
+	return repo.Index()
+}
+
+// Installed 本地已有插件的版本, 以插件目录名为插件名
+func (p *pluginManager) Installed() map[string]string {
+	p.Lock()
+	defer p.Unlock()
+	installed := map[string]string{}
+	for path, conf := range p.scannedPlugins {
+		installed[filepath.Base(filepath.Dir(path))] = conf.Version
+	}
+	return installed
+}
+
+// MarketInstall 解析版本与依赖后在后台依次下载安装, 进度通过 market.Progresses 查询
+func (p *pluginManager) MarketInstall(name, constraint string) ([]market.Step, error) {
+	if len(p.path) == 0 {
+		return nil, errors.New("插件功能未启用")
+	}
+	conf := config.App().PluginMarket
+	keys, err := market.ParseKeys(conf.PublicKeys)
+	if err != nil {
+		return nil, err
+	}
+	repo, err := market.Open(conf.Repository)
+	if err != nil {
+		return nil, err
+	}
+	idx, err := repo.Index()
+	if err != nil {
+		return nil, err
+	}
+	steps, err := market.Resolve(idx, market.CurrentEnv(), p.Installed(), name, constraint)
+	if err != nil {
+		return nil, err
+	}
+	progresses := make([]*market.Progress, len(steps))
+	for i, step := range steps {
+		if market.Running(step.Plugin.Name) {
+			return nil, fmt.Errorf("插件%s正在安装", step.Plugin.Name)
+		}
+		progresses[i] = market.Track(step.Plugin.Name, step.Release.Version)
+	}
+	go func() {
+		for i, step := range steps {
+			if err := p.deploy(repo, keys, step, progresses[i]); err != nil {
+				pine.Logger().Error("安装插件"+step.Plugin.Name+"失败", err)
+				for _, pending := range progresses[i+1:] {
+					pending.Fail(fmt.Errorf("依赖插件%s安装失败", step.Plugin.Name))
+				}
+				return
+			}
+		}
+	}()
+	return steps, nil
+}
+
+// deploy 下载解压一个插件, 已存在时备份原目录并执行升级, 失败则还原
+func (p *pluginManager) deploy(repo market.Repository, keys []ed25519.PublicKey, step market.Step, progress *market.Progress) error {
+	if !market.ValidName(step.Plugin.Name) {
+		return progress.Fail(fmt.Errorf("无效的插件名%s", step.Plugin.Name))
+	}
+	dir := filepath.Join(p.path, step.Plugin.Name)
+	backup := dir + backupSuffix
+	_ = os.RemoveAll(backup)
+	_, err := os.Stat(dir)
+	exists := err == nil
+	if exists {
+		if err := os.Rename(dir, backup); err != nil {
+			return progress.Fail(err)
+		}
+	}
+	restore := func() {
+		_ = os.RemoveAll(dir)
+		if exists {
+			_ = os.Rename(backup, dir)
+		}
+	}
+	if err := market.Fetch(repo, step.Plugin.Name, step.Release, keys, dir, progress); err != nil {
+		restore()
+		return err
+	}
+
+	message := ""
+	if exists {
+		progress.Set(market.StatusUpgrading, "")
+		restarted, err := p.upgrade(dir)
+		if err != nil {
+			restore()
+			if restarted {
+				p.restart(dir)
+			}
+			progress.Set(market.StatusRolledBack, "升级失败, 已恢复原版本: "+err.Error())
+			return err
+		}
+		if !restarted && p.loaded(dir) != "" {
+			message = "动态库插件无法热替换, 重启后生效"
+		}
+		_ = os.RemoveAll(backup)
+	}
+	scanPluginDir()
+	if file := p.loaded(dir); file != "" {
+		_, _ = helper.GetORM().Where("path = ?", file).Cols("version").Update(&tables.Plugin{Version: step.Release.Version})
+	}
+	progress.Set(market.StatusDone, message)
+	return nil
+}
+
+// loaded 目录内已加载的插件文件
+func (p *pluginManager) loaded(dir string) string {
+	p.Lock()
+	defer p.Unlock()
+	for file := range p.plugins {
+		if filepath.Dir(file) == dir {
+			return file
+		}
+	}
+	return ""
+}
+
+// upgrade 重启已加载的进程外插件并调用其 Upgrade, restarted 表示是否已停止原进程
+func (p *pluginManager) upgrade(dir string) (restarted bool, err error) {
+	file := p.loaded(dir)
+	if file == "" {
+		return false, nil
+	}
+	p.Lock()
+	r, ok := p.plugins[file].pi.(*remote)
+	if !ok {
+		p.Unlock()
+		return false, nil
+	}
+	delete(p.plugins, file)
+	delete(p.scannedPlugins, file)
+	p.Unlock()
+	r.stop()
+
+	scanPluginDir()
+	pi, err := p.Install(file)
+	if err != nil {
+		return true, err
+	}
+	if err := pi.(*remote).call("Upgrade", pluginrpc.Empty{}, &pluginrpc.Empty{}); err != nil {
+		p.Lock()
+		delete(p.plugins, file)
+		p.Unlock()
+		pi.(*remote).stop()
+		return true, err
+	}
+	return true, nil
+}
+
+// restart 还原目录后重新启动原版本插件
+func (p *pluginManager) restart(dir string) {
+	p.Lock()
+	for file := range p.scannedPlugins {
+		if filepath.Dir(file) == dir {
+			delete(p.scannedPlugins, file)
+		}
+	}
+	p.Unlock()
+	scanPluginDir()
+	p.Lock()
+	var files []string
+	for file := range p.scannedPlugins {
+		if _, installed := p.installPlugins[file]; installed && filepath.Dir(file) == dir {
+			files = append(files, file)
+		}
+	}
+	p.Unlock()
+	for _, file := range files {
+		if _, err := p.Install(file); err != nil {
+			pine.Logger().Error("恢复插件"+file+"失败", err)
+		}
+	}
+}
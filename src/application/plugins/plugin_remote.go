@@ -0,0 +1,354 @@
+package plugins
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xiusin/pine"
+	"github.com/xiusin/pine/di"
+	"github.com/xiusin/pinecms/src/application/plugins/pluginrpc"
+	"github.com/xiusin/pinecms/src/application/plugins/sdk"
+	"github.com/xiusin/pinecms/src/common/helper"
+	"xorm.io/xorm"
+)
+
+const (
+	callTimeout  = 30 * time.Second
+	pingInterval = 15 * time.Second
+	pingFailures = 3 // 连续健康检查失败次数, 超过后重启进程
+	maxBackoff   = 30 * time.Second
+	stopTimeout  = 3 * time.Second
+)
+
+// menuColumns 插件注册信息中可写入菜单表的字段
+var menuColumns = []string{"name", "parentid", "listorder", "display", "type", "icon", "view_path", "keep_alive", "router", "perms", "identification"}
+
+// remote 进程外插件, 实现 PluginIntf 以便与 .so 插件共用安装流程
+type remote struct {
+	sync.Mutex
+	path     string
+	manifest pluginrpc.Manifest
+	cmd      *exec.Cmd
+	client   *rpc.Client
+	exited   chan struct{}
+	stopped  bool
+	status   bool
+	restarts int
+}
+
+// pipeConn 子进程标准输入输出组成的连接
+type pipeConn struct {
+	io.ReadCloser
+	w io.WriteCloser
+}
+
+func (c pipeConn) Write(p []byte) (int, error) {
+	return c.w.Write(p)
+}
+
+func (c pipeConn) Close() error {
+	_ = c.w.Close()
+	return c.ReadCloser.Close()
+}
+
+// startRemote 启动插件进程并读取注册信息, 进程退出或健康检查失败时自动重启
+func startRemote(path string) (*remote, error) {
+	r := &remote{path: path, status: true}
+	if err := r.start(); err != nil {
+		return nil, err
+	}
+	go r.supervise()
+	return r, nil
+}
+
+func (r *remote) start() error {
+	stdinR, stdinW, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	stdoutR, stdoutW, err := os.Pipe()
+	if err != nil {
+		_, _ = stdinR.Close(), stdinW.Close()
+		return err
+	}
+	cmd := exec.Command(r.path)
+	// 插件以自身目录为工作目录, 只获得最小环境变量, 不继承主程序的配置与密钥
+	cmd.Dir = filepath.Dir(r.path)
+	cmd.Env = pluginEnv()
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = stdinR, stdoutW, os.Stderr
+	err = cmd.Start()
+	_, _ = stdinR.Close(), stdoutW.Close()
+	if err != nil {
+		_, _ = stdinW.Close(), stdoutR.Close()
+		return err
+	}
+	exited := make(chan struct{})
+	go func() {
+		_ = cmd.Wait()
+		close(exited)
+	}()
+
+	client := jsonrpc.NewClient(pipeConn{ReadCloser: stdoutR, w: stdinW})
+	var manifest pluginrpc.Manifest
+	err = call(client, "Describe", pluginrpc.Empty{}, &manifest)
+	if err == nil && manifest.Protocol != pluginrpc.ProtocolVersion {
+		err = fmt.Errorf("插件协议版本%d与主程序协议版本%d不一致", manifest.Protocol, pluginrpc.ProtocolVersion)
+	}
+	if err == nil && len(manifest.Sign) == 0 {
+		err = errors.New("插件无签名, 无效")
+	}
+	r.Lock()
+	if err == nil && len(r.manifest.Sign) > 0 && manifest.Sign != r.manifest.Sign {
+		err = errors.New("重启后插件签名发生变化")
+	}
+	if err != nil {
+		r.Unlock()
+		_ = client.Close()
+		_ = cmd.Process.Kill()
+		return err
+	}
+	if len(manifest.View) == 0 {
+		manifest.View = "[]"
+	}
+	r.manifest, r.cmd, r.client, r.exited = manifest, cmd, client, exited
+	r.Unlock()
+	return nil
+}
+
+// pluginEnv 插件进程的环境变量
+func pluginEnv() []string {
+	env := []string{"PATH=" + os.Getenv("PATH"), fmt.Sprintf("%s=%d", pluginrpc.EnvProtocol, pluginrpc.ProtocolVersion)}
+	if runtime.GOOS == "windows" {
+		env = append(env, "SystemRoot="+os.Getenv("SystemRoot"))
+	}
+	return env
+}
+
+// supervise 进程退出后按退避时间重启, 健康检查连续失败时结束进程
+func (r *remote) supervise() {
+	backoff, failures := time.Second, 0
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+	for {
+		r.Lock()
+		exited := r.exited
+		r.Unlock()
+		select {
+		case <-exited:
+			if r.isStopped() {
+				return
+			}
+			pine.Logger().Warn(fmt.Sprintf("插件%s进程已退出, %s后重启", r.path, backoff))
+			time.Sleep(backoff)
+			if r.isStopped() {
+				return
+			}
+			if err := r.start(); err != nil {
+				pine.Logger().Error("重启插件"+r.path+"失败", err)
+				backoff = min(backoff*2, maxBackoff)
+				continue
+			}
+			r.Lock()
+			r.restarts++
+			r.Unlock()
+			failures = 0
+		case <-ticker.C:
+			var version int
+			if err := r.call("Ping", pluginrpc.Empty{}, &version); err != nil {
+				if failures++; failures >= pingFailures {
+					pine.Logger().Error("插件"+r.path+"健康检查失败, 结束进程", err)
+					r.kill()
+					failures = 0
+				}
+				continue
+			}
+			failures, backoff = 0, time.Second
+		}
+	}
+}
+
+func (r *remote) isStopped() bool {
+	r.Lock()
+	defer r.Unlock()
+	return r.stopped
+}
+
+func (r *remote) kill() {
+	r.Lock()
+	defer r.Unlock()
+	if r.cmd != nil && r.cmd.Process != nil {
+		_ = r.cmd.Process.Kill()
+	}
+}
+
+// stop 停止进程且不再重启
+func (r *remote) stop() {
+	r.Lock()
+	r.stopped = true
+	client, exited := r.client, r.exited
+	r.Unlock()
+	if client != nil {
+		// 关闭标准输入, 插件的 Serve 随之返回
+		_ = client.Close()
+	}
+	select {
+	case <-exited:
+	case <-time.After(stopTimeout):
+		r.kill()
+	}
+}
+
+func (r *remote) call(method string, args, reply any) error {
+	r.Lock()
+	client := r.client
+	r.Unlock()
+	if client == nil {
+		return errors.New("插件未运行")
+	}
+	return call(client, method, args, reply)
+}
+
+func call(client *rpc.Client, method string, args, reply any) error {
+	c := client.Go(pluginrpc.ServiceName+"."+method, args, reply, make(chan *rpc.Call, 1))
+	select {
+	case <-c.Done:
+		return c.Error
+	case <-time.After(callTimeout):
+		return fmt.Errorf("调用插件%s超时", method)
+	}
+}
+
+func (r *remote) handle(req *pluginrpc.Request) (*pluginrpc.Response, error) {
+	resp := &pluginrpc.Response{}
+	if err := r.call("Handle", req, resp); err != nil {
+		return nil, err
+	}
+	if resp.Status == 0 {
+		resp.Status = 200
+	}
+	return resp, nil
+}
+
+func (r *remote) subscribed(event string) bool {
+	r.Lock()
+	defer r.Unlock()
+	for _, name := range r.manifest.Events {
+		if name == event || name == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *remote) Init(di.AbstractBuilder) {}
+
+func (r *remote) Sign() string {
+	r.Lock()
+	defer r.Unlock()
+	return r.manifest.Sign
+}
+
+// Migrations 将注册信息中的SQL迁移转换为 sdk.Migration
+func (r *remote) Migrations() []sdk.Migration {
+	r.Lock()
+	defer r.Unlock()
+	migrations := make([]sdk.Migration, len(r.manifest.Migrations))
+	for i, m := range r.manifest.Migrations {
+		migrations[i] = sdk.Migration{Version: m.Version, Up: execSQL(m.Up), Down: execSQL(m.Down)}
+	}
+	return migrations
+}
+
+func execSQL(sql string) func(*xorm.Session) error {
+	return func(sess *xorm.Session) error {
+		if len(strings.TrimSpace(sql)) == 0 {
+			return nil
+		}
+		_, err := sess.Exec(sql)
+		return err
+	}
+}
+
+func (r *remote) View() string {
+	r.Lock()
+	defer r.Unlock()
+	return r.manifest.View
+}
+
+// Menu 写入注册信息中的菜单, 再通知插件自行处理
+func (r *remote) Menu(table any, pluginId int) {
+	orm := helper.GetORM()
+	for _, menu := range r.manifest.Menus {
+		item := map[string]any{}
+		for _, col := range menuColumns {
+			if v, exist := menu[col]; exist {
+				item[col] = v
+			}
+		}
+		item["plugin_id"] = pluginId
+		if exist, _ := orm.Table(table).Where("plugin_id = ? AND name = ?", pluginId, item["name"]).Exist(); !exist {
+			if _, err := orm.Table(table).Insert(item); err != nil {
+				pine.Logger().Warn("写入插件菜单失败", err)
+			}
+		}
+	}
+	if err := r.call("Menu", pluginrpc.MenuArgs{PluginId: int64(pluginId)}, &pluginrpc.Empty{}); err != nil {
+		pine.Logger().Warn("插件"+r.path+"安装菜单失败", err)
+	}
+}
+
+func (r *remote) Install() {
+	if err := r.call("Install", pluginrpc.Empty{}, &pluginrpc.Empty{}); err != nil {
+		pine.Logger().Warn("插件"+r.path+"安装失败", err)
+	}
+}
+
+func (r *remote) IsInstall() bool {
+	return !r.isStopped()
+}
+
+// Uninstall 通知插件卸载后结束进程
+func (r *remote) Uninstall() {
+	if err := r.call("Uninstall", pluginrpc.Empty{}, &pluginrpc.Empty{}); err != nil {
+		pine.Logger().Warn("插件"+r.path+"卸载失败", err)
+	}
+	r.stop()
+}
+
+func (r *remote) Upgrade() {
+	if err := r.call("Upgrade", pluginrpc.Empty{}, &pluginrpc.Empty{}); err != nil {
+		pine.Logger().Warn("插件"+r.path+"升级失败", err)
+	}
+}
+
+func (r *remote) SetStatus(status bool) {
+	r.Lock()
+	defer r.Unlock()
+	r.status = status
+}
+
+func (r *remote) Status() bool {
+	r.Lock()
+	defer r.Unlock()
+	return r.status
+}
+
+func (r *remote) GetController() pine.IController {
+	return nil
+}
+
+func (r *remote) Prefix() string {
+	r.Lock()
+	defer r.Unlock()
+	return r.manifest.Prefix
+}
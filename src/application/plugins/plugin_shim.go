@@ -0,0 +1,168 @@
+package plugins
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xiusin/pine"
+	"github.com/xiusin/pine/di"
+	"github.com/xiusin/pinecms/src/application/models/tables"
+	"github.com/xiusin/pinecms/src/application/plugins/pluginrpc"
+	"github.com/xiusin/pinecms/src/config"
+)
+
+// headerAdminId 转发到插件内部服务时携带的管理员ID
+const headerAdminId = "X-Pinecms-Admin-Id"
+
+var upperChar = regexp.MustCompile("([A-Z])")
+
+// shim 将 PluginIntf 插件包装为进程外插件
+type shim struct {
+	pi       PluginIntf
+	addr     string
+	prefix   string
+	manifest pluginrpc.Manifest
+	client   *http.Client
+}
+
+// ServeRPC 以进程外方式运行已有的 PluginIntf 插件, 控制器在插件进程内监听本地端口, 主程序转发的请求经此处理.
+//
+//	func main() {
+//		plugins.ServeRPC(&task.Task{})
+//	}
+func ServeRPC(p PluginIntf) error {
+	config.InitDB()
+	if err := migrate(p); err != nil {
+		return err
+	}
+	p.Init(di.GetDefaultDI())
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+	addr := listener.Addr().String()
+	_ = listener.Close()
+
+	prefix := "/" + strings.Trim(p.Prefix(), "/")
+	s := &shim{pi: p, addr: addr, prefix: prefix, client: &http.Client{Timeout: callTimeout}}
+	s.manifest = pluginrpc.Manifest{
+		Sign:   p.Sign(),
+		Prefix: p.Prefix(),
+		View:   p.View(),
+		Routes: controllerRoutes(p.GetController()),
+	}
+
+	app := pine.New()
+	app.Use(func(ctx *pine.Context) {
+		if adminId, err := strconv.ParseInt(string(ctx.Request.Header.Peek(headerAdminId)), 10, 64); err == nil {
+			ctx.Set("adminid", adminId)
+		}
+		ctx.Next()
+	})
+	app.Handle(p.GetController(), prefix)
+	go app.Run(pine.Addr(addr), pine.WithoutStartupLog(true))
+	for i := 0; i < 50; i++ {
+		if conn, err := net.Dial("tcp", addr); err == nil {
+			_ = conn.Close()
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return pluginrpc.Serve(s)
+}
+
+// controllerRoutes 按 pine 控制器的规则生成路由: GetUserList => GET user_list, Index => ANY /
+func controllerRoutes(c pine.IController) []pluginrpc.Route {
+	var routes []pluginrpc.Route
+	if c == nil {
+		return routes
+	}
+	ignores := map[string]struct{}{}
+	base := reflect.TypeOf(&pine.Controller{})
+	for i := 0; i < base.NumMethod(); i++ {
+		ignores[base.Method(i).Name] = struct{}{}
+	}
+	typ := reflect.TypeOf(c)
+	for i := 0; i < typ.NumMethod(); i++ {
+		name := typ.Method(i).Name
+		if _, ok := ignores[name]; ok {
+			continue
+		}
+		if name == "Index" {
+			routes = append(routes, pluginrpc.Route{Method: "ANY", Path: "/"})
+			continue
+		}
+		for _, method := range []string{"Get", "Post", "Put", "Head", "Delete"} {
+			if strings.HasPrefix(name, method) {
+				path := strings.TrimLeft(upperChar.ReplaceAllStringFunc(strings.TrimPrefix(name, method), func(s string) string {
+					return "_" + strings.ToLower(s)
+				}), "_")
+				routes = append(routes, pluginrpc.Route{Method: strings.ToUpper(method), Path: "/" + path})
+			}
+		}
+	}
+	return routes
+}
+
+func (s *shim) Describe() pluginrpc.Manifest {
+	return s.manifest
+}
+
+// Handle 转发到插件进程内的控制器
+func (s *shim) Handle(req *pluginrpc.Request) (*pluginrpc.Response, error) {
+	url := fmt.Sprintf("http://%s%s/%s", s.addr, s.prefix, strings.TrimLeft(req.Path, "/"))
+	if len(req.Query) > 0 {
+		url += "?" + req.Query
+	}
+	httpReq, err := http.NewRequest(req.Method, url, bytes.NewReader(req.Body))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range req.Header {
+		httpReq.Header.Set(k, v)
+	}
+	httpReq.Header.Set(headerAdminId, strconv.FormatInt(req.AdminId, 10))
+	httpResp, err := s.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+	body, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, err
+	}
+	resp := &pluginrpc.Response{Status: httpResp.StatusCode, Header: map[string]string{}, Body: body}
+	for k := range httpResp.Header {
+		resp.Header[k] = httpResp.Header.Get(k)
+	}
+	return resp, nil
+}
+
+func (s *shim) Menu(pluginId int64) error {
+	s.pi.Menu(&tables.Menu{}, int(pluginId))
+	return nil
+}
+
+func (s *shim) Install() error {
+	s.pi.Install()
+	return nil
+}
+
+func (s *shim) Uninstall() error {
+	s.pi.Uninstall()
+	return rollback(s.pi)
+}
+
+func (s *shim) Upgrade() error {
+	s.pi.Upgrade()
+	return nil
+}
@@ -0,0 +1,70 @@
+// Package pluginrpc 进程外插件协议, 插件为独立可执行文件, 通过标准输入输出以 JSON-RPC 与主程序通信.
+// 标准输出专用于协议数据, 插件日志需写入标准错误.
+package pluginrpc
+
+// ProtocolVersion 协议版本, 主程序只加载版本一致的插件
+const ProtocolVersion = 1
+
+// ServiceName RPC服务名
+const ServiceName = "Plugin"
+
+// EnvProtocol 启动插件时传入的协议版本环境变量
+const EnvProtocol = "PINECMS_PLUGIN_PROTOCOL"
+
+// Route 插件路由, 路径相对于插件前缀, 只支持静态路径
+type Route struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+}
+
+// Manifest 插件启动后向主程序注册的信息
+type Manifest struct {
+	Protocol   int              `json:"protocol"`
+	Sign       string           `json:"sign"`
+	Name       string           `json:"name"`
+	Version    string           `json:"version"`
+	Prefix     string           `json:"prefix"`
+	Routes     []Route          `json:"routes"`
+	Menus      []map[string]any `json:"menus"`      // 主程序写入菜单表, 只写入菜单字段并自动设置 plugin_id
+	View       string           `json:"view"`       // 配置视图json
+	Events     []string         `json:"events"`     // 订阅的事件, * 表示全部
+	Migrations []Migration      `json:"migrations"` // 数据迁移, 由主程序在安装升级时执行, 卸载时回滚
+}
+
+// Migration 以SQL描述的数据迁移, Version 在插件内唯一, 按声明顺序执行
+type Migration struct {
+	Version string `json:"version"`
+	Up      string `json:"up"`
+	Down    string `json:"down"`
+}
+
+// Request 转发给插件的HTTP请求
+type Request struct {
+	Method  string            `json:"method"`
+	Path    string            `json:"path"`
+	Query   string            `json:"query"`
+	Header  map[string]string `json:"header"`
+	Body    []byte            `json:"body"`
+	AdminId int64             `json:"admin_id"`
+}
+
+// Response 插件返回的HTTP响应
+type Response struct {
+	Status int               `json:"status"`
+	Header map[string]string `json:"header"`
+	Body   []byte            `json:"body"`
+}
+
+// Event 事件通知
+type Event struct {
+	Name    string `json:"name"`
+	Payload []byte `json:"payload"` // JSON
+}
+
+// MenuArgs 安装菜单参数
+type MenuArgs struct {
+	PluginId int64 `json:"plugin_id"`
+}
+
+// Empty 无参数或无返回值
+type Empty struct{}
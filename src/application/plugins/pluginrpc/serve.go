@@ -0,0 +1,122 @@
+package pluginrpc
+
+import (
+	"fmt"
+	"io"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"os"
+)
+
+// Plugin 插件可执行文件需要实现的接口
+type Plugin interface {
+	Describe() Manifest
+	Handle(req *Request) (*Response, error)
+}
+
+// Installer 安装/卸载/升级回调, 可选实现
+type Installer interface {
+	Install() error
+	Uninstall() error
+	Upgrade() error
+}
+
+// EventHandler 事件回调, 可选实现
+type EventHandler interface {
+	Event(name string, payload []byte) error
+}
+
+// MenuInstaller 自行写入菜单, 可选实现
+type MenuInstaller interface {
+	Menu(pluginId int64) error
+}
+
+// stdio 标准输入输出组成的连接
+type stdio struct {
+	io.Reader
+	io.WriteCloser
+}
+
+func (s stdio) Close() error {
+	return s.WriteCloser.Close()
+}
+
+// Serve 在标准输入输出上提供插件服务, 主程序关闭连接后返回
+func Serve(p Plugin) error {
+	if version := os.Getenv(EnvProtocol); len(version) > 0 && version != fmt.Sprint(ProtocolVersion) {
+		return fmt.Errorf("主程序协议版本%s与插件协议版本%d不一致", version, ProtocolVersion)
+	}
+	conn := stdio{Reader: os.Stdin, WriteCloser: os.Stdout}
+	// 标准输出只用于协议, 其他输出转到标准错误
+	os.Stdout = os.Stderr
+
+	server := rpc.NewServer()
+	if err := server.RegisterName(ServiceName, &service{impl: p}); err != nil {
+		return err
+	}
+	server.ServeCodec(jsonrpc.NewServerCodec(conn))
+	return nil
+}
+
+// service RPC方法, 转发到插件实现
+type service struct {
+	impl Plugin
+}
+
+func (s *service) Describe(_ Empty, reply *Manifest) error {
+	*reply = s.impl.Describe()
+	reply.Protocol = ProtocolVersion
+	return nil
+}
+
+func (s *service) Ping(_ Empty, reply *int) error {
+	*reply = ProtocolVersion
+	return nil
+}
+
+func (s *service) Handle(req Request, reply *Response) error {
+	resp, err := s.impl.Handle(&req)
+	if err != nil {
+		return err
+	}
+	if resp == nil {
+		resp = &Response{Status: 204}
+	}
+	*reply = *resp
+	return nil
+}
+
+func (s *service) Event(event Event, _ *Empty) error {
+	if h, ok := s.impl.(EventHandler); ok {
+		return h.Event(event.Name, event.Payload)
+	}
+	return nil
+}
+
+func (s *service) Menu(args MenuArgs, _ *Empty) error {
+	if m, ok := s.impl.(MenuInstaller); ok {
+		return m.Menu(args.PluginId)
+	}
+	return nil
+}
+
+func (s *service) Install(_ Empty, _ *Empty) error {
+	if i, ok := s.impl.(Installer); ok {
+		return i.Install()
+	}
+	return nil
+}
+
+func (s *service) Uninstall(_ Empty, _ *Empty) error {
+	if i, ok := s.impl.(Installer); ok {
+		return i.Uninstall()
+	}
+	return nil
+}
+
+func (s *service) Upgrade(_ Empty, _ *Empty) error {
+	if i, ok := s.impl.(Installer); ok {
+		return i.Upgrade()
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+// Package sdk 插件开发工具: 按声明的能力限制容器中可获取的服务, 基于 View() 配置结构的类型化设置, 以及按版本执行的数据迁移.
+//
+// .so 插件与主程序同进程运行, 可以直接引用系统包, 能力声明只约束 Init 收到的容器, 不构成隔离.
+// 进程外(rpc)插件只能通过主程序代为执行的操作访问系统资源, 这些操作执行前按 Require 校验声明的能力.
+package sdk
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/xiusin/pine/di"
+	"github.com/xiusin/pinecms/src/application/controllers"
+	"github.com/xiusin/pinecms/src/common/message"
+)
+
+// 插件可声明的能力, 写在 config.json 的 capabilities 中
+const (
+	CapORM     = "orm"     // 数据库及表前缀
+	CapCache   = "cache"   // 缓存
+	CapStorage = "storage" // 上传存储引擎
+	CapMailer  = "mailer"  // 邮件发送
+	CapSearch  = "search"  // 搜索引擎
+	CapConfig  = "config"  // 系统配置
+)
+
+// capServices 能力对应的服务名, 以 . 结尾的为前缀匹配
+var capServices = map[string][]string{
+	CapORM:     {di.ResolveServiceName(controllers.ServiceXorm), controllers.ServiceTablePrefix},
+	CapCache:   {controllers.ServiceICache},
+	CapStorage: {strings.TrimSuffix(controllers.ServiceUploaderEngine, "%s")},
+	CapMailer:  {message.ServiceEmailMessage},
+	CapSearch:  {controllers.ServiceSearchName},
+	CapConfig:  {controllers.ServiceConfig},
+}
+
+// alwaysAllowed 不需要声明即可使用的服务
+var alwaysAllowed = []string{di.ResolveServiceName(slog.Default())}
+
+// Capabilities 全部可声明的能力
+func Capabilities() []string {
+	caps := make([]string, 0, len(capServices))
+	for name := range capServices {
+		caps = append(caps, name)
+	}
+	slices.Sort(caps)
+	return caps
+}
+
+// scoped 只暴露已声明能力的服务容器, 插件不能向全局容器注册服务
+type scoped struct {
+	di.AbstractBuilder
+	sign    string
+	allowed []string
+}
+
+// Scope 按声明的能力创建插件使用的服务容器
+func Scope(parent di.AbstractBuilder, sign string, caps []string) (di.AbstractBuilder, error) {
+	s := &scoped{AbstractBuilder: parent, sign: sign, allowed: slices.Clone(alwaysAllowed)}
+	for _, c := range caps {
+		services, ok := capServices[c]
+		if !ok {
+			return nil, fmt.Errorf("未知的插件能力%s, 可选: %s", c, strings.Join(Capabilities(), ", "))
+		}
+		s.allowed = append(s.allowed, services...)
+	}
+	return s, nil
+}
+
+// Require 校验插件声明了指定能力, 主程序代进程外插件访问系统资源前调用
+func Require(sign string, caps []string, need string) error {
+	if _, ok := capServices[need]; !ok {
+		return fmt.Errorf("未知的插件能力%s, 可选: %s", need, strings.Join(Capabilities(), ", "))
+	}
+	if !slices.Contains(caps, need) {
+		return fmt.Errorf("插件%s未声明%s能力", sign, need)
+	}
+	return nil
+}
+
+func (s *scoped) check(service any) error {
+	name := di.ResolveServiceName(service)
+	for _, allowed := range s.allowed {
+		if name == allowed || (strings.HasSuffix(allowed, ".") && strings.HasPrefix(name, allowed)) {
+			return nil
+		}
+	}
+	return fmt.Errorf("插件%s未声明使用服务%s的能力", s.sign, name)
+}
+
+func (s *scoped) Get(service any) (any, error) {
+	if err := s.check(service); err != nil {
+		return nil, err
+	}
+	return s.AbstractBuilder.Get(service)
+}
+
+func (s *scoped) GetWithParams(service any, params ...any) (any, error) {
+	if err := s.check(service); err != nil {
+		return nil, err
+	}
+	return s.AbstractBuilder.GetWithParams(service, params...)
+}
+
+func (s *scoped) MustGet(service any, params ...any) any {
+	if err := s.check(service); err != nil {
+		panic(err)
+	}
+	return s.AbstractBuilder.MustGet(service, params...)
+}
+
+func (s *scoped) GetDefinition(service any) (*di.Definition, error) {
+	if err := s.check(service); err != nil {
+		return nil, err
+	}
+	return s.AbstractBuilder.GetDefinition(service)
+}
+
+func (s *scoped) Exists(service any) bool {
+	return s.check(service) == nil && s.AbstractBuilder.Exists(service)
+}
+
+func (s *scoped) denied() {
+	panic(fmt.Errorf("插件%s不能向系统注册服务", s.sign))
+}
+
+func (s *scoped) Bind(any, di.BuildHandler) *di.Definition {
+	s.denied()
+	return nil
+}
+
+func (s *scoped) Singleton(any, di.BuildHandler) *di.Definition {
+	s.denied()
+	return nil
+}
+
+func (s *scoped) Instance(any, ...any) *di.Definition {
+	s.denied()
+	return nil
+}
+
+func (s *scoped) Register(...di.AbstractServiceProvider) {
+	s.denied()
+}
+
+func (s *scoped) Set(any, di.BuildHandler, bool) *di.Definition {
+	s.denied()
+	return nil
+}
+
+func (s *scoped) SetWithParams(any, di.BuildWithHandler) *di.Definition {
+	s.denied()
+	return nil
+}
+
+func (s *scoped) Add(*di.Definition) {
+	s.denied()
+}
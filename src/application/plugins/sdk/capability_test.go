@@ -0,0 +1,44 @@
+package sdk
+
+import (
+	"testing"
+
+	"github.com/xiusin/pine/di"
+	"github.com/xiusin/pinecms/src/application/controllers"
+)
+
+func TestScope(t *testing.T) {
+	services, err := Scope(di.GetDefaultDI(), "demo", []string{CapCache})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := services.(*scoped).check(controllers.ServiceICache); err != nil {
+		t.Fatalf("已声明的能力应可使用: %v", err)
+	}
+	if err := services.(*scoped).check(controllers.ServiceConfig); err == nil {
+		t.Fatal("未声明的能力不应可用")
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("插件不能向系统注册服务")
+			}
+		}()
+		services.Set("demo.service", nil, true)
+	}()
+	if _, err := Scope(di.GetDefaultDI(), "demo", []string{"shell"}); err == nil {
+		t.Fatal("未知的能力应返回错误")
+	}
+}
+
+func TestRequire(t *testing.T) {
+	if err := Require("demo", []string{CapORM}, CapORM); err != nil {
+		t.Fatal(err)
+	}
+	if err := Require("demo", []string{CapCache}, CapORM); err == nil {
+		t.Fatal("未声明能力时应返回错误")
+	}
+	if err := Require("demo", []string{"shell"}, "shell"); err == nil {
+		t.Fatal("未知的能力应返回错误")
+	}
+}
@@ -0,0 +1,79 @@
+package sdk
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/xiusin/pinecms/src/application/models/tables"
+	"xorm.io/xorm"
+)
+
+// Migration 插件数据迁移, Version 在同一插件内唯一, 按声明顺序执行
+type Migration struct {
+	Version string
+	Up      func(*xorm.Session) error
+	Down    func(*xorm.Session) error // 卸载时按相反顺序执行, 可为空
+}
+
+// Migrator 需要数据迁移的插件实现此接口, 安装与升级时执行未执行过的迁移, 卸载时回滚
+type Migrator interface {
+	Migrations() []Migration
+}
+
+// Migrate 执行插件未执行过的迁移, 每个迁移在独立事务中执行并记录
+func Migrate(orm *xorm.Engine, sign string, migrations []Migration) error {
+	if err := orm.Sync2(&tables.PluginMigration{}); err != nil {
+		return err
+	}
+	applied, err := appliedVersions(orm, sign)
+	if err != nil {
+		return err
+	}
+	for _, m := range migrations {
+		if slices.Contains(applied, m.Version) {
+			continue
+		}
+		if _, err := orm.Transaction(func(sess *xorm.Session) (any, error) {
+			if m.Up != nil {
+				if err := m.Up(sess); err != nil {
+					return nil, err
+				}
+			}
+			return sess.Insert(&tables.PluginMigration{Sign: sign, Version: m.Version})
+		}); err != nil {
+			return fmt.Errorf("插件%s执行迁移%s失败: %w", sign, m.Version, err)
+		}
+	}
+	return nil
+}
+
+// Rollback 按执行的相反顺序回滚插件已执行的迁移
+func Rollback(orm *xorm.Engine, sign string, migrations []Migration) error {
+	if exist, _ := orm.IsTableExist(&tables.PluginMigration{}); !exist {
+		return nil
+	}
+	applied, err := appliedVersions(orm, sign)
+	if err != nil {
+		return err
+	}
+	for i := len(applied) - 1; i >= 0; i-- {
+		idx := slices.IndexFunc(migrations, func(m Migration) bool { return m.Version == applied[i] })
+		if _, err := orm.Transaction(func(sess *xorm.Session) (any, error) {
+			if idx >= 0 && migrations[idx].Down != nil {
+				if err := migrations[idx].Down(sess); err != nil {
+					return nil, err
+				}
+			}
+			return sess.Where("sign = ? and version = ?", sign, applied[i]).Delete(&tables.PluginMigration{})
+		}); err != nil {
+			return fmt.Errorf("插件%s回滚迁移%s失败: %w", sign, applied[i], err)
+		}
+	}
+	return nil
+}
+
+func appliedVersions(orm *xorm.Engine, sign string) ([]string, error) {
+	var versions []string
+	err := orm.Table(&tables.PluginMigration{}).Where("sign = ?", sign).Asc("id").Cols("version").Find(&versions)
+	return versions, err
+}
@@ -0,0 +1,85 @@
+package sdk
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	_ "modernc.org/sqlite"
+	"xorm.io/xorm"
+)
+
+func testORM(t *testing.T) *xorm.Engine {
+	t.Helper()
+	orm, err := xorm.NewEngine("sqlite", "file:"+filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { orm.Close() })
+	return orm
+}
+
+func TestMigrate(t *testing.T) {
+	orm := testORM(t)
+	var executed []string
+	step := func(name string, err error) func(*xorm.Session) error {
+		return func(*xorm.Session) error {
+			executed = append(executed, name)
+			return err
+		}
+	}
+	migrations := []Migration{
+		{Version: "1.0.0", Up: step("up1", nil), Down: step("down1", nil)},
+		{Version: "1.1.0", Up: step("up2", nil), Down: step("down2", nil)},
+	}
+	if err := Migrate(orm, "demo", migrations); err != nil {
+		t.Fatal(err)
+	}
+	// 再次执行时跳过已执行的版本, 只执行新增的迁移
+	migrations = append(migrations, Migration{Version: "1.2.0", Up: step("up3", nil)})
+	if err := Migrate(orm, "demo", migrations); err != nil {
+		t.Fatal(err)
+	}
+	if versions, _ := appliedVersions(orm, "demo"); !slices.Equal(versions, []string{"1.0.0", "1.1.0", "1.2.0"}) {
+		t.Fatalf("迁移记录错误: %v", versions)
+	}
+	if err := Rollback(orm, "demo", migrations); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"up1", "up2", "up3", "down2", "down1"}; !slices.Equal(executed, want) {
+		t.Fatalf("执行顺序错误: %v", executed)
+	}
+	if versions, _ := appliedVersions(orm, "demo"); len(versions) != 0 {
+		t.Fatalf("回滚后应清除迁移记录: %v", versions)
+	}
+}
+
+func TestMigrateFailure(t *testing.T) {
+	orm := testORM(t)
+	migrations := []Migration{
+		{Version: "1", Up: func(sess *xorm.Session) error {
+			_, err := sess.Exec("CREATE TABLE demo_item (id INTEGER PRIMARY KEY)")
+			return err
+		}},
+		{Version: "2", Up: func(sess *xorm.Session) error {
+			if _, err := sess.Exec("INSERT INTO demo_item (id) VALUES (1)"); err != nil {
+				return err
+			}
+			return errors.New("失败")
+		}},
+	}
+	if err := Migrate(orm, "demo", migrations); err == nil {
+		t.Fatal("迁移失败时应返回错误")
+	}
+	if versions, _ := appliedVersions(orm, "demo"); !slices.Equal(versions, []string{"1"}) {
+		t.Fatalf("失败的迁移不应记录: %v", versions)
+	}
+	if count, _ := orm.Table("demo_item").Count(); count != 0 {
+		t.Fatalf("失败的迁移应在事务中回滚: %d", count)
+	}
+	// 其他插件的迁移记录互不影响
+	if versions, _ := appliedVersions(orm, "other"); len(versions) != 0 {
+		t.Fatalf("迁移记录应按插件区分: %v", versions)
+	}
+}
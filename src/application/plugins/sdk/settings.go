@@ -0,0 +1,257 @@
+package sdk
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+
+	"github.com/bytedance/sonic"
+	"github.com/spf13/cast"
+	"github.com/xiusin/pinecms/src/application/models/tables"
+	"github.com/xiusin/pinecms/src/common/helper"
+)
+
+// Rule 字段校验规则, 与前端表单 rules 一致
+type Rule struct {
+	Required bool     `json:"required"`
+	Message  string   `json:"message"`
+	Type     string   `json:"type"` // string number integer boolean array email url
+	Pattern  string   `json:"pattern"`
+	Min      *float64 `json:"min"` // 数字为取值范围, 字符串与数组为长度
+	Max      *float64 `json:"max"`
+	Enum     []any    `json:"enum"`
+}
+
+// Field 配置项, 即 View() 中的一项
+type Field struct {
+	Label     string `json:"label"`
+	Prop      string `json:"prop"`
+	Value     any    `json:"value"` // 默认值
+	Component struct {
+		Name    string `json:"name"`
+		Options []struct {
+			Label string `json:"label"`
+			Value any    `json:"value"`
+		} `json:"options"`
+	} `json:"component"`
+	Rules []Rule `json:"-"`
+}
+
+// Schema 插件配置结构
+type Schema []*Field
+
+// ParseSchema 解析 View() 返回的配置结构, rules 可以是对象或数组
+func ParseSchema(view []byte) (Schema, error) {
+	var raw []map[string]any
+	if err := sonic.Unmarshal(view, &raw); err != nil {
+		return nil, fmt.Errorf("插件配置结构错误: %w", err)
+	}
+	schema := make(Schema, 0, len(raw))
+	for _, item := range raw {
+		field := &Field{}
+		content, _ := sonic.Marshal(item)
+		if err := sonic.Unmarshal(content, field); err != nil {
+			return nil, fmt.Errorf("插件配置项错误: %w", err)
+		}
+		if len(field.Prop) == 0 {
+			return nil, fmt.Errorf("配置项%s缺少prop", field.Label)
+		}
+		rules := item["rules"]
+		if _, ok := rules.(map[string]any); ok {
+			rules = []any{rules}
+		}
+		if rules != nil {
+			content, _ = sonic.Marshal(rules)
+			if err := sonic.Unmarshal(content, &field.Rules); err != nil {
+				return nil, fmt.Errorf("配置项%s的rules错误: %w", field.Prop, err)
+			}
+		}
+		schema = append(schema, field)
+	}
+	return schema, nil
+}
+
+// Validate 按配置结构校验设置, 补充默认值并转换类型, 未定义的项会被丢弃
+func (s Schema) Validate(values map[string]any) (map[string]any, error) {
+	settings := map[string]any{}
+	for _, field := range s {
+		value, exist := values[field.Prop]
+		if !exist || empty(value) {
+			value = field.Value
+		}
+		typ := field.typ()
+		for _, rule := range field.Rules {
+			if rule.Required && empty(value) {
+				return nil, field.error(rule, "不能为空")
+			}
+		}
+		if empty(value) {
+			settings[field.Prop] = value
+			continue
+		}
+		value, err := convert(value, typ)
+		if err != nil {
+			return nil, field.error(Rule{}, "类型应为"+typ)
+		}
+		if err := field.check(value, typ); err != nil {
+			return nil, err
+		}
+		settings[field.Prop] = value
+	}
+	return settings, nil
+}
+
+// typ 字段类型, 优先使用规则中的 type, 否则按组件推断
+func (f *Field) typ() string {
+	for _, rule := range f.Rules {
+		if len(rule.Type) > 0 {
+			return rule.Type
+		}
+	}
+	switch f.Component.Name {
+	case "el-input-number", "el-slider", "el-rate":
+		return "number"
+	case "el-switch":
+		return "boolean"
+	case "el-checkbox-group":
+		return "array"
+	}
+	return "string"
+}
+
+func (f *Field) check(value any, typ string) error {
+	if len(f.Component.Options) > 0 && typ != "array" {
+		found := false
+		for _, opt := range f.Component.Options {
+			found = found || cast.ToString(opt.Value) == cast.ToString(value)
+		}
+		if !found {
+			return f.error(Rule{}, "不在可选范围内")
+		}
+	}
+	for _, rule := range f.Rules {
+		size, sized := 0.0, true
+		switch v := value.(type) {
+		case string:
+			size = float64(len([]rune(v)))
+		case []any:
+			size = float64(len(v))
+		case float64:
+			size = v
+		case int64:
+			size = float64(v)
+		default:
+			sized = false
+		}
+		if sized && rule.Min != nil && size < *rule.Min {
+			return f.error(rule, fmt.Sprintf("不能小于%v", *rule.Min))
+		}
+		if sized && rule.Max != nil && size > *rule.Max {
+			return f.error(rule, fmt.Sprintf("不能大于%v", *rule.Max))
+		}
+		if len(rule.Pattern) > 0 {
+			re, err := regexp.Compile(rule.Pattern)
+			if err != nil {
+				return fmt.Errorf("配置项%s的pattern错误: %w", f.Prop, err)
+			}
+			if !re.MatchString(cast.ToString(value)) {
+				return f.error(rule, "格式错误")
+			}
+		}
+		if len(rule.Enum) > 0 {
+			found := false
+			for _, e := range rule.Enum {
+				found = found || cast.ToString(e) == cast.ToString(value)
+			}
+			if !found {
+				return f.error(rule, "不在可选范围内")
+			}
+		}
+		switch rule.Type {
+		case "email":
+			if _, err := mail.ParseAddress(cast.ToString(value)); err != nil {
+				return f.error(rule, "不是有效的邮箱")
+			}
+		case "url":
+			if u, err := url.Parse(cast.ToString(value)); err != nil || len(u.Scheme) == 0 || len(u.Host) == 0 {
+				return f.error(rule, "不是有效的网址")
+			}
+		}
+	}
+	return nil
+}
+
+func (f *Field) error(rule Rule, reason string) error {
+	if len(rule.Message) > 0 {
+		return errors.New(rule.Message)
+	}
+	label := f.Label
+	if len(label) == 0 {
+		label = f.Prop
+	}
+	return errors.New(label + reason)
+}
+
+func convert(value any, typ string) (any, error) {
+	switch typ {
+	case "number", "float":
+		return cast.ToFloat64E(value)
+	case "integer":
+		return cast.ToInt64E(value)
+	case "boolean":
+		return cast.ToBoolE(value)
+	case "array":
+		if reflect.ValueOf(value).Kind() != reflect.Slice {
+			return nil, errors.New("not array")
+		}
+		return cast.ToSliceE(value)
+	}
+	return cast.ToStringE(value)
+}
+
+func empty(value any) bool {
+	if value == nil {
+		return true
+	}
+	if s, ok := value.(string); ok {
+		return len(s) == 0
+	}
+	return false
+}
+
+// Settings 读取插件设置, 已按配置结构补充默认值
+func Settings(sign string) (map[string]any, error) {
+	plugin := &tables.Plugin{}
+	if exist, err := helper.GetORM().Where("sign = ?", sign).Get(plugin); err != nil {
+		return nil, err
+	} else if !exist {
+		return nil, fmt.Errorf("插件%s未安装", sign)
+	}
+	return PluginSettings(plugin)
+}
+
+// PluginSettings 按插件记录中的配置结构校验并返回设置
+func PluginSettings(plugin *tables.Plugin) (map[string]any, error) {
+	view, _ := sonic.Marshal(plugin.View)
+	schema, err := ParseSchema(view)
+	if err != nil {
+		return nil, err
+	}
+	return schema.Validate(plugin.Config)
+}
+
+// Bind 读取插件设置到结构体, 字段使用 json 标签对应 prop
+func Bind(sign string, out any) error {
+	settings, err := Settings(sign)
+	if err != nil {
+		return err
+	}
+	content, err := sonic.Marshal(settings)
+	if err != nil {
+		return err
+	}
+	return sonic.Unmarshal(content, out)
+}
@@ -0,0 +1,67 @@
+package sdk
+
+import (
+	"testing"
+)
+
+const testView = `[
+	{"label": "站点名称", "prop": "name", "value": "pinecms", "rules": {"required": true, "min": 2, "max": 10}},
+	{"label": "每页数量", "prop": "size", "value": 10, "component": {"name": "el-input-number"}, "rules": [{"min": 1, "max": 100}]},
+	{"label": "开启", "prop": "enable", "component": {"name": "el-switch"}},
+	{"label": "模式", "prop": "mode", "value": "a", "component": {"name": "el-select", "options": [{"label": "A", "value": "a"}, {"label": "B", "value": "b"}]}},
+	{"label": "邮箱", "prop": "email", "rules": {"type": "email", "message": "邮箱格式错误"}},
+	{"label": "编码", "prop": "code", "rules": {"pattern": "^[a-z]+$"}}
+]`
+
+func TestParseSchema(t *testing.T) {
+	schema, err := ParseSchema([]byte(testView))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(schema) != 6 || len(schema[0].Rules) != 1 || len(schema[1].Rules) != 1 {
+		t.Fatalf("解析配置结构错误: %+v", schema)
+	}
+	if _, err := ParseSchema([]byte(`[{"label": "缺少prop"}]`)); err == nil {
+		t.Fatal("缺少prop时应返回错误")
+	}
+	if _, err := ParseSchema([]byte(`{`)); err == nil {
+		t.Fatal("结构错误时应返回错误")
+	}
+}
+
+func TestSchemaValidate(t *testing.T) {
+	schema, err := ParseSchema([]byte(testView))
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings, err := schema.Validate(map[string]any{"size": "20", "enable": "true", "unknown": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings["name"] != "pinecms" || settings["mode"] != "a" {
+		t.Fatalf("未设置的项应使用默认值: %v", settings)
+	}
+	if settings["size"] != float64(20) || settings["enable"] != true {
+		t.Fatalf("类型转换错误: %#v", settings)
+	}
+	if _, ok := settings["unknown"]; ok {
+		t.Fatal("未定义的项应丢弃")
+	}
+
+	cases := map[string]map[string]any{
+		"长度不足":   {"name": "p"},
+		"超出范围":   {"size": 101},
+		"类型错误":   {"size": "abc"},
+		"不在可选范围": {"mode": "c"},
+		"邮箱格式":   {"email": "pinecms"},
+		"格式错误":   {"code": "ABC"},
+	}
+	for name, values := range cases {
+		if _, err := schema.Validate(values); err == nil {
+			t.Fatalf("%s: 应校验失败", name)
+		}
+	}
+	if _, err := schema.Validate(map[string]any{"email": "pinecms"}); err == nil || err.Error() != "邮箱格式错误" {
+		t.Fatalf("应使用规则中的提示信息: %v", err)
+	}
+}
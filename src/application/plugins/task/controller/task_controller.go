@@ -28,13 +28,20 @@ func (c *TaskController) Construct() {
 }
 
 func (c *TaskController) before(act int, query any) error {
+	if act == backend.OpAdd || act == backend.OpEdit {
+		if task := query.(*table.TaskInfo); task.JobType == manager.TypeShell {
+			if _, err := manager.CheckShell(task.Service); err != nil {
+				return err
+			}
+		}
+	}
 	if act == backend.OpList {
 		sess := query.(*xorm.Session)
 		status, _ := c.Input().GetInt("status")
 		sess.Where("status = ?", status)
 		if status == 1 {
 			t, _ := c.Input().GetInt("type")
-			sess.Where("`type` = ?", t)
+			sess.Where(c.Orm.Quote("type")+" = ?", t)
 		}
 	}
 	return nil
@@ -65,7 +72,8 @@ func (c *TaskController) PostOnce() {
 	helper.PanicErr(err)
 	c.Orm.Where(c.TableKey+"=?", id).Get(task)
 
-	manager.TaskJobFunc(task)()
+	// 手动触发不受调度锁限制, 在后台执行
+	go manager.Run(task)
 	helper.Ajax("任务触发成功", 0, c.Ctx())
 }
 
@@ -0,0 +1,282 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"os"
+	"os/exec"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/traefik/yaegi/interp"
+	"github.com/traefik/yaegi/stdlib"
+	"github.com/xiusin/pine/di"
+	"github.com/xiusin/pinecms/src/application/plugins/task/table"
+	"github.com/xiusin/pinecms/src/config"
+	"xorm.io/xorm"
+)
+
+// 任务类型
+const (
+	TypeScript = "script" // tasks/{service}.sh 中的 yaegi 脚本
+	TypeShell  = "shell"  // 系统命令
+	TypeHttp   = "http"   // 请求URL
+	TypeFunc   = "func"   // 通过 RegisterFunc 注册的函数
+)
+
+// maxOutput 记录到日志的输出长度上限
+const maxOutput = 4096
+
+// Job 任务执行器, ctx 在超时或取消时结束, 返回的字符串记录到日志
+type Job interface {
+	Run(ctx context.Context, task *table.TaskInfo) (string, error)
+}
+
+// JobFunc 函数形式的执行器
+type JobFunc func(ctx context.Context, task *table.TaskInfo) (string, error)
+
+func (fn JobFunc) Run(ctx context.Context, task *table.TaskInfo) (string, error) {
+	return fn(ctx, task)
+}
+
+var (
+	jobMu sync.RWMutex
+	jobs  = map[string]Job{}
+	funcs = map[string]JobFunc{}
+)
+
+// RegisterJob 注册任务类型, 同名覆盖
+func RegisterJob(typ string, job Job) {
+	jobMu.Lock()
+	defer jobMu.Unlock()
+	jobs[typ] = job
+}
+
+// RegisterFunc 注册可由 func 类型任务调用的函数, 任务的 service 为函数名
+func RegisterFunc(name string, fn JobFunc) {
+	jobMu.Lock()
+	defer jobMu.Unlock()
+	funcs[name] = fn
+}
+
+// Funcs 已注册的函数名
+func Funcs() []string {
+	jobMu.RLock()
+	defer jobMu.RUnlock()
+	names := make([]string, 0, len(funcs))
+	for name := range funcs {
+		names = append(names, name)
+	}
+	return names
+}
+
+func getJob(typ string) (Job, error) {
+	if len(typ) == 0 {
+		typ = TypeScript
+	}
+	jobMu.RLock()
+	defer jobMu.RUnlock()
+	job, ok := jobs[typ]
+	if !ok {
+		return nil, fmt.Errorf("不支持的任务类型%s", typ)
+	}
+	return job, nil
+}
+
+func init() {
+	RegisterJob(TypeScript, &scriptJob{loaded: map[string]*script{}})
+	RegisterJob(TypeShell, JobFunc(shellJob))
+	RegisterJob(TypeHttp, JobFunc(httpJob))
+	RegisterJob(TypeFunc, JobFunc(funcJob))
+}
+
+// script 已加载的脚本及其修改时间
+type script struct {
+	fn    func(*xorm.Engine) (string, error)
+	mtime time.Time
+}
+
+// scriptJob 执行 yaegi 脚本, 脚本修改后重新加载
+type scriptJob struct {
+	sync.Mutex
+	loaded map[string]*script
+}
+
+func (j *scriptJob) Run(ctx context.Context, task *table.TaskInfo) (string, error) {
+	fn, err := j.load(taskScript(task.Service))
+	if err != nil {
+		return "", err
+	}
+	// 脚本无法中断, 超时后不再等待其结果
+	return wait(ctx, func() (string, error) { return fn(tm.orm) })
+}
+
+func (j *scriptJob) load(path string) (func(*xorm.Engine) (string, error), error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("脚本状态异常: %w", err)
+	}
+	j.Lock()
+	defer j.Unlock()
+	if s, ok := j.loaded[path]; ok && !info.ModTime().After(s.mtime) {
+		return s.fn, nil
+	}
+	engine, err := initYaegi()
+	if err != nil {
+		return nil, err
+	}
+	// 允许直接使用真实路径, 解析脚本时替换为映射包路径 如 xorm.io/engine => pinecms/engine
+	if _, err = engine.EvalPath(path); err != nil {
+		return nil, fmt.Errorf("脚本语法错误: %w", err)
+	}
+	v, err := engine.Eval(tm.ExecFn)
+	if err != nil {
+		return nil, fmt.Errorf("执行脚本错误: %w", err)
+	}
+	fn, ok := v.Interface().(func(*xorm.Engine) (string, error))
+	if !ok {
+		return nil, fmt.Errorf("脚本函数%s签名应为 func(*xorm.Engine) (string, error)", tm.ExecFn)
+	}
+	j.loaded[path] = &script{fn: fn, mtime: info.ModTime()}
+	return fn, nil
+}
+
+// scriptSymbols 脚本可用的标准库, stdlib.Symbols 不含 os/exec 与 syscall, 再去除 os.StartProcess,
+// 执行系统命令只能通过受名单限制的 shell 类型任务
+func scriptSymbols() interp.Exports {
+	symbols := maps.Clone(stdlib.Symbols)
+	symbols["os/os"] = maps.Clone(symbols["os/os"])
+	delete(symbols["os/os"], "StartProcess")
+	return symbols
+}
+
+func initYaegi() (*interp.Interpreter, error) {
+	i := interp.New(interp.Options{})
+	if err := i.Use(scriptSymbols()); err != nil {
+		return nil, fmt.Errorf("导入标准库失败: %w", err)
+	}
+	err := i.Use(interp.Exports{
+		"pinecms/pinecms": {
+			"DI": reflect.ValueOf(di.GetDefaultDI()),
+			"DB": reflect.ValueOf(xorm.Engine{}),
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("导出自定义包异常: %w", err)
+	}
+	return i, nil
+}
+
+// CheckShell 校验 shell 类型任务的命令, 需在 application.yml 中开启 task.shell 且命令在 commands 名单内
+func CheckShell(command string) ([]string, error) {
+	conf := config.App().Task.Shell
+	if !conf.Enable {
+		return nil, errors.New("shell 类型任务未开启")
+	}
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return nil, errors.New("命令不能为空")
+	}
+	if !slices.Contains(conf.Commands, args[0]) {
+		return nil, fmt.Errorf("命令%s不在允许名单内", args[0])
+	}
+	return args, nil
+}
+
+// shellJob 执行名单内的系统命令, 参数按空白分隔且不经过 shell 解释, 超时后结束进程
+func shellJob(ctx context.Context, task *table.TaskInfo) (string, error) {
+	args, err := CheckShell(task.Service)
+	if err != nil {
+		return "", err
+	}
+	out, err := exec.CommandContext(ctx, args[0], args[1:]...).CombinedOutput()
+	if ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	return truncate(string(out)), err
+}
+
+// httpRequest http 类型任务的 data 配置
+type httpRequest struct {
+	Method string            `json:"method"`
+	Header map[string]string `json:"header"`
+	Body   string            `json:"body"`
+}
+
+// httpJob 请求 service 中的URL, 状态码大于等于400视为失败
+func httpJob(ctx context.Context, task *table.TaskInfo) (string, error) {
+	conf := httpRequest{Method: http.MethodGet}
+	if len(strings.TrimSpace(task.Data)) > 0 {
+		if err := sonic.Unmarshal([]byte(task.Data), &conf); err != nil {
+			return "", fmt.Errorf("请求配置错误: %w", err)
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, strings.ToUpper(conf.Method), task.Service, strings.NewReader(conf.Body))
+	if err != nil {
+		return "", err
+	}
+	for k, v := range conf.Header {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxOutput))
+	out := fmt.Sprintf("%s %s", resp.Status, body)
+	if resp.StatusCode >= http.StatusBadRequest {
+		return out, errors.New("请求失败: " + resp.Status)
+	}
+	return out, nil
+}
+
+// funcJob 执行注册的函数
+func funcJob(ctx context.Context, task *table.TaskInfo) (string, error) {
+	jobMu.RLock()
+	fn, ok := funcs[task.Service]
+	jobMu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("函数%s未注册", task.Service)
+	}
+	return fn(ctx, task)
+}
+
+// wait 在协程中执行 fn, ctx 结束时直接返回
+func wait(ctx context.Context, fn func() (string, error)) (string, error) {
+	type result struct {
+		msg string
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		defer func() {
+			if err := recover(); err != nil {
+				done <- result{err: fmt.Errorf("%v", err)}
+			}
+		}()
+		msg, err := fn()
+		done <- result{msg, err}
+	}()
+	select {
+	case r := <-done:
+		return r.msg, r.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+func truncate(s string) string {
+	if len(s) > maxOutput {
+		return s[:maxOutput] + "..."
+	}
+	return s
+}
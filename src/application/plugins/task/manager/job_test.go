@@ -0,0 +1,67 @@
+package manager
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/xiusin/pinecms/src/application/plugins/task/table"
+	"github.com/xiusin/pinecms/src/config"
+)
+
+func TestShellJob(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("依赖 echo 命令")
+	}
+	shell := &config.App().Task.Shell
+	t.Cleanup(func() { shell.Enable, shell.Commands = false, nil })
+
+	task := &table.TaskInfo{Service: "echo hello"}
+	if _, err := shellJob(context.Background(), task); err == nil {
+		t.Fatal("未开启时不应执行命令")
+	}
+
+	shell.Enable = true
+	if _, err := shellJob(context.Background(), task); err == nil {
+		t.Fatal("不在名单内的命令不应执行")
+	}
+
+	shell.Commands = []string{"echo"}
+	out, err := shellJob(context.Background(), task)
+	if err != nil || strings.TrimSpace(out) != "hello" {
+		t.Fatalf("名单内的命令应执行: %q %v", out, err)
+	}
+
+	// 命令不经过 shell 解释, 连接符作为参数原样传递
+	out, err = shellJob(context.Background(), &table.TaskInfo{Service: "echo a; rm -rf x"})
+	if err != nil || strings.TrimSpace(out) != "a; rm -rf x" {
+		t.Errorf("命令不应经过 shell 解释: %q %v", out, err)
+	}
+	if _, err = CheckShell("  "); err == nil {
+		t.Error("空命令应返回错误")
+	}
+}
+
+func TestScriptSymbols(t *testing.T) {
+	for _, src := range []string{
+		`import "os/exec"; var _ = exec.Command`,
+		`import "syscall"; var _ = syscall.Exec`,
+		`import "os"; var _ = os.StartProcess`,
+	} {
+		i, err := initYaegi()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = i.Eval(src); err == nil {
+			t.Errorf("脚本不应能执行系统命令: %s", src)
+		}
+	}
+	i, err := initYaegi()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = i.Eval(`import "strings"; var _ = strings.ToUpper("a")`); err != nil {
+		t.Errorf("脚本应能使用其他标准库: %v", err)
+	}
+}
@@ -0,0 +1,67 @@
+package manager
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/xiusin/pinecms/src/application/plugins/task/table"
+)
+
+const (
+	lockName     = "scheduler"
+	lockLease    = 30 * time.Second // 持有锁的有效期, 主实例异常退出后由其他实例接管
+	lockInterval = 10 * time.Second // 续期间隔
+)
+
+// leader 基于数据库的调度锁, 多实例共用数据库时只有持有者触发定时任务
+type leader struct {
+	owner string
+	until atomic.Int64 // 本实例持有锁的截止时间
+}
+
+func newLeader() *leader {
+	host, _ := os.Hostname()
+	nonce := make([]byte, 8)
+	_, _ = rand.Read(nonce)
+	return &leader{owner: fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(nonce))}
+}
+
+// IsLeader 本实例是否持有调度锁
+func (l *leader) IsLeader() bool {
+	return time.Now().UnixNano() < l.until.Load()
+}
+
+// acquire 获取或续期调度锁, 锁由本实例持有或已过期时才能获取
+func (l *leader) acquire() {
+	now := time.Now()
+	expired := now.Add(lockLease)
+	lock := &table.TaskLock{Name: lockName, Owner: l.owner, ExpiredAt: expired.Unix()}
+	affected, err := tm.orm.Where("name = ? and (owner = ? or expired_at < ?)", lockName, l.owner, now.Unix()).
+		Cols("owner", "expired_at").Update(lock)
+	if err == nil && affected == 0 {
+		if exist, _ := tm.orm.Where("name = ?", lockName).Exist(&table.TaskLock{}); !exist {
+			affected, err = tm.orm.InsertOne(lock) // 并发插入时主键冲突的一方失败
+		}
+	}
+	if err != nil || affected == 0 {
+		if l.IsLeader() {
+			tm.logger.Info("[plugin:task] 调度锁已由其他实例持有")
+		}
+		l.until.Store(0)
+		return
+	}
+	// 提前一个续期间隔失效, 避免与接管的实例同时触发
+	l.until.Store(expired.Add(-lockInterval).UnixNano())
+}
+
+// keep 定时续期调度锁
+func (l *leader) keep() {
+	l.acquire()
+	for range time.Tick(lockInterval) {
+		l.acquire()
+	}
+}
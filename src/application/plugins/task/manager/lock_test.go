@@ -0,0 +1,66 @@
+package manager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/xiusin/pinecms/src/application/plugins/task/table"
+)
+
+func TestLeader(t *testing.T) {
+	testManager(t)
+	first, second := newLeader(), newLeader()
+
+	first.acquire()
+	if !first.IsLeader() {
+		t.Fatal("锁不存在时应获取调度锁")
+	}
+	second.acquire()
+	if second.IsLeader() {
+		t.Fatal("锁由其他实例持有时不应获取")
+	}
+
+	// 续期不改变持有者
+	first.acquire()
+	if !first.IsLeader() {
+		t.Fatal("持有者应可续期")
+	}
+
+	// 持有者退出后锁过期, 其他实例接管, 原持有者失去锁
+	_, err := tm.orm.Where("name = ?", lockName).Cols("expired_at").
+		Update(&table.TaskLock{ExpiredAt: time.Now().Add(-time.Second).Unix()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second.acquire()
+	if !second.IsLeader() {
+		t.Fatal("锁过期后应由其他实例接管")
+	}
+	first.acquire()
+	if first.IsLeader() {
+		t.Error("锁被接管后原持有者不应继续触发任务")
+	}
+
+	var lock table.TaskLock
+	if _, err = tm.orm.Where("name = ?", lockName).Get(&lock); err != nil || lock.Owner != second.owner {
+		t.Errorf("锁持有者错误: %+v %v", lock, err)
+	}
+	if count, _ := tm.orm.Count(&table.TaskLock{}); count != 1 {
+		t.Errorf("调度锁应只有一条记录: %d", count)
+	}
+}
+
+func TestLeaderLease(t *testing.T) {
+	testManager(t)
+	l := newLeader()
+	l.acquire()
+	// 本地有效期比数据库中的过期时间提前一个续期间隔
+	until := time.Unix(0, l.until.Load())
+	if left := time.Until(until); left > lockLease-lockInterval || left <= 0 {
+		t.Errorf("本地有效期错误: %s", left)
+	}
+	l.until.Store(time.Now().Add(-time.Second).UnixNano())
+	if l.IsLeader() {
+		t.Error("有效期过后不应再触发任务")
+	}
+}
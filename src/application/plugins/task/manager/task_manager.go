@@ -1,18 +1,17 @@
 package manager
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
-	"reflect"
 	"sync"
 	"time"
 
 	"github.com/xiusin/pinecms/src/application/plugins/task/table"
 
 	"github.com/robfig/cron/v3"
-	"github.com/traefik/yaegi/interp"
-	"github.com/traefik/yaegi/stdlib"
 	"github.com/xiusin/pine"
 	"github.com/xiusin/pine/di"
 	"github.com/xiusin/pinecms/src/common/helper"
@@ -21,30 +20,43 @@ import (
 
 var tm *taskManager
 
-type TaskFunc func(*xorm.Engine) (string, error)
+// 上次执行未结束时的处理方式
+const (
+	ConcurrencySkip  = 0 // 跳过本次
+	ConcurrencyQueue = 1 // 等待上次结束后执行
+	ConcurrencyAllow = 2 // 并行执行
+)
+
+// maxRetryDelay 重试间隔上限
+const maxRetryDelay = 10 * time.Minute
+
+// sleep 重试前等待, 测试时替换
+var sleep = time.Sleep
 
 type taskManager struct {
 	sync.Once
 	sync.Mutex
 
-	pool              map[int64]TaskFunc
-	loadedScriptMTime map[string]time.Time // 记录脚本的修改时间
-	orm               *xorm.Engine
-	logger            cron.Logger
-	cron              *cron.Cron
-	scriptPath        string
-	ExecFn            string
+	entries    map[int64]cron.EntryID  // 任务ID => 定时器ID
+	running    map[int64]chan struct{} // 任务执行令牌, 用于控制重复执行
+	orm        *xorm.Engine
+	logger     cron.Logger
+	cron       *cron.Cron
+	leader     *leader
+	scriptPath string
+	ExecFn     string
 }
 
 func TaskManager() *taskManager {
 	if tm == nil {
 		tm = &taskManager{
-			logger:            &logger{pine.Logger()},
-			orm:               di.MustGet(&xorm.Engine{}).(*xorm.Engine),
-			scriptPath:        "tasks",
-			pool:              map[int64]TaskFunc{},
-			loadedScriptMTime: map[string]time.Time{},
-			ExecFn:            "tasks.Run",
+			logger:     &logger{pine.Logger()},
+			orm:        di.MustGet(&xorm.Engine{}).(*xorm.Engine),
+			scriptPath: "tasks",
+			entries:    map[int64]cron.EntryID{},
+			running:    map[int64]chan struct{}{},
+			leader:     newLeader(),
+			ExecFn:     "tasks.Run",
 		}
 		_ = os.Mkdir(tm.scriptPath, os.ModePerm)
 	}
@@ -60,34 +72,24 @@ func (tm *taskManager) CheckErr(err error, msg string, _panic bool, extra ...any
 	}
 }
 
-func (tm *taskManager) initYaegi() (i *interp.Interpreter, err error) {
-	i = interp.New(interp.Options{})
-	err = i.Use(stdlib.Symbols)
-	tm.CheckErr(err, "导入标准库失败", false)
-	builder := di.GetDefaultDI()
-	err = i.Use(interp.Exports{
-		"pinecms/pinecms": {
-			"DI": reflect.ValueOf(builder),
-			"DB": reflect.ValueOf(xorm.Engine{}),
-		},
-	})
-	tm.CheckErr(err, "导出自定义包异常", false)
-	return i, err
-
+// IsLeader 本实例是否负责触发定时任务
+func (tm *taskManager) IsLeader() bool {
+	return tm.leader.IsLeader()
 }
 
 func (tm *taskManager) Cron() {
 	tm.Do(func() {
-		cron.Recover(tm.logger)
 		tm.cron = cron.New(
 			cron.WithSeconds(),
 			cron.WithLocation(helper.GetLocation()),
+			cron.WithChain(cron.Recover(tm.logger)),
 		)
 		var tasks []table.TaskInfo
 		_ = tm.orm.Table(&table.TaskInfo{}).Where("status = ?", 1).Find(&tasks)
-		for _, task := range tasks {
-			RegisterTask(task.Id, &task)
+		for i := range tasks {
+			RegisterTask(tasks[i].Id, &tasks[i])
 		}
+		go tm.leader.keep()
 		pine.Logger().Info("[plugin:task] 启动定时任务")
 		go tm.cron.Start()
 	})
@@ -97,7 +99,7 @@ func taskScript(service string) string {
 	return filepath.Join(tm.scriptPath, service+".sh")
 }
 
-// RegisterTask 注册任务到任务管理对象
+// RegisterTask 注册任务到任务管理对象, 已注册的任务会先移除
 func RegisterTask(id int64, task *table.TaskInfo) {
 	tm.Lock()
 	defer tm.Unlock()
@@ -109,6 +111,10 @@ func RegisterTask(id int64, task *table.TaskInfo) {
 			return
 		}
 	}
+	if entryId, exist := tm.entries[task.Id]; exist {
+		tm.cron.Remove(entryId)
+		delete(tm.entries, task.Id)
+	}
 	if task.Status == 0 {
 		return
 	}
@@ -116,33 +122,28 @@ func RegisterTask(id int64, task *table.TaskInfo) {
 	if task.TaskType == 1 && task.Every > 0 {
 		cronExpr = fmt.Sprintf("@every %ds", task.Every)
 	}
-	entityId, err := tm.cron.AddFunc(cronExpr, TaskJobFunc(task))
+	entryId, err := tm.cron.AddFunc(cronExpr, TaskJobFunc(task))
 	msg := ""
 	if err != nil {
 		msg = err.Error()
 		tm.CheckErr(err, "注册定时任务失败", false, task)
+	} else {
+		tm.entries[task.Id] = entryId
 	}
 	_, err = tm.orm.Where("id = ?", task.Id).Cols("entity_id", "error").Update(&table.TaskInfo{
-		EntityId: int(entityId),
+		EntityId: int(entryId),
 		Error:    msg,
 	})
 	tm.CheckErr(err, "注册定时任务失败", false, task)
 }
 
+// TaskJobFunc 定时触发的任务, 只在持有调度锁的实例上执行
 func TaskJobFunc(info *table.TaskInfo) func() {
 	id := info.Id
 	return func() {
-		var dur time.Duration
-		defer func() {
-			if err := recover(); err != nil {
-				_, _ = tm.orm.InsertOne(&table.TaskLog{
-					TaskId:   id,
-					Status:   false,
-					Detail:   fmt.Sprintf("%s", err),
-					ExecTime: dur.Milliseconds(),
-				})
-			}
-		}()
+		if !tm.IsLeader() {
+			return
+		}
 		task := &table.TaskInfo{}
 		exist, err := tm.orm.Where("id = ?", id).Get(task)
 		if err != nil || !exist {
@@ -154,13 +155,6 @@ func TaskJobFunc(info *table.TaskInfo) func() {
 			RemoveTask(task)
 			return
 		}
-		// 限制运行 仅 every生效
-		if task.TaskType == 1 { // 步减
-			if task.Limit == 0 {
-				return
-			}
-			_, _ = tm.orm.Table(task).Decr("limit").Update(&table.TaskInfo{})
-		}
 
 		if task.StartDate != nil && task.StartDate.After(time.Now()) {
 			return
@@ -170,49 +164,116 @@ func TaskJobFunc(info *table.TaskInfo) func() {
 			return
 		}
 
-		start := time.Now()
-		taskSh := taskScript(info.Service)
-		finfo, err := os.Stat(taskSh)
-		tm.CheckErr(err, taskSh+"脚本状态异常", true)
-		tm.Lock()
-		defer tm.Unlock()
-		if mtime, exist := tm.loadedScriptMTime[taskSh]; exist {
-			if finfo.ModTime().After(mtime) { // 有改动
-				delete(tm.loadedScriptMTime, taskSh)
+		// 限制运行 仅 every生效
+		if task.TaskType == 1 { // 步减
+			if task.Limit == 0 {
+				return
 			}
+			_, _ = tm.orm.Where("id = ?", id).Decr("limit").Update(&table.TaskInfo{})
 		}
+		Run(task)
+	}
+}
 
-		var fn TaskFunc
-		if fn, exist = tm.pool[info.Id]; !exist {
-			if engine, err := tm.initYaegi(); err != nil {
-				panic(err)
-			} else {
-				// 允许直接使用真实路径, 解析脚本时替换为映射包路径 如 xorm.io/engine => pinecms/engine
-				_, err = engine.EvalPath(taskSh)
-				tm.CheckErr(err, "脚本语法错误", true)
-				v, err := engine.Eval(tm.ExecFn)
-				tm.CheckErr(err, "执行脚本错误", true)
-				tm.pool[info.Id] = v.Interface().(func(*xorm.Engine) (string, error))
-				tm.loadedScriptMTime[taskSh] = finfo.ModTime()
-			}
+// Run 按任务配置执行一次: 超时取消, 失败按间隔翻倍重试, 上次未结束时按并发策略处理
+func Run(task *table.TaskInfo) {
+	release, ok := tm.acquire(task)
+	if !ok {
+		tm.logger.Info(fmt.Sprintf("[plugin:task] 任务%s上次执行未结束, 跳过本次", task.Name))
+		return
+	}
+	defer release()
+
+	start := time.Now()
+	msg, attempts, err := execute(task)
+	log := &table.TaskLog{
+		TaskId:   task.Id,
+		Status:   err == nil,
+		Detail:   msg,
+		ExecTime: time.Since(start).Milliseconds(),
+		Attempts: attempts,
+	}
+	if err != nil {
+		log.Detail = err.Error()
+		if len(msg) > 0 {
+			log.Detail += "\n" + msg
+		}
+	}
+	_, err = tm.orm.InsertOne(log)
+	tm.CheckErr(err, "记录任务日志失败", false, task.Id)
+
+	tm.Lock()
+	entryId, exist := tm.entries[task.Id]
+	tm.Unlock()
+	if exist {
+		next := tm.cron.Entry(entryId).Next
+		_, _ = tm.orm.Where("id = ?", task.Id).Cols("next_run_time").Update(&table.TaskInfo{NextRunTime: &next})
+	}
+}
+
+// acquire 获取任务的执行令牌, 返回释放函数
+func (tm *taskManager) acquire(task *table.TaskInfo) (func(), bool) {
+	if task.Concurrency == ConcurrencyAllow {
+		return func() {}, true
+	}
+	tm.Lock()
+	token, exist := tm.running[task.Id]
+	if !exist {
+		token = make(chan struct{}, 1)
+		tm.running[task.Id] = token
+	}
+	tm.Unlock()
+	release := func() { <-token }
+	if task.Concurrency == ConcurrencyQueue {
+		token <- struct{}{}
+		return release, true
+	}
+	select {
+	case token <- struct{}{}:
+		return release, true
+	default:
+		return nil, false
+	}
+}
+
+// execute 执行任务并按配置重试, 返回最后一次的结果与执行次数
+func execute(task *table.TaskInfo) (msg string, attempts uint, err error) {
+	job, err := getJob(task.JobType)
+	if err != nil {
+		return "", 1, err
+	}
+	delay := time.Duration(task.RetryDelay) * time.Second
+	if delay <= 0 {
+		delay = 5 * time.Second
+	}
+	for attempts = 1; ; attempts++ {
+		msg, err = runOnce(job, task)
+		if err == nil || attempts > task.Retry {
+			return msg, attempts, err
 		}
+		tm.logger.Info(fmt.Sprintf("[plugin:task] 任务%s第%d次执行失败, %s后重试: %s", task.Name, attempts, delay, err))
+		sleep(delay)
+		delay = min(delay*2, maxRetryDelay)
+	}
+}
 
-		msg, err := fn(tm.orm)
-		tm.CheckErr(err, "脚本结果异常", true)
-		dur = time.Since(start)
-		go func() {
-			entity := tm.cron.Entry(cron.EntryID(id))
-			_, _ = tm.orm.Where("id = ?", id).Update(&table.TaskInfo{
-				NextRunTime: &entity.Next,
-			})
-			_, err = tm.orm.InsertOne(&table.TaskLog{
-				TaskId:   id,
-				Status:   true,
-				Detail:   msg,
-				ExecTime: dur.Milliseconds(),
-			})
-		}()
+// runOnce 执行一次, 超时后取消 ctx
+func runOnce(job Job, task *table.TaskInfo) (msg string, err error) {
+	ctx, cancel := context.Background(), context.CancelFunc(func() {})
+	if task.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(task.Timeout)*time.Second)
 	}
+	defer cancel()
+	defer func() {
+		if e := recover(); e != nil {
+			err = fmt.Errorf("%v", e)
+		}
+	}()
+	msg, err = job.Run(ctx, task)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("执行超过%d秒, 已取消", task.Timeout)
+	}
+	return truncate(msg), err
 }
 
 // RemoveTask 移除任务
@@ -220,8 +281,9 @@ func RemoveTask(task *table.TaskInfo) {
 	tm.Lock()
 	defer tm.Unlock()
 	id := task.Id
-	tm.pool[id] = nil
-	delete(tm.pool, id)
-	tm.cron.Remove(cron.EntryID(id))
+	if entryId, exist := tm.entries[id]; exist {
+		tm.cron.Remove(entryId)
+		delete(tm.entries, id)
+	}
 	tm.orm.Where("id = ?", id).Cols("entity_id", "error").Table(task).Update(&table.TaskInfo{})
 }
@@ -0,0 +1,194 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/xiusin/pine"
+	"github.com/xiusin/pinecms/src/application/plugins/task/table"
+	_ "modernc.org/sqlite"
+	"xorm.io/xorm"
+)
+
+// testManager 使用临时 sqlite 数据库的任务管理器
+func testManager(t *testing.T) {
+	t.Helper()
+	orm, err := xorm.NewEngine("sqlite", "file:"+filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { orm.Close() })
+	if err = orm.Sync2(&table.TaskInfo{}, &table.TaskLog{}, &table.TaskLock{}); err != nil {
+		t.Fatal(err)
+	}
+	tm = &taskManager{
+		logger:  &logger{pine.Logger()},
+		orm:     orm,
+		entries: map[int64]cron.EntryID{},
+		running: map[int64]chan struct{}{},
+		leader:  newLeader(),
+	}
+	t.Cleanup(func() { tm = nil })
+}
+
+// recordSleep 记录重试等待的间隔, 不实际等待
+func recordSleep(t *testing.T) *[]time.Duration {
+	t.Helper()
+	var delays []time.Duration
+	sleep = func(d time.Duration) { delays = append(delays, d) }
+	t.Cleanup(func() { sleep = time.Sleep })
+	return &delays
+}
+
+// failTimes 前 n 次执行失败的任务
+func failTimes(n int) (Job, *int) {
+	calls := 0
+	return JobFunc(func(context.Context, *table.TaskInfo) (string, error) {
+		calls++
+		if calls <= n {
+			return "", errors.New("fail")
+		}
+		return "ok", nil
+	}), &calls
+}
+
+func TestExecuteRetry(t *testing.T) {
+	testManager(t)
+	delays := recordSleep(t)
+	job, calls := failTimes(2)
+	RegisterJob("test_retry", job)
+
+	msg, attempts, err := execute(&table.TaskInfo{Name: "retry", JobType: "test_retry", Retry: 3, RetryDelay: 1})
+	if err != nil || msg != "ok" {
+		t.Fatalf("重试后应成功: %q %v", msg, err)
+	}
+	if attempts != 3 || *calls != 3 {
+		t.Errorf("应执行3次, 记录%d次, 实际%d次", attempts, *calls)
+	}
+	if !slices.Equal(*delays, []time.Duration{time.Second, 2 * time.Second}) {
+		t.Errorf("重试间隔应翻倍: %v", *delays)
+	}
+}
+
+func TestExecuteRetryExhausted(t *testing.T) {
+	testManager(t)
+	delays := recordSleep(t)
+	job, calls := failTimes(10)
+	RegisterJob("test_retry", job)
+
+	_, attempts, err := execute(&table.TaskInfo{Name: "retry", JobType: "test_retry", Retry: 3, RetryDelay: 400})
+	if err == nil {
+		t.Fatal("重试次数用完后应返回错误")
+	}
+	if attempts != 4 || *calls != 4 {
+		t.Errorf("应执行4次, 记录%d次, 实际%d次", attempts, *calls)
+	}
+	if !slices.Equal(*delays, []time.Duration{400 * time.Second, maxRetryDelay, maxRetryDelay}) {
+		t.Errorf("重试间隔应不超过上限: %v", *delays)
+	}
+
+	// 未配置间隔时使用默认间隔, 不重试时不等待
+	*delays, *calls = nil, 0
+	if _, attempts, _ = execute(&table.TaskInfo{JobType: "test_retry", Retry: 1}); attempts != 2 {
+		t.Errorf("应执行2次: %d", attempts)
+	}
+	if _, attempts, _ = execute(&table.TaskInfo{JobType: "test_retry"}); attempts != 1 {
+		t.Errorf("不重试时应执行1次: %d", attempts)
+	}
+	if !slices.Equal(*delays, []time.Duration{5 * time.Second}) {
+		t.Errorf("默认重试间隔应为5秒: %v", *delays)
+	}
+}
+
+func TestRunOnceTimeout(t *testing.T) {
+	testManager(t)
+	job := JobFunc(func(ctx context.Context, _ *table.TaskInfo) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	})
+	_, err := runOnce(job, &table.TaskInfo{Timeout: 1})
+	if err == nil || !strings.Contains(err.Error(), "执行超过1秒") {
+		t.Errorf("超时应取消执行: %v", err)
+	}
+
+	panicJob := JobFunc(func(context.Context, *table.TaskInfo) (string, error) { panic("boom") })
+	if _, err = runOnce(panicJob, &table.TaskInfo{}); err == nil || err.Error() != "boom" {
+		t.Errorf("panic 应转为错误: %v", err)
+	}
+}
+
+func TestRunLog(t *testing.T) {
+	testManager(t)
+	recordSleep(t)
+	job, _ := failTimes(1)
+	RegisterJob("test_retry", job)
+
+	Run(&table.TaskInfo{Id: 1, JobType: "test_retry", Retry: 1})
+	var log table.TaskLog
+	if exist, err := tm.orm.Where("task_id = ?", 1).Get(&log); err != nil || !exist {
+		t.Fatalf("应记录任务日志: %v", err)
+	}
+	if !log.Status || log.Attempts != 2 || log.Detail != "ok" {
+		t.Errorf("日志记录错误: %+v", log)
+	}
+}
+
+func TestAcquireSkip(t *testing.T) {
+	testManager(t)
+	task := &table.TaskInfo{Id: 1, Concurrency: ConcurrencySkip}
+	release, ok := tm.acquire(task)
+	if !ok {
+		t.Fatal("首次执行应获取令牌")
+	}
+	if _, ok = tm.acquire(task); ok {
+		t.Error("上次未结束时应跳过")
+	}
+	if _, ok = tm.acquire(&table.TaskInfo{Id: 2}); !ok {
+		t.Error("不同任务不应互相影响")
+	}
+	release()
+	if _, ok = tm.acquire(task); !ok {
+		t.Error("上次结束后应可执行")
+	}
+}
+
+func TestAcquireQueue(t *testing.T) {
+	testManager(t)
+	task := &table.TaskInfo{Id: 1, Concurrency: ConcurrencyQueue}
+	release, _ := tm.acquire(task)
+	acquired := make(chan struct{})
+	go func() {
+		next, ok := tm.acquire(task)
+		if ok {
+			close(acquired)
+			next()
+		}
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("上次未结束时应排队等待")
+	case <-time.After(50 * time.Millisecond):
+	}
+	release()
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("上次结束后排队的执行应开始")
+	}
+}
+
+func TestAcquireAllow(t *testing.T) {
+	testManager(t)
+	task := &table.TaskInfo{Id: 1, Concurrency: ConcurrencyAllow}
+	for i := 0; i < 3; i++ {
+		if _, ok := tm.acquire(task); !ok {
+			t.Fatal("并行策略应始终可执行")
+		}
+	}
+}
@@ -5,7 +5,7 @@ import (
 )
 
 type TaskInfo struct {
-	Id          int64      `json:"id" xorm:"autoincr"`
+	Id          int64      `json:"id" xorm:"pk autoincr"`
 	EntityId    int        `json:"entity_id" xorm:"comment('任务ID')"`
 	RepeatConf  string     `json:"repeatConf" xorm:"comment('任务配置') text"`
 	Name        string     `json:"name" xorm:"comment('任务名称') varchar(50)"`
@@ -17,7 +17,12 @@ type TaskInfo struct {
 	StartDate   *time.Time `json:"startDate" xorm:"comment('开始时间')"`
 	EndDate     *time.Time `json:"endDate" xorm:"comment('结束时间')"`
 	Data        string     `json:"data" xorm:"comment('数据') text"`
-	Service     string     `json:"service" xorm:"comment('执行的service实例ID') varchar(100)"`
+	Service     string     `json:"service" xorm:"comment('执行内容: 脚本名/命令/URL/函数名') varchar(500)"`
+	JobType     string     `json:"jobType" xorm:"comment('任务类型 script:脚本 shell:命令 http:请求 func:注册函数') varchar(20)"`
+	Timeout     uint       `json:"timeout" xorm:"comment('超时时间(秒) 0:不限制')"`
+	Retry       uint       `json:"retry" xorm:"comment('失败重试次数')"`
+	RetryDelay  uint       `json:"retryDelay" xorm:"comment('首次重试间隔(秒), 之后每次翻倍')"`
+	Concurrency uint8      `json:"concurrency" xorm:"comment('上次未结束时 0:跳过 1:排队 2:并行')"`
 	Type        uint8      `json:"type" xorm:"comment('类型 0:系统 1：用户')"`
 	NextRunTime *time.Time `json:"nextRunTime" xorm:"comment('下一次执行时间')"`
 	TaskType    uint       `json:"taskType" xorm:"comment('状态 0:cron 1：时间间隔')"`
@@ -26,11 +31,19 @@ type TaskInfo struct {
 }
 
 type TaskLog struct {
-	Id        int64     `json:"id"  xorm:"autoincr"`
+	Id        int64     `json:"id" xorm:"pk autoincr"`
 	TaskId    int64     `json:"taskId" xorm:"comment('任务ID')"`
 	Status    bool      `json:"status" xorm:"comment('状态 0:失败 1：成功')"`
 	Detail    string    `json:"detail" xorm:"text comment('详情')"`
 	ExecTime  int64     `json:"exec_time" xorm:"int(11) default 0 comment('执行时长')"`
+	Attempts  uint      `json:"attempts" xorm:"default 1 comment('执行次数, 含重试')"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
+
+// TaskLock 多实例共用数据库时的调度锁, 持有者为主实例, 只有主实例触发定时任务
+type TaskLock struct {
+	Name      string `json:"name" xorm:"pk varchar(100)"`
+	Owner     string `json:"owner" xorm:"varchar(100) comment('持有实例')"`
+	ExpiredAt int64  `json:"expired_at" xorm:"comment('过期时间戳')"`
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/xiusin/pine"
 	"github.com/xiusin/pine/di"
+	"github.com/xiusin/pinecms/src/application/plugins/sdk"
 	"github.com/xiusin/pinecms/src/application/plugins/task/controller"
 	"github.com/xiusin/pinecms/src/application/plugins/task/manager"
 	"github.com/xiusin/pinecms/src/application/plugins/task/table"
@@ -162,14 +163,35 @@ func (p *Task) Uninstall() {
 
 func (p *Task) Install() {
 	if !p.isInstall {
-		if err := p.orm.Sync2(&table.TaskInfo{}, &table.TaskLog{}); err != nil {
-			pine.Logger().Error("安装task插件数据库失败", err)
-		}
 		manager.TaskManager().Cron()
 	}
 	p.isInstall = true
 }
 
+// Migrations 数据表迁移, 安装时由插件系统执行, 卸载时删除数据表
+func (p *Task) Migrations() []sdk.Migration {
+	return []sdk.Migration{{
+		Version: "0.0.1",
+		Up: func(sess *xorm.Session) error {
+			return sess.Sync2(&table.TaskInfo{}, &table.TaskLog{})
+		},
+		Down: func(sess *xorm.Session) error {
+			if err := sess.DropTable(&table.TaskLog{}); err != nil {
+				return err
+			}
+			return sess.DropTable(&table.TaskInfo{})
+		},
+	}, {
+		Version: "0.0.2",
+		Up: func(sess *xorm.Session) error {
+			return sess.Sync2(&table.TaskInfo{}, &table.TaskLog{}, &table.TaskLock{})
+		},
+		Down: func(sess *xorm.Session) error {
+			return sess.DropTable(&table.TaskLock{})
+		},
+	}}
+}
+
 func (p *Task) Upgrade() {
 
 }
@@ -0,0 +1,154 @@
+package dialect
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/xiusin/pinecms/src/common/helper"
+	"xorm.io/xorm"
+	"xorm.io/xorm/schemas"
+)
+
+// DSNConfig 数据库连接信息, SQLite 只使用 Name 作为数据库文件路径
+type DSNConfig struct {
+	Host     string
+	Port     string
+	User     string
+	Password string
+	Name     string
+}
+
+// Dialect 各数据库不能由xorm屏蔽的差异
+type Dialect interface {
+	// Name 配置文件中的 driver 值, 同时也是 database/sql 的驱动名
+	Name() string
+	DBType() schemas.DBType
+	DefaultPort() string
+	DSN(conf DSNConfig) string
+	// Instr 子串位置表达式, 未找到时为0
+	Instr(str, substr string) string
+	// Maintain 修复(repair)或优化(optimize)数据表的SQL, 不支持时返回空
+	Maintain(action, table string) []string
+	// ResetSequence 写入显式自增ID后需要执行的SQL, 不需要时返回空
+	ResetSequence(orm *xorm.Engine, table, column string) string
+	// ForeignKeys 查询当前库全部外键的SQL, 返回 table_name column_name ref_table ref_column 四列
+	ForeignKeys() string
+}
+
+// ForeignKey 外键, Table.Column 引用 RefTable.RefColumn
+type ForeignKey struct {
+	Table     string
+	Column    string
+	RefTable  string
+	RefColumn string
+}
+
+var dialects = map[string]Dialect{}
+
+// Register 注册方言
+func Register(d Dialect) {
+	dialects[d.Name()] = d
+}
+
+// Names 已注册的方言
+func Names() []string {
+	names := make([]string, 0, len(dialects))
+	for name := range dialects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get 按驱动名或数据库类型获取方言
+func Get(name string) (Dialect, error) {
+	if d, ok := dialects[name]; ok {
+		return d, nil
+	}
+	for _, d := range dialects {
+		if string(d.DBType()) == name {
+			return d, nil
+		}
+	}
+	return nil, fmt.Errorf("不支持的数据库类型: %s, 可选 %s", name, strings.Join(Names(), "/"))
+}
+
+// Of 连接对应的方言
+func Of(orm *xorm.Engine) Dialect {
+	d, err := Get(string(orm.Dialect().URI().DBType))
+	if err != nil {
+		return dialects["mysql"]
+	}
+	return d
+}
+
+// Current 当前数据库连接的方言
+func Current() Dialect {
+	return Of(helper.GetORM())
+}
+
+// Columns 按顺序读取数据表字段
+func Columns(orm *xorm.Engine, table string) ([]string, error) {
+	names, _, err := orm.Dialect().GetColumns(orm.DB(), context.Background(), table)
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("数据表%s不存在或没有字段", table)
+	}
+	return names, nil
+}
+
+// Version 数据库版本
+func Version(orm *xorm.Engine) string {
+	version, err := orm.DBVersion()
+	if err != nil {
+		return ""
+	}
+	if len(version.Edition) > 0 {
+		return version.Number + "-" + version.Edition
+	}
+	return version.Number
+}
+
+// Insert 写入一行并返回自增ID, PostgreSQL 不支持 LastInsertId, 使用 RETURNING
+func Insert(orm *xorm.Engine, table string, data map[string]any) (int64, error) {
+	fields := make([]string, 0, len(data))
+	args := make([]any, 1, len(data)+1)
+	for k, v := range data {
+		fields = append(fields, orm.Quote(k))
+		args = append(args, v)
+	}
+	args[0] = fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", orm.Quote(table), strings.Join(fields, ","),
+		strings.TrimRight(strings.Repeat("?,", len(fields)), ","))
+	if Of(orm).DBType() == schemas.POSTGRES {
+		args[0] = args[0].(string) + " RETURNING id"
+		rows, err := orm.QueryString(args...)
+		if err != nil || len(rows) == 0 {
+			return 0, err
+		}
+		var id int64
+		_, _ = fmt.Sscan(rows[0]["id"], &id)
+		return id, nil
+	}
+	result, err := orm.Exec(args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// ForeignKeys 读取当前库的全部外键
+func ForeignKeys(orm *xorm.Engine) ([]ForeignKey, error) {
+	rows, err := orm.QueryString(Of(orm).ForeignKeys())
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]ForeignKey, 0, len(rows))
+	for _, row := range rows {
+		keys = append(keys, ForeignKey{Table: row["table_name"], Column: row["column_name"], RefTable: row["ref_table"], RefColumn: row["ref_column"]})
+	}
+	return keys, nil
+}
@@ -0,0 +1,376 @@
+package dialect
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"xorm.io/xorm"
+	"xorm.io/xorm/schemas"
+)
+
+// DumpPrefix 初始化SQL文件中使用的表前缀
+const DumpPrefix = "pinecms_"
+
+var (
+	createPattern  = regexp.MustCompile("(?is)^CREATE TABLE\\s+`([^`]+)`\\s*\\((.*)\\)[^)]*$")
+	insertPattern  = regexp.MustCompile("(?is)^INSERT INTO\\s+`([^`]+)`\\s*(?:\\(([^)]*)\\)\\s*)?VALUES\\s*(.*)$")
+	columnPattern  = regexp.MustCompile("(?is)^`([^`]+)`\\s+(\\w+)(?:\\(([^)]*)\\))?(.*)$")
+	indexPattern   = regexp.MustCompile("(?is)^(PRIMARY|UNIQUE)?\\s*KEY\\s*(?:`([^`]+)`)?\\s*\\(([^)]*)\\)")
+	defaultPattern = regexp.MustCompile(`(?is)\bDEFAULT\s+('(?:[^'\\]|\\.|'')*'|[^\s,]+)`)
+	commentPattern = regexp.MustCompile(`(?is)\bCOMMENT\s+'((?:[^'\\]|\\.|'')*)'`)
+)
+
+// ImportDump 导入MySQL格式的初始化SQL, 表前缀替换为prefix.
+// MySQL直接执行, 其他数据库将建表与插入语句转换为对应方言后在事务中执行
+func ImportDump(orm *xorm.Engine, file, prefix string) error {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	if prefix != DumpPrefix {
+		content = bytes.ReplaceAll(content, []byte("`"+DumpPrefix), []byte("`"+prefix))
+	}
+	return ImportSQL(orm, bytes.NewReader(content))
+}
+
+// ImportSQL 导入MySQL格式的SQL语句
+func ImportSQL(orm *xorm.Engine, r io.Reader) error {
+	if Of(orm).DBType() == schemas.MYSQL {
+		_, err := orm.Import(r)
+		return err
+	}
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	sess := orm.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+	tables := map[string]*schemas.Table{}
+	var order []string
+	for _, stmt := range splitStatements(string(content)) {
+		switch upper := strings.ToUpper(stmt); {
+		case strings.HasPrefix(upper, "CREATE TABLE"):
+			table, err := parseCreate(stmt)
+			if err != nil {
+				_ = sess.Rollback()
+				return err
+			}
+			sqlStr, _, err := orm.Dialect().CreateTableSQL(context.Background(), orm.DB(), table, table.Name)
+			if err == nil {
+				_, err = sess.Exec(sqlStr)
+			}
+			for _, index := range table.Indexes {
+				if err == nil {
+					_, err = sess.Exec(orm.Dialect().CreateIndexSQL(table.Name, index))
+				}
+			}
+			if err != nil {
+				_ = sess.Rollback()
+				return fmt.Errorf("创建表%s失败: %w", table.Name, err)
+			}
+			tables[table.Name] = table
+			order = append(order, table.Name)
+		case strings.HasPrefix(upper, "INSERT INTO"):
+			if err := execInsert(orm, sess, tables, stmt); err != nil {
+				_ = sess.Rollback()
+				return err
+			}
+		}
+	}
+	for _, name := range order {
+		if col := tables[name].AutoIncrColumn(); col != nil {
+			if sqlStr := Of(orm).ResetSequence(orm, name, col.Name); len(sqlStr) > 0 {
+				if _, err := sess.Exec(sqlStr); err != nil {
+					_ = sess.Rollback()
+					return err
+				}
+			}
+		}
+	}
+	return sess.Commit()
+}
+
+// splitStatements 按分号拆分语句, 忽略字符串内的分号与注释
+func splitStatements(content string) []string {
+	var stmts []string
+	var buf strings.Builder
+	var quote byte
+	for i := 0; i < len(content); i++ {
+		ch := content[i]
+		if quote != 0 {
+			buf.WriteByte(ch)
+			if ch == '\\' && i+1 < len(content) {
+				i++
+				buf.WriteByte(content[i])
+			} else if ch == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch {
+		case ch == '\'' || ch == '"' || ch == '`':
+			quote = ch
+			buf.WriteByte(ch)
+		case ch == '-' && strings.HasPrefix(content[i:], "-- "), ch == '#':
+			if end := strings.IndexByte(content[i:], '\n'); end >= 0 {
+				i += end
+			} else {
+				i = len(content)
+			}
+		case ch == '/' && strings.HasPrefix(content[i:], "/*"):
+			if end := strings.Index(content[i:], "*/"); end >= 0 {
+				i += end + 1
+			} else {
+				i = len(content)
+			}
+		case ch == ';':
+			if stmt := strings.TrimSpace(buf.String()); len(stmt) > 0 {
+				stmts = append(stmts, stmt)
+			}
+			buf.Reset()
+		default:
+			buf.WriteByte(ch)
+		}
+	}
+	if stmt := strings.TrimSpace(buf.String()); len(stmt) > 0 {
+		stmts = append(stmts, stmt)
+	}
+	return stmts
+}
+
+// splitTopLevel 按逗号拆分, 忽略括号与字符串内的逗号
+func splitTopLevel(s string) []string {
+	var parts []string
+	var quote byte
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if quote != 0 {
+			if ch == '\\' {
+				i++
+			} else if ch == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch ch {
+		case '\'', '"', '`':
+			quote = ch
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, strings.TrimSpace(s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	if last := strings.TrimSpace(s[start:]); len(last) > 0 {
+		parts = append(parts, last)
+	}
+	return parts
+}
+
+func parseCreate(stmt string) (*schemas.Table, error) {
+	matches := createPattern.FindStringSubmatch(stmt)
+	if matches == nil {
+		return nil, errors.New("无法解析建表语句: " + firstLine(stmt))
+	}
+	table := schemas.NewEmptyTable()
+	table.Name = matches[1]
+	var columns []*schemas.Column
+	var primary []string
+	for _, def := range splitTopLevel(matches[2]) {
+		if m := indexPattern.FindStringSubmatch(def); m != nil && !strings.HasPrefix(def, "`") {
+			cols := identifiers(m[3])
+			switch strings.ToUpper(m[1]) {
+			case "PRIMARY":
+				primary = cols
+			default:
+				index := schemas.NewIndex(m[2], schemas.IndexType)
+				if strings.EqualFold(m[1], "UNIQUE") {
+					index.Type = schemas.UniqueType
+				}
+				index.Cols = cols
+				table.AddIndex(index)
+			}
+			continue
+		}
+		if m := columnPattern.FindStringSubmatch(def); m != nil {
+			columns = append(columns, parseColumn(table.Name, m))
+		}
+	}
+	for _, col := range columns {
+		for _, pk := range primary {
+			if col.Name == pk {
+				col.IsPrimaryKey, col.Nullable = true, false
+			}
+		}
+		// 单一整型主键id按自增处理, 内容表依赖数据库生成ID
+		if len(primary) == 1 && col.IsPrimaryKey && col.Name == "id" && col.SQLType.IsNumeric() {
+			col.IsAutoIncrement = true
+		}
+		for _, index := range table.Indexes {
+			for _, name := range index.Cols {
+				if name == col.Name {
+					col.Indexes[index.Name] = index.Type
+				}
+			}
+		}
+		table.AddColumn(col)
+	}
+	return table, nil
+}
+
+func parseColumn(tableName string, m []string) *schemas.Column {
+	typ := strings.ToUpper(m[2])
+	attrs := m[4]
+	upper := strings.ToUpper(attrs)
+	col := &schemas.Column{
+		Name:            m[1],
+		TableName:       tableName,
+		SQLType:         schemas.SQLType{Name: typ},
+		Nullable:        !strings.Contains(upper, "NOT NULL"),
+		IsAutoIncrement: strings.Contains(upper, "AUTO_INCREMENT"),
+		DefaultIsEmpty:  true,
+		Indexes:         map[string]int{},
+	}
+	switch typ {
+	case schemas.Char, schemas.Varchar, schemas.Decimal, schemas.Numeric:
+		fmt.Sscanf(strings.ReplaceAll(m[3], " ", ""), "%d,%d", &col.Length, &col.Length2)
+	case schemas.Json:
+		col.SQLType.Name = schemas.Text
+	}
+	if d := defaultPattern.FindStringSubmatch(attrs); d != nil && !strings.EqualFold(d[1], "NULL") {
+		col.DefaultIsEmpty = false
+		col.Default = d[1]
+		if strings.HasPrefix(d[1], "'") {
+			col.Default = "'" + strings.ReplaceAll(unescape(d[1][1:len(d[1])-1]), "'", "''") + "'"
+		}
+	}
+	if c := commentPattern.FindStringSubmatch(attrs); c != nil {
+		col.Comment = strings.ReplaceAll(unescape(c[1]), "'", "")
+	}
+	return col
+}
+
+func execInsert(orm *xorm.Engine, sess *xorm.Session, tables map[string]*schemas.Table, stmt string) error {
+	matches := insertPattern.FindStringSubmatch(stmt)
+	if matches == nil {
+		return errors.New("无法解析插入语句: " + firstLine(stmt))
+	}
+	table, ok := tables[matches[1]]
+	if !ok {
+		return fmt.Errorf("插入语句引用了未创建的表%s", matches[1])
+	}
+	columns := table.ColumnsSeq()
+	if len(matches[2]) > 0 {
+		columns = identifiers(matches[2])
+	}
+	quoted := make([]string, len(columns))
+	for i, name := range columns {
+		quoted[i] = orm.Quote(name)
+	}
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", orm.Quote(table.Name), strings.Join(quoted, ","),
+		strings.TrimRight(strings.Repeat("?,", len(columns)), ","))
+	for _, tuple := range splitTopLevel(matches[3]) {
+		tuple = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(tuple), "("), ")")
+		values := splitTopLevel(tuple)
+		if len(values) != len(columns) {
+			return fmt.Errorf("表%s的插入数据列数不匹配: %s", table.Name, firstLine(tuple))
+		}
+		args := []any{query}
+		for i, value := range values {
+			args = append(args, literal(value, table.GetColumn(columns[i])))
+		}
+		if _, err := sess.Exec(args...); err != nil {
+			return fmt.Errorf("写入表%s失败: %w", table.Name, err)
+		}
+	}
+	return nil
+}
+
+// literal 将MySQL字面量转换为参数值
+func literal(value string, col *schemas.Column) any {
+	if strings.EqualFold(value, "NULL") {
+		return nil
+	}
+	if strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'") && len(value) >= 2 {
+		value = unescape(value[1 : len(value)-1])
+		if col != nil && col.SQLType.IsTime() && strings.HasPrefix(value, "0000-00-00") {
+			return nil
+		}
+	}
+	return value
+}
+
+func unescape(s string) string {
+	if !strings.ContainsAny(s, `\'`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if ch == '\'' && i+1 < len(s) && s[i+1] == '\'' {
+			b.WriteByte('\'')
+			i++
+			continue
+		}
+		if ch != '\\' || i+1 == len(s) {
+			b.WriteByte(ch)
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case '0':
+			b.WriteByte(0)
+		case 'Z':
+			b.WriteByte(26)
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+func identifiers(s string) []string {
+	var names []string
+	for _, name := range strings.Split(s, ",") {
+		if name = strings.Trim(strings.TrimSpace(name), "`\""); len(name) > 0 {
+			// 索引列可能带前缀长度, 如 `title`(20)
+			if i := strings.IndexByte(name, '`'); i > 0 {
+				name = name[:i]
+			}
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i > 0 {
+		s = s[:i]
+	}
+	if len(s) > 100 {
+		s = s[:100]
+	}
+	return s
+}
@@ -0,0 +1,54 @@
+package dialect
+
+import (
+	"fmt"
+
+	_ "github.com/go-sql-driver/mysql"
+	"xorm.io/xorm"
+	"xorm.io/xorm/schemas"
+)
+
+type mysql struct{}
+
+func init() {
+	Register(mysql{})
+}
+
+func (mysql) Name() string {
+	return "mysql"
+}
+
+func (mysql) DBType() schemas.DBType {
+	return schemas.MYSQL
+}
+
+func (mysql) DefaultPort() string {
+	return "3306"
+}
+
+func (mysql) DSN(conf DSNConfig) string {
+	return fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8", conf.User, conf.Password, conf.Host, conf.Port, conf.Name)
+}
+
+func (mysql) Instr(str, substr string) string {
+	return fmt.Sprintf("INSTR(%s, %s)", str, substr)
+}
+
+func (mysql) Maintain(action, table string) []string {
+	switch action {
+	case "repair":
+		return []string{"REPAIR TABLE `" + table + "`"}
+	case "optimize":
+		return []string{"OPTIMIZE TABLE `" + table + "`"}
+	}
+	return nil
+}
+
+func (mysql) ResetSequence(*xorm.Engine, string, string) string {
+	return ""
+}
+
+func (mysql) ForeignKeys() string {
+	return "SELECT TABLE_NAME AS table_name, COLUMN_NAME AS column_name, REFERENCED_TABLE_NAME AS ref_table, REFERENCED_COLUMN_NAME AS ref_column " +
+		"FROM information_schema.KEY_COLUMN_USAGE WHERE TABLE_SCHEMA = DATABASE() AND REFERENCED_TABLE_NAME IS NOT NULL"
+}
@@ -0,0 +1,67 @@
+package dialect
+
+import (
+	"fmt"
+	"net/url"
+
+	_ "github.com/lib/pq"
+	"xorm.io/xorm"
+	"xorm.io/xorm/schemas"
+)
+
+type postgres struct{}
+
+func init() {
+	Register(postgres{})
+}
+
+func (postgres) Name() string {
+	return "postgres"
+}
+
+func (postgres) DBType() schemas.DBType {
+	return schemas.POSTGRES
+}
+
+func (postgres) DefaultPort() string {
+	return "5432"
+}
+
+func (postgres) DSN(conf DSNConfig) string {
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(conf.User, conf.Password),
+		Host:     conf.Host + ":" + conf.Port,
+		Path:     "/" + conf.Name,
+		RawQuery: "sslmode=disable",
+	}
+	return u.String()
+}
+
+func (postgres) Instr(str, substr string) string {
+	return fmt.Sprintf("STRPOS(%s, %s)", str, substr)
+}
+
+func (postgres) Maintain(action, table string) []string {
+	switch action {
+	case "repair":
+		return []string{`REINDEX TABLE "` + table + `"`}
+	case "optimize":
+		return []string{`VACUUM ANALYZE "` + table + `"`}
+	}
+	return nil
+}
+
+// ResetSequence 显式写入ID后自增序列不会前进, 需要对齐到当前最大值
+func (postgres) ResetSequence(orm *xorm.Engine, table, column string) string {
+	return fmt.Sprintf("SELECT setval(pg_get_serial_sequence('%s', '%s'), COALESCE(MAX(%s), 0) + 1, false) FROM %s",
+		table, column, orm.Quote(column), orm.Quote(table))
+}
+
+func (postgres) ForeignKeys() string {
+	return "SELECT kcu.table_name, kcu.column_name, ccu.table_name AS ref_table, ccu.column_name AS ref_column " +
+		"FROM information_schema.table_constraints tc " +
+		"JOIN information_schema.key_column_usage kcu ON kcu.constraint_name = tc.constraint_name AND kcu.table_schema = tc.table_schema " +
+		"JOIN information_schema.constraint_column_usage ccu ON ccu.constraint_name = tc.constraint_name AND ccu.table_schema = tc.table_schema " +
+		"WHERE tc.constraint_type = 'FOREIGN KEY' AND tc.table_schema = current_schema()"
+}
@@ -0,0 +1,51 @@
+package dialect
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// mysqlQuoted 反引号引用的标识符, 如 `key` 或 `%s`
+var mysqlQuoted = regexp.MustCompile("`[A-Za-z_%][A-Za-z0-9_%]*`|FROM `|TABLE `")
+
+// mysqlOnly 只在 MySQL 下执行的文件
+var mysqlOnly = []string{"common/dialect/mysql.go", "common/dialect/dump.go"}
+
+// TestNoMysqlQuote 源码中的SQL字符串不能使用反引号引用字段, PostgreSQL 下会报语法错误, 应使用 orm.Quote
+func TestNoMysqlQuote(t *testing.T) {
+	root := filepath.Join("..", "..")
+	fset := token.NewFileSet()
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return err
+		}
+		rel, _ := filepath.Rel(root, path)
+		for _, name := range mysqlOnly {
+			if filepath.ToSlash(rel) == name {
+				return nil
+			}
+		}
+		file, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return err
+		}
+		ast.Inspect(file, func(n ast.Node) bool {
+			// 结构体标签等原始字符串不检查
+			lit, ok := n.(*ast.BasicLit)
+			if ok && lit.Kind == token.STRING && strings.HasPrefix(lit.Value, `"`) && mysqlQuoted.MatchString(lit.Value) {
+				t.Errorf("%s: %s 使用了 MySQL 反引号, 请使用 orm.Quote", fset.Position(lit.Pos()), lit.Value)
+			}
+			return true
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
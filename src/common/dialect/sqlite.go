@@ -0,0 +1,55 @@
+package dialect
+
+import (
+	"fmt"
+
+	_ "modernc.org/sqlite"
+	"xorm.io/xorm"
+	"xorm.io/xorm/schemas"
+)
+
+type sqlite struct{}
+
+func init() {
+	Register(sqlite{})
+}
+
+func (sqlite) Name() string {
+	return "sqlite"
+}
+
+func (sqlite) DBType() schemas.DBType {
+	return schemas.SQLITE
+}
+
+func (sqlite) DefaultPort() string {
+	return ""
+}
+
+// DSN Name 为数据库文件路径, 开启WAL与忙等待以支持后台任务并发写入
+func (sqlite) DSN(conf DSNConfig) string {
+	return "file:" + conf.Name + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(0)"
+}
+
+func (sqlite) Instr(str, substr string) string {
+	return fmt.Sprintf("INSTR(%s, %s)", str, substr)
+}
+
+func (sqlite) Maintain(action, table string) []string {
+	switch action {
+	case "repair":
+		return []string{`REINDEX "` + table + `"`}
+	case "optimize":
+		return []string{"VACUUM"}
+	}
+	return nil
+}
+
+func (sqlite) ResetSequence(*xorm.Engine, string, string) string {
+	return ""
+}
+
+func (sqlite) ForeignKeys() string {
+	return `SELECT m.name AS table_name, p."from" AS column_name, p."table" AS ref_table, p."to" AS ref_column ` +
+		`FROM sqlite_master m JOIN pragma_foreign_key_list(m.name) p WHERE m.type = 'table'`
+}
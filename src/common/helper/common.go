@@ -6,12 +6,14 @@ import (
 	"errors"
 	"fmt"
 	"math/rand"
+	"net"
 	"os"
 	"path/filepath"
 	"reflect"
 	"runtime"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 	"unicode/utf8"
 	"unsafe"
@@ -424,3 +426,16 @@ func ArrayUnique[T comparable](items []T) []T {
 	}
 	return filterItems
 }
+
+// PublicAddr 用作 net.Dialer 的 Control, 拒绝连接回环、内网、链路本地等非公网地址, 在解析域名后校验, 可防止DNS重绑定
+func PublicAddr(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return fmt.Errorf("禁止访问地址%s", host)
+	}
+	return nil
+}
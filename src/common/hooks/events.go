@@ -0,0 +1,80 @@
+package hooks
+
+import "github.com/xiusin/pinecms/src/application/models/tables"
+
+// ContentEvent 文档事件, before 事件中可修改 Data
+type ContentEvent struct {
+	ModelId int64          `json:"mid"`
+	CatId   int64          `json:"catid"`
+	Id      int64          `json:"id"`    // 新增前为0
+	Ids     []int64        `json:"ids"`   // 删除的文档
+	Table   string         `json:"table"` // 模型表名
+	Data    map[string]any `json:"data"`  // 提交的字段
+	AdminId int64          `json:"admin_id"`
+}
+
+// CategoryEvent 分类事件, 保存时 Category 为提交的分类, 删除时为 Ids
+type CategoryEvent struct {
+	Category *tables.Category `json:"category"`
+	Ids      []int64          `json:"ids"`
+	AdminId  int64            `json:"admin_id"`
+}
+
+// MemberEvent 会员注册事件
+type MemberEvent struct {
+	Member *tables.Member `json:"member"`
+	Source string         `json:"source"`
+}
+
+// 会员注册来源
+const (
+	SourceBackend    = "backend"     // 后台添加
+	SourceWechatMini = "wechat_mini" // 小程序登录
+)
+
+// UploadEvent 上传完成事件
+type UploadEvent struct {
+	Name         string `json:"name"`
+	OriginalName string `json:"original_name"`
+	Url          string `json:"url"`
+	Md5          string `json:"md5"`
+	Size         int64  `json:"size"`
+	Exist        bool   `json:"exist"` // 文件已存在, 未重复上传
+	AdminId      int64  `json:"admin_id"`
+}
+
+// RenderEvent 页面渲染事件, 可修改或追加模板变量
+type RenderEvent struct {
+	Template string         `json:"template"`
+	Data     map[string]any `json:"-"`
+}
+
+// 文档
+var (
+	ContentBeforeSave    = New[ContentEvent]("content.before_save")
+	ContentAfterSave     = New[ContentEvent]("content.after_save")
+	ContentBeforeDelete  = New[ContentEvent]("content.before_delete")
+	ContentAfterDelete   = New[ContentEvent]("content.after_delete")
+	ContentBeforePublish = New[ContentEvent]("content.before_publish") // 保存时状态由未发布变为发布
+	ContentAfterPublish  = New[ContentEvent]("content.after_publish")
+)
+
+// 分类
+var (
+	CategoryBeforeSave   = New[CategoryEvent]("category.before_save")
+	CategoryAfterSave    = New[CategoryEvent]("category.after_save")
+	CategoryBeforeDelete = New[CategoryEvent]("category.before_delete")
+	CategoryAfterDelete  = New[CategoryEvent]("category.after_delete")
+)
+
+// 会员
+var (
+	MemberBeforeRegister = New[MemberEvent]("member.before_register")
+	MemberAfterRegister  = New[MemberEvent]("member.after_register")
+)
+
+// 上传
+var UploadCompleted = New[UploadEvent]("upload.completed")
+
+// 前台页面渲染
+var PageRender = New[RenderEvent]("page.render")
@@ -0,0 +1,112 @@
+// Package hooks 系统事件钩子, 核心控制器在文档、分类、会员、上传及页面渲染时触发, 插件与内部模块按优先级订阅.
+// Before 类事件的处理函数返回错误即可阻止本次操作, 错误信息返回给请求方.
+package hooks
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"github.com/xiusin/pine"
+)
+
+// 常用优先级, 数值越大越先执行
+const (
+	PriorityHigh   = 100
+	PriorityNormal = 0
+	PriorityLow    = -100
+)
+
+// Handler 事件处理函数, 可修改事件参数
+type Handler[T any] func(*T) error
+
+// Listener 接收全部事件, 在处理函数全部成功后调用, 用于转发给进程外插件
+type Listener func(name string, payload any)
+
+type entry[T any] struct {
+	id       uint64
+	priority int
+	fn       Handler[T]
+}
+
+// Hook 类型化事件
+type Hook[T any] struct {
+	sync.RWMutex
+	name     string
+	handlers []entry[T]
+}
+
+var (
+	seq       atomic.Uint64
+	listenMu  sync.RWMutex
+	listeners []Listener
+)
+
+// New 创建事件
+func New[T any](name string) *Hook[T] {
+	return &Hook[T]{name: name}
+}
+
+func (h *Hook[T]) Name() string {
+	return h.name
+}
+
+// On 订阅事件, 优先级相同时按订阅顺序执行, 返回取消订阅的函数
+func (h *Hook[T]) On(priority int, fn Handler[T]) (off func()) {
+	id := seq.Add(1)
+	h.Lock()
+	h.handlers = append(h.handlers, entry[T]{id: id, priority: priority, fn: fn})
+	slices.SortStableFunc(h.handlers, func(a, b entry[T]) int {
+		return b.priority - a.priority
+	})
+	h.Unlock()
+	return func() {
+		h.Lock()
+		defer h.Unlock()
+		h.handlers = slices.DeleteFunc(h.handlers, func(e entry[T]) bool {
+			return e.id == id
+		})
+	}
+}
+
+// Emit 按优先级执行处理函数, 任一函数返回错误或panic时中止并返回该错误
+func (h *Hook[T]) Emit(payload *T) error {
+	h.RLock()
+	handlers := slices.Clone(h.handlers)
+	h.RUnlock()
+	for _, e := range handlers {
+		if err := call(e.fn, payload); err != nil {
+			return err
+		}
+	}
+	listenMu.RLock()
+	defer listenMu.RUnlock()
+	for _, l := range listeners {
+		l(h.name, payload)
+	}
+	return nil
+}
+
+// Fire 触发 After 类事件, 错误只记录日志
+func (h *Hook[T]) Fire(payload *T) {
+	if err := h.Emit(payload); err != nil {
+		pine.Logger().Warn("执行事件"+h.name+"失败", err)
+	}
+}
+
+// Listen 监听全部事件
+func Listen(l Listener) {
+	listenMu.Lock()
+	defer listenMu.Unlock()
+	listeners = append(listeners, l)
+}
+
+func call[T any](fn Handler[T], payload *T) (err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = fmt.Errorf("%v", e)
+		}
+	}()
+	return fn(payload)
+}
@@ -0,0 +1,195 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/bytedance/sonic"
+	"github.com/xiusin/pine"
+	"github.com/xiusin/pinecms/src/application/models/tables"
+	"github.com/xiusin/pinecms/src/common/helper"
+	"github.com/xiusin/pinecms/src/common/storage"
+)
+
+// 数据源类型
+const (
+	SourceWXR  = "wxr"
+	SourceCSV  = "csv"
+	SourceJSON = "json"
+)
+
+// 导入的数据类型, 按此顺序依次导入
+const (
+	KindCategory   = "category"
+	KindTag        = "tag"
+	KindAttachment = "attachment"
+	KindPage       = "page"
+	KindDocument   = "document"
+)
+
+// 任务状态
+const (
+	StatusPending = iota
+	StatusRunning
+	StatusDone
+	StatusFailed
+)
+
+// 每处理多少条记录保存一次进度
+const progressStep = 20
+
+// Record 从数据源读取的一条记录
+type Record struct {
+	Line     int64          // 行号或序号
+	Kind     string         // 数据类型
+	SourceId string         // 来源ID
+	Data     map[string]any // 目标字段
+	Parent   string         // 父级分类来源ID
+	Category string         // 所属分类来源ID
+	Tags     []string       // 标签
+	Thumb    string         // 缩略图附件来源ID
+	Url      string         // 附件地址
+}
+
+// Reader 按固定顺序读取数据源, 保证断点续导时序号一致
+type Reader interface {
+	// Key 来源标识, 为空时使用任务中的标识
+	Key() string
+	// Each 依次回调每条记录, 回调返回错误时终止读取
+	Each(fn func(rec *Record) error) error
+}
+
+var (
+	readers = map[string]func(job *tables.ImportJob) (Reader, error){}
+	running sync.Map
+)
+
+// RegisterReader 注册数据源
+func RegisterReader(source string, fn func(job *tables.ImportJob) (Reader, error)) {
+	readers[source] = fn
+}
+
+// Sources 支持的数据源类型
+func Sources() []string {
+	return []string{SourceWXR, SourceCSV, SourceJSON}
+}
+
+// InitInstall 同步导入相关数据表
+func InitInstall() {
+	if err := helper.GetORM().Sync2(&tables.ImportJob{}, &tables.ImportItem{}); err != nil {
+		pine.Logger().Warn("同步导入数据表失败", err)
+	}
+}
+
+// Mapping 解析字段映射 {列名:表字段}
+func Mapping(job *tables.ImportJob) (map[string]string, error) {
+	mapping := map[string]string{}
+	if len(job.Mapping) == 0 {
+		return mapping, nil
+	}
+	if err := sonic.UnmarshalString(job.Mapping, &mapping); err != nil {
+		return nil, fmt.Errorf("字段映射格式错误: %w", err)
+	}
+	return mapping, nil
+}
+
+// IsRunning 任务是否正在当前进程中执行
+func IsRunning(id int64) bool {
+	_, ok := running.Load(id)
+	return ok
+}
+
+// Run 执行导入任务, 已处理的记录会被跳过, 可重复调用以断点续导
+func Run(job *tables.ImportJob, uploader storage.Uploader) (err error) {
+	if _, loaded := running.LoadOrStore(job.Id, true); loaded {
+		return errors.New("任务正在执行")
+	}
+	defer running.Delete(job.Id)
+
+	newReader, ok := readers[job.Source]
+	if !ok {
+		return fmt.Errorf("不支持的数据源: %s", job.Source)
+	}
+	imp, err := newImporter(job, uploader)
+	if err != nil {
+		return imp.finish(err)
+	}
+	reader, err := newReader(job)
+	if err != nil {
+		return imp.finish(err)
+	}
+	if key := reader.Key(); len(key) > 0 {
+		job.SourceKey = key
+	}
+	if len(job.SourceKey) == 0 {
+		job.SourceKey = job.Source + ":" + job.OriginName
+	}
+	job.Status, job.Error = StatusRunning, ""
+	imp.save()
+
+	var index int64
+	err = reader.Each(func(rec *Record) error {
+		index++
+		if index <= job.Cursor {
+			return nil
+		}
+		imp.handle(rec)
+		job.Cursor = index
+		if index%progressStep == 0 {
+			imp.save()
+		}
+		return nil
+	})
+	job.Total = index
+	return imp.finish(err)
+}
+
+// Columns 读取CSV/JSON的列名与前几行数据, 用于配置字段映射
+func Columns(job *tables.ImportJob, limit int) ([]string, []map[string]string, error) {
+	switch job.Source {
+	case SourceCSV, SourceJSON:
+	default:
+		return nil, nil, errors.New("只有CSV与JSON需要配置字段映射")
+	}
+	var columns []string
+	var rows []map[string]string
+	err := eachRow(job, func(_ int64, row map[string]string) error {
+		for k := range row {
+			if !contains(columns, k) {
+				columns = append(columns, k)
+			}
+		}
+		rows = append(rows, row)
+		if len(rows) >= limit {
+			return errStop
+		}
+		return nil
+	})
+	if errors.Is(err, errStop) {
+		err = nil
+	}
+	return columns, rows, err
+}
+
+var errStop = errors.New("stop")
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func splitTags(s string) []string {
+	var tags []string
+	for _, tag := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == '，' || r == '|' }) {
+		if tag = strings.TrimSpace(tag); len(tag) > 0 {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
@@ -0,0 +1,156 @@
+package importer
+
+import (
+	"bufio"
+	"crypto/md5"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cast"
+	"github.com/xiusin/pinecms/src/application/models/tables"
+)
+
+// tableReader CSV/JSON按字段映射转换为文档
+type tableReader struct {
+	job     *tables.ImportJob
+	mapping map[string]string
+}
+
+func init() {
+	newTableReader := func(job *tables.ImportJob) (Reader, error) {
+		mapping, err := Mapping(job)
+		if err != nil {
+			return nil, err
+		}
+		if len(mapping) == 0 {
+			return nil, errors.New("请先配置字段映射")
+		}
+		return &tableReader{job: job, mapping: mapping}, nil
+	}
+	RegisterReader(SourceCSV, newTableReader)
+	RegisterReader(SourceJSON, newTableReader)
+}
+
+func (r *tableReader) Key() string {
+	return ""
+}
+
+func (r *tableReader) Each(fn func(rec *Record) error) error {
+	return eachRow(r.job, func(line int64, row map[string]string) error {
+		rec := &Record{Kind: KindDocument, Line: line, Data: map[string]any{}}
+		for column, field := range r.mapping {
+			if len(field) == 0 {
+				continue
+			}
+			if field == "tags" {
+				rec.Tags = splitTags(row[column])
+			}
+			rec.Data[field] = row[column]
+		}
+		if len(r.job.IdField) > 0 {
+			rec.SourceId = strings.TrimSpace(row[r.job.IdField])
+		} else {
+			rec.SourceId = rowHash(row) // 未指定来源ID列时按行内容去重
+		}
+		return fn(rec)
+	})
+}
+
+func rowHash(row map[string]string) string {
+	keys := make([]string, 0, len(row))
+	for k := range row {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	h := md5.New()
+	for _, k := range keys {
+		_, _ = fmt.Fprintf(h, "%s=%s\n", k, row[k])
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// eachRow 逐行读取CSV或JSON数组, 行号从1开始(CSV不含表头)
+func eachRow(job *tables.ImportJob, fn func(line int64, row map[string]string) error) error {
+	f, err := os.Open(job.File)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	reader := bufio.NewReader(f)
+	if bom, _ := reader.Peek(3); string(bom) == "\xef\xbb\xbf" {
+		_, _ = reader.Discard(3)
+	}
+	if job.Source == SourceJSON {
+		return eachJSON(reader, fn)
+	}
+	return eachCSV(reader, fn)
+}
+
+func eachCSV(r io.Reader, fn func(line int64, row map[string]string) error) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("读取表头失败: %w", err)
+	}
+	for i := range header {
+		header[i] = strings.TrimSpace(header[i])
+	}
+	var line int64
+	for {
+		values, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		line++
+		if err != nil {
+			return fmt.Errorf("第%d行格式错误: %w", line, err)
+		}
+		row := make(map[string]string, len(header))
+		for i, column := range header {
+			if i < len(values) {
+				row[column] = values[i]
+			}
+		}
+		if err := fn(line, row); err != nil {
+			return err
+		}
+	}
+}
+
+// eachJSON 读取对象数组, 逐个解码避免一次载入整个文件
+func eachJSON(r io.Reader, fn func(line int64, row map[string]string) error) error {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	if tok, err := decoder.Token(); err != nil || tok != json.Delim('[') {
+		return errors.New("JSON文件必须为对象数组")
+	}
+	var line int64
+	for decoder.More() {
+		line++
+		var item map[string]any
+		if err := decoder.Decode(&item); err != nil {
+			return fmt.Errorf("第%d条数据格式错误: %w", line, err)
+		}
+		row := make(map[string]string, len(item))
+		for k, v := range item {
+			switch v := v.(type) {
+			case []any, map[string]any:
+				byts, _ := json.Marshal(v)
+				row[k] = string(byts)
+			default:
+				row[k] = cast.ToString(v)
+			}
+		}
+		if err := fn(line, row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,409 @@
+package importer
+
+import (
+	"bytes"
+	"crypto/md5"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/spf13/cast"
+	"github.com/xiusin/pine"
+	"github.com/xiusin/pine/di"
+	"github.com/xiusin/pinecms/src/application/controllers"
+	"github.com/xiusin/pinecms/src/application/models"
+	"github.com/xiusin/pinecms/src/application/models/tables"
+	"github.com/xiusin/pinecms/src/common/dialect"
+	"github.com/xiusin/pinecms/src/common/helper"
+	"github.com/xiusin/pinecms/src/common/search"
+	"github.com/xiusin/pinecms/src/common/storage"
+	"xorm.io/xorm"
+)
+
+// 附件下载的大小上限
+const maxAttachmentSize = 50 << 20
+
+var dirPattern = regexp.MustCompile("^[A-Za-z0-9_-]+$")
+
+// mediaTypes 允许下载的附件扩展名及对应的响应类型前缀
+var mediaTypes = map[string]string{
+	".jpg": "image/", ".jpeg": "image/", ".png": "image/", ".gif": "image/", ".webp": "image/", ".bmp": "image/",
+	".mp3": "audio/", ".wav": "audio/", ".m4a": "audio/", ".ogg": "audio/",
+	".mp4": "video/", ".webm": "video/", ".mov": "video/",
+	".pdf": "application/pdf",
+}
+
+// httpClient 下载附件, 只允许连接公网地址, 防止导入文件访问内网服务
+var httpClient = &http.Client{
+	Timeout: 60 * time.Second,
+	Transport: &http.Transport{
+		DialContext:         (&net.Dialer{Timeout: 10 * time.Second, Control: helper.PublicAddr}).DialContext,
+		TLSHandshakeTimeout: 10 * time.Second,
+	},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 5 {
+			return errors.New("重定向次数过多")
+		}
+		return checkScheme(req.URL)
+	},
+}
+
+func checkScheme(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("不支持的附件地址%s", u)
+	}
+	return nil
+}
+
+type importer struct {
+	job         *tables.ImportJob
+	orm         *xorm.Engine
+	uploader    storage.Uploader
+	model       *tables.DocumentModel
+	table       string
+	columns     []string
+	attachments map[string]string // 原附件地址 => 新地址
+}
+
+func newImporter(job *tables.ImportJob, uploader storage.Uploader) (*importer, error) {
+	imp := &importer{job: job, orm: helper.GetORM(), uploader: uploader}
+	imp.model = models.NewDocumentModel().GetByID(job.ModelId)
+	if imp.model == nil || imp.model.Id == 0 {
+		return imp, errors.New("导入的模型不存在")
+	}
+	imp.table = controllers.GetTableName(imp.model.Table)
+	imp.columns = models.NewDataSourceModel().TableColumns(imp.model.Table)
+	if len(imp.columns) == 0 {
+		return imp, fmt.Errorf("读取模型表%s字段失败", imp.table)
+	}
+	mapping, err := Mapping(job)
+	if err != nil {
+		return imp, err
+	}
+	for column, field := range mapping {
+		if len(field) > 0 && !contains(imp.columns, field) {
+			return imp, fmt.Errorf("列%s映射的字段%s不存在", column, field)
+		}
+	}
+	return imp, nil
+}
+
+func (imp *importer) save() {
+	if _, err := imp.orm.ID(imp.job.Id).AllCols().Update(imp.job); err != nil {
+		pine.Logger().Warn("保存导入进度失败", err)
+	}
+}
+
+func (imp *importer) finish(err error) error {
+	if err != nil {
+		imp.job.Status, imp.job.Error = StatusFailed, err.Error()
+	} else {
+		imp.job.Status = StatusDone
+	}
+	imp.save()
+	return err
+}
+
+// item 读取已导入的记录
+func (imp *importer) item(kind, sourceId string) *tables.ImportItem {
+	item := &tables.ImportItem{}
+	ok, _ := imp.orm.Where("source_key = ? AND kind = ? AND source_id = ?", imp.job.SourceKey, kind, sourceId).Get(item)
+	if !ok {
+		return nil
+	}
+	return item
+}
+
+// target 已成功导入的记录ID
+func (imp *importer) target(kind, sourceId string) int64 {
+	if len(sourceId) == 0 {
+		return 0
+	}
+	if item := imp.item(kind, sourceId); item != nil && item.Status {
+		return item.TargetId
+	}
+	return 0
+}
+
+// handle 导入单条记录, 错误只记录在导入记录中不影响后续数据
+func (imp *importer) handle(rec *Record) {
+	var err error
+	if len(rec.SourceId) == 0 {
+		rec.SourceId, err = fmt.Sprintf("#%d", rec.Line), errors.New("来源ID为空")
+	}
+	item := imp.item(rec.Kind, rec.SourceId)
+	if item != nil && item.Status {
+		imp.job.Skipped++
+		return
+	}
+	if item == nil {
+		item = &tables.ImportItem{SourceKey: imp.job.SourceKey, Kind: rec.Kind, SourceId: rec.SourceId}
+	}
+	item.JobId, item.Line, item.Origin = imp.job.Id, rec.Line, rec.Url
+
+	if err == nil {
+		err = imp.process(rec, item)
+	}
+	item.Status, item.Error = err == nil, ""
+	if err != nil {
+		item.Error = err.Error()
+		imp.job.Failed++
+	} else {
+		imp.job.Success++
+	}
+	if item.Id > 0 {
+		_, err = imp.orm.ID(item.Id).AllCols().Update(item)
+	} else {
+		_, err = imp.orm.InsertOne(item)
+	}
+	if err != nil {
+		pine.Logger().Warn("保存导入记录失败", err)
+	}
+}
+
+// process 按类型保存记录, 异常转换为行错误
+func (imp *importer) process(rec *Record, item *tables.ImportItem) (err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = fmt.Errorf("%v", e)
+		}
+	}()
+	switch rec.Kind {
+	case KindCategory:
+		item.TargetId, err = imp.saveCategory(rec)
+	case KindTag:
+		item.TargetId, err = imp.saveTag(rec)
+	case KindAttachment:
+		item.TargetId, item.Target, err = imp.saveAttachment(rec)
+	case KindPage:
+		item.TargetId, err = imp.savePage(rec)
+	case KindDocument:
+		item.TargetId, err = imp.saveDocument(rec)
+	default:
+		err = fmt.Errorf("未知的数据类型%s", rec.Kind)
+	}
+	return err
+}
+
+func (imp *importer) saveCategory(rec *Record) (int64, error) {
+	cat := &tables.Category{
+		SiteId:      imp.job.SiteId,
+		ModelId:     imp.model.Id,
+		Catname:     cast.ToString(rec.Data["catname"]),
+		Description: cast.ToString(rec.Data["description"]),
+		Dir:         cast.ToString(rec.Data["dir"]),
+		Ismenu:      true,
+	}
+	if len(cat.Catname) == 0 {
+		return 0, errors.New("分类名称为空")
+	}
+	if !dirPattern.MatchString(cat.Dir) {
+		cat.Dir = ""
+	}
+	if pid := imp.target(KindCategory, rec.Parent); pid > 0 {
+		parent := models.NewCategoryModel().GetCategory(pid)
+		cat.Parentid, cat.Topid = pid, pid
+		if parent != nil && parent.Topid > 0 {
+			cat.Topid = parent.Topid
+		}
+	}
+	_, err := imp.orm.InsertOne(cat)
+	return cat.Catid, err
+}
+
+func (imp *importer) saveTag(rec *Record) (int64, error) {
+	name := cast.ToString(rec.Data["name"])
+	if len(name) == 0 {
+		return 0, errors.New("标签名称为空")
+	}
+	return imp.tagId(name)
+}
+
+func (imp *importer) tagId(name string) (int64, error) {
+	tag := &tables.Tags{}
+	if ok, _ := imp.orm.Where("site_id = ?", imp.job.SiteId).Where("name = ?", name).Get(tag); ok {
+		return tag.Id, nil
+	}
+	tag = &tables.Tags{SiteId: imp.job.SiteId, Name: name, Status: 1}
+	_, err := imp.orm.InsertOne(tag)
+	return tag.Id, err
+}
+
+// saveAttachment 下载附件并保存到存储引擎, 相同内容只保存一次
+func (imp *importer) saveAttachment(rec *Record) (int64, string, error) {
+	if imp.uploader == nil {
+		return 0, "", errors.New("未配置存储引擎")
+	}
+	u, err := url.Parse(rec.Url)
+	if err != nil {
+		return 0, "", err
+	}
+	if err = checkScheme(u); err != nil {
+		return 0, "", err
+	}
+	ext := strings.ToLower(path.Ext(u.Path))
+	prefix, ok := mediaTypes[ext]
+	if !ok {
+		return 0, "", fmt.Errorf("不支持的附件类型%s", ext)
+	}
+	resp, err := httpClient.Get(u.String())
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, "", fmt.Errorf("下载附件失败: %s", resp.Status)
+	}
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); !strings.HasPrefix(mediaType, prefix) {
+		return 0, "", fmt.Errorf("附件类型%s与扩展名%s不符", mediaType, ext)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxAttachmentSize+1))
+	if err != nil {
+		return 0, "", err
+	}
+	if len(data) > maxAttachmentSize {
+		return 0, "", errors.New("附件超出大小限制")
+	}
+	if sniff := http.DetectContentType(data); strings.HasPrefix(sniff, "text/") { // 拒绝伪装为媒体文件的网页与脚本
+		return 0, "", fmt.Errorf("附件内容类型%s不正确", sniff)
+	}
+	md5sum := fmt.Sprintf("%x", md5.Sum(data))
+	attach := &tables.Attachments{SiteId: imp.job.SiteId}
+	if ok, _ := imp.orm.Where("site_id = ?", imp.job.SiteId).Where("md5 = ?", md5sum).Get(attach); ok {
+		return attach.Id, attach.Url, nil
+	}
+	originName := path.Base(u.Path)
+	attach.Name = string(helper.Krand(16, 3)) + ext
+	attach.Url, err = imp.uploader.Upload(helper.NowDate("20060102")+"/"+attach.Name, bytes.NewReader(data))
+	if err != nil {
+		return 0, "", err
+	}
+	attach.OriginName, attach.Size, attach.Md5 = originName, int64(len(data)), md5sum
+	attach.Type = strings.TrimPrefix(ext, ".")
+	_, err = imp.orm.InsertOne(attach)
+	return attach.Id, attach.Url, err
+}
+
+// replaceAttachments 替换内容中的原附件地址
+func (imp *importer) replaceAttachments(content string) string {
+	if imp.attachments == nil {
+		imp.attachments = map[string]string{}
+		var items []tables.ImportItem
+		imp.orm.Where("source_key = ? AND kind = ? AND status = ?", imp.job.SourceKey, KindAttachment, true).Cols("origin", "target").Find(&items)
+		for _, item := range items {
+			imp.attachments[item.Origin] = item.Target
+		}
+	}
+	for origin, target := range imp.attachments {
+		if len(origin) > 0 {
+			content = strings.ReplaceAll(content, origin, target)
+		}
+	}
+	return content
+}
+
+func (imp *importer) savePage(rec *Record) (int64, error) {
+	title := cast.ToString(rec.Data["title"])
+	if len(title) == 0 {
+		return 0, errors.New("页面标题为空")
+	}
+	dir := cast.ToString(rec.Data["dir"])
+	if !dirPattern.MatchString(dir) {
+		dir = ""
+	}
+	cat := &tables.Category{SiteId: imp.job.SiteId, Catname: title, Type: 1, Dir: dir, Ismenu: true}
+	sess := imp.orm.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return 0, err
+	}
+	if _, err := sess.InsertOne(cat); err != nil {
+		return 0, err
+	}
+	page := &tables.Page{
+		Id:          cat.Catid,
+		Title:       title,
+		Description: cast.ToString(rec.Data["description"]),
+		Content:     imp.replaceAttachments(cast.ToString(rec.Data["content"])),
+	}
+	if _, err := sess.InsertOne(page); err != nil {
+		_ = sess.Rollback()
+		return 0, err
+	}
+	return cat.Catid, sess.Commit()
+}
+
+func (imp *importer) saveDocument(rec *Record) (int64, error) {
+	data := map[string]any{}
+	for field, value := range rec.Data {
+		if contains(imp.columns, field) {
+			data[field] = value
+		}
+	}
+	if catid := imp.target(KindCategory, rec.Category); catid > 0 {
+		data["catid"] = catid
+	} else if cast.ToInt64(data["catid"]) == 0 {
+		if imp.job.Catid == 0 {
+			return 0, errors.New("未找到所属栏目")
+		}
+		data["catid"] = imp.job.Catid
+	}
+	if len(cast.ToString(data["title"])) == 0 {
+		return 0, errors.New("标题为空")
+	}
+	if content, ok := data["content"]; ok {
+		data["content"] = imp.replaceAttachments(cast.ToString(content))
+	}
+	if thumb := imp.item(KindAttachment, rec.Thumb); thumb != nil && thumb.Status {
+		data["thumb"] = thumb.Target
+	}
+	if len(rec.Tags) > 0 && contains(imp.columns, "tags") {
+		for _, tag := range rec.Tags {
+			if _, err := imp.tagId(tag); err != nil {
+				return 0, err
+			}
+		}
+		data["tags"] = strings.Join(rec.Tags, ",")
+	}
+	now := helper.NowDate(helper.TimeFormat)
+	data["mid"] = imp.model.Id
+	for _, field := range []string{"created_time", "updated_time", "pubtime"} {
+		if len(cast.ToString(data[field])) == 0 {
+			data[field] = now
+		}
+	}
+	if _, ok := data["status"]; !ok {
+		data["status"] = 1
+	}
+	delete(data, "id")
+
+	id, err := dialect.Insert(imp.orm, imp.table, data)
+	if err != nil {
+		return 0, err
+	}
+	imp.index(id, data)
+	return id, nil
+}
+
+// index 写入搜索引擎, 失败不影响导入
+func (imp *importer) index(id int64, data map[string]any) {
+	engine, err := di.Get(controllers.ServiceSearchName)
+	if err != nil {
+		return
+	}
+	data["id"] = id
+	eid, err := engine.(search.ISearch).Index("document", data)
+	if err != nil {
+		pine.Logger().Warn("导入数据写入search失败", err, "id: ", id)
+		return
+	}
+	imp.orm.Table(controllers.GetTableName("search_m_a_e")).Insert(map[string]any{"mid": imp.model.Id, "aid": id, "eid": eid})
+}
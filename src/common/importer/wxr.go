@@ -0,0 +1,173 @@
+package importer
+
+import (
+	"encoding/xml"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/xiusin/pinecms/src/application/models/tables"
+	"github.com/xiusin/pinecms/src/common/helper"
+)
+
+// WordPress导出文件(WXR)结构
+type wxrFile struct {
+	Channel struct {
+		Links      []string      `xml:"link"`
+		Categories []wxrCategory `xml:"category"`
+		Tags       []wxrTag      `xml:"tag"`
+		Items      []wxrItem     `xml:"item"`
+	} `xml:"channel"`
+}
+
+type wxrCategory struct {
+	TermId      string `xml:"term_id"`
+	Nicename    string `xml:"category_nicename"`
+	Parent      string `xml:"category_parent"`
+	Name        string `xml:"cat_name"`
+	Description string `xml:"category_description"`
+}
+
+type wxrTag struct {
+	TermId string `xml:"term_id"`
+	Slug   string `xml:"tag_slug"`
+	Name   string `xml:"tag_name"`
+}
+
+type wxrItem struct {
+	Title   string `xml:"title"`
+	Creator string `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Encoded []struct {
+		XMLName xml.Name
+		Value   string `xml:",chardata"`
+	} `xml:"encoded"` // content:encoded 与 excerpt:encoded, 命名空间随导出版本变化
+	PostId        string `xml:"post_id"`
+	PostDate      string `xml:"post_date"`
+	PostName      string `xml:"post_name"`
+	Status        string `xml:"status"`
+	PostType      string `xml:"post_type"`
+	AttachmentUrl string `xml:"attachment_url"`
+	Terms         []struct {
+		Domain   string `xml:"domain,attr"`
+		Nicename string `xml:"nicename,attr"`
+		Name     string `xml:",chardata"`
+	} `xml:"category"`
+	Meta []struct {
+		Key   string `xml:"meta_key"`
+		Value string `xml:"meta_value"`
+	} `xml:"postmeta"`
+}
+
+type wxrReader struct {
+	file wxrFile
+}
+
+func init() {
+	RegisterReader(SourceWXR, func(job *tables.ImportJob) (Reader, error) {
+		f, err := os.Open(job.File)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r := &wxrReader{}
+		decoder := xml.NewDecoder(f)
+		decoder.Strict = false
+		if err := decoder.Decode(&r.file); err != nil {
+			return nil, err
+		}
+		return r, nil
+	})
+}
+
+func (r *wxrReader) Key() string {
+	for _, link := range r.file.Channel.Links {
+		if link = strings.TrimSpace(link); len(link) > 0 {
+			return SourceWXR + ":" + strings.TrimRight(link, "/")
+		}
+	}
+	return ""
+}
+
+// Each 依次读取分类, 标签, 附件, 页面与文章, 保证被引用的数据先导入
+func (r *wxrReader) Each(fn func(rec *Record) error) error {
+	var line int64
+	emit := func(rec *Record) error {
+		line++
+		rec.Line = line
+		return fn(rec)
+	}
+	for _, cat := range r.file.Channel.Categories {
+		if err := emit(&Record{Kind: KindCategory, SourceId: cat.Nicename, Parent: cat.Parent, Data: map[string]any{
+			"catname": cat.Name, "description": cat.Description, "dir": cat.Nicename,
+		}}); err != nil {
+			return err
+		}
+	}
+	for _, tag := range r.file.Channel.Tags {
+		if err := emit(&Record{Kind: KindTag, SourceId: tag.Slug, Data: map[string]any{"name": tag.Name}}); err != nil {
+			return err
+		}
+	}
+	for _, kind := range []string{KindAttachment, KindPage, KindDocument} {
+		for _, item := range r.file.Channel.Items {
+			if rec := item.record(kind); rec != nil {
+				if err := emit(rec); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (item *wxrItem) encoded(space string) string {
+	for _, encoded := range item.Encoded {
+		if strings.Contains(encoded.XMLName.Space, space) {
+			return encoded.Value
+		}
+	}
+	return ""
+}
+
+func (item *wxrItem) record(kind string) *Record {
+	switch {
+	case kind == KindAttachment && item.PostType == "attachment":
+		return &Record{Kind: kind, SourceId: item.PostId, Url: item.AttachmentUrl}
+	case kind == KindPage && item.PostType == "page" && item.Status == "publish":
+		return &Record{Kind: kind, SourceId: item.PostId, Data: map[string]any{
+			"title": item.Title, "content": item.encoded("content"), "description": item.encoded("excerpt"), "dir": item.PostName,
+		}}
+	case kind == KindDocument && item.PostType == "post" && item.Status != "trash" && item.Status != "auto-draft":
+		rec := &Record{Kind: kind, SourceId: item.PostId, Data: map[string]any{
+			"title":       item.Title,
+			"content":     item.encoded("content"),
+			"description": item.encoded("excerpt"),
+			"author":      item.Creator,
+			"status":      0,
+		}}
+		if item.Status == "publish" {
+			rec.Data["status"] = 1
+		}
+		if t, err := time.ParseInLocation(helper.TimeFormat, item.PostDate, time.Local); err == nil && !t.IsZero() {
+			rec.Data["pubtime"] = item.PostDate
+			rec.Data["created_time"] = item.PostDate
+		}
+		for _, term := range item.Terms {
+			switch term.Domain {
+			case "category":
+				if len(rec.Category) == 0 {
+					rec.Category = term.Nicename
+				}
+			case "post_tag":
+				rec.Tags = append(rec.Tags, strings.TrimSpace(term.Name))
+			}
+		}
+		for _, meta := range item.Meta {
+			if meta.Key == "_thumbnail_id" {
+				rec.Thumb = meta.Value
+			}
+		}
+		return rec
+	}
+	return nil
+}
@@ -0,0 +1,340 @@
+package installer
+
+import (
+	"crypto/rand"
+	_ "embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/xiusin/pinecms/src/common/dialect"
+	"github.com/xiusin/pinecms/src/common/helper"
+	"github.com/xiusin/pinecms/src/common/theme"
+	"github.com/xiusin/pinecms/src/config"
+	"xorm.io/xorm"
+)
+
+const (
+	// SeedFile 初始化数据
+	SeedFile = "resources/pinecms.sql"
+	// LockFile 安装完成后写入, 存在时不再提供安装程序
+	LockFile = "resources/configs/install.lock"
+
+	appYaml   = "resources/configs/application.yml"
+	uploadDir = "resources/assets/uploads"
+)
+
+// Page 安装向导页面
+//
+//go:embed install.html
+var Page []byte
+
+// 初始数据类型
+const (
+	SeedDemo    = "demo"
+	SeedMinimal = "minimal"
+)
+
+// demoTables 基础数据模式下清空的示例数据表
+var demoTables = []string{
+	"advert", "attachments", "link", "tags", "download", "member", "category", "page",
+	"wechat_account", "wechat_material", "wechat_member", "wechat_msg_reply_rule", "wechat_msg_template",
+}
+
+var (
+	prefixPattern   = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+	usernamePattern = regexp.MustCompile(`^[a-zA-Z0-9_]{3,20}$`)
+	keyPattern      = regexp.MustCompile(`(?m)^(jwtkey|hashkey|blockkey):[ \t]*("[^"\n]*"|[^ \t#\n]*)`)
+)
+
+// Options 安装参数
+type Options struct {
+	Driver   string `json:"driver"`
+	Host     string `json:"host"`
+	Port     string `json:"port"`
+	User     string `json:"user"`
+	Password string `json:"password"`
+	Name     string `json:"name"`
+	Prefix   string `json:"prefix"`
+
+	AdminUser     string `json:"admin_user"`
+	AdminPassword string `json:"admin_password"`
+	AdminEmail    string `json:"admin_email"`
+	Seed          string `json:"seed"`
+	Theme         string `json:"theme"`
+}
+
+// Check 环境检测项
+type Check struct {
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+	Ok      bool   `json:"ok"`
+	Message string `json:"message,omitempty"`
+}
+
+// Locked 已安装或安装已锁定
+func Locked() bool {
+	if config.DB().Initialized() {
+		return true
+	}
+	_, err := os.Stat(LockFile)
+	return err == nil
+}
+
+// Checks 检测运行环境, 目录需可写, 初始化数据需存在
+func Checks() []Check {
+	checks := []Check{
+		{Name: "运行环境", Value: runtime.Version() + " " + runtime.GOOS + "/" + runtime.GOARCH, Ok: true},
+		{Name: "数据库驱动", Value: strings.Join(dialect.Names(), " / "), Ok: true},
+	}
+	for _, dir := range []string{config.RuntimePath(), filepath.Dir(appYaml), uploadDir, theme.Root()} {
+		check := Check{Name: "目录可写", Value: dir, Ok: true}
+		if err := writable(dir); err != nil {
+			check.Ok, check.Message = false, err.Error()
+		}
+		checks = append(checks, check)
+	}
+	seed := Check{Name: "初始化数据", Value: SeedFile, Ok: true}
+	if _, err := os.Stat(SeedFile); err != nil {
+		seed.Ok, seed.Message = false, "文件不存在"
+	}
+	return append(checks, seed)
+}
+
+// Passed 环境检测是否全部通过
+func Passed(checks []Check) bool {
+	for _, check := range checks {
+		if !check.Ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Themes 可选主题
+func Themes() []string {
+	var themes []string
+	files, _ := os.ReadDir(theme.Root())
+	for _, f := range files {
+		if f.IsDir() && theme.Exists(f.Name()) {
+			themes = append(themes, f.Name())
+		}
+	}
+	return themes
+}
+
+// DbConf 由安装参数生成数据库配置
+func (o *Options) DbConf() *config.DbConf {
+	conf := &config.DbConf{}
+	conf.Db.DbDriver = o.Driver
+	conf.Db.DbPrefix = o.Prefix
+	conf.Db.Conf.ServeIp = o.Host
+	conf.Db.Conf.Port = o.Port
+	conf.Db.Conf.Username = o.User
+	conf.Db.Conf.Password = o.Password
+	conf.Db.Conf.Name = o.Name
+	conf.Orm.MaxOpenConns = 10
+	conf.Orm.MaxIdleConns = 10
+	return conf
+}
+
+// TestDB 测试数据库连接
+func TestDB(o *Options) error {
+	orm, err := open(o)
+	if err != nil {
+		return err
+	}
+	return orm.Close()
+}
+
+// Install 导入初始化数据, 创建管理员, 写入配置并生成密钥, 完成后锁定安装程序
+func Install(o *Options) error {
+	if Locked() {
+		return errors.New("系统已安装, 如需重新安装请删除 " + LockFile)
+	}
+	if err := o.validate(); err != nil {
+		return err
+	}
+	orm, err := open(o)
+	if err != nil {
+		return err
+	}
+	defer orm.Close()
+
+	if err := dialect.ImportDump(orm, SeedFile, o.Prefix); err != nil {
+		return fmt.Errorf("导入初始化数据失败: %w", err)
+	}
+	if o.Seed == SeedMinimal {
+		for _, table := range demoTables {
+			if _, err := orm.Exec("DELETE FROM " + orm.Quote(o.Prefix+table)); err != nil {
+				return fmt.Errorf("清空示例数据%s失败: %w", table, err)
+			}
+		}
+	}
+	if err := createAdmin(orm, o); err != nil {
+		return fmt.Errorf("创建管理员失败: %w", err)
+	}
+	if err := saveSetting(orm, o.Prefix, theme.SettingKey, "站点主题", o.Theme); err != nil {
+		return fmt.Errorf("保存主题失败: %w", err)
+	}
+	if err := GenerateKeys(); err != nil {
+		return fmt.Errorf("生成密钥失败: %w", err)
+	}
+	if err := o.DbConf().BuildYaml(); err != nil {
+		return fmt.Errorf("保存数据库配置失败: %w", err)
+	}
+	return Lock()
+}
+
+// Lock 锁定安装程序
+func Lock() error {
+	return os.WriteFile(LockFile, []byte(helper.NowDate(helper.TimeFormat)), 0644)
+}
+
+// GenerateKeys 为 jwtkey/hashkey/blockkey 生成随机值并写回配置文件, blockkey 为32字节以满足AES
+func GenerateKeys() error {
+	content, err := os.ReadFile(appYaml)
+	if err != nil {
+		return err
+	}
+	keys := map[string]string{}
+	for _, name := range []string{"jwtkey", "hashkey", "blockkey"} {
+		if keys[name], err = randomKey(); err != nil {
+			return err
+		}
+	}
+	replaced := map[string]bool{}
+	content = keyPattern.ReplaceAllFunc(content, func(line []byte) []byte {
+		name := keyPattern.FindSubmatch(line)[1]
+		replaced[string(name)] = true
+		return []byte(fmt.Sprintf(`%s: "%s"`, name, keys[string(name)]))
+	})
+	for _, name := range []string{"jwtkey", "hashkey", "blockkey"} {
+		if !replaced[name] {
+			content = append(content, []byte(fmt.Sprintf("\n%s: \"%s\"", name, keys[name]))...)
+		}
+	}
+	if err := os.WriteFile(appYaml, content, 0644); err != nil {
+		return err
+	}
+	conf := config.App()
+	conf.JwtKey, conf.HashKey, conf.BlockKey = keys["jwtkey"], keys["hashkey"], keys["blockkey"]
+	return nil
+}
+
+func (o *Options) validate() error {
+	if _, err := dialect.Get(o.Driver); err != nil {
+		return err
+	}
+	if !o.DbConf().Db.Valid() {
+		return errors.New("数据库配置信息必须填写")
+	}
+	if !prefixPattern.MatchString(o.Prefix) {
+		return errors.New("表前缀只能包含小写字母数字下划线, 且以字母开头")
+	}
+	if !usernamePattern.MatchString(o.AdminUser) {
+		return errors.New("管理员账号为3-20位字母数字下划线")
+	}
+	if len(o.AdminPassword) < 6 {
+		return errors.New("管理员密码不能少于6位")
+	}
+	if o.Seed != SeedMinimal {
+		o.Seed = SeedDemo
+	}
+	if len(o.Theme) == 0 {
+		o.Theme = config.App().View.Theme
+	}
+	if !theme.Exists(o.Theme) {
+		return fmt.Errorf("主题%s不存在", o.Theme)
+	}
+	if _, err := os.Stat(SeedFile); err != nil {
+		return errors.New("缺少初始化数据 " + SeedFile)
+	}
+	return nil
+}
+
+func open(o *Options) (*xorm.Engine, error) {
+	d, err := dialect.Get(o.Driver)
+	if err != nil {
+		return nil, err
+	}
+	if o.Driver == "sqlite" {
+		if err := os.MkdirAll(filepath.Dir(o.Name), os.ModePerm); err != nil {
+			return nil, err
+		}
+	}
+	orm, err := xorm.NewEngine(o.Driver, d.DSN(dialect.DSNConfig{
+		Host:     o.Host,
+		Port:     o.Port,
+		User:     o.User,
+		Password: o.Password,
+		Name:     o.Name,
+	}))
+	if err != nil {
+		return nil, err
+	}
+	if err := orm.Ping(); err != nil {
+		_ = orm.Close()
+		return nil, fmt.Errorf("连接数据库失败: %w", err)
+	}
+	return orm, nil
+}
+
+// createAdmin 使用安装时填写的账号替换初始化数据中的管理员
+func createAdmin(orm *xorm.Engine, o *Options) error {
+	encrypt := string(helper.Krand(8, 3))
+	data := map[string]any{
+		"username": o.AdminUser,
+		"password": helper.Password(o.AdminPassword, encrypt),
+		"encrypt":  encrypt,
+		"email":    o.AdminEmail,
+		"realname": o.AdminUser,
+	}
+	table := o.Prefix + "admin"
+	affected, err := orm.Table(table).Where("id = ?", 1).Update(data)
+	if err != nil || affected > 0 {
+		return err
+	}
+	data["roles"], data["status"] = "[1]", 1
+	_, err = orm.Table(table).Insert(data)
+	return err
+}
+
+func saveSetting(orm *xorm.Engine, prefix, key, formName, value string) error {
+	table := prefix + "setting"
+	exist, err := orm.Table(table).Where(orm.Quote("key")+" = ?", key).Exist()
+	if err != nil {
+		return err
+	}
+	if exist {
+		_, err = orm.Table(table).Where(orm.Quote("key")+" = ?", key).Update(map[string]any{"value": value})
+		return err
+	}
+	_, err = orm.Table(table).Insert(map[string]any{"key": key, "form_name": formName, "value": value, "group": "主题配置", "editor": "el-input"})
+	return err
+}
+
+func writable(dir string) error {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return err
+	}
+	file := filepath.Join(dir, fmt.Sprintf(".install-%d", time.Now().UnixNano()))
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		return errors.New("目录不可写")
+	}
+	return os.Remove(file)
+}
+
+func randomKey() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
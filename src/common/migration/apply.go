@@ -0,0 +1,189 @@
+package migration
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bytedance/sonic"
+	"github.com/xiusin/pine"
+	"github.com/xiusin/pinecms/src/application/controllers"
+	"github.com/xiusin/pinecms/src/application/models/tables"
+	"github.com/xiusin/pinecms/src/common/helper"
+	"xorm.io/xorm/schemas"
+)
+
+var (
+	// ErrConfirm 存在数据丢失风险的步骤需要确认后执行
+	ErrConfirm = errors.New("迁移包含可能丢失数据的步骤, 请确认后执行")
+	// ErrChanged 执行时重新生成的计划与预览不一致
+	ErrChanged = errors.New("迁移计划与预览不一致, 请重新预览后执行")
+)
+
+// Apply 执行迁移并记录, checksum 为预览时计划的校验和, 计划有变化时拒绝执行.
+// 支持事务DDL的数据库在事务中执行, MySQL失败时按回滚SQL逆序补偿
+func Apply(plan *Plan, checksum string, adminId int64, confirm bool) (*tables.ModelMigration, error) {
+	if len(plan.Steps) == 0 {
+		return nil, errors.New("没有任何改动可以执行")
+	}
+	if checksum != plan.Checksum() {
+		return nil, ErrChanged
+	}
+	if len(plan.Warnings) > 0 && !confirm {
+		return nil, ErrConfirm
+	}
+	ups, downs := statements(plan.Steps)
+	if err := execute(ups, downs); err != nil {
+		return nil, err
+	}
+
+	migration := &tables.ModelMigration{
+		Mid:      plan.Mid,
+		Table:    plan.Table,
+		Checksum: plan.Checksum(),
+		AdminId:  adminId,
+		Warnings: strings.Join(plan.Warnings, "\n"),
+	}
+	migration.Steps, _ = sonic.MarshalString(plan.Steps)
+	migration.Fields, _ = sonic.MarshalString(plan.Fields)
+	migration.SchemaChecksum, _ = schemaChecksum(plan.Table)
+	orm := helper.GetORM()
+	if _, err := orm.InsertOne(migration); err != nil {
+		pine.Logger().Warn("保存模型迁移记录失败", err)
+	}
+	_, _ = orm.ID(plan.Mid).Table(&tables.DocumentModel{}).Update(map[string]any{"execed": 1})
+	clearCache(plan.Mid, plan.Table)
+	return migration, nil
+}
+
+// Rollback 回滚模型最近一次生效的迁移, 表结构在迁移后被改动时需要force,
+// 回滚会删除表或字段等丢失数据时返回提示与 ErrConfirm, 需要confirm后执行
+func Rollback(mid int64, force, confirm bool) (*tables.ModelMigration, []string, error) {
+	orm := helper.GetORM()
+	migration := &tables.ModelMigration{}
+	if ok, _ := orm.Where("mid = ? AND rolled_back = ?", mid, false).Desc("id").Get(migration); !ok {
+		return nil, nil, errors.New("没有可回滚的迁移")
+	}
+	var steps []Step
+	if err := sonic.UnmarshalString(migration.Steps, &steps); err != nil {
+		return nil, nil, err
+	}
+	if checksum(steps) != migration.Checksum {
+		return nil, nil, errors.New("迁移记录校验失败, 记录可能被篡改")
+	}
+	if current, _ := schemaChecksum(migration.Table); current != migration.SchemaChecksum && !force {
+		return nil, nil, errors.New("数据表结构在迁移后已被修改, 确认后强制回滚")
+	}
+	warnings := rollbackWarnings(migration.Table, steps)
+	if len(warnings) > 0 && !confirm {
+		return nil, warnings, ErrConfirm
+	}
+	ups, downs := statements(steps)
+	if err := execute(downs, ups); err != nil {
+		return nil, warnings, err
+	}
+	migration.RolledBack = true
+	if _, err := orm.ID(migration.Id).Cols("rolled_back").Update(migration); err != nil {
+		return nil, warnings, err
+	}
+	_, _ = orm.ID(mid).Table(&tables.DocumentModel{}).Update(map[string]any{"execed": 0})
+	clearCache(mid, migration.Table)
+	return migration, warnings, nil
+}
+
+// rollbackWarnings 回滚步骤中会丢失数据的提示
+func rollbackWarnings(table string, steps []Step) []string {
+	var warnings []string
+	for _, step := range steps {
+		switch step.Action {
+		case ActionCreate:
+			warnings = append(warnings, fmt.Sprintf("回滚将删除数据表%s及全部数据", table))
+		case ActionAdd:
+			warnings = append(warnings, fmt.Sprintf("回滚将删除字段%s及该列数据", step.Column))
+		case ActionModify:
+			warnings = append(warnings, fmt.Sprintf("回滚将字段%s恢复为原类型, 已有数据可能被截断或转换失败", step.Column))
+		}
+	}
+	return warnings
+}
+
+// statements 按步骤顺序的执行SQL与逆序的回滚SQL
+func statements(steps []Step) (ups, downs []string) {
+	for i, step := range steps {
+		ups = append(ups, step.Up)
+		downs = append(downs, steps[len(steps)-1-i].Down)
+	}
+	return ups, downs
+}
+
+// History 模型的迁移记录
+func History(mid int64) ([]tables.ModelMigration, error) {
+	var list []tables.ModelMigration
+	err := helper.GetORM().Where("mid = ?", mid).Desc("id").Find(&list)
+	return list, err
+}
+
+// execute 逐条执行SQL. MySQL的DDL会隐式提交, 失败时执行已完成步骤对应的补偿SQL
+func execute(sqls, compensations []string) error {
+	orm := helper.GetORM()
+	if orm.Dialect().URI().DBType != schemas.MYSQL {
+		sess := orm.NewSession()
+		defer sess.Close()
+		if err := sess.Begin(); err != nil {
+			return err
+		}
+		for _, sql := range sqls {
+			if _, err := sess.Exec(sql); err != nil {
+				_ = sess.Rollback()
+				return fmt.Errorf("%s: %w", sql, err)
+			}
+		}
+		return sess.Commit()
+	}
+	for i, sql := range sqls {
+		if _, err := orm.Exec(sql); err != nil {
+			for _, undo := range undoSQL(compensations, i) {
+				if _, undoErr := orm.Exec(undo); undoErr != nil {
+					pine.Logger().Warn("迁移补偿失败", undo, undoErr)
+				}
+			}
+			return fmt.Errorf("%s: %w", sql, err)
+		}
+	}
+	return nil
+}
+
+// undoSQL 已执行前done条SQL时需要执行的补偿SQL, compensations 与 sqls 逆序对应, 已执行的前done步对应末尾done条
+func undoSQL(compensations []string, done int) []string {
+	return compensations[len(compensations)-done:]
+}
+
+// schemaChecksum 当前表结构的校验和
+func schemaChecksum(table string) (string, error) {
+	orm := helper.GetORM()
+	tableName := controllers.GetTableName(table)
+	if exists, _ := orm.IsTableExist(tableName); !exists {
+		return "", nil
+	}
+	_, columns, err := orm.Dialect().GetColumns(orm.DB(), context.Background(), tableName)
+	if err != nil {
+		return "", err
+	}
+	var lines []string
+	for _, col := range columns {
+		lines = append(lines, fmt.Sprintf("%s %s %d %t", col.Name, col.SQLType.Name, col.Length, col.Nullable))
+	}
+	sort.Strings(lines)
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(strings.Join(lines, "\n")))), nil
+}
+
+func clearCache(mid int64, table string) {
+	cache := helper.Cache()
+	_ = cache.Delete(fmt.Sprintf(controllers.CacheTableNameFields, controllers.GetTableName(table)))
+	_ = cache.Delete(fmt.Sprintf(controllers.CacheModelTablePrefix, mid))
+	_ = cache.Delete(fmt.Sprintf(controllers.CacheDocumentModelPrefix, mid))
+	_ = cache.Delete(controllers.CacheModels)
+}
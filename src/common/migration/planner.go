@@ -0,0 +1,308 @@
+package migration
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bytedance/sonic"
+	"github.com/xiusin/pine"
+	"github.com/xiusin/pinecms/src/application/controllers"
+	"github.com/xiusin/pinecms/src/application/models"
+	"github.com/xiusin/pinecms/src/application/models/tables"
+	"github.com/xiusin/pinecms/src/common/helper"
+	"xorm.io/xorm/dialects"
+	"xorm.io/xorm/schemas"
+)
+
+// 迁移步骤类型
+const (
+	ActionCreate = "create"
+	ActionAdd    = "add"
+	ActionModify = "modify"
+	ActionRename = "rename"
+	ActionDrop   = "drop"
+)
+
+// Step 单个迁移步骤, Down 为回滚SQL
+type Step struct {
+	Action  string `json:"action"`
+	Column  string `json:"column"`
+	From    string `json:"from,omitempty"`
+	Up      string `json:"up"`
+	Down    string `json:"down"`
+	Warning string `json:"warning,omitempty"`
+}
+
+// Plan 模型字段与数据表的差异
+type Plan struct {
+	Mid      int64            `json:"mid"`
+	Table    string           `json:"table"`
+	Steps    []Step           `json:"steps"`
+	Warnings []string         `json:"warnings"`
+	Fields   map[int64]string `json:"fields"`
+}
+
+// systemColumns 模型表固有字段, 不在字段DSL中维护
+var systemColumns = []*schemas.Column{
+	{Name: "catid", SQLType: schemas.SQLType{Name: schemas.Int}, Default: "0", Comment: "所属栏目ID"},
+	{Name: "mid", SQLType: schemas.SQLType{Name: schemas.Int}, Default: "0", Comment: "模型ID"},
+	{Name: "created_time", SQLType: schemas.SQLType{Name: schemas.DateTime}, Nullable: true, DefaultIsEmpty: true},
+	{Name: "updated_time", SQLType: schemas.SQLType{Name: schemas.DateTime}, Nullable: true, DefaultIsEmpty: true},
+	{Name: "deleted_time", SQLType: schemas.SQLType{Name: schemas.DateTime}, Nullable: true, DefaultIsEmpty: true},
+}
+
+// fieldTypeLength 字段类型的默认长度
+var fieldTypeLength = map[string]int64{
+	schemas.Varchar: 100,
+	schemas.Int:     10,
+}
+
+// InitInstall 同步迁移记录表
+func InitInstall() {
+	if err := helper.GetORM().Sync2(&tables.ModelMigration{}); err != nil {
+		pine.Logger().Warn("同步模型迁移记录表失败", err)
+	}
+}
+
+// NewPlan 对比模型字段DSL与数据表, 生成新增/修改/重命名/删除步骤
+func NewPlan(mid int64) (*Plan, error) {
+	model := models.NewDocumentModel().GetByID(mid)
+	if model == nil || model.Id == 0 {
+		return nil, errors.New("模型不存在")
+	}
+	if len(model.Table) == 0 {
+		return nil, errors.New("模型未设置表名")
+	}
+	orm := helper.GetORM()
+	dialect := orm.Dialect()
+	tableName := controllers.GetTableName(model.Table)
+	plan := &Plan{Mid: mid, Table: model.Table, Fields: map[int64]string{}}
+
+	fieldTypes := models.NewDocumentModelFieldModel().GetMap()
+	var fields []tables.DocumentModelDsl
+	for _, field := range models.NewDocumentFieldDslModel().GetList(mid) {
+		if len(field.TableField) == 0 || isSystemColumn(field.TableField) {
+			continue
+		}
+		fields = append(fields, field)
+		plan.Fields[field.Id] = field.TableField
+	}
+
+	if exists, err := orm.IsTableExist(tableName); err != nil {
+		return nil, err
+	} else if !exists {
+		table := schemas.NewEmptyTable()
+		table.Name = tableName
+		table.AddColumn(&schemas.Column{Name: "id", SQLType: schemas.SQLType{Name: schemas.Int}, IsPrimaryKey: true, IsAutoIncrement: true, DefaultIsEmpty: true, Comment: "ID自增字段"})
+		for _, field := range fields {
+			table.AddColumn(desiredColumn(field, fieldTypes))
+		}
+		for _, col := range systemColumns {
+			c := *col
+			table.AddColumn(&c)
+		}
+		up, _, err := dialect.CreateTableSQL(context.Background(), orm.DB(), table, tableName)
+		if err != nil {
+			return nil, err
+		}
+		down, _ := dialect.DropTableSQL(tableName)
+		plan.Steps = append(plan.Steps, Step{Action: ActionCreate, Up: up, Down: down})
+		return plan, nil
+	}
+
+	_, live, err := dialect.GetColumns(orm.DB(), context.Background(), tableName)
+	if err != nil {
+		return nil, err
+	}
+	plan.diff(dialect, tableName, fields, fieldTypes, live, lastFields(mid))
+	for _, step := range plan.Steps {
+		if len(step.Warning) > 0 {
+			plan.Warnings = append(plan.Warnings, step.Warning)
+		}
+	}
+	return plan, nil
+}
+
+// diff 对比字段定义与数据表现有字段生成迁移步骤, previous 为上次迁移的字段快照, 用于识别重命名
+func (p *Plan) diff(dialect dialects.Dialect, tableName string, fields []tables.DocumentModelDsl, fieldTypes map[int64]*tables.DocumentModelField, live map[string]*schemas.Column, previous map[int64]string) {
+	claimed := map[string]bool{}
+	for _, field := range fields {
+		claimed[field.TableField] = true
+	}
+	for _, field := range fields {
+		want := desiredColumn(field, fieldTypes)
+		current, ok := live[field.TableField]
+		if !ok {
+			// 上次迁移时该字段使用旧名称且旧列仍在, 视为重命名
+			if old, renamed := previous[field.Id]; renamed && old != field.TableField && !claimed[old] && live[old] != nil {
+				current = live[old]
+				p.Steps = append(p.Steps, Step{
+					Action: ActionRename,
+					Column: field.TableField,
+					From:   old,
+					Up:     renameColumnSQL(dialect, tableName, old, withName(current, field.TableField)),
+					Down:   renameColumnSQL(dialect, tableName, field.TableField, current),
+				})
+				claimed[old] = true
+				delete(live, old)
+				current = withName(current, field.TableField)
+			} else {
+				p.Steps = append(p.Steps, Step{
+					Action: ActionAdd,
+					Column: field.TableField,
+					Up:     dialect.AddColumnSQL(tableName, want),
+					Down:   dropColumnSQL(dialect, tableName, field.TableField),
+				})
+				continue
+			}
+		}
+		delete(live, field.TableField)
+		if modify, warning := needModify(current, want); modify {
+			if dialect.URI().DBType == schemas.SQLITE {
+				p.Warnings = append(p.Warnings, fmt.Sprintf("SQLite不支持修改字段类型, 已跳过字段%s", field.TableField))
+				continue
+			}
+			p.Steps = append(p.Steps, Step{
+				Action:  ActionModify,
+				Column:  field.TableField,
+				Up:      dialect.ModifyColumnSQL(tableName, want),
+				Down:    dialect.ModifyColumnSQL(tableName, current),
+				Warning: warning,
+			})
+		}
+	}
+
+	var dropped []string
+	for name := range live {
+		if name != "id" && !isSystemColumn(name) {
+			dropped = append(dropped, name)
+		}
+	}
+	sort.Strings(dropped)
+	for _, name := range dropped {
+		p.Steps = append(p.Steps, Step{
+			Action:  ActionDrop,
+			Column:  name,
+			Up:      dropColumnSQL(dialect, tableName, name),
+			Down:    dialect.AddColumnSQL(tableName, live[name]),
+			Warning: fmt.Sprintf("删除字段%s将丢失该列全部数据, 回滚只能恢复列结构", name),
+		})
+	}
+}
+
+// SQL 计划执行的全部SQL
+func (p *Plan) SQL() string {
+	var sqls []string
+	for _, step := range p.Steps {
+		sqls = append(sqls, step.Up+";")
+	}
+	return strings.Join(sqls, "\n")
+}
+
+// Checksum 迁移步骤的校验和
+func (p *Plan) Checksum() string {
+	return checksum(p.Steps)
+}
+
+func checksum(steps []Step) string {
+	h := sha256.New()
+	for _, step := range steps {
+		_, _ = fmt.Fprintf(h, "%s\n%s\n", step.Up, step.Down)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// lastFields 最近一次生效迁移的字段快照
+func lastFields(mid int64) map[int64]string {
+	fields := map[int64]string{}
+	migration := &tables.ModelMigration{}
+	if ok, _ := helper.GetORM().Where("mid = ? AND rolled_back = ?", mid, false).Desc("id").Get(migration); ok {
+		_ = sonic.UnmarshalString(migration.Fields, &fields)
+	}
+	return fields
+}
+
+func isSystemColumn(name string) bool {
+	for _, col := range systemColumns {
+		if col.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func desiredColumn(field tables.DocumentModelDsl, fieldTypes map[int64]*tables.DocumentModelField) *schemas.Column {
+	typ := schemas.Varchar
+	if fieldType, ok := fieldTypes[field.FieldType]; ok && len(fieldType.Type) > 0 {
+		typ = strings.ToUpper(fieldType.Type)
+	}
+	return &schemas.Column{
+		Name:           field.TableField,
+		SQLType:        schemas.SQLType{Name: typ},
+		Length:         fieldTypeLength[typ],
+		Nullable:       true,
+		DefaultIsEmpty: true,
+		Comment:        strings.NewReplacer("'", "", `"`, "", "\\", "").Replace(field.FormName),
+	}
+}
+
+func withName(col *schemas.Column, name string) *schemas.Column {
+	c := *col
+	c.Name = name
+	return &c
+}
+
+// needModify 仅在类型类别变化或长度不足时修改, 不收窄已有的列
+func needModify(current, want *schemas.Column) (bool, string) {
+	kind := func(t schemas.SQLType) string {
+		switch {
+		case t.IsText() || t.IsBlob():
+			return "text"
+		case t.IsTime():
+			return "time"
+		case t.IsNumeric() || t.IsBool():
+			return "number"
+		}
+		return t.Name
+	}
+	isFloat := func(name string) bool {
+		return helper.InArray(name, []string{schemas.Float, schemas.Double, schemas.Decimal, schemas.Real, schemas.Numeric})
+	}
+	from, to := kind(current.SQLType), kind(want.SQLType)
+	switch {
+	case from != to:
+		return true, fmt.Sprintf("字段%s类型由%s改为%s, 已有数据可能被截断或转换失败", want.Name, current.SQLType.Name, want.SQLType.Name)
+	case to == "text" && want.SQLType.Name == schemas.Text:
+		return current.SQLType.Name == schemas.Varchar || current.SQLType.Name == schemas.Char, ""
+	case to == "text" && want.SQLType.Name == schemas.Varchar:
+		return (current.SQLType.Name == schemas.Varchar || current.SQLType.Name == schemas.Char) &&
+			current.Length > 0 && current.Length < want.Length, ""
+	case to == "number" && isFloat(want.SQLType.Name) && !isFloat(current.SQLType.Name):
+		return true, ""
+	case to == "number" && !isFloat(want.SQLType.Name) && isFloat(current.SQLType.Name):
+		return true, fmt.Sprintf("字段%s由%s改为%s, 小数部分将被舍弃", want.Name, current.SQLType.Name, want.SQLType.Name)
+	}
+	return false, ""
+}
+
+func dropColumnSQL(dialect dialects.Dialect, tableName, column string) string {
+	quoter := dialect.Quoter()
+	return fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", quoter.Quote(tableName), quoter.Quote(column))
+}
+
+// renameColumnSQL MySQL使用CHANGE兼容8.0以下版本, 其他数据库使用RENAME COLUMN
+func renameColumnSQL(dialect dialects.Dialect, tableName, from string, to *schemas.Column) string {
+	quoter := dialect.Quoter()
+	if dialect.URI().DBType == schemas.MYSQL {
+		s, _ := dialects.ColumnString(dialect, to, false, true)
+		if len(to.Comment) > 0 {
+			s += " COMMENT '" + to.Comment + "'"
+		}
+		return fmt.Sprintf("ALTER TABLE %s CHANGE %s %s", quoter.Quote(tableName), quoter.Quote(from), s)
+	}
+	return fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s", quoter.Quote(tableName), quoter.Quote(from), quoter.Quote(to.Name))
+}
@@ -0,0 +1,133 @@
+package migration
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/xiusin/pinecms/src/application/models/tables"
+	"xorm.io/xorm/dialects"
+	"xorm.io/xorm/schemas"
+)
+
+func testDialect(t *testing.T, dbType schemas.DBType) dialects.Dialect {
+	t.Helper()
+	dialect := dialects.QueryDialect(dbType)
+	if err := dialect.Init(&dialects.URI{DBType: dbType}); err != nil {
+		t.Fatal(err)
+	}
+	return dialect
+}
+
+func varchar(name string, length int64) *schemas.Column {
+	return &schemas.Column{Name: name, SQLType: schemas.SQLType{Name: schemas.Varchar}, Length: length, Nullable: true}
+}
+
+// testFields 字段类型1为varchar, 2为text
+var testFieldTypes = map[int64]*tables.DocumentModelField{
+	1: {Type: "varchar"},
+	2: {Type: "text"},
+}
+
+func TestPlanDiff(t *testing.T) {
+	live := map[string]*schemas.Column{
+		"id":       {Name: "id", SQLType: schemas.SQLType{Name: schemas.Int}},
+		"catid":    {Name: "catid", SQLType: schemas.SQLType{Name: schemas.Int}},
+		"subtitle": varchar("subtitle", 100),
+		"summary":  varchar("summary", 100),
+		"remark":   varchar("remark", 100),
+		"legacy":   varchar("legacy", 100),
+	}
+	fields := []tables.DocumentModelDsl{
+		{Id: 1, TableField: "subtitle", FieldType: 1}, // 无变化
+		{Id: 2, TableField: "summary", FieldType: 2},  // varchar 改为 text
+		{Id: 3, TableField: "note", FieldType: 1},     // 上次迁移名为remark, 重命名
+		{Id: 4, TableField: "author", FieldType: 1},   // 新增
+	}
+	previous := map[int64]string{1: "subtitle", 2: "summary", 3: "remark"}
+	plan := &Plan{}
+	plan.diff(testDialect(t, schemas.MYSQL), "pinecms_articles", fields, testFieldTypes, live, previous)
+
+	var actions []string
+	for _, step := range plan.Steps {
+		actions = append(actions, step.Action+":"+step.From+">"+step.Column)
+	}
+	want := []string{"modify:>summary", "rename:remark>note", "add:>author", "drop:>legacy"}
+	if !slices.Equal(actions, want) {
+		t.Fatalf("迁移步骤错误\n实际: %v\n期望: %v", actions, want)
+	}
+	rename := plan.Steps[1]
+	if !strings.Contains(rename.Up, "CHANGE `remark` `note`") || !strings.Contains(rename.Down, "CHANGE `note` `remark`") {
+		t.Fatalf("重命名SQL错误: %s / %s", rename.Up, rename.Down)
+	}
+	drop := plan.Steps[3]
+	if len(drop.Warning) == 0 || !strings.Contains(drop.Up, "DROP COLUMN `legacy`") || !strings.Contains(drop.Down, "ADD `legacy`") {
+		t.Fatalf("删除字段步骤错误: %+v", drop)
+	}
+}
+
+func TestPlanDiffRenameRequiresSnapshot(t *testing.T) {
+	live := map[string]*schemas.Column{"id": {Name: "id"}, "remark": varchar("remark", 100)}
+	fields := []tables.DocumentModelDsl{{Id: 3, TableField: "note", FieldType: 1}}
+	plan := &Plan{}
+	plan.diff(testDialect(t, schemas.MYSQL), "pinecms_articles", fields, testFieldTypes, live, nil)
+	if len(plan.Steps) != 2 || plan.Steps[0].Action != ActionAdd || plan.Steps[1].Action != ActionDrop {
+		t.Fatalf("没有字段快照时应新增并删除字段: %+v", plan.Steps)
+	}
+}
+
+func TestPlanDiffSQLiteSkipsModify(t *testing.T) {
+	live := map[string]*schemas.Column{"id": {Name: "id"}, "summary": varchar("summary", 100)}
+	fields := []tables.DocumentModelDsl{{Id: 2, TableField: "summary", FieldType: 2}}
+	plan := &Plan{}
+	plan.diff(testDialect(t, schemas.SQLITE), "pinecms_articles", fields, testFieldTypes, live, nil)
+	if len(plan.Steps) != 0 || len(plan.Warnings) != 1 {
+		t.Fatalf("SQLite应跳过修改字段并提示: %+v %v", plan.Steps, plan.Warnings)
+	}
+}
+
+func TestStatements(t *testing.T) {
+	steps := []Step{{Up: "u1", Down: "d1"}, {Up: "u2", Down: "d2"}, {Up: "u3", Down: "d3"}}
+	ups, downs := statements(steps)
+	if !slices.Equal(ups, []string{"u1", "u2", "u3"}) || !slices.Equal(downs, []string{"d3", "d2", "d1"}) {
+		t.Fatalf("执行顺序错误: %v %v", ups, downs)
+	}
+	// 执行第3步失败, 按逆序补偿已完成的前2步
+	if undo := undoSQL(downs, 2); !slices.Equal(undo, []string{"d2", "d1"}) {
+		t.Fatalf("迁移补偿顺序错误: %v", undo)
+	}
+	// 回滚时执行 downs, 第2条失败时补偿已回滚的 d3
+	if undo := undoSQL(ups, 1); !slices.Equal(undo, []string{"u3"}) {
+		t.Fatalf("回滚补偿顺序错误: %v", undo)
+	}
+	if undo := undoSQL(downs, 0); len(undo) != 0 {
+		t.Fatalf("首条失败时不需要补偿: %v", undo)
+	}
+}
+
+func TestRollbackWarnings(t *testing.T) {
+	steps := []Step{
+		{Action: ActionAdd, Column: "author"},
+		{Action: ActionRename, Column: "note", From: "remark"},
+		{Action: ActionModify, Column: "summary"},
+		{Action: ActionDrop, Column: "legacy"},
+	}
+	if warnings := rollbackWarnings("articles", steps); len(warnings) != 2 {
+		t.Fatalf("新增与修改字段的回滚应提示数据丢失: %v", warnings)
+	}
+	if warnings := rollbackWarnings("articles", []Step{{Action: ActionCreate}}); len(warnings) != 1 || !strings.Contains(warnings[0], "articles") {
+		t.Fatalf("回滚建表应提示删除数据表: %v", warnings)
+	}
+	if warnings := rollbackWarnings("articles", []Step{{Action: ActionRename}, {Action: ActionDrop}}); len(warnings) != 0 {
+		t.Fatalf("重命名与删除字段的回滚不丢失数据: %v", warnings)
+	}
+}
+
+func TestChecksum(t *testing.T) {
+	plan := &Plan{Steps: []Step{{Up: "u1", Down: "d1"}}}
+	sum := plan.Checksum()
+	plan.Steps[0].Up = "u2"
+	if plan.Checksum() == sum {
+		t.Fatal("步骤变化后校验和应变化")
+	}
+}
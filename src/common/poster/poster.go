@@ -0,0 +1,248 @@
+package poster
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	_ "image/gif"
+	_ "image/jpeg"
+
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	ElementImage  = "image"  // 固定图片
+	ElementAvatar = "avatar" // 用户头像
+	ElementQrcode = "qrcode" // 用户专属二维码
+	ElementText   = "text"   // 文本, 支持{nickname}等变量
+)
+
+// Element 海报上的一个元素
+type Element struct {
+	Type     string  `json:"type"`
+	X        int     `json:"x"`
+	Y        int     `json:"y"`
+	Width    int     `json:"width"`
+	Height   int     `json:"height"`
+	Src      string  `json:"src"`       // image类型的图片地址
+	Text     string  `json:"text"`      // text类型的内容
+	Font     string  `json:"font"`      // 字体文件路径, 为空使用默认字体
+	FontSize float64 `json:"font_size"` // 字号, 默认24
+	Color    string  `json:"color"`     // 文字颜色 #RRGGBB
+	Round    bool    `json:"round"`     // 图片是否裁剪为圆形
+}
+
+// Template 海报模板
+type Template struct {
+	Width      int       `json:"width"`
+	Height     int       `json:"height"`
+	Background string    `json:"background"`
+	Elements   []Element `json:"elements"`
+}
+
+// Data 渲染用户海报所需的数据
+type Data struct {
+	Avatar []byte
+	Qrcode []byte
+	Vars   map[string]string // 文本变量, 如 nickname => 张三
+}
+
+// Loader 读取图片地址对应的内容
+type Loader func(src string) ([]byte, error)
+
+// DefaultFont 未指定字体时使用的字体文件, 需要支持中文时配置为中文字体
+var DefaultFont = "resources/fonts/default.ttf"
+
+var fonts sync.Map
+
+func loadFont(path string) (*opentype.Font, error) {
+	if len(path) == 0 {
+		path = DefaultFont
+	}
+	if f, ok := fonts.Load(path); ok {
+		return f.(*opentype.Font), nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if path != DefaultFont {
+			return nil, err
+		}
+		data = goregular.TTF
+	}
+	f, err := opentype.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	fonts.Store(path, f)
+	return f, nil
+}
+
+func parseColor(hex string) color.Color {
+	hex = strings.TrimPrefix(hex, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil || len(hex) != 6 {
+		return color.Black
+	}
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xff}
+}
+
+func decode(data []byte) (image.Image, error) {
+	if len(data) == 0 {
+		return nil, errors.New("图片内容为空")
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	return img, err
+}
+
+// circle 圆形遮罩
+type circle struct {
+	r int
+}
+
+func (c *circle) ColorModel() color.Model { return color.AlphaModel }
+
+func (c *circle) Bounds() image.Rectangle { return image.Rect(0, 0, c.r*2, c.r*2) }
+
+func (c *circle) At(x, y int) color.Color {
+	xx, yy, rr := float64(x-c.r)+0.5, float64(y-c.r)+0.5, float64(c.r)
+	if xx*xx+yy*yy < rr*rr {
+		return color.Alpha{A: 255}
+	}
+	return color.Alpha{}
+}
+
+func drawImage(dst draw.Image, src image.Image, el Element) {
+	w, h := el.Width, el.Height
+	if w == 0 || h == 0 {
+		w, h = src.Bounds().Dx(), src.Bounds().Dy()
+	}
+	scaled := image.NewRGBA(image.Rect(0, 0, w, h))
+	xdraw.CatmullRom.Scale(scaled, scaled.Bounds(), src, src.Bounds(), draw.Over, nil)
+	rect := image.Rect(el.X, el.Y, el.X+w, el.Y+h)
+	if el.Round {
+		draw.DrawMask(dst, rect, scaled, image.Point{}, &circle{r: min(w, h) / 2}, image.Point{}, draw.Over)
+	} else {
+		draw.Draw(dst, rect, scaled, image.Point{}, draw.Over)
+	}
+}
+
+func drawText(dst draw.Image, el Element, vars map[string]string) error {
+	text := el.Text
+	for k, v := range vars {
+		text = strings.ReplaceAll(text, "{"+k+"}", v)
+	}
+	f, err := loadFont(el.Font)
+	if err != nil {
+		return err
+	}
+	size := el.FontSize
+	if size == 0 {
+		size = 24
+	}
+	face, err := opentype.NewFace(f, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+	if err != nil {
+		return err
+	}
+	defer face.Close()
+	d := &font.Drawer{Dst: dst, Src: image.NewUniform(parseColor(el.Color)), Face: face}
+	lineHeight := face.Metrics().Height.Ceil()
+	y := el.Y + face.Metrics().Ascent.Ceil()
+	for _, line := range strings.Split(text, "\n") {
+		d.Dot = fixed.P(el.X, y)
+		if el.Width > 0 {
+			// 超出宽度时自动换行
+			var buf []rune
+			for _, r := range line {
+				if d.MeasureString(string(append(buf, r))).Ceil() > el.Width && len(buf) > 0 {
+					d.DrawString(string(buf))
+					y += lineHeight
+					d.Dot = fixed.P(el.X, y)
+					buf = buf[:0]
+				}
+				buf = append(buf, r)
+			}
+			line = string(buf)
+		}
+		d.DrawString(line)
+		y += lineHeight
+	}
+	return nil
+}
+
+// Render 按模板渲染海报为PNG
+func Render(tpl *Template, data *Data, loader Loader) ([]byte, error) {
+	var canvas *image.RGBA
+	if len(tpl.Background) > 0 {
+		byts, err := loader(tpl.Background)
+		if err != nil {
+			return nil, fmt.Errorf("读取背景图失败: %w", err)
+		}
+		bg, err := decode(byts)
+		if err != nil {
+			return nil, fmt.Errorf("解析背景图失败: %w", err)
+		}
+		if tpl.Width == 0 || tpl.Height == 0 {
+			tpl.Width, tpl.Height = bg.Bounds().Dx(), bg.Bounds().Dy()
+		}
+		canvas = image.NewRGBA(image.Rect(0, 0, tpl.Width, tpl.Height))
+		xdraw.CatmullRom.Scale(canvas, canvas.Bounds(), bg, bg.Bounds(), draw.Src, nil)
+	} else {
+		if tpl.Width == 0 || tpl.Height == 0 {
+			return nil, errors.New("未设置背景图时必须设置海报尺寸")
+		}
+		canvas = image.NewRGBA(image.Rect(0, 0, tpl.Width, tpl.Height))
+		draw.Draw(canvas, canvas.Bounds(), image.White, image.Point{}, draw.Src)
+	}
+
+	for i, el := range tpl.Elements {
+		var byts []byte
+		var err error
+		switch el.Type {
+		case ElementText:
+			if err = drawText(canvas, el, data.Vars); err != nil {
+				return nil, fmt.Errorf("第%d个元素绘制文字失败: %w", i+1, err)
+			}
+			continue
+		case ElementAvatar:
+			byts = data.Avatar
+		case ElementQrcode:
+			byts = data.Qrcode
+		case ElementImage:
+			if byts, err = loader(el.Src); err != nil {
+				return nil, fmt.Errorf("第%d个元素读取图片失败: %w", i+1, err)
+			}
+		default:
+			return nil, fmt.Errorf("第%d个元素类型%s不支持", i+1, el.Type)
+		}
+		img, err := decode(byts)
+		if err != nil {
+			if el.Type == ElementAvatar {
+				continue // 用户未设置头像时不绘制
+			}
+			return nil, fmt.Errorf("第%d个元素解析图片失败: %w", i+1, err)
+		}
+		drawImage(canvas, img, el)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, canvas); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
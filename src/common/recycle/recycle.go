@@ -0,0 +1,469 @@
+// Package recycle 回收站: 文档、分类与附件删除时只标记 deleted_time, 可还原或彻底删除, 超过保留天数后自动清理.
+// 文档中引用的上传文件作为附件管理, 彻底删除文档时不删除文件, 需在附件管理中删除后再从回收站彻底删除.
+package recycle
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cast"
+	"github.com/xiusin/pine"
+	"github.com/xiusin/pine/di"
+	"github.com/xiusin/pinecms/src/application/controllers"
+	"github.com/xiusin/pinecms/src/application/models/tables"
+	"github.com/xiusin/pinecms/src/common/helper"
+	"github.com/xiusin/pinecms/src/common/search"
+	"github.com/xiusin/pinecms/src/common/storage"
+	"github.com/xiusin/pinecms/src/config"
+	"xorm.io/xorm"
+)
+
+// 回收站中的数据类型
+const (
+	KindContent    = "content"
+	KindCategory   = "category"
+	KindAttachment = "attachment"
+)
+
+// Item 回收站条目
+type Item struct {
+	Kind        string `json:"kind"`
+	Id          int64  `json:"id"`
+	ModelId     int64  `json:"mid"`
+	CatId       int64  `json:"catid"`
+	Title       string `json:"title"`
+	DeletedTime string `json:"deleted_time"`
+}
+
+// Query 回收站筛选条件, 文档未指定模型时查询全部模型
+type Query struct {
+	Kind    string
+	ModelId int64
+	SiteId  int64
+	Keyword string
+	Page    int
+	Size    int
+}
+
+// Scope 还原与彻底删除的站点范围, 只处理属于站点的数据
+type Scope struct {
+	SiteId int64
+	All    bool // 不限站点, 用于定时清理
+}
+
+// owned 校验数据属于站点, 文档通过分类归属站点
+func (s Scope) owned(orm xorm.Interface, kind, table string, ids []int64) error {
+	if s.All {
+		return nil
+	}
+	var count int64
+	var err error
+	switch kind {
+	case KindContent:
+		catTable := helper.GetORM().TableName(&tables.Category{}, true)
+		count, err = orm.Table(table).In("id", ids).Where("catid IN (SELECT id FROM "+catTable+" WHERE site_id = ?)", s.SiteId).Count()
+	case KindCategory:
+		count, err = orm.Unscoped().Table(&tables.Category{}).In("id", ids).Where("site_id = ?", s.SiteId).Count()
+	case KindAttachment:
+		count, err = orm.Unscoped().Table(&tables.Attachments{}).In("id", ids).Where("site_id = ?", s.SiteId).Count()
+	}
+	if err != nil {
+		return err
+	}
+	if count != int64(len(slices.Compact(slices.Sorted(slices.Values(ids))))) {
+		return errors.New("数据不属于当前站点")
+	}
+	return nil
+}
+
+// siteSettings 站点配置, 附件文件从所属站点配置的存储中删除
+func siteSettings(orm *xorm.Engine, siteId int64) config.Site {
+	site := &tables.Site{}
+	var exist bool
+	if siteId > 0 {
+		exist, _ = orm.ID(siteId).Get(site)
+	} else {
+		exist, _ = orm.Where("is_default = ?", true).Get(site)
+	}
+	if !exist {
+		site = nil
+	}
+	settings, _ := config.SiteConfigFor(site)
+	return settings
+}
+
+// InitInstall 同步软删除字段
+func InitInstall() {
+	if err := helper.GetORM().Sync2(&tables.Category{}, &tables.Attachments{}); err != nil {
+		pine.Logger().Warn("同步回收站字段失败", err)
+	}
+}
+
+// SoftDelete 标记文档为已删除, 搜索索引保留到彻底删除
+func SoftDelete(orm *xorm.Engine, table string, ids []int64) (int64, error) {
+	return orm.Table(table).In("id", ids).Where("deleted_time IS NULL").Update(map[string]any{"deleted_time": time.Now()})
+}
+
+// List 分页查询回收站, 按删除时间倒序
+func List(q Query) ([]Item, int64, error) {
+	orm := helper.GetORM()
+	var items []Item
+	var err error
+	switch q.Kind {
+	case KindContent, "":
+		items, err = deletedContents(orm, q.ModelId, q.SiteId, nil)
+	case KindCategory:
+		var cats []tables.Category
+		err = orm.Unscoped().Where("deleted_time IS NOT NULL").Where("site_id = ?", q.SiteId).Find(&cats)
+		for _, cat := range cats {
+			items = append(items, Item{Kind: KindCategory, Id: cat.Catid, ModelId: cat.ModelId, CatId: cat.Parentid, Title: cat.Catname, DeletedTime: timeString(cat.DeletedTime)})
+		}
+	case KindAttachment:
+		var attachments []tables.Attachments
+		err = orm.Unscoped().Where("deleted_time IS NOT NULL").Where("site_id = ?", q.SiteId).Find(&attachments)
+		for _, a := range attachments {
+			items = append(items, Item{Kind: KindAttachment, Id: a.Id, Title: a.OriginName, DeletedTime: timeString(a.DeletedTime)})
+		}
+	default:
+		return nil, 0, fmt.Errorf("不支持的类型%s", q.Kind)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(q.Keyword) > 0 {
+		items = slices.DeleteFunc(items, func(item Item) bool { return !strings.Contains(item.Title, q.Keyword) })
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].DeletedTime > items[j].DeletedTime })
+	total := int64(len(items))
+	if q.Size > 0 {
+		start := min(max(q.Page-1, 0)*q.Size, len(items))
+		items = items[start:min(start+q.Size, len(items))]
+	}
+	if items == nil {
+		items = []Item{}
+	}
+	return items, total, nil
+}
+
+// deletedContents 已删除的文档, mid 为0时查询全部模型, before 不为空时只查询删除时间早于此时间的文档
+func deletedContents(orm *xorm.Engine, mid, siteId int64, before *time.Time) ([]Item, error) {
+	var models []tables.DocumentModel
+	sess := orm.NewSession()
+	defer sess.Close()
+	if mid > 0 {
+		sess.Where("id = ?", mid)
+	}
+	if err := sess.Find(&models); err != nil {
+		return nil, err
+	}
+	var catids []int64
+	if before == nil {
+		if err := orm.Unscoped().Table(&tables.Category{}).Where("site_id = ?", siteId).Cols("id").Find(&catids); err != nil {
+			return nil, err
+		}
+	}
+	var items []Item
+	for _, model := range models {
+		query := orm.Table(controllers.GetTableName(model.Table)).Where("deleted_time IS NOT NULL")
+		if before != nil {
+			query.Where("deleted_time < ?", *before)
+		} else {
+			query.In("catid", catids)
+		}
+		var rows []map[string]any
+		if err := query.Find(&rows); err != nil {
+			pine.Logger().Warn("读取模型"+model.Name+"的回收站失败", err)
+			continue
+		}
+		for _, row := range rows {
+			items = append(items, Item{
+				Kind:        KindContent,
+				Id:          cast.ToInt64(row["id"]),
+				ModelId:     model.Id,
+				CatId:       cast.ToInt64(row["catid"]),
+				Title:       str(row["title"]),
+				DeletedTime: str(row["deleted_time"]),
+			})
+		}
+	}
+	return items, nil
+}
+
+// Restore 还原数据, 所属分类或上级分类已删除时一并还原
+func Restore(scope Scope, kind string, mid int64, ids []int64) error {
+	orm := helper.GetORM()
+	_, err := orm.Transaction(func(sess *xorm.Session) (any, error) {
+		switch kind {
+		case KindContent:
+			table, err := modelTable(sess, mid)
+			if err != nil {
+				return nil, err
+			}
+			if err := scope.owned(sess, kind, table, ids); err != nil {
+				return nil, err
+			}
+			var catids []int64
+			if err := sess.Table(table).In("id", ids).Distinct("catid").Find(&catids); err != nil {
+				return nil, err
+			}
+			if _, err := sess.Table(table).In("id", ids).Update(map[string]any{"deleted_time": nil}); err != nil {
+				return nil, err
+			}
+			return nil, restoreCategories(sess, catids)
+		case KindCategory:
+			if err := scope.owned(sess, kind, "", ids); err != nil {
+				return nil, err
+			}
+			return nil, restoreCategories(sess, ids)
+		case KindAttachment:
+			if err := scope.owned(sess, kind, "", ids); err != nil {
+				return nil, err
+			}
+			_, err := sess.Unscoped().Table(&tables.Attachments{}).In("id", ids).Update(map[string]any{"deleted_time": nil})
+			return nil, err
+		}
+		return nil, fmt.Errorf("不支持的类型%s", kind)
+	})
+	return err
+}
+
+// restoreCategories 还原分类及其已删除的上级分类
+func restoreCategories(sess *xorm.Session, ids []int64) error {
+	for len(ids) > 0 {
+		var cats []tables.Category
+		if err := sess.Unscoped().In("id", ids).Where("deleted_time IS NOT NULL").Find(&cats); err != nil {
+			return err
+		}
+		if len(cats) == 0 {
+			return nil
+		}
+		var restore, parents []int64
+		for _, cat := range cats {
+			restore = append(restore, cat.Catid)
+			if cat.Parentid > 0 {
+				parents = append(parents, cat.Parentid)
+			}
+		}
+		ids = parents
+		if _, err := sess.Unscoped().Table(&tables.Category{}).In("id", restore).Update(map[string]any{"deleted_time": nil}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Purge 彻底删除回收站中的数据, 同时删除文档的搜索索引与存储中的附件文件
+func Purge(scope Scope, kind string, mid int64, ids []int64) error {
+	orm := helper.GetORM()
+	switch kind {
+	case KindContent:
+		table, err := modelTable(orm, mid)
+		if err != nil {
+			return err
+		}
+		if err := scope.owned(orm, kind, table, ids); err != nil {
+			return err
+		}
+		return purgeContents(orm, mid, table, ids)
+	case KindCategory:
+		if err := scope.owned(orm, kind, "", ids); err != nil {
+			return err
+		}
+		return purgeCategories(orm, ids)
+	case KindAttachment:
+		if err := scope.owned(orm, kind, "", ids); err != nil {
+			return err
+		}
+		return purgeAttachments(orm, ids)
+	}
+	return fmt.Errorf("不支持的类型%s", kind)
+}
+
+// purgeContents 删除文档与搜索索引, 文档引用的文件作为附件管理, 不在此删除
+func purgeContents(orm *xorm.Engine, mid int64, table string, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	var deleted []int64
+	if err := orm.Table(table).In("id", ids).Where("deleted_time IS NOT NULL").Cols("id").Find(&deleted); err != nil {
+		return err
+	}
+	if ids = deleted; len(ids) == 0 {
+		return nil
+	}
+	if _, err := orm.Table(table).In("id", ids).Delete(); err != nil {
+		return err
+	}
+	mae := controllers.GetTableName("search_m_a_e")
+	var indexes []tables.SearchMAE
+	_ = orm.Table(mae).Where("mid = ?", mid).In("aid", ids).Find(&indexes)
+	if len(indexes) > 0 {
+		engine := di.MustGet(controllers.ServiceSearchName).(search.ISearch)
+		for _, v := range indexes {
+			engine.Delete("document", v.Eid)
+		}
+		_, _ = orm.Table(mae).Where("mid = ?", mid).In("aid", ids).Delete()
+	}
+	return nil
+}
+
+// purgeCategories 彻底删除分类及其下级分类, 分类中已删除的文档一并删除
+func purgeCategories(orm *xorm.Engine, ids []int64) error {
+	var cats []tables.Category
+	for len(ids) > 0 {
+		var found []tables.Category
+		if err := orm.Unscoped().In("id", ids).Where("deleted_time IS NOT NULL").Find(&found); err != nil {
+			return err
+		}
+		cats = append(cats, found...)
+		var children []int64
+		for _, cat := range found {
+			_ = orm.Unscoped().Table(&tables.Category{}).Where("parentid = ?", cat.Catid).Cols("id").Find(&children)
+		}
+		ids = children
+	}
+	for _, cat := range cats {
+		if exist, _ := orm.Where("parentid = ?", cat.Catid).Exist(&tables.Category{}); exist {
+			return fmt.Errorf("分类%s下有未删除的分类", cat.Catname)
+		}
+		if cat.ModelId > 0 {
+			if table, err := modelTable(orm, cat.ModelId); err == nil {
+				if exist, _ := orm.Table(table).Where("catid = ? and deleted_time IS NULL", cat.Catid).Exist(); exist {
+					return fmt.Errorf("分类%s下有未删除的文档", cat.Catname)
+				}
+				var contentIds []int64
+				_ = orm.Table(table).Where("catid = ?", cat.Catid).Cols("id").Find(&contentIds)
+				if err := purgeContents(orm, cat.ModelId, table, contentIds); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	for _, cat := range cats {
+		if _, err := orm.Unscoped().Delete(&tables.Category{Catid: cat.Catid}); err != nil {
+			return err
+		}
+		if cat.Type == 1 {
+			_, _ = orm.Delete(&tables.Page{Id: cat.Catid})
+		}
+	}
+	return nil
+}
+
+// purgeAttachments 删除附件记录与文件, 文件从附件所属站点的存储中删除
+func purgeAttachments(orm *xorm.Engine, ids []int64) error {
+	var attachments []tables.Attachments
+	if err := orm.Unscoped().In("id", ids).Where("deleted_time IS NOT NULL").Find(&attachments); err != nil {
+		return err
+	}
+	if len(attachments) == 0 {
+		return nil
+	}
+	uploaders := map[int64]storage.Uploader{}
+	for _, a := range attachments {
+		if _, exist := uploaders[a.SiteId]; !exist {
+			uploaders[a.SiteId] = storage.Engine(siteSettings(orm, a.SiteId))
+		}
+		if name := storageName(a); len(name) > 0 {
+			if err := uploaders[a.SiteId].Remove(name); err != nil {
+				pine.Logger().Warn("删除附件文件"+name+"失败", err)
+			}
+		}
+		if _, err := orm.Unscoped().Delete(&tables.Attachments{Id: a.Id}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// storageName 附件在存储中的名称, 上传时为 日期目录/文件名
+func storageName(a tables.Attachments) string {
+	u, err := url.Parse(a.Url)
+	if err != nil || len(a.Name) == 0 {
+		return ""
+	}
+	return path.Base(path.Dir(u.Path)) + "/" + a.Name
+}
+
+// Clean 彻底删除超过保留天数的数据, 返回删除的数量
+func Clean() (int, error) {
+	days := config.App().RecycleBin.RetentionDays
+	if days <= 0 {
+		return 0, nil
+	}
+	orm := helper.GetORM()
+	before := time.Now().AddDate(0, 0, -days)
+	total := 0
+
+	contents, err := deletedContents(orm, 0, 0, &before)
+	if err != nil {
+		return total, err
+	}
+	grouped := map[int64][]int64{}
+	for _, item := range contents {
+		grouped[item.ModelId] = append(grouped[item.ModelId], item.Id)
+	}
+	for mid, ids := range grouped {
+		if err := Purge(Scope{All: true}, KindContent, mid, ids); err != nil {
+			return total, err
+		}
+		total += len(ids)
+	}
+
+	var errs []error
+	for kind, bean := range map[string]any{KindAttachment: &tables.Attachments{}, KindCategory: &tables.Category{}} {
+		var ids []int64
+		if err := orm.Unscoped().Table(bean).Where("deleted_time IS NOT NULL and deleted_time < ?", before).Cols("id").Find(&ids); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := Purge(Scope{All: true}, kind, 0, ids); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		total += len(ids)
+	}
+	return total, errors.Join(errs...)
+}
+
+// Run 定时清理, 未启用river任务队列时使用
+func Run(interval time.Duration) {
+	for range time.Tick(interval) {
+		if n, err := Clean(); err != nil {
+			pine.Logger().Warn("清理回收站失败", err)
+		} else if n > 0 {
+			pine.Logger().Info(fmt.Sprintf("清理回收站%d条数据", n))
+		}
+	}
+}
+
+func modelTable(db xorm.Interface, mid int64) (string, error) {
+	model := &tables.DocumentModel{}
+	if exist, _ := db.ID(mid).Get(model); !exist || mid < 1 {
+		return "", errors.New("模型不存在")
+	}
+	return controllers.GetTableName(model.Table), nil
+}
+
+func timeString(t *tables.LocalTime) string {
+	if t == nil {
+		return ""
+	}
+	return time.Time(*t).Format(helper.TimeFormat)
+}
+
+func str(v any) string {
+	switch v := v.(type) {
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format(helper.TimeFormat)
+	}
+	return cast.ToString(v)
+}
@@ -0,0 +1,262 @@
+package recycle
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/xiusin/pinecms/src/application/controllers"
+	"github.com/xiusin/pinecms/src/application/models/tables"
+	"github.com/xiusin/pinecms/src/common/helper"
+	_ "modernc.org/sqlite"
+	"xorm.io/core"
+	"xorm.io/xorm"
+	"xorm.io/xorm/schemas"
+)
+
+const (
+	catTable     = "pinecms_category"
+	contentTable = "pinecms_articles"
+	deletedAt    = "2026-01-01 00:00:00"
+)
+
+// testORM 创建 sqlite 数据库并注入为默认连接, 文档模型1对应 articles 表
+func testORM(t *testing.T) *xorm.Engine {
+	t.Helper()
+	orm, err := xorm.NewEngine("sqlite", "file:"+filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { orm.Close() })
+	orm.SetTableMapper(core.NewPrefixMapper(core.SnakeMapper{}, "pinecms_"))
+	helper.Inject(controllers.ServiceXorm, orm, false)
+	helper.Inject(controllers.ServiceTablePrefix, "pinecms_", false)
+
+	// sqlite 驱动将 DATETIME 列读取为 time.Time, LocalTime 只能从字节转换, 测试表的时间列使用 VARCHAR
+	for _, bean := range []any{&tables.Category{}, &tables.DocumentModel{}, &tables.Attachments{}} {
+		table, err := orm.TableInfo(bean)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, col := range table.Columns() {
+			if col.SQLType.IsTime() {
+				col.SQLType = schemas.SQLType{Name: schemas.Varchar}
+			}
+		}
+		if err = orm.Sync2(bean); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err = orm.Exec("CREATE TABLE " + contentTable + " (id INTEGER PRIMARY KEY AUTOINCREMENT, catid INTEGER, title TEXT, deleted_time TEXT)"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = orm.Insert(&tables.DocumentModel{Id: 1, Name: "文章", Table: "articles"}); err != nil {
+		t.Fatal(err)
+	}
+	return orm
+}
+
+// addCategory 添加分类, deleted 为真时标记为已删除
+func addCategory(t *testing.T, orm *xorm.Engine, id, parentId, siteId int64, deleted bool) {
+	t.Helper()
+	if _, err := orm.Insert(&tables.Category{Catid: id, Parentid: parentId, SiteId: siteId, ModelId: 1, Catname: "分类"}); err != nil {
+		t.Fatal(err)
+	}
+	if deleted {
+		markDeleted(t, orm, catTable, id)
+	}
+}
+
+// addContent 添加文档, deleted 为真时标记为已删除
+func addContent(t *testing.T, orm *xorm.Engine, id, catId int64, deleted bool) {
+	t.Helper()
+	if _, err := orm.Table(contentTable).Insert(map[string]any{"id": id, "catid": catId, "title": "文档"}); err != nil {
+		t.Fatal(err)
+	}
+	if deleted {
+		markDeleted(t, orm, contentTable, id)
+	}
+}
+
+func markDeleted(t *testing.T, orm *xorm.Engine, table string, ids ...int64) {
+	t.Helper()
+	if _, err := orm.Table(table).In("id", ids).Update(map[string]any{"deleted_time": deletedAt}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// deletedIds 表中已删除的ID
+func deletedIds(t *testing.T, orm *xorm.Engine, table string) []int64 {
+	t.Helper()
+	var ids []int64
+	if err := orm.Table(table).Where("deleted_time IS NOT NULL").Asc("id").Cols("id").Find(&ids); err != nil {
+		t.Fatal(err)
+	}
+	return ids
+}
+
+// existIds 表中全部ID
+func existIds(t *testing.T, orm *xorm.Engine, table string) []int64 {
+	t.Helper()
+	var ids []int64
+	if err := orm.Table(table).Asc("id").Cols("id").Find(&ids); err != nil {
+		t.Fatal(err)
+	}
+	return ids
+}
+
+func TestRestoreContentParents(t *testing.T) {
+	orm := testORM(t)
+	addCategory(t, orm, 1, 0, 1, true)
+	addCategory(t, orm, 2, 1, 1, true)
+	addCategory(t, orm, 3, 2, 1, true)
+	addCategory(t, orm, 4, 0, 1, true)
+	addContent(t, orm, 1, 3, true)
+	addContent(t, orm, 2, 3, true)
+
+	if err := Restore(Scope{SiteId: 1}, KindContent, 1, []int64{1}); err != nil {
+		t.Fatal(err)
+	}
+	if ids := deletedIds(t, orm, contentTable); !slices.Equal(ids, []int64{2}) {
+		t.Errorf("只应还原指定的文档: %v", ids)
+	}
+	// 文档所属分类及其上级分类一并还原, 无关分类保持删除
+	if ids := deletedIds(t, orm, catTable); !slices.Equal(ids, []int64{4}) {
+		t.Errorf("应还原文档所属分类及上级分类: %v", ids)
+	}
+}
+
+func TestRestoreCategoryParents(t *testing.T) {
+	orm := testORM(t)
+	addCategory(t, orm, 1, 0, 1, true)
+	addCategory(t, orm, 2, 1, 1, true)
+	addCategory(t, orm, 3, 2, 1, true)
+	addCategory(t, orm, 4, 3, 1, true)
+
+	if err := Restore(Scope{SiteId: 1}, KindCategory, 0, []int64{3}); err != nil {
+		t.Fatal(err)
+	}
+	// 逐级还原上级分类, 下级分类4保持删除
+	if ids := deletedIds(t, orm, catTable); !slices.Equal(ids, []int64{4}) {
+		t.Errorf("应还原分类及其已删除的上级分类: %v", ids)
+	}
+}
+
+func TestRestoreScope(t *testing.T) {
+	orm := testORM(t)
+	addCategory(t, orm, 1, 0, 1, true)
+	addCategory(t, orm, 2, 0, 2, true)
+	addContent(t, orm, 1, 2, true)
+
+	if err := Restore(Scope{SiteId: 1}, KindCategory, 0, []int64{1, 2}); err == nil {
+		t.Error("不应还原其他站点的分类")
+	}
+	if err := Restore(Scope{SiteId: 1}, KindContent, 1, []int64{1}); err == nil {
+		t.Error("不应还原其他站点的文档")
+	}
+	if ids := deletedIds(t, orm, catTable); !slices.Equal(ids, []int64{1, 2}) {
+		t.Errorf("校验失败时不应还原任何分类: %v", ids)
+	}
+	if ids := deletedIds(t, orm, contentTable); !slices.Equal(ids, []int64{1}) {
+		t.Errorf("校验失败时不应还原文档: %v", ids)
+	}
+	if err := Restore(Scope{SiteId: 2}, KindCategory, 0, []int64{2}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Restore(Scope{}, KindCategory, 0, []int64{}); err != nil {
+		t.Errorf("空ID不应报错: %v", err)
+	}
+}
+
+func TestPurgeContents(t *testing.T) {
+	orm := testORM(t)
+	addCategory(t, orm, 1, 0, 1, false)
+	addContent(t, orm, 1, 1, true)
+	addContent(t, orm, 2, 1, false)
+	addContent(t, orm, 3, 1, true)
+
+	if err := Purge(Scope{SiteId: 2}, KindContent, 1, []int64{1}); err == nil {
+		t.Error("不应删除其他站点的文档")
+	}
+	if err := Purge(Scope{SiteId: 1}, KindContent, 1, []int64{1, 2}); err != nil {
+		t.Fatal(err)
+	}
+	// 未删除的文档不会被彻底删除
+	if ids := existIds(t, orm, contentTable); !slices.Equal(ids, []int64{2, 3}) {
+		t.Errorf("只应删除回收站中指定的文档: %v", ids)
+	}
+	if err := Purge(Scope{SiteId: 1}, KindContent, 2, []int64{3}); err == nil {
+		t.Error("模型不存在时应返回错误")
+	}
+}
+
+func TestPurgeCategories(t *testing.T) {
+	orm := testORM(t)
+	addCategory(t, orm, 1, 0, 1, true)
+	addCategory(t, orm, 2, 1, 1, true)
+	addCategory(t, orm, 3, 0, 1, true)
+	addContent(t, orm, 1, 2, true)
+	addContent(t, orm, 2, 3, true)
+
+	if err := Purge(Scope{SiteId: 1}, KindCategory, 0, []int64{1}); err != nil {
+		t.Fatal(err)
+	}
+	// 下级分类与分类中的文档一并删除
+	if ids := existIds(t, orm, catTable); !slices.Equal(ids, []int64{3}) {
+		t.Errorf("应删除分类及其下级分类: %v", ids)
+	}
+	if ids := existIds(t, orm, contentTable); !slices.Equal(ids, []int64{2}) {
+		t.Errorf("应删除分类中的文档: %v", ids)
+	}
+}
+
+func TestPurgeCategoriesInUse(t *testing.T) {
+	orm := testORM(t)
+	addCategory(t, orm, 1, 0, 1, true)
+	addCategory(t, orm, 2, 1, 1, false)
+	addCategory(t, orm, 3, 0, 1, true)
+	addContent(t, orm, 1, 3, false)
+
+	if err := Purge(Scope{SiteId: 1}, KindCategory, 0, []int64{1}); err == nil {
+		t.Error("有未删除的下级分类时不应删除")
+	}
+	if err := Purge(Scope{SiteId: 1}, KindCategory, 0, []int64{3}); err == nil {
+		t.Error("有未删除的文档时不应删除")
+	}
+	if err := Purge(Scope{SiteId: 2}, KindCategory, 0, []int64{3}); err == nil {
+		t.Error("不应删除其他站点的分类")
+	}
+	if ids := existIds(t, orm, catTable); !slices.Equal(ids, []int64{1, 2, 3}) {
+		t.Errorf("删除失败时分类应保留: %v", ids)
+	}
+	if ids := existIds(t, orm, contentTable); !slices.Equal(ids, []int64{1}) {
+		t.Errorf("删除失败时文档应保留: %v", ids)
+	}
+}
+
+func TestAttachmentScope(t *testing.T) {
+	orm := testORM(t)
+	for _, a := range []tables.Attachments{{Id: 1, SiteId: 1, Md5: "a"}, {Id: 2, SiteId: 2, Md5: "a"}} {
+		if _, err := orm.Insert(&a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	markDeleted(t, orm, "pinecms_attachments", 1, 2)
+
+	items, total, err := List(Query{Kind: KindAttachment, SiteId: 1})
+	if err != nil || total != 1 || items[0].Id != 1 {
+		t.Errorf("只应列出当前站点的附件: %v %v", items, err)
+	}
+	if err = Restore(Scope{SiteId: 1}, KindAttachment, 0, []int64{1, 2}); err == nil {
+		t.Error("不应还原其他站点的附件")
+	}
+	if err = Purge(Scope{SiteId: 1}, KindAttachment, 0, []int64{2}); err == nil {
+		t.Error("不应删除其他站点的附件")
+	}
+	if err = Restore(Scope{SiteId: 1}, KindAttachment, 0, []int64{1}); err != nil {
+		t.Fatal(err)
+	}
+	if ids := deletedIds(t, orm, "pinecms_attachments"); !slices.Equal(ids, []int64{2}) {
+		t.Errorf("应只还原当前站点的附件: %v", ids)
+	}
+}
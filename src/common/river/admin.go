@@ -0,0 +1,239 @@
+package river
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/riverqueue/river"
+	"github.com/riverqueue/river/rivertype"
+)
+
+// States 后台可筛选的任务状态
+var States = []rivertype.JobState{
+	rivertype.JobStateAvailable,
+	rivertype.JobStateScheduled,
+	rivertype.JobStateRunning,
+	rivertype.JobStateRetryable,
+	rivertype.JobStateCompleted,
+	rivertype.JobStateCancelled,
+	rivertype.JobStateDiscarded,
+	rivertype.JobStatePending,
+}
+
+// Queue 队列信息
+type Queue struct {
+	Name       string         `json:"name"`
+	MaxWorkers int            `json:"max_workers"`
+	Periodic   []PeriodicInfo `json:"periodic"` // 投递到该队列的周期性任务
+	Active     bool           `json:"active"`   // 已在数据库中登记, 即有实例在处理
+	PausedAt   *time.Time     `json:"paused_at"`
+	UpdatedAt  *time.Time     `json:"updated_at"`
+}
+
+// Job 任务详情
+type Job struct {
+	Id          int64                    `json:"id"`
+	Kind        string                   `json:"kind"`
+	Queue       string                   `json:"queue"`
+	State       rivertype.JobState       `json:"state"`
+	Args        json.RawMessage          `json:"args"`
+	Attempt     int                      `json:"attempt"`
+	MaxAttempts int                      `json:"max_attempts"`
+	Priority    int                      `json:"priority"`
+	Errors      []rivertype.AttemptError `json:"errors"`
+	Tags        []string                 `json:"tags"`
+	CreatedAt   time.Time                `json:"created_at"`
+	ScheduledAt time.Time                `json:"scheduled_at"`
+	AttemptedAt *time.Time               `json:"attempted_at"`
+	FinalizedAt *time.Time               `json:"finalized_at"`
+}
+
+// JobQuery 任务列表筛选条件, Cursor 为上一页返回的游标
+type JobQuery struct {
+	Queue  string `json:"queue"`
+	Kind   string `json:"kind"`
+	State  string `json:"state"`
+	Cursor string `json:"cursor"`
+	Size   int    `json:"size"`
+}
+
+// ErrDisabled 未启用river
+var ErrDisabled = errors.New("river任务队列未启用")
+
+func client() (*river.Client[*sql.Tx], error) {
+	if riverClient == nil {
+		return nil, ErrDisabled
+	}
+	return riverClient, nil
+}
+
+// Workers 已注册的worker
+func Workers() []WorkerInfo {
+	return slices.Clone(registered)
+}
+
+// Queues 配置的队列及数据库中登记的队列状态
+func Queues(ctx context.Context) ([]Queue, error) {
+	c, err := client()
+	if err != nil {
+		return nil, err
+	}
+	result, err := c.QueueList(ctx, river.NewQueueListParams().First(100))
+	if err != nil {
+		return nil, err
+	}
+	var list []Queue
+	for name, conf := range queues() {
+		q := Queue{Name: name, MaxWorkers: conf.MaxWorkers, Periodic: []PeriodicInfo{}}
+		for _, p := range periodics {
+			if p.Queue == name {
+				q.Periodic = append(q.Periodic, p)
+			}
+		}
+		for _, row := range result.Queues {
+			if row.Name == name {
+				q.Active, q.PausedAt, q.UpdatedAt = true, row.PausedAt, &row.UpdatedAt
+			}
+		}
+		list = append(list, q)
+	}
+	slices.SortFunc(list, func(a, b Queue) int { return cmp.Compare(a.Name, b.Name) })
+	return list, nil
+}
+
+// Jobs 按条件分页查询任务, 返回下一页游标
+func Jobs(ctx context.Context, q JobQuery) ([]Job, string, error) {
+	c, err := client()
+	if err != nil {
+		return nil, "", err
+	}
+	if q.Size < 1 || q.Size > 100 {
+		q.Size = 20
+	}
+	params := river.NewJobListParams().First(q.Size).OrderBy(river.JobListOrderByTime, river.SortOrderDesc)
+	if len(q.Queue) > 0 {
+		params = params.Queues(q.Queue)
+	}
+	if len(q.Kind) > 0 {
+		params = params.Kinds(q.Kind)
+	}
+	if len(q.State) > 0 {
+		state := rivertype.JobState(q.State)
+		if !slices.Contains(States, state) {
+			return nil, "", fmt.Errorf("无效的任务状态%s", q.State)
+		}
+		params = params.States(state)
+	}
+	if len(q.Cursor) > 0 {
+		cursor := &river.JobListCursor{}
+		if err := cursor.UnmarshalText([]byte(q.Cursor)); err != nil {
+			return nil, "", errors.New("无效的分页游标")
+		}
+		params = params.After(cursor)
+	}
+	result, err := c.JobList(ctx, params)
+	if err != nil {
+		return nil, "", err
+	}
+	jobs := make([]Job, len(result.Jobs))
+	for i, row := range result.Jobs {
+		jobs[i] = toJob(row)
+	}
+	next := ""
+	if result.LastCursor != nil && len(result.Jobs) == q.Size {
+		text, _ := result.LastCursor.MarshalText()
+		next = string(text)
+	}
+	return jobs, next, nil
+}
+
+// GetJob 任务详情
+func GetJob(ctx context.Context, id int64) (*Job, error) {
+	return jobAction(ctx, id, (*river.Client[*sql.Tx]).JobGet)
+}
+
+// RetryJob 立即重新执行任务, 已完成或已放弃的任务也可重试
+func RetryJob(ctx context.Context, id int64) (*Job, error) {
+	return jobAction(ctx, id, (*river.Client[*sql.Tx]).JobRetry)
+}
+
+// CancelJob 取消任务, 执行中的任务会收到取消信号
+func CancelJob(ctx context.Context, id int64) (*Job, error) {
+	return jobAction(ctx, id, (*river.Client[*sql.Tx]).JobCancel)
+}
+
+// DeleteJob 删除任务, 执行中的任务不能删除
+func DeleteJob(ctx context.Context, id int64) (*Job, error) {
+	return jobAction(ctx, id, (*river.Client[*sql.Tx]).JobDelete)
+}
+
+func jobAction(ctx context.Context, id int64, fn func(*river.Client[*sql.Tx], context.Context, int64) (*rivertype.JobRow, error)) (*Job, error) {
+	c, err := client()
+	if err != nil {
+		return nil, err
+	}
+	row, err := fn(c, ctx, id)
+	if errors.Is(err, rivertype.ErrNotFound) {
+		return nil, errors.New("任务不存在")
+	} else if errors.Is(err, rivertype.ErrJobRunning) {
+		return nil, errors.New("任务正在执行")
+	} else if err != nil {
+		return nil, err
+	}
+	job := toJob(row)
+	return &job, nil
+}
+
+// PauseQueue 暂停队列, 已在执行的任务不受影响
+func PauseQueue(ctx context.Context, name string) error {
+	c, err := client()
+	if err != nil {
+		return err
+	}
+	return queueErr(c.QueuePause(ctx, name, nil))
+}
+
+// ResumeQueue 恢复队列
+func ResumeQueue(ctx context.Context, name string) error {
+	c, err := client()
+	if err != nil {
+		return err
+	}
+	return queueErr(c.QueueResume(ctx, name, nil))
+}
+
+func queueErr(err error) error {
+	if errors.Is(err, rivertype.ErrNotFound) {
+		return errors.New("队列未运行")
+	}
+	return err
+}
+
+func toJob(row *rivertype.JobRow) Job {
+	args := json.RawMessage(row.EncodedArgs)
+	if !json.Valid(args) {
+		args = json.RawMessage("null")
+	}
+	return Job{
+		Id:          row.ID,
+		Kind:        row.Kind,
+		Queue:       row.Queue,
+		State:       row.State,
+		Args:        args,
+		Attempt:     row.Attempt,
+		MaxAttempts: row.MaxAttempts,
+		Priority:    row.Priority,
+		Errors:      row.Errors,
+		Tags:        row.Tags,
+		CreatedAt:   row.CreatedAt,
+		ScheduledAt: row.ScheduledAt,
+		AttemptedAt: row.AttemptedAt,
+		FinalizedAt: row.FinalizedAt,
+	}
+}
@@ -11,7 +11,9 @@ import (
 
 	"github.com/riverqueue/river"
 	"github.com/riverqueue/river/riverdriver/riverdatabasesql"
+	"github.com/riverqueue/river/rivermigrate"
 	"github.com/robfig/cron/v3"
+	"github.com/xiusin/pinecms/src/config"
 )
 
 const (
@@ -26,10 +28,31 @@ var riverClient *river.Client[*sql.Tx]
 var workers = river.NewWorkers()
 var riverOnceLocker sync.Once
 var periodicJobs []*river.PeriodicJob
+var registered []WorkerInfo
+var periodics []PeriodicInfo
+
+// WorkerInfo 已注册的worker
+type WorkerInfo struct {
+	Kind   string `json:"kind"`
+	Worker string `json:"worker"`
+}
+
+// PeriodicInfo 已注册的周期性任务
+type PeriodicInfo struct {
+	Kind     string `json:"kind"`
+	Queue    string `json:"queue"`
+	Schedule string `json:"schedule"`
+}
 
 // RegisterWorker 注册river任务worker
 func RegisterWorker[T river.JobArgs, W river.Worker[T]](work W) {
-	fmt.Println("注册工作者", reflect.TypeOf(work).Elem().Name(), river.AddWorkerSafely(workers, work))
+	var args T
+	name := reflect.TypeOf(work).Elem().Name()
+	if err := river.AddWorkerSafely(workers, work); err != nil {
+		fmt.Println("注册工作者", name, err)
+		return
+	}
+	registered = append(registered, WorkerInfo{Kind: args.Kind(), Worker: name})
 }
 
 // Enqueue 任务入队
@@ -41,7 +64,7 @@ func Enqueue(ctx context.Context, queue string, args river.JobArgs, delays ...ti
 	opt := &river.InsertOpts{Queue: queue}
 
 	if len(delays) > 0 { // 延迟时间
-		opt.ScheduledAt.Add(delays[0])
+		opt.ScheduledAt = time.Now().Add(delays[0])
 	}
 
 	_, err := GetRiverClient().Insert(ctx, args, opt)
@@ -70,6 +93,7 @@ func RegisterCrontab[T time.Duration | string, A river.JobArgs](t T, args A, que
 		panic(err)
 	}
 
+	periodics = append(periodics, PeriodicInfo{Kind: args.Kind(), Queue: queueName[0], Schedule: fmt.Sprint(t)})
 	periodicJobs = append(periodicJobs, river.NewPeriodicJob(
 		schedule,
 		func() (river.JobArgs, *river.InsertOpts) {
@@ -79,31 +103,54 @@ func RegisterCrontab[T time.Duration | string, A river.JobArgs](t T, args A, que
 	))
 }
 
-func InitRiverJob(db *sql.DB) {
+// InitRiverJob 执行river数据表迁移并按 application.yml 的队列配置启动任务调度
+func InitRiverJob(db *sql.DB) (err error) {
 	riverOnceLocker.Do(func() {
 		ctx := context.Background()
-		var err error
-		riverClient, err = river.NewClient(
-			riverdatabasesql.New(db),
-			&river.Config{
-				Workers:      workers,      // 工作者
-				PeriodicJobs: periodicJobs, // 周期性任务
-				Queues: map[string]river.QueueConfig{
-					// TODO: 配置队列
-					QueueDefault:          {MaxWorkers: 10},
-					QueueProductShareLock: {MaxWorkers: 10},
-					QueueCleanRecycleBin:  {MaxWorkers: 1},
-					QueueDouPlusValid:     {MaxWorkers: 10},
-				},
-			},
-		)
+		driver := riverdatabasesql.New(db)
+		var migrator *rivermigrate.Migrator[*sql.Tx]
+		if migrator, err = rivermigrate.New(driver, nil); err != nil {
+			return
+		}
+		if _, err = migrator.Migrate(ctx, rivermigrate.DirectionUp, nil); err != nil {
+			return
+		}
+		var client *river.Client[*sql.Tx]
+		client, err = river.NewClient(driver, &river.Config{
+			Workers:      workers,      // 工作者
+			PeriodicJobs: periodicJobs, // 周期性任务
+			Queues:       queues(),
+		})
 		if err != nil {
-			panic(err)
+			return
 		}
-
 		// 启动任务调度
-		fmt.Println("启动river任务调度...", riverClient.Start(ctx))
+		if err = client.Start(ctx); err == nil {
+			riverClient = client
+			fmt.Println("启动river任务调度...")
+		}
 	})
+	return err
+}
+
+// queues 配置文件中的队列, 未配置时只启用默认队列
+func queues() map[string]river.QueueConfig {
+	qs := map[string]river.QueueConfig{}
+	for name, q := range config.App().River.Queues {
+		if q.MaxWorkers < 1 {
+			q.MaxWorkers = 1
+		}
+		qs[name] = river.QueueConfig{MaxWorkers: q.MaxWorkers}
+	}
+	if len(qs) == 0 {
+		qs[QueueDefault] = river.QueueConfig{MaxWorkers: 10}
+	}
+	return qs
+}
+
+// Enabled 任务调度是否已启动
+func Enabled() bool {
+	return riverClient != nil
 }
 
 func GetRiverClient() *river.Client[*sql.Tx] {
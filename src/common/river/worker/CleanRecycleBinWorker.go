@@ -5,6 +5,8 @@ import (
 	"fmt"
 
 	"github.com/riverqueue/river"
+	"github.com/xiusin/pine"
+	"github.com/xiusin/pinecms/src/common/recycle"
 	"github.com/xiusin/pinecms/src/common/river/args"
 )
 
@@ -12,8 +14,11 @@ type CleanRecycleBinWorker struct {
 	river.WorkerDefaults[args.CronJobArgs]
 }
 
+// Work 彻底删除回收站中超过保留天数的数据
 func (w *CleanRecycleBinWorker) Work(_ context.Context, job *river.Job[args.CronJobArgs]) error {
-	fmt.Println("定时清理回收站 ~")
-
-	return nil
+	n, err := recycle.Clean()
+	if n > 0 {
+		pine.Logger().Info(fmt.Sprintf("清理回收站%d条数据", n))
+	}
+	return err
 }
@@ -12,9 +12,9 @@ func init() {
 	river.RegisterWorker(new(CleanRecycleBinWorker))
 
 	// 注册回收站清理任务
-	river.RegisterCrontab(time.Second*10, args.CronJobArgs{Name: "回收站清理"}, river.QueueCleanRecycleBin)
+	river.RegisterCrontab(time.Hour, args.CronJobArgs{Name: "回收站清理"}, river.QueueCleanRecycleBin)
 }
 
-func Start(db *sql.DB) {
-	river.InitRiverJob(db)
+func Start(db *sql.DB) error {
+	return river.InitRiverJob(db)
 }
@@ -0,0 +1,189 @@
+// Package route 注解路由: pinecms src 根据控制器的 Rest 注解生成路由表, 统一注册中间件、权限标识与接口文档.
+package route
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/xiusin/pine"
+	"github.com/xiusin/pinecms/src/application/controllers/middleware/apidoc"
+)
+
+// 挂载分组
+const (
+	MountAdmin = "admin" // 后台接口, 挂载在 /v2 下
+	MountApp   = "app"   // 应用根路由
+)
+
+// MethodAny 匹配所有请求方法
+const MethodAny = "ANY"
+
+// Route 一条注解路由
+type Route struct {
+	Mount      string   // 挂载分组, 默认 admin
+	Method     string   // GET POST PUT DELETE HEAD ANY
+	Path       string   // 相对挂载分组的完整路径, 如 /user/list
+	Controller string   // 控制器结构体名称
+	Handler    string   // 控制器方法
+	Middleware []string // 中间件名称, 见 RegisterMiddleware
+	Permission string   // casbin 权限标识, 格式 obj:act, 为空时按路径前两段校验
+	Title      string   // 接口标题
+	Group      string   // 接口分组
+	Request    any      // 请求参数结构体
+	Response   any      // 响应数据结构体
+}
+
+var (
+	mu          sync.RWMutex
+	middlewares = map[string]pine.Handler{}
+	routes      []Route
+)
+
+// ValidMethod 控制器路由支持的请求方法
+func ValidMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodHead, MethodAny:
+		return true
+	}
+	return false
+}
+
+// RegisterMiddleware 登记注解中可引用的中间件, 需在注册控制器之前调用
+func RegisterMiddleware(name string, handler pine.Handler) {
+	mu.Lock()
+	defer mu.Unlock()
+	middlewares[name] = handler
+}
+
+// Register 登记路由表用于权限校验, 返回与已登记路由的冲突
+func Register(table ...Route) []string {
+	mu.Lock()
+	defer mu.Unlock()
+	routes = append(routes, table...)
+	return Conflicts(routes)
+}
+
+// Routes 已登记的路由
+func Routes() []Route {
+	mu.RLock()
+	defer mu.RUnlock()
+	return append([]Route(nil), routes...)
+}
+
+// Permission 查找请求路径对应路由的权限标识, 未设置时返回空
+func Permission(mount, method, path string) string {
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, r := range routes {
+		if mountOf(r) == mount && (r.Method == method || r.Method == MethodAny) && match(r.Path, path) {
+			return r.Permission
+		}
+	}
+	return ""
+}
+
+// Bind 注册控制器的路由, 由生成的 RegisterRoute 调用
+func Bind(b pine.IRouterWrapper, table []Route, controller string) {
+	for _, r := range table {
+		if r.Controller != controller {
+			continue
+		}
+		var mws []pine.Handler
+		if len(r.Title) > 0 {
+			mws = append(mws, entity(r))
+		}
+		for _, name := range r.Middleware {
+			mu.RLock()
+			handler, exist := middlewares[name]
+			mu.RUnlock()
+			if !exist {
+				panic(fmt.Errorf("路由 %s %s 引用的中间件 %s 未注册", r.Method, r.Path, name))
+			}
+			mws = append(mws, handler)
+		}
+		switch r.Method {
+		case http.MethodGet:
+			b.GET(r.Path, r.Handler, mws...)
+		case http.MethodPost:
+			b.POST(r.Path, r.Handler, mws...)
+		case http.MethodPut:
+			b.PUT(r.Path, r.Handler, mws...)
+		case http.MethodDelete:
+			b.DELETE(r.Path, r.Handler, mws...)
+		case http.MethodHead:
+			b.HEAD(r.Path, r.Handler, mws...)
+		default:
+			b.ANY(r.Path, r.Handler, mws...)
+		}
+	}
+}
+
+// entity 按注解设置接口文档标题与参数, 控制器内再次设置时以控制器为准
+func entity(r Route) pine.Handler {
+	return func(ctx *pine.Context) {
+		apidoc.SetApiEntity(ctx, &apidoc.Entity{Title: r.Title, Group: r.Group, SubGroup: r.Group, ApiParam: r.Request, AppId: "admin"})
+		ctx.Next()
+	}
+}
+
+// Conflicts 检测同一挂载分组下请求方法与路径重复的路由, ANY 与任意方法冲突
+func Conflicts(table []Route) []string {
+	seen := map[string]Route{}
+	var conflicts []string
+	for _, r := range table {
+		path := normalize(r.Path)
+		keys := []string{mountOf(r) + " " + r.Method + " " + path}
+		if r.Method == MethodAny {
+			for _, m := range []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodHead} {
+				keys = append(keys, mountOf(r)+" "+m+" "+path)
+			}
+		} else {
+			keys = append(keys, mountOf(r)+" "+MethodAny+" "+path)
+		}
+		for _, key := range keys {
+			if prev, exist := seen[key]; exist && (prev.Controller != r.Controller || prev.Handler != r.Handler) {
+				conflicts = append(conflicts, fmt.Sprintf("%s %s: %s.%s 与 %s.%s 重复", r.Method, r.Path, prev.Controller, prev.Handler, r.Controller, r.Handler))
+				break
+			}
+		}
+		seen[mountOf(r)+" "+r.Method+" "+path] = r
+	}
+	sort.Strings(conflicts)
+	return conflicts
+}
+
+func mountOf(r Route) string {
+	if len(r.Mount) == 0 {
+		return MountAdmin
+	}
+	return r.Mount
+}
+
+// match 请求路径是否匹配路由, 路径参数匹配任意一段
+func match(pattern, path string) bool {
+	patterns := strings.Split(normalize(pattern), "/")
+	segments := strings.Split("/"+strings.Trim(path, "/"), "/")
+	if len(patterns) != len(segments) {
+		return false
+	}
+	for i, p := range patterns {
+		if p != "*" && p != segments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// normalize 去除末尾斜杠, 路径参数统一为 *
+func normalize(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, s := range segments {
+		if strings.HasPrefix(s, ":") || strings.HasPrefix(s, "{") || strings.HasPrefix(s, "*") {
+			segments[i] = "*"
+		}
+	}
+	return "/" + strings.Join(segments, "/")
+}
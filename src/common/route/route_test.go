@@ -0,0 +1,91 @@
+package route
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConflicts(t *testing.T) {
+	cases := []struct {
+		name     string
+		table    []Route
+		conflict bool
+	}{
+		{"不同控制器相同路由", []Route{
+			{Method: "GET", Path: "/user/list", Controller: "UserController", Handler: "List"},
+			{Method: "GET", Path: "/user/list", Controller: "MemberController", Handler: "List"},
+		}, true},
+		{"ANY 与其他方法", []Route{
+			{Method: "ANY", Path: "/user/list", Controller: "UserController", Handler: "List"},
+			{Method: "POST", Path: "/user/list", Controller: "UserController", Handler: "PostList"},
+		}, true},
+		{"其他方法与 ANY", []Route{
+			{Method: "POST", Path: "/user/list", Controller: "UserController", Handler: "PostList"},
+			{Method: "ANY", Path: "/user/list", Controller: "UserController", Handler: "List"},
+		}, true},
+		{"ANY 与 ANY", []Route{
+			{Method: "ANY", Path: "/login", Controller: "LoginController", Handler: "Login"},
+			{Method: "ANY", Path: "/login", Controller: "IndexController", Handler: "Main"},
+		}, true},
+		{"路径参数写法不同", []Route{
+			{Method: "GET", Path: "/user/:id", Controller: "UserController", Handler: "Info"},
+			{Method: "GET", Path: "/user/{uid}", Controller: "UserController", Handler: "Detail"},
+		}, true},
+		{"末尾斜杠", []Route{
+			{Method: "GET", Path: "/user/list", Controller: "UserController", Handler: "List"},
+			{Method: "GET", Path: "/user/list/", Controller: "UserController", Handler: "GetList"},
+		}, true},
+		{"默认挂载分组为 admin", []Route{
+			{Method: "GET", Path: "/user/list", Controller: "UserController", Handler: "List"},
+			{Mount: MountAdmin, Method: "GET", Path: "/user/list", Controller: "UserController", Handler: "GetList"},
+		}, true},
+		{"不同挂载分组", []Route{
+			{Mount: MountAdmin, Method: "GET", Path: "/user/list", Controller: "UserController", Handler: "List"},
+			{Mount: MountApp, Method: "GET", Path: "/user/list", Controller: "IndexController", Handler: "List"},
+		}, false},
+		{"不同请求方法", []Route{
+			{Method: "GET", Path: "/user/list", Controller: "UserController", Handler: "GetList"},
+			{Method: "POST", Path: "/user/list", Controller: "UserController", Handler: "PostList"},
+		}, false},
+		{"同一方法重复登记", []Route{
+			{Method: "GET", Path: "/user/list", Controller: "UserController", Handler: "List"},
+			{Method: "GET", Path: "/user/list", Controller: "UserController", Handler: "List"},
+		}, false},
+	}
+	for _, c := range cases {
+		conflicts := Conflicts(c.table)
+		if c.conflict != (len(conflicts) > 0) {
+			t.Errorf("%s: 冲突检测错误 %v", c.name, conflicts)
+		}
+	}
+
+	conflicts := Conflicts(cases[0].table)
+	if len(conflicts) != 1 || !strings.Contains(conflicts[0], "UserController.List 与 MemberController.List") {
+		t.Errorf("冲突信息应列出两个控制器方法: %v", conflicts)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	t.Cleanup(func() { routes = nil })
+	routes = nil
+
+	if conflicts := Register(Route{Method: "GET", Path: "/user/list", Controller: "UserController", Handler: "List", Permission: "user:list"}); len(conflicts) > 0 {
+		t.Fatalf("首次登记不应冲突: %v", conflicts)
+	}
+	if conflicts := Register(Route{Method: "ANY", Path: "/user/info/:id", Controller: "UserController", Handler: "Info", Permission: "user:info"}); len(conflicts) > 0 {
+		t.Fatalf("不同路由不应冲突: %v", conflicts)
+	}
+	if conflicts := Register(Route{Method: "GET", Path: "/user/list", Controller: "AdminController", Handler: "List"}); len(conflicts) != 1 {
+		t.Errorf("与已登记路由重复时应返回冲突: %v", conflicts)
+	}
+
+	if perm := Permission(MountAdmin, "GET", "/user/list/"); perm != "user:list" {
+		t.Errorf("权限标识错误: %q", perm)
+	}
+	if perm := Permission(MountAdmin, "POST", "/user/info/1"); perm != "user:info" {
+		t.Errorf("ANY 路由与路径参数应匹配: %q", perm)
+	}
+	if perm := Permission(MountApp, "GET", "/user/list"); perm != "" {
+		t.Errorf("其他挂载分组不应匹配: %q", perm)
+	}
+}
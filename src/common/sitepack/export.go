@@ -0,0 +1,155 @@
+package sitepack
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/xiusin/pinecms/src/common/helper"
+	"github.com/xiusin/pinecms/src/config"
+	"xorm.io/xorm/schemas"
+)
+
+// Export 导出站点数据到迁移包, 本地存储时一并打包被引用的上传文件
+func Export(out string, site map[string]string) (*Manifest, error) {
+	orm := helper.GetORM()
+	prefix := config.DB().Db.DbPrefix
+	metas, err := orm.DBMetas()
+	if err != nil {
+		return nil, err
+	}
+	existed := map[string]*schemas.Table{}
+	for _, table := range metas {
+		existed[table.Name] = table
+	}
+
+	if err := os.MkdirAll(filepath.Dir(out), os.ModePerm); err != nil {
+		return nil, err
+	}
+	f, err := os.Create(out)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+
+	manifest := &Manifest{
+		Version:   Version,
+		CreatedAt: helper.NowDate(helper.TimeFormat),
+		Driver:    config.DB().Db.DbDriver,
+		Prefix:    prefix,
+		SiteUrl:   strings.TrimRight(site["SITE_URL"], "/"),
+		UploadUrl: uploadBase(site["UPLOAD_URL_PREFIX"]),
+		Storage:   site["UPLOAD_ENGINE"],
+	}
+	files := map[string]struct{}{}
+	for _, name := range Tables() {
+		schema, ok := existed[prefix+name]
+		if !ok {
+			continue
+		}
+		table := fromSchema(name, schema)
+		if table.Rows, err = exportRows(zw, schema, name, manifest.UploadUrl, files); err != nil {
+			return nil, fmt.Errorf("导出表%s失败: %w", name, err)
+		}
+		manifest.Tables = append(manifest.Tables, table)
+	}
+
+	if isLocalStorage(manifest.Storage) {
+		if err := exportFiles(zw, site["UPLOAD_DIR"], files, manifest); err != nil {
+			return nil, err
+		}
+	}
+
+	w, err := zw.Create(manifestName)
+	if err != nil {
+		return nil, err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(manifest); err != nil {
+		return nil, err
+	}
+	return manifest, zw.Close()
+}
+
+// exportRows 分批读取表数据按行写入JSON, 同时收集引用的上传文件
+func exportRows(zw *zip.Writer, schema *schemas.Table, name, base string, files map[string]struct{}) (int64, error) {
+	orm := helper.GetORM()
+	w, err := zw.Create(tableDir + name + ".jsonl")
+	if err != nil {
+		return 0, err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	pattern := uploadPattern(base)
+
+	query := "SELECT * FROM " + orm.Quote(schema.Name)
+	if pks := schema.PrimaryKeys; len(pks) > 0 {
+		query += " ORDER BY " + orm.Quote(pks[0])
+	}
+	var total int64
+	for offset := 0; ; offset += chunkSize {
+		rows, err := orm.QueryInterface(fmt.Sprintf("%s LIMIT %d OFFSET %d", query, chunkSize, offset))
+		if err != nil {
+			return total, err
+		}
+		for _, row := range rows {
+			for k, v := range row {
+				switch v := v.(type) {
+				case []byte:
+					row[k] = string(v)
+				case time.Time:
+					row[k] = v.Format(helper.TimeFormat)
+				}
+				if s, ok := row[k].(string); ok && base != "/" {
+					for _, match := range pattern.FindAllStringSubmatch(s, -1) {
+						files[match[1]] = struct{}{}
+					}
+				}
+			}
+			if err := encoder.Encode(row); err != nil {
+				return total, err
+			}
+			total++
+		}
+		if len(rows) < chunkSize {
+			return total, nil
+		}
+	}
+}
+
+func exportFiles(zw *zip.Writer, dir string, files map[string]struct{}, manifest *Manifest) error {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		name = filepath.Clean(filepath.FromSlash(name))
+		if strings.HasPrefix(name, "..") || filepath.IsAbs(name) {
+			continue
+		}
+		src, err := os.Open(filepath.Join(dir, name))
+		if err != nil {
+			manifest.Missing++
+			continue
+		}
+		w, err := zw.Create(fileDir + filepath.ToSlash(name))
+		if err == nil {
+			_, err = io.Copy(w, src)
+		}
+		src.Close()
+		if err != nil {
+			return err
+		}
+		manifest.Files++
+	}
+	return nil
+}
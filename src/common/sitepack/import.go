@@ -0,0 +1,359 @@
+package sitepack
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/xiusin/pine"
+	"github.com/xiusin/pinecms/src/application/controllers"
+	"github.com/xiusin/pinecms/src/common/dialect"
+	"github.com/xiusin/pinecms/src/common/helper"
+	"xorm.io/xorm"
+	"xorm.io/xorm/schemas"
+)
+
+// TableResult 单表导入结果
+type TableResult struct {
+	Name     string
+	Created  bool
+	Inserted int64
+	Updated  int64
+	Skipped  int64
+	Failed   int64
+	Errors   []string
+}
+
+// Result 迁移包导入结果
+type Result struct {
+	Manifest *Manifest
+	Tables   []*TableResult
+	Files    int
+}
+
+// Import 导入迁移包, 缺失的表按包内结构创建, 表前缀与附件地址替换为当前站点
+func Import(file, conflict string, site map[string]string) (*Result, error) {
+	if len(conflict) == 0 {
+		conflict = ConflictSkip
+	}
+	if !helper.InArray(conflict, []string{ConflictSkip, ConflictOverwrite, ConflictTruncate}) {
+		return nil, errors.New("不支持的冲突处理方式: " + conflict)
+	}
+	zr, err := zip.OpenReader(file)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	entries := map[string]*zip.File{}
+	for _, f := range zr.File {
+		entries[f.Name] = f
+	}
+	manifest := &Manifest{}
+	if err := readJSON(entries[manifestName], manifest); err != nil {
+		return nil, fmt.Errorf("读取%s失败: %w", manifestName, err)
+	}
+	if manifest.Version > Version {
+		return nil, fmt.Errorf("迁移包版本%d高于当前支持的版本%d", manifest.Version, Version)
+	}
+
+	orm := helper.GetORM()
+	metas, err := orm.DBMetas()
+	if err != nil {
+		return nil, err
+	}
+	existed := map[string]*schemas.Table{}
+	for _, table := range metas {
+		existed[table.Name] = table
+	}
+
+	allowed, err := allowedTables(entries)
+	if err != nil {
+		return nil, err
+	}
+	for _, table := range manifest.Tables {
+		if !helper.InArray(table.Name, allowed) {
+			return nil, fmt.Errorf("迁移包中的表%s不在迁移范围内", table.Name)
+		}
+	}
+
+	result := &Result{Manifest: manifest}
+	replacer := urlReplacer(manifest, site)
+	for _, table := range manifest.Tables {
+		res := &TableResult{Name: table.Name}
+		result.Tables = append(result.Tables, res)
+		fullName := controllers.GetTableName(table.Name)
+		schema, ok := existed[fullName]
+		if !ok {
+			if schema, err = createTable(table, fullName); err != nil {
+				return result, fmt.Errorf("创建表%s失败: %w", fullName, err)
+			}
+			res.Created = true
+		}
+		// 单表在事务内导入, 失败时回滚该表, 已导入的表不受影响
+		_, err := orm.Transaction(func(sess *xorm.Session) (any, error) {
+			if conflict == ConflictTruncate && !res.Created {
+				if _, err := sess.Exec("DELETE FROM " + orm.Quote(fullName)); err != nil {
+					return nil, fmt.Errorf("清空表%s失败: %w", fullName, err)
+				}
+			}
+			return nil, importRows(sess, entries[tableDir+table.Name+".jsonl"], schema, conflict, replacer, res)
+		})
+		if err != nil {
+			res.Inserted, res.Updated, res.Skipped = 0, 0, 0
+			return result, fmt.Errorf("导入表%s失败, 已回滚: %w", table.Name, err)
+		}
+		// 写入了显式ID, 同步PostgreSQL的自增序列
+		if col := schema.AutoIncrColumn(); col != nil {
+			if sqlStr := dialect.Of(orm).ResetSequence(orm, fullName, col.Name); len(sqlStr) > 0 {
+				if _, err := orm.Exec(sqlStr); err != nil {
+					pine.Logger().Warn("重置自增序列失败", fullName, err)
+				}
+			}
+		}
+		_ = helper.Cache().Delete(fmt.Sprintf(controllers.CacheTableNameFields, fullName))
+	}
+
+	if isLocalStorage(site["UPLOAD_ENGINE"]) {
+		if result.Files, err = importFiles(zr.File, site["UPLOAD_DIR"], conflict); err != nil {
+			return result, err
+		}
+	}
+	for _, key := range []string{
+		controllers.CacheSetting, controllers.CacheModels, controllers.CacheCategories, controllers.CacheSites,
+		controllers.CacheSite, controllers.CacheAdminRoles, controllers.CacheTableNames, controllers.CacheFeTplList,
+	} {
+		_ = helper.Cache().Delete(key)
+	}
+	return result, nil
+}
+
+// allowedTables 允许导入的表: 系统表, 当前模型表以及迁移包内模型定义的表
+func allowedTables(entries map[string]*zip.File) ([]string, error) {
+	names := Tables()
+	models, err := archiveModelTables(entries[tableDir+"document_model.jsonl"])
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range models {
+		if !helper.InArray(name, names) {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// archiveModelTables 读取迁移包内模型定义的表名, 表名只能包含字母数字下划线
+func archiveModelTables(f *zip.File) ([]string, error) {
+	if f == nil {
+		return nil, nil
+	}
+	r, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	var names []string
+	decoder := json.NewDecoder(r)
+	for {
+		var model struct {
+			Table string `json:"table"`
+		}
+		if err := decoder.Decode(&model); err == io.EOF {
+			return names, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("读取模型定义失败: %w", err)
+		}
+		if len(model.Table) == 0 {
+			continue
+		}
+		if !tableNamePattern.MatchString(model.Table) {
+			return nil, fmt.Errorf("模型表名%s不合法", model.Table)
+		}
+		names = append(names, model.Table)
+	}
+}
+
+func readJSON(f *zip.File, v any) error {
+	if f == nil {
+		return os.ErrNotExist
+	}
+	r, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	return json.NewDecoder(r).Decode(v)
+}
+
+// urlReplacer 将来源站点的附件地址替换为当前站点地址
+func urlReplacer(manifest *Manifest, site map[string]string) *strings.Replacer {
+	siteUrl := strings.TrimRight(site["SITE_URL"], "/")
+	base := uploadBase(site["UPLOAD_URL_PREFIX"])
+	var pairs []string
+	if len(manifest.SiteUrl) > 0 && manifest.SiteUrl+manifest.UploadUrl != siteUrl+base {
+		pairs = append(pairs, manifest.SiteUrl+manifest.UploadUrl, siteUrl+base)
+	}
+	if manifest.UploadUrl != "/" && manifest.UploadUrl != base {
+		pairs = append(pairs, manifest.UploadUrl, base)
+	}
+	if len(pairs) == 0 {
+		return nil
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+func createTable(table Table, fullName string) (*schemas.Table, error) {
+	orm := helper.GetORM()
+	schema := table.schema(fullName)
+	sql, _, err := orm.Dialect().CreateTableSQL(context.Background(), orm.DB(), schema, fullName)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := orm.Exec(sql); err != nil {
+		return nil, err
+	}
+	for _, index := range schema.Indexes {
+		if _, err := orm.Exec(orm.Dialect().CreateIndexSQL(fullName, index)); err != nil {
+			return nil, err
+		}
+	}
+	return schema, nil
+}
+
+// importRows 逐行写入数据, 仅保留目标表存在的字段, 按冲突方式处理主键重复, 任一行失败时返回错误
+func importRows(sess *xorm.Session, f *zip.File, schema *schemas.Table, conflict string, replacer *strings.Replacer, res *TableResult) error {
+	if f == nil {
+		return nil
+	}
+	r, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	var pk string
+	if len(schema.PrimaryKeys) == 1 {
+		pk = schema.PrimaryKeys[0]
+	}
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			row, decodeErr := decodeRow(line, schema, replacer)
+			if decodeErr != nil {
+				return decodeErr
+			}
+			if err := saveRow(sess, schema.Name, pk, conflict, row, res); err != nil {
+				res.Failed++
+				res.Errors = append(res.Errors, fmt.Sprintf("%v: %s", row[pk], err))
+				return err
+			}
+		}
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+func decodeRow(line []byte, schema *schemas.Table, replacer *strings.Replacer) (map[string]any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(line))
+	decoder.UseNumber()
+	var data map[string]any
+	if err := decoder.Decode(&data); err != nil {
+		return nil, err
+	}
+	row := make(map[string]any, len(data))
+	for k, v := range data {
+		if schema.GetColumn(k) == nil {
+			continue
+		}
+		switch v := v.(type) {
+		case json.Number:
+			row[k] = v.String()
+		case string:
+			if replacer != nil {
+				v = replacer.Replace(v)
+			}
+			row[k] = v
+		default:
+			row[k] = v
+		}
+	}
+	return row, nil
+}
+
+func saveRow(sess *xorm.Session, table, pk, conflict string, row map[string]any, res *TableResult) error {
+	quote := sess.Engine().Quote
+	if id, ok := row[pk]; ok && conflict != ConflictTruncate {
+		exists, err := sess.Table(table).Where(quote(pk)+" = ?", id).Exist()
+		if err != nil {
+			return err
+		}
+		if exists {
+			if conflict == ConflictSkip {
+				res.Skipped++
+				return nil
+			}
+			if _, err := sess.Table(table).Where(quote(pk)+" = ?", id).Update(row); err != nil {
+				return err
+			}
+			res.Updated++
+			return nil
+		}
+	}
+	if _, err := sess.Table(table).Insert(row); err != nil {
+		return err
+	}
+	res.Inserted++
+	return nil
+}
+
+// importFiles 还原上传文件, 跳过模式下不覆盖已存在的文件
+func importFiles(files []*zip.File, dir, conflict string) (int, error) {
+	var total int
+	for _, f := range files {
+		if !strings.HasPrefix(f.Name, fileDir) || f.FileInfo().IsDir() {
+			continue
+		}
+		name := filepath.FromSlash(strings.TrimPrefix(f.Name, fileDir))
+		if !filepath.IsLocal(name) {
+			continue
+		}
+		target := filepath.Join(dir, name)
+		if _, err := os.Stat(target); err == nil && conflict == ConflictSkip {
+			continue
+		}
+		if err := extract(f, target); err != nil {
+			return total, err
+		}
+		total++
+	}
+	return total, nil
+}
+
+func extract(f *zip.File, target string) error {
+	r, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	if err := os.MkdirAll(filepath.Dir(target), os.ModePerm); err != nil {
+		return err
+	}
+	out, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	_, err = io.Copy(out, r)
+	return err
+}
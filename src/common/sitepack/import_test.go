@@ -0,0 +1,96 @@
+package sitepack
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// zipEntries 按名称与内容生成 zip 并返回条目
+func zipEntries(t *testing.T, files map[string]string) []*zip.File {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return zr.File
+}
+
+func TestImportFiles(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "uploads")
+	files := zipEntries(t, map[string]string{
+		fileDir + "2024/a.jpg":         "a",
+		fileDir + "../evil.txt":        "evil",
+		fileDir + "2024/../../b.txt":   "evil",
+		fileDir + "/etc/passwd":        "evil",
+		tableDir + "category.jsonl":    "{}",
+		fileDir + "2024/sub/..name.js": "ok",
+	})
+	total, err := importFiles(files, dir, ConflictOverwrite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 2 {
+		t.Fatalf("应还原2个文件, 实际%d个", total)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "2024", "a.jpg")); err != nil || string(data) != "a" {
+		t.Fatalf("还原内容错误: %q %v", data, err)
+	}
+	for _, name := range []string{"evil.txt", "b.txt"} {
+		if _, err := os.Stat(filepath.Join(root, name)); err == nil {
+			t.Fatalf("%s 不应写入上传目录之外", name)
+		}
+	}
+}
+
+func TestImportFilesSkip(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.jpg"), []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	files := zipEntries(t, map[string]string{fileDir + "a.jpg": "new"})
+	if total, err := importFiles(files, dir, ConflictSkip); err != nil || total != 0 {
+		t.Fatalf("跳过模式不应覆盖已有文件: %d %v", total, err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "a.jpg")); string(data) != "old" {
+		t.Fatalf("已有文件被覆盖: %q", data)
+	}
+}
+
+func TestArchiveModelTables(t *testing.T) {
+	entry := func(content string) *zip.File {
+		return zipEntries(t, map[string]string{tableDir + "document_model.jsonl": content})[0]
+	}
+	names, err := archiveModelTables(entry(`{"id":1,"table":"articles"}` + "\n" + `{"id":2,"table":""}` + "\n" + `{"id":3,"table":"products"}` + "\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 2 || names[0] != "articles" || names[1] != "products" {
+		t.Fatalf("模型表名错误: %v", names)
+	}
+	for _, content := range []string{`{"table":"admin; DROP TABLE x"}`, `{"table":"../x"}`, `{"table":`} {
+		if _, err := archiveModelTables(entry(content)); err == nil {
+			t.Fatalf("应拒绝 %s", content)
+		}
+	}
+	if names, err := archiveModelTables(nil); err != nil || names != nil {
+		t.Fatalf("缺少模型定义时应返回空: %v %v", names, err)
+	}
+}
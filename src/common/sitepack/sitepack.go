@@ -0,0 +1,170 @@
+package sitepack
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/xiusin/pinecms/src/application/models/tables"
+	"github.com/xiusin/pinecms/src/common/helper"
+	"xorm.io/xorm/schemas"
+)
+
+// Version 迁移包格式版本
+const Version = 1
+
+const (
+	manifestName = "manifest.json"
+	tableDir     = "tables/"
+	fileDir      = "files/"
+	chunkSize    = 500
+)
+
+// 冲突处理方式
+const (
+	ConflictSkip      = "skip"      // 主键已存在时跳过
+	ConflictOverwrite = "overwrite" // 主键已存在时覆盖
+	ConflictTruncate  = "truncate"  // 导入前清空目标表
+)
+
+var tableNamePattern = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// baseTables 需要迁移的系统表(不含表前缀), 管理员, 会员与日志不迁移
+var baseTables = []string{
+	"document_model", "document_model_dsl", "document_model_field",
+	"category", "category_priv", "page",
+	"setting", "dict_category", "dict", "menu",
+	"admin_role", "admin_role_priv", "casbin_rule",
+	"advert", "advert_space", "link", "tags",
+	"attachments", "attachment_type",
+	"site", "data_source",
+}
+
+// Manifest 迁移包描述
+type Manifest struct {
+	Version   int     `json:"version"`
+	CreatedAt string  `json:"created_at"`
+	Driver    string  `json:"driver"`
+	Prefix    string  `json:"prefix"`
+	SiteUrl   string  `json:"site_url"`
+	UploadUrl string  `json:"upload_url"`
+	Storage   string  `json:"storage"`
+	Tables    []Table `json:"tables"`
+	Files     int     `json:"files"`
+	Missing   int     `json:"missing"`
+}
+
+// Table 表结构与数据量, 名称不含表前缀
+type Table struct {
+	Name    string   `json:"name"`
+	Rows    int64    `json:"rows"`
+	Columns []Column `json:"columns"`
+	Indexes []Index  `json:"indexes"`
+}
+
+type Column struct {
+	Name           string `json:"name"`
+	Type           string `json:"type"`
+	Length         int64  `json:"length,omitempty"`
+	Length2        int64  `json:"length2,omitempty"`
+	Nullable       bool   `json:"nullable,omitempty"`
+	PrimaryKey     bool   `json:"pk,omitempty"`
+	AutoIncrement  bool   `json:"autoincr,omitempty"`
+	Default        string `json:"default,omitempty"`
+	DefaultIsEmpty bool   `json:"default_empty,omitempty"`
+	Comment        string `json:"comment,omitempty"`
+}
+
+type Index struct {
+	Name      string   `json:"name"`
+	Unique    bool     `json:"unique,omitempty"`
+	IsRegular bool     `json:"regular,omitempty"`
+	Cols      []string `json:"cols"`
+}
+
+// Tables 系统表与各模型表
+func Tables() []string {
+	names := append([]string{}, baseTables...)
+	var models []tables.DocumentModel
+	helper.GetORM().Cols("table").Find(&models)
+	for _, model := range models {
+		if len(model.Table) > 0 && !helper.InArray(model.Table, names) {
+			names = append(names, model.Table)
+		}
+	}
+	return names
+}
+
+func fromSchema(name string, table *schemas.Table) Table {
+	t := Table{Name: name}
+	for _, col := range table.Columns() {
+		t.Columns = append(t.Columns, Column{
+			Name:           col.Name,
+			Type:           col.SQLType.Name,
+			Length:         col.Length,
+			Length2:        col.Length2,
+			Nullable:       col.Nullable,
+			PrimaryKey:     col.IsPrimaryKey,
+			AutoIncrement:  col.IsAutoIncrement,
+			Default:        col.Default,
+			DefaultIsEmpty: col.DefaultIsEmpty,
+			Comment:        col.Comment,
+		})
+	}
+	for _, index := range table.Indexes {
+		t.Indexes = append(t.Indexes, Index{
+			Name:      index.Name,
+			Unique:    index.Type == schemas.UniqueType,
+			IsRegular: index.IsRegular,
+			Cols:      index.Cols,
+		})
+	}
+	return t
+}
+
+func (t Table) schema(fullName string) *schemas.Table {
+	table := schemas.NewEmptyTable()
+	table.Name = fullName
+	for _, c := range t.Columns {
+		col := &schemas.Column{
+			Name:            c.Name,
+			TableName:       fullName,
+			SQLType:         schemas.SQLType{Name: c.Type},
+			Length:          c.Length,
+			Length2:         c.Length2,
+			Nullable:        c.Nullable,
+			IsPrimaryKey:    c.PrimaryKey,
+			IsAutoIncrement: c.AutoIncrement,
+			Default:         c.Default,
+			DefaultIsEmpty:  c.DefaultIsEmpty,
+			Comment:         c.Comment,
+			Indexes:         map[string]int{},
+		}
+		table.AddColumn(col)
+	}
+	for _, i := range t.Indexes {
+		index := schemas.NewIndex(i.Name, schemas.IndexType)
+		if i.Unique {
+			index.Type = schemas.UniqueType
+		}
+		index.IsRegular = i.IsRegular
+		index.Cols = i.Cols
+		table.AddIndex(index)
+	}
+	return table
+}
+
+// uploadBase 附件访问前缀, 如 /uploads/
+func uploadBase(prefix string) string {
+	if prefix = strings.Trim(prefix, "/"); len(prefix) == 0 {
+		return "/"
+	}
+	return "/" + prefix + "/"
+}
+
+func uploadPattern(base string) *regexp.Regexp {
+	return regexp.MustCompile(regexp.QuoteMeta(base) + `([^"'\s<>()?#\\]+)`)
+}
+
+func isLocalStorage(engine string) bool {
+	return len(engine) == 0 || engine == "本地存储"
+}
@@ -15,10 +15,7 @@ import (
 
 	"github.com/tencentyun/cos-go-sdk-v5"
 
-	"github.com/xiusin/pine/di"
-	"github.com/xiusin/pinecms/src/application/controllers"
 	"github.com/xiusin/pinecms/src/common/helper"
-	"github.com/xiusin/pinecms/src/config"
 )
 
 type CosUploader struct {
@@ -153,21 +150,7 @@ func (s *CosUploader) getObjectName(name string) string {
 }
 
 func init() {
-	di.Set(fmt.Sprintf(controllers.ServiceUploaderEngine, (&CosUploader{}).GetEngineName()), func(builder di.AbstractBuilder) (any, error) {
-		var engine Uploader
-		var err error
-		defer func() {
-			if errPanic := recover(); errPanic != nil {
-				engine = nil
-				err = fmt.Errorf("%s", err)
-			}
-		}()
-
-		if cfg, err := config.SiteConfig(); err != nil {
-			return nil, err
-		} else {
-			engine = NewCosUploader(cfg)
-			return engine, nil
-		}
-	}, false)
+	Register((&CosUploader{}).GetEngineName(), func(settingData map[string]string) Uploader {
+		return NewCosUploader(settingData)
+	})
 }
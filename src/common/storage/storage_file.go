@@ -1,16 +1,11 @@
 package storage
 
 import (
-	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
-
-	"github.com/xiusin/pine/di"
-	"github.com/xiusin/pinecms/src/application/controllers"
-	"github.com/xiusin/pinecms/src/config"
 )
 
 type FileUploader struct {
@@ -105,11 +100,7 @@ func (s *FileUploader) Rename(oldname, newname string) error {
 }
 
 func init() {
-	di.Set(fmt.Sprintf(controllers.ServiceUploaderEngine, (&FileUploader{}).GetEngineName()), func(builder di.AbstractBuilder) (any, error) {
-		cfg, err := config.SiteConfig()
-		if err != nil {
-			return nil, err
-		}
-		return NewFileUploader(cfg), nil
-	}, false)
+	Register((&FileUploader{}).GetEngineName(), func(settingData map[string]string) Uploader {
+		return NewFileUploader(settingData)
+	})
 }
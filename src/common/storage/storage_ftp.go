@@ -13,10 +13,7 @@ import (
 	"github.com/jlaffaye/ftp"
 	"github.com/xiusin/pine"
 
-	"github.com/xiusin/pine/di"
-	"github.com/xiusin/pinecms/src/application/controllers"
 	"github.com/xiusin/pinecms/src/common/helper"
-	"github.com/xiusin/pinecms/src/config"
 )
 
 type FtpUploader struct {
@@ -183,11 +180,7 @@ func (s *FtpUploader) Rename(oldname, newname string) error {
 }
 
 func init() {
-	di.Set(fmt.Sprintf(controllers.ServiceUploaderEngine, (&FtpUploader{}).GetEngineName()), func(builder di.AbstractBuilder) (any, error) {
-		cfg, err := config.SiteConfig()
-		if err != nil {
-			return nil, err
-		}
-		return NewFtpUploader(cfg), nil
-	}, false)
+	Register((&FtpUploader{}).GetEngineName(), func(settingData map[string]string) Uploader {
+		return NewFtpUploader(settingData)
+	})
 }
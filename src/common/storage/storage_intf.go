@@ -1,12 +1,53 @@
 package storage
 
 import (
+	"fmt"
 	"io"
 	"runtime"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/xiusin/pine"
+	"github.com/xiusin/pine/di"
+	"github.com/xiusin/pinecms/src/application/controllers"
+)
+
+var (
+	driverMu sync.RWMutex
+	drivers  = map[string]func(map[string]string) Uploader{}
 )
 
+// Register 登记内置存储驱动, 按站点配置创建
+func Register(name string, fn func(settingData map[string]string) Uploader) {
+	driverMu.Lock()
+	defer driverMu.Unlock()
+	drivers[name] = fn
+}
+
+// Engine 根据站点配置创建启用的存储驱动, 未登记的驱动从容器读取(插件注册), 缺少驱动时使用本地存储
+func Engine(settingData map[string]string) (uploader Uploader) {
+	name := settingData["UPLOAD_ENGINE"]
+	driverMu.RLock()
+	fn, exist := drivers[name]
+	driverMu.RUnlock()
+	if exist {
+		defer func() {
+			if err := recover(); err != nil {
+				pine.Logger().Warn("创建存储驱动"+name+"失败, 自动转换为本地存储", err)
+				uploader = NewFileUploader(settingData)
+			}
+		}()
+		return fn(settingData)
+	}
+	engine, err := di.Get(fmt.Sprintf(controllers.ServiceUploaderEngine, name))
+	if err != nil {
+		pine.Logger().Warn("缺少存储驱动, 自动转换为本地存储", err)
+		return NewFileUploader(settingData)
+	}
+	return engine.(Uploader)
+}
+
 type Uploader interface {
 	Upload(storageName string, LocalFile io.Reader) (string, error)
 	List(dir string) (list []File, err error)
@@ -12,10 +12,7 @@ import (
 
 	"github.com/aliyun/aliyun-oss-go-sdk/oss"
 	"github.com/xiusin/pine"
-	"github.com/xiusin/pine/di"
-	"github.com/xiusin/pinecms/src/application/controllers"
 	"github.com/xiusin/pinecms/src/common/helper"
-	"github.com/xiusin/pinecms/src/config"
 )
 
 type OssUploader struct {
@@ -141,20 +138,7 @@ func (s *OssUploader) Exists(name string) (bool, error) {
 }
 
 func init() {
-	di.Set(fmt.Sprintf(controllers.ServiceUploaderEngine, (&OssUploader{}).GetEngineName()), func(builder di.AbstractBuilder) (any, error) {
-		var engine Uploader
-		var err error
-		defer func() {
-			if errPanic := recover(); errPanic != nil {
-				engine = nil
-				err = fmt.Errorf("%s", err)
-			}
-		}()
-		cfg, err := config.SiteConfig()
-		if err != nil {
-			return nil, err
-		}
-		engine = NewOssUploader(cfg)
-		return engine, nil
-	}, false)
+	Register((&OssUploader{}).GetEngineName(), func(settingData map[string]string) Uploader {
+		return NewOssUploader(settingData)
+	})
 }
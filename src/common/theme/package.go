@@ -0,0 +1,164 @@
+package theme
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/xiusin/pinecms/src/config"
+)
+
+// MaxPackageSize 主题包解压后的最大体积
+const MaxPackageSize = 100 << 20
+
+// Install 安装或升级主题包, 包内根目录或唯一子目录需包含theme.json.
+// 已安装的主题只允许升级到更高版本, force为true时允许覆盖, 覆盖前备份旧版本到runtime/themes
+func Install(r io.ReaderAt, size int64, force bool) (*Manifest, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("主题包格式错误: %s", err)
+	}
+	base, manifest, err := readManifest(zr)
+	if err != nil {
+		return nil, err
+	}
+	if err := manifest.Validate(); err != nil {
+		return nil, err
+	}
+	if Exists(manifest.Name) {
+		installed, _ := Load(manifest.Name)
+		if installed != nil && CompareVersion(manifest.Version, installed.Version) <= 0 && !force {
+			return nil, fmt.Errorf("已安装%s版本, 不可安装%s版本", installed.Version, manifest.Version)
+		}
+	}
+
+	tmpDir, err := os.MkdirTemp(Root(), ".install-"+manifest.Name+"-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+	if err := extract(zr, base, tmpDir); err != nil {
+		return nil, err
+	}
+
+	target := filepath.Join(Root(), manifest.Name)
+	if Exists(manifest.Name) {
+		if err := backup(manifest.Name); err != nil {
+			return nil, err
+		}
+		if err := os.RemoveAll(target); err != nil {
+			return nil, err
+		}
+	}
+	if err := os.Rename(tmpDir, target); err != nil {
+		return nil, err
+	}
+	return Load(manifest.Name)
+}
+
+func readManifest(zr *zip.Reader) (string, *Manifest, error) {
+	for _, f := range zr.File {
+		name := path.Clean(f.Name)
+		if path.Base(name) != ManifestFile || strings.Count(name, "/") > 1 {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return "", nil, err
+		}
+		byts, err := io.ReadAll(io.LimitReader(rc, 1<<20))
+		rc.Close()
+		if err != nil {
+			return "", nil, err
+		}
+		manifest := &Manifest{}
+		if err := sonic.Unmarshal(byts, manifest); err != nil {
+			return "", nil, fmt.Errorf("主题描述文件错误: %s", err)
+		}
+		return path.Dir(name), manifest, nil
+	}
+	return "", nil, errors.New("主题包缺少" + ManifestFile)
+}
+
+// extract 解压base目录下的文件到dst, 拒绝跳出目录的路径
+func extract(zr *zip.Reader, base, dst string) error {
+	var total int64
+	for _, f := range zr.File {
+		name := path.Clean(f.Name)
+		if base != "." {
+			if !strings.HasPrefix(name, base+"/") {
+				continue
+			}
+			name = strings.TrimPrefix(name, base+"/")
+		}
+		target := filepath.Join(dst, filepath.FromSlash(name))
+		if !strings.HasPrefix(target, filepath.Clean(dst)+string(os.PathSeparator)) {
+			return fmt.Errorf("主题包包含非法路径: %s", f.Name)
+		}
+		if f.FileInfo().IsDir() {
+			if err := os.MkdirAll(target, os.ModePerm); err != nil {
+				return err
+			}
+			continue
+		}
+		if total += int64(f.UncompressedSize64); total > MaxPackageSize {
+			return errors.New("主题包解压后体积过大")
+		}
+		if err := extractFile(f, target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func extractFile(f *zip.File, target string) error {
+	if err := os.MkdirAll(filepath.Dir(target), os.ModePerm); err != nil {
+		return err
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	out, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	_, err = io.Copy(out, io.LimitReader(rc, MaxPackageSize))
+	return err
+}
+
+// backup 备份主题目录到runtime/themes/{name}-{version}-{时间}
+func backup(name string) error {
+	manifest, err := Load(name)
+	if err != nil {
+		return err
+	}
+	dst := config.RuntimePath(filepath.Join("themes", fmt.Sprintf("%s-%s-%s", name, manifest.Version, time.Now().Format("20060102150405"))))
+	if err := os.MkdirAll(filepath.Dir(dst), os.ModePerm); err != nil {
+		return err
+	}
+	return os.CopyFS(dst, os.DirFS(filepath.Join(Root(), name)))
+}
+
+// Uninstall 卸载主题, 使用中的主题不可卸载, 卸载前备份
+func Uninstall(name string) error {
+	if !Exists(name) {
+		return fmt.Errorf("主题%s不存在", name)
+	}
+	if InUse(name) {
+		return errors.New("主题正在使用中, 无法卸载")
+	}
+	if err := backup(name); err != nil {
+		return err
+	}
+	return os.RemoveAll(filepath.Join(Root(), name))
+}
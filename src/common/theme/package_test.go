@@ -0,0 +1,179 @@
+package theme
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/xiusin/pinecms/src/config"
+)
+
+const manifestJSON = `{"name": "demo", "version": "1.0.0"}`
+
+// zipPackage 按顺序写入 name, content 成对的条目生成主题包
+func zipPackage(t *testing.T, entries ...string) *bytes.Reader {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	for i := 0; i+1 < len(entries); i += 2 {
+		w, err := zw.Create(entries[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = w.Write([]byte(entries[i+1])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return bytes.NewReader(buf.Bytes())
+}
+
+func zipReader(t *testing.T, r *bytes.Reader) *zip.Reader {
+	t.Helper()
+	zr, err := zip.NewReader(r, r.Size())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return zr
+}
+
+// testRoot 使用临时主题目录
+func testRoot(t *testing.T) string {
+	t.Helper()
+	view := &config.App().View
+	old := view.FeDirname
+	view.FeDirname = t.TempDir()
+	t.Cleanup(func() { view.FeDirname = old })
+	return view.FeDirname
+}
+
+func TestReadManifest(t *testing.T) {
+	cases := []struct {
+		entries []string
+		base    string
+	}{
+		{[]string{ManifestFile, manifestJSON}, "."},
+		{[]string{"demo/" + ManifestFile, manifestJSON, "demo/index.html", ""}, "demo"},
+	}
+	for _, c := range cases {
+		base, manifest, err := readManifest(zipReader(t, zipPackage(t, c.entries...)))
+		if err != nil || base != c.base || manifest.Name != "demo" {
+			t.Errorf("读取主题描述错误: %q %+v %v", base, manifest, err)
+		}
+	}
+
+	// 描述文件只允许在根目录或一级子目录
+	if _, _, err := readManifest(zipReader(t, zipPackage(t, "a/b/"+ManifestFile, manifestJSON))); err == nil {
+		t.Error("多级子目录中的描述文件不应识别")
+	}
+	if _, _, err := readManifest(zipReader(t, zipPackage(t, ManifestFile, "{"))); err == nil {
+		t.Error("描述文件格式错误时应返回错误")
+	}
+}
+
+func TestExtract(t *testing.T) {
+	dst := filepath.Join(t.TempDir(), "dst")
+	zr := zipReader(t, zipPackage(t,
+		"demo/"+ManifestFile, manifestJSON,
+		"demo/css/style.css", "body{}",
+		"other/index.html", "other",
+	))
+	if err := extract(zr, "demo", dst); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dst, "css", "style.css")); err != nil || string(data) != "body{}" {
+		t.Errorf("解压内容错误: %q %v", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "other")); err == nil {
+		t.Error("不应解压主题目录之外的文件")
+	}
+}
+
+func TestExtractIllegalPath(t *testing.T) {
+	for _, name := range []string{"../evil.txt", "a/../../evil.txt", "demo/../../evil.txt"} {
+		root := t.TempDir()
+		dst := filepath.Join(root, "dst")
+		err := extract(zipReader(t, zipPackage(t, name, "evil")), ".", dst)
+		if err == nil || !strings.Contains(err.Error(), "非法路径") {
+			t.Errorf("%s 应拒绝跳出目录的路径: %v", name, err)
+		}
+		if _, err = os.Stat(filepath.Join(root, "evil.txt")); err == nil {
+			t.Errorf("%s 不应写入目录之外", name)
+		}
+	}
+
+	// 绝对路径解压到目标目录内
+	dst := t.TempDir()
+	if err := extract(zipReader(t, zipPackage(t, "/etc/evil.txt", "evil")), ".", dst); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "etc", "evil.txt")); err != nil {
+		t.Errorf("绝对路径应解压到目标目录内: %v", err)
+	}
+}
+
+func TestExtractSizeLimit(t *testing.T) {
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	w, err := zw.Create("big.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = io.CopyN(w, zeroReader{}, MaxPackageSize+1); err != nil {
+		t.Fatal(err)
+	}
+	if err = zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := t.TempDir()
+	err = extract(zipReader(t, bytes.NewReader(buf.Bytes())), ".", dst)
+	if err == nil || !strings.Contains(err.Error(), "体积过大") {
+		t.Fatalf("解压后超过体积上限时应拒绝: %v", err)
+	}
+	if _, err = os.Stat(filepath.Join(dst, "big.bin")); err == nil {
+		t.Error("超过体积上限的文件不应写入")
+	}
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
+func TestInstallRejected(t *testing.T) {
+	root := testRoot(t)
+	cases := map[string]*bytes.Reader{
+		"格式错误":    bytes.NewReader([]byte("not a zip")),
+		"缺少描述文件":  zipPackage(t, "index.html", ""),
+		"主题名称非法":  zipPackage(t, ManifestFile, `{"name": "../demo", "version": "1.0.0"}`),
+		"缺少版本号":   zipPackage(t, ManifestFile, `{"name": "demo"}`),
+		"包含非法路径":  zipPackage(t, ManifestFile, manifestJSON, "../evil.txt", "evil"),
+		"依赖未注册标签": zipPackage(t, ManifestFile, `{"name": "demo", "version": "1.0.0", "tags": ["not_exist"]}`),
+	}
+	for name, r := range cases {
+		if _, err := Install(r, r.Size(), false); err == nil {
+			t.Errorf("%s: 应拒绝安装", name)
+		}
+	}
+
+	// 安装失败时不应留下主题目录与临时目录
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) > 0 {
+		t.Errorf("安装失败后主题目录应为空: %v", entries)
+	}
+	if _, err = os.Stat(filepath.Join(filepath.Dir(root), "evil.txt")); err == nil {
+		t.Error("不应写入主题目录之外")
+	}
+}
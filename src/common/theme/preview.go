@@ -0,0 +1,65 @@
+package theme
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gbrlsnchs/jwt/v3"
+	"github.com/xiusin/pine"
+	"github.com/xiusin/pinecms/src/config"
+)
+
+const (
+	// PreviewCookie 预览主题cookie, 只影响设置了cookie的浏览器
+	PreviewCookie = "pinecms_theme_preview"
+	// PreviewQuery 携带预览令牌的参数, 前台校验后写入cookie
+	PreviewQuery = "theme_preview"
+	// PreviewExitQuery 退出预览的参数
+	PreviewExitQuery = "theme_preview_exit"
+
+	previewTTL = time.Hour
+)
+
+// previewPayload 预览令牌只携带主题, 不能用于后台认证
+type previewPayload struct {
+	jwt.Payload
+	Theme string `json:"theme"`
+}
+
+// SignPreview 生成预览令牌
+func SignPreview(name string) (string, error) {
+	if !Exists(name) {
+		return "", errors.New("主题不存在")
+	}
+	now := time.Now()
+	pl := previewPayload{
+		Payload: jwt.Payload{
+			Issuer:         "pinecms",
+			ExpirationTime: jwt.NumericDate(now.Add(previewTTL)),
+			IssuedAt:       jwt.NumericDate(now),
+		},
+		Theme: name,
+	}
+	token, err := config.JwtSign(config.JwtAudiencePreview, &pl.Payload, &pl)
+	return string(token), err
+}
+
+// Preview 当前请求的预览主题, 携带预览令牌时写入cookie, 无预览时返回空
+func Preview(ctx *pine.Context) string {
+	if len(ctx.QueryArgs().Peek(PreviewExitQuery)) > 0 {
+		ctx.RemoveCookie(PreviewCookie)
+		return ""
+	}
+	if token := ctx.QueryArgs().Peek(PreviewQuery); len(token) > 0 {
+		var pl previewPayload
+		if err := config.JwtVerify(config.JwtAudiencePreview, token, &pl.Payload, &pl); err != nil || !Exists(pl.Theme) {
+			return ""
+		}
+		ctx.SetCookie(PreviewCookie, pl.Theme, int(previewTTL.Seconds()))
+		return pl.Theme
+	}
+	if name := ctx.GetCookie(PreviewCookie); Exists(name) {
+		return name
+	}
+	return ""
+}
@@ -0,0 +1,127 @@
+package theme
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gbrlsnchs/jwt/v3"
+	"github.com/xiusin/pine"
+	"github.com/xiusin/pine/render/engine/pjet"
+	"github.com/xiusin/pinecms/src/application/controllers"
+	"github.com/xiusin/pinecms/src/common/helper"
+	"github.com/xiusin/pinecms/src/config"
+)
+
+const (
+	// DraftQuery 模板预览令牌参数, 不使用后台认证读取的 token 参数
+	DraftQuery = "draft"
+	draftTTL   = 10 * time.Minute
+)
+
+var templateErrPattern = regexp.MustCompile(`^template: (.+?):(\d+): (.*)$`)
+
+// TemplateError 模板语法错误
+type TemplateError struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+func (e *TemplateError) Error() string {
+	return fmt.Sprintf("%s 第%d行: %s", e.File, e.Line, e.Message)
+}
+
+// TemplatePath 校验主题内文件的相对路径, 返回模板名与文件路径
+func TemplatePath(themeName, name string) (string, string, error) {
+	name = filepath.ToSlash(filepath.Clean("/" + name))[1:]
+	if !Exists(themeName) || len(name) == 0 {
+		return "", "", errors.New("模板路径错误")
+	}
+	return themeName + "/" + name, filepath.Join(Root(), themeName, filepath.FromSlash(name)), nil
+}
+
+// Validate 解析模板内容, 语法错误时返回 *TemplateError, 非jet文件不做校验
+func Validate(themeName, name, content string) error {
+	tplName, _, err := TemplatePath(themeName, name)
+	if err != nil || !strings.HasSuffix(tplName, ".jet") {
+		return err
+	}
+	engine := pine.Make(controllers.ServiceJetEngine).(*pjet.PineJet)
+	if _, err = engine.Parse(tplName, content); err == nil {
+		return nil
+	}
+	if matches := templateErrPattern.FindStringSubmatch(err.Error()); len(matches) == 4 {
+		line, _ := strconv.Atoi(matches[2])
+		return &TemplateError{File: strings.TrimPrefix(matches[1], "/"), Line: line, Message: matches[3]}
+	}
+	return &TemplateError{File: tplName, Message: err.Error()}
+}
+
+// TemplateDraft 模板预览草稿, Content为空时使用已保存的模板
+type TemplateDraft struct {
+	Theme    string `json:"theme"`
+	Template string `json:"template"`
+	Content  string `json:"content"`
+	Tid      int64  `json:"tid"`
+	Aid      int64  `json:"aid"`
+}
+
+// draftPayload 预览令牌只携带草稿id, 不能用于后台认证
+type draftPayload struct {
+	jwt.Payload
+	DraftId string `json:"draft_id"`
+}
+
+// SignDraft 缓存预览草稿并返回预览令牌
+func SignDraft(draft *TemplateDraft) (string, error) {
+	if _, _, err := TemplatePath(draft.Theme, draft.Template); err != nil {
+		return "", err
+	}
+	if !strings.HasSuffix(draft.Template, ".jet") {
+		return "", errors.New("只能预览jet模板")
+	}
+	draftId := helper.GetRandomString(16)
+	if err := helper.Cache().SetWithMarshal(fmt.Sprintf(controllers.CacheTemplateDraft, draftId), draft, int(draftTTL.Seconds())); err != nil {
+		return "", err
+	}
+	now := time.Now()
+	pl := draftPayload{
+		Payload: jwt.Payload{
+			Issuer:         "pinecms",
+			ExpirationTime: jwt.NumericDate(now.Add(draftTTL)),
+			IssuedAt:       jwt.NumericDate(now),
+		},
+		DraftId: draftId,
+	}
+	token, err := config.JwtSign(config.JwtAudienceDraft, &pl.Payload, &pl)
+	return string(token), err
+}
+
+// ParseDraft 校验预览令牌并读取草稿
+func ParseDraft(token string) (*TemplateDraft, error) {
+	var pl draftPayload
+	if err := config.JwtVerify(config.JwtAudienceDraft, []byte(token), &pl.Payload, &pl); err != nil {
+		return nil, errors.New("预览令牌无效")
+	}
+	draft := &TemplateDraft{}
+	if err := helper.Cache().GetWithUnmarshal(fmt.Sprintf(controllers.CacheTemplateDraft, pl.DraftId), draft); err != nil {
+		return nil, errors.New("预览已过期")
+	}
+	return draft, nil
+}
+
+// ReadTemplate 读取模板内容
+func ReadTemplate(themeName, name string) (string, error) {
+	_, file, err := TemplatePath(themeName, name)
+	if err != nil {
+		return "", err
+	}
+	byts, err := os.ReadFile(file)
+	return string(byts), err
+}
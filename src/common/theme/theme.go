@@ -0,0 +1,245 @@
+package theme
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/bytedance/sonic"
+	"github.com/xiusin/pinecms/src/application/controllers"
+	"github.com/xiusin/pinecms/src/application/models/tables"
+	"github.com/xiusin/pinecms/src/common/helper"
+	"github.com/xiusin/pinecms/src/config"
+)
+
+// ManifestFile 主题描述文件, 兼容旧主题的config.json
+const ManifestFile = "theme.json"
+
+const legacyManifestFile = "config.json"
+
+// SettingKey 当前主题的配置KEY
+const SettingKey = "SITE_THEME"
+
+var namePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// 已注册的模板标签, 用于检查主题依赖
+var registeredTags []string
+
+// Field 主题配置项
+type Field struct {
+	Key     string   `json:"key"`
+	Label   string   `json:"label"`
+	Type    string   `json:"type"` // input textarea select switch image color
+	Default string   `json:"default"`
+	Options []string `json:"options,omitempty"`
+	Remark  string   `json:"remark,omitempty"`
+}
+
+// Manifest 主题描述
+type Manifest struct {
+	Name        string         `json:"name"`
+	Title       string         `json:"title"`
+	Version     string         `json:"version"`
+	Author      string         `json:"author"`
+	Description string         `json:"description"`
+	Tags        []string       `json:"tags"`     // 依赖的模板标签
+	Settings    []Field        `json:"settings"` // 主题配置
+	Extra       map[string]any `json:"extra,omitempty"`
+	Dir         string         `json:"dir"`
+	IsDefault   bool           `json:"is_default"`
+}
+
+// RegisterTags 注册可用的模板标签
+func RegisterTags(tags ...string) {
+	registeredTags = append(registeredTags, tags...)
+}
+
+// Root 主题目录
+func Root() string {
+	return config.App().View.FeDirname
+}
+
+// Active 当前启用的主题, 未设置时使用配置文件中的主题
+func Active() string {
+	return config.GetSiteConfigByKey(SettingKey, config.App().View.Theme)
+}
+
+// Exists 主题目录是否存在
+func Exists(name string) bool {
+	if !namePattern.MatchString(name) {
+		return false
+	}
+	stat, err := os.Stat(filepath.Join(Root(), name))
+	return err == nil && stat.IsDir()
+}
+
+// Load 读取主题描述, 没有描述文件时以目录名作为主题名
+func Load(dir string) (*Manifest, error) {
+	if !Exists(dir) {
+		return nil, fmt.Errorf("主题%s不存在", dir)
+	}
+	manifest := &Manifest{Name: dir}
+	for _, file := range []string{ManifestFile, legacyManifestFile} {
+		byts, err := os.ReadFile(filepath.Join(Root(), dir, file))
+		if err != nil {
+			continue
+		}
+		if err := sonic.Unmarshal(byts, manifest); err != nil {
+			return nil, fmt.Errorf("主题%s描述文件错误: %s", dir, err)
+		}
+		break
+	}
+	manifest.Dir = dir
+	manifest.IsDefault = dir == Active()
+	return manifest, nil
+}
+
+// List 全部主题
+func List() ([]*Manifest, error) {
+	files, err := os.ReadDir(Root())
+	if err != nil {
+		return nil, err
+	}
+	var list []*Manifest
+	for _, f := range files {
+		if !f.IsDir() || strings.HasPrefix(f.Name(), ".") {
+			continue
+		}
+		if manifest, err := Load(f.Name()); err == nil {
+			list = append(list, manifest)
+		}
+	}
+	return list, nil
+}
+
+// Validate 校验主题描述与依赖的模板标签
+func (m *Manifest) Validate() error {
+	if !namePattern.MatchString(m.Name) {
+		return errors.New("主题名称只能包含字母数字下划线和中划线")
+	}
+	if len(m.Version) == 0 {
+		return errors.New("主题缺少版本号")
+	}
+	for _, tag := range m.Tags {
+		if !slices.Contains(registeredTags, tag) {
+			return fmt.Errorf("主题依赖的标签%s不存在", tag)
+		}
+	}
+	for _, field := range m.Settings {
+		if len(field.Key) == 0 {
+			return errors.New("主题配置项缺少key")
+		}
+	}
+	return nil
+}
+
+// CompareVersion 比较版本号, 返回 -1 0 1
+func CompareVersion(a, b string) int {
+	as, bs := strings.Split(strings.TrimPrefix(a, "v"), "."), strings.Split(strings.TrimPrefix(b, "v"), ".")
+	for i := 0; i < max(len(as), len(bs)); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// InUse 主题是否被全局配置或站点使用
+func InUse(name string) bool {
+	if Active() == name {
+		return true
+	}
+	exist, _ := helper.GetORM().Where("theme = ?", name).Exist(&tables.Site{})
+	return exist
+}
+
+// SetActive 启用主题, 保存到配置表
+func SetActive(name string) error {
+	if !Exists(name) {
+		return fmt.Errorf("主题%s不存在", name)
+	}
+	if err := saveSetting(SettingKey, "站点主题", name); err != nil {
+		return err
+	}
+	return helper.Cache().Delete(controllers.CacheSetting)
+}
+
+func settingKey(name string) string {
+	return "THEME_" + strings.ToUpper(name)
+}
+
+// Settings 主题配置值, 未保存的配置项使用默认值
+func Settings(name string) map[string]string {
+	values := map[string]string{}
+	manifest, err := Load(name)
+	if err != nil {
+		return values
+	}
+	for _, field := range manifest.Settings {
+		values[field.Key] = field.Default
+	}
+	var saved map[string]string
+	if val := config.GetSiteConfigByKey(settingKey(name)); len(val) > 0 {
+		_ = sonic.UnmarshalString(val, &saved)
+	}
+	for _, field := range manifest.Settings {
+		if val, ok := saved[field.Key]; ok {
+			values[field.Key] = val
+		}
+	}
+	return values
+}
+
+// SaveSettings 保存主题配置, 只保存描述中声明的配置项
+func SaveSettings(name string, values map[string]string) error {
+	manifest, err := Load(name)
+	if err != nil {
+		return err
+	}
+	saved := map[string]string{}
+	for _, field := range manifest.Settings {
+		val, ok := values[field.Key]
+		if !ok {
+			continue
+		}
+		if field.Type == "select" && len(field.Options) > 0 && !slices.Contains(field.Options, val) {
+			return fmt.Errorf("配置项%s的值不在可选范围内", field.Label)
+		}
+		saved[field.Key] = val
+	}
+	byts, _ := sonic.Marshal(saved)
+	if err := saveSetting(settingKey(name), manifest.Title+"主题配置", string(byts)); err != nil {
+		return err
+	}
+	return helper.Cache().Delete(controllers.CacheSetting)
+}
+
+func saveSetting(key, formName, value string) error {
+	orm := helper.GetORM()
+	setting := &tables.Setting{}
+	exist, err := orm.Where(orm.Quote("key")+" = ?", key).Get(setting)
+	if err != nil {
+		return err
+	}
+	if exist {
+		_, err = orm.ID(setting.Id).Cols("value").Update(&tables.Setting{Value: value})
+		return err
+	}
+	_, err = orm.InsertOne(&tables.Setting{Key: key, FormName: formName, Value: value, Group: "主题配置", Editor: "el-input"})
+	return err
+}
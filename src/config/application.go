@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"testing"
 	"time"
 
 	"gopkg.in/yaml.v2"
@@ -33,6 +34,27 @@ type Config struct {
 	HashKey  string `yaml:"hashkey"`
 	BlockKey string `yaml:"blockkey"`
 
+	PluginMarket struct {
+		Repository string   `yaml:"repository"`  // 插件仓库, HTTP地址或本地目录
+		PublicKeys []string `yaml:"public_keys"` // 插件包签名公钥, base64
+	} `yaml:"plugin_market"`
+
+	RecycleBin struct {
+		RetentionDays int `yaml:"retention_days"` // 回收站保留天数, 超过后彻底删除, 0为不清理
+	} `yaml:"recycle_bin"`
+
+	River struct {
+		Enable bool                  `yaml:"enable"` // 启用river任务队列, 仅支持PostgreSQL
+		Queues map[string]RiverQueue `yaml:"queues"`
+	} `yaml:"river"`
+
+	Task struct {
+		Shell struct {
+			Enable   bool     `yaml:"enable"`   // 允许 shell 类型的任务, 默认关闭
+			Commands []string `yaml:"commands"` // 允许执行的命令名单
+		} `yaml:"shell"`
+	} `yaml:"task"`
+
 	Upload struct {
 		MaxBodySize int64  `yaml:"max_bodysize"`
 		Engine      string `yaml:"engine"`
@@ -51,6 +73,11 @@ type Config struct {
 	} `yaml:"search"`
 }
 
+// RiverQueue river队列配置
+type RiverQueue struct {
+	MaxWorkers int `yaml:"max_workers"` // 最大并发数
+}
+
 type SessConf struct {
 	Name    string        `yaml:"name"`
 	Expires time.Duration `yaml:"expires"`
@@ -152,7 +179,11 @@ func SiteConfig() (Site, error) {
 }
 
 func init() {
-	parseConfig(appYaml, config)
+	if _, err := os.Stat(appYaml); err != nil && testing.Testing() { // 单元测试在包目录下运行, 使用默认配置
+		config.RuntimePath = filepath.Join(os.TempDir(), "pinecms_test")
+	} else {
+		parseConfig(appYaml, config)
+	}
 	config.init()
 	_ = os.MkdirAll(config.LogPath, os.ModePerm)
 }
@@ -1,7 +1,6 @@
 package config
 
 import (
-	"fmt"
 	"os"
 	"strings"
 	"sync"
@@ -11,7 +10,9 @@ import (
 	"xorm.io/xorm"
 	"xorm.io/xorm/log"
 
+	"github.com/xiusin/pine"
 	"github.com/xiusin/pinecms/src/application/controllers"
+	"github.com/xiusin/pinecms/src/common/dialect"
 	"github.com/xiusin/pinecms/src/common/helper"
 )
 
@@ -19,7 +20,9 @@ type Db struct {
 	DbDriver string `yaml:"driver"`
 	Dsn      string `yaml:"dsn"`
 	DbPrefix string `yaml:"prefix"`
-	Conf     dbInfo `yaml:"-" json:"-"`
+	// ReadonlyDsn 只读账号或从库连接, 用于模板数据源等只读查询, 为空时使用主连接
+	ReadonlyDsn string `yaml:"readonly_dsn,omitempty"`
+	Conf        dbInfo `yaml:"-" json:"-"`
 }
 
 type dbInfo struct {
@@ -61,10 +64,24 @@ func (t *DbConf) Initialized() bool {
 	return err == nil
 }
 
+// Valid 检查连接信息, SQLite 只需要数据库文件路径
+func (t *Db) Valid() bool {
+	if t.DbDriver == "sqlite" {
+		return len(strings.TrimSpace(t.Conf.Name)) > 0
+	}
+	return t.Conf.Check()
+}
+
 func (t *DbConf) buildDsn() string {
-	return fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8",
-		t.Db.Conf.Username, t.Db.Conf.Password,
-		t.Db.Conf.ServeIp, t.Db.Conf.Port, t.Db.Conf.Name)
+	d, err := dialect.Get(t.Db.DbDriver)
+	helper.PanicErr(err)
+	return d.DSN(dialect.DSNConfig{
+		Host:     t.Db.Conf.ServeIp,
+		Port:     t.Db.Conf.Port,
+		User:     t.Db.Conf.Username,
+		Password: t.Db.Conf.Password,
+		Name:     t.Db.Conf.Name,
+	})
 }
 
 func (t *DbConf) BuildYaml() error {
@@ -94,6 +111,9 @@ func InitDB(conf ...*DbConf) *xorm.Engine {
 		} else {
 			parseConfig(dbYml, configure)
 		}
+		if len(configure.Db.Dsn) == 0 {
+			configure.Db.Dsn = configure.buildDsn()
+		}
 
 		_orm, err := xorm.NewEngine(configure.Db.DbDriver, configure.Db.Dsn)
 		helper.PanicErr(err)
@@ -115,3 +135,33 @@ func InitDB(conf ...*DbConf) *xorm.Engine {
 func DB() *DbConf {
 	return configure
 }
+
+var readonly struct {
+	sync.Once
+	engine *xorm.Engine
+}
+
+// ReadonlyDB 只读数据库连接, 未配置或连接失败时返回主连接
+func ReadonlyDB() *xorm.Engine {
+	readonly.Do(func() {
+		main := InitDB()
+		readonly.engine = main
+		if len(configure.Db.ReadonlyDsn) == 0 {
+			return
+		}
+		_orm, err := xorm.NewEngine(configure.Db.DbDriver, configure.Db.ReadonlyDsn)
+		if err == nil {
+			err = _orm.Ping()
+		}
+		if err != nil {
+			pine.Logger().Warn("连接只读数据库失败, 使用主连接", err)
+			return
+		}
+		_orm.SetTableMapper(main.GetTableMapper())
+		_orm.TZLocation = main.TZLocation
+		_orm.SetMaxOpenConns(int(configure.Orm.MaxOpenConns))
+		_orm.SetMaxIdleConns(int(configure.Orm.MaxIdleConns))
+		readonly.engine = _orm
+	})
+	return readonly.engine
+}
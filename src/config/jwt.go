@@ -0,0 +1,39 @@
+package config
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"time"
+
+	"github.com/gbrlsnchs/jwt/v3"
+)
+
+// JWT 受众, 各受众使用由 jwtkey 派生的独立密钥, 令牌不能跨受众使用
+const (
+	JwtAudienceAdmin   = "admin"          // 后台登录
+	JwtAudienceMember  = "member"         // 前台会员登录
+	JwtAudienceVisitor = "im_visitor"     // 在线客服访客
+	JwtAudiencePreview = "theme_preview"  // 主题预览
+	JwtAudienceDraft   = "template_draft" // 模板草稿预览
+	JwtAudienceImFile  = "im_file"        // 在线客服上传的文件
+)
+
+// jwtAlg 受众的签名算法
+func jwtAlg(audience string) *jwt.HMACSHA {
+	mac := hmac.New(sha256.New, []byte(App().JwtKey))
+	mac.Write([]byte(audience))
+	return jwt.NewHS256(mac.Sum(nil))
+}
+
+// JwtSign 写入受众并签发令牌, pl 为 payload 内嵌的 jwt.Payload
+func JwtSign(audience string, pl *jwt.Payload, payload any) ([]byte, error) {
+	pl.Audience = jwt.Audience{audience}
+	return jwt.Sign(payload, jwtAlg(audience))
+}
+
+// JwtVerify 校验令牌的签名、受众与过期时间
+func JwtVerify(audience string, token []byte, pl *jwt.Payload, payload any) error {
+	validator := jwt.ValidatePayload(pl, jwt.AudienceValidator(jwt.Audience{audience}), jwt.ExpirationTimeValidator(time.Now()))
+	_, err := jwt.Verify(token, jwtAlg(audience), payload, validator)
+	return err
+}
@@ -0,0 +1,128 @@
+package config
+
+import (
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/xiusin/pine"
+	"github.com/xiusin/pinecms/src/application/controllers"
+	"github.com/xiusin/pinecms/src/application/models/tables"
+	"github.com/xiusin/pinecms/src/common/helper"
+)
+
+// Sites 全部启用的站点
+func Sites() ([]tables.Site, error) {
+	var sites []tables.Site
+	err := helper.Cache().Remember(controllers.CacheSites, &sites, func() (any, error) {
+		if err := helper.GetORM().Where("status = ?", true).Asc("listorder").Asc("id").Find(&sites); err != nil {
+			return nil, err
+		}
+		return &sites, nil
+	})
+	return sites, err
+}
+
+// ResolveSite 根据请求域名匹配站点, 未匹配时返回默认站点, 未配置站点时返回nil
+func ResolveSite(host string) *tables.Site {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(host)
+	sites, _ := Sites()
+	var def *tables.Site
+	for i := range sites {
+		site := &sites[i]
+		if strings.EqualFold(site.Host, host) {
+			return site
+		}
+		for _, alias := range site.Aliases {
+			if strings.EqualFold(alias, host) {
+				return site
+			}
+		}
+		if site.IsDefault && def == nil {
+			def = site
+		}
+	}
+	return def
+}
+
+// GetSiteById 根据ID获取启用的站点
+func GetSiteById(id int64) *tables.Site {
+	sites, _ := Sites()
+	for i := range sites {
+		if sites[i].Id == id {
+			return &sites[i]
+		}
+	}
+	return nil
+}
+
+// ScopeSite 按数据归属ID查找启用的站点, 0 对应默认站点, 见 SiteScope
+func ScopeSite(scope int64) *tables.Site {
+	sites, _ := Sites()
+	for i := range sites {
+		if SiteScope(&sites[i]) == scope {
+			return &sites[i]
+		}
+	}
+	return nil
+}
+
+// SiteScope 站点数据归属ID, 默认站点沿用0, 兼容启用多站点前的栏目数据
+func SiteScope(site *tables.Site) int64 {
+	if site == nil || site.IsDefault {
+		return 0
+	}
+	return site.Id
+}
+
+// SiteConfigFor 合并全局配置与站点配置, 站点为nil时返回全局配置副本
+func SiteConfigFor(site *tables.Site) (Site, error) {
+	global, err := SiteConfig()
+	if err != nil {
+		return nil, err
+	}
+	setting := make(Site, len(global)+8)
+	for k, v := range global {
+		setting[k] = v
+	}
+	if site == nil {
+		setting["SITE_ID"] = "0"
+		return setting, nil
+	}
+	for k, v := range site.Settings {
+		setting[strings.ToUpper(k)] = v
+	}
+	setting["SITE_ID"] = strconv.FormatInt(SiteScope(site), 10)
+	setting["SITE_HOST"] = site.Host
+	if len(site.Theme) > 0 {
+		setting["SITE_THEME"] = site.Theme
+	}
+	if len(site.StaticDir) > 0 {
+		setting["SITE_STATIC_PAGE_DIR"] = site.StaticDir
+	} else if !site.IsDefault { // 未设置目录时按域名隔离, 避免与默认站点的静态页面冲突
+		setting["SITE_STATIC_PAGE_DIR"] = filepath.Join(setting.Get("SITE_STATIC_PAGE_DIR", "resources/html"), site.Host)
+	}
+	if len(site.UploadEngine) > 0 {
+		setting["UPLOAD_ENGINE"] = site.UploadEngine
+	}
+	return setting, nil
+}
+
+// CtxSiteConfig 当前请求的站点配置, 由 SetGlobalConfigData 中间件写入
+func CtxSiteConfig(ctx *pine.Context) Site {
+	if setting, ok := ctx.Value(controllers.CacheSetting).(Site); ok {
+		return setting
+	}
+	setting, _ := SiteConfigFor(nil)
+	return setting
+}
+
+// CtxSiteId 当前请求的站点数据归属ID, 见 SiteScope
+func CtxSiteId(ctx *pine.Context) int64 {
+	site, _ := ctx.Value(controllers.CacheSite).(*tables.Site)
+	return SiteScope(site)
+}
@@ -1,6 +1,7 @@
 package router
 
 import (
+	"reflect"
 	"strings"
 
 	"github.com/xiusin/pine"
@@ -10,10 +11,16 @@ import (
 	"github.com/xiusin/pinecms/src/application/controllers/middleware"
 	"github.com/xiusin/pinecms/src/application/controllers/middleware/apidoc"
 	"github.com/xiusin/pinecms/src/common/helper"
+	"github.com/xiusin/pinecms/src/common/importer"
+	"github.com/xiusin/pinecms/src/common/migration"
+	"github.com/xiusin/pinecms/src/common/recycle"
+	"github.com/xiusin/pinecms/src/common/route"
 	"github.com/xiusin/pinecms/src/config"
 )
 
+// RouteGroup 注解控制器, 由 pinecms src 生成到 routes.gen.go
 type RouteGroup struct {
+	Mount   string // 挂载分组, 见 route.MountAdmin
 	Prefix  string
 	Handler pine.IController
 }
@@ -40,53 +47,70 @@ func InitApiRouter(app *pine.Application) {
 		middleware.StatesViz(app),
 	)
 
-	casbin := middleware.Casbin(config.InitDB())
+	orm := config.InitDB()
+	backend.InitSiteInstall()
+	backend.InitDataSourceInstall()
+	backend.InitTemplateHistoryInstall()
+	importer.InitInstall()
+	migration.InitInstall()
+	recycle.InitInstall()
+	casbin := middleware.Casbin(orm)
 
 	admin := app.Group("/v2", middleware.VerifyJwtToken(), casbin)
 
 	InitModuleRouter(admin, app)
 
-	adminRouteGroups := []RouteGroup{
-		{Prefix: "/user", Handler: new(backend.UserController)},
-		{Prefix: "/role", Handler: new(backend.AdminRoleController)},
-		{Prefix: "/menu", Handler: new(backend.MenuController)},
-		{Prefix: "/link", Handler: new(backend.LinkController)},
-		{Prefix: "/log", Handler: new(backend.LogController)},
-		{Prefix: "/errlog", Handler: new(backend.ErrorLogController)},
-		{Prefix: "/assets", Handler: new(backend.AssetsManagerController)},
-		{Prefix: "/attachment", Handler: new(backend.AttachmentController)},
-		{Prefix: "/attachment/type", Handler: new(backend.AttachmentTypeController)},
-		{Prefix: "/setting", Handler: new(backend.SettingController)},
-		{Prefix: "/dict/category", Handler: new(backend.DictCategoryController)},
-		{Prefix: "/dict", Handler: new(backend.DictController)},
-		{Prefix: "/model", Handler: new(backend.DocumentController)},
-		{Prefix: "/category", Handler: new(backend.CategoryController)},
-		{Prefix: "/district", Handler: new(backend.DistrictController)},
-		{Prefix: "/ad", Handler: new(backend.AdController)},
-		{Prefix: "/ad/space", Handler: new(backend.AdSpaceController)},
-		{Prefix: "/department", Handler: new(backend.DepartmentController)},
-		{Prefix: "/position", Handler: new(backend.PositionController)},
-		{Prefix: "/level", Handler: new(backend.LevelController)},
-		{Prefix: "/stat", Handler: new(backend.StatController)},
-		{Prefix: "/plugin", Handler: new(backend.PluginController)},
-		{Prefix: "/tags", Handler: new(backend.TagsController)},
-		{Prefix: "/member", Handler: new(backend.MemberController)},
-		{Prefix: "/member/group", Handler: new(backend.MemberGroupController)},
-		{Prefix: "/table", Handler: new(backend.TableController)},
-		{Prefix: "/content", Handler: new(backend.ContentController)},
-		{Prefix: "/public", Handler: new(backend.PublicController)},
-		{Prefix: "/api", Handler: new(backend.PublicController)},
-		{Handler: new(backend.ImSessionController)},
-		{Handler: new(backend.LoginController)},
-		{Handler: new(backend.IndexController)},
-		{Handler: new(backend.DatabaseController)},
-		{Handler: new(backend.DatabaseBackupController)},
+	route.RegisterMiddleware("cors", middleware.Cors())
+	route.RegisterMiddleware("request_log", requestLog.RequestRecorder())
+	route.RegisterMiddleware("limiter", middleware.Limiter(10))
+	registered := map[reflect.Type]struct{}{}
+	for _, group := range routeGroups {
+		t := reflect.TypeOf(group.Handler)
+		if _, exist := registered[t]; exist {
+			continue // 注解控制器一次注册全部前缀的路由
+		}
+		registered[t] = struct{}{}
+		if group.Mount == route.MountApp {
+			app.Handle(group.Handler)
+		} else {
+			admin.Handle(group.Handler)
+		}
 	}
-
-	for _, group := range adminRouteGroups {
-		admin.Handle(group.Handler, group.Prefix)
+	for _, conflict := range route.Register(annotatedRoutes()...) {
+		pine.Logger().Warn("路由冲突", conflict)
 	}
+	RegisterApiResources(AdminRouteGroups())
 	helper.Inject(controllers.ServiceBackendRouter, admin)
 
 	InitSubModuleRouter(app, admin)
 }
+
+// AdminRouteGroups 后台注解控制器, 挂载在 /v2 下
+func AdminRouteGroups() []RouteGroup {
+	var groups []RouteGroup
+	for _, group := range routeGroups {
+		if group.Mount == route.MountAdmin {
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
+
+// RegisterApiResources 登记后台增删改查接口与注解接口, 用于生成 OpenAPI 文档
+func RegisterApiResources(groups []RouteGroup) {
+	for _, r := range annotatedRoutes() {
+		path := r.Path
+		if r.Mount == route.MountAdmin {
+			path = "/v2" + path
+		}
+		apidoc.RegisterEndpoint(apidoc.Endpoint{Method: r.Method, Path: path, Title: r.Title, Group: r.Group, Request: r.Request, Response: r.Response})
+	}
+	for _, group := range groups {
+		if len(group.Prefix) == 0 {
+			continue
+		}
+		if res, ok := backend.ApiResource("/v2"+group.Prefix, group.Handler); ok {
+			apidoc.RegisterResource(res)
+		}
+	}
+}
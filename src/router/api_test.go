@@ -0,0 +1,36 @@
+package router
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/xiusin/pinecms/src/application/controllers"
+	"github.com/xiusin/pinecms/src/application/controllers/backend"
+	"github.com/xiusin/pinecms/src/common/helper"
+	_ "modernc.org/sqlite"
+	"xorm.io/xorm"
+)
+
+func TestApiResourceNoSideEffects(t *testing.T) {
+	orm, err := xorm.NewEngine("sqlite", "file:"+filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer orm.Close()
+	helper.Inject(controllers.ServiceXorm, orm, false)
+	helper.Inject(controllers.ServiceTablePrefix, "pinecms_", false)
+
+	resources := 0
+	for _, group := range AdminRouteGroups() {
+		if _, ok := backend.ApiResource("/v2"+group.Prefix, group.Handler); ok {
+			resources++
+		}
+	}
+	if resources == 0 {
+		t.Fatal("应读取到增删改查配置")
+	}
+	// 读取配置只执行 Construct, 不应创建数据表或写入数据
+	if tables, err := orm.DBMetas(); err != nil || len(tables) > 0 {
+		t.Fatalf("读取配置不应有副作用: %d %v", len(tables), err)
+	}
+}
@@ -3,8 +3,10 @@ package router
 import (
 	"github.com/xiusin/pine"
 	"github.com/xiusin/pinecms/src/application/controllers/backend/filemanager"
+	"github.com/xiusin/pinecms/src/application/controllers/backend/im"
 	"github.com/xiusin/pinecms/src/application/controllers/backend/webssh"
 	"github.com/xiusin/pinecms/src/application/controllers/backend/wechat"
+	"github.com/xiusin/pinecms/src/application/controllers/headless"
 )
 
 // InitModuleRouter 函数内不可出现"}", 如需实现自定义解析, 务必定义新函数
@@ -17,4 +19,6 @@ func InitModuleRouter(backendRouter *pine.Router, app *pine.Application) {
 
 func InitSubModuleRouter(app *pine.Application, admin *pine.Router) {
 	wechat.InitRouter(app, admin)
+	im.InitRouter(app, admin)
+	headless.InitRouter(app, admin)
 }
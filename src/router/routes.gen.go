@@ -0,0 +1,62 @@
+// Code generated by Rest annotation processor. DO NOT EDIT.
+// versions:
+//
+//	go: go1.27.1
+//	go-annotation: 0.1.0
+//	Rest: 0.1.0
+package router
+
+import (
+	backend "github.com/xiusin/pinecms/src/application/controllers/backend"
+	"github.com/xiusin/pinecms/src/common/route"
+)
+
+// routeGroups 注解控制器, 前缀用于登记增删改查接口文档
+var routeGroups = []RouteGroup{
+	{Mount: "admin", Prefix: "/ad", Handler: new(backend.AdController)},
+	{Mount: "admin", Prefix: "/ad/space", Handler: new(backend.AdSpaceController)},
+	{Mount: "admin", Prefix: "/role", Handler: new(backend.AdminRoleController)},
+	{Mount: "admin", Prefix: "/assets", Handler: new(backend.AssetsManagerController)},
+	{Mount: "admin", Prefix: "/attachment", Handler: new(backend.AttachmentController)},
+	{Mount: "admin", Prefix: "/attachment/type", Handler: new(backend.AttachmentTypeController)},
+	{Mount: "admin", Prefix: "/category", Handler: new(backend.CategoryController)},
+	{Mount: "admin", Prefix: "/content", Handler: new(backend.ContentController)},
+	{Mount: "admin", Prefix: "/datasource", Handler: new(backend.DataSourceController)},
+	{Mount: "admin", Prefix: "", Handler: new(backend.DatabaseBackupController)},
+	{Mount: "admin", Prefix: "", Handler: new(backend.DatabaseController)},
+	{Mount: "admin", Prefix: "/department", Handler: new(backend.DepartmentController)},
+	{Mount: "admin", Prefix: "/dict/category", Handler: new(backend.DictCategoryController)},
+	{Mount: "admin", Prefix: "/dict", Handler: new(backend.DictController)},
+	{Mount: "admin", Prefix: "/district", Handler: new(backend.DistrictController)},
+	{Mount: "admin", Prefix: "/model", Handler: new(backend.DocumentController)},
+	{Mount: "admin", Prefix: "/errlog", Handler: new(backend.ErrorLogController)},
+	{Mount: "admin", Prefix: "/import", Handler: new(backend.ImportController)},
+	{Mount: "admin", Prefix: "", Handler: new(backend.IndexController)},
+	{Mount: "admin", Prefix: "/level", Handler: new(backend.LevelController)},
+	{Mount: "admin", Prefix: "/link", Handler: new(backend.LinkController)},
+	{Mount: "admin", Prefix: "/log", Handler: new(backend.LogController)},
+	{Mount: "admin", Prefix: "", Handler: new(backend.LoginController)},
+	{Mount: "admin", Prefix: "/member", Handler: new(backend.MemberController)},
+	{Mount: "admin", Prefix: "/member/group", Handler: new(backend.MemberGroupController)},
+	{Mount: "admin", Prefix: "/menu", Handler: new(backend.MenuController)},
+	{Mount: "admin", Prefix: "/plugin", Handler: new(backend.PluginController)},
+	{Mount: "admin", Prefix: "/position", Handler: new(backend.PositionController)},
+	{Mount: "admin", Prefix: "/public", Handler: new(backend.PublicController)},
+	{Mount: "admin", Prefix: "/api", Handler: new(backend.PublicController)},
+	{Mount: "admin", Prefix: "/recycle", Handler: new(backend.RecycleController)},
+	{Mount: "admin", Prefix: "/river", Handler: new(backend.RiverController)},
+	{Mount: "admin", Prefix: "/setting", Handler: new(backend.SettingController)},
+	{Mount: "admin", Prefix: "/site", Handler: new(backend.SiteController)},
+	{Mount: "admin", Prefix: "/stat", Handler: new(backend.StatController)},
+	{Mount: "admin", Prefix: "/table", Handler: new(backend.TableController)},
+	{Mount: "admin", Prefix: "/tags", Handler: new(backend.TagsController)},
+	{Mount: "admin", Prefix: "", Handler: new(backend.ThemeController)},
+	{Mount: "admin", Prefix: "/user", Handler: new(backend.UserController)},
+}
+
+// annotatedRoutes 注解路由表
+func annotatedRoutes() []route.Route {
+	var routes []route.Route
+	routes = append(routes, backend.AnnotatedRoutes...)
+	return routes
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/xiusin/pinecms/src/common/helper"
 	commonLogger "github.com/xiusin/pinecms/src/common/logger"
 	"github.com/xiusin/pinecms/src/common/search"
+	"github.com/xiusin/pinecms/src/common/theme"
 	"github.com/xiusin/pinecms/src/config"
 )
 
@@ -46,9 +47,6 @@ func InitCache() {
 	cfg.MaxEntriesInWindow = 1000
 	cfg.MaxEntrySize = 100
 	cacheHandler = pbigcache.New(cfg)
-	if theme, _ := cacheHandler.Get(controllers.CacheTheme); len(theme) > 0 {
-		conf.View.Theme = string(theme)
-	}
 	helper.Inject(controllers.ServiceICache, cacheHandler)
 	sess := sessions.New(cacheProvider.NewStore(cacheHandler), &sessions.Config{CookieName: conf.Session.Name, Expires: conf.Session.Expires})
 	di.Instance(sess)
@@ -99,6 +97,7 @@ func initJetEngine() *pjet.PineJet {
 		"pagelist":       taglibs.PageList,
 		"list":           taglibs.List,
 		"query":          taglibs.Query,
+		"datasource":     taglibs.DataSource,
 		"likearticle":    taglibs.LikeArticle,
 		"hotwords":       taglibs.HotWords,
 		"tags":           taglibs.Tags,
@@ -112,6 +111,7 @@ func initJetEngine() *pjet.PineJet {
 
 	for name, fn := range tags {
 		jetEngine.AddGlobalFunc(name, fn)
+		theme.RegisterTags(name)
 	}
 
 	return jetEngine
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/xiusin/pine"
+	"github.com/xiusin/pinecms/src/common/dialect"
+	"github.com/xiusin/pinecms/src/common/helper"
+	"github.com/xiusin/pinecms/src/common/installer"
+)
+
+// Install 未初始化时启动安装向导, 安装完成后关闭并返回true, 被中断或已锁定时返回false
+func Install() bool {
+	if installer.Locked() {
+		pine.Logger().Error("安装程序已锁定, 缺少数据库配置. 如需重新安装请删除 " + installer.LockFile)
+		return false
+	}
+	var installed atomic.Bool // 安装成功或正在安装, 防止重复提交
+	setup := pine.New()
+	setup.GET("/", func(ctx *pine.Context) {
+		ctx.Redirect("/install")
+	})
+	setup.GET("/install", func(ctx *pine.Context) {
+		_ = ctx.WriteHTMLBytes(installer.Page)
+	})
+	setup.GET("/install/check", func(ctx *pine.Context) {
+		checks := installer.Checks()
+		ports := map[string]string{}
+		for _, name := range dialect.Names() {
+			d, _ := dialect.Get(name)
+			ports[name] = d.DefaultPort()
+		}
+		helper.Ajax(pine.H{
+			"checks":  checks,
+			"passed":  installer.Passed(checks),
+			"drivers": dialect.Names(),
+			"ports":   ports,
+			"themes":  installer.Themes(),
+			"theme":   conf.View.Theme,
+		}, 0, ctx)
+	})
+	setup.POST("/install/database", func(ctx *pine.Context) {
+		opts := &installer.Options{}
+		if err := ctx.BindJSON(opts); err != nil {
+			helper.Ajax("参数错误: "+err.Error(), 1, ctx)
+			return
+		}
+		if !opts.DbConf().Db.Valid() {
+			helper.Ajax("数据库配置信息必须填写", 1, ctx)
+			return
+		}
+		if err := installer.TestDB(opts); err != nil {
+			helper.Ajax(err.Error(), 1, ctx)
+			return
+		}
+		helper.Ajax("连接成功", 0, ctx)
+	})
+	setup.POST("/install/submit", func(ctx *pine.Context) {
+		if !installed.CompareAndSwap(false, true) {
+			helper.Ajax("系统已安装或正在安装", 1, ctx)
+			return
+		}
+		opts := &installer.Options{}
+		if err := ctx.BindJSON(opts); err != nil {
+			installed.Store(false)
+			helper.Ajax("参数错误: "+err.Error(), 1, ctx)
+			return
+		}
+		if !installer.Passed(installer.Checks()) {
+			installed.Store(false)
+			helper.Ajax("环境检测未通过", 1, ctx)
+			return
+		}
+		if err := installer.Install(opts); err != nil {
+			installed.Store(false)
+			helper.Ajax(err.Error(), 1, ctx)
+			return
+		}
+		helper.Ajax("安装成功", 0, ctx)
+		// 响应发送后关闭安装程序, 继续启动服务
+		go func() {
+			time.Sleep(time.Second)
+			setup.Close()
+		}()
+	})
+
+	addr := fmt.Sprintf("%s:%d", "127.0.0.1", conf.Port)
+	pine.Logger().Warn(fmt.Sprintf("未检测到数据库配置, 请访问 http://%s/install 完成安装", addr))
+	setup.Run(
+		pine.Addr(addr),
+		pine.WithServerName("xiusin/pinecms"),
+		pine.WithoutStartupLog(true),
+		pine.WithGracefulShutdown(),
+	)
+	return installed.Load()
+}
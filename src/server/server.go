@@ -2,10 +2,15 @@ package server
 
 import (
 	"fmt"
-	_ "github.com/go-sql-driver/mysql"
+	"time"
+
 	"github.com/gorilla/securecookie"
 	"github.com/xiusin/pine"
 	"github.com/xiusin/pinecms/src/application/plugins"
+	"github.com/xiusin/pinecms/src/common/dialect"
+	"github.com/xiusin/pinecms/src/common/helper"
+	"github.com/xiusin/pinecms/src/common/recycle"
+	"github.com/xiusin/pinecms/src/common/river/worker"
 	"github.com/xiusin/pinecms/src/router"
 )
 
@@ -17,6 +22,7 @@ func Server() {
 	router.InitStatics(app)
 
 	go plugins.Init()
+	go startRiver()
 
 	// 内部托管任意路由
 	router.InitRouter(app)
@@ -30,3 +36,20 @@ func Server() {
 		pine.WithMaxMultipartMemory(100 * 1024 * 1024),
 	)
 }
+
+// startRiver 按配置启动river任务队列, 未启动时回收站由定时器清理
+func startRiver() {
+	if !conf.River.Enable {
+		recycle.Run(time.Hour)
+		return
+	}
+	if dialect.Current().Name() != "postgres" {
+		pine.Logger().Warn("river任务队列仅支持PostgreSQL, 已跳过")
+		recycle.Run(time.Hour)
+		return
+	}
+	if err := worker.Start(helper.GetORM().DB().DB); err != nil {
+		pine.Logger().Error("启动river任务队列失败", err)
+		recycle.Run(time.Hour)
+	}
+}